@@ -26,11 +26,17 @@ import (
 	"devlab/internal/api"
 	"devlab/internal/config"
 	"devlab/internal/docker"
+	"devlab/internal/logging"
 	"devlab/internal/scenario"
 	"devlab/internal/storage"
+	"devlab/internal/tracing"
 	pb "devlab/proto"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
@@ -39,46 +45,101 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 	otelgin "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	otelgrpc "go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
-	"go.opentelemetry.io/otel/sdk/resource"
-	"go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
-func initTracer() func() {
-	exp, _ := stdouttrace.New(stdouttrace.WithPrettyPrint())
-	provider := trace.NewTracerProvider(
-		trace.WithBatcher(exp),
-		trace.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String("devlab-api"),
-		)),
-	)
-	otel.SetTracerProvider(provider)
-	return func() { _ = provider.Shutdown(context.Background()) }
-}
+// healthCheckInterval is how often the gRPC health status is refreshed
+const healthCheckInterval = 10 * time.Second
+
+// imageDigestTimeout bounds resolving image digests at boot, so an
+// unresponsive daemon delays startup rather than blocking it
+const imageDigestTimeout = 30 * time.Second
 
 func main() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	zerologlog.Logger = zerologlog.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-	shutdown := initTracer()
-	defer shutdown()
 
-	cfg := config.Load()
-	mongoClient, err := storage.GetMongoClient(context.Background(), cfg.MongoURI)
+	cfg, err := config.Load()
+	if err != nil {
+		zerologlog.Fatal().Err(err).Msg("failed to load configuration")
+	}
+	if err := logging.Configure(cfg.LogLevel); err != nil {
+		zerologlog.Fatal().Err(err).Msg("invalid LOG_LEVEL")
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		zerologlog.Fatal().Err(err).Msg("failed to initialise tracing")
+	}
+	defer func() { _ = shutdownTracing(context.Background()) }()
+
+	api.SetJWTLeeway(cfg.JWTLeeway)
+
+	tlsConfig, err := api.LoadTLSConfig(cfg.TLS)
+	if err != nil {
+		zerologlog.Fatal().Err(err).Msg("failed to load TLS certificate")
+	}
+
+	mongoClient, err := storage.GetMongoClient(context.Background(), cfg.MongoURI, storage.MongoConcerns{
+		WriteConcern: cfg.Mongo.WriteConcern,
+		ReadConcern:  cfg.Mongo.ReadConcern,
+		WriteTimeout: cfg.Mongo.WriteTimeout,
+	})
 	if err != nil {
 		zerologlog.Fatal().Err(err).Msg("failed to connect to MongoDB")
 	}
 	db := mongoClient.Database(cfg.DBName)
-	dockerClient := docker.RealClient{}
+	if err := storage.EnsureIndexes(context.Background(), db); err != nil {
+		// Searches fail without the text index, but everything else still works
+		zerologlog.Error().Err(err).Msg("failed to ensure MongoDB indexes")
+	}
+	dockerOpts, err := docker.OptionsFromConfig(cfg)
+	if err != nil {
+		zerologlog.Fatal().Err(err).Msg("failed to configure Docker client")
+	}
+	dockerClient, err := docker.NewRealClient(dockerOpts)
+	if err != nil {
+		zerologlog.Fatal().Err(err).Msg("failed to configure Docker client")
+	}
 	scenarioManager := scenario.NewManager(cfg, db, dockerClient)
+	for image, pinned := range scenarioManager.ImagePins {
+		if !docker.IsDigestRef(pinned) {
+			zerologlog.Fatal().Msgf("image %s is pinned to %s, which is not a digest reference", image, pinned)
+		}
+	}
+	if cfg.Container.ResolveImageDigests {
+		resolveCtx, cancel := context.WithTimeout(context.Background(), imageDigestTimeout)
+		resolved, err := dockerClient.ResolveImageDigests(resolveCtx, docker.BaseImages(cfg.DefaultScenarioImage))
+		cancel()
+		if err != nil {
+			// Scenarios still start, just from whatever the tags point at
+			zerologlog.Error().Err(err).Msg("failed to resolve image digests")
+		}
+		for image, pinned := range resolved {
+			if _, configured := scenarioManager.ImagePins[image]; !configured {
+				scenarioManager.ImagePins[image] = pinned
+			}
+		}
+	}
+	if cfg.ScenarioTemplatesFile != "" {
+		scenarioManager.Templates, err = scenario.LoadTemplates(cfg.ScenarioTemplatesFile)
+		if err != nil {
+			zerologlog.Fatal().Err(err).Msg("failed to load scenario templates")
+		}
+	}
+	if cfg.ScenarioCache.Size > 0 {
+		scenarioManager.Store = storage.NewCachedStore(storage.NewMongoStore(db), cfg.ScenarioCache.Size, cfg.ScenarioCache.TTL)
+	}
+	go scenarioManager.RunReconciler(context.Background(), cfg.ReconcileInterval)
 	handler := &api.Handler{Scenario: scenarioManager}
 
 	// REST API
 	r := gin.New()
-	r.Use(gin.Recovery())
+	r.Use(api.RequestIDMiddleware())
+	r.Use(api.RecoveryMiddleware())
 	r.Use(otelgin.Middleware("devlab-api"))
 
 	// Swagger docs endpoint
@@ -88,31 +149,133 @@ func main() {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Provisioning endpoints get the longer start timeout; the event and log
+	// streams are long-lived by design and are never cut off, and exec bounds
+	// itself by the command timeout
+	requestTimeouts := map[string]time.Duration{
+		"/scenarios/start":           cfg.RequestTimeout.Start,
+		"/scenarios/:id/clone":       cfg.RequestTimeout.Start,
+		"/scenarios/:id/exec":        0,
+		"/scenarios/:id/logs/stream": 0,
+		"/events":                    0,
+		// Bulk stops and admin transfers scale with the number of scenarios;
+		// each bulk stop bounds its stops itself
+		"/scenarios/stop":         0,
+		"/admin/scenarios/export": 0,
+		"/admin/scenarios/import": 0,
+	}
+	timeoutMiddleware := api.TimeoutMiddleware(cfg.RequestTimeout.Default, requestTimeouts)
+
 	// Protected scenario endpoints
 	scenarioGroup := r.Group("/")
-	scenarioGroup.Use(api.JWTAuthMiddleware())
+	scenarioGroup.Use(api.JWTAuthMiddleware(), timeoutMiddleware)
 	scenarioGroup.POST("/scenarios/start", handler.StartScenarioREST)
 	scenarioGroup.GET("/scenarios/types", handler.GetScenarioTypesREST)
+	scenarioGroup.GET("/scenarios/search", handler.SearchScenariosREST)
+	scenarioGroup.POST("/scenarios/stop", handler.StopScenariosREST)
+	scenarioGroup.GET("/scenarios/terminals", handler.ListTerminalsREST)
+	scenarioGroup.GET("/events", handler.EventsREST)
 	scenarioGroup.GET("/scenarios/:id/status", handler.GetScenarioStatusREST)
 	scenarioGroup.GET("/scenarios/:id/terminal", handler.GetTerminalURLREST)
+	scenarioGroup.GET("/scenarios/:id/terminal/credentials", handler.GetTerminalCredentialsREST)
+	scenarioGroup.POST("/scenarios/:id/terminal/credentials", handler.RotateTerminalCredentialsREST)
+	scenarioGroup.POST("/scenarios/:id/terminal/rebind", handler.RebindTerminalPortREST)
 	scenarioGroup.GET("/scenarios/:id/directory", handler.GetDirectoryStructureREST)
+	scenarioGroup.POST("/scenarios/:id/extend", handler.ExtendScenarioREST)
+	scenarioGroup.POST("/scenarios/:id/heartbeat", handler.HeartbeatREST)
+	scenarioGroup.POST("/scenarios/:id/clone", handler.CloneScenarioREST)
+	scenarioGroup.POST("/scenarios/:id/pause", handler.PauseScenarioREST)
+	scenarioGroup.POST("/scenarios/:id/resume", handler.ResumeScenarioREST)
+	scenarioGroup.POST("/scenarios/:id/exec", handler.ExecuteCommandREST)
+	scenarioGroup.GET("/scenarios/:id/logs/stream", handler.ScenarioLogsStreamREST)
+	scenarioGroup.GET("/scenarios/:id/results", handler.GetScenarioResultsREST)
+	scenarioGroup.GET("/scenarios/:id", handler.DescribeScenarioREST)
+	scenarioGroup.PATCH("/scenarios/:id", handler.UpdateScenarioREST)
 	scenarioGroup.DELETE("/scenarios/:id", handler.StopScenarioREST)
+
+	// Admin endpoints require a token with role "admin"
+	adminGroup := r.Group("/admin")
+	adminGroup.Use(api.JWTAuthMiddleware(), api.AdminOnlyMiddleware(), timeoutMiddleware)
+	adminGroup.GET("/scenarios/stats", handler.ScenarioStatsREST)
+	adminGroup.GET("/scenarios/export", handler.ExportScenariosREST)
+	adminGroup.POST("/scenarios/import", handler.ImportScenariosREST)
+	adminGroup.PATCH("/scenarios/:id", handler.AdminUpdateScenarioREST)
+	adminGroup.POST("/scenarios/:id/force-remove", handler.ForceRemoveScenarioREST)
+	srv := &http.Server{Addr: ":8000", Handler: r, TLSConfig: tlsConfig}
 	go func() {
-		zerologlog.Info().Msg("API server running on :8000")
-		r.Run(":8000")
+		var err error
+		if tlsConfig != nil {
+			zerologlog.Info().Msg("API server running on :8000 (TLS)")
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			zerologlog.Info().Msg("API server running on :8000")
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			zerologlog.Fatal().Err(err).Msg("API server failed")
+		}
 	}()
 
 	// gRPC server
-	grpcServer := grpc.NewServer(
+	grpcOpts := []grpc.ServerOption{
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
-	)
+		grpc.UnaryInterceptor(api.JWTUnaryInterceptor()),
+		grpc.StreamInterceptor(api.JWTStreamInterceptor()),
+	}
+	if tlsConfig != nil {
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(grpcOpts...)
 	pb.RegisterScenarioServiceServer(grpcServer, &api.GRPCServer{Scenario: scenarioManager})
+
+	// Standard gRPC health protocol, driven by MongoDB and Docker reachability
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	readiness := &api.Readiness{Checks: map[string]api.DependencyCheck{
+		"mongodb": func(ctx context.Context) error { return mongoClient.Ping(ctx, nil) },
+		"docker":  dockerClient.Ping,
+	}}
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	defer stopHealth()
+	go readiness.RunHealthUpdates(healthCtx, healthServer, healthCheckInterval)
+
 	lis, err := net.Listen("tcp", ":9090")
 	if err != nil {
 		zerologlog.Fatal().Err(err).Msg("failed to listen")
 	}
-	zerologlog.Info().Msg("gRPC server running on :9090")
-	if err := grpcServer.Serve(lis); err != nil {
-		zerologlog.Fatal().Err(err).Msg("failed to serve")
+	go func() {
+		zerologlog.Info().Msg("gRPC server running on :9090")
+		if err := grpcServer.Serve(lis); err != nil {
+			zerologlog.Fatal().Err(err).Msg("failed to serve")
+		}
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+	zerologlog.Info().Msg("received shutdown signal, stopping servers")
+
+	// Report NOT_SERVING first so load balancers drain before connections close
+	stopHealth()
+	healthServer.Shutdown()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// GracefulStop waits for every RPC, including open streams, to finish;
+	// past the shutdown deadline the remaining ones are cut off
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		zerologlog.Warn().Msg("gRPC server did not stop gracefully in time, forcing stop")
+		grpcServer.Stop()
+	}
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		zerologlog.Error().Err(err).Msg("API server shutdown failed")
 	}
 }
@@ -5,24 +5,50 @@ import (
 	"devlab/internal/cleanup"
 	"devlab/internal/config"
 	"devlab/internal/docker"
+	"devlab/internal/logging"
+	"devlab/internal/queue"
 	"devlab/internal/storage"
+	"devlab/internal/tracing"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+
+	"github.com/rs/zerolog"
+	zerologlog "github.com/rs/zerolog/log"
 )
 
 func main() {
 	log.Println("Async Cleanup Worker starting...")
 
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("[worker] failed to load configuration: %v", err)
+	}
+	zerologlog.Logger = zerologlog.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	if err := logging.Configure(cfg.LogLevel); err != nil {
+		log.Fatalf("[worker] %v", err)
+	}
+	// Trace queue messages under the worker's own service name; OTEL_SERVICE_NAME
+	// still takes precedence when set
+	cfg.Tracing.ServiceName = "devlab-worker"
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatalf("[worker] failed to initialise tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	log.Printf("[worker] configuration loaded: cleanup enabled=%v, interval=%v, max age=%v",
 		cfg.Cleanup.EnableCleanup, cfg.Cleanup.CleanupInterval, cfg.Cleanup.MaxScenarioAge)
 
 	// Connect to MongoDB
-	mongoClient, err := storage.GetMongoClient(context.Background(), cfg.MongoURI)
+	mongoClient, err := storage.GetMongoClient(context.Background(), cfg.MongoURI, storage.MongoConcerns{
+		WriteConcern: cfg.Mongo.WriteConcern,
+		ReadConcern:  cfg.Mongo.ReadConcern,
+		WriteTimeout: cfg.Mongo.WriteTimeout,
+	})
 	if err != nil {
 		log.Fatalf("[worker] failed to connect to MongoDB: %v", err)
 	}
@@ -32,11 +58,34 @@ func main() {
 	log.Printf("[worker] connected to database: %s", cfg.DBName)
 
 	// Initialize Docker client
-	dockerClient := &docker.RealClient{}
+	dockerOpts, err := docker.OptionsFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("[worker] failed to configure Docker client: %v", err)
+	}
+	dockerClient, err := docker.NewRealClient(dockerOpts)
+	if err != nil {
+		log.Fatalf("[worker] failed to configure Docker client: %v", err)
+	}
 
 	// Initialize cleanup manager
 	cleanupManager := cleanup.NewCleanupManager(cfg, db, dockerClient)
 
+	// Expiry warnings are best effort: cleanup still runs without RabbitMQ
+	if cfg.Cleanup.WarningWindow > 0 {
+		queueManager, err := queue.NewQueueManager(cfg.RabbitMQURI)
+		if err != nil {
+			log.Printf("[worker] expiry warnings disabled: %v", err)
+		} else if err := queueManager.DeclareQueue(queue.ScenarioEventsQueue); err != nil {
+			log.Printf("[worker] expiry warnings disabled: %v", err)
+			queueManager.Close()
+		} else {
+			defer queueManager.Close()
+			cleanupManager.SetPublisher(queueManager)
+		}
+	}
+
+	cleanupManager.SetWebhook(cleanup.NewWebhookNotifier(cfg.Cleanup))
+
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
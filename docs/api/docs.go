@@ -24,32 +24,75 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/scenarios/start": {
+        "/admin/scenarios/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Admin only. Stream every stored scenario, oldest first, as one extended JSON document per line, for backup or migration with the import endpoint. The export is recorded in the audit log.",
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Export all scenarios",
+                "responses": {
+                    "200": {
+                        "description": "One scenario document per line",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/scenarios/import": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Launch a new coding environment (container) for a user",
+                "description": "Admin only. Upsert, by scenario ID, the scenarios in the body, one extended JSON document per line as produced by the export endpoint. Container IDs and terminal ports are checked against this host: scenarios whose container is missing lose them and, if active, are stopped as orphaned. Lines that cannot be imported are reported and skipped. The import is recorded in the audit log.",
                 "consumes": [
-                    "application/json"
+                    "application/x-ndjson"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "scenarios"
+                    "admin"
                 ],
-                "summary": "Start a new scenario",
+                "summary": "Import scenarios",
                 "parameters": [
                     {
-                        "description": "Scenario start request",
+                        "description": "One scenario document per line",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/types.StartScenarioRequest"
+                            "type": "string"
                         }
                     }
                 ],
@@ -57,14 +100,51 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/types.StartScenarioResponse"
+                            "$ref": "#/definitions/types.ImportScenariosResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/types.ErrorResponse"
                         }
+                    }
+                }
+            }
+        },
+        "/admin/scenarios/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Admin only. Summarise every stored scenario: counts by status, by type and by user, and the running scenario that was created first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get scenario statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ScenarioStatsResponse"
+                        }
                     },
                     "401": {
                         "description": "Unauthorized",
@@ -72,6 +152,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -81,18 +167,24 @@ const docTemplate = `{
                 }
             }
         },
-        "/scenarios/{id}": {
-            "delete": {
+        "/admin/scenarios/{id}": {
+            "patch": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Stop and clean up a running scenario",
+                "description": "Change the notes or lock of any user's scenario, e.g. to keep a long-lived demo scenario from being cleaned up. The change is recorded in the audit log.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "scenarios"
+                    "admin"
                 ],
-                "summary": "Stop a scenario",
+                "summary": "Update any scenario",
                 "parameters": [
                     {
                         "type": "string",
@@ -100,16 +192,22 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.UpdateScenarioRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/types.ScenarioDetailsResponse"
                         }
                     },
                     "400": {
@@ -124,6 +222,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -133,21 +237,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/scenarios/{id}/directory": {
-            "get": {
+        "/admin/scenarios/{id}/force-remove": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get the file and directory structure for a scenario",
+                "description": "Admin only. Remove the scenario's container if it still exists, ignoring errors, and mark the scenario cleaned up whatever its current status. The action is recorded in the audit log.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "scenarios"
+                    "admin"
                 ],
-                "summary": "Get directory structure",
+                "summary": "Force-remove a stuck scenario",
                 "parameters": [
                     {
                         "type": "string",
@@ -161,7 +265,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/types.DirectoryStructureResponse"
+                            "$ref": "#/definitions/types.ForceRemoveScenarioResponse"
                         }
                     },
                     "400": {
@@ -176,6 +280,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -185,35 +295,78 @@ const docTemplate = `{
                 }
             }
         },
-        "/scenarios/{id}/status": {
+        "/events": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get the current status of a scenario",
+                "description": "Server-sent events stream emitting a \"status\" event whenever one of the authenticated user's scenarios changes status. The current status of every scenario is sent when the stream opens.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Stream scenario status changes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ScenarioEvent"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Find the caller's scenarios whose name, tags, notes, or type match a free-text query, best match first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "scenarios"
                 ],
-                "summary": "Get scenario status",
+                "summary": "Search scenarios",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Scenario ID",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Search text",
+                        "name": "q",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Results per page (default 20, max 100)",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token from a previous page's next_page_token",
+                        "name": "page_token",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/types.ScenarioStatusResponse"
+                            "$ref": "#/definitions/types.SearchScenariosResponse"
                         }
                     },
                     "400": {
@@ -228,8 +381,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/types.ErrorResponse"
                         }
@@ -237,35 +390,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/scenarios/{id}/terminal": {
-            "get": {
+        "/scenarios/start": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get the web terminal URL for a scenario",
+                "description": "Launch a new coding environment (container) for a user",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "scenarios"
                 ],
-                "summary": "Get terminal URL",
+                "summary": "Start a new scenario",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Scenario ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Scenario start request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.StartScenarioRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/types.TerminalURLResponse"
+                            "$ref": "#/definitions/types.StartScenarioResponse"
                         }
                     },
                     "400": {
@@ -280,81 +438,1849 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "499": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
                         "schema": {
                             "$ref": "#/definitions/types.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "types.DirectoryStructureResponse": {
-            "type": "object",
-            "properties": {
-                "message": {
-                    "type": "string"
-                },
-                "path": {
-                    "type": "string"
-                },
-                "scenario_id": {
-                    "type": "string"
-                },
-                "structure": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/types.FileNode"
-                    }
-                }
-            }
-        },
-        "types.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "string"
-                },
-                "error": {
-                    "type": "string"
-                },
-                "message": {
-                    "type": "string"
-                }
-            }
         },
-        "types.FileNode": {
-            "type": "object",
-            "properties": {
-                "children": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+        "/scenarios/stop": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
                     }
-                },
-                "content": {
-                    "type": "string"
-                },
-                "isOpen": {
-                    "type": "boolean"
-                },
-                "isRoot": {
+                ],
+                "description": "Stop up to 100 of the caller's scenarios at once. Stops run concurrently, bounded by the server's MAX_CONCURRENT_STOPS, and each succeeds or fails on its own: scenarios that could not be stopped, including ones that do not exist or belong to another user, are reported in failed. Already stopped scenarios count as stopped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Stop several scenarios",
+                "parameters": [
+                    {
+                        "description": "Scenarios to stop",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.StopScenariosRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.StopScenariosResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/terminals": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the web terminal URL of each of the caller's running scenarios in one call. Other scenarios are skipped. reachable is false when a running scenario's container is gone, paused or not publishing ttyd.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "List the caller's terminals",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.ScenarioTerminal"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the complete stored record of a scenario owned by the caller. The terminal password is redacted unless include_secrets=true.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Describe a scenario",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include the terminal password",
+                        "name": "include_secrets",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ScenarioDetailsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stop and clean up a running scenario. Stopping an already stopped scenario succeeds without changes.",
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Stop a scenario",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the notes of a scenario owned by the caller, or lock it so cleanup never removes it. Fields left out of the body are unchanged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Update a scenario",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.UpdateScenarioRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ScenarioDetailsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/{id}/clone": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Start a new scenario with the type, script, mode, name, tags and container settings of one owned by the caller. The clone gets its own container, terminal port and credentials, and belongs to the caller, or to user_id when an admin gives one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Clone a scenario",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Clone options",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/types.CloneScenarioRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.StartScenarioResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/{id}/directory": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the file and directory structure for a scenario",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Get directory structure",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "flat",
+                            "nested"
+                        ],
+                        "type": "string",
+                        "default": "flat",
+                        "description": "Response shape: flat lists every node, nested embeds children",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.DirectoryStructureResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/{id}/exec": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Run a command in a running scenario owned by the caller and return its output. The command is not run through a shell; the deployment's allow- and deny-lists decide which programs may run. A non-zero exit code is reported in the response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Run a command in a scenario",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Command to run",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ExecCommandRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ExecCommandResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/{id}/extend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Push the expiry of an active scenario owned by the caller forward so cleanup does not reap it. Extensions are capped at the maximum scenario lifetime.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Extend a scenario's lifetime",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ExtendScenarioResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/{id}/heartbeat": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Mark a scenario owned by the caller as in use, e.g. while its terminal is being typed in, so inactivity-based cleanup does not reap it. Heartbeats sent more often than the configured interval are rejected with 429.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Record scenario activity",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.HeartbeatResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/{id}/logs/stream": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Server-sent events stream of the output of a scenario owned by the caller. Each line is sent as a \"stdout\" or \"stderr\" event, starting with the last ` + "`" + `tail` + "`" + ` lines. An \"end\" event is sent when the container exits; otherwise the stream runs until the client disconnects.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Follow scenario logs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of earlier lines to send first (default 100, 0 for none)",
+                        "name": "tail",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream of log lines",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/{id}/pause": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Freeze the container of a running scenario owned by the caller so it stops using CPU while keeping its state. A paused scenario still counts towards capacity and still expires, but is not reaped for inactivity. Pausing a paused scenario succeeds without changes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Pause a scenario",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.PauseScenarioResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/{id}/results": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the captured stdout, stderr and exit code of a finished batch scenario owned by the caller",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Get batch scenario results",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ScenarioResultsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/{id}/resume": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Unfreeze a paused scenario owned by the caller. Resuming a running scenario succeeds without changes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Resume a paused scenario",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.PauseScenarioResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/{id}/status": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the current status of a scenario",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Get scenario status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ScenarioStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/{id}/terminal": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the web terminal URL for a scenario",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Get terminal URL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.TerminalURLResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/{id}/terminal/credentials": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the web terminal login for a scenario owned by the caller",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Get terminal credentials",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.TerminalCredentialsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generate a new web terminal login for a running interactive scenario owned by the caller and restart ttyd. The new login is only stored once ttyd is serving it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Rotate terminal credentials",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.TerminalCredentialsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/{id}/terminal/rebind": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Allocate a new host port for the web terminal of a running scenario owned by the caller, or of any running scenario for an admin. The container is recreated from a snapshot of its filesystem, so running processes are restarted. The scenario script is not run again. A workspace on tmpfs, which a snapshot does not keep, is refused with 409.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Move a scenario's terminal to a new host port",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scenario ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.RebindTerminalResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "types.CloneScenarioRequest": {
+            "type": "object",
+            "properties": {
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.DirectoryStructureResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "scenario_id": {
+                    "type": "string"
+                },
+                "structure": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.FileNode"
+                    }
+                },
+                "tree": {
+                    "$ref": "#/definitions/types.NestedFileNode"
+                },
+                "truncated": {
+                    "description": "Truncated is set when the entry cap, the time limit or a failing find\ncut the listing short; entries below the depth limit are never listed\nand do not set it",
+                    "type": "boolean"
+                }
+            }
+        },
+        "types.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.FieldError"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ExecCommandRequest": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "description": "Command is the program and its arguments; it is not run through a shell",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "timeout_seconds": {
+                    "description": "TimeoutSeconds bounds the command; zero uses the deployment default",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.ExecCommandResponse": {
+            "type": "object",
+            "properties": {
+                "exit_code": {
+                    "type": "integer"
+                },
+                "scenario_id": {
+                    "type": "string"
+                },
+                "stderr": {
+                    "type": "string"
+                },
+                "stdout": {
+                    "type": "string"
+                },
+                "timed_out": {
+                    "type": "boolean"
+                },
+                "truncated": {
+                    "description": "Truncated is set when the command wrote more than the server's output\nlimit; the output is cut off there and ExitCode is -1 if the command\nwas still running",
+                    "type": "boolean"
+                }
+            }
+        },
+        "types.ExtendScenarioResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "scenario_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.FileNode": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "content": {
+                    "type": "string"
+                },
+                "is_open": {
+                    "type": "boolean"
+                },
+                "is_root": {
+                    "type": "boolean"
+                },
+                "is_saved": {
+                    "type": "boolean"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "\"file\" or \"folder\"",
+                    "type": "string"
+                }
+            }
+        },
+        "types.ForceRemoveScenarioResponse": {
+            "type": "object",
+            "properties": {
+                "container_removed": {
+                    "description": "ContainerRemoved is false when the container was already gone or could not be removed",
+                    "type": "boolean"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "previous_status": {
+                    "$ref": "#/definitions/types.ScenarioStatus"
+                },
+                "scenario_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/types.ScenarioStatus"
+                }
+            }
+        },
+        "types.HeartbeatResponse": {
+            "type": "object",
+            "properties": {
+                "last_activity_at": {
+                    "type": "string"
+                },
+                "next_heartbeat_at": {
+                    "description": "NextHeartbeatAt is the earliest time another heartbeat will be accepted",
+                    "type": "string"
+                },
+                "scenario_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ImportScenariosResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ScenarioImportFailure"
+                    }
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "reconciled": {
+                    "description": "Reconciled counts the imported scenarios whose container, terminal\nport or status was corrected to match this host",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.NestedFileNode": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.NestedFileNode"
+                    }
+                },
+                "is_open": {
+                    "type": "boolean"
+                },
+                "is_root": {
+                    "type": "boolean"
+                },
+                "is_saved": {
+                    "type": "boolean"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "\"file\" or \"folder\"",
+                    "type": "string"
+                }
+            }
+        },
+        "types.PauseScenarioResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "scenario_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/types.ScenarioStatus"
+                }
+            }
+        },
+        "types.RebindTerminalResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "scenario_id": {
+                    "type": "string"
+                },
+                "terminal_port": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ResourceLimits": {
+            "type": "object",
+            "properties": {
+                "cpus": {
+                    "description": "CPUs is the number of CPUs the container may use, e.g. 1.5",
+                    "type": "number"
+                },
+                "memory_mb": {
+                    "description": "MemoryMB is the memory limit in megabytes; at least 6 when set",
+                    "type": "integer"
+                }
+            }
+        },
+        "types.RunningScenarioSummary": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "running_for": {
+                    "description": "RunningFor is the time since CreatedAt, e.g. \"26h3m0s\"",
+                    "type": "string"
+                },
+                "scenario_id": {
+                    "type": "string"
+                },
+                "scenario_type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ScenarioDetailsResponse": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "container_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "exit_code": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "image": {
+                    "type": "string"
+                },
+                "last_activity_at": {
+                    "type": "string"
+                },
+                "locked": {
                     "type": "boolean"
                 },
-                "isSaved": {
+                "message": {
+                    "type": "string"
+                },
+                "mode": {
+                    "$ref": "#/definitions/types.ScenarioMode"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "post_start_error": {
+                    "type": "string"
+                },
+                "provisioning_logs": {
+                    "type": "string"
+                },
+                "scenario_id": {
+                    "type": "string"
+                },
+                "scenario_type": {
+                    "type": "string"
+                },
+                "script": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/types.ScenarioStatus"
+                },
+                "status_history": {
+                    "description": "StatusHistory lists the scenario's most recent status transitions,\noldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.StatusChange"
+                    }
+                },
+                "stop_reason": {
+                    "$ref": "#/definitions/types.StopReason"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "terminal_password": {
+                    "type": "string"
+                },
+                "terminal_port": {
+                    "type": "integer"
+                },
+                "terminal_username": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ScenarioEvent": {
+            "type": "object",
+            "properties": {
+                "scenario_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/types.ScenarioStatus"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ScenarioImportFailure": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "scenario_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ScenarioMode": {
+            "type": "string",
+            "enum": [
+                "interactive",
+                "batch"
+            ],
+            "x-enum-varnames": [
+                "ScenarioModeInteractive",
+                "ScenarioModeBatch"
+            ]
+        },
+        "types.ScenarioResultsResponse": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "exit_code": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "scenario_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/types.ScenarioStatus"
+                },
+                "stderr": {
+                    "type": "string"
+                },
+                "stdout": {
+                    "type": "string"
+                },
+                "timed_out": {
                     "type": "boolean"
+                }
+            }
+        },
+        "types.ScenarioSearchResult": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
                 },
-                "path": {
+                "name": {
                     "type": "string"
                 },
-                "type": {
-                    "description": "\"file\" or \"folder\"",
+                "scenario_id": {
+                    "type": "string"
+                },
+                "scenario_type": {
                     "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "status": {
+                    "$ref": "#/definitions/types.ScenarioStatus"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.ScenarioStatsResponse": {
+            "type": "object",
+            "properties": {
+                "by_status": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "by_type": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "by_user": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "oldest_running": {
+                    "description": "OldestRunning is omitted when no scenario is running",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.RunningScenarioSummary"
+                        }
+                    ]
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
+        "types.ScenarioStatus": {
+            "type": "string",
+            "enum": [
+                "provisioning",
+                "running",
+                "paused",
+                "stopped",
+                "cleaned_up",
+                "completed",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "ScenarioStatusProvisioning",
+                "ScenarioStatusRunning",
+                "ScenarioStatusPaused",
+                "ScenarioStatusStopped",
+                "ScenarioStatusCleanedUp",
+                "ScenarioStatusCompleted",
+                "ScenarioStatusFailed"
+            ]
+        },
         "types.ScenarioStatusResponse": {
             "type": "object",
             "properties": {
@@ -374,24 +2300,154 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "status": {
-                    "type": "string"
+                    "$ref": "#/definitions/types.ScenarioStatus"
+                },
+                "stop_reason": {
+                    "$ref": "#/definitions/types.StopReason"
                 },
                 "user_id": {
                     "type": "string"
                 }
             }
         },
+        "types.ScenarioStopFailure": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "scenario_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ScenarioTerminal": {
+            "type": "object",
+            "properties": {
+                "reachable": {
+                    "type": "boolean"
+                },
+                "scenario_id": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.SearchScenariosResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "next_page_token": {
+                    "description": "NextPageToken resumes the search; it is empty on the last page",
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ScenarioSearchResult"
+                    }
+                }
+            }
+        },
         "types.StartScenarioRequest": {
             "type": "object",
             "properties": {
+                "command": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "entrypoint": {
+                    "description": "Entrypoint and Command replace the generated startup logic, so they\ncannot be combined with a script or batch mode. StartTTYD still launches\nthe web terminal in the background before handing over to the override.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "env": {
+                    "description": "Env sets extra environment variables in the container. Names must be\nvalid shell identifiers and values cannot contain null bytes.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "keep_on_failure": {
+                    "description": "KeepOnFailure leaves a container that fails to come up in place for\ndebugging; the scenario is recorded as failed with the container's logs",
+                    "type": "boolean"
+                },
+                "labels": {
+                    "description": "Labels are attached to the container, e.g. for external accounting.\nKeys use lowercase letters, digits, dots and hyphens, and may not start\nwith \"devlab.\", which is reserved for devlab's own labels.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "limits": {
+                    "description": "Limits caps the container's CPU and memory. Unset fields use the\nserver's default for the scenario type, if any.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.ResourceLimits"
+                        }
+                    ]
+                },
+                "mode": {
+                    "$ref": "#/definitions/types.ScenarioMode"
+                },
+                "name": {
+                    "description": "Name and Tags label the scenario for search; Notes is freeform text\nabout it, also searched",
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "post_start": {
+                    "description": "PostStart is run in the container once it is up, before the scenario is\nreported as running. A failure is recorded on the scenario and stops it\nonly when PostStartFatal is set.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "post_start_fatal": {
+                    "type": "boolean"
+                },
                 "scenario_type": {
                     "type": "string"
                 },
                 "script": {
                     "type": "string"
                 },
+                "script_timeout_seconds": {
+                    "description": "ScriptTimeoutSeconds bounds a batch script or post-start hook; zero\nuses the server default, and a limit above the server maximum is cut down\nto it. A script still running at the limit is killed.",
+                    "type": "integer"
+                },
+                "start_ttyd": {
+                    "type": "boolean"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "template": {
+                    "description": "Template names a server-side scenario template, e.g. \"intro-go-lab\",\nthat fills in the fields the request leaves unset. Its env and labels\nare merged with the request's, which win on conflict.",
+                    "type": "string"
+                },
+                "ttl_seconds": {
+                    "description": "TTLSeconds is how long the scenario lives before cleanup; zero uses the\nserver default. TTLs outside the server's range are clamped into it or\nrejected, depending on its configuration.",
+                    "type": "integer"
+                },
                 "user_id": {
                     "type": "string"
+                },
+                "wait_for_running": {
+                    "description": "WaitForRunning asks start to check once whether the container is already\nup and, if so, return its terminal URL and credentials with the response",
+                    "type": "boolean"
                 }
             }
         },
@@ -402,6 +2458,105 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "status": {
+                    "$ref": "#/definitions/types.ScenarioStatus"
+                },
+                "terminal_credentials": {
+                    "$ref": "#/definitions/types.TerminalCredentials"
+                },
+                "terminal_url": {
+                    "description": "TerminalURL and TerminalCredentials are set only when the scenario was\nalready running when start returned; otherwise they are null and the\nclient fetches them once the scenario is running",
+                    "type": "string"
+                }
+            }
+        },
+        "types.StatusChange": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/types.ScenarioStatus"
+                }
+            }
+        },
+        "types.StopReason": {
+            "type": "string",
+            "enum": [
+                "user_requested",
+                "expired",
+                "orphaned",
+                "failed",
+                "force_removed",
+                "provisioning_timeout"
+            ],
+            "x-enum-varnames": [
+                "StopReasonUserRequested",
+                "StopReasonExpired",
+                "StopReasonOrphaned",
+                "StopReasonFailed",
+                "StopReasonForceRemoved",
+                "StopReasonProvisioningTimeout"
+            ]
+        },
+        "types.StopScenariosRequest": {
+            "type": "object",
+            "properties": {
+                "scenario_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.StopScenariosResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ScenarioStopFailure"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "stopped": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.TerminalCredentials": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.TerminalCredentialsResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "scenario_id": {
+                    "type": "string"
+                },
+                "username": {
                     "type": "string"
                 }
             }
@@ -419,6 +2574,18 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "types.UpdateScenarioRequest": {
+            "type": "object",
+            "properties": {
+                "locked": {
+                    "description": "Locked keeps the scenario from being cleaned up, however long it has\nexpired or sat idle",
+                    "type": "boolean"
+                },
+                "notes": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"devlab/internal/types"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultEventsPollInterval is how often the events stream re-reads the user's scenarios
+const DefaultEventsPollInterval = 2 * time.Second
+
+// EventsREST godoc
+// @Summary Stream scenario status changes
+// @Description Server-sent events stream emitting a "status" event whenever one of the authenticated user's scenarios changes status. The current status of every scenario is sent when the stream opens.
+// @Tags scenarios
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Success 200 {object} types.ScenarioEvent
+// @Failure 401 {object} types.ErrorResponse
+// @Router /events [get]
+func (h *Handler) EventsREST(c *gin.Context) {
+	userID := UserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, types.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    "UNAUTHORIZED",
+			Message: "Token does not identify a user",
+		})
+		return
+	}
+
+	interval := h.EventsPollInterval
+	if interval <= 0 {
+		interval = DefaultEventsPollInterval
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastStatus := make(map[string]types.ScenarioStatus)
+	for {
+		h.emitScenarioChanges(c, userID, lastStatus)
+
+		select {
+		case <-ctx.Done():
+			// Client disconnected
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// emitScenarioChanges polls the user's scenarios and writes an event for each
+// one whose status differs from lastStatus, updating lastStatus in place
+func (h *Handler) emitScenarioChanges(c *gin.Context, userID string, lastStatus map[string]types.ScenarioStatus) {
+	scenarios, err := h.Scenario.ListUserScenarios(c.Request.Context(), userID)
+	if err != nil {
+		// Keep the stream open; the next poll may succeed
+		log.Printf("[api] failed to list scenarios for user %s: %v", userID, err)
+		return
+	}
+
+	changed := false
+	for _, scenario := range scenarios {
+		if lastStatus[scenario.ScenarioID] == scenario.Status {
+			continue
+		}
+		lastStatus[scenario.ScenarioID] = scenario.Status
+		c.SSEvent("status", types.ScenarioEvent{
+			ScenarioID: scenario.ScenarioID,
+			Status:     scenario.Status,
+			Timestamp:  time.Now().UTC(),
+		})
+		changed = true
+	}
+
+	if changed {
+		c.Writer.Flush()
+	}
+}
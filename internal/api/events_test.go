@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"devlab/internal/types"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEventsREST_EmitsStatusTransitions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	provisioning := []*types.ScenarioStatusResponse{{ScenarioID: "scn-1", UserID: "user-1", Status: "provisioning"}}
+	running := []*types.ScenarioStatusResponse{{ScenarioID: "scn-1", UserID: "user-1", Status: "running"}}
+
+	mockManager := new(MockScenarioManager)
+	mockManager.On("ListUserScenarios", mock.Anything, "user-1").Return(provisioning, nil).Once()
+	mockManager.On("ListUserScenarios", mock.Anything, "user-1").Return(nil, errors.New("transient")).Once()
+	mockManager.On("ListUserScenarios", mock.Anything, "user-1").Return(running, nil)
+
+	handler := &Handler{Scenario: mockManager, EventsPollInterval: 5 * time.Millisecond}
+	router := gin.New()
+	router.GET("/events", withUser("user-1"), handler.EventsREST)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/events", nil)
+	w := httptest.NewRecorder()
+
+	// Returns once the request context is done, as on client disconnect
+	router.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream"))
+	assert.Equal(t, 2, strings.Count(body, "event:status"), "unchanged polls must not repeat events")
+	assert.Contains(t, body, `"scenario_id":"scn-1","status":"provisioning"`)
+	assert.Contains(t, body, `"scenario_id":"scn-1","status":"running"`)
+	assert.Less(t, strings.Index(body, "provisioning"), strings.Index(body, `"running"`))
+}
+
+func TestEventsREST_RequiresUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockManager := new(MockScenarioManager)
+	handler := &Handler{Scenario: mockManager}
+	router := gin.New()
+	router.GET("/events", handler.EventsREST)
+
+	req, _ := http.NewRequest("GET", "/events", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockManager.AssertNotCalled(t, "ListUserScenarios", mock.Anything, mock.Anything)
+}
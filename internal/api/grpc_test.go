@@ -0,0 +1,403 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"devlab/internal/config"
+	"devlab/internal/scenario"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	pb "devlab/proto"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newBufconnClient serves a GRPCServer backed by manager over an in-memory
+// listener and returns a client connected to it
+func newBufconnClient(t *testing.T, manager ScenarioManager) pb.ScenarioServiceClient {
+	return serveBufconn(t, &GRPCServer{Scenario: manager})
+}
+
+// serveBufconn serves server over an in-memory listener and returns a client
+// connected to it
+func serveBufconn(t *testing.T, server *GRPCServer) pb.ScenarioServiceClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(JWTUnaryInterceptor()),
+		grpc.StreamInterceptor(JWTStreamInterceptor()),
+	)
+	pb.RegisterScenarioServiceServer(srv, server)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewScenarioServiceClient(conn)
+}
+
+// withBearer returns ctx carrying a signed token for userID
+func withBearer(t *testing.T, ctx context.Context, userID string) context.Context {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": userID}).SignedString(jwtSecret)
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func TestGRPCListScenarios_Pagination(t *testing.T) {
+	base := time.Now()
+	var seeded []*storage.Scenario
+	for i := 0; i < 5; i++ {
+		seeded = append(seeded, &storage.Scenario{
+			ScenarioID: fmt.Sprintf("scn-alice-%d", i),
+			UserID:     "alice",
+			Status:     types.ScenarioStatusRunning,
+			CreatedAt:  base.Add(time.Duration(i) * time.Second),
+		})
+	}
+	seeded = append(seeded, &storage.Scenario{ScenarioID: "scn-bob", UserID: "bob", Status: types.ScenarioStatusRunning, CreatedAt: base})
+
+	client := newBufconnClient(t, &scenario.Manager{Store: storage.NewMemoryStore(seeded...)})
+	ctx := withBearer(t, context.Background(), "alice")
+
+	var ids []string
+	pageToken := ""
+	for pages := 1; ; pages++ {
+		require.LessOrEqual(t, pages, 3, "five scenarios should fit in three pages of two")
+		resp, err := client.ListScenarios(ctx, &pb.ListScenariosRequest{PageSize: 2, PageToken: pageToken})
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(resp.Scenarios), 2)
+		for _, s := range resp.Scenarios {
+			assert.Equal(t, "alice", s.UserId)
+			ids = append(ids, s.ScenarioId)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+	assert.Equal(t, []string{"scn-alice-0", "scn-alice-1", "scn-alice-2", "scn-alice-3", "scn-alice-4"}, ids)
+}
+
+func TestGRPCListScenarios_Errors(t *testing.T) {
+	client := newBufconnClient(t, &scenario.Manager{Store: storage.NewMemoryStore()})
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		req          *pb.ListScenariosRequest
+		expectedCode codes.Code
+	}{
+		{
+			name:         "missing_token",
+			ctx:          context.Background(),
+			req:          &pb.ListScenariosRequest{},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "invalid_token",
+			ctx:          metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nope"),
+			req:          &pb.ListScenariosRequest{},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "invalid_page_token",
+			ctx:          withBearer(t, context.Background(), "alice"),
+			req:          &pb.ListScenariosRequest{PageToken: "!!"},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "negative_page_size",
+			ctx:          withBearer(t, context.Background(), "alice"),
+			req:          &pb.ListScenariosRequest{PageSize: -1},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.ListScenarios(tt.ctx, tt.req)
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+}
+
+// errorInfo returns the ErrorInfo detail of a gRPC error
+func errorInfo(t *testing.T, err error) *errdetails.ErrorInfo {
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	require.Fail(t, "no ErrorInfo detail", "%v", err)
+	return nil
+}
+
+func TestGRPCErrorDetails(t *testing.T) {
+	cfg := &config.Config{Container: config.ContainerConfig{ReservedTypes: []string{"legacy"}}}
+	client := newBufconnClient(t, &scenario.Manager{Cfg: cfg, Store: storage.NewMemoryStore()})
+	ctx := withBearer(t, context.Background(), "alice")
+
+	t.Run("scenario_not_found", func(t *testing.T) {
+		_, err := client.GetScenarioStatus(ctx, &pb.GetScenarioStatusRequest{ScenarioId: "scn-missing"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		info := errorInfo(t, err)
+		assert.Equal(t, "SCENARIO_NOT_FOUND", info.Reason)
+		assert.Equal(t, ErrorDomain, info.Domain)
+		assert.Equal(t, map[string]string{"scenario_id": "scn-missing"}, info.Metadata)
+	})
+
+	t.Run("invalid_scenario_type", func(t *testing.T) {
+		_, err := client.StartScenario(ctx, &pb.StartScenarioRequest{UserId: "alice", ScenarioType: "legacy"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		info := errorInfo(t, err)
+		assert.Equal(t, "INVALID_SCENARIO_TYPE", info.Reason)
+		assert.Equal(t, map[string]string{"scenario_type": "legacy"}, info.Metadata)
+	})
+
+	t.Run("request_validation", func(t *testing.T) {
+		_, err := client.ListScenarios(ctx, &pb.ListScenariosRequest{PageSize: -1})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		info := errorInfo(t, err)
+		assert.Equal(t, "INVALID_PAGE_SIZE", info.Reason)
+		assert.Empty(t, info.Metadata)
+	})
+}
+
+func TestGRPCScenarioOwnership(t *testing.T) {
+	store := storage.NewMemoryStore(&storage.Scenario{
+		ScenarioID: "scn-alice", UserID: "alice", ScenarioType: "go", Status: types.ScenarioStatusStopped, CreatedAt: time.Now(),
+	})
+	client := newBufconnClient(t, &scenario.Manager{Store: store})
+	bob := withBearer(t, context.Background(), "bob")
+
+	calls := map[string]func(ctx context.Context) error{
+		"GetScenarioStatus": func(ctx context.Context) error {
+			_, err := client.GetScenarioStatus(ctx, &pb.GetScenarioStatusRequest{ScenarioId: "scn-alice"})
+			return err
+		},
+		"GetTerminalURL": func(ctx context.Context) error {
+			_, err := client.GetTerminalURL(ctx, &pb.GetTerminalURLRequest{ScenarioId: "scn-alice"})
+			return err
+		},
+		"StopScenario": func(ctx context.Context) error {
+			_, err := client.StopScenario(ctx, &pb.StopScenarioRequest{ScenarioId: "scn-alice"})
+			return err
+		},
+		"GetDirectoryStructure": func(ctx context.Context) error {
+			_, err := client.GetDirectoryStructure(ctx, &pb.GetDirectoryStructureRequest{ScenarioId: "scn-alice"})
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			err := call(bob)
+			assert.Equal(t, codes.PermissionDenied, status.Code(err))
+			assert.Equal(t, "FORBIDDEN", errorInfo(t, err).Reason)
+		})
+	}
+
+	// The scenario is untouched by the rejected calls, and its owner still reaches it
+	stored, err := store.GetScenario(context.Background(), "scn-alice")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusStopped, stored.Status)
+	resp, err := client.GetScenarioStatus(withBearer(t, context.Background(), "alice"), &pb.GetScenarioStatusRequest{ScenarioId: "scn-alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", resp.UserId)
+}
+
+func TestGRPCStartScenario_OwnerFromToken(t *testing.T) {
+	manager := new(MockScenarioManager)
+	manager.On("StartScenario", mock.Anything, mock.MatchedBy(func(req *types.StartScenarioRequest) bool {
+		return req.UserID == "bob"
+	})).Return(&types.StartScenarioResponse{ScenarioID: "scn-bob", Status: types.ScenarioStatusProvisioning}, nil)
+	client := newBufconnClient(t, manager)
+	bob := withBearer(t, context.Background(), "bob")
+
+	resp, err := client.StartScenario(bob, &pb.StartScenarioRequest{ScenarioType: "go"})
+	require.NoError(t, err)
+	assert.Equal(t, "scn-bob", resp.ScenarioId)
+
+	_, err = client.StartScenario(bob, &pb.StartScenarioRequest{UserId: "alice", ScenarioType: "go"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	manager.AssertNumberOfCalls(t, "StartScenario", 1)
+}
+
+func TestGRPCWatchUserScenarios(t *testing.T) {
+	store := storage.NewMemoryStore(
+		&storage.Scenario{ScenarioID: "scn-alice-1", UserID: "alice", Status: types.ScenarioStatusRunning, CreatedAt: time.Now()},
+		&storage.Scenario{ScenarioID: "scn-alice-2", UserID: "alice", Status: types.ScenarioStatusRunning, CreatedAt: time.Now().Add(time.Second)},
+		&storage.Scenario{ScenarioID: "scn-bob", UserID: "bob", Status: types.ScenarioStatusRunning, CreatedAt: time.Now()},
+	)
+	client := serveBufconn(t, &GRPCServer{
+		Scenario:          &scenario.Manager{Store: store},
+		WatchPollInterval: 10 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(withBearer(t, context.Background(), "alice"))
+	defer cancel()
+	stream, err := client.WatchUserScenarios(ctx, &pb.WatchUserScenariosRequest{})
+	require.NoError(t, err)
+
+	snapshot, err := stream.Recv()
+	require.NoError(t, err)
+	assert.True(t, snapshot.Snapshot)
+	require.Len(t, snapshot.Scenarios, 2)
+	assert.Equal(t, "scn-alice-1", snapshot.Scenarios[0].ScenarioId)
+	assert.Equal(t, "scn-alice-2", snapshot.Scenarios[1].ScenarioId)
+
+	stopped, err := store.GetScenario(context.Background(), "scn-alice-1")
+	require.NoError(t, err)
+	stopped.Status = types.ScenarioStatusStopped
+	require.NoError(t, store.UpdateScenario(context.Background(), stopped))
+
+	update, err := stream.Recv()
+	require.NoError(t, err)
+	assert.False(t, update.Snapshot)
+	require.Len(t, update.Scenarios, 1)
+	assert.Equal(t, "scn-alice-1", update.Scenarios[0].ScenarioId)
+	assert.Equal(t, string(types.ScenarioStatusStopped), update.Scenarios[0].Status)
+	assert.Empty(t, update.RemovedScenarioIds)
+
+	require.NoError(t, store.DeleteScenario(context.Background(), "scn-alice-2"))
+
+	update, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Empty(t, update.Scenarios)
+	assert.Equal(t, []string{"scn-alice-2"}, update.RemovedScenarioIds)
+
+	cancel()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestGRPCWatchUserScenarios_Unauthenticated(t *testing.T) {
+	client := newBufconnClient(t, &scenario.Manager{Store: storage.NewMemoryStore()})
+
+	stream, err := client.WatchUserScenarios(context.Background(), &pb.WatchUserScenariosRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestJWTUnaryInterceptor(t *testing.T) {
+	interceptor := JWTUnaryInterceptor()
+	validToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": "alice"}).SignedString(jwtSecret)
+	require.NoError(t, err)
+	otherKeyToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": "alice"}).SignedString([]byte("wrong"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		method         string
+		authorization  string
+		expectedCode   codes.Code
+		expectedUserID string
+	}{
+		{
+			name:         "missing_token",
+			method:       pb.ScenarioService_StopScenario_FullMethodName,
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:          "not_bearer",
+			method:        pb.ScenarioService_StopScenario_FullMethodName,
+			authorization: "Basic abc",
+			expectedCode:  codes.Unauthenticated,
+		},
+		{
+			name:          "invalid_signature",
+			method:        pb.ScenarioService_StopScenario_FullMethodName,
+			authorization: "Bearer " + otherKeyToken,
+			expectedCode:  codes.Unauthenticated,
+		},
+		{
+			name:           "valid_token",
+			method:         pb.ScenarioService_StopScenario_FullMethodName,
+			authorization:  "Bearer " + validToken,
+			expectedCode:   codes.OK,
+			expectedUserID: "alice",
+		},
+		{
+			name:         "health_exempt",
+			method:       "/grpc.health.v1.Health/Check",
+			expectedCode: codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := metadata.MD{}
+			if tt.authorization != "" {
+				md.Set("authorization", tt.authorization)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+
+			var handlerUserID string
+			called := false
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				handlerUserID = UserIDFromGRPCContext(ctx)
+				return nil, nil
+			})
+
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			assert.Equal(t, tt.expectedCode == codes.OK, called)
+			assert.Equal(t, tt.expectedUserID, handlerUserID)
+		})
+	}
+}
+
+// fakeServerStream is a grpc.ServerStream that only carries a context
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestJWTStreamInterceptor(t *testing.T) {
+	interceptor := JWTStreamInterceptor()
+	info := &grpc.StreamServerInfo{FullMethod: "/scenario.ScenarioService/Watch"}
+
+	err := interceptor(nil, &fakeServerStream{ctx: context.Background()}, info, func(srv interface{}, ss grpc.ServerStream) error {
+		t.Fatal("handler should not run without a token")
+		return nil
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	var userID string
+	md, _ := metadata.FromOutgoingContext(withBearer(t, context.Background(), "alice"))
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	err = interceptor(nil, &fakeServerStream{ctx: ctx}, info, func(srv interface{}, ss grpc.ServerStream) error {
+		userID = UserIDFromGRPCContext(ss.Context())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", userID)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"devlab/internal/docker"
+	"devlab/internal/scenario"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details attached to gRPC errors
+const ErrorDomain = "devlab"
+
+// grpcErrorCodes maps manager errors to a gRPC code and the reason reported
+// in ErrorInfo, which matches the REST ErrorResponse.Code for the same error.
+// The first match wins.
+var grpcErrorCodes = []struct {
+	err    error
+	code   codes.Code
+	reason string
+}{
+	{scenario.ErrClientCancelled, codes.Canceled, "CLIENT_CLOSED_REQUEST"},
+	{scenario.ErrScenarioNotFound, codes.NotFound, "SCENARIO_NOT_FOUND"},
+	{scenario.ErrNotScenarioOwner, codes.PermissionDenied, "FORBIDDEN"},
+	{scenario.ErrInvalidScenarioID, codes.InvalidArgument, "INVALID_SCENARIO_ID"},
+	{scenario.ErrInvalidScenarioMode, codes.InvalidArgument, "INVALID_MODE"},
+	{scenario.ErrInvalidOverride, codes.InvalidArgument, "INVALID_CONTAINER_OVERRIDE"},
+	{scenario.ErrScriptTooLarge, codes.InvalidArgument, "SCRIPT_TOO_LARGE"},
+	{scenario.ErrInvalidScriptTimeout, codes.InvalidArgument, "INVALID_SCRIPT_TIMEOUT"},
+	{scenario.ErrInvalidTTL, codes.InvalidArgument, "INVALID_TTL"},
+	{scenario.ErrInvalidNotes, codes.InvalidArgument, "INVALID_NOTES"},
+	{scenario.ErrInvalidPageToken, codes.InvalidArgument, "INVALID_PAGE_TOKEN"},
+	{scenario.ErrInvalidDirectoryFormat, codes.InvalidArgument, "INVALID_FORMAT"},
+	{docker.ErrInvalidScenarioType, codes.InvalidArgument, "INVALID_SCENARIO_TYPE"},
+	{scenario.ErrUnknownTemplate, codes.InvalidArgument, "UNKNOWN_TEMPLATE"},
+	{docker.ErrInvalidEnv, codes.InvalidArgument, "INVALID_ENV"},
+	{docker.ErrInvalidLabel, codes.InvalidArgument, "INVALID_LABEL"},
+	{docker.ErrInvalidLimits, codes.InvalidArgument, "INVALID_LIMITS"},
+	{scenario.ErrScenarioAlreadyStopped, codes.FailedPrecondition, "SCENARIO_ALREADY_STOPPED"},
+	{scenario.ErrScenarioNotRunning, codes.FailedPrecondition, "SCENARIO_NOT_RUNNING"},
+	{scenario.ErrNoTerminal, codes.FailedPrecondition, "NO_TERMINAL"},
+	{docker.ErrContainerNotRunning, codes.FailedPrecondition, "CONTAINER_NOT_RUNNING"},
+	{scenario.ErrProvisioningTimeout, codes.DeadlineExceeded, "PROVISIONING_TIMEOUT"},
+	{scenario.ErrCapacityReached, codes.Unavailable, "CAPACITY_REACHED"},
+	{scenario.ErrProvisioningBusy, codes.Unavailable, "PROVISIONING_BUSY"},
+	{scenario.ErrDatabaseUnavailable, codes.Unavailable, "DATABASE_UNAVAILABLE"},
+	{docker.ErrPortUnavailable, codes.Unavailable, "PORT_UNAVAILABLE"},
+	{docker.ErrDockerDaemonUnavailable, codes.Unavailable, "DOCKER_UNAVAILABLE"},
+	{docker.ErrTTYDFailedToStart, codes.Internal, "TTYD_FAILED"},
+	{docker.ErrContainerUserNotFound, codes.Internal, "CONTAINER_USER_NOT_FOUND"},
+}
+
+// grpcError converts a manager error to a gRPC status error. The status
+// carries an ErrorInfo whose reason clients can branch on and whose metadata
+// holds the non-empty values of metadata, e.g. the scenario ID.
+func grpcError(err error, metadata map[string]string) error {
+	code, reason := codes.Internal, "INTERNAL_ERROR"
+	for _, mapping := range grpcErrorCodes {
+		if errors.Is(err, mapping.err) {
+			code, reason = mapping.code, mapping.reason
+			break
+		}
+	}
+	return grpcStatusError(code, reason, err.Error(), metadata)
+}
+
+// grpcStatusError builds a gRPC status error with code and message carrying
+// an ErrorInfo with reason and the non-empty values of metadata
+func grpcStatusError(code codes.Code, reason, message string, metadata map[string]string) error {
+	info := &errdetails.ErrorInfo{Reason: reason, Domain: ErrorDomain}
+	for key, value := range metadata {
+		if value != "" {
+			if info.Metadata == nil {
+				info.Metadata = make(map[string]string)
+			}
+			info.Metadata[key] = value
+		}
+	}
+
+	st := status.New(code, message)
+	if detailed, err := st.WithDetails(info); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -7,25 +7,63 @@ import (
 	"devlab/internal/types"
 	pb "devlab/proto"
 	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
+// StatusClientClosedRequest is the non-standard (nginx) status used when the
+// client disconnects before the server could respond.
+const StatusClientClosedRequest = 499
+
 type ScenarioManager interface {
 	StartScenario(ctx context.Context, req *types.StartScenarioRequest) (*types.StartScenarioResponse, error)
 	GetScenarioStatus(ctx context.Context, scenarioID string) (*types.ScenarioStatusResponse, error)
 	GetTerminalURL(ctx context.Context, scenarioID string) (string, error)
 	StopScenario(ctx context.Context, scenarioID string) error
-	GetDirectoryStructure(ctx context.Context, scenarioID string) (*types.DirectoryStructureResponse, error)
+	StopScenarios(ctx context.Context, userID string, scenarioIDs []string) (*types.StopScenariosResponse, error)
+	GetDirectoryStructure(ctx context.Context, scenarioID string, format types.DirectoryFormat) (*types.DirectoryStructureResponse, error)
+	GetTerminalCredentials(ctx context.Context, scenarioID, userID string) (*types.TerminalCredentialsResponse, error)
+	RotateTerminalCredentials(ctx context.Context, scenarioID, userID string) (*types.TerminalCredentialsResponse, error)
+	RebindTerminalPort(ctx context.Context, scenarioID, userID string, admin bool) (*types.RebindTerminalResponse, error)
+	CloneScenario(ctx context.Context, scenarioID, userID, ownerID string, admin bool) (*types.StartScenarioResponse, error)
+	PauseScenario(ctx context.Context, scenarioID, userID string) (*types.PauseScenarioResponse, error)
+	ResumeScenario(ctx context.Context, scenarioID, userID string) (*types.PauseScenarioResponse, error)
+	ExecuteCommand(ctx context.Context, scenarioID, userID string, req *types.ExecCommandRequest) (*types.ExecCommandResponse, error)
+	FollowScenarioLogs(ctx context.Context, scenarioID, userID string, tail int) (*docker.LogStream, error)
+	ImageAvailability(ctx context.Context, images []string) (map[string]bool, error)
+	ForceRemoveScenario(ctx context.Context, scenarioID, adminID string) (*types.ForceRemoveScenarioResponse, error)
+	AdminUpdateScenario(ctx context.Context, scenarioID, adminID string, req *types.UpdateScenarioRequest) (*types.ScenarioDetailsResponse, error)
+	ExportScenarios(ctx context.Context, adminID string, w io.Writer) (int, error)
+	ImportScenarios(ctx context.Context, adminID string, r io.Reader) (*types.ImportScenariosResponse, error)
+	ScenarioStats(ctx context.Context) (*types.ScenarioStatsResponse, error)
+	ListUserScenarios(ctx context.Context, userID string) ([]*types.ScenarioStatusResponse, error)
+	ListTerminals(ctx context.Context, userID string) ([]types.ScenarioTerminal, error)
+	ListUserScenariosPage(ctx context.Context, userID, pageToken string, pageSize int) ([]*types.ScenarioStatusResponse, string, error)
+	Heartbeat(ctx context.Context, scenarioID, userID string) (*types.HeartbeatResponse, error)
+	ExtendScenario(ctx context.Context, scenarioID, userID string) (*types.ExtendScenarioResponse, error)
+	GetScenarioResults(ctx context.Context, scenarioID, userID string) (*types.ScenarioResultsResponse, error)
+	DescribeScenario(ctx context.Context, scenarioID, userID string, includeSecrets bool) (*types.ScenarioDetailsResponse, error)
+	UpdateScenario(ctx context.Context, scenarioID, userID string, req *types.UpdateScenarioRequest) (*types.ScenarioDetailsResponse, error)
+	SearchScenarios(ctx context.Context, userID, query, pageToken string, pageSize int) (*types.SearchScenariosResponse, error)
 }
 
 // REST handler
 type Handler struct {
 	Scenario ScenarioManager
+	// EventsPollInterval controls how often /events checks for status changes;
+	// zero uses DefaultEventsPollInterval
+	EventsPollInterval time.Duration
 }
 
 // StartScenarioREST godoc
@@ -35,14 +73,942 @@ type Handler struct {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param request body types.StartScenarioRequest true "Scenario start request"
-// @Success 200 {object} types.StartScenarioResponse
+// @Param request body types.StartScenarioRequest true "Scenario start request"
+// @Success 200 {object} types.StartScenarioResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 422 {object} types.ErrorResponse
+// @Failure 499 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Failure 504 {object} types.ErrorResponse
+// @Router /scenarios/start [post]
+func (h *Handler) StartScenarioREST(c *gin.Context) {
+	var req types.StartScenarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Invalid request format",
+			Code:    "INVALID_REQUEST",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Validate required fields
+	if statusCode, errResp := validateStartScenarioRequest(&req); errResp != nil {
+		c.JSON(statusCode, errResp)
+		return
+	}
+
+	resp, err := h.Scenario.StartScenario(c.Request.Context(), &req)
+	if err != nil {
+		// Determine appropriate HTTP status code based on error type
+		statusCode := http.StatusInternalServerError
+		errorCode := "INTERNAL_ERROR"
+
+		if errors.Is(err, scenario.ErrClientCancelled) {
+			statusCode = StatusClientClosedRequest
+			errorCode = "CLIENT_CLOSED_REQUEST"
+		} else if errors.Is(err, docker.ErrInvalidScenarioType) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_SCENARIO_TYPE"
+		} else if errors.Is(err, scenario.ErrUnknownTemplate) {
+			statusCode = http.StatusBadRequest
+			errorCode = "UNKNOWN_TEMPLATE"
+		} else if errors.Is(err, scenario.ErrInvalidScenarioMode) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_MODE"
+		} else if errors.Is(err, scenario.ErrInvalidOverride) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_CONTAINER_OVERRIDE"
+		} else if errors.Is(err, docker.ErrInvalidEnv) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_ENV"
+		} else if errors.Is(err, docker.ErrInvalidLabel) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_LABEL"
+		} else if errors.Is(err, docker.ErrInvalidLimits) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_LIMITS"
+		} else if errors.Is(err, scenario.ErrInvalidNotes) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_NOTES"
+		} else if errors.Is(err, scenario.ErrScriptTooLarge) {
+			statusCode = http.StatusBadRequest
+			errorCode = "SCRIPT_TOO_LARGE"
+		} else if errors.Is(err, scenario.ErrInvalidScriptTimeout) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_SCRIPT_TIMEOUT"
+		} else if errors.Is(err, scenario.ErrInvalidTTL) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_TTL"
+		} else if errors.Is(err, scenario.ErrProvisioningTimeout) {
+			statusCode = http.StatusGatewayTimeout
+			errorCode = "PROVISIONING_TIMEOUT"
+		} else if errors.Is(err, scenario.ErrCapacityReached) {
+			statusCode = http.StatusServiceUnavailable
+			errorCode = "CAPACITY_REACHED"
+		} else if errors.Is(err, scenario.ErrProvisioningBusy) {
+			statusCode = http.StatusServiceUnavailable
+			errorCode = "PROVISIONING_BUSY"
+		} else if errors.Is(err, docker.ErrPortUnavailable) {
+			statusCode = http.StatusServiceUnavailable
+			errorCode = "PORT_UNAVAILABLE"
+		} else if errors.Is(err, docker.ErrTTYDFailedToStart) {
+			statusCode = http.StatusInternalServerError
+			errorCode = "TTYD_FAILED"
+		} else if errors.Is(err, docker.ErrContainerUserNotFound) {
+			statusCode = http.StatusInternalServerError
+			errorCode = "CONTAINER_USER_NOT_FOUND"
+		} else if errors.Is(err, docker.ErrDockerDaemonUnavailable) {
+			statusCode = http.StatusServiceUnavailable
+			errorCode = "DOCKER_UNAVAILABLE"
+		}
+
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to start scenario",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// validateStartScenarioRequest collects every invalid field of a start request.
+// A single problem keeps its specific error code and returns 400; several
+// problems are reported together with 422.
+func validateStartScenarioRequest(req *types.StartScenarioRequest) (int, *types.ErrorResponse) {
+	var problems []types.ErrorResponse
+
+	if strings.TrimSpace(req.UserID) == "" {
+		problems = append(problems, types.ErrorResponse{
+			Error:   "User ID is required",
+			Code:    "MISSING_USER_ID",
+			Message: "user_id field cannot be empty",
+			Fields:  []types.FieldError{{Field: "user_id", Message: "user_id field cannot be empty"}},
+		})
+	}
+
+	// A template supplies the scenario type when the request leaves it out
+	if strings.TrimSpace(req.ScenarioType) == "" && req.Template == "" {
+		problems = append(problems, types.ErrorResponse{
+			Error:   "Scenario type is required",
+			Code:    "MISSING_SCENARIO_TYPE",
+			Message: "scenario_type field cannot be empty",
+			Fields:  []types.FieldError{{Field: "scenario_type", Message: "scenario_type field cannot be empty"}},
+		})
+	}
+
+	if !req.Mode.Valid() {
+		problems = append(problems, types.ErrorResponse{
+			Error:   "Invalid scenario mode",
+			Code:    "INVALID_MODE",
+			Message: "mode must be interactive or batch",
+			Fields:  []types.FieldError{{Field: "mode", Message: "mode must be interactive or batch"}},
+		})
+	}
+
+	switch len(problems) {
+	case 0:
+		return http.StatusOK, nil
+	case 1:
+		return http.StatusBadRequest, &problems[0]
+	}
+
+	resp := &types.ErrorResponse{
+		Error:   "Request validation failed",
+		Code:    "VALIDATION_FAILED",
+		Message: fmt.Sprintf("%d fields are invalid", len(problems)),
+	}
+	for _, problem := range problems {
+		resp.Fields = append(resp.Fields, problem.Fields...)
+	}
+	return http.StatusUnprocessableEntity, resp
+}
+
+// GetScenarioStatusREST godoc
+// @Summary Get scenario status
+// @Description Get the current status of a scenario
+// @Tags scenarios
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Success 200 {object} types.ScenarioStatusResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /scenarios/{id}/status [get]
+func (h *Handler) GetScenarioStatusREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	resp, err := h.Scenario.GetScenarioStatus(c.Request.Context(), scenarioID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		errorCode := "INTERNAL_ERROR"
+
+		if errors.Is(err, scenario.ErrScenarioNotFound) {
+			statusCode = http.StatusNotFound
+			errorCode = "SCENARIO_NOT_FOUND"
+		} else if errors.Is(err, scenario.ErrInvalidScenarioID) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_SCENARIO_ID"
+		}
+
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to get scenario status",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListTerminalsREST godoc
+// @Summary List the caller's terminals
+// @Description Get the web terminal URL of each of the caller's running scenarios in one call. Other scenarios are skipped. reachable is false when a running scenario's container is gone, paused or not publishing ttyd.
+// @Tags scenarios
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} types.ScenarioTerminal
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Router /scenarios/terminals [get]
+func (h *Handler) ListTerminalsREST(c *gin.Context) {
+	terminals, err := h.Scenario.ListTerminals(c.Request.Context(), UserIDFromContext(c))
+	if err != nil {
+		statusCode, errorCode := http.StatusInternalServerError, "INTERNAL_ERROR"
+		if errors.Is(err, docker.ErrDockerDaemonUnavailable) {
+			statusCode, errorCode = http.StatusServiceUnavailable, "DOCKER_UNAVAILABLE"
+		}
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to list terminals",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, terminals)
+}
+
+// GetTerminalURLREST godoc
+// @Summary Get terminal URL
+// @Description Get the web terminal URL for a scenario
+// @Tags scenarios
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Success 200 {object} types.TerminalURLResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Router /scenarios/{id}/terminal [get]
+func (h *Handler) GetTerminalURLREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	terminalURL, err := h.Scenario.GetTerminalURL(c.Request.Context(), scenarioID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		errorCode := "INTERNAL_ERROR"
+
+		if errors.Is(err, scenario.ErrScenarioNotFound) {
+			statusCode = http.StatusNotFound
+			errorCode = "SCENARIO_NOT_FOUND"
+		} else if errors.Is(err, scenario.ErrScenarioNotRunning) {
+			statusCode = http.StatusConflict
+			errorCode = "SCENARIO_NOT_RUNNING"
+		} else if errors.Is(err, scenario.ErrNoTerminal) {
+			statusCode = http.StatusConflict
+			errorCode = "NO_TERMINAL"
+		} else if errors.Is(err, docker.ErrDockerDaemonUnavailable) {
+			statusCode = http.StatusServiceUnavailable
+			errorCode = "DOCKER_UNAVAILABLE"
+		} else if errors.Is(err, docker.ErrContainerNotFound) {
+			statusCode = http.StatusNotFound
+			errorCode = "CONTAINER_NOT_FOUND"
+		} else if errors.Is(err, docker.ErrContainerNotRunning) {
+			statusCode = http.StatusConflict
+			errorCode = "CONTAINER_NOT_RUNNING"
+		} else if errors.Is(err, scenario.ErrInvalidScenarioID) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_SCENARIO_ID"
+		}
+
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to get terminal URL",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resp := &types.TerminalURLResponse{
+		ScenarioID: scenarioID,
+		URL:        terminalURL,
+		Message:    "Terminal URL retrieved successfully",
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetTerminalCredentialsREST godoc
+// @Summary Get terminal credentials
+// @Description Get the web terminal login for a scenario owned by the caller
+// @Tags scenarios
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Success 200 {object} types.TerminalCredentialsResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /scenarios/{id}/terminal/credentials [get]
+func (h *Handler) GetTerminalCredentialsREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	resp, err := h.Scenario.GetTerminalCredentials(c.Request.Context(), scenarioID, UserIDFromContext(c))
+	if err != nil {
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to get terminal credentials",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RotateTerminalCredentialsREST godoc
+// @Summary Rotate terminal credentials
+// @Description Generate a new web terminal login for a running interactive scenario owned by the caller and restart ttyd. The new login is only stored once ttyd is serving it.
+// @Tags scenarios
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Success 200 {object} types.TerminalCredentialsResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /scenarios/{id}/terminal/credentials [post]
+func (h *Handler) RotateTerminalCredentialsREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	resp, err := h.Scenario.RotateTerminalCredentials(c.Request.Context(), scenarioID, UserIDFromContext(c))
+	if err != nil {
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to rotate terminal credentials",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RebindTerminalPortREST godoc
+// @Summary Move a scenario's terminal to a new host port
+// @Description Allocate a new host port for the web terminal of a running scenario owned by the caller, or of any running scenario for an admin. The container is recreated from a snapshot of its filesystem, so running processes are restarted. The scenario script is not run again. A workspace on tmpfs, which a snapshot does not keep, is refused with 409.
+// @Tags scenarios
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Success 200 {object} types.RebindTerminalResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Router /scenarios/{id}/terminal/rebind [post]
+func (h *Handler) RebindTerminalPortREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	resp, err := h.Scenario.RebindTerminalPort(c.Request.Context(), scenarioID, UserIDFromContext(c), IsAdmin(c))
+	if err != nil {
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
+		if errors.Is(err, docker.ErrPortUnavailable) {
+			statusCode, errorCode = http.StatusServiceUnavailable, "PORT_UNAVAILABLE"
+		} else if errors.Is(err, docker.ErrWorkspaceNotPersistent) {
+			statusCode, errorCode = http.StatusConflict, "WORKSPACE_NOT_PERSISTENT"
+		}
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to rebind terminal port",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CloneScenarioREST godoc
+// @Summary Clone a scenario
+// @Description Start a new scenario with the type, script, mode, name, tags and container settings of one owned by the caller. The clone gets its own container, terminal port and credentials, and belongs to the caller, or to user_id when an admin gives one.
+// @Tags scenarios
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Param request body types.CloneScenarioRequest false "Clone options"
+// @Success 200 {object} types.StartScenarioResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Failure 504 {object} types.ErrorResponse
+// @Router /scenarios/{id}/clone [post]
+func (h *Handler) CloneScenarioREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	// The body is optional; an empty one clones for the caller
+	var req types.CloneScenarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Invalid request format",
+			Code:    "INVALID_REQUEST",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.Scenario.CloneScenario(c.Request.Context(), scenarioID, UserIDFromContext(c), req.UserID, IsAdmin(c))
+	if err != nil {
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
+		if errors.Is(err, scenario.ErrCapacityReached) {
+			statusCode, errorCode = http.StatusServiceUnavailable, "CAPACITY_REACHED"
+		} else if errors.Is(err, scenario.ErrProvisioningBusy) {
+			statusCode, errorCode = http.StatusServiceUnavailable, "PROVISIONING_BUSY"
+		} else if errors.Is(err, docker.ErrPortUnavailable) {
+			statusCode, errorCode = http.StatusServiceUnavailable, "PORT_UNAVAILABLE"
+		} else if errors.Is(err, scenario.ErrProvisioningTimeout) {
+			statusCode, errorCode = http.StatusGatewayTimeout, "PROVISIONING_TIMEOUT"
+		}
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to clone scenario",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// PauseScenarioREST godoc
+// @Summary Pause a scenario
+// @Description Freeze the container of a running scenario owned by the caller so it stops using CPU while keeping its state. A paused scenario still counts towards capacity and still expires, but is not reaped for inactivity. Pausing a paused scenario succeeds without changes.
+// @Tags scenarios
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Success 200 {object} types.PauseScenarioResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Router /scenarios/{id}/pause [post]
+func (h *Handler) PauseScenarioREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	resp, err := h.Scenario.PauseScenario(c.Request.Context(), scenarioID, UserIDFromContext(c))
+	if err != nil {
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to pause scenario",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ResumeScenarioREST godoc
+// @Summary Resume a paused scenario
+// @Description Unfreeze a paused scenario owned by the caller. Resuming a running scenario succeeds without changes.
+// @Tags scenarios
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Success 200 {object} types.PauseScenarioResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Router /scenarios/{id}/resume [post]
+func (h *Handler) ResumeScenarioREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	resp, err := h.Scenario.ResumeScenario(c.Request.Context(), scenarioID, UserIDFromContext(c))
+	if err != nil {
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to resume scenario",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// HeartbeatREST godoc
+// @Summary Record scenario activity
+// @Description Mark a scenario owned by the caller as in use, e.g. while its terminal is being typed in, so inactivity-based cleanup does not reap it. Heartbeats sent more often than the configured interval are rejected with 429.
+// @Tags scenarios
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Success 200 {object} types.HeartbeatResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 429 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /scenarios/{id}/heartbeat [post]
+func (h *Handler) HeartbeatREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	resp, err := h.Scenario.Heartbeat(c.Request.Context(), scenarioID, UserIDFromContext(c))
+	if err != nil {
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
+		if errors.Is(err, scenario.ErrHeartbeatTooFrequent) {
+			statusCode, errorCode = http.StatusTooManyRequests, "HEARTBEAT_TOO_FREQUENT"
+		}
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to record heartbeat",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ExtendScenarioREST godoc
+// @Summary Extend a scenario's lifetime
+// @Description Push the expiry of an active scenario owned by the caller forward so cleanup does not reap it. Extensions are capped at the maximum scenario lifetime.
+// @Tags scenarios
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Success 200 {object} types.ExtendScenarioResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Router /scenarios/{id}/extend [post]
+func (h *Handler) ExtendScenarioREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	resp, err := h.Scenario.ExtendScenario(c.Request.Context(), scenarioID, UserIDFromContext(c))
+	if err != nil {
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to extend scenario",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ExecuteCommandREST godoc
+// @Summary Run a command in a scenario
+// @Description Run a command in a running scenario owned by the caller and return its output. The command is not run through a shell; the deployment's allow- and deny-lists decide which programs may run. A non-zero exit code is reported in the response.
+// @Tags scenarios
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Param request body types.ExecCommandRequest true "Command to run"
+// @Success 200 {object} types.ExecCommandResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Router /scenarios/{id}/exec [post]
+func (h *Handler) ExecuteCommandREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	var req types.ExecCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Invalid request format",
+			Code:    "INVALID_REQUEST",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.Scenario.ExecuteCommand(c.Request.Context(), scenarioID, UserIDFromContext(c), &req)
+	if err != nil {
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
+		if errors.Is(err, scenario.ErrInvalidCommand) {
+			statusCode, errorCode = http.StatusBadRequest, "INVALID_COMMAND"
+		} else if errors.Is(err, scenario.ErrCommandNotAllowed) {
+			statusCode, errorCode = http.StatusForbidden, "COMMAND_NOT_ALLOWED"
+		}
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to run command",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetScenarioResultsREST godoc
+// @Summary Get batch scenario results
+// @Description Get the captured stdout, stderr and exit code of a finished batch scenario owned by the caller
+// @Tags scenarios
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Success 200 {object} types.ScenarioResultsResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Router /scenarios/{id}/results [get]
+func (h *Handler) GetScenarioResultsREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	resp, err := h.Scenario.GetScenarioResults(c.Request.Context(), scenarioID, UserIDFromContext(c))
+	if err != nil {
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to get scenario results",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ownedScenarioErrorStatus maps errors from owner-only scenario operations to HTTP status and error code
+// DescribeScenarioREST godoc
+// @Summary Describe a scenario
+// @Description Get the complete stored record of a scenario owned by the caller. The terminal password is redacted unless include_secrets=true.
+// @Tags scenarios
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Param include_secrets query bool false "Include the terminal password"
+// @Success 200 {object} types.ScenarioDetailsResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /scenarios/{id} [get]
+func (h *Handler) DescribeScenarioREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	includeSecrets := c.Query("include_secrets") == "true"
+	resp, err := h.Scenario.DescribeScenario(c.Request.Context(), scenarioID, UserIDFromContext(c), includeSecrets)
+	if err != nil {
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to describe scenario",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateScenarioREST godoc
+// @Summary Update a scenario
+// @Description Change the notes of a scenario owned by the caller, or lock it so cleanup never removes it. Fields left out of the body are unchanged.
+// @Tags scenarios
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Param request body types.UpdateScenarioRequest true "Fields to change"
+// @Success 200 {object} types.ScenarioDetailsResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /scenarios/{id} [patch]
+func (h *Handler) UpdateScenarioREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	var req types.UpdateScenarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Invalid request format",
+			Code:    "INVALID_REQUEST",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.Scenario.UpdateScenario(c.Request.Context(), scenarioID, UserIDFromContext(c), &req)
+	if err != nil {
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to update scenario",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// SearchScenariosREST godoc
+// @Summary Search scenarios
+// @Description Find the caller's scenarios whose name, tags, notes, or type match a free-text query, best match first
+// @Tags scenarios
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Search text"
+// @Param page_size query int false "Results per page (default 20, max 100)"
+// @Param page_token query string false "Token from a previous page's next_page_token"
+// @Success 200 {object} types.SearchScenariosResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /scenarios/search [get]
+func (h *Handler) SearchScenariosREST(c *gin.Context) {
+	pageSize := 0
+	if raw := c.Query("page_size"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   "Invalid page size",
+				Code:    "INVALID_PAGE_SIZE",
+				Message: "page_size must be a non-negative integer",
+			})
+			return
+		}
+		pageSize = n
+	}
+
+	resp, err := h.Scenario.SearchScenarios(c.Request.Context(), UserIDFromContext(c), c.Query("q"), c.Query("page_token"), pageSize)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		errorCode := "INTERNAL_ERROR"
+		if errors.Is(err, scenario.ErrInvalidSearchQuery) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_QUERY"
+		} else if errors.Is(err, scenario.ErrInvalidPageToken) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_PAGE_TOKEN"
+		}
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to search scenarios",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func ownedScenarioErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, scenario.ErrScenarioNotFound):
+		return http.StatusNotFound, "SCENARIO_NOT_FOUND"
+	case errors.Is(err, scenario.ErrNotScenarioOwner):
+		return http.StatusForbidden, "FORBIDDEN"
+	case errors.Is(err, scenario.ErrNoResults):
+		return http.StatusNotFound, "NO_RESULTS"
+	case errors.Is(err, scenario.ErrResultsNotReady):
+		return http.StatusConflict, "RESULTS_NOT_READY"
+	case errors.Is(err, scenario.ErrScenarioNotRunning):
+		return http.StatusConflict, "SCENARIO_NOT_RUNNING"
+	case errors.Is(err, scenario.ErrNoTerminal):
+		return http.StatusConflict, "NO_TERMINAL"
+	case errors.Is(err, scenario.ErrTerminalRestartFailed):
+		return http.StatusInternalServerError, "TERMINAL_RESTART_FAILED"
+	case errors.Is(err, scenario.ErrScenarioNotPaused):
+		return http.StatusConflict, "SCENARIO_NOT_PAUSED"
+	case errors.Is(err, scenario.ErrMaxLifetimeReached):
+		return http.StatusConflict, "MAX_LIFETIME_REACHED"
+	case errors.Is(err, docker.ErrContainerNotRunning):
+		return http.StatusConflict, "CONTAINER_NOT_RUNNING"
+	case errors.Is(err, docker.ErrDockerDaemonUnavailable):
+		return http.StatusServiceUnavailable, "DOCKER_UNAVAILABLE"
+	case errors.Is(err, scenario.ErrInvalidScenarioID):
+		return http.StatusBadRequest, "INVALID_SCENARIO_ID"
+	case errors.Is(err, scenario.ErrInvalidNotes):
+		return http.StatusBadRequest, "INVALID_NOTES"
+	}
+	return http.StatusInternalServerError, "INTERNAL_ERROR"
+}
+
+// AdminUpdateScenarioREST godoc
+// @Summary Update any scenario
+// @Description Change the notes or lock of any user's scenario, e.g. to keep a long-lived demo scenario from being cleaned up. The change is recorded in the audit log.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Param request body types.UpdateScenarioRequest true "Fields to change"
+// @Success 200 {object} types.ScenarioDetailsResponse
 // @Failure 400 {object} types.ErrorResponse
 // @Failure 401 {object} types.ErrorResponse
-// @Failure 500 {object} types.ErrorResponse
-// @Router /scenarios/start [post]
-func (h *Handler) StartScenarioREST(c *gin.Context) {
-	var req types.StartScenarioRequest
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /admin/scenarios/{id} [patch]
+func (h *Handler) AdminUpdateScenarioREST(c *gin.Context) {
+	var req types.UpdateScenarioRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, types.ErrorResponse{
 			Error:   "Invalid request format",
@@ -52,47 +1018,11 @@ func (h *Handler) StartScenarioREST(c *gin.Context) {
 		return
 	}
 
-	// Validate required fields
-	if strings.TrimSpace(req.UserID) == "" {
-		c.JSON(http.StatusBadRequest, types.ErrorResponse{
-			Error:   "User ID is required",
-			Code:    "MISSING_USER_ID",
-			Message: "user_id field cannot be empty",
-		})
-		return
-	}
-
-	if strings.TrimSpace(req.ScenarioType) == "" {
-		c.JSON(http.StatusBadRequest, types.ErrorResponse{
-			Error:   "Scenario type is required",
-			Code:    "MISSING_SCENARIO_TYPE",
-			Message: "scenario_type field cannot be empty",
-		})
-		return
-	}
-
-	resp, err := h.Scenario.StartScenario(c.Request.Context(), &req)
+	resp, err := h.Scenario.AdminUpdateScenario(c.Request.Context(), c.Param("id"), UserIDFromContext(c), &req)
 	if err != nil {
-		// Determine appropriate HTTP status code based on error type
-		statusCode := http.StatusInternalServerError
-		errorCode := "INTERNAL_ERROR"
-
-		if errors.Is(err, docker.ErrInvalidScenarioType) {
-			statusCode = http.StatusBadRequest
-			errorCode = "INVALID_SCENARIO_TYPE"
-		} else if errors.Is(err, docker.ErrPortUnavailable) {
-			statusCode = http.StatusServiceUnavailable
-			errorCode = "PORT_UNAVAILABLE"
-		} else if errors.Is(err, docker.ErrTTYDFailedToStart) {
-			statusCode = http.StatusInternalServerError
-			errorCode = "TTYD_FAILED"
-		} else if errors.Is(err, docker.ErrDockerDaemonUnavailable) {
-			statusCode = http.StatusServiceUnavailable
-			errorCode = "DOCKER_UNAVAILABLE"
-		}
-
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
 		c.JSON(statusCode, types.ErrorResponse{
-			Error:   "Failed to start scenario",
+			Error:   "Failed to update scenario",
 			Code:    errorCode,
 			Message: err.Error(),
 		})
@@ -102,19 +1032,20 @@ func (h *Handler) StartScenarioREST(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-// GetScenarioStatusREST godoc
-// @Summary Get scenario status
-// @Description Get the current status of a scenario
-// @Tags scenarios
+// ForceRemoveScenarioREST godoc
+// @Summary Force-remove a stuck scenario
+// @Description Admin only. Remove the scenario's container if it still exists, ignoring errors, and mark the scenario cleaned up whatever its current status. The action is recorded in the audit log.
+// @Tags admin
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Scenario ID"
-// @Success 200 {object} types.ScenarioStatusResponse
+// @Success 200 {object} types.ForceRemoveScenarioResponse
 // @Failure 400 {object} types.ErrorResponse
 // @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
 // @Failure 404 {object} types.ErrorResponse
-// @Router /scenarios/{id}/status [get]
-func (h *Handler) GetScenarioStatusREST(c *gin.Context) {
+// @Router /admin/scenarios/{id}/force-remove [post]
+func (h *Handler) ForceRemoveScenarioREST(c *gin.Context) {
 	scenarioID := c.Param("id")
 	if scenarioID == "" {
 		c.JSON(http.StatusBadRequest, types.ErrorResponse{
@@ -125,21 +1056,11 @@ func (h *Handler) GetScenarioStatusREST(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.Scenario.GetScenarioStatus(c.Request.Context(), scenarioID)
+	resp, err := h.Scenario.ForceRemoveScenario(c.Request.Context(), scenarioID, UserIDFromContext(c))
 	if err != nil {
-		statusCode := http.StatusInternalServerError
-		errorCode := "INTERNAL_ERROR"
-
-		if errors.Is(err, scenario.ErrScenarioNotFound) {
-			statusCode = http.StatusNotFound
-			errorCode = "SCENARIO_NOT_FOUND"
-		} else if errors.Is(err, scenario.ErrInvalidScenarioID) {
-			statusCode = http.StatusBadRequest
-			errorCode = "INVALID_SCENARIO_ID"
-		}
-
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
 		c.JSON(statusCode, types.ErrorResponse{
-			Error:   "Failed to get scenario status",
+			Error:   "Failed to force remove scenario",
 			Code:    errorCode,
 			Message: err.Error(),
 		})
@@ -149,70 +1070,92 @@ func (h *Handler) GetScenarioStatusREST(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-// GetTerminalURLREST godoc
-// @Summary Get terminal URL
-// @Description Get the web terminal URL for a scenario
-// @Tags scenarios
+// ScenarioStatsREST godoc
+// @Summary Get scenario statistics
+// @Description Admin only. Summarise every stored scenario: counts by status, by type and by user, and the running scenario that was created first.
+// @Tags admin
 // @Produce json
 // @Security BearerAuth
-// @Param id path string true "Scenario ID"
-// @Success 200 {object} types.TerminalURLResponse
-// @Failure 400 {object} types.ErrorResponse
+// @Success 200 {object} types.ScenarioStatsResponse
 // @Failure 401 {object} types.ErrorResponse
-// @Failure 404 {object} types.ErrorResponse
-// @Router /scenarios/{id}/terminal [get]
-func (h *Handler) GetTerminalURLREST(c *gin.Context) {
-	scenarioID := c.Param("id")
-	if scenarioID == "" {
-		c.JSON(http.StatusBadRequest, types.ErrorResponse{
-			Error:   "Scenario ID is required",
-			Code:    "MISSING_SCENARIO_ID",
-			Message: "scenario ID parameter cannot be empty",
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /admin/scenarios/stats [get]
+func (h *Handler) ScenarioStatsREST(c *gin.Context) {
+	resp, err := h.Scenario.ScenarioStats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, types.ErrorResponse{
+			Error:   "Failed to get scenario stats",
+			Code:    "INTERNAL_ERROR",
+			Message: err.Error(),
 		})
 		return
 	}
 
-	terminalURL, err := h.Scenario.GetTerminalURL(c.Request.Context(), scenarioID)
-	if err != nil {
-		statusCode := http.StatusInternalServerError
-		errorCode := "INTERNAL_ERROR"
+	c.JSON(http.StatusOK, resp)
+}
 
-		if errors.Is(err, scenario.ErrScenarioNotFound) {
-			statusCode = http.StatusNotFound
-			errorCode = "SCENARIO_NOT_FOUND"
-		} else if errors.Is(err, scenario.ErrScenarioNotRunning) {
-			statusCode = http.StatusConflict
-			errorCode = "SCENARIO_NOT_RUNNING"
-		} else if errors.Is(err, docker.ErrContainerNotFound) {
-			statusCode = http.StatusNotFound
-			errorCode = "CONTAINER_NOT_FOUND"
-		} else if errors.Is(err, docker.ErrContainerNotRunning) {
-			statusCode = http.StatusConflict
-			errorCode = "CONTAINER_NOT_RUNNING"
-		} else if errors.Is(err, scenario.ErrInvalidScenarioID) {
-			statusCode = http.StatusBadRequest
-			errorCode = "INVALID_SCENARIO_ID"
+// ExportScenariosREST godoc
+// @Summary Export all scenarios
+// @Description Admin only. Stream every stored scenario, oldest first, as one extended JSON document per line, for backup or migration with the import endpoint. The export is recorded in the audit log.
+// @Tags admin
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Success 200 {string} string "One scenario document per line"
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /admin/scenarios/export [get]
+func (h *Handler) ExportScenariosREST(c *gin.Context) {
+	c.Header("Content-Type", "application/x-ndjson")
+	exported, err := h.Scenario.ExportScenarios(c.Request.Context(), UserIDFromContext(c), c.Writer)
+	if err != nil {
+		if c.Writer.Written() {
+			// The status is already sent; the client sees a truncated export
+			log.Printf("[api] scenario export failed after %d scenarios: %v", exported, err)
+			return
 		}
-
-		c.JSON(statusCode, types.ErrorResponse{
-			Error:   "Failed to get terminal URL",
-			Code:    errorCode,
+		c.JSON(http.StatusInternalServerError, types.ErrorResponse{
+			Error:   "Failed to export scenarios",
+			Code:    "EXPORT_FAILED",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	resp := &types.TerminalURLResponse{
-		ScenarioID: scenarioID,
-		URL:        terminalURL,
-		Message:    "Terminal URL retrieved successfully",
+	c.Status(http.StatusOK)
+}
+
+// ImportScenariosREST godoc
+// @Summary Import scenarios
+// @Description Admin only. Upsert, by scenario ID, the scenarios in the body, one extended JSON document per line as produced by the export endpoint. Container IDs and terminal ports are checked against this host: scenarios whose container is missing lose them and, if active, are stopped as orphaned. Lines that cannot be imported are reported and skipped. The import is recorded in the audit log.
+// @Tags admin
+// @Accept application/x-ndjson
+// @Produce json
+// @Security BearerAuth
+// @Param request body string true "One scenario document per line"
+// @Success 200 {object} types.ImportScenariosResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /admin/scenarios/import [post]
+func (h *Handler) ImportScenariosREST(c *gin.Context) {
+	resp, err := h.Scenario.ImportScenarios(c.Request.Context(), UserIDFromContext(c), c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, types.ErrorResponse{
+			Error:   "Failed to import scenarios",
+			Code:    "IMPORT_FAILED",
+			Message: err.Error(),
+		})
+		return
 	}
+
 	c.JSON(http.StatusOK, resp)
 }
 
 // StopScenarioREST godoc
 // @Summary Stop a scenario
-// @Description Stop and clean up a running scenario
+// @Description Stop and clean up a running scenario. Stopping an already stopped scenario succeeds without changes.
 // @Tags scenarios
 // @Security BearerAuth
 // @Param id path string true "Scenario ID"
@@ -267,6 +1210,47 @@ func (h *Handler) StopScenarioREST(c *gin.Context) {
 	})
 }
 
+// StopScenariosREST godoc
+// @Summary Stop several scenarios
+// @Description Stop up to 100 of the caller's scenarios at once. Stops run concurrently, bounded by the server's MAX_CONCURRENT_STOPS, and each succeeds or fails on its own: scenarios that could not be stopped, including ones that do not exist or belong to another user, are reported in failed. Already stopped scenarios count as stopped.
+// @Tags scenarios
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body types.StopScenariosRequest true "Scenarios to stop"
+// @Success 200 {object} types.StopScenariosResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /scenarios/stop [post]
+func (h *Handler) StopScenariosREST(c *gin.Context) {
+	var req types.StopScenariosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Invalid request format",
+			Code:    "INVALID_REQUEST",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.Scenario.StopScenarios(c.Request.Context(), UserIDFromContext(c), req.ScenarioIDs)
+	if err != nil {
+		statusCode, errorCode := http.StatusInternalServerError, "INTERNAL_ERROR"
+		if errors.Is(err, scenario.ErrInvalidBulkStop) {
+			statusCode, errorCode = http.StatusBadRequest, "INVALID_BULK_STOP"
+		}
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to stop scenarios",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 // GetDirectoryStructureREST godoc
 // @Summary Get directory structure
 // @Description Get the file and directory structure for a scenario
@@ -274,32 +1258,75 @@ func (h *Handler) StopScenarioREST(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Scenario ID"
+// @Param format query string false "Response shape: flat lists every node, nested embeds children" Enums(flat, nested) default(flat)
 // @Success 200 {object} types.DirectoryStructureResponse
 // @Failure 400 {object} types.ErrorResponse
 // @Failure 401 {object} types.ErrorResponse
 // @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
 // @Router /scenarios/{id}/directory [get]
 func (h *Handler) GetDirectoryStructureREST(c *gin.Context) {
 	scenarioID := c.Param("id")
 	if scenarioID == "" {
-		c.JSON(400, gin.H{
-			"error": "scenario ID cannot be empty",
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	format := types.DirectoryFormat(c.DefaultQuery("format", string(types.DirectoryFormatFlat)))
+	if !format.Valid() {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Invalid directory format",
+			Code:    "INVALID_FORMAT",
+			Message: "format must be flat or nested",
 		})
 		return
 	}
 
-	resp, err := h.Scenario.GetDirectoryStructure(c.Request.Context(), scenarioID)
+	resp, err := h.Scenario.GetDirectoryStructure(c.Request.Context(), scenarioID, format)
 	if err != nil {
-		c.JSON(500, gin.H{
-			"error": err.Error(),
+		statusCode := http.StatusInternalServerError
+		errorCode := "INTERNAL_ERROR"
+
+		if errors.Is(err, scenario.ErrScenarioNotFound) {
+			statusCode = http.StatusNotFound
+			errorCode = "SCENARIO_NOT_FOUND"
+		} else if errors.Is(err, scenario.ErrScenarioNotRunning) {
+			statusCode = http.StatusConflict
+			errorCode = "SCENARIO_NOT_RUNNING"
+		} else if errors.Is(err, docker.ErrContainerNotRunning) {
+			statusCode = http.StatusConflict
+			errorCode = "CONTAINER_NOT_RUNNING"
+		} else if errors.Is(err, docker.ErrDockerDaemonUnavailable) {
+			statusCode = http.StatusServiceUnavailable
+			errorCode = "DOCKER_UNAVAILABLE"
+		} else if errors.Is(err, scenario.ErrInvalidScenarioID) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_SCENARIO_ID"
+		} else if errors.Is(err, scenario.ErrInvalidDirectoryFormat) {
+			statusCode = http.StatusBadRequest
+			errorCode = "INVALID_FORMAT"
+		}
+
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to get directory structure",
+			Code:    errorCode,
+			Message: err.Error(),
 		})
 		return
 	}
 
-	c.JSON(200, resp)
+	c.JSON(http.StatusOK, resp)
 }
 
-// GetScenarioTypesREST returns information about available scenario types
+// GetScenarioTypesREST returns information about available scenario types.
+// With ?check=true each type is annotated with image_available, reporting
+// whether its image is present on the Docker host.
 func (h *Handler) GetScenarioTypesREST(c *gin.Context) {
 	scenarioTypes := []map[string]interface{}{
 		{
@@ -358,6 +1385,33 @@ func (h *Handler) GetScenarioTypesREST(c *gin.Context) {
 		},
 	}
 
+	if c.Query("check") == "true" {
+		images := make([]string, 0, len(scenarioTypes))
+		for _, scenarioType := range scenarioTypes {
+			images = append(images, scenarioType["image"].(string))
+		}
+
+		available, err := h.Scenario.ImageAvailability(c.Request.Context(), images)
+		if err != nil {
+			statusCode := http.StatusInternalServerError
+			errorCode := "IMAGE_CHECK_FAILED"
+			if errors.Is(err, docker.ErrDockerDaemonUnavailable) {
+				statusCode = http.StatusServiceUnavailable
+				errorCode = "DOCKER_UNAVAILABLE"
+			}
+			c.JSON(statusCode, types.ErrorResponse{
+				Error:   "Failed to check scenario images",
+				Code:    errorCode,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		for _, scenarioType := range scenarioTypes {
+			scenarioType["image_available"] = available[scenarioType["image"].(string)]
+		}
+	}
+
 	c.JSON(200, gin.H{
 		"scenario_types":   scenarioTypes,
 		"message":          "Available scenario types retrieved successfully",
@@ -372,72 +1426,73 @@ func (h *Handler) GetScenarioTypesREST(c *gin.Context) {
 type GRPCServer struct {
 	pb.UnimplementedScenarioServiceServer
 	Scenario ScenarioManager
+	// WatchPollInterval controls how often WatchUserScenarios re-reads the
+	// caller's scenarios; zero uses DefaultEventsPollInterval
+	WatchPollInterval time.Duration
 }
 
+// StartScenario starts a scenario owned by the caller authenticated by the JWT
+// interceptors. A user_id naming anyone else is rejected.
 func (s *GRPCServer) StartScenario(ctx context.Context, req *pb.StartScenarioRequest) (*pb.StartScenarioResponse, error) {
+	userID := UserIDFromGRPCContext(ctx)
+	if userID == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "authentication required")
+	}
+	if req.UserId != "" && req.UserId != userID {
+		return nil, grpcStatusError(codes.PermissionDenied, "FORBIDDEN", "cannot start a scenario for another user", nil)
+	}
 	internalReq := &types.StartScenarioRequest{
-		UserID:       req.UserId,
-		ScenarioType: req.ScenarioType,
-		Script:       req.Script,
+		UserID:         userID,
+		ScenarioType:   req.ScenarioType,
+		Script:         req.Script,
+		WaitForRunning: req.WaitForRunning,
 	}
 	resp, err := s.Scenario.StartScenario(ctx, internalReq)
 	if err != nil {
-		errMsg := err.Error()
-		switch {
-		case strings.Contains(errMsg, "invalid scenario type"):
-			return nil, status.Errorf(codes.InvalidArgument, errMsg)
-		case strings.Contains(errMsg, "port already in use"):
-			return nil, status.Errorf(codes.Internal, errMsg)
-		case strings.Contains(errMsg, "container not found"):
-			return nil, status.Errorf(codes.Internal, errMsg)
-		case strings.Contains(errMsg, "database connection failed"):
-			return nil, status.Errorf(codes.Internal, errMsg)
-		default:
-			return nil, status.Errorf(codes.Internal, errMsg)
-		}
+		return nil, grpcError(err, map[string]string{"scenario_type": req.ScenarioType})
 	}
-	return &pb.StartScenarioResponse{
+	pbResp := &pb.StartScenarioResponse{
 		ScenarioId: resp.ScenarioID,
-		Status:     resp.Status,
-	}, nil
+		Status:     string(resp.Status),
+	}
+	if resp.TerminalURL != nil {
+		pbResp.TerminalUrl = *resp.TerminalURL
+	}
+	if resp.TerminalCredentials != nil {
+		pbResp.TerminalCredentials = &pb.TerminalCredentials{
+			Username: resp.TerminalCredentials.Username,
+			Password: resp.TerminalCredentials.Password,
+		}
+	}
+	return pbResp, nil
 }
 
 func (s *GRPCServer) GetScenarioStatus(ctx context.Context, req *pb.GetScenarioStatusRequest) (*pb.GetScenarioStatusResponse, error) {
+	if err := s.authorizeScenario(ctx, req.ScenarioId); err != nil {
+		return nil, err
+	}
 	resp, err := s.Scenario.GetScenarioStatus(ctx, req.ScenarioId)
 	if err != nil {
-		errMsg := err.Error()
-		switch {
-		case strings.Contains(errMsg, "scenario not found"):
-			return nil, status.Errorf(codes.NotFound, errMsg)
-		case strings.Contains(errMsg, "database connection failed"):
-			return nil, status.Errorf(codes.Internal, errMsg)
-		default:
-			return nil, status.Errorf(codes.Internal, errMsg)
-		}
+		return nil, grpcError(err, map[string]string{"scenario_id": req.ScenarioId})
 	}
 	return &pb.GetScenarioStatusResponse{
 		ScenarioId:      resp.ScenarioID,
 		UserId:          resp.UserID,
 		ScenarioType:    resp.ScenarioType,
 		ContainerId:     resp.ContainerID,
-		Status:          resp.Status,
+		Status:          string(resp.Status),
 		ContainerStatus: resp.ContainerStatus,
 		Message:         resp.Message,
 	}, nil
 }
 
 func (s *GRPCServer) GetTerminalURL(ctx context.Context, req *pb.GetTerminalURLRequest) (*pb.GetTerminalURLResponse, error) {
+	if err := s.authorizeScenario(ctx, req.ScenarioId); err != nil {
+		return nil, err
+	}
 	terminalURL, err := s.Scenario.GetTerminalURL(ctx, req.ScenarioId)
 	if err != nil {
-		errMsg := err.Error()
-		switch {
-		case strings.Contains(errMsg, "scenario not found"):
-			return nil, status.Errorf(codes.NotFound, errMsg)
-		case strings.Contains(errMsg, "container not running"):
-			return nil, status.Errorf(codes.FailedPrecondition, errMsg)
-		default:
-			return nil, status.Errorf(codes.Internal, errMsg)
-		}
+		return nil, grpcError(err, map[string]string{"scenario_id": req.ScenarioId})
 	}
 	return &pb.GetTerminalURLResponse{
 		ScenarioId: req.ScenarioId,
@@ -448,20 +1503,15 @@ func (s *GRPCServer) GetTerminalURL(ctx context.Context, req *pb.GetTerminalURLR
 
 func (s *GRPCServer) StopScenario(ctx context.Context, req *pb.StopScenarioRequest) (*pb.StopScenarioResponse, error) {
 	if req.ScenarioId == "" {
-		return nil, status.Errorf(codes.InvalidArgument, "scenario ID cannot be empty")
+		return nil, grpcStatusError(codes.InvalidArgument, "MISSING_SCENARIO_ID", "scenario ID cannot be empty", nil)
+	}
+	if err := s.authorizeScenario(ctx, req.ScenarioId); err != nil {
+		return nil, err
 	}
 
 	err := s.Scenario.StopScenario(ctx, req.ScenarioId)
 	if err != nil {
-		errMsg := err.Error()
-		switch {
-		case strings.Contains(errMsg, "scenario not found"):
-			return nil, status.Errorf(codes.NotFound, errMsg)
-		case strings.Contains(errMsg, "scenario already stopped"):
-			return nil, status.Errorf(codes.FailedPrecondition, errMsg)
-		default:
-			return nil, status.Errorf(codes.Internal, errMsg)
-		}
+		return nil, grpcError(err, map[string]string{"scenario_id": req.ScenarioId})
 	}
 
 	return &pb.StopScenarioResponse{
@@ -470,15 +1520,12 @@ func (s *GRPCServer) StopScenario(ctx context.Context, req *pb.StopScenarioReque
 }
 
 func (s *GRPCServer) GetDirectoryStructure(ctx context.Context, req *pb.GetDirectoryStructureRequest) (*pb.GetDirectoryStructureResponse, error) {
-	resp, err := s.Scenario.GetDirectoryStructure(ctx, req.ScenarioId)
+	if err := s.authorizeScenario(ctx, req.ScenarioId); err != nil {
+		return nil, err
+	}
+	resp, err := s.Scenario.GetDirectoryStructure(ctx, req.ScenarioId, types.DirectoryFormatFlat)
 	if err != nil {
-		errMsg := err.Error()
-		switch {
-		case strings.Contains(errMsg, "scenario not found"):
-			return nil, status.Errorf(codes.NotFound, errMsg)
-		default:
-			return nil, status.Errorf(codes.Internal, errMsg)
-		}
+		return nil, grpcError(err, map[string]string{"scenario_id": req.ScenarioId})
 	}
 
 	// Map internal FileNode to proto FileNode
@@ -503,3 +1550,114 @@ func (s *GRPCServer) GetDirectoryStructure(ctx context.Context, req *pb.GetDirec
 		Message:    resp.Message,
 	}, nil
 }
+
+// authorizeScenario checks that the caller authenticated by the JWT
+// interceptors owns scenarioID, so a valid token for one user cannot read,
+// open or stop another user's scenarios
+func (s *GRPCServer) authorizeScenario(ctx context.Context, scenarioID string) error {
+	userID := UserIDFromGRPCContext(ctx)
+	if userID == "" {
+		return status.Errorf(codes.Unauthenticated, "authentication required")
+	}
+	if _, err := s.Scenario.DescribeScenario(ctx, scenarioID, userID, false); err != nil {
+		return grpcError(err, map[string]string{"scenario_id": scenarioID})
+	}
+	return nil
+}
+
+// ListScenarios pages through the scenarios owned by the caller authenticated by
+// the JWT interceptors
+func (s *GRPCServer) ListScenarios(ctx context.Context, req *pb.ListScenariosRequest) (*pb.ListScenariosResponse, error) {
+	userID := UserIDFromGRPCContext(ctx)
+	if userID == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "authentication required")
+	}
+	if req.PageSize < 0 {
+		return nil, grpcStatusError(codes.InvalidArgument, "INVALID_PAGE_SIZE", "page size cannot be negative", nil)
+	}
+
+	scenarios, next, err := s.Scenario.ListUserScenariosPage(ctx, userID, req.PageToken, int(req.PageSize))
+	if err != nil {
+		return nil, grpcError(err, nil)
+	}
+
+	resp := &pb.ListScenariosResponse{NextPageToken: next}
+	for _, sc := range scenarios {
+		resp.Scenarios = append(resp.Scenarios, scenarioSummary(sc))
+	}
+	return resp, nil
+}
+
+// WatchUserScenarios streams the caller's scenarios: a snapshot when the stream
+// opens, then, after every poll that finds a difference, the scenarios that
+// were added or changed and the IDs of those that disappeared. The stream ends
+// when the client cancels.
+func (s *GRPCServer) WatchUserScenarios(req *pb.WatchUserScenariosRequest, stream pb.ScenarioService_WatchUserScenariosServer) error {
+	ctx := stream.Context()
+	userID := UserIDFromGRPCContext(ctx)
+	if userID == "" {
+		return status.Errorf(codes.Unauthenticated, "authentication required")
+	}
+
+	interval := s.WatchPollInterval
+	if interval <= 0 {
+		interval = DefaultEventsPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last map[string]*pb.ScenarioSummary
+	for {
+		scenarios, err := s.Scenario.ListUserScenarios(ctx, userID)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return status.FromContextError(ctx.Err()).Err()
+		case err != nil && last == nil:
+			return grpcError(err, nil)
+		case err != nil:
+			// Keep the stream open; the next poll may succeed
+			log.Printf("[api] failed to list scenarios for user %s: %v", userID, err)
+		default:
+			current := make(map[string]*pb.ScenarioSummary, len(scenarios))
+			resp := &pb.WatchUserScenariosResponse{Snapshot: last == nil}
+			for _, sc := range scenarios {
+				summary := scenarioSummary(sc)
+				current[sc.ScenarioID] = summary
+				if prev, ok := last[sc.ScenarioID]; !ok || !proto.Equal(prev, summary) {
+					resp.Scenarios = append(resp.Scenarios, summary)
+				}
+			}
+			for id := range last {
+				if _, ok := current[id]; !ok {
+					resp.RemovedScenarioIds = append(resp.RemovedScenarioIds, id)
+				}
+			}
+			sort.Strings(resp.RemovedScenarioIds)
+
+			if resp.Snapshot || len(resp.Scenarios) > 0 || len(resp.RemovedScenarioIds) > 0 {
+				if err := stream.Send(resp); err != nil {
+					return err
+				}
+			}
+			last = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// scenarioSummary maps a scenario status to its gRPC summary
+func scenarioSummary(sc *types.ScenarioStatusResponse) *pb.ScenarioSummary {
+	return &pb.ScenarioSummary{
+		ScenarioId:   sc.ScenarioID,
+		UserId:       sc.UserID,
+		ScenarioType: sc.ScenarioType,
+		ContainerId:  sc.ContainerID,
+		Status:       string(sc.Status),
+		StopReason:   string(sc.StopReason),
+	}
+}
@@ -1,15 +1,23 @@
 package api
 
 import (
+	"context"
+	"devlab/internal/docker"
+	"devlab/internal/scenario"
+	"devlab/internal/storage"
 	"devlab/internal/types"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -36,8 +44,30 @@ func TestStartScenarioREST(t *testing.T) {
 			mockError:      nil,
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
-				"scenario_id": "scn-123",
-				"status":      "provisioning",
+				"scenario_id":          "scn-123",
+				"status":               "provisioning",
+				"terminal_url":         nil,
+				"terminal_credentials": nil,
+			},
+		},
+		{
+			name:        "running_start_includes_terminal",
+			requestBody: `{"user_id": "test-user", "scenario_type": "go", "wait_for_running": true}`,
+			mockResponse: &types.StartScenarioResponse{
+				ScenarioID:          "scn-123",
+				Status:              "running",
+				TerminalURL:         func() *string { u := "http://localhost:3001"; return &u }(),
+				TerminalCredentials: &types.TerminalCredentials{Username: "devlab", Password: "secret"},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"scenario_id":  "scn-123",
+				"status":       "running",
+				"terminal_url": "http://localhost:3001",
+				"terminal_credentials": map[string]interface{}{
+					"username": "devlab",
+					"password": "secret",
+				},
 			},
 		},
 		{
@@ -64,6 +94,63 @@ func TestStartScenarioREST(t *testing.T) {
 				"message": "scenario_type field cannot be empty",
 			},
 		},
+		{
+			name:        "template_supplies_scenario_type",
+			requestBody: `{"user_id": "test-user", "template": "intro-go-lab"}`,
+			mockResponse: &types.StartScenarioResponse{
+				ScenarioID: "scn-123",
+				Status:     "provisioning",
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"scenario_id": "scn-123",
+				"status":      "provisioning",
+			},
+		},
+		{
+			name:           "unknown_template",
+			requestBody:    `{"user_id": "test-user", "template": "advanced-rust"}`,
+			mockResponse:   nil,
+			mockError:      fmt.Errorf("%w: %q", scenario.ErrUnknownTemplate, "advanced-rust"),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to start scenario",
+				"code":  "UNKNOWN_TEMPLATE",
+			},
+		},
+		{
+			name:           "client_cancelled",
+			requestBody:    `{"user_id": "test-user", "scenario_type": "go"}`,
+			mockResponse:   nil,
+			mockError:      fmt.Errorf("%w: context canceled", scenario.ErrClientCancelled),
+			expectedStatus: StatusClientClosedRequest,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to start scenario",
+				"code":  "CLIENT_CLOSED_REQUEST",
+			},
+		},
+		{
+			name:           "capacity_reached",
+			requestBody:    `{"user_id": "test-user", "scenario_type": "go"}`,
+			mockResponse:   nil,
+			mockError:      fmt.Errorf("%w: limit is 10", scenario.ErrCapacityReached),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to start scenario",
+				"code":  "CAPACITY_REACHED",
+			},
+		},
+		{
+			name:           "provisioning_timeout",
+			requestBody:    `{"user_id": "test-user", "scenario_type": "go"}`,
+			mockResponse:   nil,
+			mockError:      fmt.Errorf("%w: scenario scn-1 was not provisioned within 4m0s", scenario.ErrProvisioningTimeout),
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to start scenario",
+				"code":  "PROVISIONING_TIMEOUT",
+			},
+		},
 		{
 			name:           "invalid_json",
 			requestBody:    `{"user_id": "test-user", "scenario_type": "go"`,
@@ -122,6 +209,72 @@ func TestStartScenarioREST(t *testing.T) {
 	}
 }
 
+func TestStartScenarioREST_ValidationErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		requestBody    string
+		expectedStatus int
+		expectedCode   string
+		expectedFields []types.FieldError
+	}{
+		{
+			name:           "missing_both_fields",
+			requestBody:    `{"script": "echo hi"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "VALIDATION_FAILED",
+			expectedFields: []types.FieldError{
+				{Field: "user_id", Message: "user_id field cannot be empty"},
+				{Field: "scenario_type", Message: "scenario_type field cannot be empty"},
+			},
+		},
+		{
+			name:           "missing_single_field",
+			requestBody:    `{"user_id": "test-user", "scenario_type": "  "}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "MISSING_SCENARIO_TYPE",
+			expectedFields: []types.FieldError{
+				{Field: "scenario_type", Message: "scenario_type field cannot be empty"},
+			},
+		},
+		{
+			name:           "invalid_mode",
+			requestBody:    `{"user_id": "test-user", "scenario_type": "go", "mode": "once"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_MODE",
+			expectedFields: []types.FieldError{
+				{Field: "mode", Message: "mode must be interactive or batch"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.POST("/scenarios/start", handler.StartScenarioREST)
+
+			req, _ := http.NewRequest("POST", "/scenarios/start", strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response types.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedCode, response.Code)
+			assert.Equal(t, tt.expectedFields, response.Fields)
+
+			mockManager.AssertNotCalled(t, "StartScenario", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 func TestGetScenarioStatusREST(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -149,6 +302,21 @@ func TestGetScenarioStatusREST(t *testing.T) {
 				"status":      "running",
 			},
 		},
+		{
+			name:       "docker_unavailable_returns_stored_status",
+			scenarioID: "scn-123",
+			mockResponse: &types.ScenarioStatusResponse{
+				ScenarioID: "scn-123",
+				UserID:     "test-user",
+				Status:     "running",
+				Message:    "Container status unavailable",
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"status":  "running",
+				"message": "Container status unavailable",
+			},
+		},
 		{
 			name:           "empty_scenario_id",
 			scenarioID:     "",
@@ -205,6 +373,72 @@ func TestGetScenarioStatusREST(t *testing.T) {
 	}
 }
 
+func TestGetTerminalURLREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		mockURL        string
+		mockError      error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:           "successful_url",
+			mockURL:        "http://localhost:3001",
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"scenario_id": "scn-123",
+				"url":         "http://localhost:3001",
+			},
+		},
+		{
+			name:           "docker_unavailable",
+			mockError:      fmt.Errorf("failed to verify container: %w: connection refused", docker.ErrDockerDaemonUnavailable),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to get terminal URL",
+				"code":  "DOCKER_UNAVAILABLE",
+			},
+		},
+		{
+			name:           "container_not_found",
+			mockError:      fmt.Errorf("failed to get terminal URL: %w: no such container", docker.ErrContainerNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedBody: map[string]interface{}{
+				"code": "CONTAINER_NOT_FOUND",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			mockManager.On("GetTerminalURL", mock.Anything, "scn-123").Return(tt.mockURL, tt.mockError)
+
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.GET("/scenarios/:id/terminal", handler.GetTerminalURLREST)
+
+			req, _ := http.NewRequest("GET", "/scenarios/scn-123/terminal", nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for key, expectedValue := range tt.expectedBody {
+				assert.Equal(t, expectedValue, response[key], "Field %s should match", key)
+			}
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
 func TestStopScenarioREST(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -291,3 +525,1740 @@ func TestStopScenarioREST(t *testing.T) {
 		})
 	}
 }
+
+// withUser stands in for JWTAuthMiddleware by setting the authenticated user ID
+func withUser(userID string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID != "" {
+			c.Set(ContextUserIDKey, userID)
+		}
+		c.Next()
+	}
+}
+
+// withRole stores validated claims carrying role, as JWTAuthMiddleware would
+func withRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("jwt_claims", jwt.MapClaims{"role": role})
+		c.Next()
+	}
+}
+
+func TestTerminalCredentialsREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	credentials := &types.TerminalCredentialsResponse{
+		ScenarioID: "scn-123",
+		Username:   "devlab",
+		Password:   "s3cret",
+		Message:    "Terminal credentials retrieved successfully",
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		managerMethod  string
+		userID         string
+		mockResponse   *types.TerminalCredentialsResponse
+		mockError      error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:           "owner_gets_credentials",
+			method:         "GET",
+			managerMethod:  "GetTerminalCredentials",
+			userID:         "owner-user",
+			mockResponse:   credentials,
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"scenario_id": "scn-123",
+				"username":    "devlab",
+				"password":    "s3cret",
+			},
+		},
+		{
+			name:           "non_owner_rejected",
+			method:         "GET",
+			managerMethod:  "GetTerminalCredentials",
+			userID:         "other-user",
+			mockError:      fmt.Errorf("%w: scn-123", scenario.ErrNotScenarioOwner),
+			expectedStatus: http.StatusForbidden,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to get terminal credentials",
+				"code":  "FORBIDDEN",
+			},
+		},
+		{
+			name:           "owner_rotates_credentials",
+			method:         "POST",
+			managerMethod:  "RotateTerminalCredentials",
+			userID:         "owner-user",
+			mockResponse:   credentials,
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"username": "devlab",
+				"password": "s3cret",
+			},
+		},
+		{
+			name:           "non_owner_rotation_rejected",
+			method:         "POST",
+			managerMethod:  "RotateTerminalCredentials",
+			userID:         "other-user",
+			mockError:      fmt.Errorf("%w: scn-123", scenario.ErrNotScenarioOwner),
+			expectedStatus: http.StatusForbidden,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to rotate terminal credentials",
+				"code":  "FORBIDDEN",
+			},
+		},
+		{
+			name:           "rotation_with_docker_unavailable",
+			method:         "POST",
+			managerMethod:  "RotateTerminalCredentials",
+			userID:         "owner-user",
+			mockError:      fmt.Errorf("failed to restart terminal: %w: connection refused", docker.ErrDockerDaemonUnavailable),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody: map[string]interface{}{
+				"code": "DOCKER_UNAVAILABLE",
+			},
+		},
+		{
+			name:           "rotation_of_stopped_scenario",
+			method:         "POST",
+			managerMethod:  "RotateTerminalCredentials",
+			userID:         "owner-user",
+			mockError:      fmt.Errorf("%w: scenario status is stopped", scenario.ErrScenarioNotRunning),
+			expectedStatus: http.StatusConflict,
+			expectedBody: map[string]interface{}{
+				"code": "SCENARIO_NOT_RUNNING",
+			},
+		},
+		{
+			name:           "rotation_of_batch_scenario",
+			method:         "POST",
+			managerMethod:  "RotateTerminalCredentials",
+			userID:         "owner-user",
+			mockError:      fmt.Errorf("%w: scn-123 runs in batch mode", scenario.ErrNoTerminal),
+			expectedStatus: http.StatusConflict,
+			expectedBody: map[string]interface{}{
+				"code": "NO_TERMINAL",
+			},
+		},
+		{
+			name:           "rotation_with_ttyd_down",
+			method:         "POST",
+			managerMethod:  "RotateTerminalCredentials",
+			userID:         "owner-user",
+			mockError:      fmt.Errorf("%w: ERROR: ttyd failed to restart", scenario.ErrTerminalRestartFailed),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody: map[string]interface{}{
+				"code": "TERMINAL_RESTART_FAILED",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			mockManager.On(tt.managerMethod, mock.Anything, "scn-123", tt.userID).Return(tt.mockResponse, tt.mockError)
+
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.Use(withUser(tt.userID))
+			router.GET("/scenarios/:id/terminal/credentials", handler.GetTerminalCredentialsREST)
+			router.POST("/scenarios/:id/terminal/credentials", handler.RotateTerminalCredentialsREST)
+
+			req, _ := http.NewRequest(tt.method, "/scenarios/scn-123/terminal/credentials", nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for key, expectedValue := range tt.expectedBody {
+				assert.Equal(t, expectedValue, response[key], "Field %s should match", key)
+			}
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRebindTerminalPortREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		userID         string
+		admin          bool
+		mockResponse   *types.RebindTerminalResponse
+		mockError      error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:   "owner_rebinds",
+			userID: "owner-user",
+			mockResponse: &types.RebindTerminalResponse{
+				ScenarioID:   "scn-123",
+				TerminalPort: 3050,
+				URL:          "http://localhost:3050",
+				Message:      "Terminal port rebound successfully",
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"terminal_port": float64(3050),
+				"url":           "http://localhost:3050",
+			},
+		},
+		{
+			name:           "non_owner_rejected",
+			userID:         "other-user",
+			mockError:      fmt.Errorf("%w: scn-123", scenario.ErrNotScenarioOwner),
+			expectedStatus: http.StatusForbidden,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to rebind terminal port",
+				"code":  "FORBIDDEN",
+			},
+		},
+		{
+			name:   "admin_rebinds",
+			userID: "admin-user",
+			admin:  true,
+			mockResponse: &types.RebindTerminalResponse{
+				ScenarioID:   "scn-123",
+				TerminalPort: 3051,
+				URL:          "http://localhost:3051",
+				Message:      "Terminal port rebound successfully",
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"terminal_port": float64(3051),
+			},
+		},
+		{
+			name:           "no_free_port",
+			userID:         "owner-user",
+			mockError:      fmt.Errorf("failed to rebind terminal: %w", docker.ErrPortUnavailable),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody: map[string]interface{}{
+				"code": "PORT_UNAVAILABLE",
+			},
+		},
+		{
+			name:           "workspace_on_tmpfs",
+			userID:         "owner-user",
+			mockError:      fmt.Errorf("failed to rebind terminal: %w: /home/devlab is a tmpfs mount", docker.ErrWorkspaceNotPersistent),
+			expectedStatus: http.StatusConflict,
+			expectedBody: map[string]interface{}{
+				"code": "WORKSPACE_NOT_PERSISTENT",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			mockManager.On("RebindTerminalPort", mock.Anything, "scn-123", tt.userID, tt.admin).Return(tt.mockResponse, tt.mockError)
+
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.Use(withUser(tt.userID))
+			if tt.admin {
+				router.Use(withRole(AdminRole))
+			}
+			router.POST("/scenarios/:id/terminal/rebind", handler.RebindTerminalPortREST)
+
+			req, _ := http.NewRequest("POST", "/scenarios/scn-123/terminal/rebind", nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for key, expectedValue := range tt.expectedBody {
+				assert.Equal(t, expectedValue, response[key], "Field %s should match", key)
+			}
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCloneScenarioREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		userID          string
+		body            string
+		role            string
+		expectedOwnerID string
+		mockResponse    *types.StartScenarioResponse
+		mockError       error
+		expectedStatus  int
+		expectedBody    map[string]interface{}
+	}{
+		{
+			name:   "clone_for_caller",
+			userID: "owner-user",
+			mockResponse: &types.StartScenarioResponse{
+				ScenarioID: "scn-456",
+				Status:     types.ScenarioStatusProvisioning,
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"scenario_id": "scn-456",
+				"status":      "provisioning",
+			},
+		},
+		{
+			name:            "admin_clones_for_other_user",
+			userID:          "owner-user",
+			body:            `{"user_id": "student"}`,
+			role:            AdminRole,
+			expectedOwnerID: "student",
+			mockResponse: &types.StartScenarioResponse{
+				ScenarioID: "scn-456",
+				Status:     types.ScenarioStatusProvisioning,
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"scenario_id": "scn-456",
+			},
+		},
+		{
+			name:            "non_admin_cannot_clone_for_other_user",
+			userID:          "owner-user",
+			body:            `{"user_id": "student"}`,
+			expectedOwnerID: "student",
+			mockError:       fmt.Errorf("%w: only admins may clone a scenario for another user", scenario.ErrNotScenarioOwner),
+			expectedStatus:  http.StatusForbidden,
+			expectedBody: map[string]interface{}{
+				"code": "FORBIDDEN",
+			},
+		},
+		{
+			name:           "non_owner_rejected",
+			userID:         "other-user",
+			mockError:      fmt.Errorf("%w: scn-123", scenario.ErrNotScenarioOwner),
+			expectedStatus: http.StatusForbidden,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to clone scenario",
+				"code":  "FORBIDDEN",
+			},
+		},
+		{
+			name:           "capacity_reached",
+			userID:         "owner-user",
+			mockError:      scenario.ErrCapacityReached,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody: map[string]interface{}{
+				"code": "CAPACITY_REACHED",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			mockManager.On("CloneScenario", mock.Anything, "scn-123", tt.userID, tt.expectedOwnerID, tt.role == AdminRole).Return(tt.mockResponse, tt.mockError)
+
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.Use(withUser(tt.userID), withRole(tt.role))
+			router.POST("/scenarios/:id/clone", handler.CloneScenarioREST)
+
+			req, _ := http.NewRequest("POST", "/scenarios/scn-123/clone", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for key, expectedValue := range tt.expectedBody {
+				assert.Equal(t, expectedValue, response[key], "Field %s should match", key)
+			}
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+
+	t.Run("invalid_body", func(t *testing.T) {
+		mockManager := new(MockScenarioManager)
+		handler := &Handler{Scenario: mockManager}
+
+		router := gin.New()
+		router.Use(withUser("owner-user"))
+		router.POST("/scenarios/:id/clone", handler.CloneScenarioREST)
+
+		req, _ := http.NewRequest("POST", "/scenarios/scn-123/clone", strings.NewReader("{"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockManager.AssertNotCalled(t, "CloneScenario", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestForceRemoveScenarioREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		mockResponse   *types.ForceRemoveScenarioResponse
+		mockError      error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name: "container_already_gone",
+			mockResponse: &types.ForceRemoveScenarioResponse{
+				ScenarioID:     "scn-123",
+				PreviousStatus: types.ScenarioStatusProvisioning,
+				Status:         types.ScenarioStatusCleanedUp,
+				Message:        "Scenario force removed",
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"previous_status": "provisioning", "status": "cleaned_up", "container_removed": false},
+		},
+		{
+			name:           "not_found",
+			mockError:      fmt.Errorf("%w: scn-123", scenario.ErrScenarioNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   map[string]interface{}{"error": "Failed to force remove scenario", "code": "SCENARIO_NOT_FOUND"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			handler := &Handler{Scenario: mockManager}
+			mockManager.On("ForceRemoveScenario", mock.Anything, "scn-123", "admin-user").Return(tt.mockResponse, tt.mockError)
+
+			router := gin.New()
+			router.Use(withUser("admin-user"))
+			router.POST("/admin/scenarios/:id/force-remove", handler.ForceRemoveScenarioREST)
+
+			req, _ := http.NewRequest("POST", "/admin/scenarios/scn-123/force-remove", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for key, expectedValue := range tt.expectedBody {
+				assert.Equal(t, expectedValue, response[key], "Field %s should match", key)
+			}
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportScenariosREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("streams_documents", func(t *testing.T) {
+		mockManager := new(MockScenarioManager)
+		handler := &Handler{Scenario: mockManager}
+		mockManager.On("ExportScenarios", mock.Anything, "admin-user", mock.Anything).
+			Run(func(args mock.Arguments) {
+				args.Get(2).(io.Writer).Write([]byte("{\"scenario_id\":\"scn-1\"}\n"))
+			}).
+			Return(1, nil)
+
+		router := gin.New()
+		router.Use(withUser("admin-user"))
+		router.GET("/admin/scenarios/export", handler.ExportScenariosREST)
+
+		req, _ := http.NewRequest("GET", "/admin/scenarios/export", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.Equal(t, "{\"scenario_id\":\"scn-1\"}\n", w.Body.String())
+	})
+
+	t.Run("fails_before_writing", func(t *testing.T) {
+		mockManager := new(MockScenarioManager)
+		handler := &Handler{Scenario: mockManager}
+		mockManager.On("ExportScenarios", mock.Anything, "admin-user", mock.Anything).Return(0, errors.New("database connection failed"))
+
+		router := gin.New()
+		router.Use(withUser("admin-user"))
+		router.GET("/admin/scenarios/export", handler.ExportScenariosREST)
+
+		req, _ := http.NewRequest("GET", "/admin/scenarios/export", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		var response types.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "EXPORT_FAILED", response.Code)
+	})
+}
+
+func TestImportScenariosREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	body := "{\"scenario_id\":\"scn-1\"}\n"
+	mockManager := new(MockScenarioManager)
+	handler := &Handler{Scenario: mockManager}
+	mockManager.On("ImportScenarios", mock.Anything, "admin-user", mock.MatchedBy(func(r io.Reader) bool {
+		data, err := io.ReadAll(r)
+		return err == nil && string(data) == body
+	})).Return(&types.ImportScenariosResponse{Imported: 1, Message: "Imported 1 scenarios"}, nil)
+
+	router := gin.New()
+	router.Use(withUser("admin-user"))
+	router.POST("/admin/scenarios/import", handler.ImportScenariosREST)
+
+	req, _ := http.NewRequest("POST", "/admin/scenarios/import", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response types.ImportScenariosResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Imported)
+	mockManager.AssertExpectations(t)
+}
+
+func TestStopScenariosREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(*MockScenarioManager)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "partial_failure",
+			body: `{"scenario_ids": ["scn-1", "scn-2"]}`,
+			setupMock: func(m *MockScenarioManager) {
+				m.On("StopScenarios", mock.Anything, "alice", []string{"scn-1", "scn-2"}).Return(&types.StopScenariosResponse{
+					Stopped: []string{"scn-1"},
+					Failed:  []types.ScenarioStopFailure{{ScenarioID: "scn-2", Error: "scenario not found: scn-2"}},
+					Message: "Stopped 1 of 2 scenarios",
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "invalid_request",
+			body: `{"scenario_ids": []}`,
+			setupMock: func(m *MockScenarioManager) {
+				m.On("StopScenarios", mock.Anything, "alice", []string{}).Return(nil, fmt.Errorf("%w: no scenario IDs given", scenario.ErrInvalidBulkStop))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_BULK_STOP",
+		},
+		{
+			name:           "malformed_body",
+			body:           `{"scenario_ids": "scn-1"}`,
+			setupMock:      func(m *MockScenarioManager) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_REQUEST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			tt.setupMock(mockManager)
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.Use(withUser("alice"))
+			router.POST("/scenarios/stop", handler.StopScenariosREST)
+
+			req, _ := http.NewRequest("POST", "/scenarios/stop", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response types.ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+			} else {
+				var response types.StopScenariosResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, []string{"scn-1"}, response.Stopped)
+				require.Len(t, response.Failed, 1)
+				assert.Equal(t, "scn-2", response.Failed[0].ScenarioID)
+			}
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPauseResumeScenarioREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		action         string
+		mockResponse   *types.PauseScenarioResponse
+		mockError      error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:           "pause",
+			action:         "pause",
+			mockResponse:   &types.PauseScenarioResponse{ScenarioID: "scn-123", Status: types.ScenarioStatusPaused, Message: "Scenario paused successfully"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"status": "paused"},
+		},
+		{
+			name:           "pause_not_running",
+			action:         "pause",
+			mockError:      fmt.Errorf("%w: scn-123 is stopped", scenario.ErrScenarioNotRunning),
+			expectedStatus: http.StatusConflict,
+			expectedBody:   map[string]interface{}{"error": "Failed to pause scenario", "code": "SCENARIO_NOT_RUNNING"},
+		},
+		{
+			name:           "resume",
+			action:         "resume",
+			mockResponse:   &types.PauseScenarioResponse{ScenarioID: "scn-123", Status: types.ScenarioStatusRunning, Message: "Scenario resumed successfully"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"status": "running"},
+		},
+		{
+			name:           "resume_not_paused",
+			action:         "resume",
+			mockError:      fmt.Errorf("%w: scn-123 is stopped", scenario.ErrScenarioNotPaused),
+			expectedStatus: http.StatusConflict,
+			expectedBody:   map[string]interface{}{"error": "Failed to resume scenario", "code": "SCENARIO_NOT_PAUSED"},
+		},
+		{
+			name:           "resume_docker_down",
+			action:         "resume",
+			mockError:      fmt.Errorf("failed to resume scenario: %w", docker.ErrDockerDaemonUnavailable),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   map[string]interface{}{"code": "DOCKER_UNAVAILABLE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.Use(withUser("owner-user"))
+			if tt.action == "pause" {
+				mockManager.On("PauseScenario", mock.Anything, "scn-123", "owner-user").Return(tt.mockResponse, tt.mockError)
+				router.POST("/scenarios/:id/pause", handler.PauseScenarioREST)
+			} else {
+				mockManager.On("ResumeScenario", mock.Anything, "scn-123", "owner-user").Return(tt.mockResponse, tt.mockError)
+				router.POST("/scenarios/:id/resume", handler.ResumeScenarioREST)
+			}
+
+			req, _ := http.NewRequest("POST", "/scenarios/scn-123/"+tt.action, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for key, expectedValue := range tt.expectedBody {
+				assert.Equal(t, expectedValue, response[key], "Field %s should match", key)
+			}
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHeartbeatREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	lastActivityAt := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		userID         string
+		mockResponse   *types.HeartbeatResponse
+		mockError      error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:   "owner_records_activity",
+			userID: "owner-user",
+			mockResponse: &types.HeartbeatResponse{
+				ScenarioID:      "scn-123",
+				LastActivityAt:  lastActivityAt,
+				NextHeartbeatAt: lastActivityAt.Add(30 * time.Second),
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"scenario_id":       "scn-123",
+				"last_activity_at":  "2025-01-02T12:00:00Z",
+				"next_heartbeat_at": "2025-01-02T12:00:30Z",
+			},
+		},
+		{
+			name:           "non_owner_rejected",
+			userID:         "other-user",
+			mockError:      fmt.Errorf("%w: scn-123", scenario.ErrNotScenarioOwner),
+			expectedStatus: http.StatusForbidden,
+			expectedBody: map[string]interface{}{
+				"code": "FORBIDDEN",
+			},
+		},
+		{
+			name:           "rate_limited",
+			userID:         "owner-user",
+			mockError:      fmt.Errorf("%w: retry after 20s", scenario.ErrHeartbeatTooFrequent),
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to record heartbeat",
+				"code":  "HEARTBEAT_TOO_FREQUENT",
+			},
+		},
+		{
+			name:           "store_error",
+			userID:         "owner-user",
+			mockError:      errors.New("failed to record heartbeat for scenario scn-123: mongo unavailable"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody: map[string]interface{}{
+				"code": "INTERNAL_ERROR",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			mockManager.On("Heartbeat", mock.Anything, "scn-123", tt.userID).Return(tt.mockResponse, tt.mockError)
+
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.Use(withUser(tt.userID))
+			router.POST("/scenarios/:id/heartbeat", handler.HeartbeatREST)
+
+			req, _ := http.NewRequest("POST", "/scenarios/scn-123/heartbeat", nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for key, expectedValue := range tt.expectedBody {
+				assert.Equal(t, expectedValue, response[key], "Field %s should match", key)
+			}
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExtendScenarioREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	expiresAt := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		userID         string
+		mockResponse   *types.ExtendScenarioResponse
+		mockError      error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:           "owner_extends_scenario",
+			userID:         "owner-user",
+			mockResponse:   &types.ExtendScenarioResponse{ScenarioID: "scn-123", ExpiresAt: expiresAt},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"scenario_id": "scn-123",
+				"expires_at":  "2025-01-02T12:00:00Z",
+			},
+		},
+		{
+			name:           "non_owner_rejected",
+			userID:         "other-user",
+			mockError:      fmt.Errorf("%w: scn-123", scenario.ErrNotScenarioOwner),
+			expectedStatus: http.StatusForbidden,
+			expectedBody: map[string]interface{}{
+				"code": "FORBIDDEN",
+			},
+		},
+		{
+			name:           "max_lifetime_reached",
+			userID:         "owner-user",
+			mockError:      fmt.Errorf("%w: scn-123", scenario.ErrMaxLifetimeReached),
+			expectedStatus: http.StatusConflict,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to extend scenario",
+				"code":  "MAX_LIFETIME_REACHED",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			mockManager.On("ExtendScenario", mock.Anything, "scn-123", tt.userID).Return(tt.mockResponse, tt.mockError)
+
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.Use(withUser(tt.userID))
+			router.POST("/scenarios/:id/extend", handler.ExtendScenarioREST)
+
+			req, _ := http.NewRequest("POST", "/scenarios/scn-123/extend", nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for key, expectedValue := range tt.expectedBody {
+				assert.Equal(t, expectedValue, response[key], "Field %s should match", key)
+			}
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetScenarioResultsREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	completedAt := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		userID         string
+		mockResponse   *types.ScenarioResultsResponse
+		mockError      error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:   "completed_batch_scenario",
+			userID: "owner-user",
+			mockResponse: &types.ScenarioResultsResponse{
+				ScenarioID:  "scn-123",
+				Status:      types.ScenarioStatusCompleted,
+				Stdout:      "hello\n",
+				ExitCode:    0,
+				CompletedAt: completedAt,
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"scenario_id":  "scn-123",
+				"status":       "completed",
+				"stdout":       "hello\n",
+				"stderr":       "",
+				"exit_code":    float64(0),
+				"completed_at": "2025-01-02T12:00:00Z",
+			},
+		},
+		{
+			name:           "interactive_scenario_has_no_results",
+			userID:         "owner-user",
+			mockError:      fmt.Errorf("%w: scn-123", scenario.ErrNoResults),
+			expectedStatus: http.StatusNotFound,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to get scenario results",
+				"code":  "NO_RESULTS",
+			},
+		},
+		{
+			name:           "batch_still_running",
+			userID:         "owner-user",
+			mockError:      fmt.Errorf("%w: scenario status is running", scenario.ErrResultsNotReady),
+			expectedStatus: http.StatusConflict,
+			expectedBody: map[string]interface{}{
+				"code": "RESULTS_NOT_READY",
+			},
+		},
+		{
+			name:           "non_owner_rejected",
+			userID:         "other-user",
+			mockError:      fmt.Errorf("%w: scn-123", scenario.ErrNotScenarioOwner),
+			expectedStatus: http.StatusForbidden,
+			expectedBody: map[string]interface{}{
+				"code": "FORBIDDEN",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			mockManager.On("GetScenarioResults", mock.Anything, "scn-123", tt.userID).Return(tt.mockResponse, tt.mockError)
+
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.Use(withUser(tt.userID))
+			router.GET("/scenarios/:id/results", handler.GetScenarioResultsREST)
+
+			req, _ := http.NewRequest("GET", "/scenarios/scn-123/results", nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for key, expectedValue := range tt.expectedBody {
+				assert.Equal(t, expectedValue, response[key], "Field %s should match", key)
+			}
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDescribeScenarioREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	details := &types.ScenarioDetailsResponse{
+		ScenarioID:       "scn-123",
+		UserID:           "owner-user",
+		ScenarioType:     "go",
+		Script:           "go run .",
+		Status:           types.ScenarioStatusRunning,
+		TerminalPort:     3001,
+		TerminalUsername: "devlab",
+	}
+	withSecret := *details
+	withSecret.TerminalPassword = "secret"
+
+	tests := []struct {
+		name           string
+		query          string
+		userID         string
+		includeSecrets bool
+		mockResponse   *types.ScenarioDetailsResponse
+		mockError      error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:           "redacted_by_default",
+			userID:         "owner-user",
+			mockResponse:   details,
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"scenario_id":       "scn-123",
+				"script":            "go run .",
+				"terminal_port":     float64(3001),
+				"terminal_username": "devlab",
+				"terminal_password": nil,
+			},
+		},
+		{
+			name:           "secrets_requested",
+			query:          "?include_secrets=true",
+			userID:         "owner-user",
+			includeSecrets: true,
+			mockResponse:   &withSecret,
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"terminal_password": "secret",
+			},
+		},
+		{
+			name:           "non_owner_rejected",
+			userID:         "other-user",
+			mockError:      fmt.Errorf("%w: scn-123", scenario.ErrNotScenarioOwner),
+			expectedStatus: http.StatusForbidden,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to describe scenario",
+				"code":  "FORBIDDEN",
+			},
+		},
+		{
+			name:           "not_found",
+			userID:         "owner-user",
+			mockError:      fmt.Errorf("%w: scn-123", scenario.ErrScenarioNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedBody: map[string]interface{}{
+				"code": "SCENARIO_NOT_FOUND",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			mockManager.On("DescribeScenario", mock.Anything, "scn-123", tt.userID, tt.includeSecrets).Return(tt.mockResponse, tt.mockError)
+
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.Use(withUser(tt.userID))
+			router.GET("/scenarios/:id", handler.DescribeScenarioREST)
+
+			req, _ := http.NewRequest("GET", "/scenarios/scn-123"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for key, expectedValue := range tt.expectedBody {
+				assert.Equal(t, expectedValue, response[key], "Field %s should match", key)
+			}
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSearchScenariosREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	results := &types.SearchScenariosResponse{
+		Results: []types.ScenarioSearchResult{
+			{ScenarioID: "scn-1", Name: "Concurrency workshop", ScenarioType: "go", Status: types.ScenarioStatusRunning, Score: 10},
+		},
+		NextPageToken: "next",
+		Message:       "Scenarios searched successfully",
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		userID         string
+		expectCall     bool
+		mockQuery      string
+		mockToken      string
+		mockPageSize   int
+		mockResponse   *types.SearchScenariosResponse
+		mockError      error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:           "scoped_to_caller",
+			query:          "?q=concurrency&page_size=5&page_token=abc",
+			userID:         "owner-user",
+			expectCall:     true,
+			mockQuery:      "concurrency",
+			mockToken:      "abc",
+			mockPageSize:   5,
+			mockResponse:   results,
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"next_page_token": "next",
+			},
+		},
+		{
+			name:           "other_user_searches_own_scenarios",
+			query:          "?q=concurrency",
+			userID:         "other-user",
+			expectCall:     true,
+			mockQuery:      "concurrency",
+			mockResponse:   &types.SearchScenariosResponse{Results: []types.ScenarioSearchResult{}},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"results": []interface{}{},
+			},
+		},
+		{
+			name:           "empty_query",
+			userID:         "owner-user",
+			expectCall:     true,
+			mockError:      fmt.Errorf("%w: query cannot be empty", scenario.ErrInvalidSearchQuery),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to search scenarios",
+				"code":  "INVALID_QUERY",
+			},
+		},
+		{
+			name:           "invalid_page_token",
+			query:          "?q=go&page_token=bad",
+			userID:         "owner-user",
+			expectCall:     true,
+			mockQuery:      "go",
+			mockToken:      "bad",
+			mockError:      fmt.Errorf("%w: malformed offset", scenario.ErrInvalidPageToken),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"code": "INVALID_PAGE_TOKEN",
+			},
+		},
+		{
+			name:           "invalid_page_size",
+			query:          "?q=go&page_size=lots",
+			userID:         "owner-user",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"code": "INVALID_PAGE_SIZE",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			if tt.expectCall {
+				mockManager.On("SearchScenarios", mock.Anything, tt.userID, tt.mockQuery, tt.mockToken, tt.mockPageSize).Return(tt.mockResponse, tt.mockError)
+			}
+
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.Use(withUser(tt.userID))
+			router.GET("/scenarios/search", handler.SearchScenariosREST)
+
+			req, _ := http.NewRequest("GET", "/scenarios/search"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for key, expectedValue := range tt.expectedBody {
+				assert.Equal(t, expectedValue, response[key], "Field %s should match", key)
+			}
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetDirectoryStructureREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		format         types.DirectoryFormat
+		mockResponse   *types.DirectoryStructureResponse
+		mockError      error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:   "successful_listing",
+			format: types.DirectoryFormatFlat,
+			mockResponse: &types.DirectoryStructureResponse{
+				ScenarioID: "scn-123",
+				Path:       "/home/devlab",
+				Structure:  []types.FileNode{{Path: "/home/devlab", Type: "folder", IsRoot: true}},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"scenario_id": "scn-123",
+				"path":        "/home/devlab",
+			},
+		},
+		{
+			name:   "nested_listing",
+			query:  "?format=nested",
+			format: types.DirectoryFormatNested,
+			mockResponse: &types.DirectoryStructureResponse{
+				ScenarioID: "scn-123",
+				Path:       "/home/devlab",
+				Tree: &types.NestedFileNode{
+					Path: "/home/devlab", Type: "folder", IsRoot: true,
+					Children: []*types.NestedFileNode{{Path: "/home/devlab/main.go", Type: "file"}},
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"scenario_id": "scn-123",
+				"tree": map[string]interface{}{
+					"path": "/home/devlab", "type": "folder",
+					"is_root": true, "is_open": false, "is_saved": false,
+					"isRoot": true, "isOpen": false, "isSaved": false,
+					"children": []interface{}{
+						map[string]interface{}{
+							"path": "/home/devlab/main.go", "type": "file",
+							"is_root": false, "is_open": false, "is_saved": false,
+							"isRoot": false, "isOpen": false, "isSaved": false,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:           "invalid_format",
+			query:          "?format=xml",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"code": "INVALID_FORMAT",
+			},
+		},
+		{
+			name:           "scenario_not_found",
+			format:         types.DirectoryFormatFlat,
+			mockError:      fmt.Errorf("%w: scn-123", scenario.ErrScenarioNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to get directory structure",
+				"code":  "SCENARIO_NOT_FOUND",
+			},
+		},
+		{
+			name:           "scenario_not_running",
+			format:         types.DirectoryFormatFlat,
+			mockError:      fmt.Errorf("%w: container abc", scenario.ErrScenarioNotRunning),
+			expectedStatus: http.StatusConflict,
+			expectedBody: map[string]interface{}{
+				"code": "SCENARIO_NOT_RUNNING",
+			},
+		},
+		{
+			name:           "invalid_scenario_id",
+			format:         types.DirectoryFormatFlat,
+			mockError:      fmt.Errorf("%w: scenario ID cannot be empty", scenario.ErrInvalidScenarioID),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"code": "INVALID_SCENARIO_ID",
+			},
+		},
+		{
+			name:           "docker_unavailable",
+			format:         types.DirectoryFormatFlat,
+			mockError:      fmt.Errorf("failed to check container existence: %w: connection refused", docker.ErrDockerDaemonUnavailable),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody: map[string]interface{}{
+				"code": "DOCKER_UNAVAILABLE",
+			},
+		},
+		{
+			name:           "exec_failure",
+			format:         types.DirectoryFormatFlat,
+			mockError:      errors.New("failed to get directory structure: exec failed"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody: map[string]interface{}{
+				"code":    "INTERNAL_ERROR",
+				"message": "failed to get directory structure: exec failed",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			if tt.format != "" {
+				mockManager.On("GetDirectoryStructure", mock.Anything, "scn-123", tt.format).Return(tt.mockResponse, tt.mockError)
+			}
+
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.GET("/scenarios/:id/directory", handler.GetDirectoryStructureREST)
+
+			req, _ := http.NewRequest("GET", "/scenarios/scn-123/directory"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for key, expectedValue := range tt.expectedBody {
+				assert.Equal(t, expectedValue, response[key], "Field %s should match", key)
+			}
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetScenarioTypesREST_ImageCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		mockAvailable  map[string]bool
+		mockError      error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "no_check",
+			query:          "",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "missing_image_reported_unavailable",
+			query: "?check=true",
+			mockAvailable: map[string]bool{
+				"devlab-go:latest":         true,
+				"devlab-docker:latest":     true,
+				"devlab-k8s:latest":        true,
+				"devlab-python:latest":     false,
+				"devlab-go-k8s:latest":     true,
+				"devlab-python-k8s:latest": true,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "docker_unavailable",
+			query:          "?check=true",
+			mockError:      fmt.Errorf("failed to check image devlab-go:latest: %w", docker.ErrDockerDaemonUnavailable),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   "DOCKER_UNAVAILABLE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			handler := &Handler{Scenario: mockManager}
+			if tt.query != "" {
+				mockManager.On("ImageAvailability", mock.Anything, mock.Anything).Return(tt.mockAvailable, tt.mockError)
+			}
+
+			router := gin.New()
+			router.GET("/scenarios/types", handler.GetScenarioTypesREST)
+
+			req, _ := http.NewRequest("GET", "/scenarios/types"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			if tt.expectedCode != "" {
+				assert.Equal(t, tt.expectedCode, response["code"])
+				return
+			}
+
+			for _, raw := range response["scenario_types"].([]interface{}) {
+				scenarioType := raw.(map[string]interface{})
+				available, annotated := scenarioType["image_available"]
+				if tt.mockAvailable == nil {
+					assert.False(t, annotated, "image_available should only be set with check=true")
+					continue
+				}
+				assert.Equal(t, tt.mockAvailable[scenarioType["image"].(string)], available, "type %s", scenarioType["type"])
+			}
+
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExecuteCommandREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockResponse   *types.ExecCommandResponse
+		mockError      error
+		expectCall     bool
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:           "success",
+			body:           `{"command":["go","test","./..."],"timeout_seconds":60}`,
+			mockResponse:   &types.ExecCommandResponse{ScenarioID: "scn-123", Stdout: "ok  \tdemo\t0.01s\n", ExitCode: 0},
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"stdout": "ok  \tdemo\t0.01s\n", "stderr": "", "exit_code": float64(0), "timed_out": false},
+		},
+		{
+			name:           "non_zero_exit",
+			body:           `{"command":["go","test","./..."]}`,
+			mockResponse:   &types.ExecCommandResponse{ScenarioID: "scn-123", Stdout: "FAIL\tdemo\n", Stderr: "exit status 1\n", ExitCode: 1},
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]interface{}{"stdout": "FAIL\tdemo\n", "stderr": "exit status 1\n", "exit_code": float64(1)},
+		},
+		{
+			name:           "denied_command",
+			body:           `{"command":["nc","-l","4444"]}`,
+			mockError:      fmt.Errorf("%w: nc is denied", scenario.ErrCommandNotAllowed),
+			expectCall:     true,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   map[string]interface{}{"error": "Failed to run command", "code": "COMMAND_NOT_ALLOWED"},
+		},
+		{
+			name:           "invalid_command",
+			body:           `{"command":[]}`,
+			mockError:      fmt.Errorf("%w: command cannot be empty", scenario.ErrInvalidCommand),
+			expectCall:     true,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"code": "INVALID_COMMAND"},
+		},
+		{
+			name:           "not_owner",
+			body:           `{"command":["ls"]}`,
+			mockError:      fmt.Errorf("%w: scn-123", scenario.ErrNotScenarioOwner),
+			expectCall:     true,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   map[string]interface{}{"code": "FORBIDDEN"},
+		},
+		{
+			name:           "malformed_body",
+			body:           `{"command":"ls"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"code": "INVALID_REQUEST"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			handler := &Handler{Scenario: mockManager}
+			if tt.expectCall {
+				mockManager.On("ExecuteCommand", mock.Anything, "scn-123", "owner-user", mock.AnythingOfType("*types.ExecCommandRequest")).Return(tt.mockResponse, tt.mockError)
+			}
+
+			router := gin.New()
+			router.Use(withUser("owner-user"))
+			router.POST("/scenarios/:id/exec", handler.ExecuteCommandREST)
+
+			req, _ := http.NewRequest("POST", "/scenarios/scn-123/exec", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			for key, expectedValue := range tt.expectedBody {
+				assert.Equal(t, expectedValue, response[key], "Field %s should match", key)
+			}
+
+			mockManager.AssertExpectations(t)
+			if !tt.expectCall {
+				mockManager.AssertNotCalled(t, "ExecuteCommand", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestScenarioStatsREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	stats := &types.ScenarioStatsResponse{
+		Total:    3,
+		ByStatus: map[types.ScenarioStatus]int{types.ScenarioStatusRunning: 2, types.ScenarioStatusStopped: 1},
+		ByType:   map[string]int{"go": 3},
+		ByUser:   map[string]int{"alice": 2, "bob": 1},
+		OldestRunning: &types.RunningScenarioSummary{
+			ScenarioID: "scn-1",
+			UserID:     "alice",
+			RunningFor: "2h0m0s",
+		},
+	}
+
+	tests := []struct {
+		name           string
+		claims         jwt.MapClaims
+		mockError      error
+		expectedStatus int
+	}{
+		{name: "admin", claims: jwt.MapClaims{"user_id": "root", "role": "admin"}, expectedStatus: http.StatusOK},
+		{name: "not_admin", claims: jwt.MapClaims{"user_id": "alice", "role": "student"}, expectedStatus: http.StatusForbidden},
+		{name: "store_failure", claims: jwt.MapClaims{"user_id": "root", "role": "admin"}, mockError: errors.New("database connection failed"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			handler := &Handler{Scenario: mockManager}
+			if tt.expectedStatus != http.StatusForbidden {
+				if tt.mockError != nil {
+					mockManager.On("ScenarioStats", mock.Anything).Return(nil, tt.mockError)
+				} else {
+					mockManager.On("ScenarioStats", mock.Anything).Return(stats, nil)
+				}
+			}
+
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tt.claims).SignedString(jwtSecret)
+			require.NoError(t, err)
+
+			router := gin.New()
+			router.Use(JWTAuthMiddleware(), AdminOnlyMiddleware())
+			router.GET("/admin/scenarios/stats", handler.ScenarioStatsREST)
+
+			req, _ := http.NewRequest("GET", "/admin/scenarios/stats", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response types.ScenarioStatsResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, *stats, response)
+			}
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+// listingDocker is a docker.Client whose only implemented call is ListContainers
+type listingDocker struct {
+	docker.Client
+	containers []docker.ContainerInfo
+	calls      int
+}
+
+func (d *listingDocker) ListContainers(ctx context.Context) ([]docker.ContainerInfo, error) {
+	d.calls++
+	return d.containers, nil
+}
+
+func TestListTerminalsREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := storage.NewMemoryStore(
+		&storage.Scenario{ScenarioID: "scn-running", UserID: "alice", ContainerID: "container-running", Status: types.ScenarioStatusRunning},
+		&storage.Scenario{ScenarioID: "scn-exited", UserID: "alice", ContainerID: "container-exited", Status: types.ScenarioStatusRunning},
+		&storage.Scenario{ScenarioID: "scn-stopped", UserID: "alice", ContainerID: "container-stopped", Status: types.ScenarioStatusStopped},
+		&storage.Scenario{ScenarioID: "scn-paused", UserID: "alice", ContainerID: "container-paused", Status: types.ScenarioStatusPaused},
+	)
+	dockerClient := &listingDocker{containers: []docker.ContainerInfo{
+		{ID: "container-running", Status: "Up 3 minutes", TerminalURL: "http://localhost:3001"},
+		{ID: "container-exited", Status: "Exited (137) 1 minute ago"},
+		{ID: "container-stopped", Status: "Exited (0) 1 hour ago"},
+	}}
+	handler := &Handler{Scenario: &scenario.Manager{Docker: dockerClient, Store: store}}
+
+	router := gin.New()
+	router.Use(withUser("alice"))
+	router.GET("/scenarios/terminals", handler.ListTerminalsREST)
+
+	req, _ := http.NewRequest("GET", "/scenarios/terminals", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response []types.ScenarioTerminal
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.ElementsMatch(t, []types.ScenarioTerminal{
+		{ScenarioID: "scn-running", URL: "http://localhost:3001", Reachable: true},
+		{ScenarioID: "scn-exited", Reachable: false},
+	}, response)
+	assert.Equal(t, 1, dockerClient.calls)
+}
+
+func TestListTerminalsREST_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"docker_unavailable", fmt.Errorf("failed to list containers: %w", docker.ErrDockerDaemonUnavailable), http.StatusServiceUnavailable, "DOCKER_UNAVAILABLE"},
+		{"internal", errors.New("boom"), http.StatusInternalServerError, "INTERNAL_ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			mockManager.On("ListTerminals", mock.Anything, "alice").Return(nil, tt.err)
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.Use(withUser("alice"))
+			router.GET("/scenarios/terminals", handler.ListTerminalsREST)
+
+			req, _ := http.NewRequest("GET", "/scenarios/terminals", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			var response types.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedCode, response.Code)
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateScenarioREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	notes := "Pairing with bob on the scheduler"
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(*MockScenarioManager)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "notes_updated",
+			body: `{"notes": "Pairing with bob on the scheduler"}`,
+			setupMock: func(m *MockScenarioManager) {
+				m.On("UpdateScenario", mock.Anything, "scn-123", "alice", &types.UpdateScenarioRequest{Notes: &notes}).
+					Return(&types.ScenarioDetailsResponse{ScenarioID: "scn-123", Notes: notes, Message: "Scenario updated successfully"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "notes_too_long",
+			body: `{"notes": "x"}`,
+			setupMock: func(m *MockScenarioManager) {
+				m.On("UpdateScenario", mock.Anything, "scn-123", "alice", mock.Anything).
+					Return(nil, fmt.Errorf("%w: 4097 characters exceeds the limit of 4096", scenario.ErrInvalidNotes))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_NOTES",
+		},
+		{
+			name: "not_owner",
+			body: `{"notes": "x"}`,
+			setupMock: func(m *MockScenarioManager) {
+				m.On("UpdateScenario", mock.Anything, "scn-123", "alice", mock.Anything).Return(nil, scenario.ErrNotScenarioOwner)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "FORBIDDEN",
+		},
+		{
+			name:           "malformed_body",
+			body:           `{"notes": 42}`,
+			setupMock:      func(m *MockScenarioManager) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_REQUEST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			tt.setupMock(mockManager)
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.Use(withUser("alice"))
+			router.PATCH("/scenarios/:id", handler.UpdateScenarioREST)
+
+			req, _ := http.NewRequest("PATCH", "/scenarios/scn-123", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response types.ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+			} else {
+				var response types.ScenarioDetailsResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, notes, response.Notes)
+			}
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdminUpdateScenarioREST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	locked := true
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(*MockScenarioManager)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "locked",
+			body: `{"locked": true}`,
+			setupMock: func(m *MockScenarioManager) {
+				m.On("AdminUpdateScenario", mock.Anything, "scn-123", "admin-user", &types.UpdateScenarioRequest{Locked: &locked}).
+					Return(&types.ScenarioDetailsResponse{ScenarioID: "scn-123", UserID: "owner-user", Locked: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "not_found",
+			body: `{"locked": true}`,
+			setupMock: func(m *MockScenarioManager) {
+				m.On("AdminUpdateScenario", mock.Anything, "scn-123", "admin-user", mock.Anything).
+					Return(nil, fmt.Errorf("%w: scn-123", scenario.ErrScenarioNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "SCENARIO_NOT_FOUND",
+		},
+		{
+			name:           "malformed_body",
+			body:           `{"locked": "yes"}`,
+			setupMock:      func(m *MockScenarioManager) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_REQUEST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			tt.setupMock(mockManager)
+			handler := &Handler{Scenario: mockManager}
+
+			router := gin.New()
+			router.Use(withUser("admin-user"))
+			router.PATCH("/admin/scenarios/:id", handler.AdminUpdateScenarioREST)
+
+			req, _ := http.NewRequest("PATCH", "/admin/scenarios/scn-123", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var response types.ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedCode, response.Code)
+			} else {
+				var response types.ScenarioDetailsResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.True(t, response.Locked)
+			}
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
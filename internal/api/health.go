@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	pb "devlab/proto"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// DefaultReadinessTimeout bounds each dependency check when Readiness.Timeout is unset
+const DefaultReadinessTimeout = 2 * time.Second
+
+// DependencyCheck reports whether a dependency the API needs is reachable
+type DependencyCheck func(ctx context.Context) error
+
+// Readiness decides whether the API can serve requests by checking each of
+// its dependencies, such as MongoDB and the Docker daemon
+type Readiness struct {
+	Checks  map[string]DependencyCheck
+	Timeout time.Duration
+}
+
+// Check runs every dependency check and returns the first failure, naming
+// the dependency, or nil when all are reachable
+func (r *Readiness) Check(ctx context.Context) error {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultReadinessTimeout
+	}
+
+	names := make([]string, 0, len(r.Checks))
+	for name := range r.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := r.Checks[name](checkCtx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("%s unreachable: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// UpdateHealth sets the overall and ScenarioService status of hs from a
+// readiness check
+func (r *Readiness) UpdateHealth(ctx context.Context, hs *health.Server) {
+	status := healthpb.HealthCheckResponse_SERVING
+	if err := r.Check(ctx); err != nil {
+		log.Printf("[api] not ready: %v", err)
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	hs.SetServingStatus("", status)
+	hs.SetServingStatus(pb.ScenarioService_ServiceDesc.ServiceName, status)
+}
+
+// RunHealthUpdates refreshes hs every interval until ctx is done
+func (r *Readiness) RunHealthUpdates(ctx context.Context, hs *health.Server, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	r.UpdateHealth(ctx, hs)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.UpdateHealth(ctx, hs)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestReadinessHealthService(t *testing.T) {
+	var dockerErr error
+	readiness := &Readiness{Checks: map[string]DependencyCheck{
+		"mongodb": func(ctx context.Context) error { return nil },
+		"docker":  func(ctx context.Context) error { return dockerErr },
+	}}
+
+	// Serve the health service behind the auth interceptor, as main does
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(JWTUnaryInterceptor()))
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	client := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	readiness.UpdateHealth(ctx, hs)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("scenario.ScenarioService"))
+
+	dockerErr = errors.New("daemon unreachable")
+	readiness.UpdateHealth(ctx, hs)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check("scenario.ScenarioService"))
+
+	dockerErr = nil
+	readiness.UpdateHealth(ctx, hs)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(""))
+
+	// Shutdown reports NOT_SERVING and later updates cannot undo it
+	hs.Shutdown()
+	readiness.UpdateHealth(ctx, hs)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+}
+
+func TestReadinessCheck_NamesFailingDependency(t *testing.T) {
+	readiness := &Readiness{Checks: map[string]DependencyCheck{
+		"mongodb": func(ctx context.Context) error { return errors.New("connection refused") },
+	}}
+
+	err := readiness.Check(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mongodb unreachable")
+}
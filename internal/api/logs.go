@@ -0,0 +1,106 @@
+package api
+
+import (
+	"devlab/internal/docker"
+	"devlab/internal/types"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultLogsTail is how many earlier lines the logs stream starts with when
+// the request does not say
+const DefaultLogsTail = 100
+
+// ScenarioLogsStreamREST godoc
+// @Summary Follow scenario logs
+// @Description Server-sent events stream of the output of a scenario owned by the caller. Each line is sent as a "stdout" or "stderr" event, starting with the last `tail` lines. An "end" event is sent when the container exits; otherwise the stream runs until the client disconnects.
+// @Tags scenarios
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param id path string true "Scenario ID"
+// @Param tail query int false "Number of earlier lines to send first (default 100, 0 for none)"
+// @Success 200 {string} string "Stream of log lines"
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 409 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Router /scenarios/{id}/logs/stream [get]
+func (h *Handler) ScenarioLogsStreamREST(c *gin.Context) {
+	scenarioID := c.Param("id")
+	if scenarioID == "" {
+		c.JSON(http.StatusBadRequest, types.ErrorResponse{
+			Error:   "Scenario ID is required",
+			Code:    "MISSING_SCENARIO_ID",
+			Message: "scenario ID parameter cannot be empty",
+		})
+		return
+	}
+
+	tail := DefaultLogsTail
+	if raw := c.Query("tail"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, types.ErrorResponse{
+				Error:   "Invalid tail",
+				Code:    "INVALID_TAIL",
+				Message: "tail must be a non-negative integer",
+			})
+			return
+		}
+		tail = n
+	}
+
+	ctx := c.Request.Context()
+	stream, err := h.Scenario.FollowScenarioLogs(ctx, scenarioID, UserIDFromContext(c), tail)
+	if err != nil {
+		statusCode, errorCode := ownedScenarioErrorStatus(err)
+		c.JSON(statusCode, types.ErrorResponse{
+			Error:   "Failed to follow logs",
+			Code:    errorCode,
+			Message: err.Error(),
+		})
+		return
+	}
+	defer stream.Close()
+
+	// Closing the stream on disconnect unblocks a read waiting for output
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.Close()
+		case <-done:
+		}
+	}()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	// Each line is flushed before the next is read, so a slow client holds
+	// back the read instead of output piling up in memory
+	err = stream.Each(func(line docker.LogLine) error {
+		c.SSEvent(line.Stream, line.Text)
+		c.Writer.Flush()
+		return ctx.Err()
+	})
+	if ctx.Err() != nil {
+		// Client disconnected
+		return
+	}
+	if err != nil {
+		log.Printf("[api] logs stream of scenario %s failed: %v", scenarioID, err)
+		return
+	}
+
+	c.SSEvent("end", "container exited")
+	c.Writer.Flush()
+}
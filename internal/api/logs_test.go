@@ -0,0 +1,75 @@
+package api
+
+import (
+	"devlab/internal/docker"
+	"devlab/internal/scenario"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestScenarioLogsStreamREST_StreamsLines(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	source := io.NopCloser(strings.NewReader("$ go test\r\nok  \tdemo\t0.01s\r\n"))
+	mockManager := new(MockScenarioManager)
+	mockManager.On("FollowScenarioLogs", mock.Anything, "scn-1", "user-1", 20).Return(docker.NewLogStream(source, true), nil)
+
+	handler := &Handler{Scenario: mockManager}
+	router := gin.New()
+	router.GET("/scenarios/:id/logs/stream", withUser("user-1"), handler.ScenarioLogsStreamREST)
+
+	req, _ := http.NewRequest("GET", "/scenarios/scn-1/logs/stream?tail=20", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream"))
+	assert.Equal(t, "event:stdout\ndata:$ go test\n\nevent:stdout\ndata:ok  \tdemo\t0.01s\n\nevent:end\ndata:container exited\n\n", body)
+	mockManager.AssertExpectations(t)
+}
+
+func TestScenarioLogsStreamREST_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		url        string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"invalid tail", "/scenarios/scn-1/logs/stream?tail=-1", nil, http.StatusBadRequest, "INVALID_TAIL"},
+		{"not owner", "/scenarios/scn-1/logs/stream", scenario.ErrNotScenarioOwner, http.StatusForbidden, "FORBIDDEN"},
+		{"not running", "/scenarios/scn-1/logs/stream", fmt.Errorf("%w: no container", scenario.ErrScenarioNotRunning), http.StatusConflict, "SCENARIO_NOT_RUNNING"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockManager := new(MockScenarioManager)
+			if tt.err != nil {
+				mockManager.On("FollowScenarioLogs", mock.Anything, "scn-1", "user-1", DefaultLogsTail).Return(nil, tt.err)
+			}
+
+			handler := &Handler{Scenario: mockManager}
+			router := gin.New()
+			router.GET("/scenarios/:id/logs/stream", withUser("user-1"), handler.ScenarioLogsStreamREST)
+
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantCode)
+			mockManager.AssertExpectations(t)
+		})
+	}
+}
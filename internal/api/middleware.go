@@ -1,15 +1,40 @@
 package api
 
 import (
+	"context"
+	"devlab/internal/tracing"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 var jwtSecret = []byte("devlab_secret")
 
+// DefaultJWTLeeway is how far a token's exp and nbf may be off before it is rejected
+const DefaultJWTLeeway = 30 * time.Second
+
+// jwtLeeway is the clock skew tolerated when validating exp and nbf
+var jwtLeeway = DefaultJWTLeeway
+
+// SetJWTLeeway sets the clock skew tolerated when validating a token's exp
+// and nbf claims. It must be called before the server starts handling requests.
+func SetJWTLeeway(leeway time.Duration) {
+	if leeway < 0 {
+		leeway = 0
+	}
+	jwtLeeway = leeway
+}
+
+// ContextUserIDKey is the gin context key holding the authenticated user's ID
+const ContextUserIDKey = "user_id"
+
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		header := c.GetHeader("Authorization")
@@ -17,15 +42,142 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid Authorization header"})
 			return
 		}
-		tokenString := strings.TrimPrefix(header, "Bearer ")
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
-		if err != nil || !token.Valid {
+		token, err := parseToken(strings.TrimPrefix(header, "Bearer "))
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			return
 		}
 		c.Set("jwt_claims", token.Claims)
+		if userID := tokenUserID(token); userID != "" {
+			c.Set(ContextUserIDKey, userID)
+			c.Request = c.Request.WithContext(tracing.WithUserID(c.Request.Context(), userID))
+		}
+		c.Next()
+	}
+}
+
+// AdminRole is the role claim value that grants access to admin endpoints
+const AdminRole = "admin"
+
+// AdminOnlyMiddleware restricts a route to tokens carrying role "admin". It
+// must run after JWTAuthMiddleware, which stores the validated claims.
+func AdminOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsAdmin(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin privileges required"})
+			return
+		}
 		c.Next()
 	}
 }
+
+// IsAdmin reports whether the request's validated token carries role "admin"
+func IsAdmin(c *gin.Context) bool {
+	claims, _ := c.Get("jwt_claims")
+	mapClaims, _ := claims.(jwt.MapClaims)
+	role, _ := mapClaims["role"].(string)
+	return role == AdminRole
+}
+
+// parseToken validates a signed JWT
+func parseToken(tokenString string) (*jwt.Token, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	}, jwt.WithLeeway(jwtLeeway))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return token, nil
+}
+
+// tokenUserID returns the user_id claim of token, or "" when it has none
+func tokenUserID(token *jwt.Token) string {
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		if userID, ok := claims["user_id"].(string); ok {
+			return userID
+		}
+	}
+	return ""
+}
+
+type grpcUserIDKey struct{}
+
+// grpcAuthExemptPrefixes lists the services that infrastructure probes call
+// without credentials
+var grpcAuthExemptPrefixes = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.",
+}
+
+// JWTUnaryInterceptor is the gRPC counterpart of JWTAuthMiddleware. It
+// validates the bearer token in the "authorization" metadata, rejects calls
+// without a valid one with codes.Unauthenticated, and records the token's
+// user for UserIDFromGRPCContext.
+func JWTUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticateGRPC(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// JWTStreamInterceptor applies JWTUnaryInterceptor's checks to streaming RPCs
+func JWTStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateGRPC(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticatedStream overrides the stream context with the authenticated one
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticateGRPC validates the call's bearer token and returns ctx carrying
+// its user ID. Exempt methods are passed through unchanged.
+func authenticateGRPC(ctx context.Context, fullMethod string) (context.Context, error) {
+	for _, prefix := range grpcAuthExemptPrefixes {
+		if strings.HasPrefix(fullMethod, prefix) {
+			return ctx, nil
+		}
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+		return nil, status.Errorf(codes.Unauthenticated, "missing or invalid authorization metadata")
+	}
+	token, err := parseToken(strings.TrimPrefix(values[0], "Bearer "))
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid or expired token")
+	}
+	userID := tokenUserID(token)
+	ctx = tracing.WithUserID(ctx, userID)
+	return context.WithValue(ctx, grpcUserIDKey{}, userID), nil
+}
+
+// UserIDFromGRPCContext returns the user authenticated by the gRPC
+// interceptors, or "" when the token carried none
+func UserIDFromGRPCContext(ctx context.Context) string {
+	userID, _ := ctx.Value(grpcUserIDKey{}).(string)
+	return userID
+}
+
+// UserIDFromContext returns the authenticated user's ID, or "" when the token carried none
+func UserIDFromContext(c *gin.Context) string {
+	return c.GetString(ContextUserIDKey)
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTAuthMiddleware_Leeway(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetJWTLeeway(30 * time.Second)
+	defer SetJWTLeeway(DefaultJWTLeeway)
+
+	now := time.Now()
+	tests := []struct {
+		name           string
+		claims         jwt.MapClaims
+		expectedStatus int
+	}{
+		{
+			name:           "expired_within_leeway",
+			claims:         jwt.MapClaims{"user_id": "alice", "exp": now.Add(-10 * time.Second).Unix()},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "expired_beyond_leeway",
+			claims:         jwt.MapClaims{"user_id": "alice", "exp": now.Add(-time.Minute).Unix()},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "not_yet_valid_within_leeway",
+			claims:         jwt.MapClaims{"user_id": "alice", "nbf": now.Add(10 * time.Second).Unix()},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "not_yet_valid_beyond_leeway",
+			claims:         jwt.MapClaims{"user_id": "alice", "nbf": now.Add(time.Minute).Unix()},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tt.claims).SignedString(jwtSecret)
+			require.NoError(t, err)
+
+			router := gin.New()
+			router.Use(JWTAuthMiddleware())
+			router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req, _ := http.NewRequest("GET", "/ping", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	t.Run("zero_leeway", func(t *testing.T) {
+		SetJWTLeeway(0)
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": "alice", "exp": now.Add(-10 * time.Second).Unix()}).SignedString(jwtSecret)
+		require.NoError(t, err)
+
+		_, err = parseToken(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenExpired)
+	})
+}
+
+func TestAdminOnlyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		claims         jwt.MapClaims
+		expectedStatus int
+	}{
+		{name: "admin", claims: jwt.MapClaims{"user_id": "root", "role": "admin"}, expectedStatus: http.StatusOK},
+		{name: "other_role", claims: jwt.MapClaims{"user_id": "alice", "role": "student"}, expectedStatus: http.StatusForbidden},
+		{name: "no_role", claims: jwt.MapClaims{"user_id": "alice"}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tt.claims).SignedString(jwtSecret)
+			require.NoError(t, err)
+
+			router := gin.New()
+			router.Use(JWTAuthMiddleware(), AdminOnlyMiddleware())
+			router.POST("/admin/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req, _ := http.NewRequest("POST", "/admin/ping", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
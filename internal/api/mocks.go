@@ -2,7 +2,9 @@ package api
 
 import (
 	"context"
+	"devlab/internal/docker"
 	"devlab/internal/types"
+	"io"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -38,10 +40,199 @@ func (m *MockScenarioManager) StopScenario(ctx context.Context, scenarioID strin
 	return args.Error(0)
 }
 
-func (m *MockScenarioManager) GetDirectoryStructure(ctx context.Context, scenarioID string) (*types.DirectoryStructureResponse, error) {
-	args := m.Called(ctx, scenarioID)
+func (m *MockScenarioManager) GetDirectoryStructure(ctx context.Context, scenarioID string, format types.DirectoryFormat) (*types.DirectoryStructureResponse, error) {
+	args := m.Called(ctx, scenarioID, format)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*types.DirectoryStructureResponse), args.Error(1)
 }
+
+func (m *MockScenarioManager) GetTerminalCredentials(ctx context.Context, scenarioID, userID string) (*types.TerminalCredentialsResponse, error) {
+	args := m.Called(ctx, scenarioID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.TerminalCredentialsResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) RotateTerminalCredentials(ctx context.Context, scenarioID, userID string) (*types.TerminalCredentialsResponse, error) {
+	args := m.Called(ctx, scenarioID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.TerminalCredentialsResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) ListUserScenarios(ctx context.Context, userID string) ([]*types.ScenarioStatusResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*types.ScenarioStatusResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) ListUserScenariosPage(ctx context.Context, userID, pageToken string, pageSize int) ([]*types.ScenarioStatusResponse, string, error) {
+	args := m.Called(ctx, userID, pageToken, pageSize)
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+	return args.Get(0).([]*types.ScenarioStatusResponse), args.String(1), args.Error(2)
+}
+
+func (m *MockScenarioManager) DescribeScenario(ctx context.Context, scenarioID, userID string, includeSecrets bool) (*types.ScenarioDetailsResponse, error) {
+	args := m.Called(ctx, scenarioID, userID, includeSecrets)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.ScenarioDetailsResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) UpdateScenario(ctx context.Context, scenarioID, userID string, req *types.UpdateScenarioRequest) (*types.ScenarioDetailsResponse, error) {
+	args := m.Called(ctx, scenarioID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.ScenarioDetailsResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) AdminUpdateScenario(ctx context.Context, scenarioID, adminID string, req *types.UpdateScenarioRequest) (*types.ScenarioDetailsResponse, error) {
+	args := m.Called(ctx, scenarioID, adminID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.ScenarioDetailsResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) SearchScenarios(ctx context.Context, userID, query, pageToken string, pageSize int) (*types.SearchScenariosResponse, error) {
+	args := m.Called(ctx, userID, query, pageToken, pageSize)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.SearchScenariosResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) RebindTerminalPort(ctx context.Context, scenarioID, userID string, admin bool) (*types.RebindTerminalResponse, error) {
+	args := m.Called(ctx, scenarioID, userID, admin)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.RebindTerminalResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) CloneScenario(ctx context.Context, scenarioID, userID, ownerID string, admin bool) (*types.StartScenarioResponse, error) {
+	args := m.Called(ctx, scenarioID, userID, ownerID, admin)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.StartScenarioResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) PauseScenario(ctx context.Context, scenarioID, userID string) (*types.PauseScenarioResponse, error) {
+	args := m.Called(ctx, scenarioID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.PauseScenarioResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) ResumeScenario(ctx context.Context, scenarioID, userID string) (*types.PauseScenarioResponse, error) {
+	args := m.Called(ctx, scenarioID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.PauseScenarioResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) ImageAvailability(ctx context.Context, images []string) (map[string]bool, error) {
+	args := m.Called(ctx, images)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]bool), args.Error(1)
+}
+
+func (m *MockScenarioManager) ForceRemoveScenario(ctx context.Context, scenarioID, adminID string) (*types.ForceRemoveScenarioResponse, error) {
+	args := m.Called(ctx, scenarioID, adminID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.ForceRemoveScenarioResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) ExportScenarios(ctx context.Context, adminID string, w io.Writer) (int, error) {
+	args := m.Called(ctx, adminID, w)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockScenarioManager) ListTerminals(ctx context.Context, userID string) ([]types.ScenarioTerminal, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]types.ScenarioTerminal), args.Error(1)
+}
+
+func (m *MockScenarioManager) StopScenarios(ctx context.Context, userID string, scenarioIDs []string) (*types.StopScenariosResponse, error) {
+	args := m.Called(ctx, userID, scenarioIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.StopScenariosResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) ImportScenarios(ctx context.Context, adminID string, r io.Reader) (*types.ImportScenariosResponse, error) {
+	args := m.Called(ctx, adminID, r)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.ImportScenariosResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) ScenarioStats(ctx context.Context) (*types.ScenarioStatsResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.ScenarioStatsResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) ExecuteCommand(ctx context.Context, scenarioID, userID string, req *types.ExecCommandRequest) (*types.ExecCommandResponse, error) {
+	args := m.Called(ctx, scenarioID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.ExecCommandResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) FollowScenarioLogs(ctx context.Context, scenarioID, userID string, tail int) (*docker.LogStream, error) {
+	args := m.Called(ctx, scenarioID, userID, tail)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*docker.LogStream), args.Error(1)
+}
+
+func (m *MockScenarioManager) Heartbeat(ctx context.Context, scenarioID, userID string) (*types.HeartbeatResponse, error) {
+	args := m.Called(ctx, scenarioID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.HeartbeatResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) ExtendScenario(ctx context.Context, scenarioID, userID string) (*types.ExtendScenarioResponse, error) {
+	args := m.Called(ctx, scenarioID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.ExtendScenarioResponse), args.Error(1)
+}
+
+func (m *MockScenarioManager) GetScenarioResults(ctx context.Context, scenarioID, userID string) (*types.ScenarioResultsResponse, error) {
+	args := m.Called(ctx, scenarioID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*types.ScenarioResultsResponse), args.Error(1)
+}
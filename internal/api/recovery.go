@@ -0,0 +1,70 @@
+package api
+
+import (
+	"devlab/internal/types"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	zerologlog "github.com/rs/zerolog/log"
+)
+
+// RequestIDHeader carries the request ID on requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// ContextRequestIDKey is the gin context key holding the request ID
+const ContextRequestIDKey = "request_id"
+
+// RequestIDMiddleware tags each request with the caller's X-Request-ID, or a
+// freshly generated one, and echoes it back on the response
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		c.Set(ContextRequestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// RequestIDFromContext returns the ID assigned by RequestIDMiddleware, or ""
+// when the middleware is not installed
+func RequestIDFromContext(c *gin.Context) string {
+	return c.GetString(ContextRequestIDKey)
+}
+
+// RecoveryMiddleware replaces gin.Recovery. A panicking handler is logged with
+// its stack and request ID, and the client receives a structured 500 instead
+// of an empty body.
+func RecoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			zerologlog.Error().
+				Str("request_id", RequestIDFromContext(c)).
+				Str("method", c.Request.Method).
+				Str("path", c.Request.URL.Path).
+				Interface("panic", recovered).
+				Bytes("stack", debug.Stack()).
+				Msg("recovered from handler panic")
+
+			if c.Writer.Written() {
+				// Headers are already on the wire; all that can be done is to stop
+				c.Abort()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, types.ErrorResponse{
+				Error:   "Internal server error",
+				Code:    "INTERNAL_PANIC",
+				Message: "the server encountered an unexpected error while handling the request",
+			})
+		}()
+		c.Next()
+	}
+}
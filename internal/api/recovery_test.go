@@ -0,0 +1,56 @@
+package api
+
+import (
+	"bytes"
+	"devlab/internal/types"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	zerologlog "github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	originalLogger := zerologlog.Logger
+	defer func() { zerologlog.Logger = originalLogger }()
+
+	var buf bytes.Buffer
+	zerologlog.Logger = zerolog.New(&buf)
+
+	router := gin.New()
+	router.Use(RequestIDMiddleware(), RecoveryMiddleware())
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+	router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "req-123", w.Header().Get(RequestIDHeader))
+	var body types.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "INTERNAL_PANIC", body.Code)
+	assert.Equal(t, "Internal server error", body.Error)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "req-123", entry["request_id"])
+	assert.Equal(t, "boom", entry["panic"])
+	assert.Equal(t, "/panic", entry["path"])
+	assert.NotEmpty(t, entry["stack"])
+
+	// The router keeps serving after a panic
+	req, _ = http.NewRequest("GET", "/ping", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Header().Get(RequestIDHeader))
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"devlab/internal/types"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeoutMiddleware bounds each request's context with a deadline so a slow
+// Docker or database call cannot hold a connection indefinitely. The timeout
+// for a route is looked up in overrides by its path pattern (c.FullPath()),
+// falling back to defaultTimeout; zero leaves the route unbounded, as
+// streaming endpoints need. Handlers pass the context down, so the deadline
+// also cancels any provisioning still in flight. A request that runs out of
+// time gets a 504, replacing whatever the handler tries to write afterwards.
+func TimeoutMiddleware(defaultTimeout time.Duration, overrides map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout, ok := overrides[c.FullPath()]
+		if !ok {
+			timeout = defaultTimeout
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		writer := &timeoutWriter{ResponseWriter: c.Writer, ctx: ctx}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.timedOut || (!writer.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded)) {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, types.ErrorResponse{
+				Error:   "Request timed out",
+				Code:    "REQUEST_TIMEOUT",
+				Message: fmt.Sprintf("the request did not complete within %s", timeout),
+			})
+		}
+	}
+}
+
+// timeoutWriter drops a response started after the request's deadline, so
+// the middleware can answer with a 504 instead
+type timeoutWriter struct {
+	gin.ResponseWriter
+	ctx      context.Context
+	timedOut bool
+}
+
+// expired reports whether the deadline passed before the response was started
+func (w *timeoutWriter) expired() bool {
+	if !w.timedOut && !w.ResponseWriter.Written() && errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		w.timedOut = true
+	}
+	return w.timedOut
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if w.expired() {
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	if w.expired() {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	if w.expired() {
+		return 0, context.DeadlineExceeded
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	if w.expired() {
+		return 0, context.DeadlineExceeded
+	}
+	return w.ResponseWriter.WriteString(s)
+}
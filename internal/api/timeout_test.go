@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"devlab/internal/scenario"
+	"devlab/internal/types"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutMiddleware_SlowManager(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var managerCtx context.Context
+	mockManager := new(MockScenarioManager)
+	mockManager.On("StartScenario", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			managerCtx = args.Get(0).(context.Context)
+			select {
+			case <-managerCtx.Done():
+			case <-time.After(5 * time.Second):
+			}
+		}).
+		Return(nil, fmt.Errorf("%w: %w", scenario.ErrClientCancelled, context.DeadlineExceeded))
+	handler := &Handler{Scenario: mockManager}
+
+	router := gin.New()
+	router.Use(withUser("test-user"), TimeoutMiddleware(50*time.Millisecond, nil))
+	router.POST("/scenarios/start", handler.StartScenarioREST)
+
+	req, _ := http.NewRequest("POST", "/scenarios/start", strings.NewReader(`{"user_id":"test-user","scenario_type":"go"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	started := time.Now()
+	router.ServeHTTP(w, req)
+
+	assert.Less(t, time.Since(started), 5*time.Second)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	var body types.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "REQUEST_TIMEOUT", body.Code)
+
+	// The provisioning call saw its context cancelled by the deadline
+	require.NotNil(t, managerCtx)
+	assert.ErrorIs(t, managerCtx.Err(), context.DeadlineExceeded)
+	mockManager.AssertExpectations(t)
+}
+
+func TestTimeoutMiddleware_Routes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	deadlines := make(map[string]time.Duration)
+	record := func(c *gin.Context) {
+		if deadline, ok := c.Request.Context().Deadline(); ok {
+			deadlines[c.FullPath()] = time.Until(deadline).Round(time.Minute)
+		} else {
+			deadlines[c.FullPath()] = 0
+		}
+		c.Status(http.StatusOK)
+	}
+
+	router := gin.New()
+	router.Use(TimeoutMiddleware(time.Minute, map[string]time.Duration{
+		"/scenarios/start": 5 * time.Minute,
+		"/events":          0,
+	}))
+	router.GET("/scenarios/:id/status", record)
+	router.POST("/scenarios/start", record)
+	router.GET("/events", record)
+
+	for _, target := range []struct{ method, path string }{
+		{"GET", "/scenarios/scn-1/status"},
+		{"POST", "/scenarios/start"},
+		{"GET", "/events"},
+	} {
+		req, _ := http.NewRequest(target.method, target.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, target.path)
+	}
+
+	assert.Equal(t, map[string]time.Duration{
+		"/scenarios/:id/status": time.Minute,
+		"/scenarios/start":      5 * time.Minute,
+		"/events":               0,
+	}, deadlines)
+}
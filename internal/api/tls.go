@@ -0,0 +1,34 @@
+package api
+
+import (
+	"crypto/tls"
+	"devlab/internal/config"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidTLSConfig is returned when the configured certificate or key cannot be used
+var ErrInvalidTLSConfig = errors.New("invalid TLS configuration")
+
+// LoadTLSConfig validates the configured certificate and key and returns a TLS
+// configuration shared by the REST and gRPC servers. It returns nil when TLS is
+// not configured.
+func LoadTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, fmt.Errorf("%w: both TLS_CERT_FILE and TLS_KEY_FILE must be set", ErrInvalidTLSConfig)
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load certificate %s and key %s: %v", ErrInvalidTLSConfig, cfg.CertFile, cfg.KeyFile, err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
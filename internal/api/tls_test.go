@@ -0,0 +1,95 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"devlab/internal/config"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSignedCert writes a throwaway certificate and key into dir
+func writeSelfSignedCert(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, t.TempDir())
+
+	tests := []struct {
+		name        string
+		cfg         config.TLSConfig
+		expectNil   bool
+		expectError bool
+	}{
+		{
+			name:      "tls_disabled",
+			cfg:       config.TLSConfig{},
+			expectNil: true,
+		},
+		{
+			name: "valid_cert_and_key",
+			cfg:  config.TLSConfig{CertFile: certFile, KeyFile: keyFile},
+		},
+		{
+			name:        "bad_cert_path",
+			cfg:         config.TLSConfig{CertFile: "/nonexistent/cert.pem", KeyFile: keyFile},
+			expectError: true,
+		},
+		{
+			name:        "missing_key",
+			cfg:         config.TLSConfig{CertFile: certFile},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsConfig, err := LoadTLSConfig(tt.cfg)
+
+			if tt.expectError {
+				assert.ErrorIs(t, err, ErrInvalidTLSConfig)
+				assert.Nil(t, tlsConfig)
+				return
+			}
+
+			require.NoError(t, err)
+			if tt.expectNil {
+				assert.Nil(t, tlsConfig)
+			} else {
+				require.NotNil(t, tlsConfig)
+				assert.Len(t, tlsConfig.Certificates, 1)
+			}
+		})
+	}
+}
@@ -4,103 +4,349 @@ import (
 	"context"
 	"devlab/internal/config"
 	"devlab/internal/docker"
+	"devlab/internal/queue"
 	"devlab/internal/storage"
+	"devlab/internal/types"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
+	zerologlog "github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // CleanupManager handles cleanup operations for scenarios
 type CleanupManager struct {
-	cfg    *config.Config
-	db     *mongo.Database
-	docker docker.Client
+	cfg       *config.Config
+	store     storage.Store
+	docker    docker.Client
+	publisher Publisher
+	webhook   *WebhookNotifier
+	now       func() time.Time
+}
+
+// Publisher delivers cleanup notifications, e.g. the RabbitMQ queue manager
+type Publisher interface {
+	PublishMessage(ctx context.Context, queueName string, message interface{}) error
 }
 
 // NewCleanupManager creates a new cleanup manager
 func NewCleanupManager(cfg *config.Config, db *mongo.Database, dockerClient docker.Client) *CleanupManager {
 	return &CleanupManager{
 		cfg:    cfg,
-		db:     db,
+		store:  storage.NewMongoStore(db),
 		docker: dockerClient,
 	}
 }
 
+// SetPublisher enables expiry warnings, published to queue.ScenarioEventsQueue
+func (cm *CleanupManager) SetPublisher(publisher Publisher) {
+	cm.publisher = publisher
+}
+
+// SetWebhook enables HTTP notifications of expiry warnings and cleanups.
+// Deliveries run in the background so a slow endpoint never holds up cleanup.
+func (cm *CleanupManager) SetWebhook(webhook *WebhookNotifier) {
+	cm.webhook = webhook
+}
+
+// notifyWebhook sends event to the webhook in the background, logging failures
+func (cm *CleanupManager) notifyWebhook(ctx context.Context, event queue.ScenarioEvent) {
+	if cm.webhook == nil {
+		return
+	}
+	go func() {
+		if err := cm.webhook.Notify(context.WithoutCancel(ctx), event); err != nil {
+			log.Printf("[cleanup] %v", err)
+		}
+	}()
+}
+
 // CleanupExpiredScenarios removes scenarios that have exceeded their lifetime
+// and warns about those about to
 func (cm *CleanupManager) CleanupExpiredScenarios(ctx context.Context) error {
 	log.Println("[cleanup] starting expired scenario cleanup")
 
-	// Get cleanup configuration
-	maxAge := cm.cfg.Cleanup.MaxScenarioAge
-	if maxAge == 0 {
-		maxAge = 24 * time.Hour // Default to 24 hours
-	}
+	policy := newReapPolicy(cm.cfg.Cleanup)
+	now := cm.clock()
 
-	// Find expired scenarios
-	expiredScenarios, err := cm.findExpiredScenarios(ctx, maxAge)
+	scenarios, err := cm.expiryCandidates(ctx, now, policy)
 	if err != nil {
 		return fmt.Errorf("failed to find expired scenarios: %w", err)
 	}
 
-	log.Printf("[cleanup] found %d expired scenarios", len(expiredScenarios))
+	cm.warnExpiringScenarios(ctx, scenarios, now, policy)
 
-	// Clean up each expired scenario
+	// Find expired scenarios
+	expiredScenarios := filterExpired(scenarios, now, policy)
+	zerologlog.Debug().Msgf("[cleanup] found %d expired scenarios", len(expiredScenarios))
+
+	// Clean up the expired scenarios, a bounded number at a time so a burst
+	// of expiries neither lags behind nor floods the Docker daemon
+	workers := min(cm.maxConcurrentCleanups(), len(expiredScenarios))
+	jobs := make(chan *storage.Scenario)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for scenario := range jobs {
+				cm.cleanupExpired(ctx, scenario, policy)
+			}
+		}()
+	}
 	for _, scenario := range expiredScenarios {
-		if err := cm.cleanupScenario(ctx, scenario); err != nil {
-			log.Printf("[cleanup] failed to cleanup scenario %s: %v", scenario.ScenarioID, err)
-			continue
-		}
-		log.Printf("[cleanup] successfully cleaned up scenario %s", scenario.ScenarioID)
+		jobs <- scenario
 	}
+	close(jobs)
+	wg.Wait()
 
 	return nil
 }
 
-// CleanupOrphanedContainers removes containers that are not associated with any scenario
-func (cm *CleanupManager) CleanupOrphanedContainers(ctx context.Context) error {
+// expiryCandidates returns the active scenarios that may be due for cleanup,
+// or for an expiry warning, at now. In age mode only those whose stored
+// expiry falls within the warning window are loaded, through the expires_at
+// index. Inactivity depends on each scenario's last activity, so that mode
+// still checks every active scenario.
+func (cm *CleanupManager) expiryCandidates(ctx context.Context, now time.Time, policy reapPolicy) ([]*storage.Scenario, error) {
+	if policy.mode == ReapModeInactivity {
+		return cm.store.ListScenariosByStatus(ctx, types.ActiveScenarioStatuses...)
+	}
+	by := now
+	if window := cm.cfg.Cleanup.WarningWindow; window > 0 && (cm.publisher != nil || cm.webhook != nil) {
+		by = now.Add(window)
+	}
+	return cm.store.ListScenariosExpiringBy(ctx, by, types.ActiveScenarioStatuses...)
+}
+
+// cleanupExpired cleans up one expired scenario and sends its webhook
+// notice. A failure, even a panic, is logged and affects no other scenario.
+func (cm *CleanupManager) cleanupExpired(ctx context.Context, scenario *storage.Scenario, policy reapPolicy) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[cleanup] panic while cleaning up scenario %s: %v", scenario.ScenarioID, r)
+		}
+	}()
+
+	if err := cm.cleanupScenario(ctx, scenario); err != nil {
+		log.Printf("[cleanup] failed to cleanup scenario %s: %v", scenario.ScenarioID, err)
+		return
+	}
+	log.Printf("[cleanup] successfully cleaned up scenario %s", scenario.ScenarioID)
+	cm.notifyWebhook(ctx, queue.ScenarioEvent{
+		Event:      queue.EventScenarioCleanedUp,
+		ScenarioID: scenario.ScenarioID,
+		UserID:     scenario.UserID,
+		ExpiresAt:  policy.expiresAt(scenario),
+	})
+}
+
+// maxConcurrentCleanups returns how many expired scenarios are cleaned up at
+// once; an unset limit cleans them up one at a time
+func (cm *CleanupManager) maxConcurrentCleanups() int {
+	if cm.cfg.Cleanup.MaxConcurrent <= 0 {
+		return 1
+	}
+	return cm.cfg.Cleanup.MaxConcurrent
+}
+
+// CleanupReport records the outcome of one orphaned container cleanup pass
+type CleanupReport struct {
+	// Pruned lists the stopped orphans removed in a single batch
+	Pruned []string
+	// Results holds one entry per orphan removed individually
+	Results []ContainerCleanupResult
+}
+
+// ContainerCleanupResult is the outcome of removing one orphaned container
+type ContainerCleanupResult struct {
+	ContainerID string
+	Removed     bool
+	// Err is set when the container could not be removed
+	Err error
+}
+
+// Failed returns the results of the containers that could not be removed
+func (r *CleanupReport) Failed() []ContainerCleanupResult {
+	var failed []ContainerCleanupResult
+	for _, result := range r.Results {
+		if !result.Removed {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// CleanupOrphanedContainers removes containers that are not associated with
+// any scenario, or only with an inactive one past its expiry, such as a
+// container kept for debugging after it failed to come up. The container of
+// a locked scenario is never touched. A container that fails to stop or be removed is recorded in
+// the report and never keeps the remaining ones from being cleaned up; an
+// error is only returned when the orphans cannot be determined at all.
+func (cm *CleanupManager) CleanupOrphanedContainers(ctx context.Context) (*CleanupReport, error) {
 	log.Println("[cleanup] starting orphaned container cleanup")
 
 	// Get all running containers
 	containers, err := cm.docker.ListContainers(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to list containers: %w", err)
+		return nil, fmt.Errorf("failed to list containers: %w", err)
 	}
 
 	// Get all scenario container IDs from database
 	scenarioContainers, err := cm.getScenarioContainerIDs(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get scenario container IDs: %w", err)
+		return nil, fmt.Errorf("failed to get scenario container IDs: %w", err)
 	}
 
 	// Find orphaned containers
-	var orphanedCount int
+	var orphaned, stopped []docker.ContainerInfo
 	for _, container := range containers {
-		if !cm.isScenarioContainer(container.ID, scenarioContainers) {
-			log.Printf("[cleanup] found orphaned container: %s", container.ID)
-
-			// Stop and remove the orphaned container
-			if err := cm.docker.StopContainer(ctx, container.ID); err != nil {
-				log.Printf("[cleanup] failed to stop orphaned container %s: %v", container.ID, err)
-				continue
-			}
+		if cm.isScenarioContainer(container.ID, scenarioContainers) {
+			continue
+		}
+		log.Printf("[cleanup] found orphaned container: %s", container.ID)
+		if isStoppedContainer(container) {
+			stopped = append(stopped, container)
+		} else {
+			orphaned = append(orphaned, container)
+		}
+	}
 
-			if err := cm.docker.RemoveContainer(ctx, container.ID); err != nil {
-				log.Printf("[cleanup] failed to remove orphaned container %s: %v", container.ID, err)
-				continue
+	report := &CleanupReport{}
+
+	// Many stopped orphans are removed in one batch rather than one stop and
+	// remove each. Only the orphans found above are named: a label prune would
+	// also reach the stopped containers of scenarios that are kept, such as
+	// locked ones.
+	threshold := cm.cfg.Cleanup.PruneThreshold
+	if threshold > 0 && len(stopped) >= threshold {
+		ids := make([]string, 0, len(stopped))
+		for _, container := range stopped {
+			ids = append(ids, container.ID)
+		}
+		batch, err := cm.docker.RemoveStoppedContainers(ctx, ids)
+		if err != nil {
+			log.Printf("[cleanup] failed to batch remove stopped containers, removing the rest individually: %v", err)
+		}
+		log.Printf("[cleanup] batch removed %d stopped orphaned containers", len(batch.ContainersDeleted))
+		report.Pruned = batch.ContainersDeleted
+		removed := make(map[string]bool, len(batch.ContainersDeleted))
+		for _, id := range batch.ContainersDeleted {
+			removed[id] = true
+		}
+		for _, container := range stopped {
+			if !removed[container.ID] {
+				orphaned = append(orphaned, container)
 			}
+		}
+	} else {
+		orphaned = append(orphaned, stopped...)
+	}
 
-			orphanedCount++
+	for _, container := range orphaned {
+		result := cm.removeOrphan(ctx, container.ID)
+		if result.Err != nil {
+			log.Printf("[cleanup] failed to clean up orphaned container %s: %v", container.ID, result.Err)
+		} else {
 			log.Printf("[cleanup] successfully cleaned up orphaned container %s", container.ID)
 		}
+		report.Results = append(report.Results, result)
+	}
+
+	zerologlog.Debug().Msgf("[cleanup] cleaned up %d orphaned containers, %d failed",
+		len(report.Pruned)+len(report.Results)-len(report.Failed()), len(report.Failed()))
+	return report, nil
+}
+
+// haltContainer stops a container gracefully, or kills it when
+// Cleanup.KillContainers is set
+func (cm *CleanupManager) haltContainer(ctx context.Context, containerID string) error {
+	if cm.cfg != nil && cm.cfg.Cleanup.KillContainers {
+		return cm.docker.KillContainer(ctx, containerID)
+	}
+	return cm.docker.StopContainer(ctx, containerID)
+}
+
+// removeOrphan halts and removes one orphaned container. Removal is attempted
+// even when halting fails, since removing also stops a running container.
+func (cm *CleanupManager) removeOrphan(ctx context.Context, containerID string) ContainerCleanupResult {
+	stopErr := cm.haltContainer(ctx, containerID)
+	if stopErr != nil {
+		log.Printf("[cleanup] failed to stop orphaned container %s, removing anyway: %v", containerID, stopErr)
+	}
+
+	if err := cm.docker.RemoveContainer(ctx, containerID); err != nil {
+		if stopErr != nil {
+			err = errors.Join(fmt.Errorf("stop: %w", stopErr), fmt.Errorf("remove: %w", err))
+		}
+		return ContainerCleanupResult{ContainerID: containerID, Err: err}
 	}
+	return ContainerCleanupResult{ContainerID: containerID, Removed: true}
+}
+
+// isStoppedContainer reports whether Docker lists the container as not running
+func isStoppedContainer(container docker.ContainerInfo) bool {
+	return !strings.HasPrefix(container.Status, "Up")
+}
 
-	log.Printf("[cleanup] cleaned up %d orphaned containers", orphanedCount)
+// CleanupUnusedSnapshots removes snapshot images older than the configured
+// SnapshotMaxAge whose scenario is no longer active. Base scenario images and
+// the configured default images are never removed, even if mislabelled.
+func (cm *CleanupManager) CleanupUnusedSnapshots(ctx context.Context) error {
+	maxAge := cm.cfg.Cleanup.SnapshotMaxAge
+	if maxAge <= 0 {
+		return nil
+	}
+
+	log.Println("[cleanup] starting snapshot image cleanup")
+
+	images, err := cm.docker.ListImages(ctx, docker.SnapshotLabelFilter)
+	if err != nil {
+		return fmt.Errorf("failed to list snapshot images: %w", err)
+	}
+
+	activeScenarios, err := cm.store.ListScenariosByStatus(ctx, types.ActiveScenarioStatuses...)
+	if err != nil {
+		return fmt.Errorf("failed to list active scenarios: %w", err)
+	}
+	referenced := make(map[string]bool, len(activeScenarios))
+	for _, scenario := range activeScenarios {
+		referenced[scenario.ScenarioID] = true
+	}
+
+	cutoff := cm.clock().Add(-maxAge)
+	var removedCount int
+	for _, image := range images {
+		if cm.isProtectedImage(image) || referenced[image.Labels[docker.ScenarioIDLabel]] || image.CreatedAt.After(cutoff) {
+			continue
+		}
+
+		if err := cm.docker.RemoveImage(ctx, image.ID); err != nil {
+			log.Printf("[cleanup] failed to remove snapshot image %s: %v", image.ID, err)
+			continue
+		}
+		removedCount++
+	}
+
+	zerologlog.Debug().Msgf("[cleanup] removed %d unused snapshot images", removedCount)
 	return nil
 }
 
+// isProtectedImage reports whether image is tagged as a base or configured
+// default image
+func (cm *CleanupManager) isProtectedImage(image docker.ImageInfo) bool {
+	for _, tag := range image.Tags {
+		if docker.IsBaseImage(tag) || tag == cm.cfg.DefaultScenarioImage || tag == cm.cfg.DockerImage {
+			return true
+		}
+	}
+	return false
+}
+
 // RunPeriodicCleanup runs cleanup operations periodically
 func (cm *CleanupManager) RunPeriodicCleanup(ctx context.Context, interval time.Duration) {
 	log.Printf("[cleanup] starting periodic cleanup with interval: %v", interval)
@@ -120,34 +366,150 @@ func (cm *CleanupManager) RunPeriodicCleanup(ctx context.Context, interval time.
 				log.Printf("[cleanup] error cleaning up expired scenarios: %v", err)
 			}
 
-			if err := cm.CleanupOrphanedContainers(ctx); err != nil {
+			if report, err := cm.CleanupOrphanedContainers(ctx); err != nil {
 				log.Printf("[cleanup] error cleaning up orphaned containers: %v", err)
+			} else if failed := report.Failed(); len(failed) > 0 {
+				log.Printf("[cleanup] %d orphaned containers could not be removed", len(failed))
+			}
+
+			if err := cm.CleanupUnusedSnapshots(ctx); err != nil {
+				log.Printf("[cleanup] error cleaning up snapshot images: %v", err)
 			}
 		}
 	}
 }
 
-// findExpiredScenarios finds scenarios that have exceeded the maximum age
-func (cm *CleanupManager) findExpiredScenarios(ctx context.Context, maxAge time.Duration) ([]*storage.Scenario, error) {
-	cutoffTime := time.Now().Add(-maxAge)
+// warnExpiringScenarios publishes a scenario.expiring_soon event, and sends it
+// to the webhook, for each active scenario within the warning window of its
+// expiry. The warning is recorded
+// against that expiry time, so it is sent once, and again only if the scenario
+// is extended and later approaches its new expiry.
+func (cm *CleanupManager) warnExpiringScenarios(ctx context.Context, scenarios []*storage.Scenario, now time.Time, policy reapPolicy) {
+	window := cm.cfg.Cleanup.WarningWindow
+	if (cm.publisher == nil && cm.webhook == nil) || window <= 0 {
+		return
+	}
+
+	for _, scenario := range scenarios {
+		// Locked scenarios are not going to be cleaned up
+		if scenario.Locked {
+			continue
+		}
+		expiresAt := policy.expiresAt(scenario)
+		if !now.Before(expiresAt) || now.Before(expiresAt.Add(-window)) {
+			continue
+		}
+		if scenario.ExpiryWarnedFor.Equal(expiresAt) {
+			continue
+		}
 
-	filter := bson.M{
-		"created_at": bson.M{"$lt": cutoffTime},
-		"status":     bson.M{"$in": []string{"running", "provisioning"}},
+		event := queue.ScenarioEvent{
+			Event:      queue.EventScenarioExpiringSoon,
+			ScenarioID: scenario.ScenarioID,
+			UserID:     scenario.UserID,
+			ExpiresAt:  expiresAt,
+		}
+		if cm.publisher != nil {
+			if err := cm.publisher.PublishMessage(ctx, queue.ScenarioEventsQueue, event); err != nil {
+				log.Printf("[cleanup] failed to publish expiry warning for scenario %s: %v", scenario.ScenarioID, err)
+				continue
+			}
+		}
+		cm.notifyWebhook(ctx, event)
+
+		// Only the warning is recorded, so a heartbeat, extension or stop
+		// made since the scan started is not overwritten
+		scenario.ExpiryWarnedFor = expiresAt
+		if err := cm.store.MarkExpiryWarned(ctx, scenario.ScenarioID, expiresAt); err != nil {
+			log.Printf("[cleanup] failed to record expiry warning for scenario %s: %v", scenario.ScenarioID, err)
+			continue
+		}
+		log.Printf("[cleanup] warned that scenario %s expires at %s", scenario.ScenarioID, expiresAt.Format(time.RFC3339))
 	}
+}
 
-	cursor, err := cm.db.Collection("scenarios").Find(ctx, filter)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query expired scenarios: %w", err)
+// clock returns the current time, overridable in tests
+func (cm *CleanupManager) clock() time.Time {
+	if cm.now != nil {
+		return cm.now()
 	}
-	defer cursor.Close(ctx)
+	return time.Now()
+}
+
+// Reap modes for CleanupConfig.ReapMode
+const (
+	ReapModeAge        = "age"
+	ReapModeInactivity = "inactivity"
+)
+
+// reapPolicy decides when an active scenario is due for cleanup
+type reapPolicy struct {
+	mode        string
+	cfg         config.CleanupConfig
+	maxLifetime time.Duration
+}
 
-	var scenarios []*storage.Scenario
-	if err = cursor.All(ctx, &scenarios); err != nil {
-		return nil, fmt.Errorf("failed to decode expired scenarios: %w", err)
+func newReapPolicy(cfg config.CleanupConfig) reapPolicy {
+	policy := reapPolicy{
+		mode:        cfg.ReapMode,
+		cfg:         cfg,
+		maxLifetime: cfg.MaxScenarioLifetime,
+	}
+	if policy.maxLifetime == 0 {
+		policy.maxLifetime = 72 * time.Hour
 	}
+	return policy
+}
 
-	return scenarios, nil
+// maxAge returns the max age for scenarioType, honouring per-type overrides
+func (p reapPolicy) maxAge(scenarioType string) time.Duration {
+	if maxAge := p.cfg.MaxScenarioAgeFor(scenarioType); maxAge > 0 {
+		return maxAge
+	}
+	return 24 * time.Hour // Default to 24 hours
+}
+
+// idleTimeout returns the idle timeout for scenarioType, honouring per-type overrides
+func (p reapPolicy) idleTimeout(scenarioType string) time.Duration {
+	if idleTimeout := p.cfg.IdleTimeoutFor(scenarioType); idleTimeout > 0 {
+		return idleTimeout
+	}
+	return time.Hour
+}
+
+// expiresAt returns when scenario becomes due for cleanup. Age mode uses the
+// scenario's expiry (ExpiresAt, or created_at + its type's max age for older
+// records). Inactivity mode reaps after its type's idle timeout without
+// activity, and still enforces the maximum lifetime so an always-polled
+// scenario cannot live forever. A paused scenario is idle by design, so only
+// the lifetime applies to it.
+func (p reapPolicy) expiresAt(scenario *storage.Scenario) time.Time {
+	if p.mode == ReapModeInactivity {
+		idleAt := scenario.LastActivity().Add(p.idleTimeout(scenario.ScenarioType))
+		lifetimeAt := scenario.CreatedAt.Add(p.maxLifetime)
+		if scenario.Status == types.ScenarioStatusPaused || lifetimeAt.Before(idleAt) {
+			return lifetimeAt
+		}
+		return idleAt
+	}
+	return scenario.Expiry(p.maxAge(scenario.ScenarioType))
+}
+
+// expired reports whether scenario should be reaped at now
+func (p reapPolicy) expired(scenario *storage.Scenario, now time.Time) bool {
+	return !now.Before(p.expiresAt(scenario))
+}
+
+// filterExpired returns the scenarios policy considers due for cleanup at
+// now. Locked scenarios are never due.
+func filterExpired(scenarios []*storage.Scenario, now time.Time, policy reapPolicy) []*storage.Scenario {
+	var expired []*storage.Scenario
+	for _, scenario := range scenarios {
+		if !scenario.Locked && policy.expired(scenario, now) {
+			expired = append(expired, scenario)
+		}
+	}
+	return expired
 }
 
 // cleanupScenario stops and removes a scenario and its container
@@ -164,9 +526,8 @@ func (cm *CleanupManager) cleanupScenario(ctx context.Context, scenario *storage
 			status, err := cm.docker.GetContainerStatus(ctx, scenario.ContainerID)
 			if err != nil {
 				log.Printf("[cleanup] failed to get container status for %s: %v", scenario.ContainerID, err)
-			} else if status == "running" {
-				// Stop the container
-				if err := cm.docker.StopContainer(ctx, scenario.ContainerID); err != nil {
+			} else if status == "running" || status == "paused" {
+				if err := cm.haltContainer(ctx, scenario.ContainerID); err != nil {
 					log.Printf("[cleanup] failed to stop container %s: %v", scenario.ContainerID, err)
 				}
 			}
@@ -179,36 +540,37 @@ func (cm *CleanupManager) cleanupScenario(ctx context.Context, scenario *storage
 	}
 
 	// Update scenario status to cleaned up
-	scenario.Status = "cleaned_up"
+	scenario.SetStatus(types.ScenarioStatusCleanedUp, "expired")
+	scenario.StopReason = types.StopReasonExpired
 	scenario.UpdatedAt = time.Now()
 
-	if err := storage.UpdateScenario(ctx, cm.db, scenario); err != nil {
+	policy := storage.UpdateRetryPolicy{Retries: cm.cfg.Mongo.UpdateRetries, Backoff: cm.cfg.Mongo.UpdateBackoff}
+	if err := storage.UpdateScenarioWithRetry(ctx, cm.store, scenario, policy); err != nil {
 		return fmt.Errorf("failed to update scenario status: %w", err)
 	}
 
 	return nil
 }
 
-// getScenarioContainerIDs gets all container IDs associated with scenarios
+// getScenarioContainerIDs gets all container IDs associated with scenarios,
+// leaving out those of inactive, unlocked scenarios past their expiry
 func (cm *CleanupManager) getScenarioContainerIDs(ctx context.Context) (map[string]bool, error) {
-	filter := bson.M{"container_id": bson.M{"$ne": ""}}
-
-	cursor, err := cm.db.Collection("scenarios").Find(ctx, filter)
+	scenarios, err := cm.store.ListScenarios(ctx, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query scenario container IDs: %w", err)
 	}
-	defer cursor.Close(ctx)
 
+	now := cm.clock()
 	containerIDs := make(map[string]bool)
-	for cursor.Next(ctx) {
-		var scenario storage.Scenario
-		if err := cursor.Decode(&scenario); err != nil {
-			log.Printf("[cleanup] failed to decode scenario: %v", err)
+	for _, scenario := range scenarios {
+		if scenario.ContainerID == "" {
 			continue
 		}
-		if scenario.ContainerID != "" {
-			containerIDs[scenario.ContainerID] = true
+		expired := !scenario.ExpiresAt.IsZero() && !now.Before(scenario.ExpiresAt)
+		if expired && !scenario.Status.Active() && !scenario.Locked {
+			continue
 		}
+		containerIDs[scenario.ContainerID] = true
 	}
 
 	return containerIDs, nil
@@ -4,11 +4,20 @@ import (
 	"context"
 	"devlab/internal/config"
 	"devlab/internal/docker"
+	"devlab/internal/queue"
+	"devlab/internal/retry"
+	"devlab/internal/storage"
+	"devlab/internal/storage/storagetest"
+	"devlab/internal/types"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockDockerClient is a mock implementation of the docker.Client interface
@@ -16,8 +25,8 @@ type MockDockerClient struct {
 	mock.Mock
 }
 
-func (m *MockDockerClient) StartScenarioContainer(ctx context.Context, scenarioType, script string) (string, int, error) {
-	args := m.Called(ctx, scenarioType, script)
+func (m *MockDockerClient) StartScenarioContainer(ctx context.Context, spec docker.ContainerSpec) (string, int, error) {
+	args := m.Called(ctx, spec)
 	return args.String(0), args.Int(1), args.Error(2)
 }
 
@@ -41,11 +50,21 @@ func (m *MockDockerClient) ContainerExists(ctx context.Context, containerID stri
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockDockerClient) ExecuteCommand(ctx context.Context, containerID string, command []string) (string, error) {
+func (m *MockDockerClient) ExecuteCommand(ctx context.Context, containerID string, command []string, opts docker.ExecuteCommandOpts) (string, error) {
 	args := m.Called(ctx, containerID, command)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockDockerClient) RunCommand(ctx context.Context, containerID string, command []string, opts docker.ExecuteCommandOpts) (docker.ExitResult, error) {
+	args := m.Called(ctx, containerID, command)
+	return args.Get(0).(docker.ExitResult), args.Error(1)
+}
+
+func (m *MockDockerClient) HostLoad(ctx context.Context) (docker.HostLoad, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(docker.HostLoad), args.Error(1)
+}
+
 func (m *MockDockerClient) ListContainers(ctx context.Context) ([]docker.ContainerInfo, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]docker.ContainerInfo), args.Error(1)
@@ -56,6 +75,67 @@ func (m *MockDockerClient) RemoveContainer(ctx context.Context, containerID stri
 	return args.Error(0)
 }
 
+func (m *MockDockerClient) PruneStoppedContainers(ctx context.Context, labelFilter string) (docker.PruneReport, error) {
+	args := m.Called(ctx, labelFilter)
+	return args.Get(0).(docker.PruneReport), args.Error(1)
+}
+
+func (m *MockDockerClient) RemoveStoppedContainers(ctx context.Context, containerIDs []string) (docker.PruneReport, error) {
+	args := m.Called(ctx, containerIDs)
+	return args.Get(0).(docker.PruneReport), args.Error(1)
+}
+
+func (m *MockDockerClient) ListImages(ctx context.Context, labelFilter string) ([]docker.ImageInfo, error) {
+	args := m.Called(ctx, labelFilter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]docker.ImageInfo), args.Error(1)
+}
+
+func (m *MockDockerClient) RemoveImage(ctx context.Context, imageID string) error {
+	args := m.Called(ctx, imageID)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) WaitForExit(ctx context.Context, containerID string) (docker.ExitResult, error) {
+	args := m.Called(ctx, containerID)
+	return args.Get(0).(docker.ExitResult), args.Error(1)
+}
+
+func (m *MockDockerClient) RebindTerminalPort(ctx context.Context, containerID string, spec docker.RebindSpec) (string, int, error) {
+	args := m.Called(ctx, containerID, spec)
+	return args.String(0), args.Int(1), args.Error(2)
+}
+
+func (m *MockDockerClient) ImageExists(ctx context.Context, image string) (bool, error) {
+	args := m.Called(ctx, image)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDockerClient) KillContainer(ctx context.Context, containerID string) error {
+	args := m.Called(ctx, containerID)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) PauseContainer(ctx context.Context, containerID string) error {
+	args := m.Called(ctx, containerID)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) UnpauseContainer(ctx context.Context, containerID string) error {
+	args := m.Called(ctx, containerID)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) FollowLogs(ctx context.Context, containerID string, tail int) (*docker.LogStream, error) {
+	args := m.Called(ctx, containerID, tail)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*docker.LogStream), args.Error(1)
+}
+
 func TestCleanupManager_isScenarioContainer(t *testing.T) {
 	// Setup
 	cfg := &config.Config{}
@@ -135,3 +215,670 @@ func TestCleanupManager_OrphanedContainerDetection(t *testing.T) {
 			"Container %s should be orphaned: %v", tc.containerID, tc.isOrphaned)
 	}
 }
+
+func TestCleanupOrphanedContainers_BatchRemovesManyStoppedOrphans(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Cleanup: config.CleanupConfig{PruneThreshold: 2}}
+	mockDocker := &MockDockerClient{}
+	cleanupManager := NewCleanupManager(cfg, nil, mockDocker)
+	cleanupManager.store = storage.NewMemoryStore(
+		&storage.Scenario{ScenarioID: "s1", ContainerID: "owned"},
+		&storage.Scenario{ScenarioID: "s2", ContainerID: "locked-stopped", Status: types.ScenarioStatusStopped, Locked: true},
+	)
+
+	mockDocker.On("ListContainers", ctx).Return([]docker.ContainerInfo{
+		{ID: "owned", Status: "Up 2 hours"},
+		{ID: "locked-stopped", Status: "Exited (0) 1 day ago"},
+		{ID: "exited-1", Status: "Exited (0) 3 hours ago"},
+		{ID: "exited-2", Status: "Exited (137) 1 hour ago"},
+		{ID: "exited-3", Status: "Exited (0) 2 days ago"},
+		{ID: "running-orphan", Status: "Up 5 minutes"},
+	}, nil)
+	mockDocker.On("RemoveStoppedContainers", ctx, []string{"exited-1", "exited-2", "exited-3"}).
+		Return(docker.PruneReport{ContainersDeleted: []string{"exited-1", "exited-2"}}, errors.New("exited-3: daemon busy"))
+	// Orphans the batch did not remove are still removed individually
+	for _, id := range []string{"running-orphan", "exited-3"} {
+		mockDocker.On("StopContainer", ctx, id).Return(nil)
+		mockDocker.On("RemoveContainer", ctx, id).Return(nil)
+	}
+
+	report, err := cleanupManager.CleanupOrphanedContainers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"exited-1", "exited-2"}, report.Pruned)
+	assert.Len(t, report.Results, 2)
+	assert.Empty(t, report.Failed())
+
+	mockDocker.AssertExpectations(t)
+	mockDocker.AssertNotCalled(t, "PruneStoppedContainers", mock.Anything, mock.Anything)
+	mockDocker.AssertNotCalled(t, "StopContainer", ctx, "exited-1")
+	mockDocker.AssertNotCalled(t, "StopContainer", ctx, "owned")
+	mockDocker.AssertNotCalled(t, "RemoveContainer", ctx, "locked-stopped")
+}
+
+func TestCleanupOrphanedContainers_FewStoppedOrphansRemovedIndividually(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Cleanup: config.CleanupConfig{PruneThreshold: 5}}
+	mockDocker := &MockDockerClient{}
+	cleanupManager := NewCleanupManager(cfg, nil, mockDocker)
+	cleanupManager.store = storage.NewMemoryStore()
+
+	mockDocker.On("ListContainers", ctx).Return([]docker.ContainerInfo{
+		{ID: "exited-1", Status: "Exited (0) 3 hours ago"},
+	}, nil)
+	mockDocker.On("StopContainer", ctx, "exited-1").Return(nil)
+	mockDocker.On("RemoveContainer", ctx, "exited-1").Return(nil)
+
+	report, err := cleanupManager.CleanupOrphanedContainers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []ContainerCleanupResult{{ContainerID: "exited-1", Removed: true}}, report.Results)
+
+	mockDocker.AssertExpectations(t)
+	mockDocker.AssertNotCalled(t, "RemoveStoppedContainers", mock.Anything, mock.Anything)
+}
+
+func TestCleanupOrphanedContainers_ReportsFailuresPerContainer(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{}
+	mockDocker := &MockDockerClient{}
+	cleanupManager := NewCleanupManager(cfg, nil, mockDocker)
+	cleanupManager.store = storage.NewMemoryStore()
+
+	mockDocker.On("ListContainers", ctx).Return([]docker.ContainerInfo{
+		{ID: "orphan-1", Status: "Up 1 hour"},
+		{ID: "stuck", Status: "Up 2 hours"},
+		{ID: "unstoppable", Status: "Up 3 hours"},
+		{ID: "orphan-2", Status: "Up 4 hours"},
+	}, nil)
+	for _, id := range []string{"orphan-1", "orphan-2"} {
+		mockDocker.On("StopContainer", ctx, id).Return(nil)
+		mockDocker.On("RemoveContainer", ctx, id).Return(nil)
+	}
+	// A failed stop is not final: removal stops the container itself
+	mockDocker.On("StopContainer", ctx, "unstoppable").Return(errors.New("stop timed out"))
+	mockDocker.On("RemoveContainer", ctx, "unstoppable").Return(nil)
+	mockDocker.On("StopContainer", ctx, "stuck").Return(errors.New("stop timed out"))
+	mockDocker.On("RemoveContainer", ctx, "stuck").Return(errors.New("device or resource busy"))
+
+	report, err := cleanupManager.CleanupOrphanedContainers(ctx)
+	require.NoError(t, err)
+
+	mockDocker.AssertExpectations(t)
+	require.Len(t, report.Results, 4)
+	failed := report.Failed()
+	require.Len(t, failed, 1)
+	assert.Equal(t, "stuck", failed[0].ContainerID)
+	assert.ErrorContains(t, failed[0].Err, "stop timed out")
+	assert.ErrorContains(t, failed[0].Err, "device or resource busy")
+	for _, result := range report.Results {
+		if result.ContainerID != "stuck" {
+			assert.True(t, result.Removed, result.ContainerID)
+		}
+	}
+}
+
+func TestCleanupUnusedSnapshots_RemovesOnlyUnreferencedOldSnapshots(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	old := now.Add(-10 * 24 * time.Hour)
+	cfg := &config.Config{
+		DefaultScenarioImage: "devlab-custom:latest",
+		Cleanup:              config.CleanupConfig{SnapshotMaxAge: 7 * 24 * time.Hour},
+	}
+	mockDocker := &MockDockerClient{}
+	cleanupManager := NewCleanupManager(cfg, nil, mockDocker)
+	cleanupManager.now = func() time.Time { return now }
+	cleanupManager.store = storage.NewMemoryStore(
+		&storage.Scenario{ScenarioID: "running", Status: types.ScenarioStatusRunning},
+		&storage.Scenario{ScenarioID: "stopped", Status: types.ScenarioStatusStopped},
+	)
+
+	snapshot := func(id, scenarioID string, createdAt time.Time, tags ...string) docker.ImageInfo {
+		return docker.ImageInfo{
+			ID:        id,
+			Tags:      tags,
+			Labels:    map[string]string{docker.SnapshotLabel: "true", docker.ScenarioIDLabel: scenarioID},
+			CreatedAt: createdAt,
+		}
+	}
+	mockDocker.On("ListImages", ctx, docker.SnapshotLabelFilter).Return([]docker.ImageInfo{
+		snapshot("sha256:stopped-old", "stopped", old),
+		snapshot("sha256:gone-old", "deleted", old),
+		snapshot("sha256:stopped-new", "stopped", now.Add(-time.Hour)),
+		snapshot("sha256:running-old", "running", old),
+		snapshot("sha256:base", "stopped", old, "devlab-go:latest"),
+		snapshot("sha256:default", "stopped", old, "devlab-custom:latest"),
+	}, nil)
+	mockDocker.On("RemoveImage", ctx, "sha256:stopped-old").Return(nil)
+	mockDocker.On("RemoveImage", ctx, "sha256:gone-old").Return(nil)
+
+	require.NoError(t, cleanupManager.CleanupUnusedSnapshots(ctx))
+
+	mockDocker.AssertExpectations(t)
+	mockDocker.AssertNumberOfCalls(t, "RemoveImage", 2)
+}
+
+func TestCleanupUnusedSnapshots_DisabledWithoutMaxAge(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	cleanupManager := NewCleanupManager(&config.Config{}, nil, mockDocker)
+
+	require.NoError(t, cleanupManager.CleanupUnusedSnapshots(context.Background()))
+	mockDocker.AssertNotCalled(t, "ListImages", mock.Anything, mock.Anything)
+}
+
+func TestFilterExpired_RespectsExtendedExpiry(t *testing.T) {
+	now := time.Now()
+	maxAge := 24 * time.Hour
+
+	legacy := &storage.Scenario{ScenarioID: "scn-legacy", CreatedAt: now.Add(-25 * time.Hour)}
+	extended := &storage.Scenario{ScenarioID: "scn-extended", CreatedAt: now.Add(-25 * time.Hour), ExpiresAt: now.Add(time.Hour)}
+	lapsed := &storage.Scenario{ScenarioID: "scn-lapsed", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Minute)}
+	fresh := &storage.Scenario{ScenarioID: "scn-fresh", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(23 * time.Hour)}
+
+	expired := filterExpired([]*storage.Scenario{legacy, extended, lapsed, fresh}, now, newReapPolicy(config.CleanupConfig{MaxScenarioAge: maxAge}))
+
+	assert.Equal(t, []*storage.Scenario{legacy, lapsed}, expired)
+}
+
+func TestFilterExpired_AgeVersusInactivity(t *testing.T) {
+	now := time.Now()
+
+	// Old but in active use: only age-based reaping kills it
+	activeOld := &storage.Scenario{ScenarioID: "scn-active-old", CreatedAt: now.Add(-25 * time.Hour), LastActivityAt: now.Add(-5 * time.Minute)}
+	// Young but abandoned: only inactivity-based reaping kills it
+	idleYoung := &storage.Scenario{ScenarioID: "scn-idle-young", CreatedAt: now.Add(-3 * time.Hour), LastActivityAt: now.Add(-2 * time.Hour)}
+	// Never touched since creation: inactivity falls back to created_at
+	untouched := &storage.Scenario{ScenarioID: "scn-untouched", CreatedAt: now.Add(-90 * time.Minute)}
+	// Polled constantly but past the maximum lifetime
+	ancient := &storage.Scenario{ScenarioID: "scn-ancient", CreatedAt: now.Add(-73 * time.Hour), LastActivityAt: now}
+	// Fresh and in use: never reaped
+	activeYoung := &storage.Scenario{ScenarioID: "scn-active-young", CreatedAt: now.Add(-time.Hour), LastActivityAt: now.Add(-time.Minute)}
+
+	scenarios := []*storage.Scenario{activeOld, idleYoung, untouched, ancient, activeYoung}
+	cleanupCfg := config.CleanupConfig{
+		MaxScenarioAge:      24 * time.Hour,
+		IdleTimeout:         time.Hour,
+		MaxScenarioLifetime: 72 * time.Hour,
+	}
+
+	cleanupCfg.ReapMode = ReapModeAge
+	ageExpired := filterExpired(scenarios, now, newReapPolicy(cleanupCfg))
+	assert.Equal(t, []*storage.Scenario{activeOld, ancient}, ageExpired)
+
+	cleanupCfg.ReapMode = ReapModeInactivity
+	idleExpired := filterExpired(scenarios, now, newReapPolicy(cleanupCfg))
+	assert.Equal(t, []*storage.Scenario{idleYoung, untouched, ancient}, idleExpired)
+}
+
+func TestFilterExpired_PerTypeOverrides(t *testing.T) {
+	now := time.Now()
+
+	// Same age and idle time; only their types differ
+	k8s := &storage.Scenario{ScenarioID: "scn-k8s", ScenarioType: "k8s", CreatedAt: now.Add(-3 * time.Hour), LastActivityAt: now.Add(-45 * time.Minute)}
+	goScenario := &storage.Scenario{ScenarioID: "scn-go", ScenarioType: "go", CreatedAt: now.Add(-3 * time.Hour), LastActivityAt: now.Add(-45 * time.Minute)}
+
+	scenarios := []*storage.Scenario{k8s, goScenario}
+	cleanupCfg := config.CleanupConfig{
+		MaxScenarioAge:       24 * time.Hour,
+		MaxScenarioAgeByType: map[string]time.Duration{"k8s": 2 * time.Hour},
+		IdleTimeout:          time.Hour,
+		IdleTimeoutByType:    map[string]time.Duration{"k8s": 30 * time.Minute},
+		MaxScenarioLifetime:  72 * time.Hour,
+	}
+
+	cleanupCfg.ReapMode = ReapModeAge
+	assert.Equal(t, []*storage.Scenario{k8s}, filterExpired(scenarios, now, newReapPolicy(cleanupCfg)))
+
+	cleanupCfg.ReapMode = ReapModeInactivity
+	assert.Equal(t, []*storage.Scenario{k8s}, filterExpired(scenarios, now, newReapPolicy(cleanupCfg)))
+}
+
+func TestCleanupExpiredScenarios_PerTypeMaxAge(t *testing.T) {
+	ctx := context.Background()
+	createdAt := time.Now().Add(-3 * time.Hour)
+	store := storage.NewMemoryStore(
+		&storage.Scenario{ScenarioID: "scn-k8s", ScenarioType: "k8s", ContainerID: "container-k8s", Status: types.ScenarioStatusRunning, CreatedAt: createdAt},
+		&storage.Scenario{ScenarioID: "scn-go", ScenarioType: "go", ContainerID: "container-go", Status: types.ScenarioStatusRunning, CreatedAt: createdAt},
+	)
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ContainerExists", mock.Anything, "container-k8s").Return(false, nil)
+
+	cleanupManager := &CleanupManager{
+		cfg: &config.Config{Cleanup: config.CleanupConfig{
+			MaxScenarioAge:       24 * time.Hour,
+			MaxScenarioAgeByType: map[string]time.Duration{"k8s": 2 * time.Hour},
+		}},
+		store:  store,
+		docker: mockDocker,
+	}
+	require.NoError(t, cleanupManager.CleanupExpiredScenarios(ctx))
+
+	k8s, err := store.GetScenario(ctx, "scn-k8s")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusCleanedUp, k8s.Status)
+	goScenario, err := store.GetScenario(ctx, "scn-go")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusRunning, goScenario.Status)
+	mockDocker.AssertNotCalled(t, "ContainerExists", mock.Anything, "container-go")
+}
+
+func TestExpiryCandidates_LoadsOnlyScenariosNearExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	running := types.ScenarioStatusRunning
+	cleanupManager := &CleanupManager{
+		cfg: &config.Config{Cleanup: config.CleanupConfig{WarningWindow: 15 * time.Minute}},
+		store: storage.NewMemoryStore(
+			&storage.Scenario{ScenarioID: "scn-due", Status: running, CreatedAt: now.Add(-4 * time.Hour), ExpiresAt: now.Add(-time.Minute)},
+			&storage.Scenario{ScenarioID: "scn-soon", Status: running, CreatedAt: now.Add(-3 * time.Hour), ExpiresAt: now.Add(10 * time.Minute)},
+			&storage.Scenario{ScenarioID: "scn-later", Status: running, CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(time.Hour)},
+			&storage.Scenario{ScenarioID: "scn-legacy", Status: running, CreatedAt: now.Add(-time.Hour)},
+			&storage.Scenario{ScenarioID: "scn-stopped", Status: types.ScenarioStatusStopped, CreatedAt: now, ExpiresAt: now.Add(-time.Minute)},
+		),
+	}
+	ids := func() []string {
+		scenarios, err := cleanupManager.expiryCandidates(ctx, now, newReapPolicy(cleanupManager.cfg.Cleanup))
+		require.NoError(t, err)
+		var ids []string
+		for _, s := range scenarios {
+			ids = append(ids, s.ScenarioID)
+		}
+		return ids
+	}
+
+	// Without anyone to warn, only scenarios already due are loaded
+	assert.Equal(t, []string{"scn-due", "scn-legacy"}, ids())
+
+	cleanupManager.SetPublisher(&recordingPublisher{})
+	assert.Equal(t, []string{"scn-due", "scn-soon", "scn-legacy"}, ids())
+}
+
+func TestFilterExpired_PausedScenarios(t *testing.T) {
+	now := time.Now()
+
+	// Paused long ago: idle, but pausing is not abandonment
+	pausedIdle := &storage.Scenario{ScenarioID: "scn-paused-idle", Status: types.ScenarioStatusPaused, CreatedAt: now.Add(-3 * time.Hour), LastActivityAt: now.Add(-2 * time.Hour)}
+	// Paused past the maximum lifetime
+	pausedAncient := &storage.Scenario{ScenarioID: "scn-paused-ancient", Status: types.ScenarioStatusPaused, CreatedAt: now.Add(-73 * time.Hour), LastActivityAt: now.Add(-2 * time.Hour)}
+	// Paused past its age-based expiry
+	pausedExpired := &storage.Scenario{ScenarioID: "scn-paused-expired", Status: types.ScenarioStatusPaused, CreatedAt: now.Add(-25 * time.Hour), LastActivityAt: now.Add(-25 * time.Hour)}
+
+	scenarios := []*storage.Scenario{pausedIdle, pausedAncient, pausedExpired}
+	cleanupCfg := config.CleanupConfig{
+		MaxScenarioAge:      24 * time.Hour,
+		IdleTimeout:         time.Hour,
+		MaxScenarioLifetime: 72 * time.Hour,
+	}
+
+	cleanupCfg.ReapMode = ReapModeInactivity
+	assert.Equal(t, []*storage.Scenario{pausedAncient}, filterExpired(scenarios, now, newReapPolicy(cleanupCfg)))
+
+	cleanupCfg.ReapMode = ReapModeAge
+	assert.Equal(t, []*storage.Scenario{pausedAncient, pausedExpired}, filterExpired(scenarios, now, newReapPolicy(cleanupCfg)))
+}
+
+func TestCleanupExpiredScenarios_IncludesPaused(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(&storage.Scenario{
+		ScenarioID:  "scn-1",
+		ContainerID: "container-1",
+		Status:      types.ScenarioStatusPaused,
+		CreatedAt:   time.Now().Add(-25 * time.Hour),
+	})
+
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(true, nil)
+	mockDocker.On("GetContainerStatus", mock.Anything, "container-1").Return("paused", nil)
+	mockDocker.On("StopContainer", mock.Anything, "container-1").Return(nil)
+	mockDocker.On("RemoveContainer", mock.Anything, "container-1").Return(nil)
+
+	cleanupManager := &CleanupManager{cfg: &config.Config{Cleanup: config.CleanupConfig{MaxScenarioAge: 24 * time.Hour}}, store: store, docker: mockDocker}
+	require.NoError(t, cleanupManager.CleanupExpiredScenarios(ctx))
+
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusCleanedUp, stored.Status)
+	mockDocker.AssertExpectations(t)
+}
+
+func TestCleanupExpiredScenarios_BoundedConcurrency(t *testing.T) {
+	const (
+		count = 30
+		limit = 3
+	)
+	ctx := context.Background()
+	var seeded []*storage.Scenario
+	for i := 0; i < count; i++ {
+		seeded = append(seeded, &storage.Scenario{
+			ScenarioID:  fmt.Sprintf("scn-%d", i),
+			ContainerID: fmt.Sprintf("container-%d", i),
+			Status:      types.ScenarioStatusRunning,
+			CreatedAt:   time.Now().Add(-25 * time.Hour),
+		})
+	}
+	store := storage.NewMemoryStore(seeded...)
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	mockDocker := &MockDockerClient{}
+	// One failing container must not hold up the others
+	mockDocker.On("ContainerExists", mock.Anything, "container-0").Return(false, errors.New("daemon hiccup"))
+	mockDocker.On("ContainerExists", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+	}).Return(false, nil)
+
+	cfg := &config.Config{Cleanup: config.CleanupConfig{MaxScenarioAge: 24 * time.Hour, MaxConcurrent: limit}}
+	cleanupManager := &CleanupManager{cfg: cfg, store: store, docker: mockDocker}
+	require.NoError(t, cleanupManager.CleanupExpiredScenarios(ctx))
+
+	assert.LessOrEqual(t, peak, limit)
+	mockDocker.AssertNumberOfCalls(t, "ContainerExists", count)
+	for _, scenario := range seeded {
+		stored, err := store.GetScenario(ctx, scenario.ScenarioID)
+		require.NoError(t, err)
+		assert.Equal(t, types.ScenarioStatusCleanedUp, stored.Status, scenario.ScenarioID)
+	}
+}
+
+func TestCleanupScenario_RecordsExpiredReason(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", ContainerID: "container-1", Status: "running"})
+
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(true, nil)
+	mockDocker.On("GetContainerStatus", mock.Anything, "container-1").Return("running", nil)
+	mockDocker.On("StopContainer", mock.Anything, "container-1").Return(nil)
+	mockDocker.On("RemoveContainer", mock.Anything, "container-1").Return(nil)
+
+	cleanupManager := &CleanupManager{cfg: &config.Config{}, store: store, docker: mockDocker}
+	scenario, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	require.NoError(t, cleanupManager.cleanupScenario(ctx, scenario))
+
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusCleanedUp, stored.Status)
+	assert.Equal(t, types.StopReasonExpired, stored.StopReason)
+	mockDocker.AssertExpectations(t)
+}
+
+func TestCleanupScenario_StopOrKill(t *testing.T) {
+	for _, kill := range []bool{false, true} {
+		t.Run(fmt.Sprintf("kill=%t", kill), func(t *testing.T) {
+			ctx := context.Background()
+			store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", ContainerID: "container-1", Status: "running"})
+
+			haltMethod, otherMethod := "StopContainer", "KillContainer"
+			if kill {
+				haltMethod, otherMethod = otherMethod, haltMethod
+			}
+			mockDocker := &MockDockerClient{}
+			mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(true, nil)
+			mockDocker.On("GetContainerStatus", mock.Anything, "container-1").Return("running", nil)
+			mockDocker.On(haltMethod, mock.Anything, "container-1").Return(nil)
+			mockDocker.On("RemoveContainer", mock.Anything, "container-1").Return(nil)
+
+			cfg := &config.Config{Cleanup: config.CleanupConfig{KillContainers: kill}}
+			cleanupManager := &CleanupManager{cfg: cfg, store: store, docker: mockDocker}
+			scenario, err := store.GetScenario(ctx, "scn-1")
+			require.NoError(t, err)
+			require.NoError(t, cleanupManager.cleanupScenario(ctx, scenario))
+
+			mockDocker.AssertExpectations(t)
+			mockDocker.AssertNotCalled(t, otherMethod, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCleanupOrphanedContainers_KillsWhenConfigured(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Cleanup: config.CleanupConfig{PruneThreshold: 5, KillContainers: true}}
+	mockDocker := &MockDockerClient{}
+	cleanupManager := NewCleanupManager(cfg, nil, mockDocker)
+	cleanupManager.store = storage.NewMemoryStore()
+
+	mockDocker.On("ListContainers", ctx).Return([]docker.ContainerInfo{
+		{ID: "running-1", Status: "Up 3 hours"},
+	}, nil)
+	mockDocker.On("KillContainer", ctx, "running-1").Return(nil)
+	mockDocker.On("RemoveContainer", ctx, "running-1").Return(nil)
+
+	report, err := cleanupManager.CleanupOrphanedContainers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []ContainerCleanupResult{{ContainerID: "running-1", Removed: true}}, report.Results)
+
+	mockDocker.AssertExpectations(t)
+	mockDocker.AssertNotCalled(t, "StopContainer", mock.Anything, mock.Anything)
+}
+
+// recordingPublisher captures published messages
+type recordingPublisher struct {
+	messages []queue.ScenarioEvent
+}
+
+func (p *recordingPublisher) PublishMessage(ctx context.Context, queueName string, message interface{}) error {
+	p.messages = append(p.messages, message.(queue.ScenarioEvent))
+	return nil
+}
+
+func TestCleanupExpiredScenarios_WarnsOnceBeforeReaping(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := start.Add(time.Hour)
+
+	store := storage.NewMemoryStore(&storage.Scenario{
+		ScenarioID:  "scn-1",
+		UserID:      "user-1",
+		ContainerID: "container-1",
+		Status:      "running",
+		CreatedAt:   start,
+		ExpiresAt:   expiresAt,
+	})
+
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(false, nil)
+
+	publisher := &recordingPublisher{}
+	now := start
+	cleanupManager := &CleanupManager{
+		cfg:    &config.Config{Cleanup: config.CleanupConfig{MaxScenarioAge: time.Hour, WarningWindow: 15 * time.Minute}},
+		store:  store,
+		docker: mockDocker,
+		now:    func() time.Time { return now },
+	}
+	cleanupManager.SetPublisher(publisher)
+
+	// Outside the window: nothing happens
+	now = expiresAt.Add(-20 * time.Minute)
+	require.NoError(t, cleanupManager.CleanupExpiredScenarios(ctx))
+	assert.Empty(t, publisher.messages)
+
+	// Inside the window: warned once, across several cycles
+	for _, offset := range []time.Duration{-14 * time.Minute, -10 * time.Minute, -time.Minute} {
+		now = expiresAt.Add(offset)
+		require.NoError(t, cleanupManager.CleanupExpiredScenarios(ctx))
+	}
+	require.Len(t, publisher.messages, 1)
+	assert.Equal(t, queue.ScenarioEvent{
+		Event:      queue.EventScenarioExpiringSoon,
+		ScenarioID: "scn-1",
+		UserID:     "user-1",
+		ExpiresAt:  expiresAt,
+	}, publisher.messages[0])
+
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusRunning, stored.Status, "warning must not reap the scenario")
+
+	// Past expiry: reaped without another warning
+	now = expiresAt.Add(time.Minute)
+	require.NoError(t, cleanupManager.CleanupExpiredScenarios(ctx))
+	assert.Len(t, publisher.messages, 1)
+
+	stored, err = store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusCleanedUp, stored.Status)
+}
+
+// stoppingPublisher stops the warned scenario while the warning is published,
+// as a user racing the cleanup scan would
+type stoppingPublisher struct {
+	store storage.Store
+}
+
+func (p *stoppingPublisher) PublishMessage(ctx context.Context, queueName string, message interface{}) error {
+	scenario, err := p.store.GetScenario(ctx, message.(queue.ScenarioEvent).ScenarioID)
+	if err != nil {
+		return err
+	}
+	scenario.Status = types.ScenarioStatusStopped
+	return p.store.UpdateScenario(ctx, scenario)
+}
+
+func TestWarnExpiringScenarios_KeepsConcurrentChanges(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	expiresAt := now.Add(5 * time.Minute)
+	store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", Status: types.ScenarioStatusRunning, CreatedAt: now, ExpiresAt: expiresAt})
+
+	cleanupManager := &CleanupManager{
+		cfg:   &config.Config{Cleanup: config.CleanupConfig{WarningWindow: 15 * time.Minute}},
+		store: store,
+		now:   func() time.Time { return now },
+	}
+	cleanupManager.SetPublisher(&stoppingPublisher{store: store})
+	require.NoError(t, cleanupManager.CleanupExpiredScenarios(ctx))
+
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusStopped, stored.Status)
+	assert.True(t, stored.ExpiryWarnedFor.Equal(expiresAt))
+}
+
+func TestCleanupScenario_RetriesTransientUpdateError(t *testing.T) {
+	ctx := context.Background()
+	store := &storagetest.FlakyStore{
+		Store:    storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", Status: "running"}),
+		Err:      retry.New("database unavailable"),
+		Failures: 1,
+	}
+	cfg := &config.Config{Mongo: config.MongoConfig{UpdateRetries: 2, UpdateBackoff: time.Millisecond}}
+	cleanupManager := &CleanupManager{cfg: cfg, store: store, docker: &MockDockerClient{}}
+
+	scenario, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	require.NoError(t, cleanupManager.cleanupScenario(ctx, scenario))
+	assert.Equal(t, 2, store.Updates)
+
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusCleanedUp, stored.Status)
+	assert.Equal(t, types.StopReasonExpired, stored.StopReason)
+}
+
+func TestCleanupExpiredScenarios_SkipsLocked(t *testing.T) {
+	ctx := context.Background()
+	expired := time.Now().Add(-25 * time.Hour)
+	store := storage.NewMemoryStore(
+		&storage.Scenario{ScenarioID: "scn-locked", ContainerID: "container-locked", Status: types.ScenarioStatusRunning, CreatedAt: expired, Locked: true},
+		&storage.Scenario{ScenarioID: "scn-unlocked", ContainerID: "container-unlocked", Status: types.ScenarioStatusRunning, CreatedAt: expired},
+	)
+
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ContainerExists", mock.Anything, "container-unlocked").Return(true, nil)
+	mockDocker.On("GetContainerStatus", mock.Anything, "container-unlocked").Return("running", nil)
+	mockDocker.On("StopContainer", mock.Anything, "container-unlocked").Return(nil)
+	mockDocker.On("RemoveContainer", mock.Anything, "container-unlocked").Return(nil)
+
+	cfg := &config.Config{Cleanup: config.CleanupConfig{MaxScenarioAge: 24 * time.Hour}}
+	cleanupManager := &CleanupManager{cfg: cfg, store: store, docker: mockDocker}
+	require.NoError(t, cleanupManager.CleanupExpiredScenarios(ctx))
+
+	locked, err := store.GetScenario(ctx, "scn-locked")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusRunning, locked.Status)
+	unlocked, err := store.GetScenario(ctx, "scn-unlocked")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusCleanedUp, unlocked.Status)
+
+	mockDocker.AssertExpectations(t)
+	mockDocker.AssertNotCalled(t, "ContainerExists", mock.Anything, "container-locked")
+	mockDocker.AssertNotCalled(t, "StopContainer", mock.Anything, "container-locked")
+}
+
+func TestCleanupOrphanedContainers_KeepsLockedScenarioContainers(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{}
+	mockDocker := &MockDockerClient{}
+	cleanupManager := NewCleanupManager(cfg, nil, mockDocker)
+	cleanupManager.store = storage.NewMemoryStore(&storage.Scenario{
+		ScenarioID:  "scn-locked",
+		ContainerID: "container-locked",
+		Status:      types.ScenarioStatusStopped,
+		Locked:      true,
+	})
+
+	mockDocker.On("ListContainers", ctx).Return([]docker.ContainerInfo{
+		{ID: "container-locked", Status: "Exited (0) 3 days ago"},
+		{ID: "container-orphan", Status: "Exited (0) 3 days ago"},
+	}, nil)
+	mockDocker.On("StopContainer", ctx, "container-orphan").Return(nil)
+	mockDocker.On("RemoveContainer", ctx, "container-orphan").Return(nil)
+
+	report, err := cleanupManager.CleanupOrphanedContainers(ctx)
+	require.NoError(t, err)
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, "container-orphan", report.Results[0].ContainerID)
+	mockDocker.AssertExpectations(t)
+	mockDocker.AssertNotCalled(t, "StopContainer", ctx, "container-locked")
+	mockDocker.AssertNotCalled(t, "RemoveContainer", ctx, "container-locked")
+}
+
+func TestCleanupOrphanedContainers_RemovesExpiredKeptContainers(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	cfg := &config.Config{}
+	mockDocker := &MockDockerClient{}
+	cleanupManager := NewCleanupManager(cfg, nil, mockDocker)
+	cleanupManager.store = storage.NewMemoryStore(
+		&storage.Scenario{ScenarioID: "scn-expired", ContainerID: "container-expired", Status: types.ScenarioStatusFailed, ExpiresAt: now.Add(-time.Minute)},
+		&storage.Scenario{ScenarioID: "scn-kept", ContainerID: "container-kept", Status: types.ScenarioStatusFailed, ExpiresAt: now.Add(time.Hour)},
+		&storage.Scenario{ScenarioID: "scn-running", ContainerID: "container-running", Status: types.ScenarioStatusRunning, ExpiresAt: now.Add(-time.Minute)},
+	)
+
+	mockDocker.On("ListContainers", ctx).Return([]docker.ContainerInfo{
+		{ID: "container-expired", Status: "Exited (1) 2 days ago"},
+		{ID: "container-kept", Status: "Exited (1) 5 minutes ago"},
+		{ID: "container-running", Status: "Up 2 days"},
+	}, nil)
+	mockDocker.On("StopContainer", ctx, "container-expired").Return(nil)
+	mockDocker.On("RemoveContainer", ctx, "container-expired").Return(nil)
+
+	report, err := cleanupManager.CleanupOrphanedContainers(ctx)
+	require.NoError(t, err)
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, "container-expired", report.Results[0].ContainerID)
+	mockDocker.AssertExpectations(t)
+	mockDocker.AssertNotCalled(t, "RemoveContainer", ctx, "container-kept")
+	mockDocker.AssertNotCalled(t, "RemoveContainer", ctx, "container-running")
+}
+
+func TestWarnExpiringScenarios_SkipsLocked(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	locked := &storage.Scenario{ScenarioID: "scn-locked", Status: types.ScenarioStatusRunning, CreatedAt: now.Add(-23 * time.Hour), Locked: true}
+	publisher := &recordingPublisher{}
+
+	cfg := &config.Config{Cleanup: config.CleanupConfig{MaxScenarioAge: 24 * time.Hour, WarningWindow: 2 * time.Hour}}
+	cleanupManager := &CleanupManager{cfg: cfg, store: storage.NewMemoryStore(locked)}
+	cleanupManager.SetPublisher(publisher)
+	cleanupManager.warnExpiringScenarios(ctx, []*storage.Scenario{locked}, now, newReapPolicy(cfg.Cleanup))
+
+	assert.Empty(t, publisher.messages)
+}
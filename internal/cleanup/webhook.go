@@ -0,0 +1,121 @@
+package cleanup
+
+import (
+	"bytes"
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/queue"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Webhook delivery defaults used when the config leaves them unset
+const (
+	defaultWebhookTimeout     = 5 * time.Second
+	defaultWebhookMaxAttempts = 3
+	defaultWebhookRetryDelay  = time.Second
+)
+
+// WebhookNotifier POSTs scenario events as JSON to a global webhook URL, or to
+// a per-user URL when one is configured for the scenario's owner
+type WebhookNotifier struct {
+	url         string
+	urlsByUser  map[string]string
+	client      *http.Client
+	maxAttempts int
+	// retryDelay grows linearly with each failed attempt
+	retryDelay time.Duration
+}
+
+// NewWebhookNotifier returns a notifier for cfg's webhook settings, or nil
+// when no webhook URL is configured
+func NewWebhookNotifier(cfg config.CleanupConfig) *WebhookNotifier {
+	if cfg.WebhookURL == "" && len(cfg.WebhookURLsByUser) == 0 {
+		return nil
+	}
+
+	timeout := cfg.WebhookTimeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	maxAttempts := cfg.WebhookMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultWebhookMaxAttempts
+	}
+	return &WebhookNotifier{
+		url:         cfg.WebhookURL,
+		urlsByUser:  cfg.WebhookURLsByUser,
+		client:      &http.Client{Timeout: timeout},
+		maxAttempts: maxAttempts,
+		retryDelay:  defaultWebhookRetryDelay,
+	}
+}
+
+// urlFor returns the webhook URL for userID's scenarios, or "" when neither
+// a per-user nor a global URL is configured
+func (n *WebhookNotifier) urlFor(userID string) string {
+	if url, ok := n.urlsByUser[userID]; ok {
+		return url
+	}
+	return n.url
+}
+
+// Notify delivers event to its owner's webhook. Network failures and 5xx
+// responses are retried up to the configured number of attempts; any other
+// non-2xx response is treated as final.
+func (n *WebhookNotifier) Notify(ctx context.Context, event queue.ScenarioEvent) error {
+	url := n.urlFor(event.UserID)
+	if url == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= n.maxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(time.Duration(attempt-1) * n.retryDelay):
+			case <-ctx.Done():
+				return fmt.Errorf("webhook delivery cancelled: %w", ctx.Err())
+			}
+		}
+
+		retry, err := n.post(ctx, url, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return fmt.Errorf("failed to deliver %s webhook for scenario %s: %w", event.Event, event.ScenarioID, lastErr)
+}
+
+// post sends one delivery attempt and reports whether a failure is worth retrying
+func (n *WebhookNotifier) post(ctx context.Context, url string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("invalid webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	return resp.StatusCode >= 500, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+}
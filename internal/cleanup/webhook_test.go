@@ -0,0 +1,146 @@
+package cleanup
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/queue"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookServer records each delivery and answers with statuses in turn,
+// repeating the last one
+func webhookServer(t *testing.T, statuses ...int) (*httptest.Server, chan map[string]interface{}, *int32) {
+	payloads := make(chan map[string]interface{}, 10)
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(atomic.AddInt32(&calls, 1))
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]interface{}
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		payloads <- payload
+
+		w.WriteHeader(statuses[min(call, len(statuses))-1])
+	}))
+	t.Cleanup(server.Close)
+	return server, payloads, &calls
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	expiresAt := time.Date(2025, 1, 1, 13, 0, 0, 0, time.UTC)
+	event := queue.ScenarioEvent{Event: queue.EventScenarioExpiringSoon, ScenarioID: "scn-1", UserID: "user-1", ExpiresAt: expiresAt}
+
+	tests := []struct {
+		name          string
+		statuses      []int
+		expectedCalls int32
+		expectError   bool
+	}{
+		{name: "delivered", statuses: []int{http.StatusNoContent}, expectedCalls: 1},
+		{name: "retries_5xx", statuses: []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}, expectedCalls: 3},
+		{name: "gives_up_after_max_attempts", statuses: []int{http.StatusInternalServerError}, expectedCalls: 3, expectError: true},
+		{name: "4xx_not_retried", statuses: []int{http.StatusNotFound}, expectedCalls: 1, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, payloads, calls := webhookServer(t, tt.statuses...)
+			notifier := NewWebhookNotifier(config.CleanupConfig{WebhookURL: server.URL})
+			notifier.retryDelay = time.Millisecond
+
+			err := notifier.Notify(context.Background(), event)
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedCalls, atomic.LoadInt32(calls))
+			assert.Equal(t, map[string]interface{}{
+				"event":       "scenario.expiring_soon",
+				"scenario_id": "scn-1",
+				"user_id":     "user-1",
+				"expires_at":  "2025-01-01T13:00:00Z",
+			}, <-payloads)
+		})
+	}
+}
+
+func TestWebhookNotifier_PerUserURL(t *testing.T) {
+	global, globalPayloads, _ := webhookServer(t, http.StatusOK)
+	personal, personalPayloads, _ := webhookServer(t, http.StatusOK)
+	notifier := NewWebhookNotifier(config.CleanupConfig{
+		WebhookURL:        global.URL,
+		WebhookURLsByUser: map[string]string{"user-2": personal.URL},
+	})
+
+	require.NoError(t, notifier.Notify(context.Background(), queue.ScenarioEvent{Event: queue.EventScenarioCleanedUp, ScenarioID: "scn-1", UserID: "user-1"}))
+	require.NoError(t, notifier.Notify(context.Background(), queue.ScenarioEvent{Event: queue.EventScenarioCleanedUp, ScenarioID: "scn-2", UserID: "user-2"}))
+
+	assert.Equal(t, "scn-1", (<-globalPayloads)["scenario_id"])
+	assert.Equal(t, "scn-2", (<-personalPayloads)["scenario_id"])
+	assert.Empty(t, globalPayloads)
+}
+
+func TestNewWebhookNotifier_Disabled(t *testing.T) {
+	assert.Nil(t, NewWebhookNotifier(config.CleanupConfig{}))
+}
+
+func TestCleanupExpiredScenarios_NotifiesWebhook(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := start.Add(time.Hour)
+	store := storage.NewMemoryStore(&storage.Scenario{
+		ScenarioID:  "scn-1",
+		UserID:      "user-1",
+		ContainerID: "container-1",
+		Status:      types.ScenarioStatusRunning,
+		CreatedAt:   start,
+		ExpiresAt:   expiresAt,
+	})
+
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(false, nil)
+
+	// The endpoint is down for the first delivery; cleanup must not wait on it
+	server, payloads, _ := webhookServer(t, http.StatusServiceUnavailable, http.StatusOK)
+	now := expiresAt.Add(-5 * time.Minute)
+	cleanupManager := &CleanupManager{
+		cfg:    &config.Config{Cleanup: config.CleanupConfig{MaxScenarioAge: time.Hour, WarningWindow: 15 * time.Minute}},
+		store:  store,
+		docker: mockDocker,
+		now:    func() time.Time { return now },
+	}
+	webhook := NewWebhookNotifier(config.CleanupConfig{WebhookURL: server.URL})
+	webhook.retryDelay = 10 * time.Millisecond
+	cleanupManager.SetWebhook(webhook)
+
+	require.NoError(t, cleanupManager.CleanupExpiredScenarios(ctx))
+	assert.Equal(t, "scenario.expiring_soon", (<-payloads)["event"])
+	assert.Equal(t, "scenario.expiring_soon", (<-payloads)["event"], "a 5xx is retried")
+
+	now = expiresAt.Add(time.Minute)
+	require.NoError(t, cleanupManager.CleanupExpiredScenarios(ctx))
+	cleaned := <-payloads
+	assert.Equal(t, "scenario.cleaned_up", cleaned["event"])
+	assert.Equal(t, "scn-1", cleaned["scenario_id"])
+	assert.Equal(t, "user-1", cleaned["user_id"])
+
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusCleanedUp, stored.Status)
+}
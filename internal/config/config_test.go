@@ -6,12 +6,21 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// mustLoad loads the configuration, failing the test on error
+func mustLoad(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := Load()
+	require.NoError(t, err)
+	return cfg
+}
+
 // TestConfigLoading tests basic config loading
 func TestConfigLoading(t *testing.T) {
 	// Test default config loading
-	cfg := Load()
+	cfg := mustLoad(t)
 
 	assert.NotNil(t, cfg)
 	assert.NotEmpty(t, cfg.MongoURI)
@@ -37,7 +46,7 @@ func TestEnvironmentVariables(t *testing.T) {
 		os.Unsetenv("CLEANUP_ENABLED")
 	}()
 
-	cfg := Load()
+	cfg := mustLoad(t)
 
 	assert.Equal(t, "mongodb://test-host:27017", cfg.MongoURI)
 	assert.Equal(t, "test_db", cfg.DBName)
@@ -52,7 +61,7 @@ func TestConfigValidation(t *testing.T) {
 	os.Setenv("MONGODB_URI", "invalid-uri")
 	defer os.Unsetenv("MONGODB_URI")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 
 	// Should still load but with default values
 	assert.NotNil(t, cfg)
@@ -68,7 +77,7 @@ func TestDefaultValues(t *testing.T) {
 	os.Unsetenv("CLEANUP_MAX_SCENARIO_AGE")
 	os.Unsetenv("ENABLE_CLEANUP")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 
 	// Check default values
 	assert.Equal(t, "mongodb://localhost:27017", cfg.MongoURI)
@@ -81,7 +90,7 @@ func TestDefaultValues(t *testing.T) {
 // TestDockerImageConfig tests Docker image configuration
 func TestDockerImageConfig(t *testing.T) {
 	// Test default Docker image
-	cfg := Load()
+	cfg := mustLoad(t)
 
 	assert.Equal(t, "golang:1.21", cfg.DockerImage)
 
@@ -89,18 +98,564 @@ func TestDockerImageConfig(t *testing.T) {
 	os.Setenv("DOCKER_IMAGE", "golang:1.22")
 	defer os.Unsetenv("DOCKER_IMAGE")
 
-	cfg = Load()
+	cfg = mustLoad(t)
 	assert.Equal(t, "golang:1.22", cfg.DockerImage)
 }
 
+// TestDefaultScenarioImageConfig tests the fallback image for unknown scenario types
+func TestDefaultScenarioImageConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Equal(t, "devlab-go:latest", cfg.DefaultScenarioImage)
+
+	os.Setenv("DEFAULT_SCENARIO_IMAGE", "devlab-minimal:latest")
+	defer os.Unsetenv("DEFAULT_SCENARIO_IMAGE")
+
+	cfg = mustLoad(t)
+	assert.Equal(t, "devlab-minimal:latest", cfg.DefaultScenarioImage)
+}
+
+// TestContainerDiskQuotaConfig tests per-deployment disk quota configuration
+func TestContainerDiskQuotaConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Empty(t, cfg.Container.DiskQuota)
+	assert.Equal(t, "storage-opt", cfg.Container.DiskQuotaMode)
+
+	os.Setenv("CONTAINER_DISK_QUOTA", "2G")
+	os.Setenv("CONTAINER_DISK_QUOTA_MODE", "tmpfs")
+	defer func() {
+		os.Unsetenv("CONTAINER_DISK_QUOTA")
+		os.Unsetenv("CONTAINER_DISK_QUOTA_MODE")
+	}()
+
+	cfg = mustLoad(t)
+	assert.Equal(t, "2G", cfg.Container.DiskQuota)
+	assert.Equal(t, "tmpfs", cfg.Container.DiskQuotaMode)
+}
+
+// TestContainerMaxScriptBytesConfig tests the inline script size limit
+func TestContainerMaxScriptBytesConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Equal(t, 64*1024, cfg.Container.MaxScriptBytes)
+
+	os.Setenv("CONTAINER_MAX_SCRIPT_BYTES", "1024")
+	defer os.Unsetenv("CONTAINER_MAX_SCRIPT_BYTES")
+
+	cfg = mustLoad(t)
+	assert.Equal(t, 1024, cfg.Container.MaxScriptBytes)
+}
+
+// TestContainerNetworkConfig tests custom DNS servers and /etc/hosts entries
+func TestContainerNetworkConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Empty(t, cfg.Container.DNS)
+	assert.Empty(t, cfg.Container.ExtraHosts)
+
+	os.Setenv("CONTAINER_DNS", "10.0.0.53, 10.0.0.54")
+	os.Setenv("CONTAINER_EXTRA_HOSTS", "mirror.internal:10.0.0.10")
+	defer func() {
+		os.Unsetenv("CONTAINER_DNS")
+		os.Unsetenv("CONTAINER_EXTRA_HOSTS")
+	}()
+
+	cfg = mustLoad(t)
+	assert.Equal(t, []string{"10.0.0.53", "10.0.0.54"}, cfg.Container.DNS)
+	assert.Equal(t, []string{"mirror.internal:10.0.0.10"}, cfg.Container.ExtraHosts)
+}
+
+// TestContainerUserConfig tests the default and per-type container users
+func TestContainerUserConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Empty(t, cfg.Container.User)
+	assert.Nil(t, cfg.Container.UsersByType)
+
+	os.Setenv("CONTAINER_USER", "devlab")
+	os.Setenv("CONTAINER_USERS_BY_TYPE", `{"k8s": "root"}`)
+	defer func() {
+		os.Unsetenv("CONTAINER_USER")
+		os.Unsetenv("CONTAINER_USERS_BY_TYPE")
+	}()
+
+	cfg = mustLoad(t)
+	assert.Equal(t, "devlab", cfg.Container.User)
+	assert.Equal(t, map[string]string{"k8s": "root"}, cfg.Container.UsersByType)
+
+	// Malformed JSON fails loading rather than running every type as the default user
+	os.Setenv("CONTAINER_USERS_BY_TYPE", "not json")
+	_, err := Load()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorContains(t, err, "CONTAINER_USERS_BY_TYPE")
+}
+
+func TestContainerScriptTimeoutConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Zero(t, cfg.Container.ScriptTimeout)
+	assert.Equal(t, 24*time.Hour, cfg.Container.MaxScriptTimeout)
+
+	os.Setenv("CONTAINER_SCRIPT_TIMEOUT", "10m")
+	os.Setenv("CONTAINER_MAX_SCRIPT_TIMEOUT", "2h")
+	defer func() {
+		os.Unsetenv("CONTAINER_SCRIPT_TIMEOUT")
+		os.Unsetenv("CONTAINER_MAX_SCRIPT_TIMEOUT")
+	}()
+
+	cfg = mustLoad(t)
+	assert.Equal(t, 10*time.Minute, cfg.Container.ScriptTimeout)
+	assert.Equal(t, 2*time.Hour, cfg.Container.MaxScriptTimeout)
+}
+
+func TestRequestTimeoutConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Equal(t, 30*time.Second, cfg.RequestTimeout.Default)
+	assert.Equal(t, 5*time.Minute, cfg.RequestTimeout.Start)
+
+	os.Setenv("REQUEST_TIMEOUT", "10s")
+	os.Setenv("REQUEST_TIMEOUT_START", "2m")
+	defer func() {
+		os.Unsetenv("REQUEST_TIMEOUT")
+		os.Unsetenv("REQUEST_TIMEOUT_START")
+	}()
+
+	cfg = mustLoad(t)
+	assert.Equal(t, 10*time.Second, cfg.RequestTimeout.Default)
+	assert.Equal(t, 2*time.Minute, cfg.RequestTimeout.Start)
+}
+
+func TestCleanupWebhookConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Empty(t, cfg.Cleanup.WebhookURL)
+	assert.Nil(t, cfg.Cleanup.WebhookURLsByUser)
+	assert.Equal(t, 5*time.Second, cfg.Cleanup.WebhookTimeout)
+	assert.Equal(t, 3, cfg.Cleanup.WebhookMaxAttempts)
+
+	os.Setenv("CLEANUP_WEBHOOK_URL", "https://hooks.example.com/devlab")
+	os.Setenv("CLEANUP_WEBHOOK_URLS_BY_USER", `{"user-1": "https://user-1.example.com/hook"}`)
+	defer func() {
+		os.Unsetenv("CLEANUP_WEBHOOK_URL")
+		os.Unsetenv("CLEANUP_WEBHOOK_URLS_BY_USER")
+	}()
+
+	cfg = mustLoad(t)
+	assert.Equal(t, "https://hooks.example.com/devlab", cfg.Cleanup.WebhookURL)
+	assert.Equal(t, map[string]string{"user-1": "https://user-1.example.com/hook"}, cfg.Cleanup.WebhookURLsByUser)
+}
+
+func TestContainerReservedTypesConfig(t *testing.T) {
+	assert.Nil(t, mustLoad(t).Container.ReservedTypes)
+
+	os.Setenv("CONTAINER_RESERVED_TYPES", "java, dotnet")
+	defer os.Unsetenv("CONTAINER_RESERVED_TYPES")
+
+	assert.Equal(t, []string{"java", "dotnet"}, mustLoad(t).Container.ReservedTypes)
+}
+
+func TestContainerNamePrefixConfig(t *testing.T) {
+	assert.Equal(t, "devlab-", mustLoad(t).Container.NamePrefix)
+
+	os.Setenv("CONTAINER_NAME_PREFIX", "lab-")
+	defer os.Unsetenv("CONTAINER_NAME_PREFIX")
+
+	assert.Equal(t, "lab-", mustLoad(t).Container.NamePrefix)
+}
+
+func TestContainerStartupTemplateConfig(t *testing.T) {
+	assert.Empty(t, mustLoad(t).Container.StartupTemplate)
+
+	os.Setenv("CONTAINER_STARTUP_TEMPLATE", "/etc/devlab/startup.sh.tmpl")
+	defer os.Unsetenv("CONTAINER_STARTUP_TEMPLATE")
+
+	assert.Equal(t, "/etc/devlab/startup.sh.tmpl", mustLoad(t).Container.StartupTemplate)
+}
+
+func TestContainerKeepOnFailureConfig(t *testing.T) {
+	assert.False(t, mustLoad(t).Container.KeepOnFailure)
+
+	os.Setenv("CONTAINER_KEEP_ON_FAILURE", "true")
+	defer os.Unsetenv("CONTAINER_KEEP_ON_FAILURE")
+
+	assert.True(t, mustLoad(t).Container.KeepOnFailure)
+}
+
+func TestContainerK3sReadyTimeoutConfig(t *testing.T) {
+	assert.Equal(t, 3*time.Minute, mustLoad(t).Container.K3sReadyTimeout)
+
+	os.Setenv("CONTAINER_K3S_READY_TIMEOUT", "0")
+	defer os.Unsetenv("CONTAINER_K3S_READY_TIMEOUT")
+
+	assert.Zero(t, mustLoad(t).Container.K3sReadyTimeout)
+}
+
+func TestExecConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Nil(t, cfg.Exec.AllowedCommands)
+	assert.Nil(t, cfg.Exec.DeniedCommands)
+	assert.Equal(t, 30*time.Second, cfg.Exec.DefaultTimeout)
+	assert.Equal(t, 5*time.Minute, cfg.Exec.MaxTimeout)
+
+	os.Setenv("EXEC_ALLOWED_COMMANDS", "go,make")
+	os.Setenv("EXEC_DENIED_COMMANDS", "nc")
+	defer func() {
+		os.Unsetenv("EXEC_ALLOWED_COMMANDS")
+		os.Unsetenv("EXEC_DENIED_COMMANDS")
+	}()
+
+	cfg = mustLoad(t)
+	assert.Equal(t, []string{"go", "make"}, cfg.Exec.AllowedCommands)
+	assert.Equal(t, []string{"nc"}, cfg.Exec.DeniedCommands)
+}
+
+func TestCleanupPerTypeConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Nil(t, cfg.Cleanup.MaxScenarioAgeByType)
+	assert.Equal(t, 24*time.Hour, cfg.Cleanup.MaxScenarioAgeFor("k8s"))
+
+	os.Setenv("CLEANUP_MAX_SCENARIO_AGE_BY_TYPE", `{"k8s":"2h","python-k8s":"90m"}`)
+	os.Setenv("CLEANUP_IDLE_TIMEOUT_BY_TYPE", `{"k8s":"20m"}`)
+	defer func() {
+		os.Unsetenv("CLEANUP_MAX_SCENARIO_AGE_BY_TYPE")
+		os.Unsetenv("CLEANUP_IDLE_TIMEOUT_BY_TYPE")
+	}()
+
+	cfg = mustLoad(t)
+	assert.Equal(t, 2*time.Hour, cfg.Cleanup.MaxScenarioAgeFor("k8s"))
+	assert.Equal(t, 90*time.Minute, cfg.Cleanup.MaxScenarioAgeFor("python-k8s"))
+	assert.Equal(t, 24*time.Hour, cfg.Cleanup.MaxScenarioAgeFor("go"))
+	assert.Equal(t, 20*time.Minute, cfg.Cleanup.IdleTimeoutFor("k8s"))
+	assert.Equal(t, time.Hour, cfg.Cleanup.IdleTimeoutFor("go"))
+
+	// A malformed duration fails loading rather than guessing
+	os.Setenv("CLEANUP_IDLE_TIMEOUT_BY_TYPE", `{"k8s":"soon"}`)
+	_, err := Load()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorContains(t, err, "CLEANUP_IDLE_TIMEOUT_BY_TYPE")
+}
+
+func TestContainerPortRangeConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Equal(t, 3001, cfg.Container.PortRangeStart)
+	assert.Equal(t, 3009, cfg.Container.PortRangeEnd)
+	assert.Equal(t, 10*time.Minute, cfg.Container.PortReclaimAfter)
+
+	os.Setenv("CONTAINER_PORT_RANGE_START", "4000")
+	os.Setenv("CONTAINER_PORT_RANGE_END", "4099")
+	os.Setenv("CONTAINER_PORT_RECLAIM_AFTER", "2m")
+	defer os.Unsetenv("CONTAINER_PORT_RANGE_START")
+	defer os.Unsetenv("CONTAINER_PORT_RANGE_END")
+	defer os.Unsetenv("CONTAINER_PORT_RECLAIM_AFTER")
+
+	cfg = mustLoad(t)
+	assert.Equal(t, 4000, cfg.Container.PortRangeStart)
+	assert.Equal(t, 4099, cfg.Container.PortRangeEnd)
+	assert.Equal(t, 2*time.Minute, cfg.Container.PortReclaimAfter)
+}
+
+func TestCleanupHeartbeatIntervalConfig(t *testing.T) {
+	assert.Equal(t, 30*time.Second, mustLoad(t).Cleanup.HeartbeatInterval)
+
+	os.Setenv("CLEANUP_HEARTBEAT_INTERVAL", "5s")
+	defer os.Unsetenv("CLEANUP_HEARTBEAT_INTERVAL")
+
+	assert.Equal(t, 5*time.Second, mustLoad(t).Cleanup.HeartbeatInterval)
+}
+
+func TestContainerMountablePathsConfig(t *testing.T) {
+	assert.Empty(t, mustLoad(t).Container.MountablePaths)
+
+	os.Setenv("CONTAINER_MOUNTABLE_PATHS", "/srv/datasets, /opt/tools")
+	defer os.Unsetenv("CONTAINER_MOUNTABLE_PATHS")
+
+	assert.Equal(t, []string{"/srv/datasets", "/opt/tools"}, mustLoad(t).Container.MountablePaths)
+}
+
+func TestContainerImageDigestsConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Empty(t, cfg.Container.ImageDigests)
+	assert.False(t, cfg.Container.ResolveImageDigests)
+
+	os.Setenv("CONTAINER_IMAGE_DIGESTS", `{"devlab-go:latest":"devlab-go@sha256:abc"}`)
+	os.Setenv("CONTAINER_RESOLVE_IMAGE_DIGESTS", "true")
+	defer os.Unsetenv("CONTAINER_IMAGE_DIGESTS")
+	defer os.Unsetenv("CONTAINER_RESOLVE_IMAGE_DIGESTS")
+
+	cfg = mustLoad(t)
+	assert.Equal(t, map[string]string{"devlab-go:latest": "devlab-go@sha256:abc"}, cfg.Container.ImageDigests)
+	assert.True(t, cfg.Container.ResolveImageDigests)
+}
+
+func TestContainerHardeningConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.False(t, cfg.Container.Hardening)
+	assert.Equal(t, []string{"NET_RAW", "MKNOD", "AUDIT_WRITE", "SETFCAP", "SYS_CHROOT"}, cfg.Container.CapDrop)
+	assert.Empty(t, cfg.Container.SeccompProfile)
+
+	os.Setenv("CONTAINER_HARDENING", "true")
+	os.Setenv("CONTAINER_CAP_DROP", "ALL")
+	os.Setenv("CONTAINER_SECCOMP_PROFILE", "/etc/devlab/seccomp.json")
+	defer os.Unsetenv("CONTAINER_HARDENING")
+	defer os.Unsetenv("CONTAINER_CAP_DROP")
+	defer os.Unsetenv("CONTAINER_SECCOMP_PROFILE")
+
+	cfg = mustLoad(t)
+	assert.True(t, cfg.Container.Hardening)
+	assert.Equal(t, []string{"ALL"}, cfg.Container.CapDrop)
+	assert.Equal(t, "/etc/devlab/seccomp.json", cfg.Container.SeccompProfile)
+}
+
+func TestContainerReadonlyRootfsConfig(t *testing.T) {
+	assert.False(t, mustLoad(t).Container.ReadonlyRootfs)
+
+	os.Setenv("CONTAINER_READONLY_ROOTFS", "true")
+	defer os.Unsetenv("CONTAINER_READONLY_ROOTFS")
+
+	assert.True(t, mustLoad(t).Container.ReadonlyRootfs)
+}
+
+func TestContainerLabelsConfig(t *testing.T) {
+	assert.Empty(t, mustLoad(t).Container.Labels)
+
+	os.Setenv("CONTAINER_LABELS", `{"cost-center": "eng", "team": "platform"}`)
+	defer os.Unsetenv("CONTAINER_LABELS")
+
+	assert.Equal(t, map[string]string{"cost-center": "eng", "team": "platform"}, mustLoad(t).Container.Labels)
+}
+
+func TestTerminalIdleTimeoutConfig(t *testing.T) {
+	assert.Zero(t, mustLoad(t).Container.TerminalIdleTimeout)
+
+	os.Setenv("TERMINAL_IDLE_TIMEOUT", "15m")
+	defer os.Unsetenv("TERMINAL_IDLE_TIMEOUT")
+
+	assert.Equal(t, 15*time.Minute, mustLoad(t).Container.TerminalIdleTimeout)
+}
+
+// TestRegistryAuthConfig tests parsing of per-registry pull credentials
+func TestRegistryAuthConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Empty(t, cfg.RegistryAuth)
+
+	os.Setenv("REGISTRY_AUTH", `{"registry.example.com":{"username":"ci","password":"secret"}}`)
+	defer os.Unsetenv("REGISTRY_AUTH")
+
+	cfg = mustLoad(t)
+	assert.Equal(t, RegistryCredential{Username: "ci", Password: "secret"}, cfg.RegistryAuth["registry.example.com"])
+
+	// Malformed JSON fails loading instead of surfacing as a pull error later
+	os.Setenv("REGISTRY_AUTH", `{"registry.example.com":`)
+	_, err := Load()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorContains(t, err, "REGISTRY_AUTH")
+}
+
+func TestHostLoadConfig(t *testing.T) {
+	assert.Equal(t, HostLoadConfig{}, mustLoad(t).HostLoad)
+
+	os.Setenv("HOST_MAX_RUNNING_CONTAINERS", "200")
+	os.Setenv("HOST_MAX_LOAD_PER_CPU", "1.5")
+	defer os.Unsetenv("HOST_MAX_RUNNING_CONTAINERS")
+	defer os.Unsetenv("HOST_MAX_LOAD_PER_CPU")
+	assert.Equal(t, HostLoadConfig{MaxRunningContainers: 200, MaxLoadPerCPU: 1.5}, mustLoad(t).HostLoad)
+}
+
+func TestDockerConfig(t *testing.T) {
+	assert.Equal(t, DockerConfig{
+		ClientTimeout:         time.Minute,
+		StartReadinessTimeout: 5 * time.Second,
+		StopTimeout:           10 * time.Second,
+		PullRetries:           2,
+		PullBackoff:           time.Second,
+	}, mustLoad(t).Docker)
+
+	for key, value := range map[string]string{
+		"DOCKER_CLIENT_TIMEOUT":          "30s",
+		"DOCKER_START_READINESS_TIMEOUT": "2s",
+		"DOCKER_STOP_TIMEOUT":            "3s",
+		"DOCKER_PULL_RETRIES":            "0",
+		"DOCKER_PULL_BACKOFF":            "500ms",
+	} {
+		os.Setenv(key, value)
+		defer os.Unsetenv(key)
+	}
+	assert.Equal(t, DockerConfig{
+		ClientTimeout:         30 * time.Second,
+		StartReadinessTimeout: 2 * time.Second,
+		StopTimeout:           3 * time.Second,
+		PullBackoff:           500 * time.Millisecond,
+	}, mustLoad(t).Docker)
+}
+
+func TestProvisioningTimeoutConfig(t *testing.T) {
+	assert.Equal(t, 4*time.Minute, mustLoad(t).ProvisioningTimeout)
+
+	os.Setenv("PROVISIONING_TIMEOUT", "90s")
+	defer os.Unsetenv("PROVISIONING_TIMEOUT")
+	assert.Equal(t, 90*time.Second, mustLoad(t).ProvisioningTimeout)
+}
+
+func TestScenarioTemplatesFileConfig(t *testing.T) {
+	assert.Empty(t, mustLoad(t).ScenarioTemplatesFile)
+
+	os.Setenv("SCENARIO_TEMPLATES_FILE", "/etc/devlab/templates.json")
+	defer os.Unsetenv("SCENARIO_TEMPLATES_FILE")
+	assert.Equal(t, "/etc/devlab/templates.json", mustLoad(t).ScenarioTemplatesFile)
+}
+
+func TestExecMaxOutputBytesConfig(t *testing.T) {
+	assert.Equal(t, 1<<20, mustLoad(t).Exec.MaxOutputBytes)
+
+	os.Setenv("EXEC_MAX_OUTPUT_BYTES", "65536")
+	defer os.Unsetenv("EXEC_MAX_OUTPUT_BYTES")
+	assert.Equal(t, 65536, mustLoad(t).Exec.MaxOutputBytes)
+}
+
+func TestBlkioWeightConfig(t *testing.T) {
+	assert.Zero(t, mustLoad(t).Container.BlkioWeight)
+
+	os.Setenv("CONTAINER_BLKIO_WEIGHT", "300")
+	defer os.Unsetenv("CONTAINER_BLKIO_WEIGHT")
+	assert.Equal(t, 300, mustLoad(t).Container.BlkioWeight)
+}
+
+func TestDefaultLimitsConfig(t *testing.T) {
+	assert.Empty(t, mustLoad(t).Container.DefaultLimits)
+
+	os.Setenv("CONTAINER_DEFAULT_LIMITS", `{"go":{"cpus":1},"go-k8s":{"cpus":2.5,"memory_mb":4096}}`)
+	defer os.Unsetenv("CONTAINER_DEFAULT_LIMITS")
+
+	assert.Equal(t, map[string]ResourceLimits{
+		"go":     {CPUs: 1},
+		"go-k8s": {CPUs: 2.5, MemoryMB: 4096},
+	}, mustLoad(t).Container.DefaultLimits)
+
+	// Malformed limits fail loading rather than leaving containers unlimited
+	os.Setenv("CONTAINER_DEFAULT_LIMITS", `{"go":{"cpus":"lots"}}`)
+	_, err := Load()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorContains(t, err, "CONTAINER_DEFAULT_LIMITS")
+}
+
+// TestMaxTotalScenariosConfig tests the global scenario capacity setting
+func TestMaxTotalScenariosConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Zero(t, cfg.MaxTotalScenarios, "zero means unlimited")
+
+	os.Setenv("MAX_TOTAL_SCENARIOS", "50")
+	defer os.Unsetenv("MAX_TOTAL_SCENARIOS")
+
+	cfg = mustLoad(t)
+	assert.Equal(t, 50, cfg.MaxTotalScenarios)
+}
+
+func TestConcurrentStartsConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Zero(t, cfg.MaxConcurrentStarts, "zero means unlimited")
+	assert.Equal(t, 30*time.Second, cfg.StartQueueTimeout)
+
+	os.Setenv("MAX_CONCURRENT_STARTS", "4")
+	os.Setenv("START_QUEUE_TIMEOUT", "0s")
+	defer func() {
+		os.Unsetenv("MAX_CONCURRENT_STARTS")
+		os.Unsetenv("START_QUEUE_TIMEOUT")
+	}()
+
+	cfg = mustLoad(t)
+	assert.Equal(t, 4, cfg.MaxConcurrentStarts)
+	assert.Zero(t, cfg.StartQueueTimeout)
+}
+
+func TestJWTLeewayConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Equal(t, 30*time.Second, cfg.JWTLeeway)
+
+	os.Setenv("JWT_LEEWAY", "2m")
+	defer os.Unsetenv("JWT_LEEWAY")
+
+	cfg = mustLoad(t)
+	assert.Equal(t, 2*time.Minute, cfg.JWTLeeway)
+}
+
+func TestScenarioIDConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Equal(t, "scn-", cfg.ScenarioID.Prefix)
+	assert.Equal(t, "uuidv7", cfg.ScenarioID.Format)
+
+	os.Setenv("SCENARIO_ID_PREFIX", "lab-")
+	os.Setenv("SCENARIO_ID_FORMAT", "uuidv4")
+	defer os.Unsetenv("SCENARIO_ID_PREFIX")
+	defer os.Unsetenv("SCENARIO_ID_FORMAT")
+
+	cfg = mustLoad(t)
+	assert.Equal(t, "lab-", cfg.ScenarioID.Prefix)
+	assert.Equal(t, "uuidv4", cfg.ScenarioID.Format)
+}
+
+// TestDirectoryConfig tests the directory listing limits
+func TestDirectoryConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Equal(t, 10, cfg.Directory.MaxDepth)
+	assert.Equal(t, 10*time.Second, cfg.Directory.Timeout)
+	assert.Equal(t, 5000, cfg.Directory.MaxEntries)
+	assert.Equal(t, 3, cfg.Directory.RetryAttempts)
+	assert.Equal(t, 250*time.Millisecond, cfg.Directory.RetryDelay)
+	assert.Empty(t, cfg.Directory.User)
+
+	os.Setenv("DIRECTORY_MAX_DEPTH", "3")
+	os.Setenv("DIRECTORY_TIMEOUT", "2s")
+	os.Setenv("DIRECTORY_MAX_ENTRIES", "100")
+	os.Setenv("DIRECTORY_RETRY_ATTEMPTS", "5")
+	os.Setenv("DIRECTORY_RETRY_DELAY", "1s")
+	os.Setenv("DIRECTORY_USER", "devlab")
+	defer func() {
+		os.Unsetenv("DIRECTORY_MAX_DEPTH")
+		os.Unsetenv("DIRECTORY_TIMEOUT")
+		os.Unsetenv("DIRECTORY_MAX_ENTRIES")
+		os.Unsetenv("DIRECTORY_RETRY_ATTEMPTS")
+		os.Unsetenv("DIRECTORY_RETRY_DELAY")
+		os.Unsetenv("DIRECTORY_USER")
+	}()
+
+	cfg = mustLoad(t)
+	assert.Equal(t, 3, cfg.Directory.MaxDepth)
+	assert.Equal(t, 2*time.Second, cfg.Directory.Timeout)
+	assert.Equal(t, 100, cfg.Directory.MaxEntries)
+	assert.Equal(t, 5, cfg.Directory.RetryAttempts)
+	assert.Equal(t, time.Second, cfg.Directory.RetryDelay)
+	assert.Equal(t, "devlab", cfg.Directory.User)
+}
+
+// TestScenarioCacheConfig tests the scenario lookup cache settings
+func TestScenarioCacheConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Zero(t, cfg.ScenarioCache.Size)
+	assert.Equal(t, 2*time.Second, cfg.ScenarioCache.TTL)
+
+	os.Setenv("SCENARIO_CACHE_SIZE", "500")
+	os.Setenv("SCENARIO_CACHE_TTL", "5s")
+	defer func() {
+		os.Unsetenv("SCENARIO_CACHE_SIZE")
+		os.Unsetenv("SCENARIO_CACHE_TTL")
+	}()
+
+	cfg = mustLoad(t)
+	assert.Equal(t, 500, cfg.ScenarioCache.Size)
+	assert.Equal(t, 5*time.Second, cfg.ScenarioCache.TTL)
+}
+
 // TestCleanupConfig tests cleanup configuration
 func TestCleanupConfig(t *testing.T) {
 	// Test default cleanup settings
-	cfg := Load()
+	cfg := mustLoad(t)
 
 	assert.True(t, cfg.Cleanup.EnableCleanup)
 	assert.Equal(t, 15*time.Minute, cfg.Cleanup.CleanupInterval)
 	assert.Equal(t, 24*time.Hour, cfg.Cleanup.MaxScenarioAge)
+	assert.Equal(t, time.Hour, cfg.Cleanup.ExtendIncrement)
+	assert.Equal(t, 72*time.Hour, cfg.Cleanup.MaxScenarioLifetime)
+	assert.Equal(t, 5*time.Minute, cfg.Cleanup.MinScenarioTTL)
+	assert.Equal(t, 24*time.Hour, cfg.Cleanup.MaxScenarioTTL)
+	assert.False(t, cfg.Cleanup.RejectTTLOutOfRange)
+	assert.Equal(t, "age", cfg.Cleanup.ReapMode)
+	assert.Equal(t, 15*time.Minute, cfg.Cleanup.WarningWindow)
+	assert.Equal(t, 10, cfg.Cleanup.PruneThreshold)
+	assert.Equal(t, 7*24*time.Hour, cfg.Cleanup.SnapshotMaxAge)
 
 	// Test custom cleanup settings
 	os.Setenv("CLEANUP_ENABLED", "false")
@@ -112,7 +667,7 @@ func TestCleanupConfig(t *testing.T) {
 		os.Unsetenv("CLEANUP_MAX_SCENARIO_AGE")
 	}()
 
-	cfg = Load()
+	cfg = mustLoad(t)
 	assert.False(t, cfg.Cleanup.EnableCleanup)
 	assert.Equal(t, 30*time.Minute, cfg.Cleanup.CleanupInterval)
 	assert.Equal(t, 48*time.Hour, cfg.Cleanup.MaxScenarioAge)
@@ -128,7 +683,7 @@ func TestInvalidConfigValues(t *testing.T) {
 		os.Unsetenv("CLEANUP_MAX_SCENARIO_AGE")
 	}()
 
-	cfg := Load()
+	cfg := mustLoad(t)
 
 	// Should fall back to default values
 	assert.Equal(t, 15*time.Minute, cfg.Cleanup.CleanupInterval)
@@ -138,14 +693,14 @@ func TestInvalidConfigValues(t *testing.T) {
 // TestConfigReload tests config reloading
 func TestConfigReload(t *testing.T) {
 	// Initial config
-	cfg1 := Load()
+	cfg1 := mustLoad(t)
 
 	// Change environment variable
 	os.Setenv("DB_NAME", "new_db_name")
 	defer os.Unsetenv("DB_NAME")
 
 	// Reload config
-	cfg2 := Load()
+	cfg2 := mustLoad(t)
 
 	assert.NotEqual(t, cfg1.DBName, cfg2.DBName)
 	assert.Equal(t, "new_db_name", cfg2.DBName)
@@ -154,9 +709,9 @@ func TestConfigReload(t *testing.T) {
 // TestConfigConsistency tests config consistency across loads
 func TestConfigConsistency(t *testing.T) {
 	// Load config multiple times
-	cfg1 := Load()
-	cfg2 := Load()
-	cfg3 := Load()
+	cfg1 := mustLoad(t)
+	cfg2 := mustLoad(t)
+	cfg3 := mustLoad(t)
 
 	// All configs should be identical
 	assert.Equal(t, cfg1.MongoURI, cfg2.MongoURI)
@@ -166,3 +721,64 @@ func TestConfigConsistency(t *testing.T) {
 	assert.Equal(t, cfg1.Cleanup.EnableCleanup, cfg2.Cleanup.EnableCleanup)
 	assert.Equal(t, cfg2.Cleanup.EnableCleanup, cfg3.Cleanup.EnableCleanup)
 }
+
+func TestTracingConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Empty(t, cfg.Tracing.OTLPEndpoint)
+	assert.Equal(t, "http/protobuf", cfg.Tracing.OTLPProtocol)
+	assert.Equal(t, "devlab-api", cfg.Tracing.ServiceName)
+	assert.Equal(t, "parentbased_always_on", cfg.Tracing.Sampler)
+	assert.Equal(t, []string{"user_id"}, cfg.Tracing.BaggageKeys)
+
+	os.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4317")
+	os.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc")
+	os.Setenv("OTEL_SERVICE_NAME", "devlab-staging")
+	os.Setenv("OTEL_TRACES_SAMPLER", "traceidratio")
+	os.Setenv("OTEL_TRACES_SAMPLER_ARG", "0.1")
+	os.Setenv("TRACING_BAGGAGE_KEYS", "user_id, scenario_id")
+	defer func() {
+		os.Unsetenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		os.Unsetenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+		os.Unsetenv("OTEL_SERVICE_NAME")
+		os.Unsetenv("OTEL_TRACES_SAMPLER")
+		os.Unsetenv("OTEL_TRACES_SAMPLER_ARG")
+		os.Unsetenv("TRACING_BAGGAGE_KEYS")
+	}()
+
+	cfg = mustLoad(t)
+	assert.Equal(t, "http://collector:4317", cfg.Tracing.OTLPEndpoint)
+	assert.Equal(t, "grpc", cfg.Tracing.OTLPProtocol)
+	assert.Equal(t, "devlab-staging", cfg.Tracing.ServiceName)
+	assert.Equal(t, "traceidratio", cfg.Tracing.Sampler)
+	assert.Equal(t, "0.1", cfg.Tracing.SamplerArg)
+	assert.Equal(t, []string{"user_id", "scenario_id"}, cfg.Tracing.BaggageKeys)
+}
+
+func TestMongoConfig(t *testing.T) {
+	cfg := mustLoad(t)
+	assert.Empty(t, cfg.Mongo.WriteConcern)
+	assert.Empty(t, cfg.Mongo.ReadConcern)
+	assert.Zero(t, cfg.Mongo.WriteTimeout)
+	assert.Equal(t, 3, cfg.Mongo.UpdateRetries)
+	assert.Equal(t, 100*time.Millisecond, cfg.Mongo.UpdateBackoff)
+
+	os.Setenv("MONGODB_WRITE_CONCERN", "majority")
+	os.Setenv("MONGODB_READ_CONCERN", "local")
+	os.Setenv("MONGODB_WRITE_TIMEOUT", "5s")
+	os.Setenv("MONGODB_UPDATE_RETRIES", "5")
+	os.Setenv("MONGODB_UPDATE_BACKOFF", "1s")
+	defer func() {
+		os.Unsetenv("MONGODB_WRITE_CONCERN")
+		os.Unsetenv("MONGODB_READ_CONCERN")
+		os.Unsetenv("MONGODB_WRITE_TIMEOUT")
+		os.Unsetenv("MONGODB_UPDATE_RETRIES")
+		os.Unsetenv("MONGODB_UPDATE_BACKOFF")
+	}()
+
+	cfg = mustLoad(t)
+	assert.Equal(t, "majority", cfg.Mongo.WriteConcern)
+	assert.Equal(t, "local", cfg.Mongo.ReadConcern)
+	assert.Equal(t, 5*time.Second, cfg.Mongo.WriteTimeout)
+	assert.Equal(t, 5, cfg.Mongo.UpdateRetries)
+	assert.Equal(t, time.Second, cfg.Mongo.UpdateBackoff)
+}
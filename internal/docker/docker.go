@@ -1,506 +1,2930 @@
 package docker
 
 import (
+	"bytes"
 	"context"
+	"devlab/internal/config"
+	"devlab/internal/retry"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
+	"maps"
+	"math"
 	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
 
+	"github.com/distribution/reference"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
+	zerologlog "github.com/rs/zerolog/log"
 )
 
-// Custom error types for better error handling
+// Custom error types for better error handling. Running out of ports and
+// losing the daemon are transient, so those two are created with retry.New.
 var (
 	ErrContainerNotFound       = errors.New("container not found")
 	ErrContainerNotRunning     = errors.New("container is not running")
-	ErrPortUnavailable         = errors.New("no available ports found")
+	ErrPortUnavailable         = retry.New("no available ports found")
 	ErrTTYDFailedToStart       = errors.New("ttyd failed to start")
 	ErrInvalidScenarioType     = errors.New("invalid scenario type")
-	ErrDockerDaemonUnavailable = errors.New("docker daemon unavailable")
+	ErrDockerDaemonUnavailable = retry.New("docker daemon unavailable")
+	ErrContainerUserNotFound   = errors.New("container user does not exist in image")
+	ErrMountNotAllowed         = errors.New("host path is not allowed to be mounted")
+	ErrInvalidEnv              = errors.New("invalid environment variable")
+	ErrInvalidLabel            = errors.New("invalid container label")
+	ErrInvalidLimits           = errors.New("invalid resource limits")
+	ErrOutputTruncated         = errors.New("command output exceeded the size limit")
+	ErrInvalidOptions          = errors.New("invalid docker client options")
+	ErrWorkspaceNotPersistent  = errors.New("scenario workspace is not persistent")
 )
 
 type Client interface {
-	StartScenarioContainer(ctx context.Context, scenarioType, script string) (string, int, error)
+	StartScenarioContainer(ctx context.Context, spec ContainerSpec) (string, int, error)
 	GetContainerStatus(ctx context.Context, containerID string) (string, error)
 	GetTerminalURL(ctx context.Context, containerID string) (string, error)
 	StopContainer(ctx context.Context, containerID string) error
+	KillContainer(ctx context.Context, containerID string) error
 	ContainerExists(ctx context.Context, containerID string) (bool, error)
-	ExecuteCommand(ctx context.Context, containerID string, command []string) (string, error)
+	ImageExists(ctx context.Context, image string) (bool, error)
+	ExecuteCommand(ctx context.Context, containerID string, command []string, opts ExecuteCommandOpts) (string, error)
+	RunCommand(ctx context.Context, containerID string, command []string, opts ExecuteCommandOpts) (ExitResult, error)
 	ListContainers(ctx context.Context) ([]ContainerInfo, error)
 	RemoveContainer(ctx context.Context, containerID string) error
+	PruneStoppedContainers(ctx context.Context, labelFilter string) (PruneReport, error)
+	RemoveStoppedContainers(ctx context.Context, containerIDs []string) (PruneReport, error)
+	ListImages(ctx context.Context, labelFilter string) ([]ImageInfo, error)
+	RemoveImage(ctx context.Context, imageID string) error
+	WaitForExit(ctx context.Context, containerID string) (ExitResult, error)
+	RebindTerminalPort(ctx context.Context, containerID string, spec RebindSpec) (string, int, error)
+	PauseContainer(ctx context.Context, containerID string) error
+	UnpauseContainer(ctx context.Context, containerID string) error
+	FollowLogs(ctx context.Context, containerID string, tail int) (*LogStream, error)
+	HostLoad(ctx context.Context) (HostLoad, error)
 }
 
+// Default ttyd login used when a scenario has no generated credentials
+const (
+	DefaultTerminalUsername = "admin"
+	DefaultTerminalPassword = "admin"
+)
+
+// Unprivileged user and home directory baked into the scenario images
+const (
+	ScenarioUser    = "devlab"
+	ScenarioHomeDir = "/home/devlab"
+)
+
+// KubeconfigPath is where the k3s bootstrap writes the kubeconfig in k8s images
+const KubeconfigPath = ScenarioHomeDir + "/.kube/config"
+
+// IsK8sScenarioType reports whether scenarioType's image bootstraps k3s
+func IsK8sScenarioType(scenarioType string) bool {
+	switch scenarioType {
+	case "k8s", "go-k8s", "python-k8s":
+		return true
+	}
+	return false
+}
+
+// ScenarioExecEnv returns the environment commands executed in a scenario of
+// the given type need, or nil when the image defaults suffice
+func ScenarioExecEnv(scenarioType string) []string {
+	if IsK8sScenarioType(scenarioType) {
+		return []string{"KUBECONFIG=" + KubeconfigPath}
+	}
+	return nil
+}
+
+// ContainerSpec describes the scenario container to provision
+type ContainerSpec struct {
+	ScenarioType string
+	Script       string
+	// Name is the container name; empty lets Docker generate one
+	Name string
+	// TerminalUsername and TerminalPassword protect the ttyd web terminal
+	TerminalUsername string
+	TerminalPassword string
+	// Batch runs the script once with no terminal instead of keeping ttyd alive
+	Batch bool
+	// Entrypoint and Command, when set, replace the generated startup script.
+	// StartTTYD launches ttyd in the background before exec'ing the override.
+	Entrypoint []string
+	Command    []string
+	StartTTYD  bool
+	// HostPort is the host port ttyd is published on; zero picks a free one
+	HostPort int
+	// KeepOnFailure leaves an interactive container that fails to come up in
+	// place, for debugging, and reports it with a ProvisioningError
+	KeepOnFailure bool
+	// Mounts bind host paths into the container; each source must lie under
+	// one of the client's MountablePaths
+	Mounts []BindMount
+	// Image, when set, replaces the scenario type's image, e.g. with the
+	// digest the image was pinned to
+	Image string
+	// Env sets extra environment variables in the container. They are passed
+	// through the container config only, never interpolated into a shell
+	// command; see ValidateEnv.
+	Env map[string]string
+	// Labels are extra container labels; see Options.Labels for how they
+	// combine with others and ValidateLabels for the accepted keys
+	Labels map[string]string
+	// Limits caps the container's CPU and memory. Unset fields fall back to
+	// the client's DefaultLimits for the scenario type; see ValidateLimits.
+	Limits ResourceLimits
+}
+
+// ResourceLimits caps a container's CPU and memory; zero leaves a resource
+// unlimited
+type ResourceLimits struct {
+	// CPUs is the number of CPUs the container may use, e.g. 1.5
+	CPUs float64
+	// MemoryMB is the container's memory limit in megabytes
+	MemoryMB int64
+}
+
+// BindMount mounts the host path Source at Target inside a scenario container
+type BindMount struct {
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+// ProvisioningError reports a scenario container that failed to come up and
+// was kept for debugging instead of being removed
+type ProvisioningError struct {
+	ContainerID string
+	// Logs is the container's output up to the failure
+	Logs string
+	Err  error
+}
+
+func (e *ProvisioningError) Error() string {
+	return fmt.Sprintf("%v (container %s kept for debugging)", e.Err, e.ContainerID)
+}
+
+func (e *ProvisioningError) Unwrap() error {
+	return e.Err
+}
+
+// ExitError reports an exec'd command that ran but exited non-zero
+type ExitError struct {
+	ExitCode int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command failed with exit code %d", e.ExitCode)
+}
+
+// ExitResult is the output and exit code of a finished batch container
+type ExitResult struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+	// Truncated is set when the output exceeded the size limit and was cut
+	// off; the command may then still have been running
+	Truncated bool
+}
+
+// maxBatchOutput caps each captured output stream so results fit in a scenario document
+const maxBatchOutput = 1 << 20
+
 // ContainerInfo represents information about a Docker container
 type ContainerInfo struct {
 	ID     string
 	Name   string
 	Status string
+	// TerminalURL is the address ttyd is published on, empty when the
+	// container publishes no ttyd port
+	TerminalURL string
 }
 
-type RealClient struct{}
+// Running reports whether Docker lists the container as up and not paused
+func (c ContainerInfo) Running() bool {
+	return strings.HasPrefix(c.Status, "Up") && !strings.Contains(c.Status, "(Paused)")
+}
 
-func (RealClient) StartScenarioContainer(ctx context.Context, scenarioType, script string) (string, int, error) {
-	if ctx == nil {
-		return "", 0, errors.New("nil context provided")
+// PruneReport summarises a batch removal of stopped containers
+type PruneReport struct {
+	ContainersDeleted []string
+	SpaceReclaimed    uint64
+}
+
+// ManagedLabel marks containers provisioned by devlab; ManagedLabelFilter
+// selects them in Docker label filters
+const (
+	ManagedLabel       = "devlab.managed"
+	ManagedLabelFilter = ManagedLabel + "=true"
+)
+
+// ImageInfo represents information about a local Docker image
+type ImageInfo struct {
+	ID        string
+	Tags      []string
+	Labels    map[string]string
+	CreatedAt time.Time
+}
+
+// Labels carried by snapshot images committed from scenario containers.
+// SnapshotLabelFilter selects them in Docker label filters and
+// ScenarioIDLabel names the scenario a snapshot was taken from.
+const (
+	SnapshotLabel       = "devlab.snapshot"
+	SnapshotLabelFilter = SnapshotLabel + "=true"
+	ScenarioIDLabel     = "devlab.scenario_id"
+)
+
+// DefaultScenarioImage is used for unknown scenario types when no other default is configured
+const DefaultScenarioImage = "devlab-go:latest"
+
+// Disk quota modes for scenario workspaces
+const (
+	DiskQuotaStorageOpt = "storage-opt"
+	DiskQuotaTmpfs      = "tmpfs"
+)
+
+// RegistryCredential is the login used to pull images from a private registry
+type RegistryCredential struct {
+	Username string
+	Password string
+}
+
+type RealClient struct {
+	opts Options
+}
+
+// Options tunes how a RealClient talks to the daemon and the containers it
+// creates. Zero fields keep the defaults, so the zero RealClient behaves as one
+// built from Options{}.
+type Options struct {
+	// ClientTimeout bounds each request to the daemon, e.g. inspecting or
+	// stopping a container, so it should exceed StopTimeout. Image pulls,
+	// commands, waits and log streams can legitimately run far longer and are
+	// never bounded by it. Zero leaves requests unbounded.
+	ClientTimeout time.Duration
+	// StartReadinessTimeout is how long a new interactive container must keep
+	// running before it counts as started
+	StartReadinessTimeout time.Duration
+	// StopTimeout is how long a container is given to exit after SIGTERM
+	// before it is killed; zero keeps the daemon's default of 10 seconds
+	StopTimeout time.Duration
+	// PortRangeStart and PortRangeEnd bound the host ports ttyd is published
+	// on when a spec does not allocate one
+	PortRangeStart int
+	PortRangeEnd   int
+	// PullRetries is how many more times an image pull that fails transiently,
+	// e.g. on a registry timeout, is attempted, waiting PullBackoff before the
+	// first retry and doubling the wait after each
+	PullRetries int
+	PullBackoff time.Duration
+	// DefaultImage is the image used for unknown scenario types
+	DefaultImage string
+	// RegistryAuth holds private registry credentials keyed by registry host (e.g. "registry.example.com")
+	RegistryAuth map[string]RegistryCredential
+	// DiskQuota limits the scenario's writable storage (e.g. "2G"); empty means unlimited
+	DiskQuota string
+	// DiskQuotaMode selects how DiskQuota is enforced: DiskQuotaStorageOpt (default) or DiskQuotaTmpfs
+	DiskQuotaMode string
+	// DNS lists the nameservers scenario containers use instead of the daemon's
+	DNS []string
+	// ExtraHosts adds "host:ip" entries to the containers' /etc/hosts
+	ExtraHosts []string
+	// ContainerUser is the user scenario containers run as, so ttyd and the
+	// scenario script run as it too; empty keeps the image's default user.
+	// ContainerUsers overrides it per scenario type.
+	ContainerUser  string
+	ContainerUsers map[string]string
+	// StartupTemplate renders the startup script of interactive containers;
+	// nil uses the built-in script
+	StartupTemplate *template.Template
+	// MountablePaths lists the host path prefixes scenarios may bind-mount;
+	// empty allows no bind mounts at all
+	MountablePaths []string
+	// CapDrop lists the Linux capabilities removed from scenario containers,
+	// e.g. "NET_RAW" or "ALL"; empty keeps Docker's default set
+	CapDrop []string
+	// NoNewPrivileges stops container processes from gaining privileges, e.g.
+	// through setuid binaries such as sudo
+	NoNewPrivileges bool
+	// SeccompProfile is the JSON seccomp profile applied to scenario
+	// containers, as returned by LoadSeccompProfile; empty keeps the daemon's
+	SeccompProfile string
+	// ReadonlyRootfs mounts the container's root filesystem read-only, leaving
+	// only the paths in readonlyRootfsWritablePaths writable
+	ReadonlyRootfs bool
+	// Labels are set on every scenario container. A spec's labels override
+	// them and devlab's own labels, such as ManagedLabel, override both.
+	Labels map[string]string
+	// TerminalIdleTimeout closes a web terminal session that has had no
+	// input this long, so abandoned browser sessions do not linger; zero
+	// keeps terminals open indefinitely
+	TerminalIdleTimeout time.Duration
+	// DefaultLimits holds the CPU and memory limits of scenarios, keyed by
+	// scenario type, that apply where a spec sets none; types without an
+	// entry run unlimited
+	DefaultLimits map[string]ResourceLimits
+	// BlkioWeight is the relative block IO weight of scenario containers,
+	// from 10 to 1000, so one scenario cannot starve the others of disk
+	// bandwidth; zero keeps the daemon's default. A kernel without IO weight
+	// support discards it and the daemon warns; see ValidateBlkioWeight.
+	BlkioWeight uint16
+}
+
+// Defaults of the Options fields that are not left to the daemon
+const (
+	DefaultStartReadinessTimeout = 5 * time.Second
+	DefaultPullBackoff           = time.Second
+)
+
+// NewRealClient returns a client for the daemon configured from the
+// environment, e.g. DOCKER_HOST, that behaves as set by opts. It fails with
+// ErrInvalidOptions when the port range is inverted.
+func NewRealClient(opts Options) (RealClient, error) {
+	if opts.PortRangeStart > 0 && opts.PortRangeStart > opts.PortRangeEnd {
+		return RealClient{}, fmt.Errorf("%w: port range start %d is after end %d",
+			ErrInvalidOptions, opts.PortRangeStart, opts.PortRangeEnd)
 	}
+	return RealClient{opts: opts}, nil
+}
 
-	cli, err := client.NewClientWithOpts(client.FromEnv)
-	if err != nil {
-		log.Printf("[docker] failed to create client: %v", err)
-		return "", 0, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+// OptionsFromConfig builds the client options of a deployment, so the API and
+// the worker run their clients with the same settings. It validates the
+// container settings and loads the startup template and seccomp profile the
+// configuration points at.
+func OptionsFromConfig(cfg *config.Config) (Options, error) {
+	registryAuth := make(map[string]RegistryCredential, len(cfg.RegistryAuth))
+	for host, cred := range cfg.RegistryAuth {
+		registryAuth[host] = RegistryCredential{Username: cred.Username, Password: cred.Password}
+	}
+	defaultLimits := make(map[string]ResourceLimits, len(cfg.Container.DefaultLimits))
+	for scenarioType, limits := range cfg.Container.DefaultLimits {
+		defaultLimits[scenarioType] = ResourceLimits{CPUs: limits.CPUs, MemoryMB: limits.MemoryMB}
+		if err := ValidateLimits(defaultLimits[scenarioType]); err != nil {
+			return Options{}, fmt.Errorf("invalid CONTAINER_DEFAULT_LIMITS for scenario type %s: %w", scenarioType, err)
+		}
+	}
+	if err := ValidateBlkioWeight(cfg.Container.BlkioWeight); err != nil {
+		return Options{}, fmt.Errorf("invalid CONTAINER_BLKIO_WEIGHT: %w", err)
+	}
+	if err := ValidateLabels(cfg.Container.Labels); err != nil {
+		return Options{}, fmt.Errorf("invalid CONTAINER_LABELS: %w", err)
 	}
-	defer cli.Close()
 
-	// Validate scenario type
-	if scenarioType == "" {
-		return "", 0, fmt.Errorf("%w: scenario type cannot be empty", ErrInvalidScenarioType)
+	opts := Options{
+		ClientTimeout:         cfg.Docker.ClientTimeout,
+		StartReadinessTimeout: cfg.Docker.StartReadinessTimeout,
+		StopTimeout:           cfg.Docker.StopTimeout,
+		PortRangeStart:        cfg.Container.PortRangeStart,
+		PortRangeEnd:          cfg.Container.PortRangeEnd,
+		PullRetries:           cfg.Docker.PullRetries,
+		PullBackoff:           cfg.Docker.PullBackoff,
+		DefaultImage:          cfg.DefaultScenarioImage,
+		RegistryAuth:          registryAuth,
+		DiskQuota:             cfg.Container.DiskQuota,
+		DiskQuotaMode:         cfg.Container.DiskQuotaMode,
+		DNS:                   cfg.Container.DNS,
+		ExtraHosts:            cfg.Container.ExtraHosts,
+		ContainerUser:         cfg.Container.User,
+		ContainerUsers:        cfg.Container.UsersByType,
+		MountablePaths:        cfg.Container.MountablePaths,
+		ReadonlyRootfs:        cfg.Container.ReadonlyRootfs,
+		Labels:                cfg.Container.Labels,
+		TerminalIdleTimeout:   cfg.Container.TerminalIdleTimeout,
+		DefaultLimits:         defaultLimits,
+		BlkioWeight:           uint16(cfg.Container.BlkioWeight),
+	}
+	if cfg.Container.StartupTemplate != "" {
+		startupTemplate, err := LoadStartupTemplate(cfg.Container.StartupTemplate)
+		if err != nil {
+			return Options{}, fmt.Errorf("failed to load startup script template: %w", err)
+		}
+		opts.StartupTemplate = startupTemplate
 	}
+	if cfg.Container.Hardening {
+		opts.CapDrop = cfg.Container.CapDrop
+		opts.NoNewPrivileges = true
+		if cfg.Container.SeccompProfile != "" {
+			seccompProfile, err := LoadSeccompProfile(cfg.Container.SeccompProfile)
+			if err != nil {
+				return Options{}, fmt.Errorf("failed to load seccomp profile: %w", err)
+			}
+			opts.SeccompProfile = seccompProfile
+		}
+	}
+	return opts, nil
+}
 
-	// Select image based on scenarioType
-	image := "devlab-go:latest"
-	switch scenarioType {
-	case "go":
-		image = "devlab-go:latest"
-	case "docker":
-		image = "devlab-docker:latest"
-	case "k8s":
-		image = "devlab-k8s:latest"
-	case "python":
-		image = "devlab-python:latest"
-	case "go-k8s":
-		image = "devlab-go-k8s:latest"
-	case "python-k8s":
-		image = "devlab-python-k8s:latest"
-	default:
-		log.Printf("[docker] unknown scenario type: %s, using default devlab-go image", scenarioType)
+// Options returns the options the client runs with, defaults filled in
+func (c RealClient) Options() Options {
+	opts := c.opts
+	if opts.StartReadinessTimeout <= 0 {
+		opts.StartReadinessTimeout = DefaultStartReadinessTimeout
+	}
+	if opts.PortRangeStart <= 0 {
+		opts.PortRangeStart, opts.PortRangeEnd = DefaultPortRangeStart, DefaultPortRangeEnd
+	}
+	if opts.PullRetries < 0 {
+		opts.PullRetries = 0
+	}
+	if opts.PullBackoff <= 0 {
+		opts.PullBackoff = DefaultPullBackoff
+	}
+	return opts
+}
+
+// newClient connects to the daemon, bounding each request by ClientTimeout
+func (c RealClient) newClient() (*client.Client, error) {
+	if timeout := c.opts.ClientTimeout; timeout > 0 {
+		return client.NewClientWithOpts(client.FromEnv, client.WithTimeout(timeout))
+	}
+	return client.NewClientWithOpts(client.FromEnv)
+}
+
+// newStreamingClient connects to the daemon without bounding requests, for
+// pulls, commands, waits and log streams
+func (RealClient) newStreamingClient() (*client.Client, error) {
+	return client.NewClientWithOpts(client.FromEnv)
+}
+
+// stopOptions gives a stopped container StopTimeout to exit
+func (c RealClient) stopOptions() container.StopOptions {
+	if c.opts.StopTimeout <= 0 {
+		return container.StopOptions{}
+	}
+	seconds := int(math.Ceil(c.opts.StopTimeout.Seconds()))
+	return container.StopOptions{Timeout: &seconds}
+}
+
+// Bounds of a block IO weight the daemon accepts
+const (
+	MinBlkioWeight = 10
+	MaxBlkioWeight = 1000
+)
+
+// ValidateBlkioWeight rejects a non-zero block IO weight outside the range
+// the daemon accepts
+func ValidateBlkioWeight(weight int) error {
+	if weight != 0 && (weight < MinBlkioWeight || weight > MaxBlkioWeight) {
+		return fmt.Errorf("block IO weight %d is outside %d-%d", weight, MinBlkioWeight, MaxBlkioWeight)
+	}
+	return nil
+}
+
+// applyIOWeight sets the container's block IO weight when one is configured
+func (c RealClient) applyIOWeight(hostConfig *container.HostConfig) {
+	hostConfig.BlkioWeight = c.opts.BlkioWeight
+}
+
+// applyResourceLimits caps the container's CPU and memory. Each of spec's
+// limits overrides the scenario type's default; a resource neither sets is
+// left unlimited.
+func (c RealClient) applyResourceLimits(hostConfig *container.HostConfig, spec ContainerSpec) {
+	limits := c.opts.DefaultLimits[spec.ScenarioType]
+	if spec.Limits.CPUs > 0 {
+		limits.CPUs = spec.Limits.CPUs
+	}
+	if spec.Limits.MemoryMB > 0 {
+		limits.MemoryMB = spec.Limits.MemoryMB
+	}
+	hostConfig.NanoCPUs = int64(limits.CPUs * 1e9)
+	hostConfig.Memory = limits.MemoryMB * 1024 * 1024
+}
+
+// applyTerminalIdleTimeout passes the terminal idle timeout, in whole
+// seconds, to ttydIdleWatchdog through the container's environment
+func (c RealClient) applyTerminalIdleTimeout(containerConfig *container.Config) {
+	if seconds := int(c.opts.TerminalIdleTimeout / time.Second); seconds > 0 {
+		containerConfig.Env = append(containerConfig.Env, fmt.Sprintf("%s=%d", terminalIdleTimeoutEnv, seconds))
+	}
+}
+
+// applyContainerUser sets the user the container's processes run as for
+// scenarioType, leaving the image default when none is configured
+func (c RealClient) applyContainerUser(containerConfig *container.Config, scenarioType string) {
+	if user, ok := c.opts.ContainerUsers[scenarioType]; ok {
+		containerConfig.User = user
+		return
+	}
+	containerConfig.User = c.opts.ContainerUser
+}
+
+// applyLabels merges the client's default labels and spec's labels into the
+// labels containerConfig already carries, which take precedence
+func (c RealClient) applyLabels(containerConfig *container.Config, spec ContainerSpec) {
+	labels := make(map[string]string, len(c.opts.Labels)+len(spec.Labels)+len(containerConfig.Labels))
+	maps.Copy(labels, c.opts.Labels)
+	maps.Copy(labels, spec.Labels)
+	maps.Copy(labels, containerConfig.Labels)
+	containerConfig.Labels = labels
+}
+
+// isUnknownUserError reports whether the daemon refused to start a container
+// because its configured user is missing from the image's passwd file
+func isUnknownUserError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unable to find user") || strings.Contains(msg, "no matching entries in passwd file")
+}
+
+// startError wraps a ContainerStart failure, naming the configured user when
+// the image does not have it
+func startError(err error, user string) error {
+	if user != "" && isUnknownUserError(err) {
+		return fmt.Errorf("%w: %q: %v", ErrContainerUserNotFound, user, err)
+	}
+	return fmt.Errorf("failed to start container: %w", err)
+}
+
+// applyNetworkConfig sets the configured nameservers and /etc/hosts entries,
+// leaving the daemon defaults in place when none are configured
+func (c RealClient) applyNetworkConfig(hostConfig *container.HostConfig) {
+	if len(c.opts.DNS) > 0 {
+		hostConfig.DNS = append([]string(nil), c.opts.DNS...)
+	}
+	if len(c.opts.ExtraHosts) > 0 {
+		hostConfig.ExtraHosts = append([]string(nil), c.opts.ExtraHosts...)
+	}
+}
+
+// applySecurityOptions applies the configured hardening, leaving Docker's
+// defaults in place when none is configured
+func (c RealClient) applySecurityOptions(hostConfig *container.HostConfig) {
+	if len(c.opts.CapDrop) > 0 {
+		hostConfig.CapDrop = append(strslice.StrSlice(nil), c.opts.CapDrop...)
+	}
+	if c.opts.NoNewPrivileges {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "no-new-privileges")
+	}
+	if c.opts.SeccompProfile != "" {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "seccomp="+c.opts.SeccompProfile)
+	}
+}
+
+// Paths that stay writable, as tmpfs mounts, in containers with a read-only
+// root filesystem. Every scenario needs the workspace and /tmp, where the
+// startup script writes ttyd's pid file. Kubernetes types also need the
+// directories k3s keeps its config, data, extracted binaries, runtime state
+// and logs in, and the docker type those of its Docker daemon. Custom images
+// writing anywhere else fail under a read-only root filesystem.
+var (
+	readonlyRootfsWritablePaths = []string{ScenarioHomeDir, "/tmp"}
+	k3sWritablePaths            = []string{"/etc/rancher", "/var/lib/rancher", "/var/lib/kubelet", "/var/lib/cni", "/var/log", "/run"}
+	dockerdWritablePaths        = []string{"/var/lib/docker", "/run"}
+)
+
+// writablePaths returns the paths a scenarioType container writes to
+func writablePaths(scenarioType string) []string {
+	paths := slices.Clone(readonlyRootfsWritablePaths)
+	switch {
+	case IsK8sScenarioType(scenarioType):
+		paths = append(paths, k3sWritablePaths...)
+	case scenarioType == "docker":
+		paths = append(paths, dockerdWritablePaths...)
+	}
+	return paths
+}
+
+// applyReadonlyRootfs makes the root filesystem read-only when configured and
+// mounts a tmpfs over each path a scenarioType container writes to. A tmpfs
+// the disk quota already mounted keeps its size limit. Like the tmpfs disk
+// quota, the workspace tmpfs hides whatever the image put in ScenarioHomeDir.
+func (c RealClient) applyReadonlyRootfs(hostConfig *container.HostConfig, scenarioType string) {
+	if !c.opts.ReadonlyRootfs {
+		return
 	}
-	log.Printf("[docker] using image: %s for scenario type: %s", image, scenarioType)
 
-	// Find an available port for ttyd
-	hostPort, err := findAvailablePort()
+	hostConfig.ReadonlyRootfs = true
+	if hostConfig.Tmpfs == nil {
+		hostConfig.Tmpfs = map[string]string{}
+	}
+	for _, path := range writablePaths(scenarioType) {
+		if _, ok := hostConfig.Tmpfs[path]; ok {
+			continue
+		}
+		switch path {
+		case ScenarioHomeDir:
+			hostConfig.Tmpfs[path] = "rw,exec,uid=1000,gid=1000,mode=0755"
+		case "/tmp":
+			hostConfig.Tmpfs[path] = "rw,exec,mode=1777"
+		default:
+			hostConfig.Tmpfs[path] = "rw,exec"
+		}
+	}
+}
+
+// LoadSeccompProfile reads the seccomp profile at path. The daemon takes the
+// profile itself rather than a path, which would be resolved on its host, so
+// it is read once here and checked to be JSON.
+func LoadSeccompProfile(path string) (string, error) {
+	content, err := os.ReadFile(path)
 	if err != nil {
-		log.Printf("[docker] failed to find available port: %v", err)
-		return "", 0, fmt.Errorf("%w: %v", ErrPortUnavailable, err)
+		return "", fmt.Errorf("failed to read seccomp profile: %w", err)
+	}
+	if !json.Valid(content) {
+		return "", fmt.Errorf("invalid seccomp profile %s: not JSON", path)
+	}
+	return string(content), nil
+}
+
+// applyDiskQuota limits the container's writable storage. storage-opt caps the
+// whole container filesystem; tmpfs mounts a size-limited, memory-backed
+// ScenarioHomeDir instead, which hides whatever the image put there.
+func applyDiskQuota(hostConfig *container.HostConfig, quota, mode string) {
+	if quota == "" {
+		return
+	}
+
+	switch mode {
+	case DiskQuotaTmpfs:
+		if hostConfig.Tmpfs == nil {
+			hostConfig.Tmpfs = map[string]string{}
+		}
+		hostConfig.Tmpfs[ScenarioHomeDir] = "rw,exec,size=" + quota + ",uid=1000,gid=1000,mode=0755"
+	default:
+		if hostConfig.StorageOpt == nil {
+			hostConfig.StorageOpt = map[string]string{}
+		}
+		hostConfig.StorageOpt["size"] = quota
 	}
-	log.Printf("[docker] using host port %d for ttyd", hostPort)
+}
 
+// bindMounts converts requested bind mounts to Docker mounts, rejecting any
+// whose source is not an absolute path under one of the allowed prefixes.
+// Paths are compared after cleaning, so ".." cannot climb out of a prefix,
+// and only whole path elements match: /srv/data does not allow /srv/database.
+// Symlinks are not resolved, as the paths live on the Docker host.
+func bindMounts(requested []BindMount, allowed []string) ([]mount.Mount, error) {
 	var mounts []mount.Mount
+	for _, m := range requested {
+		if !filepath.IsAbs(m.Source) || !filepath.IsAbs(m.Target) {
+			return nil, fmt.Errorf("%w: %q -> %q must both be absolute paths", ErrMountNotAllowed, m.Source, m.Target)
+		}
+		source := filepath.Clean(m.Source)
+		if !pathAllowed(source, allowed) {
+			return nil, fmt.Errorf("%w: %s is outside the allowed paths", ErrMountNotAllowed, source)
+		}
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   source,
+			Target:   filepath.Clean(m.Target),
+			ReadOnly: m.ReadOnly,
+		})
+	}
+	return mounts, nil
+}
 
-	// Create a startup script that runs ttyd (pre-installed in custom images)
-	startupScript := fmt.Sprintf(`#!/bin/sh
-set -e
+// pathAllowed reports whether the clean absolute path is one of the allowed
+// prefixes or lies beneath one
+func pathAllowed(path string, allowed []string) bool {
+	for _, prefix := range allowed {
+		prefix = filepath.Clean(prefix)
+		if !filepath.IsAbs(prefix) {
+			continue
+		}
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
 
-# Set scenario type for k3s initialization
-SCENARIO_TYPE="%s"
+// registryAuthFor returns the base64-encoded auth header for pulling image, or
+// "" when no credentials are configured for the image's registry host
+func registryAuthFor(image string, credentials map[string]RegistryCredential) (string, error) {
+	if len(credentials) == 0 {
+		return "", nil
+	}
 
-echo "Starting ttyd on port 3000..."
-# Start ttyd in background with error checking
-ttyd -p 3000 -c admin:admin --writable -t disableReuse=true bash &
-TTYD_PID=$!
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("invalid image reference %q: %w", image, err)
+	}
 
-# Wait a moment for ttyd to start and check if it's running
-sleep 3
-if ! kill -0 $TTYD_PID 2>/dev/null; then
-    echo "ERROR: ttyd failed to start"
-    exit 1
-fi
+	host := reference.Domain(named)
+	credential, ok := credentials[host]
+	if !ok {
+		return "", nil
+	}
 
-echo "ttyd started successfully on port 3000"
+	return registry.EncodeAuthConfig(registry.AuthConfig{
+		Username:      credential.Username,
+		Password:      credential.Password,
+		ServerAddress: host,
+	})
+}
 
-# Initialize k3s for k8s scenarios
-if [ "$SCENARIO_TYPE" = "k8s" ] || [ "$SCENARIO_TYPE" = "go-k8s" ] || [ "$SCENARIO_TYPE" = "python-k8s" ]; then
-    echo "Initializing k3s for Kubernetes scenario..."
-    /usr/local/bin/start-k3s.sh &
-    echo "k3s initialization started in background"
-fi
+// ensureImage pulls image when it is not present locally, authenticating
+// against its registry when credentials are configured and retrying transient
+// failures as set by PullRetries
+func (c RealClient) ensureImage(ctx context.Context, cli *client.Client, image string) error {
+	_, _, err := cli.ImageInspectWithRaw(ctx, image)
+	if err == nil {
+		return nil
+	}
+	if !client.IsErrNotFound(err) {
+		return fmt.Errorf("failed to inspect image %s: %w", image, err)
+	}
 
-# Run the scenario script if provided
-%s
+	auth, err := registryAuthFor(image, c.opts.RegistryAuth)
+	if err != nil {
+		return err
+	}
 
-# Keep container running
-echo "Container ready for terminal access"
-sleep infinity
-`, scenarioType, script)
+	return c.pullImageWithRetry(ctx, cli, image, auth)
+}
 
-	// Create startup script content (will be written inside container)
-	startupScriptContent := startupScript
+// pullImageWithRetry pulls image, retrying transient failures up to
+// PullRetries times. Other errors, and the last transient one, are returned.
+func (c RealClient) pullImageWithRetry(ctx context.Context, cli imagePuller, image, auth string) error {
+	opts := c.Options()
+	backoff := opts.PullBackoff
+	for attempt := 0; ; attempt++ {
+		err := pullImage(ctx, cli, image, auth)
+		if err == nil || attempt >= opts.PullRetries || !isTransientPullError(err) {
+			return err
+		}
 
-	exposedPorts := nat.PortSet{"3000/tcp": struct{}{}}
-	portBindings := nat.PortMap{
-		"3000/tcp": []nat.PortBinding{{
-			HostIP:   "0.0.0.0",
-			HostPort: fmt.Sprintf("%d", hostPort),
-		}},
+		log.Printf("[docker] retrying pull of image %s after transient error (attempt %d of %d): %v", image, attempt+1, opts.PullRetries, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
 	}
+}
 
-	resp, err := cli.ContainerCreate(ctx, &container.Config{
-		Image:        image,
-		Cmd:          []string{"sh", "-c", "cat > /tmp/startup.sh << 'EOF'\n" + startupScriptContent + "\nEOF\nchmod +x /tmp/startup.sh && sh /tmp/startup.sh"},
-		Tty:          true,
-		ExposedPorts: exposedPorts,
-	}, &container.HostConfig{
-		Mounts:       mounts,
-		PortBindings: portBindings,
-	}, nil, nil, "")
+// imagePuller is the subset of the Docker API used by pullImage
+type imagePuller interface {
+	ImagePull(ctx context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error)
+}
+
+// pullImage pulls image once, authenticating with the encoded auth if set
+func pullImage(ctx context.Context, cli imagePuller, image, auth string) error {
+	log.Printf("[docker] pulling image %s", image)
+	out, err := cli.ImagePull(ctx, image, types.ImagePullOptions{RegistryAuth: auth})
 	if err != nil {
-		log.Printf("[docker] failed to create container: %v", err)
-		return "", 0, fmt.Errorf("failed to create container: %w", err)
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	defer out.Close()
+
+	// The pull only completes once the progress stream has been drained
+	if _, err := io.Copy(io.Discard, out); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	return nil
+}
+
+// isTransientPullError reports whether a failed pull is worth retrying:
+// anything but a missing image, rejected credentials, an invalid reference or
+// the caller giving up
+func isTransientPullError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return !errdefs.IsNotFound(err) && !errdefs.IsUnauthorized(err) &&
+		!errdefs.IsForbidden(err) && !errdefs.IsInvalidParameter(err)
+}
+
+// createContainer creates a scenario container, degrading gracefully where
+// the host cannot enforce a limit: without storage driver support the disk
+// quota is dropped, and limits the daemon itself discards, such as a block IO
+// weight on a kernel without support for it, are logged from its warnings.
+func createContainer(ctx context.Context, cli *client.Client, containerConfig *container.Config, hostConfig *container.HostConfig, name string) (container.CreateResponse, error) {
+	resp, err := cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, name)
+	if err != nil && isStorageOptUnsupported(err) {
+		// Only some storage drivers (e.g. overlay2 on xfs with pquota) can limit size
+		log.Printf("[docker] WARNING: storage driver does not support size limits, starting without disk quota: %v", err)
+		hostConfig.StorageOpt = nil
+		resp, err = cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, name)
+	}
+	for _, warning := range resp.Warnings {
+		log.Printf("[docker] WARNING: daemon warning creating container %s: %s", resp.ID, warning)
+	}
+	return resp, err
+}
+
+// isStorageOptUnsupported reports whether the daemon rejected a container
+// because its storage driver cannot enforce --storage-opt size
+func isStorageOptUnsupported(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "storage-opt") || strings.Contains(msg, "storage opt")
+}
+
+// scenarioImages maps each known scenario type to its base image
+var scenarioImages = map[string]string{
+	"go":         "devlab-go:latest",
+	"docker":     "devlab-docker:latest",
+	"k8s":        "devlab-k8s:latest",
+	"python":     "devlab-python:latest",
+	"go-k8s":     "devlab-go-k8s:latest",
+	"python-k8s": "devlab-python-k8s:latest",
+}
+
+// IsBaseImage reports whether ref is one of the scenario base images, which
+// image cleanup must never remove
+func IsBaseImage(ref string) bool {
+	if ref == DefaultScenarioImage {
+		return true
+	}
+	for _, image := range scenarioImages {
+		if ref == image {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportedScenarioTypes lists the scenario types with a dedicated image, sorted
+func SupportedScenarioTypes() []string {
+	supported := make([]string, 0, len(scenarioImages))
+	for scenarioType := range scenarioImages {
+		supported = append(supported, scenarioType)
+	}
+	sort.Strings(supported)
+	return supported
+}
+
+// ValidateScenarioType rejects an empty scenario type and any type listed in
+// reserved, which operators use for types that must fail loudly rather than
+// fall back to the default image. Other unknown types remain allowed.
+func ValidateScenarioType(scenarioType string, reserved []string) error {
+	if scenarioType == "" {
+		return fmt.Errorf("%w: scenario type cannot be empty", ErrInvalidScenarioType)
+	}
+	for _, r := range reserved {
+		if scenarioType == r {
+			return fmt.Errorf("%w: %q is not supported, use one of: %s", ErrInvalidScenarioType, scenarioType, strings.Join(SupportedScenarioTypes(), ", "))
+		}
+	}
+	return nil
+}
+
+// envKeyPattern matches the environment variable names a shell can reference
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedEnvKeys are set by devlab itself and cannot be overridden
+var reservedEnvKeys = map[string]bool{
+	"TTYD_CREDENTIAL":      true,
+	"SCENARIO_TYPE":        true,
+	terminalIdleTimeoutEnv: true,
+}
+
+// ValidateEnv rejects environment variables whose names are not valid shell
+// identifiers or are reserved, and values containing null bytes, which
+// cannot be represented in a process environment
+func ValidateEnv(env map[string]string) error {
+	for _, key := range sortedKeys(env) {
+		if !envKeyPattern.MatchString(key) {
+			return fmt.Errorf("%w: %q is not a valid name", ErrInvalidEnv, key)
+		}
+		if reservedEnvKeys[key] {
+			return fmt.Errorf("%w: %q is reserved", ErrInvalidEnv, key)
+		}
+		if strings.ContainsRune(env[key], 0) {
+			return fmt.Errorf("%w: value of %q contains a null byte", ErrInvalidEnv, key)
+		}
+	}
+	return nil
+}
+
+// labelKeyPattern follows Docker's label key guidelines: lowercase letters,
+// digits, dots and hyphens, starting and ending with a letter or digit
+var labelKeyPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]*[a-z0-9])?$`)
+
+// reservedLabelPrefix namespaces the labels devlab sets itself, such as
+// ManagedLabel, which cleanup and snapshot pruning rely on
+const reservedLabelPrefix = "devlab."
+
+// ValidateLabels rejects container label keys that do not match
+// labelKeyPattern or that fall under devlab's own reserved prefix
+func ValidateLabels(labels map[string]string) error {
+	for _, key := range sortedKeys(labels) {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("%w: %q is not a valid key", ErrInvalidLabel, key)
+		}
+		if strings.HasPrefix(key, reservedLabelPrefix) {
+			return fmt.Errorf("%w: %q uses the reserved %q prefix", ErrInvalidLabel, key, reservedLabelPrefix)
+		}
+	}
+	return nil
+}
+
+// MinMemoryLimitMB is the smallest memory limit Docker accepts
+const MinMemoryLimitMB = 6
+
+// ValidateLimits rejects negative or non-finite limits and memory limits
+// below MinMemoryLimitMB
+func ValidateLimits(limits ResourceLimits) error {
+	if limits.CPUs < 0 || math.IsNaN(limits.CPUs) || math.IsInf(limits.CPUs, 0) {
+		return fmt.Errorf("%w: %v CPUs", ErrInvalidLimits, limits.CPUs)
+	}
+	if limits.MemoryMB < 0 || (limits.MemoryMB > 0 && limits.MemoryMB < MinMemoryLimitMB) {
+		return fmt.Errorf("%w: memory must be at least %d MB, got %d", ErrInvalidLimits, MinMemoryLimitMB, limits.MemoryMB)
+	}
+	return nil
+}
+
+// envList formats env as KEY=value entries sorted by name
+func envList(env map[string]string) []string {
+	var list []string
+	for _, key := range sortedKeys(env) {
+		list = append(list, key+"="+env[key])
+	}
+	return list
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// containerImage returns the image spec's container runs: the pinned image
+// when the caller resolved one, otherwise the image of the scenario type
+func containerImage(spec ContainerSpec, defaultImage string) string {
+	if spec.Image != "" {
+		return spec.Image
+	}
+	return ImageForScenarioType(spec.ScenarioType, defaultImage)
+}
+
+// BaseImages lists the scenario base images and defaultImage, sorted, without duplicates
+func BaseImages(defaultImage string) []string {
+	if defaultImage == "" {
+		defaultImage = DefaultScenarioImage
+	}
+	images := []string{defaultImage}
+	for _, image := range scenarioImages {
+		if !slices.Contains(images, image) {
+			images = append(images, image)
+		}
+	}
+	sort.Strings(images)
+	return images
+}
+
+// IsDigestRef reports whether ref names an immutable image, either by
+// repository digest (name@sha256:...) or by image ID (sha256:...)
+func IsDigestRef(ref string) bool {
+	return strings.HasPrefix(ref, "sha256:") || strings.Contains(ref, "@sha256:")
+}
+
+// isLatestRef reports whether ref is tagged latest, explicitly or by omission
+func isLatestRef(ref string) bool {
+	if IsDigestRef(ref) {
+		return false
+	}
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return false
+	}
+	tagged, ok := named.(reference.Tagged)
+	return !ok || tagged.Tag() == "latest"
+}
+
+// digestRef returns the immutable reference for an inspected image: its
+// repository digest when it was pulled from a registry, otherwise its ID,
+// which is all a locally built image has
+func digestRef(inspect types.ImageInspect) string {
+	if len(inspect.RepoDigests) > 0 {
+		return inspect.RepoDigests[0]
+	}
+	return inspect.ID
+}
+
+// ResolveImageDigests pins each of images tagged latest to the digest it has
+// on the Docker host right now, so scenarios started later run the same image
+// even if the tag moves. Images that are not present are left unpinned.
+func (c RealClient) ResolveImageDigests(ctx context.Context, images []string) (map[string]string, error) {
+	cli, err := c.newClient()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	resolved := make(map[string]string)
+	for _, image := range images {
+		if !isLatestRef(image) {
+			continue
+		}
+		inspect, _, err := cli.ImageInspectWithRaw(ctx, image)
+		if client.IsErrNotFound(err) {
+			log.Printf("[docker] image %s is not present, leaving it unpinned", image)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect image %s: %w", image, err)
+		}
+		resolved[image] = digestRef(inspect)
+		log.Printf("[docker] pinned image %s to %s", image, resolved[image])
+	}
+	return resolved, nil
+}
+
+// ImageForScenarioType selects the image for a scenario type, falling back to
+// defaultImage (or DefaultScenarioImage when unset) for unknown types
+func ImageForScenarioType(scenarioType, defaultImage string) string {
+	if image, ok := scenarioImages[scenarioType]; ok {
+		return image
+	}
+
+	if defaultImage == "" {
+		defaultImage = DefaultScenarioImage
+	}
+	log.Printf("[docker] unknown scenario type: %s, using default image %s", scenarioType, defaultImage)
+	return defaultImage
+}
+
+func (c RealClient) StartScenarioContainer(ctx context.Context, spec ContainerSpec) (string, int, error) {
+	scenarioType := spec.ScenarioType
+	if ctx == nil {
+		return "", 0, errors.New("nil context provided")
+	}
+
+	cli, err := c.newStreamingClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return "", 0, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	// Validate scenario type
+	if scenarioType == "" {
+		return "", 0, fmt.Errorf("%w: scenario type cannot be empty", ErrInvalidScenarioType)
+	}
+
+	if err := ValidateEnv(spec.Env); err != nil {
+		return "", 0, err
+	}
+	if err := ValidateLabels(spec.Labels); err != nil {
+		return "", 0, err
+	}
+	if err := ValidateLimits(spec.Limits); err != nil {
+		return "", 0, err
+	}
+
+	// Reject disallowed host paths before pulling anything
+	mounts, err := bindMounts(spec.Mounts, c.opts.MountablePaths)
+	if err != nil {
+		log.Printf("[docker] %v", err)
+		return "", 0, err
+	}
+
+	// Select image based on scenarioType
+	image := containerImage(spec, c.opts.DefaultImage)
+	zerologlog.Debug().Msgf("[docker] using image: %s for scenario type: %s", image, scenarioType)
+
+	if err := c.ensureImage(ctx, cli, image); err != nil {
+		log.Printf("[docker] failed to prepare image %s: %v", image, err)
+		return "", 0, err
+	}
+
+	if spec.Batch {
+		return c.startBatchContainer(ctx, cli, image, spec, mounts)
+	}
+
+	// Find an available port for ttyd unless the caller allocated one
+	hostPort := spec.HostPort
+	if hostPort == 0 {
+		hostPort, err = c.findAvailablePort()
+		if err != nil {
+			log.Printf("[docker] failed to find available port: %v", err)
+			return "", 0, fmt.Errorf("%w: %v", ErrPortUnavailable, err)
+		}
+	}
+	zerologlog.Debug().Msgf("[docker] using host port %d for ttyd", hostPort)
+
+	portBindings := nat.PortMap{
+		"3000/tcp": []nat.PortBinding{{
+			HostIP:   "0.0.0.0",
+			HostPort: fmt.Sprintf("%d", hostPort),
+		}},
+	}
+
+	containerConfig, err := interactiveContainerConfig(image, spec, c.opts.StartupTemplate)
+	if err != nil {
+		log.Printf("[docker] %v", err)
+		return "", 0, err
+	}
+	c.applyContainerUser(containerConfig, scenarioType)
+	c.applyLabels(containerConfig, spec)
+	c.applyTerminalIdleTimeout(containerConfig)
+	hostConfig := &container.HostConfig{
+		Mounts:       mounts,
+		PortBindings: portBindings,
+	}
+	applyDiskQuota(hostConfig, c.opts.DiskQuota, c.opts.DiskQuotaMode)
+	c.applyResourceLimits(hostConfig, spec)
+	c.applyIOWeight(hostConfig)
+	c.applyReadonlyRootfs(hostConfig, scenarioType)
+	c.applyNetworkConfig(hostConfig)
+	c.applySecurityOptions(hostConfig)
+
+	resp, err := createContainer(ctx, cli, containerConfig, hostConfig, spec.Name)
+	if err != nil {
+		log.Printf("[docker] failed to create container: %v", err)
+		return "", 0, fmt.Errorf("failed to create container: %w", err)
+	}
+
+	if err := cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		log.Printf("[docker] failed to start container %s: %v", resp.ID, err)
+		// A container that never started has no logs
+		return "", 0, failedContainer(ctx, cli, resp.ID, spec.KeepOnFailure, "", startError(err, containerConfig.User))
+	}
+
+	// Wait a bit and check if container is still running
+	select {
+	case <-time.After(c.Options().StartReadinessTimeout):
+	case <-ctx.Done():
+		log.Printf("[docker] context cancelled while waiting for container %s, removing it", resp.ID)
+		cli.ContainerRemove(context.WithoutCancel(ctx), resp.ID, container.RemoveOptions{Force: true})
+		return "", 0, fmt.Errorf("container provisioning cancelled: %w", ctx.Err())
+	}
+	containerInfo, err := cli.ContainerInspect(ctx, resp.ID)
+	if err != nil {
+		log.Printf("[docker] failed to inspect container %s: %v", resp.ID, err)
+		return "", 0, fmt.Errorf("failed to verify container status: %w", err)
+	}
+
+	if containerInfo.State.Status != "running" {
+		log.Printf("[docker] container %s is not running, status: %s", resp.ID, containerInfo.State.Status)
+		output := exitedContainerLogs(ctx, cli, resp.ID, containerInfo.Config != nil && containerInfo.Config.Tty)
+		logExitedContainer(resp.ID, image, containerInfo.State.ExitCode, output)
+		return "", 0, failedContainer(ctx, cli, resp.ID, spec.KeepOnFailure, output, exitedContainerError(image, containerInfo.State.ExitCode, output))
+	}
+
+	log.Printf("[docker] started container: %s with ttyd on port %d", resp.ID, hostPort)
+	return resp.ID, hostPort, nil
+}
+
+// maxExitedLogBytes caps the logs read from a container that exited during provisioning
+const maxExitedLogBytes = 64 * 1024
+
+// Lines of an exited container's output that are read, logged and quoted in
+// the provisioning error respectively
+const (
+	exitedLogLines      = 200
+	exitedLogTailLines  = 20
+	exitedErrorLogLines = 5
+)
+
+// exitedContainerLogs returns the last exitedLogLines lines of a container's
+// output, or "" when they cannot be read. The output is multiplexed unless the
+// container has a TTY, as interactive containers do.
+func exitedContainerLogs(ctx context.Context, cli *client.Client, containerID string, tty bool) string {
+	opts := container.LogsOptions{ShowStdout: true, ShowStderr: true, Tail: strconv.Itoa(exitedLogLines)}
+	logs, err := cli.ContainerLogs(ctx, containerID, opts)
+	if err != nil {
+		log.Printf("[docker] failed to read logs for container %s: %v", containerID, err)
+		return ""
+	}
+	defer logs.Close()
+	return readExitedLogs(logs, tty)
+}
+
+// readExitedLogs reads up to maxExitedLogBytes of raw log output, merging
+// stdout and stderr in the order they were written. Output read before an
+// error, e.g. a frame cut off by the size limit, is kept.
+func readExitedLogs(r io.Reader, tty bool) string {
+	var output bytes.Buffer
+	limited := io.LimitReader(r, maxExitedLogBytes)
+	var err error
+	if tty {
+		_, err = io.Copy(&output, limited)
+	} else {
+		_, err = stdcopy.StdCopy(&output, &output, limited)
+	}
+	if err != nil {
+		log.Printf("[docker] failed to read container logs to the end: %v", err)
+	}
+	return output.String()
+}
+
+// logExitedContainer logs the end of the output of a container that exited
+// during provisioning, which usually says why ttyd or the startup script failed
+func logExitedContainer(containerID, image string, exitCode int, logs string) {
+	zerologlog.Error().
+		Str("container_id", containerID).
+		Str("image", image).
+		Int("exit_code", exitCode).
+		Str("logs", logTail(logs, exitedLogTailLines)).
+		Msg("[docker] container exited during provisioning")
+}
+
+// logTail returns the last n non-blank lines of logs, without the carriage
+// returns a TTY adds
+func logTail(logs string, n int) string {
+	var lines []string
+	for _, line := range strings.Split(logs, "\n") {
+		if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// failedContainer disposes of a container that failed to come up with cause.
+// It is removed, even if the caller has gone away, unless keep is set, in
+// which case it is left in place and reported with logs.
+func failedContainer(ctx context.Context, cli *client.Client, containerID string, keep bool, logs string, cause error) error {
+	if !keep {
+		if err := cli.ContainerRemove(context.WithoutCancel(ctx), containerID, container.RemoveOptions{Force: true}); err != nil {
+			log.Printf("[docker] failed to remove failed container %s: %v", containerID, err)
+		}
+		return cause
+	}
+	log.Printf("[docker] keeping failed container %s for debugging", containerID)
+	return &ProvisioningError{ContainerID: containerID, Logs: logs, Err: cause}
+}
+
+// exitedContainerError explains why an interactive container exited before
+// it was ready, calling out an image without ttyd specifically and otherwise
+// quoting the end of its output
+func exitedContainerError(image string, exitCode int, logs string) error {
+	if strings.Contains(logs, ttydMissingMessage) {
+		return fmt.Errorf("%w: %s %s", ErrTTYDFailedToStart, ttydMissingMessage, image)
+	}
+	if tail := logTail(logs, exitedErrorLogLines); tail != "" {
+		return fmt.Errorf("%w: container exited unexpectedly with code %d, last output: %q", ErrTTYDFailedToStart, exitCode, tail)
+	}
+	return fmt.Errorf("%w: container exited unexpectedly with code %d", ErrTTYDFailedToStart, exitCode)
+}
+
+// ttydMissingMessage is printed by ttydPreflight and recognised in the logs of
+// a container that exited during provisioning
+const ttydMissingMessage = "ttyd not found in image"
+
+// ttydPreflight exits with 127 and a clear message when the image has no ttyd,
+// which custom images must provide
+const ttydPreflight = `command -v ttyd >/dev/null 2>&1 || { echo "ERROR: ` + ttydMissingMessage + `" >&2; exit 127; }`
+
+// terminalIdleTimeoutEnv carries the terminal idle timeout, in seconds, into
+// scenario containers
+const terminalIdleTimeoutEnv = "TTYD_IDLE_TIMEOUT"
+
+// ttydIdleWatchdog ends the terminal session once its tty has gone
+// TTYD_IDLE_TIMEOUT seconds without input, whatever is running in it. It polls
+// the tty's access time, which the kernel updates as the user types, and hangs
+// up the session shell, which closes the browser session. It never starts when
+// no idle timeout is configured.
+const ttydIdleWatchdog = `t="${` + terminalIdleTimeoutEnv + `:-0}"; ` +
+	`if [ "$t" -gt 0 ] 2>/dev/null && tty=$(tty); then ` +
+	`i=$t; [ "$i" -gt 30 ] && i=30; ` +
+	`( while sleep "$i" && kill -0 $$; do ` +
+	`[ $(( $(date +%s) - $(stat -c %X "$tty") )) -lt "$t" ] || { kill -HUP $$; exit; }; ` +
+	`done ) >/dev/null 2>&1 & fi`
+
+// ttydShell is the command ttyd serves: bash, watched by ttydIdleWatchdog
+const ttydShell = `sh -c '` + ttydIdleWatchdog + `; exec bash'`
+
+// ttydPIDFile holds the pid of the running ttyd, so TTYDRestartCommand can
+// replace it
+const ttydPIDFile = "/tmp/ttyd.pid"
+
+// ttydLauncher starts ttyd in the background and then execs its arguments, so an
+// entrypoint override keeps the web terminal without the generated startup script
+const ttydLauncher = ttydPreflight + `
+ttyd -p 3000 -c "${TTYD_CREDENTIAL:-admin:admin}" --writable -t disableReuse=true ` + ttydShell + ` &
+echo $! > ` + ttydPIDFile + `
+exec "$@"`
+
+// ttydContainerPort is the port ttyd listens on inside scenario containers
+const ttydContainerPort = 3000
+
+// StartupScriptData is what a startup script template is rendered with
+type StartupScriptData struct {
+	ScenarioType string
+	Script       string
+	TtydPort     int
+	// Preflight exits early with a clear message when the image has no ttyd
+	Preflight string
+	// Shell is the command ttyd serves; it honours the deployment's terminal
+	// idle timeout, which a template serving plain bash ignores
+	Shell string
+}
+
+// defaultStartupScript is the built-in startup script template: it runs ttyd,
+// initialises k3s for k8s scenario types and then the scenario script
+const defaultStartupScript = `#!/bin/sh
+set -e
+
+# Set scenario type for k3s initialization
+SCENARIO_TYPE="{{.ScenarioType}}"
+
+{{.Preflight}}
+
+echo "Starting ttyd on port {{.TtydPort}}..."
+# Start ttyd in background with error checking
+ttyd -p {{.TtydPort}} -c "${TTYD_CREDENTIAL:-admin:admin}" --writable -t disableReuse=true {{.Shell}} &
+TTYD_PID=$!
+echo $TTYD_PID > /tmp/ttyd.pid
+
+# Wait a moment for ttyd to start and check if it's running
+sleep 3
+if ! kill -0 $TTYD_PID 2>/dev/null; then
+    echo "ERROR: ttyd failed to start"
+    exit 1
+fi
+
+echo "ttyd started successfully on port {{.TtydPort}}"
+
+# Initialize k3s for k8s scenarios
+if [ "$SCENARIO_TYPE" = "k8s" ] || [ "$SCENARIO_TYPE" = "go-k8s" ] || [ "$SCENARIO_TYPE" = "python-k8s" ]; then
+    echo "Initializing k3s for Kubernetes scenario..."
+    /usr/local/bin/start-k3s.sh &
+    echo "k3s initialization started in background"
+fi
+
+# Run the scenario script if provided
+{{.Script}}
+
+# Keep container running
+echo "Container ready for terminal access"
+sleep infinity
+`
+
+var defaultStartupTemplate = template.Must(template.New("startup").Parse(defaultStartupScript))
+
+// LoadStartupTemplate parses the startup script template at path. The
+// template is rendered once with placeholder data so that references to
+// unknown fields fail at load time rather than on the first scenario start.
+func LoadStartupTemplate(path string) (*template.Template, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read startup template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse startup template %s: %w", path, err)
+	}
+	if _, err := renderStartupScript(tmpl, ContainerSpec{ScenarioType: "go"}); err != nil {
+		return nil, fmt.Errorf("invalid startup template %s: %w", path, err)
+	}
+	return tmpl, nil
+}
+
+// renderStartupScript renders tmpl, or the built-in template when nil, for spec
+func renderStartupScript(tmpl *template.Template, spec ContainerSpec) (string, error) {
+	if tmpl == nil {
+		tmpl = defaultStartupTemplate
+	}
+	var script strings.Builder
+	err := tmpl.Execute(&script, StartupScriptData{
+		ScenarioType: spec.ScenarioType,
+		Script:       spec.Script,
+		TtydPort:     ttydContainerPort,
+		Preflight:    ttydPreflight,
+		Shell:        ttydShell,
+	})
+	if err != nil {
+		return "", err
+	}
+	return script.String(), nil
+}
+
+// startupScriptPath is where a container's startup script is written before
+// it runs
+const startupScriptPath = "/tmp/startup.sh"
+
+// startupCommand returns the container command that writes script to
+// startupScriptPath and runs it
+func startupCommand(script string) []string {
+	return []string{"sh", "-c", "cat > " + startupScriptPath + " << 'EOF'\n" + script + "\nEOF\nchmod +x " + startupScriptPath + " && sh " + startupScriptPath}
+}
+
+// isStartupCommand reports whether cmd runs a startup script, as opposed to
+// a command override
+func isStartupCommand(cmd []string) bool {
+	return len(cmd) == 3 && cmd[0] == "sh" && cmd[1] == "-c" && strings.HasPrefix(cmd[2], "cat > "+startupScriptPath+" ")
+}
+
+// interactiveContainerConfig builds the container configuration for a scenario
+// with a web terminal. The startup script rendered from startup (nil for the
+// built-in) runs ttyd and the scenario script unless spec overrides the
+// entrypoint or command.
+func interactiveContainerConfig(image string, spec ContainerSpec, startup *template.Template) (*container.Config, error) {
+	startupScriptContent, err := renderStartupScript(startup, spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render startup script: %w", err)
+	}
+
+	exposedPorts := nat.PortSet{"3000/tcp": struct{}{}}
+
+	// Credentials reach ttyd through the environment so they are never interpolated into the script
+	var env []string
+	if spec.TerminalUsername != "" && spec.TerminalPassword != "" {
+		env = append(env, "TTYD_CREDENTIAL="+spec.TerminalUsername+":"+spec.TerminalPassword)
+	}
+	env = append(env, envList(spec.Env)...)
+
+	containerConfig := &container.Config{
+		Image:        image,
+		Cmd:          startupCommand(startupScriptContent),
+		Env:          env,
+		Tty:          true,
+		ExposedPorts: exposedPorts,
+		Labels:       map[string]string{ManagedLabel: "true"},
+	}
+
+	if len(spec.Entrypoint) > 0 || len(spec.Command) > 0 {
+		containerConfig.Entrypoint = spec.Entrypoint
+		containerConfig.Cmd = spec.Command
+		if spec.StartTTYD {
+			// ttyd runs under sh and then execs the override
+			containerConfig.Entrypoint = append([]string{"sh", "-c", ttydLauncher, "sh"}, spec.Entrypoint...)
+		}
+	}
+
+	return containerConfig, nil
+}
+
+// Ping reports whether the Docker daemon is reachable
+func (c RealClient) Ping(ctx context.Context) error {
+	cli, err := c.newClient()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	if _, err := cli.Ping(ctx); err != nil {
+		return fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	return nil
+}
+
+// HostLoad is how busy the Docker host is
+type HostLoad struct {
+	// RunningContainers counts every running container, not only scenarios
+	RunningContainers int
+	CPUs              int
+	// Load1 is the one-minute load average of the host the API runs on, which
+	// is the Docker host only when the daemon is local; zero when unknown
+	Load1 float64
+}
+
+// LoadPerCPU is the one-minute load average per CPU, or zero when unknown
+func (l HostLoad) LoadPerCPU() float64 {
+	if l.CPUs <= 0 {
+		return 0
+	}
+	return l.Load1 / float64(l.CPUs)
+}
+
+// loadAvgPath is where Linux reports the system load averages
+const loadAvgPath = "/proc/loadavg"
+
+// HostLoad reports the daemon's running containers and CPUs along with the
+// local load average
+func (c RealClient) HostLoad(ctx context.Context) (HostLoad, error) {
+	if ctx == nil {
+		return HostLoad{}, errors.New("nil context provided")
+	}
+
+	cli, err := c.newClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return HostLoad{}, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	info, err := cli.Info(ctx)
+	if err != nil {
+		log.Printf("[docker] failed to get daemon info: %v", err)
+		return HostLoad{}, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+
+	load := HostLoad{RunningContainers: info.ContainersRunning, CPUs: info.NCPU}
+	if content, err := os.ReadFile(loadAvgPath); err == nil {
+		if load.Load1, err = parseLoadAverage(string(content)); err != nil {
+			log.Printf("[docker] %v", err)
+		}
+	}
+	return load, nil
+}
+
+// parseLoadAverage returns the one-minute load average from the contents of
+// /proc/loadavg, e.g. "0.52 0.58 0.59 1/467 12345"
+func parseLoadAverage(content string) (float64, error) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return 0, errors.New("empty load average")
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid load average %q: %w", fields[0], err)
+	}
+	return load, nil
+}
+
+func (c RealClient) GetContainerStatus(ctx context.Context, containerID string) (string, error) {
+	if ctx == nil {
+		return "", errors.New("nil context provided")
+	}
+
+	if containerID == "" {
+		return "", errors.New("container ID cannot be empty")
+	}
+
+	cli, err := c.newClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return "", fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	containerInfo, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		log.Printf("[docker] failed to inspect container %s: %v", containerID, err)
+		return "", inspectError(err)
+	}
+
+	status := containerInfo.State.Status
+	zerologlog.Debug().Msgf("[docker] container %s status: %s", containerID, status)
+	return status, nil
+}
+
+func (c RealClient) GetTerminalURL(ctx context.Context, containerID string) (string, error) {
+	if ctx == nil {
+		return "", errors.New("nil context provided")
+	}
+
+	if containerID == "" {
+		return "", errors.New("container ID cannot be empty")
+	}
+
+	cli, err := c.newClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return "", fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	containerInfo, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		log.Printf("[docker] failed to inspect container %s: %v", containerID, err)
+		return "", inspectError(err)
+	}
+
+	// Check if container is running
+	if containerInfo.State.Status != "running" {
+		return "", fmt.Errorf("%w: container status is %s", ErrContainerNotRunning, containerInfo.State.Status)
+	}
+
+	// Find the host port mapping for container port 3000
+	networkSettings := containerInfo.NetworkSettings
+	if networkSettings == nil || networkSettings.Ports == nil {
+		return "", fmt.Errorf("no port mappings found for container %s", containerID)
+	}
+
+	portBindings, exists := networkSettings.Ports["3000/tcp"]
+	if !exists || len(portBindings) == 0 {
+		return "", fmt.Errorf("port 3000 not mapped for container %s", containerID)
+	}
+
+	hostPort := portBindings[0].HostPort
+	hostIP := portBindings[0].HostIP
+	if hostIP == "" {
+		hostIP = "localhost"
+	}
+
+	terminalURL := "http://" + net.JoinHostPort(hostIP, hostPort)
+	zerologlog.Debug().Msgf("[docker] terminal URL for container %s: %s", containerID, terminalURL)
+	return terminalURL, nil
+}
+
+func (c RealClient) StopContainer(ctx context.Context, containerID string) error {
+	if ctx == nil {
+		return errors.New("nil context provided")
+	}
+
+	if containerID == "" {
+		return errors.New("container ID cannot be empty")
+	}
+
+	cli, err := c.newClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	// Check if container exists and get its status
+	containerInfo, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return fmt.Errorf("%w: container %s", ErrContainerNotFound, containerID)
+		}
+		return fmt.Errorf("failed to check container existence: %w", err)
+	}
+
+	// If container is already stopped or exited, just remove it
+	if containerInfo.State.Status == "exited" || containerInfo.State.Status == "stopped" {
+		if err := cli.ContainerRemove(ctx, containerID, container.RemoveOptions{}); err != nil {
+			log.Printf("[docker] failed to remove stopped container %s: %v", containerID, err)
+			return fmt.Errorf("failed to remove stopped container: %w", err)
+		}
+		log.Printf("[docker] removed stopped container: %s", containerID)
+		return nil
+	}
+
+	// A frozen container cannot handle SIGTERM, so stop would wait out its timeout
+	if containerInfo.State.Paused {
+		if err := cli.ContainerUnpause(ctx, containerID); err != nil {
+			log.Printf("[docker] failed to unpause container %s before stopping: %v", containerID, err)
+		}
+	}
+
+	// Stop the container
+	if err := cli.ContainerStop(ctx, containerID, c.stopOptions()); err != nil {
+		log.Printf("[docker] failed to stop container %s: %v", containerID, err)
+		return fmt.Errorf("failed to stop container: %w", err)
+	}
+
+	// Remove the container
+	if err := cli.ContainerRemove(ctx, containerID, container.RemoveOptions{}); err != nil {
+		log.Printf("[docker] failed to remove container %s: %v", containerID, err)
+		return fmt.Errorf("failed to remove container: %w", err)
+	}
+
+	log.Printf("[docker] stopped and removed container: %s", containerID)
+	return nil
+}
+
+// KillContainer sends SIGKILL to the container's processes without waiting
+// for a graceful shutdown. Unlike StopContainer it leaves the container in
+// place for the caller to remove. Killing a container that is not running
+// succeeds.
+func (c RealClient) KillContainer(ctx context.Context, containerID string) error {
+	if ctx == nil {
+		return errors.New("nil context provided")
+	}
+
+	if containerID == "" {
+		return errors.New("container ID cannot be empty")
+	}
+
+	cli, err := c.newClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	if err := cli.ContainerKill(ctx, containerID, "SIGKILL"); err != nil {
+		if errdefs.IsConflict(err) {
+			// Already exited
+			return nil
+		}
+		log.Printf("[docker] failed to kill container %s: %v", containerID, err)
+		return containerOpError(err, "kill", containerID)
+	}
+
+	log.Printf("[docker] killed container: %s", containerID)
+	return nil
+}
+
+// PauseContainer freezes every process in the container, releasing its CPU
+// while keeping memory and filesystem state
+func (c RealClient) PauseContainer(ctx context.Context, containerID string) error {
+	if ctx == nil {
+		return errors.New("nil context provided")
+	}
+
+	if containerID == "" {
+		return errors.New("container ID cannot be empty")
+	}
+
+	cli, err := c.newClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	if err := cli.ContainerPause(ctx, containerID); err != nil {
+		log.Printf("[docker] failed to pause container %s: %v", containerID, err)
+		return containerOpError(err, "pause", containerID)
+	}
+
+	log.Printf("[docker] paused container: %s", containerID)
+	return nil
+}
+
+// UnpauseContainer resumes a container frozen by PauseContainer
+func (c RealClient) UnpauseContainer(ctx context.Context, containerID string) error {
+	if ctx == nil {
+		return errors.New("nil context provided")
+	}
+
+	if containerID == "" {
+		return errors.New("container ID cannot be empty")
+	}
+
+	cli, err := c.newClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	if err := cli.ContainerUnpause(ctx, containerID); err != nil {
+		log.Printf("[docker] failed to unpause container %s: %v", containerID, err)
+		return containerOpError(err, "unpause", containerID)
+	}
+
+	log.Printf("[docker] unpaused container: %s", containerID)
+	return nil
+}
+
+// containerOpError classifies a failed operation, such as kill, pause or
+// unpause, on an existing container; errors other than a missing daemon or
+// container are wrapped with the name of the operation
+func containerOpError(err error, op, containerID string) error {
+	switch {
+	case client.IsErrConnectionFailed(err):
+		return fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	case client.IsErrNotFound(err):
+		return fmt.Errorf("%w: container %s", ErrContainerNotFound, containerID)
+	}
+	return fmt.Errorf("failed to %s container: %w", op, err)
+}
+
+func (c RealClient) ContainerExists(ctx context.Context, containerID string) (bool, error) {
+	if ctx == nil {
+		return false, errors.New("nil context provided")
+	}
+
+	if containerID == "" {
+		return false, errors.New("container ID cannot be empty")
+	}
+
+	cli, err := c.newClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return false, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	_, err = cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		if client.IsErrConnectionFailed(err) {
+			return false, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+		}
+		return false, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	return true, nil
+}
+
+// ImageExists reports whether image is present in the local image store. It
+// does not consult the registry, so a missing image may still be pullable.
+func (c RealClient) ImageExists(ctx context.Context, image string) (bool, error) {
+	if ctx == nil {
+		return false, errors.New("nil context provided")
+	}
+
+	if image == "" {
+		return false, errors.New("image cannot be empty")
+	}
+
+	cli, err := c.newClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return false, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	if _, _, err := cli.ImageInspectWithRaw(ctx, image); err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		if client.IsErrConnectionFailed(err) {
+			return false, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+		}
+		return false, fmt.Errorf("failed to inspect image: %w", err)
+	}
+
+	return true, nil
+}
+
+// inspectError classifies a failed container inspect. An unreachable daemon is
+// reported as ErrDockerDaemonUnavailable so callers do not mistake an outage
+// for a missing container; every other failure is ErrContainerNotFound.
+func inspectError(err error) error {
+	if client.IsErrConnectionFailed(err) {
+		return fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	return fmt.Errorf("%w: %v", ErrContainerNotFound, err)
+}
+
+// TTYDRestartedMessage is printed by TTYDRestartCommand once the new ttyd is
+// up
+const TTYDRestartedMessage = "ttyd restarted"
+
+// TTYDRestartCommand returns the exec command that restarts ttyd inside a
+// scenario container with new login credentials. The credential is passed as a
+// positional argument so it is never interpreted by the shell. The command
+// prints TTYDRestartedMessage once the new ttyd is running and exits non-zero
+// when it is not, e.g. because the old one still holds the port.
+func TTYDRestartCommand(username, password string) []string {
+	script := `kill "$(cat ` + ttydPIDFile + `)" 2>/dev/null; sleep 1; ` +
+		`nohup ttyd -p 3000 -c "$1" --writable -t disableReuse=true ` + ttydShell + ` >/dev/null 2>&1 & ` +
+		`pid=$!; echo $pid > ` + ttydPIDFile + `; sleep 1; ` +
+		`kill -0 $pid 2>/dev/null || { echo "ERROR: ttyd failed to restart" >&2; exit 1; }; ` +
+		`echo "` + TTYDRestartedMessage + `"`
+	return []string{"sh", "-c", script, "sh", username + ":" + password}
+}
+
+// Default host port range for ttyd
+const (
+	DefaultPortRangeStart = 3001
+	DefaultPortRangeEnd   = 3009
+)
+
+// findAvailablePort finds the lowest available port in the client's range
+func (c RealClient) findAvailablePort() (int, error) {
+	opts := c.Options()
+	for port := opts.PortRangeStart; port <= opts.PortRangeEnd; port++ {
+		if !portInUse(port) {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: no available ports found in range %d-%d", ErrPortUnavailable, opts.PortRangeStart, opts.PortRangeEnd)
+}
+
+// portInUse reports whether something on the host is already listening on port
+func portInUse(port int) bool {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return true
+	}
+	ln.Close()
+	return false
+}
+
+// PortPool hands out ttyd host ports from a fixed range. An allocated port
+// stays reserved until released, so concurrent starts never race for the
+// same port before their containers bind it, and the lowest free port is
+// always handed out first, so released ports are reused deterministically.
+//
+// Ports can also be freed behind the pool's back, e.g. by the cleanup worker
+// removing an expired container. An allocation older than reclaimAfter whose
+// port nothing is listening on any more is therefore evicted and reused.
+type PortPool struct {
+	first, last  int
+	reclaimAfter time.Duration
+	inUse        func(port int) bool
+	now          func() time.Time
+
+	mu        sync.Mutex
+	allocated map[int]time.Time
+}
+
+// NewPortPool returns a pool over the ports first to last inclusive, falling
+// back to the default range when first is unset
+func NewPortPool(first, last int, reclaimAfter time.Duration) *PortPool {
+	if first <= 0 {
+		first, last = DefaultPortRangeStart, DefaultPortRangeEnd
+	}
+	return &PortPool{
+		first:        first,
+		last:         last,
+		reclaimAfter: reclaimAfter,
+		inUse:        portInUse,
+		now:          time.Now,
+		allocated:    make(map[int]time.Time),
+	}
+}
+
+// Allocate reserves and returns the lowest port that is neither allocated nor
+// held by another process on the host. It fails with ErrPortUnavailable only
+// when every port in the range is taken.
+func (p *PortPool) Allocate() (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	for port := p.first; port <= p.last; port++ {
+		if allocatedAt, ok := p.allocated[port]; ok {
+			if now.Sub(allocatedAt) < p.reclaimAfter || p.inUse(port) {
+				continue
+			}
+			log.Printf("[docker] reclaiming port %d, allocated at %s but no longer in use", port, allocatedAt.Format(time.RFC3339))
+		} else if p.inUse(port) {
+			continue
+		}
+		p.allocated[port] = now
+		return port, nil
+	}
+	return 0, fmt.Errorf("%w: all %d ports in range %d-%d are in use", ErrPortUnavailable, p.last-p.first+1, p.first, p.last)
+}
+
+// Release returns port to the pool; releasing a port that is not allocated is a no-op
+func (p *PortPool) Release(port int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.allocated, port)
+}
+
+// ExecuteCommandOpts customises how ExecuteCommand runs inside the container.
+// The zero value runs as the container's default user and working directory.
+type ExecuteCommandOpts struct {
+	// User is the user (name or uid[:gid]) to run the command as
+	User string
+	// WorkingDir is the directory the command starts in
+	WorkingDir string
+	// Env holds extra KEY=value variables set for the command
+	Env []string
+	// MaxOutputBytes caps the output read from the command; zero uses
+	// DefaultMaxExecOutput. Reading stops once the command writes more.
+	MaxOutputBytes int
+}
+
+// DefaultMaxExecOutput is the output cap of commands whose options set none
+const DefaultMaxExecOutput = 1 << 20
+
+func (o ExecuteCommandOpts) maxOutputBytes() int {
+	if o.MaxOutputBytes > 0 {
+		return o.MaxOutputBytes
+	}
+	return DefaultMaxExecOutput
+}
+
+// outputCap limits the bytes written through its writers, in total, to
+// remaining. The write crossing the limit is cut short and fails with
+// ErrOutputTruncated, which stops the copy feeding it.
+type outputCap struct {
+	remaining int
+	truncated bool
+}
+
+func (c *outputCap) writer(buf *bytes.Buffer) io.Writer {
+	return cappedWriter{limit: c, buf: buf}
+}
+
+type cappedWriter struct {
+	limit *outputCap
+	buf   *bytes.Buffer
+}
+
+func (w cappedWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit.remaining {
+		n, _ := w.buf.Write(p[:w.limit.remaining])
+		w.limit.remaining = 0
+		w.limit.truncated = true
+		return n, ErrOutputTruncated
+	}
+	w.limit.remaining -= len(p)
+	return w.buf.Write(p)
+}
+
+// readExecOutput reads r to the end, or until more than maxBytes have been
+// read, in which case the first maxBytes are returned with ErrOutputTruncated
+func readExecOutput(r io.Reader, maxBytes int) (string, error) {
+	var output bytes.Buffer
+	limit := &outputCap{remaining: maxBytes}
+	_, err := io.Copy(limit.writer(&output), r)
+	return output.String(), err
+}
+
+// captureExecOutput demultiplexes r into stdout and stderr until it ends or
+// the two together exceed maxBytes, which sets truncated and stops reading
+// without an error
+func captureExecOutput(r io.Reader, maxBytes int) (stdout, stderr string, truncated bool, err error) {
+	var stdoutBuf, stderrBuf bytes.Buffer
+	limit := &outputCap{remaining: maxBytes}
+	_, err = stdcopy.StdCopy(limit.writer(&stdoutBuf), limit.writer(&stderrBuf), r)
+	if errors.Is(err, ErrOutputTruncated) {
+		err = nil
+	}
+	return stdoutBuf.String(), stderrBuf.String(), limit.truncated, err
+}
+
+// newExecConfig builds the exec configuration for command
+func newExecConfig(command []string, opts ExecuteCommandOpts) types.ExecConfig {
+	return types.ExecConfig{
+		User:         opts.User,
+		WorkingDir:   opts.WorkingDir,
+		Env:          opts.Env,
+		Cmd:          command,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+}
+
+// ExecuteCommand runs command in a running container and returns its output.
+// When the command fails, or ctx ends, after it has started, the output read
+// so far is returned along with the error. Output beyond opts.MaxOutputBytes
+// is not read: the output up to the cap is returned with ErrOutputTruncated.
+func (c RealClient) ExecuteCommand(ctx context.Context, containerID string, command []string, opts ExecuteCommandOpts) (string, error) {
+	if ctx == nil {
+		return "", errors.New("nil context provided")
+	}
+
+	if containerID == "" {
+		return "", errors.New("container ID cannot be empty")
+	}
+
+	if len(command) == 0 {
+		return "", errors.New("command cannot be empty")
+	}
+
+	cli, err := c.newStreamingClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return "", fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	// Check if container exists and is running
+	containerInfo, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		log.Printf("[docker] failed to inspect container %s: %v", containerID, err)
+		return "", inspectError(err)
+	}
+
+	if containerInfo.State.Status != "running" {
+		return "", fmt.Errorf("%w: container status is %s", ErrContainerNotRunning, containerInfo.State.Status)
+	}
+
+	// Create exec configuration
+	execConfig := newExecConfig(command, opts)
+
+	// Create exec instance
+	execResp, err := cli.ContainerExecCreate(ctx, containerID, execConfig)
+	if err != nil {
+		log.Printf("[docker] failed to create exec for container %s: %v", containerID, err)
+		return "", fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	// Attach to exec instance
+	resp, err := cli.ContainerExecAttach(ctx, execResp.ID, types.ExecStartCheck{})
+	if err != nil {
+		log.Printf("[docker] failed to attach to exec for container %s: %v", containerID, err)
+		return "", fmt.Errorf("failed to attach to exec: %w", err)
+	}
+	defer resp.Close()
+
+	// The attached stream does not observe ctx, so close it when ctx ends to
+	// unblock the read below
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			resp.Close()
+		case <-done:
+		}
+	}()
+
+	// Read output; whatever arrived before a failure is still returned
+	output, err := readExecOutput(resp.Reader, opts.maxOutputBytes())
+	if errors.Is(err, ErrOutputTruncated) {
+		log.Printf("[docker] output of exec in container %s exceeded %d bytes, stopped reading", containerID, opts.maxOutputBytes())
+		return output, err
+	}
+	if err != nil {
+		log.Printf("[docker] failed to read exec output for container %s: %v", containerID, err)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return output, fmt.Errorf("failed to read exec output: %w", err)
+	}
+
+	// Check exec exit code
+	inspectResp, err := cli.ContainerExecInspect(ctx, execResp.ID)
+	if err != nil {
+		log.Printf("[docker] failed to inspect exec for container %s: %v", containerID, err)
+		return "", fmt.Errorf("failed to inspect exec: %w", err)
+	}
+
+	if inspectResp.ExitCode != 0 {
+		log.Printf("[docker] exec command failed with exit code %d for container %s", inspectResp.ExitCode, containerID)
+		return output, &ExitError{ExitCode: inspectResp.ExitCode}
+	}
+
+	zerologlog.Debug().Msgf("[docker] executed command successfully in container %s", containerID)
+	return output, nil
+}
+
+// RunCommand runs command in a running container like ExecuteCommand, but
+// keeps stdout and stderr apart and reports a non-zero exit code in the
+// result rather than as an error. When ctx ends first, the output read so far
+// is returned along with the error. Output beyond opts.MaxOutputBytes is not
+// read; the result is then marked truncated and its exit code is -1 if the
+// command had not exited yet.
+func (c RealClient) RunCommand(ctx context.Context, containerID string, command []string, opts ExecuteCommandOpts) (ExitResult, error) {
+	if ctx == nil {
+		return ExitResult{}, errors.New("nil context provided")
+	}
+
+	if containerID == "" {
+		return ExitResult{}, errors.New("container ID cannot be empty")
+	}
+
+	if len(command) == 0 {
+		return ExitResult{}, errors.New("command cannot be empty")
+	}
+
+	cli, err := c.newStreamingClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return ExitResult{}, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	containerInfo, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		log.Printf("[docker] failed to inspect container %s: %v", containerID, err)
+		return ExitResult{}, inspectError(err)
+	}
+	if containerInfo.State.Status != "running" {
+		return ExitResult{}, fmt.Errorf("%w: container status is %s", ErrContainerNotRunning, containerInfo.State.Status)
+	}
+
+	execResp, err := cli.ContainerExecCreate(ctx, containerID, newExecConfig(command, opts))
+	if err != nil {
+		log.Printf("[docker] failed to create exec for container %s: %v", containerID, err)
+		return ExitResult{}, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	resp, err := cli.ContainerExecAttach(ctx, execResp.ID, types.ExecStartCheck{})
+	if err != nil {
+		log.Printf("[docker] failed to attach to exec for container %s: %v", containerID, err)
+		return ExitResult{}, fmt.Errorf("failed to attach to exec: %w", err)
+	}
+	defer resp.Close()
+
+	// As in ExecuteCommand, the attached stream only ends early if closed
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			resp.Close()
+		case <-done:
+		}
+	}()
+
+	stdout, stderr, truncated, err := captureExecOutput(resp.Reader, opts.maxOutputBytes())
+	result := ExitResult{Stdout: stdout, Stderr: stderr, Truncated: truncated}
+	if truncated {
+		log.Printf("[docker] output of exec in container %s exceeded %d bytes, stopped reading", containerID, opts.maxOutputBytes())
+	}
+	if err != nil {
+		log.Printf("[docker] failed to read exec output for container %s: %v", containerID, err)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return result, fmt.Errorf("failed to read exec output: %w", err)
+	}
+
+	inspectResp, err := cli.ContainerExecInspect(ctx, execResp.ID)
+	if err != nil {
+		log.Printf("[docker] failed to inspect exec for container %s: %v", containerID, err)
+		return result, fmt.Errorf("failed to inspect exec: %w", err)
+	}
+	result.ExitCode = inspectResp.ExitCode
+	if inspectResp.Running {
+		result.ExitCode = -1
+	}
+
+	zerologlog.Debug().Msgf("[docker] command in container %s exited with code %d", containerID, result.ExitCode)
+	return result, nil
+}
+
+func (c RealClient) ListContainers(ctx context.Context) ([]ContainerInfo, error) {
+	if ctx == nil {
+		return nil, errors.New("nil context provided")
+	}
+
+	cli, err := c.newClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return nil, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		log.Printf("[docker] failed to list containers: %v", err)
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var containerInfos []ContainerInfo
+	for _, container := range containers {
+		name := container.ID
+		if len(container.Names) > 0 {
+			// The API reports names with a leading slash
+			name = strings.TrimPrefix(container.Names[0], "/")
+		}
+		containerInfos = append(containerInfos, ContainerInfo{
+			ID:          container.ID,
+			Name:        name,
+			Status:      container.Status,
+			TerminalURL: terminalURLFromPorts(container.Ports),
+		})
+	}
+
+	zerologlog.Debug().Msgf("[docker] found %d containers", len(containerInfos))
+	return containerInfos, nil
+}
+
+// terminalURLFromPorts returns the terminal URL of a container listed with
+// ports, preferring an IPv4 binding of the ttyd port, in the form
+// GetTerminalURL returns
+func terminalURLFromPorts(ports []types.Port) string {
+	terminalURL := ""
+	for _, port := range ports {
+		if port.PrivatePort != ttydContainerPort || port.Type != "tcp" || port.PublicPort == 0 {
+			continue
+		}
+		hostIP := port.IP
+		if hostIP == "" {
+			hostIP = "localhost"
+		}
+		url := "http://" + net.JoinHostPort(hostIP, strconv.Itoa(int(port.PublicPort)))
+		if !strings.Contains(hostIP, ":") {
+			return url
+		}
+		if terminalURL == "" {
+			terminalURL = url
+		}
+	}
+	return terminalURL
+}
+
+func (c RealClient) RemoveContainer(ctx context.Context, containerID string) error {
+	if ctx == nil {
+		return errors.New("nil context provided")
+	}
+
+	if containerID == "" {
+		return errors.New("container ID cannot be empty")
+	}
+
+	cli, err := c.newClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	// Check if container exists
+	containerInfo, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		log.Printf("[docker] failed to inspect container %s: %v", containerID, err)
+		return fmt.Errorf("%w: %v", ErrContainerNotFound, err)
+	}
+
+	// Stop the container if it's running
+	if containerInfo.State.Status == "paused" {
+		if err := cli.ContainerUnpause(ctx, containerID); err != nil {
+			log.Printf("[docker] failed to unpause container %s before removal: %v", containerID, err)
+		}
+	}
+	if containerInfo.State.Status == "running" || containerInfo.State.Status == "paused" {
+		log.Printf("[docker] stopping container %s before removal", containerID)
+		if err := cli.ContainerStop(ctx, containerID, c.stopOptions()); err != nil {
+			log.Printf("[docker] failed to stop container %s: %v", containerID, err)
+			return fmt.Errorf("failed to stop container: %w", err)
+		}
+	}
+
+	// Remove the container
+	if err := cli.ContainerRemove(ctx, containerID, container.RemoveOptions{}); err != nil {
+		log.Printf("[docker] failed to remove container %s: %v", containerID, err)
+		return fmt.Errorf("failed to remove container: %w", err)
+	}
+
+	log.Printf("[docker] successfully removed container %s", containerID)
+	return nil
+}
+
+// containerPruner is the subset of the Docker API used by pruneStoppedContainers
+type containerPruner interface {
+	ContainersPrune(ctx context.Context, pruneFilters filters.Args) (types.ContainersPruneReport, error)
+}
+
+// PruneStoppedContainers removes every stopped container matching labelFilter
+// (e.g. ManagedLabelFilter) in a single call
+func (c RealClient) PruneStoppedContainers(ctx context.Context, labelFilter string) (PruneReport, error) {
+	if ctx == nil {
+		return PruneReport{}, errors.New("nil context provided")
+	}
+
+	cli, err := c.newClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return PruneReport{}, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
 	}
+	defer cli.Close()
 
-	if err := cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
-		log.Printf("[docker] failed to start container %s: %v", resp.ID, err)
-		// Try to clean up the created container
-		cli.ContainerRemove(ctx, resp.ID, container.RemoveOptions{})
-		return "", 0, fmt.Errorf("failed to start container: %w", err)
+	return pruneStoppedContainers(ctx, cli, labelFilter)
+}
+
+func pruneStoppedContainers(ctx context.Context, cli containerPruner, labelFilter string) (PruneReport, error) {
+	if labelFilter == "" {
+		// An empty filter would prune every stopped container on the host
+		return PruneReport{}, errors.New("label filter cannot be empty")
 	}
 
-	// Wait a bit and check if container is still running
-	time.Sleep(5 * time.Second)
-	containerInfo, err := cli.ContainerInspect(ctx, resp.ID)
+	report, err := cli.ContainersPrune(ctx, filters.NewArgs(filters.Arg("label", labelFilter)))
 	if err != nil {
-		log.Printf("[docker] failed to inspect container %s: %v", resp.ID, err)
-		return "", 0, fmt.Errorf("failed to verify container status: %w", err)
+		log.Printf("[docker] failed to prune containers with label %s: %v", labelFilter, err)
+		return PruneReport{}, fmt.Errorf("failed to prune containers: %w", err)
 	}
 
-	if containerInfo.State.Status != "running" {
-		log.Printf("[docker] container %s is not running, status: %s", resp.ID, containerInfo.State.Status)
-		// Try to get logs for debugging
-		logs, _ := cli.ContainerLogs(ctx, resp.ID, container.LogsOptions{})
-		if logs != nil {
-			defer logs.Close()
-			log.Printf("[docker] container logs for %s:", resp.ID)
-			// Read and log the container logs
-		}
-		return "", 0, fmt.Errorf("%w: container exited unexpectedly", ErrTTYDFailedToStart)
-	}
+	log.Printf("[docker] pruned %d stopped containers, reclaimed %d bytes", len(report.ContainersDeleted), report.SpaceReclaimed)
+	return PruneReport{
+		ContainersDeleted: report.ContainersDeleted,
+		SpaceReclaimed:    report.SpaceReclaimed,
+	}, nil
+}
 
-	log.Printf("[docker] started container: %s with ttyd on port %d", resp.ID, hostPort)
-	return resp.ID, hostPort, nil
+// containerRemover is the subset of the Docker API used by removeStoppedContainers
+type containerRemover interface {
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
 }
 
-func (RealClient) GetContainerStatus(ctx context.Context, containerID string) (string, error) {
+// RemoveStoppedContainers removes exactly the given stopped containers over a
+// single client connection. Unlike PruneStoppedContainers it never touches a
+// container that was not named, so callers can batch-remove the orphans they
+// found without reaching containers that still belong to a scenario. The
+// report lists the containers removed; an error is returned for the rest.
+func (c RealClient) RemoveStoppedContainers(ctx context.Context, containerIDs []string) (PruneReport, error) {
 	if ctx == nil {
-		return "", errors.New("nil context provided")
-	}
-
-	if containerID == "" {
-		return "", errors.New("container ID cannot be empty")
+		return PruneReport{}, errors.New("nil context provided")
 	}
 
-	cli, err := client.NewClientWithOpts(client.FromEnv)
+	cli, err := c.newClient()
 	if err != nil {
 		log.Printf("[docker] failed to create client: %v", err)
-		return "", fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+		return PruneReport{}, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
 	}
 	defer cli.Close()
 
-	containerInfo, err := cli.ContainerInspect(ctx, containerID)
-	if err != nil {
-		log.Printf("[docker] failed to inspect container %s: %v", containerID, err)
-		return "", fmt.Errorf("%w: %v", ErrContainerNotFound, err)
+	return removeStoppedContainers(ctx, cli, containerIDs)
+}
+
+func removeStoppedContainers(ctx context.Context, cli containerRemover, containerIDs []string) (PruneReport, error) {
+	var report PruneReport
+	var errs []error
+	for _, containerID := range containerIDs {
+		if err := cli.ContainerRemove(ctx, containerID, container.RemoveOptions{}); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", containerID, err))
+			continue
+		}
+		report.ContainersDeleted = append(report.ContainersDeleted, containerID)
 	}
 
-	status := containerInfo.State.Status
-	log.Printf("[docker] container %s status: %s", containerID, status)
-	return status, nil
+	log.Printf("[docker] removed %d of %d stopped containers", len(report.ContainersDeleted), len(containerIDs))
+	if len(errs) > 0 {
+		return report, fmt.Errorf("failed to remove containers: %w", errors.Join(errs...))
+	}
+	return report, nil
 }
 
-func (RealClient) GetTerminalURL(ctx context.Context, containerID string) (string, error) {
+// ListImages returns local images matching labelFilter (e.g. SnapshotLabelFilter)
+func (c RealClient) ListImages(ctx context.Context, labelFilter string) ([]ImageInfo, error) {
 	if ctx == nil {
-		return "", errors.New("nil context provided")
+		return nil, errors.New("nil context provided")
 	}
 
-	if containerID == "" {
-		return "", errors.New("container ID cannot be empty")
+	if labelFilter == "" {
+		return nil, errors.New("label filter cannot be empty")
 	}
 
-	cli, err := client.NewClientWithOpts(client.FromEnv)
+	cli, err := c.newClient()
 	if err != nil {
 		log.Printf("[docker] failed to create client: %v", err)
-		return "", fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+		return nil, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
 	}
 	defer cli.Close()
 
-	containerInfo, err := cli.ContainerInspect(ctx, containerID)
+	images, err := cli.ImageList(ctx, types.ImageListOptions{Filters: filters.NewArgs(filters.Arg("label", labelFilter))})
 	if err != nil {
-		log.Printf("[docker] failed to inspect container %s: %v", containerID, err)
-		return "", fmt.Errorf("%w: %v", ErrContainerNotFound, err)
+		log.Printf("[docker] failed to list images with label %s: %v", labelFilter, err)
+		return nil, fmt.Errorf("failed to list images: %w", err)
 	}
 
-	// Check if container is running
-	if containerInfo.State.Status != "running" {
-		return "", fmt.Errorf("%w: container status is %s", ErrContainerNotRunning, containerInfo.State.Status)
+	var imageInfos []ImageInfo
+	for _, image := range images {
+		imageInfos = append(imageInfos, ImageInfo{
+			ID:        image.ID,
+			Tags:      image.RepoTags,
+			Labels:    image.Labels,
+			CreatedAt: time.Unix(image.Created, 0),
+		})
 	}
 
-	// Find the host port mapping for container port 3000
-	networkSettings := containerInfo.NetworkSettings
-	if networkSettings == nil || networkSettings.Ports == nil {
-		return "", fmt.Errorf("no port mappings found for container %s", containerID)
+	zerologlog.Debug().Msgf("[docker] found %d images with label %s", len(imageInfos), labelFilter)
+	return imageInfos, nil
+}
+
+// RemoveImage deletes a local image. Images still used by a container are
+// left in place and reported as an error.
+func (c RealClient) RemoveImage(ctx context.Context, imageID string) error {
+	if ctx == nil {
+		return errors.New("nil context provided")
 	}
 
-	portBindings, exists := networkSettings.Ports["3000/tcp"]
-	if !exists || len(portBindings) == 0 {
-		return "", fmt.Errorf("port 3000 not mapped for container %s", containerID)
+	if imageID == "" {
+		return errors.New("image ID cannot be empty")
 	}
 
-	hostPort := portBindings[0].HostPort
-	hostIP := portBindings[0].HostIP
-	if hostIP == "" {
-		hostIP = "localhost"
+	cli, err := c.newClient()
+	if err != nil {
+		log.Printf("[docker] failed to create client: %v", err)
+		return fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+	}
+	defer cli.Close()
+
+	if _, err := cli.ImageRemove(ctx, imageID, types.ImageRemoveOptions{PruneChildren: true}); err != nil {
+		log.Printf("[docker] failed to remove image %s: %v", imageID, err)
+		return fmt.Errorf("failed to remove image: %w", err)
 	}
 
-	terminalURL := fmt.Sprintf("http://%s:%s", hostIP, hostPort)
-	log.Printf("[docker] terminal URL for container %s: %s", containerID, terminalURL)
-	return terminalURL, nil
+	log.Printf("[docker] removed image %s", imageID)
+	return nil
 }
 
-func (RealClient) StopContainer(ctx context.Context, containerID string) error {
+// RebindSpec identifies the scenario whose terminal is moved to a new host
+// port and the credentials its restarted ttyd must keep using
+type RebindSpec struct {
+	ScenarioID string
+	// ScenarioType selects what the replacement's startup script initialises
+	ScenarioType     string
+	TerminalUsername string
+	TerminalPassword string
+	// HostPort is the new host port for ttyd; zero picks a free one
+	HostPort int
+}
+
+// RebindTerminalPort moves a running scenario container's ttyd to a newly
+// allocated host port. Docker cannot change the port bindings of an existing
+// container, so the container is committed to a snapshot image and recreated
+// from it with the new binding and the original's name; the workspace survives
+// but running processes do not. A commit does not capture tmpfs mounts, so a
+// container whose workspace is on one, e.g. under a read-only root filesystem
+// or a tmpfs disk quota, is refused with ErrWorkspaceNotPersistent. The
+// replacement's startup script starts ttyd and k3s again but not the scenario
+// script, whose setup the snapshot already holds. Any failure before the
+// replacement is running restarts the original. It returns the replacement
+// container's ID and host port.
+func (c RealClient) RebindTerminalPort(ctx context.Context, containerID string, spec RebindSpec) (string, int, error) {
 	if ctx == nil {
-		return errors.New("nil context provided")
+		return "", 0, errors.New("nil context provided")
 	}
 
 	if containerID == "" {
-		return errors.New("container ID cannot be empty")
+		return "", 0, errors.New("container ID cannot be empty")
 	}
 
-	cli, err := client.NewClientWithOpts(client.FromEnv)
+	cli, err := c.newClient()
 	if err != nil {
 		log.Printf("[docker] failed to create client: %v", err)
-		return fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+		return "", 0, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
 	}
 	defer cli.Close()
 
-	// Check if container exists and get its status
 	containerInfo, err := cli.ContainerInspect(ctx, containerID)
 	if err != nil {
-		if client.IsErrNotFound(err) {
-			return fmt.Errorf("%w: container %s", ErrContainerNotFound, containerID)
+		log.Printf("[docker] failed to inspect container %s: %v", containerID, err)
+		return "", 0, inspectError(err)
+	}
+	if containerInfo.State.Status != "running" {
+		return "", 0, fmt.Errorf("%w: container status is %s", ErrContainerNotRunning, containerInfo.State.Status)
+	}
+	if workspaceOnTmpfs(containerInfo.HostConfig) {
+		return "", 0, fmt.Errorf("%w: %s is a tmpfs mount, which a snapshot does not keep", ErrWorkspaceNotPersistent, ScenarioHomeDir)
+	}
+
+	// Without its scenario script, so the replacement does not repeat its setup
+	var startup []string
+	if isStartupCommand(containerInfo.Config.Cmd) {
+		script, err := renderStartupScript(c.opts.StartupTemplate, ContainerSpec{ScenarioType: spec.ScenarioType})
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to render startup script: %w", err)
 		}
-		return fmt.Errorf("failed to check container existence: %w", err)
+		startup = startupCommand(script)
 	}
 
-	// If container is already stopped or exited, just remove it
-	if containerInfo.State.Status == "exited" || containerInfo.State.Status == "stopped" {
-		if err := cli.ContainerRemove(ctx, containerID, container.RemoveOptions{}); err != nil {
-			log.Printf("[docker] failed to remove stopped container %s: %v", containerID, err)
-			return fmt.Errorf("failed to remove stopped container: %w", err)
+	hostPort := spec.HostPort
+	if hostPort == 0 {
+		hostPort, err = c.findAvailablePort()
+		if err != nil {
+			log.Printf("[docker] failed to find available port: %v", err)
+			return "", 0, fmt.Errorf("%w: %v", ErrPortUnavailable, err)
 		}
-		log.Printf("[docker] removed stopped container: %s", containerID)
-		return nil
 	}
 
-	// Stop the container
-	if err := cli.ContainerStop(ctx, containerID, container.StopOptions{}); err != nil {
+	if err := cli.ContainerStop(ctx, containerID, c.stopOptions()); err != nil {
 		log.Printf("[docker] failed to stop container %s: %v", containerID, err)
-		return fmt.Errorf("failed to stop container: %w", err)
+		return "", 0, fmt.Errorf("failed to stop container: %w", err)
 	}
-
-	// Remove the container
-	if err := cli.ContainerRemove(ctx, containerID, container.RemoveOptions{}); err != nil {
-		log.Printf("[docker] failed to remove container %s: %v", containerID, err)
-		return fmt.Errorf("failed to remove container: %w", err)
+	// The replacement takes over the original's name, so the original is
+	// moved aside until the replacement is running
+	name := strings.TrimPrefix(containerInfo.Name, "/")
+	renamed := false
+	if name != "" {
+		if err := cli.ContainerRename(ctx, containerID, name+"-rebinding"); err != nil {
+			log.Printf("[docker] failed to rename container %s, replacement gets a generated name: %v", containerID, err)
+			name = ""
+		} else {
+			renamed = true
+		}
 	}
-
-	log.Printf("[docker] stopped and removed container: %s", containerID)
-	return nil
-}
-
-func (RealClient) ContainerExists(ctx context.Context, containerID string) (bool, error) {
-	if ctx == nil {
-		return false, errors.New("nil context provided")
+	// Bring the original back if it cannot be replaced, so the scenario keeps its terminal
+	restore := func() {
+		ctx := context.WithoutCancel(ctx)
+		if renamed {
+			if err := cli.ContainerRename(ctx, containerID, name); err != nil {
+				log.Printf("[docker] failed to restore name of container %s after failed rebind: %v", containerID, err)
+			}
+		}
+		if err := cli.ContainerStart(ctx, containerID, container.StartOptions{}); err != nil {
+			log.Printf("[docker] failed to restart container %s after failed rebind: %v", containerID, err)
+		}
 	}
 
-	if containerID == "" {
-		return false, errors.New("container ID cannot be empty")
+	snapshot, err := cli.ContainerCommit(ctx, containerID, container.CommitOptions{
+		Changes: []string{
+			"LABEL " + SnapshotLabel + "=true",
+			"LABEL " + ScenarioIDLabel + "=" + spec.ScenarioID,
+		},
+	})
+	if err != nil {
+		log.Printf("[docker] failed to commit container %s: %v", containerID, err)
+		restore()
+		return "", 0, fmt.Errorf("failed to snapshot container: %w", err)
 	}
 
-	cli, err := client.NewClientWithOpts(client.FromEnv)
+	hostConfig := containerInfo.HostConfig
+	hostConfig.PortBindings = nat.PortMap{
+		"3000/tcp": []nat.PortBinding{{
+			HostIP:   "0.0.0.0",
+			HostPort: fmt.Sprintf("%d", hostPort),
+		}},
+	}
+	resp, err := cli.ContainerCreate(ctx, reboundContainerConfig(containerInfo.Config, snapshot.ID, spec, startup), hostConfig, nil, nil, name)
 	if err != nil {
-		log.Printf("[docker] failed to create client: %v", err)
-		return false, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+		log.Printf("[docker] failed to recreate container %s: %v", containerID, err)
+		restore()
+		return "", 0, fmt.Errorf("failed to create container: %w", err)
 	}
-	defer cli.Close()
 
-	_, err = cli.ContainerInspect(ctx, containerID)
-	if err != nil {
-		if client.IsErrNotFound(err) {
-			return false, nil
-		}
-		return false, fmt.Errorf("failed to inspect container: %w", err)
+	if err := cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		log.Printf("[docker] failed to start replacement container %s: %v", resp.ID, err)
+		cli.ContainerRemove(context.WithoutCancel(ctx), resp.ID, container.RemoveOptions{Force: true})
+		restore()
+		return "", 0, fmt.Errorf("failed to start container: %w", err)
 	}
 
-	return true, nil
+	if err := cli.ContainerRemove(ctx, containerID, container.RemoveOptions{Force: true}); err != nil {
+		log.Printf("[docker] failed to remove replaced container %s: %v", containerID, err)
+	}
+
+	log.Printf("[docker] rebound container %s as %s with ttyd on port %d", containerID, resp.ID, hostPort)
+	return resp.ID, hostPort, nil
 }
 
-// findAvailablePort finds an available port starting from 3001
-func findAvailablePort() (int, error) {
-	for port := 3001; port < 3010; port++ {
-		addr := fmt.Sprintf(":%d", port)
-		ln, err := net.Listen("tcp", addr)
-		if err == nil {
-			ln.Close()
-			return port, nil
-		}
+// workspaceOnTmpfs reports whether a container keeps its workspace on a
+// tmpfs mount
+func workspaceOnTmpfs(hostConfig *container.HostConfig) bool {
+	if hostConfig == nil {
+		return false
 	}
-	return 0, fmt.Errorf("%w: no available ports found in range 3001-3009", ErrPortUnavailable)
+	_, ok := hostConfig.Tmpfs[ScenarioHomeDir]
+	return ok
 }
 
-func (RealClient) ExecuteCommand(ctx context.Context, containerID string, command []string) (string, error) {
-	if ctx == nil {
-		return "", errors.New("nil context provided")
+// reboundContainerConfig adapts a container's configuration for its
+// replacement started from image. The snapshot label belongs to the image
+// only, and ttyd is given the scenario's current credentials, which may have
+// been rotated since the container was created. A non-nil startup replaces
+// the container's startup command.
+func reboundContainerConfig(config *container.Config, image string, spec RebindSpec, startup []string) *container.Config {
+	rebound := *config
+	rebound.Image = image
+	if startup != nil {
+		rebound.Cmd = startup
 	}
 
-	if containerID == "" {
-		return "", errors.New("container ID cannot be empty")
+	rebound.Labels = make(map[string]string, len(config.Labels))
+	for key, value := range config.Labels {
+		if key != SnapshotLabel {
+			rebound.Labels[key] = value
+		}
 	}
 
-	if len(command) == 0 {
-		return "", errors.New("command cannot be empty")
+	rebound.Env = nil
+	for _, env := range config.Env {
+		if !strings.HasPrefix(env, "TTYD_CREDENTIAL=") {
+			rebound.Env = append(rebound.Env, env)
+		}
+	}
+	if spec.TerminalUsername != "" && spec.TerminalPassword != "" {
+		rebound.Env = append(rebound.Env, "TTYD_CREDENTIAL="+spec.TerminalUsername+":"+spec.TerminalPassword)
 	}
+	return &rebound
+}
 
-	cli, err := client.NewClientWithOpts(client.FromEnv)
-	if err != nil {
-		log.Printf("[docker] failed to create client: %v", err)
-		return "", fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+// batchContainerConfig builds the container configuration for a batch
+// scenario, which runs its script once with no terminal
+func batchContainerConfig(image string, spec ContainerSpec) *container.Config {
+	return &container.Config{
+		Image:  image,
+		Cmd:    []string{"sh", "-c", "cat > /tmp/scenario.sh << 'EOF'\n" + spec.Script + "\nEOF\nsh /tmp/scenario.sh"},
+		Env:    append([]string{"SCENARIO_TYPE=" + spec.ScenarioType}, envList(spec.Env)...),
+		Labels: map[string]string{ManagedLabel: "true"},
 	}
-	defer cli.Close()
+}
 
-	// Check if container exists and is running
-	containerInfo, err := cli.ContainerInspect(ctx, containerID)
+// startBatchContainer runs the scenario script as the container's only process.
+// The container has no terminal so stdout and stderr can be told apart, and it
+// exits with the script's exit code.
+func (c RealClient) startBatchContainer(ctx context.Context, cli *client.Client, image string, spec ContainerSpec, mounts []mount.Mount) (string, int, error) {
+	containerConfig := batchContainerConfig(image, spec)
+	c.applyContainerUser(containerConfig, spec.ScenarioType)
+	c.applyLabels(containerConfig, spec)
+	hostConfig := &container.HostConfig{Mounts: mounts}
+	applyDiskQuota(hostConfig, c.opts.DiskQuota, c.opts.DiskQuotaMode)
+	c.applyResourceLimits(hostConfig, spec)
+	c.applyIOWeight(hostConfig)
+	c.applyReadonlyRootfs(hostConfig, spec.ScenarioType)
+	c.applyNetworkConfig(hostConfig)
+	c.applySecurityOptions(hostConfig)
+
+	resp, err := createContainer(ctx, cli, containerConfig, hostConfig, spec.Name)
 	if err != nil {
-		log.Printf("[docker] failed to inspect container %s: %v", containerID, err)
-		return "", fmt.Errorf("%w: %v", ErrContainerNotFound, err)
+		log.Printf("[docker] failed to create batch container: %v", err)
+		return "", 0, fmt.Errorf("failed to create container: %w", err)
 	}
 
-	if containerInfo.State.Status != "running" {
-		return "", fmt.Errorf("%w: container status is %s", ErrContainerNotRunning, containerInfo.State.Status)
+	if err := cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		log.Printf("[docker] failed to start batch container %s: %v", resp.ID, err)
+		cli.ContainerRemove(context.WithoutCancel(ctx), resp.ID, container.RemoveOptions{Force: true})
+		return "", 0, startError(err, containerConfig.User)
 	}
 
-	// Create exec configuration
-	execConfig := types.ExecConfig{
-		Cmd:          command,
-		AttachStdout: true,
-		AttachStderr: true,
+	log.Printf("[docker] started batch container: %s", resp.ID)
+	return resp.ID, 0, nil
+}
+
+// WaitForExit blocks until the container stops and returns its exit code and
+// captured output. The container is left in place for the caller to remove.
+func (c RealClient) WaitForExit(ctx context.Context, containerID string) (ExitResult, error) {
+	if ctx == nil {
+		return ExitResult{}, errors.New("nil context provided")
 	}
 
-	// Create exec instance
-	execResp, err := cli.ContainerExecCreate(ctx, containerID, execConfig)
-	if err != nil {
-		log.Printf("[docker] failed to create exec for container %s: %v", containerID, err)
-		return "", fmt.Errorf("failed to create exec: %w", err)
+	if containerID == "" {
+		return ExitResult{}, errors.New("container ID cannot be empty")
 	}
 
-	// Attach to exec instance
-	resp, err := cli.ContainerExecAttach(ctx, execResp.ID, types.ExecStartCheck{})
+	cli, err := c.newStreamingClient()
 	if err != nil {
-		log.Printf("[docker] failed to attach to exec for container %s: %v", containerID, err)
-		return "", fmt.Errorf("failed to attach to exec: %w", err)
+		log.Printf("[docker] failed to create client: %v", err)
+		return ExitResult{}, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
 	}
-	defer resp.Close()
+	defer cli.Close()
 
-	// Read output
-	output, err := ioutil.ReadAll(resp.Reader)
-	if err != nil {
-		log.Printf("[docker] failed to read exec output for container %s: %v", containerID, err)
-		return "", fmt.Errorf("failed to read exec output: %w", err)
+	var result ExitResult
+	statusCh, errCh := cli.ContainerWait(ctx, containerID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		if client.IsErrNotFound(err) {
+			return ExitResult{}, fmt.Errorf("%w: container %s", ErrContainerNotFound, containerID)
+		}
+		return ExitResult{}, fmt.Errorf("failed to wait for container: %w", err)
+	case status := <-statusCh:
+		result.ExitCode = int(status.StatusCode)
 	}
 
-	// Check exec exit code
-	inspectResp, err := cli.ContainerExecInspect(ctx, execResp.ID)
+	logs, err := cli.ContainerLogs(ctx, containerID, container.LogsOptions{ShowStdout: true, ShowStderr: true})
 	if err != nil {
-		log.Printf("[docker] failed to inspect exec for container %s: %v", containerID, err)
-		return "", fmt.Errorf("failed to inspect exec: %w", err)
+		log.Printf("[docker] failed to read logs for container %s: %v", containerID, err)
+		return result, fmt.Errorf("failed to read container logs: %w", err)
 	}
+	defer logs.Close()
 
-	if inspectResp.ExitCode != 0 {
-		log.Printf("[docker] exec command failed with exit code %d for container %s", inspectResp.ExitCode, containerID)
-		return string(output), fmt.Errorf("command failed with exit code %d", inspectResp.ExitCode)
+	result.Stdout, result.Stderr, err = readBatchLogs(logs)
+	if err != nil {
+		return result, fmt.Errorf("failed to read container logs: %w", err)
 	}
 
-	log.Printf("[docker] executed command successfully in container %s", containerID)
-	return string(output), nil
+	zerologlog.Debug().Msgf("[docker] container %s exited with code %d", containerID, result.ExitCode)
+	return result, nil
 }
 
-func (RealClient) ListContainers(ctx context.Context) ([]ContainerInfo, error) {
+// FollowLogs opens a live stream of the container's output, starting with its
+// last tail lines, or all of them when tail is negative. The stream ends
+// when the container exits or ctx is cancelled; the caller must close it.
+func (c RealClient) FollowLogs(ctx context.Context, containerID string, tail int) (*LogStream, error) {
 	if ctx == nil {
 		return nil, errors.New("nil context provided")
 	}
 
-	cli, err := client.NewClientWithOpts(client.FromEnv)
+	if containerID == "" {
+		return nil, errors.New("container ID cannot be empty")
+	}
+
+	cli, err := c.newStreamingClient()
 	if err != nil {
 		log.Printf("[docker] failed to create client: %v", err)
 		return nil, fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
 	}
-	defer cli.Close()
 
-	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+	// Interactive containers have a TTY, so their logs are not multiplexed
+	containerInfo, err := cli.ContainerInspect(ctx, containerID)
 	if err != nil {
-		log.Printf("[docker] failed to list containers: %v", err)
-		return nil, fmt.Errorf("failed to list containers: %w", err)
+		cli.Close()
+		log.Printf("[docker] failed to inspect container %s: %v", containerID, err)
+		return nil, inspectError(err)
 	}
 
-	var containerInfos []ContainerInfo
-	for _, container := range containers {
-		name := container.ID
-		if len(container.Names) > 0 {
-			name = container.Names[0]
-		}
-		containerInfos = append(containerInfos, ContainerInfo{
-			ID:     container.ID,
-			Name:   name,
-			Status: container.Status,
-		})
+	opts := container.LogsOptions{ShowStdout: true, ShowStderr: true, Follow: true}
+	if tail >= 0 {
+		opts.Tail = strconv.Itoa(tail)
+	}
+	logs, err := cli.ContainerLogs(ctx, containerID, opts)
+	if err != nil {
+		cli.Close()
+		log.Printf("[docker] failed to follow logs for container %s: %v", containerID, err)
+		return nil, fmt.Errorf("failed to follow container logs: %w", err)
 	}
 
-	log.Printf("[docker] found %d containers", len(containerInfos))
-	return containerInfos, nil
+	stream := NewLogStream(logs, containerInfo.Config != nil && containerInfo.Config.Tty)
+	stream.closeClient = cli.Close
+	return stream, nil
 }
 
-func (RealClient) RemoveContainer(ctx context.Context, containerID string) error {
-	if ctx == nil {
-		return errors.New("nil context provided")
-	}
+// Streams a LogLine can come from
+const (
+	LogStreamStdout = "stdout"
+	LogStreamStderr = "stderr"
+)
 
-	if containerID == "" {
-		return errors.New("container ID cannot be empty")
-	}
+// maxLogLineBytes caps a single streamed log line; longer lines are split
+const maxLogLineBytes = 16 * 1024
+
+// LogLine is one line of container output, without its trailing newline
+type LogLine struct {
+	Stream string
+	Text   string
+}
 
-	cli, err := client.NewClientWithOpts(client.FromEnv)
+// LogStream reads container output line by line. Output of a container
+// without a TTY is demultiplexed into its stdout and stderr lines.
+type LogStream struct {
+	r           io.ReadCloser
+	tty         bool
+	closeClient func() error
+	closeOnce   sync.Once
+}
+
+// NewLogStream returns a LogStream over raw log output, which is multiplexed
+// unless tty is set
+func NewLogStream(r io.ReadCloser, tty bool) *LogStream {
+	return &LogStream{r: r, tty: tty}
+}
+
+// Each calls onLine for every line until the output ends, returning nil then.
+// Lines are read only as fast as onLine returns, so a slow consumer slows the
+// read rather than buffering output. An error from onLine stops the stream
+// and is returned.
+func (s *LogStream) Each(onLine func(LogLine) error) error {
+	stdout := &logLineWriter{stream: LogStreamStdout, onLine: onLine}
+	stderr := &logLineWriter{stream: LogStreamStderr, onLine: onLine}
+
+	var err error
+	if s.tty {
+		_, err = io.Copy(stdout, s.r)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, s.r)
+	}
 	if err != nil {
-		log.Printf("[docker] failed to create client: %v", err)
-		return fmt.Errorf("%w: %v", ErrDockerDaemonUnavailable, err)
+		return err
 	}
-	defer cli.Close()
 
-	// Check if container exists
-	containerInfo, err := cli.ContainerInspect(ctx, containerID)
-	if err != nil {
-		log.Printf("[docker] failed to inspect container %s: %v", containerID, err)
-		return fmt.Errorf("%w: %v", ErrContainerNotFound, err)
+	// Output ending without a newline still holds a final line
+	if err := stdout.flush(); err != nil {
+		return err
 	}
+	return stderr.flush()
+}
 
-	// Stop the container if it's running
-	if containerInfo.State.Status == "running" {
-		log.Printf("[docker] stopping container %s before removal", containerID)
-		if err := cli.ContainerStop(ctx, containerID, container.StopOptions{}); err != nil {
-			log.Printf("[docker] failed to stop container %s: %v", containerID, err)
-			return fmt.Errorf("failed to stop container: %w", err)
+// Close stops the stream; it is safe to call more than once and concurrently
+// with Each, which then returns
+func (s *LogStream) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		err = s.r.Close()
+		if s.closeClient != nil {
+			s.closeClient()
+		}
+	})
+	return err
+}
+
+// logLineWriter splits the output written to it into lines of one stream
+type logLineWriter struct {
+	stream string
+	onLine func(LogLine) error
+	buf    []byte
+}
+
+func (w *logLineWriter) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.buf = append(w.buf, p...)
+			p = nil
+		} else {
+			w.buf = append(w.buf, p[:i]...)
+			p = p[i+1:]
+		}
+		for len(w.buf) > maxLogLineBytes {
+			if err := w.emit(w.buf[:maxLogLineBytes]); err != nil {
+				return 0, err
+			}
+			w.buf = w.buf[maxLogLineBytes:]
+		}
+		if i >= 0 {
+			if err := w.emit(w.buf); err != nil {
+				return 0, err
+			}
+			w.buf = w.buf[:0]
 		}
 	}
+	return written, nil
+}
 
-	// Remove the container
-	if err := cli.ContainerRemove(ctx, containerID, container.RemoveOptions{}); err != nil {
-		log.Printf("[docker] failed to remove container %s: %v", containerID, err)
-		return fmt.Errorf("failed to remove container: %w", err)
+// flush emits any buffered partial line
+func (w *logLineWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.emit(w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+func (w *logLineWriter) emit(line []byte) error {
+	return w.onLine(LogLine{Stream: w.stream, Text: strings.TrimSuffix(string(line), "\r")})
+}
+
+// maxBatchLogBytes caps the raw logs read from a finished batch container:
+// room for both streams at maxBatchOutput plus their frame headers
+const maxBatchLogBytes = 3 * maxBatchOutput
+
+// readBatchLogs reads up to maxBatchLogBytes of a batch container's
+// multiplexed logs and splits them into its output streams, each cut to
+// maxBatchOutput, so a chatty container cannot exhaust memory
+func readBatchLogs(r io.Reader) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, io.LimitReader(r, maxBatchLogBytes)); err != nil {
+		return "", "", err
 	}
+	return truncateOutput(stdout.String()), truncateOutput(stderr.String()), nil
+}
 
-	log.Printf("[docker] successfully removed container %s", containerID)
-	return nil
+// truncateOutput keeps the first maxBatchOutput bytes of a captured stream
+func truncateOutput(output string) string {
+	if len(output) <= maxBatchOutput {
+		return output
+	}
+	return output[:maxBatchOutput]
 }
@@ -1,14 +1,37 @@
 package docker
 
 import (
+	"bytes"
 	"context"
+	"devlab/internal/config"
+	"devlab/internal/retry"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/rs/zerolog"
+	zerologlog "github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Mock Docker client for testing
@@ -31,6 +54,21 @@ func (m *MockDockerClient) ContainerInspect(ctx context.Context, container strin
 	return args.Get(0).(types.ContainerJSON), args.Error(1)
 }
 
+func (m *MockDockerClient) ContainersPrune(ctx context.Context, pruneFilters filters.Args) (types.ContainersPruneReport, error) {
+	args := m.Called(ctx, pruneFilters)
+	return args.Get(0).(types.ContainersPruneReport), args.Error(1)
+}
+
+func (m *MockDockerClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	args := m.Called(ctx, containerID, options)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) ImagePull(ctx context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error) {
+	args := m.Called(ctx, ref, options)
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
 func (m *MockDockerClient) Close() error {
 	args := m.Called()
 	return args.Error(0)
@@ -113,7 +151,7 @@ func TestStartScenarioContainer(t *testing.T) {
 			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
 			defer cancel()
 
-			containerID, _, err := client.StartScenarioContainer(ctx, tt.scenarioType, tt.script)
+			containerID, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: tt.scenarioType, Script: tt.script})
 
 			// We expect an error because Docker daemon is not available in test environment
 			// But we can verify the function doesn't panic and handles the scenario type correctly
@@ -147,11 +185,11 @@ func TestStartScenarioContainer_ImageSelection(t *testing.T) {
 			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
 			defer cancel()
 
-			_, _, err := client.StartScenarioContainer(ctx, tc.scenarioType, "echo test")
+			_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: tc.scenarioType, Script: "echo test"})
 
 			// Function should not panic, even if Docker is not available
 			assert.NotPanics(t, func() {
-				client.StartScenarioContainer(ctx, tc.scenarioType, "echo test")
+				client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: tc.scenarioType, Script: "echo test"})
 			})
 
 			// Error is expected if Docker daemon is not available
@@ -163,6 +201,662 @@ func TestStartScenarioContainer_ImageSelection(t *testing.T) {
 	}
 }
 
+func TestImageForScenarioType(t *testing.T) {
+	testCases := []struct {
+		name          string
+		scenarioType  string
+		defaultImage  string
+		expectedImage string
+	}{
+		{"known_type", "python", "minimal:latest", "devlab-python:latest"},
+		{"unknown_type_configured_default", "java", "minimal:latest", "minimal:latest"},
+		{"unknown_type_no_default", "java", "", DefaultScenarioImage},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedImage, ImageForScenarioType(tc.scenarioType, tc.defaultImage))
+		})
+	}
+}
+
+func TestContainerImage(t *testing.T) {
+	const pinned = "devlab-go@sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945"
+
+	assert.Equal(t, pinned, containerImage(ContainerSpec{ScenarioType: "go", Image: pinned}, ""),
+		"a pinned image is what the container is created from")
+	assert.Equal(t, "devlab-go:latest", containerImage(ContainerSpec{ScenarioType: "go"}, ""))
+	assert.Equal(t, "minimal:latest", containerImage(ContainerSpec{ScenarioType: "java"}, "minimal:latest"))
+}
+
+func TestImageDigestRefs(t *testing.T) {
+	for ref, expected := range map[string]bool{
+		"devlab-go@sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945": true,
+		"sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945":           true,
+		"devlab-go:latest": false,
+		"devlab-go":        false,
+	} {
+		assert.Equal(t, expected, IsDigestRef(ref), ref)
+	}
+
+	for ref, expected := range map[string]bool{
+		"devlab-go:latest":                  true,
+		"devlab-go":                         true,
+		"registry.example.com:5000/go":      true,
+		"registry.example.com:5000/go:1.21": false,
+		"golang:1.21":                       false,
+		"devlab-go@sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945": false,
+	} {
+		assert.Equal(t, expected, isLatestRef(ref), ref)
+	}
+
+	assert.Equal(t, "devlab-go@sha256:abc", digestRef(types.ImageInspect{ID: "sha256:def", RepoDigests: []string{"devlab-go@sha256:abc"}}))
+	// Locally built images have no repository digest
+	assert.Equal(t, "sha256:def", digestRef(types.ImageInspect{ID: "sha256:def"}))
+}
+
+func TestBaseImages(t *testing.T) {
+	images := BaseImages("")
+	assert.Len(t, images, 6, "the default image is not listed twice")
+	assert.Contains(t, images, DefaultScenarioImage)
+	assert.IsNonDecreasing(t, images)
+
+	images = BaseImages("minimal:latest")
+	assert.Len(t, images, 7)
+	assert.Contains(t, images, "minimal:latest")
+}
+
+func TestValidateScenarioType(t *testing.T) {
+	reserved := []string{"java"}
+
+	assert.NoError(t, ValidateScenarioType("go", reserved))
+	// Unknown types keep falling back to the default image
+	assert.NoError(t, ValidateScenarioType("rust", reserved))
+	assert.ErrorIs(t, ValidateScenarioType("", reserved), ErrInvalidScenarioType)
+
+	err := ValidateScenarioType("java", reserved)
+	assert.ErrorIs(t, err, ErrInvalidScenarioType)
+	assert.Contains(t, err.Error(), `"java" is not supported, use one of: docker, go, go-k8s, k8s, python, python-k8s`)
+}
+
+func TestIsBaseImage(t *testing.T) {
+	for _, image := range []string{"devlab-go:latest", "devlab-k8s:latest", "devlab-python-k8s:latest", DefaultScenarioImage} {
+		assert.True(t, IsBaseImage(image), image)
+	}
+	for _, image := range []string{"devlab-snapshot:abc123", "golang:1.21", ""} {
+		assert.False(t, IsBaseImage(image), image)
+	}
+}
+
+func TestApplyDiskQuota(t *testing.T) {
+	t.Run("no_quota", func(t *testing.T) {
+		hostConfig := &container.HostConfig{}
+		applyDiskQuota(hostConfig, "", DiskQuotaStorageOpt)
+
+		assert.Nil(t, hostConfig.StorageOpt)
+		assert.Nil(t, hostConfig.Tmpfs)
+	})
+
+	t.Run("storage_opt", func(t *testing.T) {
+		hostConfig := &container.HostConfig{}
+		applyDiskQuota(hostConfig, "2G", DiskQuotaStorageOpt)
+
+		assert.Equal(t, map[string]string{"size": "2G"}, hostConfig.StorageOpt)
+		assert.Nil(t, hostConfig.Tmpfs)
+	})
+
+	t.Run("default_mode_is_storage_opt", func(t *testing.T) {
+		hostConfig := &container.HostConfig{}
+		applyDiskQuota(hostConfig, "1G", "")
+
+		assert.Equal(t, "1G", hostConfig.StorageOpt["size"])
+	})
+
+	t.Run("tmpfs", func(t *testing.T) {
+		hostConfig := &container.HostConfig{}
+		applyDiskQuota(hostConfig, "512m", DiskQuotaTmpfs)
+
+		assert.Nil(t, hostConfig.StorageOpt)
+		assert.Contains(t, hostConfig.Tmpfs["/home/devlab"], "size=512m")
+	})
+}
+
+func TestApplyNetworkConfig(t *testing.T) {
+	t.Run("unset", func(t *testing.T) {
+		hostConfig := &container.HostConfig{}
+		RealClient{}.applyNetworkConfig(hostConfig)
+
+		assert.Nil(t, hostConfig.DNS)
+		assert.Nil(t, hostConfig.ExtraHosts)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		c := RealClient{opts: Options{
+			DNS:        []string{"10.0.0.53", "10.0.0.54"},
+			ExtraHosts: []string{"mirror.internal:10.0.0.10"},
+		}}
+		hostConfig := &container.HostConfig{}
+		c.applyNetworkConfig(hostConfig)
+
+		assert.Equal(t, []string{"10.0.0.53", "10.0.0.54"}, hostConfig.DNS)
+		assert.Equal(t, []string{"mirror.internal:10.0.0.10"}, hostConfig.ExtraHosts)
+
+		// The host config gets its own copy of the client's slices
+		hostConfig.DNS[0] = "8.8.8.8"
+		assert.Equal(t, "10.0.0.53", c.opts.DNS[0])
+	})
+}
+
+func TestApplyReadonlyRootfs(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		hostConfig := &container.HostConfig{}
+		RealClient{}.applyReadonlyRootfs(hostConfig, "go")
+
+		assert.False(t, hostConfig.ReadonlyRootfs)
+		assert.Nil(t, hostConfig.Tmpfs)
+	})
+
+	t.Run("workspace_and_tmp", func(t *testing.T) {
+		hostConfig := &container.HostConfig{}
+		RealClient{opts: Options{ReadonlyRootfs: true}}.applyReadonlyRootfs(hostConfig, "python")
+
+		assert.True(t, hostConfig.ReadonlyRootfs)
+		assert.Equal(t, map[string]string{
+			"/home/devlab": "rw,exec,uid=1000,gid=1000,mode=0755",
+			"/tmp":         "rw,exec,mode=1777",
+		}, hostConfig.Tmpfs)
+	})
+
+	t.Run("k3s_paths", func(t *testing.T) {
+		hostConfig := &container.HostConfig{}
+		RealClient{opts: Options{ReadonlyRootfs: true}}.applyReadonlyRootfs(hostConfig, "go-k8s")
+
+		for _, path := range []string{"/home/devlab", "/tmp", "/etc/rancher", "/var/lib/rancher", "/run"} {
+			assert.Contains(t, hostConfig.Tmpfs, path)
+		}
+	})
+
+	t.Run("keeps_disk_quota_tmpfs", func(t *testing.T) {
+		hostConfig := &container.HostConfig{}
+		applyDiskQuota(hostConfig, "512m", DiskQuotaTmpfs)
+		RealClient{opts: Options{ReadonlyRootfs: true}}.applyReadonlyRootfs(hostConfig, "go")
+
+		assert.Contains(t, hostConfig.Tmpfs["/home/devlab"], "size=512m")
+		assert.Contains(t, hostConfig.Tmpfs, "/tmp")
+	})
+}
+
+func TestApplySecurityOptions(t *testing.T) {
+	t.Run("unset", func(t *testing.T) {
+		hostConfig := &container.HostConfig{}
+		RealClient{}.applySecurityOptions(hostConfig)
+
+		assert.Nil(t, hostConfig.CapDrop)
+		assert.Nil(t, hostConfig.SecurityOpt)
+	})
+
+	t.Run("hardened", func(t *testing.T) {
+		c := RealClient{opts: Options{
+			CapDrop:         []string{"NET_RAW", "MKNOD"},
+			NoNewPrivileges: true,
+			SeccompProfile:  `{"defaultAction":"SCMP_ACT_ERRNO"}`,
+		}}
+		hostConfig := &container.HostConfig{}
+		c.applySecurityOptions(hostConfig)
+
+		assert.Equal(t, []string{"NET_RAW", "MKNOD"}, []string(hostConfig.CapDrop))
+		assert.Equal(t, []string{
+			"no-new-privileges",
+			`seccomp={"defaultAction":"SCMP_ACT_ERRNO"}`,
+		}, hostConfig.SecurityOpt)
+
+		// The host config gets its own copy of the client's slice
+		hostConfig.CapDrop[0] = "ALL"
+		assert.Equal(t, "NET_RAW", c.opts.CapDrop[0])
+	})
+}
+
+func TestApplyLabels(t *testing.T) {
+	c := RealClient{opts: Options{Labels: map[string]string{"cost-center": "platform", "team": "infra"}}}
+	spec := ContainerSpec{
+		ScenarioType: "go",
+		Labels:       map[string]string{"cost-center": "training", "course-id": "k8s-101", ManagedLabel: "false"},
+	}
+
+	config := batchContainerConfig("devlab-go:latest", spec)
+	c.applyLabels(config, spec)
+
+	assert.Equal(t, map[string]string{
+		// The request overrides the client's defaults
+		"cost-center": "training",
+		"team":        "infra",
+		"course-id":   "k8s-101",
+		// and devlab's own labels override both
+		ManagedLabel: "true",
+	}, config.Labels)
+	assert.Equal(t, "platform", c.opts.Labels["cost-center"])
+}
+
+func TestApplyResourceLimits(t *testing.T) {
+	c := RealClient{opts: Options{DefaultLimits: map[string]ResourceLimits{
+		"go":     {CPUs: 1, MemoryMB: 512},
+		"go-k8s": {CPUs: 2, MemoryMB: 4096},
+	}}}
+
+	tests := []struct {
+		name             string
+		spec             ContainerSpec
+		expectedNanoCPUs int64
+		expectedMemory   int64
+	}{
+		{
+			name:             "type_default",
+			spec:             ContainerSpec{ScenarioType: "go-k8s"},
+			expectedNanoCPUs: 2_000_000_000,
+			expectedMemory:   4096 * 1024 * 1024,
+		},
+		{
+			name:             "request_overrides_default",
+			spec:             ContainerSpec{ScenarioType: "go", Limits: ResourceLimits{CPUs: 0.5, MemoryMB: 2048}},
+			expectedNanoCPUs: 500_000_000,
+			expectedMemory:   2048 * 1024 * 1024,
+		},
+		{
+			name:             "request_overrides_memory_only",
+			spec:             ContainerSpec{ScenarioType: "go", Limits: ResourceLimits{MemoryMB: 256}},
+			expectedNanoCPUs: 1_000_000_000,
+			expectedMemory:   256 * 1024 * 1024,
+		},
+		{
+			name: "unlimited_without_default",
+			spec: ContainerSpec{ScenarioType: "python"},
+		},
+		{
+			name:             "request_without_default",
+			spec:             ContainerSpec{ScenarioType: "python", Limits: ResourceLimits{CPUs: 1.5}},
+			expectedNanoCPUs: 1_500_000_000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostConfig := &container.HostConfig{}
+			c.applyResourceLimits(hostConfig, tt.spec)
+
+			assert.Equal(t, tt.expectedNanoCPUs, hostConfig.NanoCPUs)
+			assert.Equal(t, tt.expectedMemory, hostConfig.Memory)
+		})
+	}
+
+	t.Run("no_defaults", func(t *testing.T) {
+		hostConfig := &container.HostConfig{}
+		RealClient{}.applyResourceLimits(hostConfig, ContainerSpec{ScenarioType: "go"})
+
+		assert.Zero(t, hostConfig.NanoCPUs)
+		assert.Zero(t, hostConfig.Memory)
+	})
+}
+
+func TestApplyIOWeight(t *testing.T) {
+	hostConfig := &container.HostConfig{}
+	RealClient{}.applyIOWeight(hostConfig)
+	assert.Zero(t, hostConfig.BlkioWeight)
+
+	RealClient{opts: Options{BlkioWeight: 300}}.applyIOWeight(hostConfig)
+	assert.Equal(t, uint16(300), hostConfig.BlkioWeight)
+}
+
+func TestParseLoadAverage(t *testing.T) {
+	load, err := parseLoadAverage("3.52 2.58 1.59 2/467 12345\n")
+	require.NoError(t, err)
+	assert.Equal(t, 3.52, load)
+
+	_, err = parseLoadAverage("")
+	assert.Error(t, err)
+	_, err = parseLoadAverage("busy 1 1")
+	assert.Error(t, err)
+}
+
+func TestHostLoad_LoadPerCPU(t *testing.T) {
+	assert.Equal(t, 1.5, HostLoad{CPUs: 4, Load1: 6}.LoadPerCPU())
+	assert.Zero(t, HostLoad{Load1: 6}.LoadPerCPU())
+}
+
+func TestValidateBlkioWeight(t *testing.T) {
+	for _, weight := range []int{0, MinBlkioWeight, 500, MaxBlkioWeight} {
+		assert.NoError(t, ValidateBlkioWeight(weight), weight)
+	}
+	for _, weight := range []int{-1, MinBlkioWeight - 1, MaxBlkioWeight + 1, 70000} {
+		assert.Error(t, ValidateBlkioWeight(weight), weight)
+	}
+}
+
+func TestValidateLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		limits      ResourceLimits
+		expectError bool
+	}{
+		{name: "unset"},
+		{name: "valid", limits: ResourceLimits{CPUs: 0.25, MemoryMB: MinMemoryLimitMB}},
+		{name: "negative_cpus", limits: ResourceLimits{CPUs: -1}, expectError: true},
+		{name: "nan_cpus", limits: ResourceLimits{CPUs: math.NaN()}, expectError: true},
+		{name: "infinite_cpus", limits: ResourceLimits{CPUs: math.Inf(1)}, expectError: true},
+		{name: "negative_memory", limits: ResourceLimits{MemoryMB: -512}, expectError: true},
+		{name: "memory_below_minimum", limits: ResourceLimits{MemoryMB: MinMemoryLimitMB - 1}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLimits(tt.limits)
+			if tt.expectError {
+				assert.ErrorIs(t, err, ErrInvalidLimits)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		expectError bool
+	}{
+		{name: "nil"},
+		{name: "valid", labels: map[string]string{"cost-center": "", "com.example.course-id": "k8s-101", "v2": "x"}},
+		{name: "empty_key", labels: map[string]string{"": "x"}, expectError: true},
+		{name: "uppercase", labels: map[string]string{"CostCenter": "x"}, expectError: true},
+		{name: "leading_dot", labels: map[string]string{".hidden": "x"}, expectError: true},
+		{name: "trailing_hyphen", labels: map[string]string{"course-": "x"}, expectError: true},
+		{name: "space", labels: map[string]string{"cost center": "x"}, expectError: true},
+		{name: "equals", labels: map[string]string{"a=b": "x"}, expectError: true},
+		{name: "reserved_prefix", labels: map[string]string{"devlab.managed": "false"}, expectError: true},
+		{name: "reserved_prefix_new_key", labels: map[string]string{"devlab.course": "k8s-101"}, expectError: true},
+		{name: "prefix_without_dot", labels: map[string]string{"devlab-course": "k8s-101"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLabels(tt.labels)
+			if tt.expectError {
+				assert.ErrorIs(t, err, ErrInvalidLabel)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLoadSeccompProfile(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "seccomp.json")
+	require.NoError(t, os.WriteFile(valid, []byte(`{"defaultAction":"SCMP_ACT_ALLOW"}`), 0o644))
+	profile, err := LoadSeccompProfile(valid)
+	require.NoError(t, err)
+	assert.Equal(t, `{"defaultAction":"SCMP_ACT_ALLOW"}`, profile)
+
+	invalid := filepath.Join(dir, "broken.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{"defaultAction":`), 0o644))
+	_, err = LoadSeccompProfile(invalid)
+	assert.Error(t, err)
+
+	_, err = LoadSeccompProfile(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestApplyContainerUser(t *testing.T) {
+	c := RealClient{opts: Options{
+		ContainerUser:  "devlab",
+		ContainerUsers: map[string]string{"k8s": "root", "python": ""},
+	}}
+
+	tests := []struct {
+		name         string
+		client       RealClient
+		scenarioType string
+		expectedUser string
+	}{
+		{name: "unset_keeps_image_default", client: RealClient{}, scenarioType: "go", expectedUser: ""},
+		{name: "default_user", client: c, scenarioType: "go", expectedUser: "devlab"},
+		{name: "per_type_override", client: c, scenarioType: "k8s", expectedUser: "root"},
+		{name: "per_type_image_default", client: c, scenarioType: "python", expectedUser: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			containerConfig, err := interactiveContainerConfig("devlab-go:latest", ContainerSpec{ScenarioType: tt.scenarioType}, nil)
+			require.NoError(t, err)
+			tt.client.applyContainerUser(containerConfig, tt.scenarioType)
+			assert.Equal(t, tt.expectedUser, containerConfig.User)
+		})
+	}
+}
+
+func TestProvisioningError(t *testing.T) {
+	cause := exitedContainerError("devlab-go:latest", 1, "")
+	err := fmt.Errorf("failed to provision container: %w", &ProvisioningError{ContainerID: "abc123", Logs: "boom", Err: cause})
+
+	assert.ErrorIs(t, err, ErrTTYDFailedToStart)
+	var kept *ProvisioningError
+	require.ErrorAs(t, err, &kept)
+	assert.Equal(t, "abc123", kept.ContainerID)
+	assert.Equal(t, "boom", kept.Logs)
+	assert.Contains(t, err.Error(), "container abc123 kept for debugging")
+}
+
+func TestStartupTemplate(t *testing.T) {
+	spec := ContainerSpec{ScenarioType: "go-k8s", Script: "echo hello"}
+
+	t.Run("built_in", func(t *testing.T) {
+		script, err := renderStartupScript(nil, spec)
+
+		require.NoError(t, err)
+		assert.Contains(t, script, `SCENARIO_TYPE="go-k8s"`)
+		assert.Contains(t, script, "ttyd -p 3000 ")
+		assert.Contains(t, script, ttydPreflight)
+		assert.Contains(t, script, "\necho hello\n")
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "startup.sh.tmpl")
+		custom := "#!/bin/sh\n{{.Preflight}}\nttyd -p {{.TtydPort}} -O bash &\nexport TYPE={{.ScenarioType}}\n{{.Script}}\nsleep infinity\n"
+		require.NoError(t, os.WriteFile(path, []byte(custom), 0o644))
+
+		tmpl, err := LoadStartupTemplate(path)
+		require.NoError(t, err)
+		config, err := interactiveContainerConfig("devlab-go:latest", spec, tmpl)
+		require.NoError(t, err)
+
+		expected := "#!/bin/sh\n" + ttydPreflight + "\nttyd -p 3000 -O bash &\nexport TYPE=go-k8s\necho hello\nsleep infinity\n"
+		require.Len(t, config.Cmd, 3)
+		assert.Contains(t, config.Cmd[2], "<< 'EOF'\n"+expected+"\nEOF\n")
+	})
+
+	t.Run("unknown_field_rejected_at_load", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "startup.sh.tmpl")
+		require.NoError(t, os.WriteFile(path, []byte("echo {{.Port}}\n"), 0o644))
+
+		_, err := LoadStartupTemplate(path)
+		assert.ErrorContains(t, err, "Port")
+	})
+
+	t.Run("invalid_syntax", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "startup.sh.tmpl")
+		require.NoError(t, os.WriteFile(path, []byte("echo {{.Script\n"), 0o644))
+
+		_, err := LoadStartupTemplate(path)
+		assert.Error(t, err)
+	})
+
+	t.Run("missing_file", func(t *testing.T) {
+		_, err := LoadStartupTemplate(filepath.Join(t.TempDir(), "missing.tmpl"))
+		assert.Error(t, err)
+	})
+}
+
+func TestTerminalIdleTimeout(t *testing.T) {
+	spec := ContainerSpec{ScenarioType: "go"}
+
+	t.Run("configured", func(t *testing.T) {
+		config, err := interactiveContainerConfig("devlab-go:latest", spec, nil)
+		require.NoError(t, err)
+		RealClient{opts: Options{TerminalIdleTimeout: 15 * time.Minute}}.applyTerminalIdleTimeout(config)
+
+		require.Len(t, config.Cmd, 3)
+		assert.Contains(t, config.Cmd[2], "--writable -t disableReuse=true "+ttydShell+" &")
+		assert.Contains(t, config.Env, "TTYD_IDLE_TIMEOUT=900")
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		config, err := interactiveContainerConfig("devlab-go:latest", spec, nil)
+		require.NoError(t, err)
+		RealClient{}.applyTerminalIdleTimeout(config)
+
+		for _, env := range config.Env {
+			assert.NotContains(t, env, "TTYD_IDLE_TIMEOUT")
+		}
+	})
+
+	t.Run("restart_keeps_timeout", func(t *testing.T) {
+		assert.Contains(t, TTYDRestartCommand("devlab", "secret")[2], ttydShell)
+		assert.Contains(t, ttydLauncher, ttydShell)
+	})
+
+	t.Run("idle_session_hung_up", func(t *testing.T) {
+		if _, err := exec.LookPath("script"); err != nil {
+			t.Skip("script not available")
+		}
+		// script gives the session a tty, as ttyd does; the open stdin
+		// pipe keeps it connected without ever sending input
+		cmd := exec.Command("script", "-qec", ttydShell, os.DevNull)
+		cmd.Env = append(os.Environ(), "HOME="+t.TempDir(), "TTYD_IDLE_TIMEOUT=1")
+		stdin, err := cmd.StdinPipe()
+		require.NoError(t, err)
+		defer stdin.Close()
+		require.NoError(t, cmd.Start())
+
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+		select {
+		case <-done:
+		case <-time.After(15 * time.Second):
+			_ = cmd.Process.Kill()
+			t.Fatal("idle terminal session was not closed")
+		}
+	})
+}
+
+func TestContainerOpError(t *testing.T) {
+	err := containerOpError(errdefs.NotFound(errors.New("no such container")), "kill", "abc123")
+	assert.ErrorIs(t, err, ErrContainerNotFound)
+	assert.Contains(t, err.Error(), "abc123")
+
+	err = containerOpError(errdefs.System(errors.New("cgroup busy")), "pause", "abc123")
+	assert.NotErrorIs(t, err, ErrContainerNotFound)
+	assert.EqualError(t, err, "failed to pause container: cgroup busy")
+}
+
+func TestStartError(t *testing.T) {
+	unknownUser := errors.New(`Error response from daemon: unable to find user devlab: no matching entries in passwd file`)
+
+	err := startError(unknownUser, "devlab")
+	assert.ErrorIs(t, err, ErrContainerUserNotFound)
+	assert.Contains(t, err.Error(), `"devlab"`)
+
+	// Without a configured user the failure is not about the user
+	assert.NotErrorIs(t, startError(unknownUser, ""), ErrContainerUserNotFound)
+
+	other := errors.New("Error response from daemon: port is already allocated")
+	err = startError(other, "devlab")
+	assert.NotErrorIs(t, err, ErrContainerUserNotFound)
+	assert.ErrorIs(t, err, other)
+}
+
+func TestIsStorageOptUnsupported(t *testing.T) {
+	assert.True(t, isStorageOptUnsupported(errors.New("Error response from daemon: --storage-opt is supported only for overlay over xfs with 'pquota' mount option")))
+	assert.False(t, isStorageOptUnsupported(errors.New("Error response from daemon: No such image: devlab-go:latest")))
+}
+
+func TestTTYDRestartCommand(t *testing.T) {
+	command := TTYDRestartCommand("devlab", "p@ss; rm -rf /")
+
+	assert.Equal(t, []string{"sh", "-c"}, command[:2])
+	assert.NotContains(t, command[2], "p@ss", "credentials must not be interpolated into the shell script")
+	assert.Equal(t, "devlab:p@ss; rm -rf /", command[len(command)-1])
+	assert.Contains(t, command[2], "> "+ttydPIDFile)
+	assert.Contains(t, command[2], "kill -0 $pid")
+	assert.Contains(t, command[2], TTYDRestartedMessage)
+
+	// The restart can only replace a ttyd whose pid was recorded
+	assert.Contains(t, ttydLauncher, "echo $! > "+ttydPIDFile)
+	assert.Contains(t, defaultStartupScript, "> "+ttydPIDFile)
+}
+
+func TestRegistryAuthFor(t *testing.T) {
+	creds := map[string]RegistryCredential{
+		"registry.example.com": {Username: "ci", Password: "secret"},
+		"docker.io":            {Username: "hub-user", Password: "hub-pass"},
+	}
+
+	tests := []struct {
+		name           string
+		image          string
+		creds          map[string]RegistryCredential
+		expectUsername string
+		expectServer   string
+	}{
+		{
+			name:           "private_registry",
+			image:          "registry.example.com/devlab/go:1.21",
+			creds:          creds,
+			expectUsername: "ci",
+			expectServer:   "registry.example.com",
+		},
+		{
+			name:           "docker_hub_short_name",
+			image:          "devlab-go:latest",
+			creds:          creds,
+			expectUsername: "hub-user",
+			expectServer:   "docker.io",
+		},
+		{
+			name:  "unknown_registry",
+			image: "ghcr.io/devlab/go:latest",
+			creds: creds,
+		},
+		{
+			name:  "no_credentials",
+			image: "registry.example.com/devlab/go:1.21",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := registryAuthFor(tt.image, tt.creds)
+			require.NoError(t, err)
+
+			if tt.expectUsername == "" {
+				assert.Empty(t, auth)
+				return
+			}
+
+			decoded, err := base64.URLEncoding.DecodeString(auth)
+			require.NoError(t, err)
+
+			var authConfig registry.AuthConfig
+			require.NoError(t, json.Unmarshal(decoded, &authConfig))
+			assert.Equal(t, tt.expectUsername, authConfig.Username)
+			assert.Equal(t, tt.expectServer, authConfig.ServerAddress)
+		})
+	}
+
+	_, err := registryAuthFor("INVALID//image", creds)
+	assert.Error(t, err)
+}
+
 func TestStartScenarioContainer_ScriptInjection(t *testing.T) {
 	client := RealClient{}
 
@@ -201,11 +895,11 @@ func TestStartScenarioContainer_ScriptInjection(t *testing.T) {
 			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
 			defer cancel()
 
-			_, _, err := client.StartScenarioContainer(ctx, "go", tt.script)
+			_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: tt.script})
 
 			// Function should not panic
 			assert.NotPanics(t, func() {
-				_, _, _ = client.StartScenarioContainer(ctx, "go", tt.script)
+				_, _, _ = client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: tt.script})
 			})
 
 			// Error is expected if Docker daemon is not available
@@ -223,7 +917,7 @@ func TestStartScenarioContainer_ContextHandling(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		cancel() // Cancel immediately
 
-		_, _, err := client.StartScenarioContainer(ctx, "go", "echo test")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo test"})
 
 		// Should handle context cancellation gracefully
 		assert.Error(t, err)
@@ -233,7 +927,7 @@ func TestStartScenarioContainer_ContextHandling(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Nanosecond)
 		defer cancel()
 
-		_, _, err := client.StartScenarioContainer(ctx, "go", "echo test")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo test"})
 
 		// Should handle timeout gracefully
 		assert.Error(t, err)
@@ -241,7 +935,7 @@ func TestStartScenarioContainer_ContextHandling(t *testing.T) {
 
 	t.Run("nil_context", func(t *testing.T) {
 		// This should return an error, not panic
-		_, _, err := client.StartScenarioContainer(nil, "go", "echo test")
+		_, _, err := client.StartScenarioContainer(nil, ContainerSpec{ScenarioType: "go", Script: "echo test"})
 
 		// Should handle nil context gracefully by returning an error
 		assert.Error(t, err)
@@ -259,7 +953,7 @@ func BenchmarkStartScenarioContainer(b *testing.B) {
 
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_, _, err := client.StartScenarioContainer(ctx, "go", "echo benchmark")
+			_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo benchmark"})
 			if err != nil {
 				// Expected error if Docker is not available
 				break
@@ -273,7 +967,7 @@ func BenchmarkStartScenarioContainer(b *testing.B) {
 
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			_, _, err := client.StartScenarioContainer(ctx, "docker", "echo benchmark")
+			_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "docker", Script: "echo benchmark"})
 			if err != nil {
 				// Expected error if Docker is not available
 				break
@@ -290,7 +984,7 @@ func TestStartScenarioContainer_ErrorScenarios(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
 		defer cancel()
 
-		_, _, err := client.StartScenarioContainer(ctx, "go", "echo test")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo test"})
 
 		// Should return a meaningful error
 		if err != nil {
@@ -302,7 +996,7 @@ func TestStartScenarioContainer_ErrorScenarios(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
 		defer cancel()
 
-		_, _, err := client.StartScenarioContainer(ctx, "invalid-type", "echo test")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "invalid-type", Script: "echo test"})
 		// Should not error due to invalid scenario type, but may fail due to Docker issues
 		if err != nil {
 			// If there's an error, it should not be due to invalid scenario type
@@ -427,7 +1121,7 @@ func TestGetTerminalURL_NoPortMapping(t *testing.T) {
 
 // Test the findAvailablePort function
 func TestFindAvailablePort(t *testing.T) {
-	port, err := findAvailablePort()
+	port, err := RealClient{}.findAvailablePort()
 
 	assert.NoError(t, err)
 	assert.GreaterOrEqual(t, port, 3001)
@@ -438,17 +1132,148 @@ func TestFindAvailablePort(t *testing.T) {
 func TestFindAvailablePort_MultipleCalls(t *testing.T) {
 	ports := make(map[int]bool)
 
-	for i := 0; i < 5; i++ {
-		port, err := findAvailablePort()
-		assert.NoError(t, err)
-		assert.GreaterOrEqual(t, port, 3001)
-		assert.LessOrEqual(t, port, 3009)
-		ports[port] = true
-	}
+	for i := 0; i < 5; i++ {
+		port, err := RealClient{}.findAvailablePort()
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, port, 3001)
+		assert.LessOrEqual(t, port, 3009)
+		ports[port] = true
+	}
+
+	// In a real environment, we might get different ports
+	// In test environment, we might get the same port if it's available
+	assert.True(t, len(ports) >= 1)
+}
+
+func TestBindMounts(t *testing.T) {
+	allowed := []string{"/srv/datasets", "/opt/tools/"}
+
+	t.Run("allowed", func(t *testing.T) {
+		mounts, err := bindMounts([]BindMount{
+			{Source: "/srv/datasets/mnist", Target: "/home/devlab/data", ReadOnly: true},
+			{Source: "/opt/tools", Target: "/opt/tools/"},
+		}, allowed)
+		require.NoError(t, err)
+		assert.Equal(t, []mount.Mount{
+			{Type: mount.TypeBind, Source: "/srv/datasets/mnist", Target: "/home/devlab/data", ReadOnly: true},
+			{Type: mount.TypeBind, Source: "/opt/tools", Target: "/opt/tools"},
+		}, mounts)
+	})
+
+	for name, source := range map[string]string{
+		"outside_prefixes":  "/etc",
+		"sibling_of_prefix": "/srv/datasets-private",
+		"climbs_out":        "/srv/datasets/../secrets",
+		"relative":          "srv/datasets",
+		"parent_of_prefix":  "/srv",
+		"docker_socket":     "/var/run/docker.sock",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := bindMounts([]BindMount{{Source: source, Target: "/mnt"}}, allowed)
+			assert.ErrorIs(t, err, ErrMountNotAllowed)
+		})
+	}
+
+	t.Run("no_allow_list", func(t *testing.T) {
+		_, err := bindMounts([]BindMount{{Source: "/srv/datasets", Target: "/mnt"}}, nil)
+		assert.ErrorIs(t, err, ErrMountNotAllowed)
+
+		mounts, err := bindMounts(nil, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, mounts)
+	})
+}
+
+func TestStartScenarioContainer_RejectsDisallowedMount(t *testing.T) {
+	client := RealClient{opts: Options{MountablePaths: []string{"/srv/datasets"}}}
+
+	// Rejected before the daemon is contacted, so this runs without Docker
+	_, _, err := client.StartScenarioContainer(context.Background(), ContainerSpec{
+		ScenarioType: "go",
+		Mounts:       []BindMount{{Source: "/etc", Target: "/mnt/etc"}},
+	})
+
+	assert.ErrorIs(t, err, ErrMountNotAllowed)
+	assert.Contains(t, err.Error(), "/etc is outside the allowed paths")
+}
+
+// testPortPool returns a pool over first-last whose host checks and clock are
+// controlled by the test
+func testPortPool(first, last int, hostHeld map[int]bool, now *time.Time) *PortPool {
+	pool := NewPortPool(first, last, 10*time.Minute)
+	pool.inUse = func(port int) bool { return hostHeld[port] }
+	pool.now = func() time.Time { return *now }
+	return pool
+}
+
+func TestPortPool(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("lowest_free_first_and_reused_after_release", func(t *testing.T) {
+		pool := testPortPool(4001, 4003, nil, &now)
+		for _, want := range []int{4001, 4002, 4003} {
+			port, err := pool.Allocate()
+			require.NoError(t, err)
+			assert.Equal(t, want, port)
+		}
+
+		pool.Release(4002)
+		port, err := pool.Allocate()
+		require.NoError(t, err)
+		assert.Equal(t, 4002, port, "a released port is reused immediately")
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		pool := testPortPool(4001, 4002, nil, &now)
+		_, err := pool.Allocate()
+		require.NoError(t, err)
+		_, err = pool.Allocate()
+		require.NoError(t, err)
+
+		_, err = pool.Allocate()
+		assert.ErrorIs(t, err, ErrPortUnavailable)
+		assert.Contains(t, err.Error(), "all 2 ports in range 4001-4002 are in use")
+	})
+
+	t.Run("skips_ports_held_on_host", func(t *testing.T) {
+		pool := testPortPool(4001, 4003, map[int]bool{4001: true, 4002: true}, &now)
+		port, err := pool.Allocate()
+		require.NoError(t, err)
+		assert.Equal(t, 4003, port)
+
+		_, err = pool.Allocate()
+		assert.ErrorIs(t, err, ErrPortUnavailable)
+	})
 
-	// In a real environment, we might get different ports
-	// In test environment, we might get the same port if it's available
-	assert.True(t, len(ports) >= 1)
+	t.Run("reclaims_stale_unused_allocation", func(t *testing.T) {
+		clock := now
+		hostHeld := map[int]bool{}
+		pool := testPortPool(4001, 4001, hostHeld, &clock)
+		_, err := pool.Allocate()
+		require.NoError(t, err)
+		hostHeld[4001] = true
+
+		clock = clock.Add(time.Hour)
+		_, err = pool.Allocate()
+		assert.ErrorIs(t, err, ErrPortUnavailable, "a stale allocation still bound on the host is kept")
+
+		// Freed behind the pool's back, e.g. by the cleanup worker
+		delete(hostHeld, 4001)
+		clock = clock.Add(-55 * time.Minute)
+		_, err = pool.Allocate()
+		assert.ErrorIs(t, err, ErrPortUnavailable, "a recent allocation is not reclaimed")
+
+		clock = clock.Add(55 * time.Minute)
+		port, err := pool.Allocate()
+		require.NoError(t, err)
+		assert.Equal(t, 4001, port)
+	})
+
+	t.Run("default_range", func(t *testing.T) {
+		pool := NewPortPool(0, 0, 0)
+		assert.Equal(t, DefaultPortRangeStart, pool.first)
+		assert.Equal(t, DefaultPortRangeEnd, pool.last)
+	})
 }
 
 func TestStartScenarioContainer_ErrorHandling(t *testing.T) {
@@ -457,7 +1282,7 @@ func TestStartScenarioContainer_ErrorHandling(t *testing.T) {
 
 	t.Run("nil_context", func(t *testing.T) {
 		// This should return an error, not panic
-		_, _, err := client.StartScenarioContainer(nil, "go", "echo test")
+		_, _, err := client.StartScenarioContainer(nil, ContainerSpec{ScenarioType: "go", Script: "echo test"})
 
 		// Should handle nil context gracefully by returning an error
 		assert.Error(t, err)
@@ -465,14 +1290,14 @@ func TestStartScenarioContainer_ErrorHandling(t *testing.T) {
 	})
 
 	t.Run("empty_scenario_type", func(t *testing.T) {
-		_, _, err := client.StartScenarioContainer(ctx, "", "echo test")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "", Script: "echo test"})
 		assert.Error(t, err)
 		assert.ErrorIs(t, err, ErrInvalidScenarioType)
 		assert.Contains(t, err.Error(), "empty")
 	})
 
 	t.Run("invalid_scenario_type", func(t *testing.T) {
-		_, _, err := client.StartScenarioContainer(ctx, "invalid-type", "echo test")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "invalid-type", Script: "echo test"})
 		// Should not error, but use default image
 		assert.NoError(t, err)
 	})
@@ -480,7 +1305,7 @@ func TestStartScenarioContainer_ErrorHandling(t *testing.T) {
 	t.Run("port_unavailability", func(t *testing.T) {
 		// This test would require mocking the port finding logic
 		// For now, we'll test the error type is correct
-		_, _, err := client.StartScenarioContainer(ctx, "go", "echo test")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo test"})
 		// The actual error depends on Docker availability, but we can test the structure
 		if err != nil {
 			// Should not be a port unavailability error in normal conditions
@@ -508,10 +1333,22 @@ func TestGetContainerStatus_ErrorHandling(t *testing.T) {
 	t.Run("nonexistent_container", func(t *testing.T) {
 		_, err := client.GetContainerStatus(ctx, "nonexistent-container-id")
 		assert.Error(t, err)
-		assert.ErrorIs(t, err, ErrContainerNotFound)
+		assertContainerNotFound(t, err)
 	})
 }
 
+// assertContainerNotFound checks the error of a lookup of a missing container.
+// Without a reachable daemon the lookup fails with ErrDockerDaemonUnavailable
+// instead, which must not be mistaken for a missing container.
+func assertContainerNotFound(t *testing.T, err error) {
+	t.Helper()
+	if errors.Is(err, ErrDockerDaemonUnavailable) {
+		assert.NotErrorIs(t, err, ErrContainerNotFound)
+		return
+	}
+	assert.ErrorIs(t, err, ErrContainerNotFound)
+}
+
 func TestGetTerminalURL_ErrorHandling(t *testing.T) {
 	client := RealClient{}
 	ctx := context.Background()
@@ -531,7 +1368,7 @@ func TestGetTerminalURL_ErrorHandling(t *testing.T) {
 	t.Run("nonexistent_container", func(t *testing.T) {
 		_, err := client.GetTerminalURL(ctx, "nonexistent-container-id")
 		assert.Error(t, err)
-		assert.ErrorIs(t, err, ErrContainerNotFound)
+		assertContainerNotFound(t, err)
 	})
 
 	t.Run("stopped_container", func(t *testing.T) {
@@ -540,7 +1377,7 @@ func TestGetTerminalURL_ErrorHandling(t *testing.T) {
 		_, err := client.GetTerminalURL(ctx, "nonexistent-container-id")
 		assert.Error(t, err)
 		// Should be container not found, not container not running
-		assert.ErrorIs(t, err, ErrContainerNotFound)
+		assertContainerNotFound(t, err)
 	})
 }
 
@@ -594,7 +1431,7 @@ func TestFindAvailablePort_ErrorHandling(t *testing.T) {
 	t.Run("port_range_exhaustion", func(t *testing.T) {
 		// This test would require mocking all ports to be in use
 		// For now, we test the function works in normal conditions
-		port, err := findAvailablePort()
+		port, err := RealClient{}.findAvailablePort()
 		if err != nil {
 			assert.ErrorIs(t, err, ErrPortUnavailable)
 		} else {
@@ -611,7 +1448,7 @@ func TestStartScenarioContainer_TTYDFailureHandling(t *testing.T) {
 	t.Run("ttyd_installation_failure", func(t *testing.T) {
 		// This test would require a container image without package managers
 		// For now, we test the error handling structure
-		_, _, err := client.StartScenarioContainer(ctx, "go", "echo test")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo test"})
 		if err != nil {
 			// Should not be a TTYD failure error in normal conditions
 			assert.NotErrorIs(t, err, ErrTTYDFailedToStart)
@@ -621,7 +1458,7 @@ func TestStartScenarioContainer_TTYDFailureHandling(t *testing.T) {
 	t.Run("ttyd_startup_failure", func(t *testing.T) {
 		// This test would require mocking ttyd to fail to start
 		// For now, we test the error handling structure
-		_, _, err := client.StartScenarioContainer(ctx, "go", "echo test")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo test"})
 		if err != nil {
 			// Should not be a TTYD failure error in normal conditions
 			assert.NotErrorIs(t, err, ErrTTYDFailedToStart)
@@ -636,7 +1473,7 @@ func TestDockerDaemonUnavailable(t *testing.T) {
 	t.Run("docker_daemon_unavailable", func(t *testing.T) {
 		// This test would require stopping the Docker daemon
 		// For now, we test the error handling structure
-		_, _, err := client.StartScenarioContainer(ctx, "go", "echo test")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo test"})
 		if err != nil {
 			// Should not be a Docker daemon error in normal conditions
 			assert.NotErrorIs(t, err, ErrDockerDaemonUnavailable)
@@ -673,7 +1510,7 @@ func TestContextHandling_Enhanced(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		cancel() // Cancel immediately
 
-		_, _, err := client.StartScenarioContainer(ctx, "go", "echo test")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo test"})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "canceled")
 	})
@@ -684,14 +1521,14 @@ func TestContextHandling_Enhanced(t *testing.T) {
 
 		time.Sleep(1 * time.Millisecond) // Ensure timeout
 
-		_, _, err := client.StartScenarioContainer(ctx, "go", "echo test")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo test"})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "deadline")
 	})
 
 	t.Run("nil_context", func(t *testing.T) {
 		// This should return an error, not panic
-		_, _, err := client.StartScenarioContainer(nil, "go", "echo test")
+		_, _, err := client.StartScenarioContainer(nil, ContainerSpec{ScenarioType: "go", Script: "echo test"})
 
 		// Should handle nil context gracefully by returning an error
 		assert.Error(t, err)
@@ -704,7 +1541,7 @@ func TestErrorScenarios_Enhanced(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("docker_daemon_unavailable", func(t *testing.T) {
-		_, _, err := client.StartScenarioContainer(ctx, "go", "echo test")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo test"})
 		if err != nil {
 			// In normal conditions, this should not be a Docker daemon error
 			assert.NotErrorIs(t, err, ErrDockerDaemonUnavailable)
@@ -712,13 +1549,13 @@ func TestErrorScenarios_Enhanced(t *testing.T) {
 	})
 
 	t.Run("invalid_scenario_type", func(t *testing.T) {
-		_, _, err := client.StartScenarioContainer(ctx, "invalid-type", "echo test")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "invalid-type", Script: "echo test"})
 		// Should not error, but use default image
 		assert.NoError(t, err)
 	})
 
 	t.Run("empty_script", func(t *testing.T) {
-		_, _, err := client.StartScenarioContainer(ctx, "go", "")
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: ""})
 		// Should not error with empty script
 		assert.NoError(t, err)
 	})
@@ -732,7 +1569,7 @@ for i in {1..5}; do
 done
 echo "Script completed"`
 
-		_, _, err := client.StartScenarioContainer(ctx, "go", script)
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: script})
 		// Should handle complex scripts
 		assert.NoError(t, err)
 	})
@@ -743,7 +1580,7 @@ echo "Script completed"`
 			"echo \"Testing quotes: 'single' \\\"double\\\" `backticks`\"\n" +
 			"echo \"Testing variables: $PATH $HOME\"\n"
 
-		_, _, err := client.StartScenarioContainer(ctx, "go", script)
+		_, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: script})
 		// Should handle special characters in scripts
 		assert.NoError(t, err)
 	})
@@ -755,7 +1592,7 @@ func TestGetTerminalURL_Enhanced(t *testing.T) {
 
 	t.Run("successful_go_scenario_with_terminal", func(t *testing.T) {
 		// Start a container first
-		containerID, _, err := client.StartScenarioContainer(ctx, "go", "echo 'Starting terminal test'")
+		containerID, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo 'Starting terminal test'"})
 		if err != nil {
 			t.Skipf("Skipping test due to Docker error: %v", err)
 		}
@@ -776,7 +1613,7 @@ func TestGetTerminalURL_Enhanced(t *testing.T) {
 
 	t.Run("successful_docker_scenario_with_terminal", func(t *testing.T) {
 		// Start a container first
-		containerID, _, err := client.StartScenarioContainer(ctx, "docker", "echo 'Starting Docker terminal test'")
+		containerID, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "docker", Script: "echo 'Starting Docker terminal test'"})
 		if err != nil {
 			t.Skipf("Skipping test due to Docker error: %v", err)
 		}
@@ -798,7 +1635,540 @@ func TestGetTerminalURL_Enhanced(t *testing.T) {
 	t.Run("docker_error", func(t *testing.T) {
 		_, err := client.GetTerminalURL(ctx, "nonexistent-container")
 		assert.Error(t, err)
-		assert.ErrorIs(t, err, ErrContainerNotFound)
+		assertContainerNotFound(t, err)
+	})
+}
+
+func TestNewExecConfig(t *testing.T) {
+	command := []string{"ls", "-la"}
+
+	t.Run("default_opts", func(t *testing.T) {
+		execConfig := newExecConfig(command, ExecuteCommandOpts{})
+
+		assert.Equal(t, command, []string(execConfig.Cmd))
+		assert.Empty(t, execConfig.User)
+		assert.Empty(t, execConfig.WorkingDir)
+		assert.Nil(t, execConfig.Env)
+		assert.True(t, execConfig.AttachStdout)
+		assert.True(t, execConfig.AttachStderr)
+	})
+
+	t.Run("user_and_workdir", func(t *testing.T) {
+		execConfig := newExecConfig(command, ExecuteCommandOpts{User: ScenarioUser, WorkingDir: ScenarioHomeDir})
+
+		assert.Equal(t, command, []string(execConfig.Cmd))
+		assert.Equal(t, "devlab", execConfig.User)
+		assert.Equal(t, "/home/devlab", execConfig.WorkingDir)
+	})
+
+	t.Run("env", func(t *testing.T) {
+		env := []string{"KUBECONFIG=/home/devlab/.kube/config"}
+		execConfig := newExecConfig(command, ExecuteCommandOpts{Env: env})
+
+		assert.Equal(t, env, execConfig.Env)
+	})
+}
+
+func TestValidateEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		expectError bool
+	}{
+		{name: "nil"},
+		{name: "valid", env: map[string]string{"_PRIVATE": "1", "GO_VERSION": "1.23", "lower": "x"}},
+		{name: "shell_metacharacters_in_value", env: map[string]string{"CMD": "`id`; $(rm -rf /) && echo '\"'"}},
+		{name: "empty_name", env: map[string]string{"": "x"}, expectError: true},
+		{name: "leading_digit", env: map[string]string{"1VAR": "x"}, expectError: true},
+		{name: "dash", env: map[string]string{"MY-VAR": "x"}, expectError: true},
+		{name: "equals", env: map[string]string{"A=B": "x"}, expectError: true},
+		{name: "command_substitution_in_name", env: map[string]string{"$(id)": "x"}, expectError: true},
+		{name: "reserved", env: map[string]string{"SCENARIO_TYPE": "python"}, expectError: true},
+		{name: "reserved_idle_timeout", env: map[string]string{"TTYD_IDLE_TIMEOUT": "0"}, expectError: true},
+		{name: "null_byte", env: map[string]string{"VAR": "a\x00b"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEnv(tt.env)
+			if tt.expectError {
+				assert.ErrorIs(t, err, ErrInvalidEnv)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestContainerConfig_EnvNotInterpolated(t *testing.T) {
+	const payload = "$(touch /tmp/pwned); `id` && echo 'EOF'"
+	spec := ContainerSpec{
+		ScenarioType: "go",
+		Script:       "echo $PAYLOAD",
+		Env:          map[string]string{"PAYLOAD": payload, "A_FIRST": "1"},
+	}
+
+	t.Run("interactive", func(t *testing.T) {
+		config, err := interactiveContainerConfig("devlab-go:latest", spec, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"A_FIRST=1", "PAYLOAD=" + payload}, config.Env)
+		assert.NotContains(t, strings.Join(config.Cmd, " "), payload)
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		config := batchContainerConfig("devlab-go:latest", spec)
+
+		assert.Equal(t, []string{"SCENARIO_TYPE=go", "A_FIRST=1", "PAYLOAD=" + payload}, config.Env)
+		assert.NotContains(t, strings.Join(config.Cmd, " "), payload)
+	})
+
+	t.Run("value_reaches_script_unexecuted", func(t *testing.T) {
+		config := batchContainerConfig("devlab-go:latest", spec)
+		marker := filepath.Join(t.TempDir(), "pwned")
+		cmd := exec.Command(config.Cmd[0], config.Cmd[1], strings.ReplaceAll(config.Cmd[2], "/tmp/scenario.sh", filepath.Join(t.TempDir(), "scenario.sh")))
+		cmd.Env = append(os.Environ(), "PAYLOAD=$(touch "+marker+")")
+		out, err := cmd.Output()
+		require.NoError(t, err)
+
+		assert.Equal(t, "$(touch "+marker+")\n", string(out))
+		assert.NoFileExists(t, marker)
+	})
+}
+
+func TestInteractiveContainerConfig(t *testing.T) {
+	spec := ContainerSpec{
+		ScenarioType:     "go",
+		TerminalUsername: "devlab",
+		TerminalPassword: "secret",
+	}
+
+	t.Run("generated_startup", func(t *testing.T) {
+		config, err := interactiveContainerConfig("devlab-go:latest", spec, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, "devlab-go:latest", config.Image)
+		assert.Nil(t, config.Entrypoint)
+		require.Len(t, config.Cmd, 3)
+		assert.Equal(t, []string{"sh", "-c"}, []string(config.Cmd[:2]))
+		assert.Contains(t, config.Cmd[2], "ttyd -p 3000")
+		assert.Contains(t, config.Cmd[2], ttydPreflight)
+		assert.Contains(t, config.Env, "TTYD_CREDENTIAL=devlab:secret")
+		assert.Equal(t, "true", config.Labels[ManagedLabel])
+	})
+
+	t.Run("entrypoint_override", func(t *testing.T) {
+		override := spec
+		override.Entrypoint = []string{"/sbin/my-init"}
+		override.Command = []string{"--foreground"}
+		config, err := interactiveContainerConfig("devlab-go:latest", override, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"/sbin/my-init"}, []string(config.Entrypoint))
+		assert.Equal(t, []string{"--foreground"}, []string(config.Cmd))
+		assert.Contains(t, config.Env, "TTYD_CREDENTIAL=devlab:secret")
+	})
+
+	t.Run("command_only_keeps_image_entrypoint", func(t *testing.T) {
+		override := spec
+		override.Command = []string{"sleep", "infinity"}
+		config, err := interactiveContainerConfig("devlab-go:latest", override, nil)
+		require.NoError(t, err)
+
+		assert.Nil(t, config.Entrypoint)
+		assert.Equal(t, []string{"sleep", "infinity"}, []string(config.Cmd))
+	})
+
+	t.Run("override_with_ttyd", func(t *testing.T) {
+		override := spec
+		override.Entrypoint = []string{"/sbin/my-init"}
+		override.Command = []string{"--foreground"}
+		override.StartTTYD = true
+		config, err := interactiveContainerConfig("devlab-go:latest", override, nil)
+		require.NoError(t, err)
+
+		require.Len(t, config.Entrypoint, 5)
+		assert.Equal(t, []string{"sh", "-c"}, []string(config.Entrypoint[:2]))
+		assert.Contains(t, config.Entrypoint[2], "ttyd -p 3000")
+		assert.Contains(t, config.Entrypoint[2], `exec "$@"`)
+		assert.Equal(t, "/sbin/my-init", config.Entrypoint[4])
+		assert.Equal(t, []string{"--foreground"}, []string(config.Cmd))
+	})
+}
+
+func TestExitedContainerError(t *testing.T) {
+	err := exitedContainerError("custom:latest", 127, "ERROR: ttyd not found in image\r\n")
+	assert.ErrorIs(t, err, ErrTTYDFailedToStart)
+	assert.Contains(t, err.Error(), "ttyd not found in image custom:latest")
+
+	err = exitedContainerError("devlab-go:latest", 1, "Starting ttyd on port 3000...\r\nERROR: ttyd failed to start\r\n")
+	assert.ErrorIs(t, err, ErrTTYDFailedToStart)
+	assert.Contains(t, err.Error(), "exited unexpectedly with code 1")
+}
+
+// endlessOutput writes multiplexed frames of stream to w until w fails,
+// like a command printing without end
+func endlessOutput(w *io.PipeWriter, stream stdcopy.StdType) {
+	frames := stdcopy.NewStdWriter(w, stream)
+	chunk := bytes.Repeat([]byte("y\n"), 512)
+	for {
+		if _, err := frames.Write(chunk); err != nil {
+			return
+		}
+	}
+}
+
+func TestReadExecOutput(t *testing.T) {
+	t.Run("under_cap", func(t *testing.T) {
+		output, err := readExecOutput(strings.NewReader("hello\n"), 64)
+		require.NoError(t, err)
+		assert.Equal(t, "hello\n", output)
+	})
+
+	t.Run("stops_at_cap", func(t *testing.T) {
+		r, w := io.Pipe()
+		go func() {
+			chunk := bytes.Repeat([]byte("x"), 1000)
+			for {
+				if _, err := w.Write(chunk); err != nil {
+					return
+				}
+			}
+		}()
+		defer r.Close()
+
+		output, err := readExecOutput(r, 2500)
+		assert.ErrorIs(t, err, ErrOutputTruncated)
+		assert.Equal(t, strings.Repeat("x", 2500), output)
+	})
+}
+
+func TestCaptureExecOutput(t *testing.T) {
+	t.Run("under_cap", func(t *testing.T) {
+		var raw bytes.Buffer
+		stdcopy.NewStdWriter(&raw, stdcopy.Stdout).Write([]byte("ok\n"))
+		stdcopy.NewStdWriter(&raw, stdcopy.Stderr).Write([]byte("warning\n"))
+
+		stdout, stderr, truncated, err := captureExecOutput(&raw, 64)
+		require.NoError(t, err)
+		assert.False(t, truncated)
+		assert.Equal(t, "ok\n", stdout)
+		assert.Equal(t, "warning\n", stderr)
+	})
+
+	t.Run("stops_at_cap", func(t *testing.T) {
+		r, w := io.Pipe()
+		go endlessOutput(w, stdcopy.Stdout)
+		defer r.Close()
+
+		stdout, stderr, truncated, err := captureExecOutput(r, 10_000)
+		require.NoError(t, err)
+		assert.True(t, truncated)
+		assert.Len(t, stdout, 10_000)
+		assert.Empty(t, stderr)
+	})
+
+	t.Run("cap_covers_both_streams", func(t *testing.T) {
+		var raw bytes.Buffer
+		stdcopy.NewStdWriter(&raw, stdcopy.Stderr).Write(bytes.Repeat([]byte("e"), 60))
+		stdcopy.NewStdWriter(&raw, stdcopy.Stdout).Write(bytes.Repeat([]byte("o"), 60))
+
+		stdout, stderr, truncated, err := captureExecOutput(&raw, 100)
+		require.NoError(t, err)
+		assert.True(t, truncated)
+		assert.Equal(t, strings.Repeat("e", 60), stderr)
+		assert.Equal(t, strings.Repeat("o", 40), stdout)
+	})
+}
+
+func TestExitedContainerError_QuotesOutput(t *testing.T) {
+	logs := "Starting ttyd on port 3000...\r\nline 2\r\nline 3\r\nline 4\r\nline 5\r\nbind(3000): Address in use\r\n\r\n"
+	err := exitedContainerError("devlab-go:latest", 1, logs)
+
+	assert.ErrorIs(t, err, ErrTTYDFailedToStart)
+	assert.Contains(t, err.Error(), `last output: "line 2\nline 3\nline 4\nline 5\nbind(3000): Address in use"`)
+	assert.NotContains(t, err.Error(), "Starting ttyd")
+}
+
+func TestReadExitedLogs(t *testing.T) {
+	t.Run("demultiplexes", func(t *testing.T) {
+		var raw bytes.Buffer
+		stdout := stdcopy.NewStdWriter(&raw, stdcopy.Stdout)
+		stderr := stdcopy.NewStdWriter(&raw, stdcopy.Stderr)
+		stdout.Write([]byte("Starting ttyd on port 3000...\n"))
+		stderr.Write([]byte("ERROR: ttyd failed to start\n"))
+
+		assert.Equal(t, "Starting ttyd on port 3000...\nERROR: ttyd failed to start\n", readExitedLogs(&raw, false))
+	})
+
+	t.Run("tty", func(t *testing.T) {
+		assert.Equal(t, "$ ls\r\nmain.go\r\n", readExitedLogs(strings.NewReader("$ ls\r\nmain.go\r\n"), true))
+	})
+
+	t.Run("keeps_output_before_truncated_frame", func(t *testing.T) {
+		var raw bytes.Buffer
+		stdcopy.NewStdWriter(&raw, stdcopy.Stderr).Write([]byte("fatal: missing tool\n"))
+		raw.Write([]byte{2, 0, 0, 0, 0, 0, 0, 10, 'c', 'u', 't'})
+
+		assert.Equal(t, "fatal: missing tool\n", readExitedLogs(&raw, false))
+	})
+}
+
+func TestReadBatchLogs(t *testing.T) {
+	t.Run("demultiplexes", func(t *testing.T) {
+		var raw bytes.Buffer
+		stdcopy.NewStdWriter(&raw, stdcopy.Stdout).Write([]byte("ok\n"))
+		stdcopy.NewStdWriter(&raw, stdcopy.Stderr).Write([]byte("warning\n"))
+
+		stdout, stderr, err := readBatchLogs(&raw)
+		require.NoError(t, err)
+		assert.Equal(t, "ok\n", stdout)
+		assert.Equal(t, "warning\n", stderr)
+	})
+
+	t.Run("stops_reading_at_limit", func(t *testing.T) {
+		// A container that never stops writing must not be read to the end
+		var frame bytes.Buffer
+		stdcopy.NewStdWriter(&frame, stdcopy.Stdout).Write(bytes.Repeat([]byte("y\n"), 32*1024))
+		endless := &endlessReader{data: frame.Bytes()}
+
+		stdout, stderr, err := readBatchLogs(endless)
+		require.NoError(t, err)
+		assert.Len(t, stdout, maxBatchOutput)
+		assert.Empty(t, stderr)
+		assert.LessOrEqual(t, endless.read, int64(maxBatchLogBytes))
+	})
+}
+
+// endlessReader repeats data forever, counting the bytes read
+type endlessReader struct {
+	data []byte
+	read int64
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		n += copy(p[n:], r.data[int((r.read+int64(n))%int64(len(r.data))):])
+	}
+	r.read += int64(n)
+	return n, nil
+}
+
+func TestLogExitedContainer(t *testing.T) {
+	originalLogger := zerologlog.Logger
+	defer func() { zerologlog.Logger = originalLogger }()
+	var buf bytes.Buffer
+	zerologlog.Logger = zerolog.New(&buf)
+
+	var raw bytes.Buffer
+	stderr := stdcopy.NewStdWriter(&raw, stdcopy.Stderr)
+	for i := 1; i <= exitedLogTailLines+5; i++ {
+		fmt.Fprintf(stderr, "line %d\n", i)
+	}
+	logExitedContainer("abc123", "devlab-go:latest", 1, readExitedLogs(&raw, false))
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "error", entry["level"])
+	assert.Equal(t, "abc123", entry["container_id"])
+	assert.Equal(t, float64(1), entry["exit_code"])
+	logs := entry["logs"].(string)
+	assert.Len(t, strings.Split(logs, "\n"), exitedLogTailLines)
+	assert.True(t, strings.HasPrefix(logs, "line 6\n"))
+	assert.True(t, strings.HasSuffix(logs, "line 25"))
+}
+
+func TestTTYDPreflight_MissingTTYD(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := exec.LookPath("ttyd"); err == nil {
+		t.Skip("ttyd is installed on this host")
+	}
+
+	// The launcher must stop before exec'ing the override when ttyd is absent
+	marker := filepath.Join(dir, "override-ran")
+	cmd := exec.Command("sh", "-c", ttydLauncher, "sh", "touch", marker)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 127, exitErr.ExitCode())
+	assert.Contains(t, stderr.String(), ttydMissingMessage)
+	assert.NoFileExists(t, marker)
+	assert.ErrorIs(t, exitedContainerError("custom:latest", exitErr.ExitCode(), stderr.String()), ErrTTYDFailedToStart)
+}
+
+func TestReboundContainerConfig(t *testing.T) {
+	original := &container.Config{
+		Image: "devlab-go:latest",
+		Env:   []string{"PATH=/usr/bin", "TTYD_CREDENTIAL=devlab:old"},
+		Labels: map[string]string{
+			ManagedLabel:    "true",
+			SnapshotLabel:   "true",
+			ScenarioIDLabel: "scn-1",
+		},
+	}
+
+	rebound := reboundContainerConfig(original, "sha256:snapshot", RebindSpec{ScenarioID: "scn-1", TerminalUsername: "devlab", TerminalPassword: "new"}, nil)
+
+	assert.Equal(t, "sha256:snapshot", rebound.Image)
+	assert.Equal(t, []string{"PATH=/usr/bin", "TTYD_CREDENTIAL=devlab:new"}, rebound.Env)
+	assert.Equal(t, map[string]string{ManagedLabel: "true", ScenarioIDLabel: "scn-1"}, rebound.Labels)
+
+	// The inspected configuration is left untouched
+	assert.Equal(t, "devlab-go:latest", original.Image)
+	assert.Contains(t, original.Env, "TTYD_CREDENTIAL=devlab:old")
+	assert.Contains(t, original.Labels, SnapshotLabel)
+}
+
+func TestReboundContainerConfig_SkipsScenarioScript(t *testing.T) {
+	spec := ContainerSpec{ScenarioType: "go", Script: "git clone https://example.com/repo.git"}
+	original, err := interactiveContainerConfig("devlab-go:latest", spec, nil)
+	require.NoError(t, err)
+	require.True(t, isStartupCommand(original.Cmd))
+
+	script, err := renderStartupScript(nil, ContainerSpec{ScenarioType: spec.ScenarioType})
+	require.NoError(t, err)
+	rebound := reboundContainerConfig(original, "sha256:snapshot", RebindSpec{ScenarioID: "scn-1", ScenarioType: "go"}, startupCommand(script))
+
+	// ttyd still starts, but the setup the snapshot already holds is not repeated
+	assert.Contains(t, rebound.Cmd[2], "ttyd -p")
+	assert.NotContains(t, rebound.Cmd[2], spec.Script)
+	assert.Contains(t, original.Cmd[2], spec.Script)
+
+	// Command overrides are the container's own process and are kept
+	override, err := interactiveContainerConfig("custom:latest", ContainerSpec{Command: []string{"sleep", "infinity"}}, nil)
+	require.NoError(t, err)
+	assert.False(t, isStartupCommand(override.Cmd))
+}
+
+func TestWorkspaceOnTmpfs(t *testing.T) {
+	assert.False(t, workspaceOnTmpfs(nil))
+	assert.False(t, workspaceOnTmpfs(&container.HostConfig{}))
+
+	storageOpt := &container.HostConfig{}
+	applyDiskQuota(storageOpt, "2G", DiskQuotaStorageOpt)
+	assert.False(t, workspaceOnTmpfs(storageOpt))
+
+	tmpfsQuota := &container.HostConfig{}
+	applyDiskQuota(tmpfsQuota, "2G", DiskQuotaTmpfs)
+	assert.True(t, workspaceOnTmpfs(tmpfsQuota))
+
+	readonly := &container.HostConfig{}
+	RealClient{opts: Options{ReadonlyRootfs: true}}.applyReadonlyRootfs(readonly, "go")
+	assert.True(t, workspaceOnTmpfs(readonly))
+}
+
+func TestInspectError(t *testing.T) {
+	err := inspectError(client.ErrorConnectionFailed("unix:///var/run/docker.sock"))
+	assert.ErrorIs(t, err, ErrDockerDaemonUnavailable)
+	assert.NotErrorIs(t, err, ErrContainerNotFound)
+
+	err = inspectError(errors.New("No such container: abc"))
+	assert.ErrorIs(t, err, ErrContainerNotFound)
+	assert.NotErrorIs(t, err, ErrDockerDaemonUnavailable)
+}
+
+func TestErrorsRetryable(t *testing.T) {
+	tests := []struct {
+		err       error
+		retryable bool
+	}{
+		{ErrContainerNotFound, false},
+		{ErrContainerNotRunning, false},
+		{ErrPortUnavailable, true},
+		{ErrTTYDFailedToStart, false},
+		{ErrInvalidScenarioType, false},
+		{ErrDockerDaemonUnavailable, true},
+		{ErrContainerUserNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			assert.Equal(t, tt.retryable, retry.IsRetryable(tt.err))
+			assert.Equal(t, tt.retryable, retry.IsRetryable(fmt.Errorf("failed to provision container: %w", tt.err)))
+		})
+	}
+
+	assert.True(t, retry.IsRetryable(inspectError(client.ErrorConnectionFailed("unix:///var/run/docker.sock"))))
+	assert.False(t, retry.IsRetryable(inspectError(errors.New("No such container: abc"))))
+}
+
+func TestScenarioExecEnv(t *testing.T) {
+	for _, scenarioType := range []string{"k8s", "go-k8s", "python-k8s"} {
+		assert.Equal(t, []string{"KUBECONFIG=/home/devlab/.kube/config"}, ScenarioExecEnv(scenarioType), scenarioType)
+	}
+	for _, scenarioType := range []string{"go", "python", "docker", "unknown"} {
+		assert.Nil(t, ScenarioExecEnv(scenarioType), scenarioType)
+	}
+}
+
+func TestPruneStoppedContainers(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("prunes_by_label", func(t *testing.T) {
+		cli := &MockDockerClient{}
+		cli.On("ContainersPrune", ctx, mock.MatchedBy(func(args filters.Args) bool {
+			return args.Len() == 1 && args.ExactMatch("label", ManagedLabelFilter)
+		})).Return(types.ContainersPruneReport{
+			ContainersDeleted: []string{"c1", "c2"},
+			SpaceReclaimed:    2048,
+		}, nil)
+
+		report, err := pruneStoppedContainers(ctx, cli, ManagedLabelFilter)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c1", "c2"}, report.ContainersDeleted)
+		assert.Equal(t, uint64(2048), report.SpaceReclaimed)
+		cli.AssertExpectations(t)
+	})
+
+	t.Run("empty_filter_refused", func(t *testing.T) {
+		cli := &MockDockerClient{}
+
+		_, err := pruneStoppedContainers(ctx, cli, "")
+
+		assert.Error(t, err)
+		cli.AssertNotCalled(t, "ContainersPrune", mock.Anything, mock.Anything)
+	})
+
+	t.Run("prune_error", func(t *testing.T) {
+		cli := &MockDockerClient{}
+		cli.On("ContainersPrune", ctx, mock.Anything).Return(types.ContainersPruneReport{}, errors.New("daemon busy"))
+
+		_, err := pruneStoppedContainers(ctx, cli, ManagedLabelFilter)
+
+		assert.ErrorContains(t, err, "daemon busy")
+	})
+}
+
+func TestRemoveStoppedContainers(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("removes_only_named", func(t *testing.T) {
+		cli := &MockDockerClient{}
+		cli.On("ContainerRemove", ctx, "c1", container.RemoveOptions{}).Return(nil)
+		cli.On("ContainerRemove", ctx, "c2", container.RemoveOptions{}).Return(nil)
+
+		report, err := removeStoppedContainers(ctx, cli, []string{"c1", "c2"})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c1", "c2"}, report.ContainersDeleted)
+		cli.AssertExpectations(t)
+		cli.AssertNumberOfCalls(t, "ContainerRemove", 2)
+	})
+
+	t.Run("partial_failure", func(t *testing.T) {
+		cli := &MockDockerClient{}
+		cli.On("ContainerRemove", ctx, "c1", container.RemoveOptions{}).Return(errors.New("daemon busy"))
+		cli.On("ContainerRemove", ctx, "c2", container.RemoveOptions{}).Return(nil)
+
+		report, err := removeStoppedContainers(ctx, cli, []string{"c1", "c2"})
+
+		assert.ErrorContains(t, err, "c1: daemon busy")
+		assert.Equal(t, []string{"c2"}, report.ContainersDeleted)
 	})
 }
 
@@ -846,7 +2216,7 @@ func TestRealClient_ExecuteCommand(t *testing.T) {
 				ctx = context.Background()
 			}
 
-			output, err := client.ExecuteCommand(ctx, tt.containerID, tt.command)
+			output, err := client.ExecuteCommand(ctx, tt.containerID, tt.command, ExecuteCommandOpts{})
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -871,7 +2241,7 @@ func TestRealClient_ExecuteCommand_Integration(t *testing.T) {
 
 	// Start a test container
 	ctx := context.Background()
-	containerID, _, err := client.StartScenarioContainer(ctx, "go", "echo 'test container'")
+	containerID, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo 'test container'"})
 	if err != nil {
 		t.Skipf("Skipping test - failed to start test container: %v", err)
 	}
@@ -909,7 +2279,7 @@ func TestRealClient_ExecuteCommand_Integration(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			output, err := client.ExecuteCommand(ctx, containerID, tt.command)
+			output, err := client.ExecuteCommand(ctx, containerID, tt.command, ExecuteCommandOpts{})
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -980,7 +2350,7 @@ func TestRealClient_StopContainer_Integration(t *testing.T) {
 
 	// Start a test container
 	ctx := context.Background()
-	containerID, _, err := client.StartScenarioContainer(ctx, "go", "echo 'test container for stopping'")
+	containerID, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo 'test container for stopping'"})
 	if err != nil {
 		t.Skipf("Skipping test - failed to start test container: %v", err)
 	}
@@ -1033,7 +2403,7 @@ func TestRealClient_StopContainer_ErrorHandling(t *testing.T) {
 		}
 
 		ctx := context.Background()
-		containerID, _, err := client.StartScenarioContainer(ctx, "go", "echo 'test'")
+		containerID, _, err := client.StartScenarioContainer(ctx, ContainerSpec{ScenarioType: "go", Script: "echo 'test'"})
 		if err != nil {
 			t.Skipf("Skipping test - failed to start container: %v", err)
 		}
@@ -1048,3 +2418,270 @@ func TestRealClient_StopContainer_ErrorHandling(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestLogStream_Each(t *testing.T) {
+	collect := func(stream *LogStream) ([]LogLine, error) {
+		var lines []LogLine
+		err := stream.Each(func(line LogLine) error {
+			lines = append(lines, line)
+			return nil
+		})
+		return lines, err
+	}
+
+	t.Run("demultiplexes", func(t *testing.T) {
+		var raw bytes.Buffer
+		stdout := stdcopy.NewStdWriter(&raw, stdcopy.Stdout)
+		stderr := stdcopy.NewStdWriter(&raw, stdcopy.Stderr)
+		stdout.Write([]byte("building\nrun"))
+		stderr.Write([]byte("warning: slow\n"))
+		stdout.Write([]byte("ning\ndone"))
+
+		lines, err := collect(NewLogStream(io.NopCloser(&raw), false))
+		require.NoError(t, err)
+		assert.Equal(t, []LogLine{
+			{Stream: LogStreamStdout, Text: "building"},
+			{Stream: LogStreamStderr, Text: "warning: slow"},
+			{Stream: LogStreamStdout, Text: "running"},
+			{Stream: LogStreamStdout, Text: "done"},
+		}, lines)
+	})
+
+	t.Run("tty", func(t *testing.T) {
+		lines, err := collect(NewLogStream(io.NopCloser(strings.NewReader("$ ls\r\nmain.go\r\n")), true))
+		require.NoError(t, err)
+		assert.Equal(t, []LogLine{
+			{Stream: LogStreamStdout, Text: "$ ls"},
+			{Stream: LogStreamStdout, Text: "main.go"},
+		}, lines)
+	})
+
+	t.Run("splits_long_lines", func(t *testing.T) {
+		long := strings.Repeat("x", maxLogLineBytes+10)
+		lines, err := collect(NewLogStream(io.NopCloser(strings.NewReader(long+"\n")), true))
+		require.NoError(t, err)
+		require.Len(t, lines, 2)
+		assert.Len(t, lines[0].Text, maxLogLineBytes)
+		assert.Len(t, lines[1].Text, 10)
+	})
+
+	t.Run("consumer_error_stops", func(t *testing.T) {
+		stopped := errors.New("client gone")
+		calls := 0
+		err := NewLogStream(io.NopCloser(strings.NewReader("a\nb\nc\n")), true).Each(func(LogLine) error {
+			calls++
+			return stopped
+		})
+		assert.ErrorIs(t, err, stopped)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestTerminalURLFromPorts(t *testing.T) {
+	tests := []struct {
+		name     string
+		ports    []types.Port
+		expected string
+	}{
+		{name: "no_ports", expected: ""},
+		{
+			name:     "unpublished",
+			ports:    []types.Port{{PrivatePort: ttydContainerPort, Type: "tcp"}},
+			expected: "",
+		},
+		{
+			name:     "prefers_ipv4",
+			ports:    []types.Port{{IP: "::", PrivatePort: ttydContainerPort, PublicPort: 3001, Type: "tcp"}, {IP: "0.0.0.0", PrivatePort: ttydContainerPort, PublicPort: 3001, Type: "tcp"}},
+			expected: "http://0.0.0.0:3001",
+		},
+		{
+			name:     "ipv6_only",
+			ports:    []types.Port{{IP: "::", PrivatePort: ttydContainerPort, PublicPort: 3002, Type: "tcp"}},
+			expected: "http://[::]:3002",
+		},
+		{
+			name:     "ignores_other_ports",
+			ports:    []types.Port{{PrivatePort: 8080, PublicPort: 8080, Type: "tcp"}, {PrivatePort: ttydContainerPort, PublicPort: 3003, Type: "tcp"}},
+			expected: "http://localhost:3003",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := terminalURLFromPorts(tt.ports); got != tt.expected {
+				t.Errorf("terminalURLFromPorts() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestContainerInfoRunning(t *testing.T) {
+	for status, expected := range map[string]bool{
+		"Up 5 minutes":           true,
+		"Up 5 minutes (Paused)":  false,
+		"Exited (0) 2 hours ago": false,
+		"Created":                false,
+		"Up 2 seconds (healthy)": true,
+	} {
+		if got := (ContainerInfo{Status: status}).Running(); got != expected {
+			t.Errorf("Running() for %q = %v, want %v", status, got, expected)
+		}
+	}
+}
+
+func TestNewRealClient_Options(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		assert.Equal(t, Options{
+			StartReadinessTimeout: DefaultStartReadinessTimeout,
+			PortRangeStart:        DefaultPortRangeStart,
+			PortRangeEnd:          DefaultPortRangeEnd,
+			PullBackoff:           DefaultPullBackoff,
+		}, RealClient{}.Options())
+		c, err := NewRealClient(Options{})
+		require.NoError(t, err)
+		assert.Equal(t, RealClient{}.Options(), c.Options())
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		opts := Options{
+			ClientTimeout:         45 * time.Second,
+			StartReadinessTimeout: 2 * time.Second,
+			StopTimeout:           3 * time.Second,
+			PortRangeStart:        4001,
+			PortRangeEnd:          4010,
+			PullRetries:           3,
+			PullBackoff:           250 * time.Millisecond,
+		}
+		c, err := NewRealClient(opts)
+		require.NoError(t, err)
+		assert.Equal(t, opts, c.Options())
+	})
+
+	t.Run("inverted_port_range", func(t *testing.T) {
+		_, err := NewRealClient(Options{PortRangeStart: 4010, PortRangeEnd: 4001})
+		assert.ErrorIs(t, err, ErrInvalidOptions)
+	})
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	cfg := &config.Config{
+		DefaultScenarioImage: "devlab-go:latest",
+		RegistryAuth:         map[string]config.RegistryCredential{"registry.example.com": {Username: "ci", Password: "secret"}},
+		Docker:               config.DockerConfig{ClientTimeout: 45 * time.Second, PullRetries: 2},
+		Container: config.ContainerConfig{
+			PortRangeStart: 4001,
+			PortRangeEnd:   4010,
+			DNS:            []string{"10.0.0.53"},
+			Labels:         map[string]string{"team": "infra"},
+			DefaultLimits:  map[string]config.ResourceLimits{"go": {CPUs: 1, MemoryMB: 512}},
+			BlkioWeight:    300,
+			CapDrop:        []string{"NET_RAW"},
+		},
+	}
+
+	opts, err := OptionsFromConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Second, opts.ClientTimeout)
+	assert.Equal(t, 2, opts.PullRetries)
+	assert.Equal(t, 4001, opts.PortRangeStart)
+	assert.Equal(t, "devlab-go:latest", opts.DefaultImage)
+	assert.Equal(t, map[string]RegistryCredential{"registry.example.com": {Username: "ci", Password: "secret"}}, opts.RegistryAuth)
+	assert.Equal(t, []string{"10.0.0.53"}, opts.DNS)
+	assert.Equal(t, map[string]string{"team": "infra"}, opts.Labels)
+	assert.Equal(t, map[string]ResourceLimits{"go": {CPUs: 1, MemoryMB: 512}}, opts.DefaultLimits)
+	assert.Equal(t, uint16(300), opts.BlkioWeight)
+	// Security options only apply with hardening on
+	assert.Empty(t, opts.CapDrop)
+	assert.False(t, opts.NoNewPrivileges)
+
+	cfg.Container.Hardening = true
+	opts, err = OptionsFromConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"NET_RAW"}, opts.CapDrop)
+	assert.True(t, opts.NoNewPrivileges)
+
+	cfg.Container.Labels = map[string]string{"devlab.managed": "false"}
+	_, err = OptionsFromConfig(cfg)
+	assert.ErrorIs(t, err, ErrInvalidLabel)
+}
+
+func TestRealClient_NewClient(t *testing.T) {
+	c := RealClient{opts: Options{ClientTimeout: 45 * time.Second}}
+
+	cli, err := c.newClient()
+	require.NoError(t, err)
+	defer cli.Close()
+	assert.Equal(t, 45*time.Second, cli.HTTPClient().Timeout)
+
+	// Pulls, commands and log streams must not be cut off by the timeout
+	streaming, err := c.newStreamingClient()
+	require.NoError(t, err)
+	defer streaming.Close()
+	assert.Zero(t, streaming.HTTPClient().Timeout)
+}
+
+func TestRealClient_StopOptions(t *testing.T) {
+	assert.Nil(t, RealClient{}.stopOptions().Timeout)
+
+	timeout := RealClient{opts: Options{StopTimeout: 1500 * time.Millisecond}}.stopOptions().Timeout
+	require.NotNil(t, timeout)
+	assert.Equal(t, 2, *timeout)
+}
+
+func TestFindAvailablePort_CustomRange(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	c := RealClient{opts: Options{PortRangeStart: port, PortRangeEnd: port}}
+
+	_, err = c.findAvailablePort()
+	assert.ErrorIs(t, err, ErrPortUnavailable)
+	assert.ErrorContains(t, err, fmt.Sprintf("%d-%d", port, port))
+
+	require.NoError(t, ln.Close())
+	got, err := c.findAvailablePort()
+	require.NoError(t, err)
+	assert.Equal(t, port, got)
+}
+
+func TestPullImageWithRetry(t *testing.T) {
+	ctx := context.Background()
+	c := RealClient{opts: Options{PullRetries: 2, PullBackoff: time.Millisecond}}
+	registryTimeout := errors.New("net/http: TLS handshake timeout")
+	pulled := func() io.ReadCloser { return io.NopCloser(strings.NewReader(`{"status":"Downloaded"}`)) }
+
+	t.Run("retries_transient_failures", func(t *testing.T) {
+		cli := &MockDockerClient{}
+		cli.On("ImagePull", ctx, "devlab-go:latest", mock.Anything).Return(io.NopCloser(nil), registryTimeout).Twice()
+		cli.On("ImagePull", ctx, "devlab-go:latest", mock.Anything).Return(pulled(), nil).Once()
+
+		require.NoError(t, c.pullImageWithRetry(ctx, cli, "devlab-go:latest", ""))
+		cli.AssertNumberOfCalls(t, "ImagePull", 3)
+	})
+
+	t.Run("gives_up_after_retries", func(t *testing.T) {
+		cli := &MockDockerClient{}
+		cli.On("ImagePull", ctx, "devlab-go:latest", mock.Anything).Return(io.NopCloser(nil), registryTimeout)
+
+		err := c.pullImageWithRetry(ctx, cli, "devlab-go:latest", "")
+		assert.ErrorIs(t, err, registryTimeout)
+		cli.AssertNumberOfCalls(t, "ImagePull", 3)
+	})
+
+	t.Run("permanent_failure_not_retried", func(t *testing.T) {
+		cli := &MockDockerClient{}
+		cli.On("ImagePull", ctx, "missing:latest", mock.Anything).Return(io.NopCloser(nil), errdefs.NotFound(errors.New("manifest unknown")))
+
+		err := c.pullImageWithRetry(ctx, cli, "missing:latest", "")
+		assert.ErrorContains(t, err, "manifest unknown")
+		cli.AssertNumberOfCalls(t, "ImagePull", 1)
+	})
+
+	t.Run("no_retries_by_default", func(t *testing.T) {
+		cli := &MockDockerClient{}
+		cli.On("ImagePull", ctx, "devlab-go:latest", mock.Anything).Return(io.NopCloser(nil), registryTimeout)
+
+		assert.Error(t, RealClient{}.pullImageWithRetry(ctx, cli, "devlab-go:latest", ""))
+		cli.AssertNumberOfCalls(t, "ImagePull", 1)
+	})
+}
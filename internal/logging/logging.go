@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/rs/zerolog"
+	zerologlog "github.com/rs/zerolog/log"
+)
+
+// ParseLevel maps a LOG_LEVEL value (debug, info, warn, error) to a zerolog level
+func ParseLevel(level string) (zerolog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return zerolog.DebugLevel, nil
+	case "", "info":
+		return zerolog.InfoLevel, nil
+	case "warn", "warning":
+		return zerolog.WarnLevel, nil
+	case "error":
+		return zerolog.ErrorLevel, nil
+	default:
+		return zerolog.NoLevel, fmt.Errorf("invalid log level %q: must be one of debug, info, warn, error", level)
+	}
+}
+
+// Configure applies level to the zerolog global logger and routes the standard
+// library logger through zerolog so it honours the same setting
+func Configure(level string) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	zerolog.SetGlobalLevel(lvl)
+	log.SetFlags(0)
+	log.SetOutput(stdLogWriter{})
+	return nil
+}
+
+// stdLogWriter forwards standard library log lines to the current zerolog global logger
+type stdLogWriter struct{}
+
+func (stdLogWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\n")
+	zerologlog.WithLevel(stdLevel(msg)).Msg(msg)
+	return len(p), nil
+}
+
+// Keywords that mark a standard library log line as a warning or an error.
+// Warnings are checked first, so "retrying ... after transient error" is not
+// reported as a failure.
+var (
+	stdWarnKeywords  = []string{"warning", "retrying", "requeueing", "refusing", "timed out"}
+	stdErrorKeywords = []string{"failed", "failure", "error", "panic", "could not"}
+)
+
+// stdLevel infers the severity of a standard library log line from its
+// wording, so failures logged with log.Printf survive LOG_LEVEL=warn or error
+func stdLevel(msg string) zerolog.Level {
+	lower := strings.ToLower(msg)
+	for _, keyword := range stdWarnKeywords {
+		if strings.Contains(lower, keyword) {
+			return zerolog.WarnLevel
+		}
+	}
+	for _, keyword := range stdErrorKeywords {
+		if strings.Contains(lower, keyword) {
+			return zerolog.ErrorLevel
+		}
+	}
+	return zerolog.InfoLevel
+}
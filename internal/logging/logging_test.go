@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+	zerologlog "github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    zerolog.Level
+		expectError bool
+	}{
+		{input: "debug", expected: zerolog.DebugLevel},
+		{input: "", expected: zerolog.InfoLevel},
+		{input: "INFO", expected: zerolog.InfoLevel},
+		{input: "warn", expected: zerolog.WarnLevel},
+		{input: "error", expected: zerolog.ErrorLevel},
+		{input: "verbose", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			level, err := ParseLevel(tt.input)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, level)
+		})
+	}
+}
+
+func TestConfigure_FiltersLowerSeverity(t *testing.T) {
+	originalLogger := zerologlog.Logger
+	originalLevel := zerolog.GlobalLevel()
+	defer func() {
+		zerologlog.Logger = originalLogger
+		zerolog.SetGlobalLevel(originalLevel)
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	var buf bytes.Buffer
+	zerologlog.Logger = zerolog.New(&buf)
+
+	require.NoError(t, Configure("warn"))
+	zerologlog.Debug().Msg("debug line")
+	zerologlog.Info().Msg("info line")
+	log.Printf("[docker] std info line")
+	zerologlog.Warn().Msg("warn line")
+	zerologlog.Error().Msg("error line")
+
+	output := buf.String()
+	assert.NotContains(t, output, "debug line")
+	assert.NotContains(t, output, "info line")
+	assert.Contains(t, output, "warn line")
+	assert.Contains(t, output, "error line")
+
+	buf.Reset()
+	require.NoError(t, Configure("debug"))
+	zerologlog.Debug().Msg("debug line")
+	log.Printf("[docker] std info line")
+
+	output = buf.String()
+	assert.Contains(t, output, "debug line")
+	assert.Contains(t, output, `"level":"info","message":"[docker] std info line"`)
+
+	assert.Error(t, Configure("loud"))
+}
+
+func TestConfigure_KeepsStdLibErrorsAtErrorLevel(t *testing.T) {
+	originalLogger := zerologlog.Logger
+	originalLevel := zerolog.GlobalLevel()
+	defer func() {
+		zerologlog.Logger = originalLogger
+		zerolog.SetGlobalLevel(originalLevel)
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	var buf bytes.Buffer
+	zerologlog.Logger = zerolog.New(&buf)
+
+	require.NoError(t, Configure("error"))
+	log.Printf("[docker] started container: abc with ttyd on port 3001")
+	log.Printf("[docker] retrying pull of image devlab-go:latest after transient error (attempt 1 of 3): timeout")
+	log.Printf("[scenario] failed to get scenario from DB: connection refused")
+
+	output := buf.String()
+	assert.NotContains(t, output, "started container")
+	assert.NotContains(t, output, "retrying pull")
+	assert.Contains(t, output, `"level":"error","message":"[scenario] failed to get scenario from DB: connection refused"`)
+}
+
+func TestStdLevel(t *testing.T) {
+	tests := []struct {
+		msg      string
+		expected zerolog.Level
+	}{
+		{msg: "[scenario] scenario abc stopped successfully", expected: zerolog.InfoLevel},
+		{msg: "[docker] WARNING: daemon warning creating container abc: low memory", expected: zerolog.WarnLevel},
+		{msg: "[storage] retrying update of scenario abc after transient error (attempt 1 of 3): timeout", expected: zerolog.WarnLevel},
+		{msg: "[docker] failed to stop container abc: boom", expected: zerolog.ErrorLevel},
+		{msg: "[cleanup] error cleaning up orphaned containers: boom", expected: zerolog.ErrorLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			assert.Equal(t, tt.expected, stdLevel(tt.msg))
+		})
+	}
+}
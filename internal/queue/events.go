@@ -0,0 +1,20 @@
+package queue
+
+import "time"
+
+// ScenarioEventsQueue carries scenario lifecycle notifications for other services
+const ScenarioEventsQueue = "scenario_events"
+
+// Event types published on ScenarioEventsQueue and sent to cleanup webhooks
+const (
+	EventScenarioExpiringSoon = "scenario.expiring_soon"
+	EventScenarioCleanedUp    = "scenario.cleaned_up"
+)
+
+// ScenarioEvent is the JSON message published on ScenarioEventsQueue
+type ScenarioEvent struct {
+	Event      string    `json:"event"`
+	ScenarioID string    `json:"scenario_id"`
+	UserID     string    `json:"user_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
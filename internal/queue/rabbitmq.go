@@ -2,11 +2,13 @@ package queue
 
 import (
 	"context"
+	"devlab/internal/retry"
 	"encoding/json"
 	"fmt"
 	"log"
 
 	amqp "github.com/rabbitmq/amqp091-go"
+	zerologlog "github.com/rs/zerolog/log"
 )
 
 // QueueManager handles RabbitMQ operations
@@ -44,37 +46,51 @@ func (qm *QueueManager) Close() error {
 	return nil
 }
 
-// PublishMessage publishes a message to a queue
+// PublishMessage publishes a message to a queue. The trace context of ctx is
+// sent in the message headers so consumers continue the same trace.
 func (qm *QueueManager) PublishMessage(ctx context.Context, queueName string, message interface{}) error {
 	body, err := json.Marshal(message)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
+	ctx, span, headers := startPublishSpan(ctx, queueName)
+	defer span.End()
+
 	err = qm.channel.PublishWithContext(ctx,
 		"",        // exchange
 		queueName, // routing key
 		false,     // mandatory
 		false,     // immediate
 		amqp.Publishing{
+			Headers:     headers,
 			ContentType: "application/json",
 			Body:        body,
 		})
 
 	if err != nil {
+		span.RecordError(err)
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
 
-	log.Printf("[queue] published message to queue: %s", queueName)
+	zerologlog.Debug().Msgf("[queue] published message to queue: %s", queueName)
 	return nil
 }
 
 // ConsumeMessages consumes messages from a queue
 func (qm *QueueManager) ConsumeMessages(ctx context.Context, queueName string, handler func([]byte) error) error {
+	return qm.ConsumeMessagesWithContext(ctx, queueName, func(_ context.Context, body []byte) error {
+		return handler(body)
+	})
+}
+
+// ConsumeMessagesWithContext consumes messages from a queue, passing handler a
+// context that continues the trace the message was published under
+func (qm *QueueManager) ConsumeMessagesWithContext(ctx context.Context, queueName string, handler func(context.Context, []byte) error) error {
 	msgs, err := qm.channel.Consume(
 		queueName, // queue
 		"",        // consumer
-		true,      // auto-ack
+		false,     // auto-ack
 		false,     // exclusive
 		false,     // no-local
 		false,     // no-wait
@@ -91,9 +107,7 @@ func (qm *QueueManager) ConsumeMessages(ctx context.Context, queueName string, h
 				log.Printf("[queue] stopping consumer for queue: %s", queueName)
 				return
 			case msg := <-msgs:
-				if err := handler(msg.Body); err != nil {
-					log.Printf("[queue] error handling message: %v", err)
-				}
+				handleDelivery(ctx, queueName, msg, handler)
 			}
 		}
 	}()
@@ -102,6 +116,37 @@ func (qm *QueueManager) ConsumeMessages(ctx context.Context, queueName string, h
 	return nil
 }
 
+// handleDelivery runs handler for msg inside a consumer span linked to the
+// publisher's trace. A message whose handler fails with a retryable error is
+// requeued once; any other outcome acknowledges it so it is not redelivered.
+func handleDelivery(ctx context.Context, queueName string, msg amqp.Delivery, handler func(context.Context, []byte) error) {
+	ctx, span := startConsumeSpan(ctx, queueName, msg.Headers)
+	defer span.End()
+
+	err := handler(ctx, msg.Body)
+	if err == nil {
+		ackDelivery(msg)
+		return
+	}
+
+	span.RecordError(err)
+	if retry.IsRetryable(err) && !msg.Redelivered {
+		log.Printf("[queue] retryable error handling message, requeueing: %v", err)
+		if nackErr := msg.Nack(false, true); nackErr != nil {
+			log.Printf("[queue] failed to requeue message: %v", nackErr)
+		}
+		return
+	}
+	log.Printf("[queue] error handling message: %v", err)
+	ackDelivery(msg)
+}
+
+func ackDelivery(msg amqp.Delivery) {
+	if err := msg.Ack(false); err != nil {
+		log.Printf("[queue] failed to acknowledge message: %v", err)
+	}
+}
+
 // DeclareQueue declares a queue if it doesn't exist
 func (qm *QueueManager) DeclareQueue(queueName string) error {
 	_, err := qm.channel.QueueDeclare(
@@ -2,9 +2,12 @@ package queue
 
 import (
 	"context"
+	"devlab/internal/docker"
+	"fmt"
 	"testing"
 	"time"
 
+	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -160,3 +163,52 @@ func TestRabbitMQMessageDelivery(t *testing.T) {
 
 	assert.Len(t, received, len(messages), "Should receive all published messages")
 }
+
+// recordingAcknowledger records how a delivery was settled
+type recordingAcknowledger struct {
+	acked, requeued bool
+}
+
+func (a *recordingAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.acked = true
+	return nil
+}
+
+func (a *recordingAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.requeued = requeue
+	return nil
+}
+
+func (a *recordingAcknowledger) Reject(tag uint64, requeue bool) error {
+	a.requeued = requeue
+	return nil
+}
+
+func TestHandleDelivery_Settlement(t *testing.T) {
+	tests := []struct {
+		name           string
+		handlerErr     error
+		redelivered    bool
+		expectAck      bool
+		expectRequeued bool
+	}{
+		{name: "success", expectAck: true},
+		{name: "retryable_error", handlerErr: fmt.Errorf("start failed: %w", docker.ErrDockerDaemonUnavailable), expectRequeued: true},
+		{name: "retryable_error_redelivered", handlerErr: docker.ErrPortUnavailable, redelivered: true, expectAck: true},
+		{name: "permanent_error", handlerErr: fmt.Errorf("start failed: %w", docker.ErrInvalidScenarioType), expectAck: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acknowledger := &recordingAcknowledger{}
+			msg := amqp.Delivery{Acknowledger: acknowledger, Redelivered: tt.redelivered, Body: []byte(`{}`)}
+
+			handleDelivery(context.Background(), ScenarioEventsQueue, msg, func(context.Context, []byte) error {
+				return tt.handlerErr
+			})
+
+			assert.Equal(t, tt.expectAck, acknowledger.acked)
+			assert.Equal(t, tt.expectRequeued, acknowledger.requeued)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package queue
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "devlab/internal/queue"
+
+// headerCarrier adapts AMQP message headers to an OpenTelemetry TextMapCarrier
+// so trace context travels with each message
+type headerCarrier amqp.Table
+
+var _ propagation.TextMapCarrier = headerCarrier{}
+
+func (c headerCarrier) Get(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// startPublishSpan starts the producer span for a message to queueName and
+// returns the headers carrying its trace context
+func startPublishSpan(ctx context.Context, queueName string) (context.Context, trace.Span, amqp.Table) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "publish "+queueName,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("messaging.destination.name", queueName)),
+	)
+	headers := amqp.Table{}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(headers))
+	return ctx, span, headers
+}
+
+// startConsumeSpan continues the trace carried in headers with a consumer span
+// for a message from queueName
+func startConsumeSpan(ctx context.Context, queueName string, headers amqp.Table) (context.Context, trace.Span) {
+	if headers != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier(headers))
+	}
+	return otel.Tracer(tracerName).Start(ctx, "process "+queueName,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.String("messaging.destination.name", queueName)),
+	)
+}
@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"context"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// useRecordingTracer installs a tracer provider that records ended spans and
+// the W3C propagator for the duration of the test
+func useRecordingTracer(t *testing.T) (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return provider, recorder
+}
+
+func TestTraceContextPropagatesThroughMessageHeaders(t *testing.T) {
+	provider, recorder := useRecordingTracer(t)
+
+	// Producer side: publish inside an existing request trace
+	requestCtx, requestSpan := provider.Tracer("test").Start(context.Background(), "start scenario")
+	_, publishSpan, headers := startPublishSpan(requestCtx, ScenarioEventsQueue)
+	publishSpan.End()
+	requestSpan.End()
+	assert.NotEmpty(t, headers["traceparent"], "published headers carry the trace context")
+
+	// Consumer side: a fresh context, as in another process
+	var handlerSpan trace.SpanContext
+	handleDelivery(context.Background(), ScenarioEventsQueue, amqp.Delivery{Headers: headers, Body: []byte(`{}`)},
+		func(ctx context.Context, body []byte) error {
+			handlerSpan = trace.SpanContextFromContext(ctx)
+			return nil
+		})
+
+	require.True(t, handlerSpan.IsValid())
+	assert.Equal(t, requestSpan.SpanContext().TraceID(), handlerSpan.TraceID(), "consumer continues the producer's trace")
+
+	var processSpan sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.Name() == "process "+ScenarioEventsQueue {
+			processSpan = s
+		}
+	}
+	require.NotNil(t, processSpan)
+	assert.Equal(t, trace.SpanKindConsumer, processSpan.SpanKind())
+	assert.Equal(t, publishSpan.SpanContext().SpanID(), processSpan.Parent().SpanID())
+}
+
+func TestHandleDelivery_WithoutTraceHeaders(t *testing.T) {
+	useRecordingTracer(t)
+
+	called := false
+	handleDelivery(context.Background(), ScenarioEventsQueue, amqp.Delivery{Body: []byte(`{}`)},
+		func(ctx context.Context, body []byte) error {
+			called = true
+			assert.True(t, trace.SpanContextFromContext(ctx).IsValid(), "a new trace is started")
+			return nil
+		})
+	assert.True(t, called)
+}
@@ -0,0 +1,30 @@
+package retry
+
+import "errors"
+
+// retryableError is a sentinel error whose cause is expected to be transient
+type retryableError struct {
+	msg string
+}
+
+func (e *retryableError) Error() string {
+	return e.msg
+}
+
+// Retryable marks the error as worth retrying
+func (e *retryableError) Retryable() bool {
+	return true
+}
+
+// New returns a sentinel error classified as retryable. Like errors.New, each
+// call returns a distinct value, so errors.Is matches only that sentinel.
+func New(text string) error {
+	return &retryableError{msg: text}
+}
+
+// IsRetryable reports whether any error in err's chain is marked retryable.
+// Errors that carry no classification, including nil, are not retryable.
+func IsRetryable(err error) bool {
+	var r interface{ Retryable() bool }
+	return errors.As(err, &r) && r.Retryable()
+}
@@ -0,0 +1,42 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	transient := New("daemon unavailable")
+	permanent := errors.New("invalid input")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "nil", err: nil, expected: false},
+		{name: "retryable_sentinel", err: transient, expected: true},
+		{name: "plain_error", err: permanent, expected: false},
+		{name: "wrapped_retryable", err: fmt.Errorf("failed to start: %w", transient), expected: true},
+		{name: "double_wrapped_retryable", err: fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", transient)), expected: true},
+		{name: "wrapped_plain", err: fmt.Errorf("failed: %w", permanent), expected: false},
+		{name: "joined_with_retryable", err: errors.Join(permanent, transient), expected: true},
+		{name: "formatted_not_wrapped", err: fmt.Errorf("failed: %v", transient), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestNew_DistinctSentinels(t *testing.T) {
+	a, b := New("busy"), New("busy")
+	assert.ErrorIs(t, fmt.Errorf("wrapped: %w", a), a)
+	assert.NotErrorIs(t, a, b)
+	assert.Equal(t, "busy", a.Error())
+}
@@ -0,0 +1,17 @@
+package scenario
+
+import (
+	zerologlog "github.com/rs/zerolog/log"
+)
+
+// auditLog records a privileged action as a structured log entry tagged
+// audit=true, so it can be routed to the audit trail by the log pipeline
+func auditLog(action, actorID, scenarioID string, fields map[string]interface{}) {
+	zerologlog.Info().
+		Bool("audit", true).
+		Str("action", action).
+		Str("actor_id", actorID).
+		Str("scenario_id", scenarioID).
+		Fields(fields).
+		Msg("audit: " + action)
+}
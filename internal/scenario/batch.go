@@ -0,0 +1,182 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/docker"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// watchBatch runs completeBatch for a batch scenario unless this process is
+// already waiting on it
+func (m *Manager) watchBatch(ctx context.Context, scenarioID, containerID string, timeout time.Duration) {
+	if _, watched := m.batches.LoadOrStore(scenarioID, struct{}{}); watched {
+		return
+	}
+	defer m.batches.Delete(scenarioID)
+	m.completeBatch(ctx, scenarioID, containerID, timeout)
+}
+
+// ReconcileBatches settles running batch scenarios no process is waiting on,
+// as happens when the API restarts while their scripts run. Scripts that have
+// already exited are completed now; the rest are waited on for what remains
+// of their timeout.
+func (m *Manager) ReconcileBatches(ctx context.Context) error {
+	scenarios, err := m.store().ListScenariosByStatus(ctx, types.ScenarioStatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to list running scenarios: %w", err)
+	}
+
+	for _, scenario := range scenarios {
+		if scenario.Mode != types.ScenarioModeBatch {
+			continue
+		}
+		if _, watched := m.batches.Load(scenario.ScenarioID); watched {
+			continue
+		}
+
+		exists, err := m.Docker.ContainerExists(ctx, scenario.ContainerID)
+		if err != nil {
+			log.Printf("[scenario] failed to check batch container %s: %v", scenario.ContainerID, err)
+			continue
+		}
+		containerStatus := ""
+		if exists {
+			if containerStatus, err = m.Docker.GetContainerStatus(ctx, scenario.ContainerID); err != nil {
+				log.Printf("[scenario] failed to get status of batch container %s: %v", scenario.ContainerID, err)
+				continue
+			}
+		}
+
+		if !exists || containerStatus == "exited" || containerStatus == "dead" {
+			log.Printf("[scenario] completing unwatched batch scenario %s", scenario.ScenarioID)
+			m.watchBatch(ctx, scenario.ScenarioID, scenario.ContainerID, 0)
+			continue
+		}
+
+		timeout := scenario.ScriptTimeout
+		if timeout > 0 {
+			// An overdue script is given a moment to report before it is stopped
+			timeout = max(timeout-time.Since(scenario.CreatedAt), time.Millisecond)
+		}
+		log.Printf("[scenario] resuming wait on batch scenario %s", scenario.ScenarioID)
+		go m.watchBatch(context.WithoutCancel(ctx), scenario.ScenarioID, scenario.ContainerID, timeout)
+	}
+	return nil
+}
+
+// completeBatch waits for a batch scenario's script to exit, records its
+// output and exit code on the scenario, and removes the container. A script
+// still running after timeout (when non-zero) is stopped and recorded as a
+// timed-out failure. A scenario stopped while its script was still running
+// keeps its stopped status.
+func (m *Manager) completeBatch(ctx context.Context, scenarioID, containerID string, timeout time.Duration) {
+	result, timedOut, waitErr := m.waitForScript(ctx, containerID, timeout)
+	if waitErr != nil {
+		log.Printf("[scenario] failed to collect result of batch scenario %s: %v", scenarioID, waitErr)
+	}
+
+	defer func() {
+		if err := m.Docker.RemoveContainer(ctx, containerID); err != nil && !errors.Is(err, docker.ErrContainerNotFound) {
+			log.Printf("[scenario] failed to remove batch container %s: %v", containerID, err)
+		}
+	}()
+
+	scenario, err := m.store().GetScenario(ctx, scenarioID)
+	if err != nil {
+		log.Printf("[scenario] failed to load batch scenario %s: %v", scenarioID, err)
+		return
+	}
+	if scenario.Status != types.ScenarioStatusRunning {
+		log.Printf("[scenario] batch scenario %s is already %s, not recording result", scenarioID, scenario.Status)
+		return
+	}
+
+	now := time.Now()
+	scenario.Result = &storage.ScenarioResult{
+		Stdout:      result.Stdout,
+		Stderr:      result.Stderr,
+		ExitCode:    result.ExitCode,
+		TimedOut:    timedOut,
+		CompletedAt: now,
+	}
+	if waitErr != nil {
+		scenario.Result.ExitCode = -1
+	}
+
+	switch {
+	case waitErr != nil:
+		scenario.SetStatus(types.ScenarioStatusFailed, "failed to wait for script: "+waitErr.Error())
+		markStopReason(scenario, types.StopReasonFailed)
+	case timedOut:
+		scenario.SetStatus(types.ScenarioStatusFailed, "script timed out")
+		markStopReason(scenario, types.StopReasonFailed)
+	case result.ExitCode != 0:
+		scenario.SetStatus(types.ScenarioStatusFailed, fmt.Sprintf("script exited with code %d", result.ExitCode))
+		markStopReason(scenario, types.StopReasonFailed)
+	default:
+		scenario.SetStatus(types.ScenarioStatusCompleted, "script exited with code 0")
+	}
+	scenario.UpdatedAt = now
+	if err := m.updateStatus(ctx, scenario); err != nil {
+		log.Printf("[scenario] failed to record result of batch scenario %s: %v", scenarioID, err)
+		return
+	}
+
+	log.Printf("[scenario] batch scenario %s %s with exit code %d", scenarioID, scenario.Status, scenario.Result.ExitCode)
+}
+
+// waitForScript waits for a batch container to exit. Once timeout elapses the
+// container is stopped, which kills the script, and its partial output is
+// collected instead.
+func (m *Manager) waitForScript(ctx context.Context, containerID string, timeout time.Duration) (docker.ExitResult, bool, error) {
+	if timeout <= 0 {
+		result, err := m.Docker.WaitForExit(ctx, containerID)
+		return result, false, err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := m.Docker.WaitForExit(waitCtx, containerID)
+	if err == nil || !errors.Is(waitCtx.Err(), context.DeadlineExceeded) || ctx.Err() != nil {
+		return result, false, err
+	}
+
+	log.Printf("[scenario] batch script in container %s exceeded its %s timeout, stopping it", containerID, timeout)
+	if err := m.Docker.StopContainer(ctx, containerID); err != nil && !errors.Is(err, docker.ErrContainerNotFound) {
+		log.Printf("[scenario] failed to stop timed out batch container %s: %v", containerID, err)
+	}
+	result, err = m.Docker.WaitForExit(ctx, containerID)
+	return result, true, err
+}
+
+// GetScenarioResults returns the captured output of a batch scenario owned by
+// userID. Interactive scenarios have no results.
+func (m *Manager) GetScenarioResults(ctx context.Context, scenarioID, userID string) (*types.ScenarioResultsResponse, error) {
+	scenario, err := m.getOwnedScenario(ctx, scenarioID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if scenario.Result == nil {
+		if scenario.Mode == types.ScenarioModeBatch && scenario.Status.Active() {
+			return nil, fmt.Errorf("%w: scenario status is %s", ErrResultsNotReady, scenario.Status)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrNoResults, scenarioID)
+	}
+
+	return &types.ScenarioResultsResponse{
+		ScenarioID:  scenarioID,
+		Status:      scenario.Status,
+		Stdout:      scenario.Result.Stdout,
+		Stderr:      scenario.Result.Stderr,
+		ExitCode:    scenario.Result.ExitCode,
+		TimedOut:    scenario.Result.TimedOut,
+		CompletedAt: scenario.Result.CompletedAt,
+		Message:     "Scenario results retrieved successfully",
+	}, nil
+}
@@ -0,0 +1,295 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/docker"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newBatchScenario(id string) *storage.Scenario {
+	return &storage.Scenario{
+		ScenarioID:  id,
+		UserID:      "test-user",
+		ContainerID: "container-" + id,
+		Status:      types.ScenarioStatusRunning,
+		Mode:        types.ScenarioModeBatch,
+	}
+}
+
+func TestStartScenario_BatchRunsToCompletion(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	store := storage.NewMemoryStore()
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	mockDocker.On("StartScenarioContainer", mock.Anything, mock.MatchedBy(func(spec docker.ContainerSpec) bool {
+		return spec.Batch && spec.Script == "go run ." && spec.TerminalPassword == ""
+	})).Return("batch-container", 0, nil)
+	mockDocker.On("WaitForExit", mock.Anything, "batch-container").
+		Return(docker.ExitResult{ExitCode: 0, Stdout: "hello\n"}, nil)
+	mockDocker.On("RemoveContainer", mock.Anything, "batch-container").Return(nil)
+
+	resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+		UserID:       "test-user",
+		ScenarioType: "go",
+		Script:       "go run .",
+		Mode:         types.ScenarioModeBatch,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusRunning, resp.Status)
+
+	require.Eventually(t, func() bool {
+		s, err := store.GetScenario(context.Background(), resp.ScenarioID)
+		return err == nil && s.Status == types.ScenarioStatusCompleted
+	}, time.Second, 10*time.Millisecond)
+
+	s, err := store.GetScenario(context.Background(), resp.ScenarioID)
+	require.NoError(t, err)
+	require.NotNil(t, s.Result)
+	assert.Equal(t, "hello\n", s.Result.Stdout)
+	assert.Zero(t, s.Result.ExitCode)
+	assert.False(t, s.Result.CompletedAt.IsZero())
+	assert.Empty(t, s.StopReason)
+}
+
+func TestCompleteBatch_NonZeroExitRecordsFailure(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	store := storage.NewMemoryStore(newBatchScenario("scn-1"))
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	mockDocker.On("WaitForExit", mock.Anything, "container-scn-1").
+		Return(docker.ExitResult{ExitCode: 2, Stdout: "building\n", Stderr: "undefined: foo\n"}, nil)
+	mockDocker.On("RemoveContainer", mock.Anything, "container-scn-1").Return(nil)
+
+	manager.completeBatch(context.Background(), "scn-1", "container-scn-1", 0)
+
+	s, err := store.GetScenario(context.Background(), "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusFailed, s.Status)
+	assert.Equal(t, types.StopReasonFailed, s.StopReason)
+	require.NotNil(t, s.Result)
+	assert.Equal(t, 2, s.Result.ExitCode)
+	assert.Equal(t, "undefined: foo\n", s.Result.Stderr)
+	mockDocker.AssertExpectations(t)
+}
+
+func TestCompleteBatch_TimeoutKillsScript(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	store := storage.NewMemoryStore(newBatchScenario("scn-1"))
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	// The script sleeps forever: the first wait only returns once its deadline passes
+	mockDocker.On("WaitForExit", mock.Anything, "container-scn-1").
+		Run(func(args mock.Arguments) { <-args.Get(0).(context.Context).Done() }).
+		Return(docker.ExitResult{}, context.DeadlineExceeded).Once()
+	mockDocker.On("StopContainer", mock.Anything, "container-scn-1").Return(nil)
+	mockDocker.On("WaitForExit", mock.Anything, "container-scn-1").
+		Return(docker.ExitResult{ExitCode: 137, Stdout: "sleeping\n"}, nil).Once()
+	mockDocker.On("RemoveContainer", mock.Anything, "container-scn-1").Return(nil)
+
+	started := time.Now()
+	manager.completeBatch(context.Background(), "scn-1", "container-scn-1", 50*time.Millisecond)
+
+	assert.GreaterOrEqual(t, time.Since(started), 50*time.Millisecond)
+	assert.Less(t, time.Since(started), 5*time.Second)
+	s, err := store.GetScenario(context.Background(), "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusFailed, s.Status)
+	assert.Equal(t, types.StopReasonFailed, s.StopReason)
+	require.NotNil(t, s.Result)
+	assert.True(t, s.Result.TimedOut)
+	assert.Equal(t, 137, s.Result.ExitCode)
+	assert.Equal(t, "sleeping\n", s.Result.Stdout)
+	mockDocker.AssertExpectations(t)
+}
+
+func TestStartScenario_ScriptTimeout(t *testing.T) {
+	tests := []struct {
+		name            string
+		requested       int
+		configured      time.Duration
+		limit           time.Duration
+		expectedTimeout time.Duration
+		expectError     bool
+	}{
+		{name: "unset", expectedTimeout: 0},
+		{name: "config_default", configured: time.Minute, expectedTimeout: time.Minute},
+		{name: "request_overrides_default", requested: 30, configured: time.Minute, expectedTimeout: 30 * time.Second},
+		{name: "request_capped", requested: 7200, limit: time.Hour, expectedTimeout: time.Hour},
+		{name: "default_capped", configured: 2 * time.Hour, limit: time.Hour, expectedTimeout: time.Hour},
+		{name: "negative_rejected", requested: -1, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDocker := &MockDockerClient{}
+			store := storage.NewMemoryStore()
+			cfg := &config.Config{}
+			cfg.Container.ScriptTimeout = tt.configured
+			cfg.Container.MaxScriptTimeout = tt.limit
+			manager := &Manager{Cfg: cfg, Docker: mockDocker, Store: store}
+			mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).Return("container-1", 3001, nil)
+
+			resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+				UserID:               "test-user",
+				ScenarioType:         "go",
+				ScriptTimeoutSeconds: tt.requested,
+			})
+
+			if tt.expectError {
+				assert.ErrorIs(t, err, ErrInvalidScriptTimeout)
+				mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			stored, err := store.GetScenario(context.Background(), resp.ScenarioID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedTimeout, stored.ScriptTimeout)
+		})
+	}
+}
+
+func TestCompleteBatch_KeepsUserStop(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	stopped := newBatchScenario("scn-1")
+	stopped.Status = types.ScenarioStatusStopped
+	stopped.StopReason = types.StopReasonUserRequested
+	store := storage.NewMemoryStore(stopped)
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	mockDocker.On("WaitForExit", mock.Anything, "container-scn-1").
+		Return(docker.ExitResult{}, docker.ErrContainerNotFound)
+	mockDocker.On("RemoveContainer", mock.Anything, "container-scn-1").Return(docker.ErrContainerNotFound)
+
+	manager.completeBatch(context.Background(), "scn-1", "container-scn-1", 0)
+
+	s, err := store.GetScenario(context.Background(), "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusStopped, s.Status)
+	assert.Equal(t, types.StopReasonUserRequested, s.StopReason)
+	assert.Nil(t, s.Result)
+}
+
+func TestGetScenarioStatus_BatchSkipsContainerChecks(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	completed := newBatchScenario("scn-1")
+	completed.Status = types.ScenarioStatusCompleted
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore(completed)}
+
+	resp, err := manager.GetScenarioStatus(context.Background(), "scn-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusCompleted, resp.Status)
+	mockDocker.AssertNotCalled(t, "ContainerExists", mock.Anything, mock.Anything)
+}
+
+func TestStartScenario_InvalidMode(t *testing.T) {
+	manager := &Manager{Cfg: &config.Config{}, Docker: &MockDockerClient{}, Store: storage.NewMemoryStore()}
+
+	_, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+		UserID:       "test-user",
+		ScenarioType: "go",
+		Mode:         "once",
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidScenarioMode)
+}
+
+func TestGetScenarioResults(t *testing.T) {
+	completedAt := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	completed := newBatchScenario("batch-done")
+	completed.Status = types.ScenarioStatusCompleted
+	completed.Result = &storage.ScenarioResult{Stdout: "ok\n", ExitCode: 0, CompletedAt: completedAt}
+	running := newBatchScenario("batch-running")
+	interactive := &storage.Scenario{ScenarioID: "interactive", UserID: "test-user", Status: types.ScenarioStatusRunning}
+
+	manager := &Manager{
+		Cfg:    &config.Config{},
+		Docker: &MockDockerClient{},
+		Store:  storage.NewMemoryStore(completed, running, interactive),
+	}
+	ctx := context.Background()
+
+	resp, err := manager.GetScenarioResults(ctx, "batch-done", "test-user")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusCompleted, resp.Status)
+	assert.Equal(t, "ok\n", resp.Stdout)
+	assert.Equal(t, completedAt, resp.CompletedAt)
+
+	_, err = manager.GetScenarioResults(ctx, "batch-running", "test-user")
+	assert.ErrorIs(t, err, ErrResultsNotReady)
+
+	_, err = manager.GetScenarioResults(ctx, "interactive", "test-user")
+	assert.ErrorIs(t, err, ErrNoResults)
+
+	_, err = manager.GetScenarioResults(ctx, "batch-done", "other-user")
+	assert.ErrorIs(t, err, ErrNotScenarioOwner)
+}
+
+func TestReconcileBatches(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("completes_exited_script", func(t *testing.T) {
+		store := storage.NewMemoryStore(newBatchScenario("scn-1"),
+			// Interactive scenarios are left to the provisioning reconciler
+			&storage.Scenario{ScenarioID: "scn-2", ContainerID: "container-scn-2", Status: types.ScenarioStatusRunning})
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("ContainerExists", mock.Anything, "container-scn-1").Return(true, nil)
+		mockDocker.On("GetContainerStatus", mock.Anything, "container-scn-1").Return("exited", nil)
+		mockDocker.On("WaitForExit", mock.Anything, "container-scn-1").Return(docker.ExitResult{ExitCode: 0, Stdout: "done\n"}, nil)
+		mockDocker.On("RemoveContainer", mock.Anything, "container-scn-1").Return(nil)
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+		require.NoError(t, manager.ReconcileBatches(ctx))
+
+		s, err := store.GetScenario(ctx, "scn-1")
+		require.NoError(t, err)
+		assert.Equal(t, types.ScenarioStatusCompleted, s.Status)
+		require.NotNil(t, s.Result)
+		assert.Equal(t, "done\n", s.Result.Stdout)
+		mockDocker.AssertExpectations(t)
+		mockDocker.AssertNotCalled(t, "ContainerExists", mock.Anything, "container-scn-2")
+	})
+
+	t.Run("skips_watched", func(t *testing.T) {
+		store := storage.NewMemoryStore(newBatchScenario("scn-1"))
+		mockDocker := &MockDockerClient{}
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+		manager.batches.Store("scn-1", struct{}{})
+
+		require.NoError(t, manager.ReconcileBatches(ctx))
+
+		mockDocker.AssertNotCalled(t, "ContainerExists", mock.Anything, mock.Anything)
+	})
+
+	t.Run("stops_overdue_script", func(t *testing.T) {
+		scenario := newBatchScenario("scn-1")
+		scenario.CreatedAt = time.Now().Add(-time.Hour)
+		scenario.ScriptTimeout = time.Minute
+		store := storage.NewMemoryStore(scenario)
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("ContainerExists", mock.Anything, "container-scn-1").Return(true, nil)
+		mockDocker.On("GetContainerStatus", mock.Anything, "container-scn-1").Return("running", nil)
+		mockDocker.On("WaitForExit", mock.Anything, "container-scn-1").
+			Run(func(args mock.Arguments) { <-args.Get(0).(context.Context).Done() }).
+			Return(docker.ExitResult{}, context.DeadlineExceeded).Once()
+		mockDocker.On("StopContainer", mock.Anything, "container-scn-1").Return(nil)
+		mockDocker.On("WaitForExit", mock.Anything, "container-scn-1").Return(docker.ExitResult{ExitCode: 137}, nil).Once()
+		mockDocker.On("RemoveContainer", mock.Anything, "container-scn-1").Return(nil)
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+		require.NoError(t, manager.ReconcileBatches(ctx))
+
+		require.Eventually(t, func() bool {
+			s, err := store.GetScenario(ctx, "scn-1")
+			return err == nil && s.Status == types.ScenarioStatusFailed && s.Result != nil && s.Result.TimedOut
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,112 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/types"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// MaxBulkStopScenarios caps how many scenarios one bulk stop may name
+const MaxBulkStopScenarios = 100
+
+// defaultConcurrentStops is used when Cfg.MaxConcurrentStops is unset
+const defaultConcurrentStops = 8
+
+// defaultBulkStopTimeout bounds each stop of a bulk stop when
+// Cfg.RequestTimeout.Default is unset
+const defaultBulkStopTimeout = 30 * time.Second
+
+// StopScenarios stops the scenarios in scenarioIDs owned by userID. Up to
+// Cfg.MaxConcurrentStops are stopped at once, protecting the Docker daemon
+// from a burst of stops. Each scenario is stopped independently: one that
+// cannot be stopped is reported in the response and the others still are.
+// Duplicate IDs are stopped once. Results keep the order of scenarioIDs.
+// Each stop gets as long as a single stop request would and, once started,
+// runs to completion even if the caller goes away.
+func (m *Manager) StopScenarios(ctx context.Context, userID string, scenarioIDs []string) (*types.StopScenariosResponse, error) {
+	if ctx == nil {
+		return nil, errors.New("nil context provided")
+	}
+
+	var ids []string
+	seen := make(map[string]bool, len(scenarioIDs))
+	for _, id := range scenarioIDs {
+		if id == "" {
+			return nil, fmt.Errorf("%w: scenario IDs cannot be empty", ErrInvalidBulkStop)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no scenario IDs given", ErrInvalidBulkStop)
+	}
+	if len(ids) > MaxBulkStopScenarios {
+		return nil, fmt.Errorf("%w: %d scenarios exceeds the limit of %d", ErrInvalidBulkStop, len(ids), MaxBulkStopScenarios)
+	}
+
+	workers := m.maxConcurrentStops()
+	if workers > len(ids) {
+		workers = len(ids)
+	}
+	log.Printf("[scenario] stopping %d scenarios for user %s, %d at a time", len(ids), userID, workers)
+
+	errs := make([]error, len(ids))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.bulkStopTimeout())
+				errs[j] = m.stopOwnedScenario(stopCtx, ids[j], userID)
+				cancel()
+			}
+		}()
+	}
+	for j := range ids {
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+
+	resp := &types.StopScenariosResponse{Stopped: []string{}}
+	for j, id := range ids {
+		if errs[j] != nil {
+			log.Printf("[scenario] bulk stop of scenario %s failed: %v", id, errs[j])
+			resp.Failed = append(resp.Failed, types.ScenarioStopFailure{ScenarioID: id, Error: errs[j].Error()})
+			continue
+		}
+		resp.Stopped = append(resp.Stopped, id)
+	}
+	resp.Message = fmt.Sprintf("Stopped %d of %d scenarios", len(resp.Stopped), len(ids))
+	return resp, nil
+}
+
+// stopOwnedScenario stops scenarioID if it belongs to userID
+func (m *Manager) stopOwnedScenario(ctx context.Context, scenarioID, userID string) error {
+	if _, err := m.getOwnedScenario(ctx, scenarioID, userID); err != nil {
+		return err
+	}
+	return m.StopScenario(ctx, scenarioID)
+}
+
+func (m *Manager) maxConcurrentStops() int {
+	if m.Cfg == nil || m.Cfg.MaxConcurrentStops <= 0 {
+		return defaultConcurrentStops
+	}
+	return m.Cfg.MaxConcurrentStops
+}
+
+func (m *Manager) bulkStopTimeout() time.Duration {
+	if m.Cfg == nil || m.Cfg.RequestTimeout.Default <= 0 {
+		return defaultBulkStopTimeout
+	}
+	return m.Cfg.RequestTimeout.Default
+}
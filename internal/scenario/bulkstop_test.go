@@ -0,0 +1,132 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopScenarios(t *testing.T) {
+	const (
+		count       = 40
+		concurrency = 4
+	)
+
+	var seeded []*storage.Scenario
+	var ids []string
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("scn-%02d", i)
+		ids = append(ids, id)
+		seeded = append(seeded, &storage.Scenario{
+			ScenarioID:  id,
+			UserID:      "alice",
+			ContainerID: "container-" + id,
+			Status:      types.ScenarioStatusRunning,
+		})
+	}
+	seeded = append(seeded, &storage.Scenario{ScenarioID: "scn-bob", UserID: "bob", ContainerID: "container-bob", Status: types.ScenarioStatusRunning})
+	store := storage.NewMemoryStore(seeded...)
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	mockDocker := &MockDockerClient{}
+	// One failing container must not stop the others
+	mockDocker.On("StopContainer", mock.Anything, "container-scn-07").Return(errors.New("daemon hiccup"))
+	mockDocker.On("StopContainer", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+	}).Return(nil)
+
+	manager := &Manager{Cfg: &config.Config{MaxConcurrentStops: concurrency}, Docker: mockDocker, Store: store}
+
+	request := append(append([]string{}, ids...), "scn-bob", "scn-missing", "scn-00")
+	resp, err := manager.StopScenarios(context.Background(), "alice", request)
+	require.NoError(t, err)
+
+	assert.Len(t, resp.Stopped, count-1)
+	assert.NotContains(t, resp.Stopped, "scn-07")
+	require.Len(t, resp.Failed, 3)
+	assert.Equal(t, "scn-07", resp.Failed[0].ScenarioID)
+	assert.Contains(t, resp.Failed[0].Error, "daemon hiccup")
+	assert.Equal(t, "scn-bob", resp.Failed[1].ScenarioID)
+	assert.Equal(t, "scn-missing", resp.Failed[2].ScenarioID)
+	assert.Equal(t, "Stopped 39 of 42 scenarios", resp.Message)
+
+	assert.LessOrEqual(t, peak, concurrency)
+	mockDocker.AssertNumberOfCalls(t, "StopContainer", count)
+	mockDocker.AssertNotCalled(t, "StopContainer", mock.Anything, "container-bob")
+
+	for _, id := range resp.Stopped {
+		s, err := store.GetScenario(context.Background(), id)
+		require.NoError(t, err)
+		assert.Equal(t, types.ScenarioStatusStopped, s.Status)
+	}
+	bob, err := store.GetScenario(context.Background(), "scn-bob")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusRunning, bob.Status)
+}
+
+func TestStopScenarios_InvalidRequest(t *testing.T) {
+	manager := &Manager{Cfg: &config.Config{}, Docker: &MockDockerClient{}, Store: storage.NewMemoryStore()}
+	tooMany := make([]string, MaxBulkStopScenarios+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("scn-%d", i)
+	}
+
+	for name, ids := range map[string][]string{
+		"none":     nil,
+		"empty_id": {"scn-1", ""},
+		"too_many": tooMany,
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := manager.StopScenarios(context.Background(), "alice", ids)
+			assert.ErrorIs(t, err, ErrInvalidBulkStop)
+			assert.Nil(t, resp)
+		})
+	}
+}
+
+func TestStopScenarios_EachStopHasItsOwnDeadline(t *testing.T) {
+	store := storage.NewMemoryStore(
+		&storage.Scenario{ScenarioID: "scn-1", UserID: "alice", ContainerID: "container-1", Status: types.ScenarioStatusRunning},
+		&storage.Scenario{ScenarioID: "scn-2", UserID: "alice", ContainerID: "container-2", Status: types.ScenarioStatusRunning},
+	)
+
+	// The caller goes away while the stops are under way
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("StopContainer", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		cancel()
+		stopCtx := args.Get(0).(context.Context)
+		assert.NoError(t, stopCtx.Err(), "a stop is not cut short by the caller")
+		deadline, ok := stopCtx.Deadline()
+		require.True(t, ok)
+		assert.LessOrEqual(t, time.Until(deadline), 10*time.Second)
+	}).Return(nil)
+
+	cfg := &config.Config{MaxConcurrentStops: 1}
+	cfg.RequestTimeout.Default = 10 * time.Second
+	manager := &Manager{Cfg: cfg, Docker: mockDocker, Store: store}
+
+	resp, err := manager.StopScenarios(ctx, "alice", []string{"scn-1", "scn-2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"scn-1", "scn-2"}, resp.Stopped)
+	assert.Empty(t, resp.Failed)
+}
@@ -0,0 +1,109 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// reserveCapacity admits a new scenario when fewer than Cfg.MaxTotalScenarios
+// are active, counting starts still being provisioned by this process so
+// concurrent requests cannot overshoot the cap. The returned release must be
+// called once the scenario is stored or the start has failed. A zero cap
+// admits everything.
+func (m *Manager) reserveCapacity(ctx context.Context) (func(), error) {
+	limit := 0
+	if m.Cfg != nil {
+		limit = m.Cfg.MaxTotalScenarios
+	}
+	if limit <= 0 {
+		return func() {}, nil
+	}
+
+	m.capacityMu.Lock()
+	defer m.capacityMu.Unlock()
+
+	active, err := m.store().CountActiveScenarios(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active scenarios: %w", err)
+	}
+
+	if int(active)+m.pending >= limit {
+		log.Printf("[scenario] refusing new scenario: %d active, %d starting, limit %d", active, m.pending, limit)
+		return nil, fmt.Errorf("%w: limit is %d", ErrCapacityReached, limit)
+	}
+
+	m.pending++
+	return func() {
+		m.capacityMu.Lock()
+		defer m.capacityMu.Unlock()
+		m.pending--
+	}, nil
+}
+
+// checkHostLoad refuses a new scenario with ErrCapacityReached while the
+// Docker host is over a Cfg.HostLoad threshold. A host whose load cannot be
+// read does not block the start; provisioning reports an unreachable daemon.
+func (m *Manager) checkHostLoad(ctx context.Context) error {
+	if m.Cfg == nil {
+		return nil
+	}
+	limits := m.Cfg.HostLoad
+	if limits.MaxRunningContainers <= 0 && limits.MaxLoadPerCPU <= 0 {
+		return nil
+	}
+
+	load, err := m.Docker.HostLoad(ctx)
+	if err != nil {
+		log.Printf("[scenario] failed to check host load, starting anyway: %v", err)
+		return nil
+	}
+	if limits.MaxRunningContainers > 0 && load.RunningContainers >= limits.MaxRunningContainers {
+		log.Printf("[scenario] refusing new scenario: host runs %d containers, limit %d", load.RunningContainers, limits.MaxRunningContainers)
+		return fmt.Errorf("%w: host is running %d containers, limit is %d", ErrCapacityReached, load.RunningContainers, limits.MaxRunningContainers)
+	}
+	if limits.MaxLoadPerCPU > 0 && load.LoadPerCPU() > limits.MaxLoadPerCPU {
+		log.Printf("[scenario] refusing new scenario: host load %.2f per CPU, limit %.2f", load.LoadPerCPU(), limits.MaxLoadPerCPU)
+		return fmt.Errorf("%w: host load is %.2f per CPU, limit is %.2f", ErrCapacityReached, load.LoadPerCPU(), limits.MaxLoadPerCPU)
+	}
+	return nil
+}
+
+// acquireProvisionSlot limits how many containers this process provisions at
+// once to Cfg.MaxConcurrentStarts, protecting the Docker daemon from bursts of
+// pulls and creates. A caller beyond the limit waits up to
+// Cfg.StartQueueTimeout for a slot and then fails with ErrProvisioningBusy;
+// a zero timeout fails immediately. The returned release frees the slot.
+func (m *Manager) acquireProvisionSlot(ctx context.Context) (func(), error) {
+	if m.Cfg == nil || m.Cfg.MaxConcurrentStarts <= 0 {
+		return func() {}, nil
+	}
+	limit, timeout := m.Cfg.MaxConcurrentStarts, m.Cfg.StartQueueTimeout
+
+	m.provisionOnce.Do(func() {
+		m.provisionSlots = make(chan struct{}, limit)
+	})
+	release := func() { <-m.provisionSlots }
+
+	select {
+	case m.provisionSlots <- struct{}{}:
+		return release, nil
+	default:
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("%w: %d starts in progress", ErrProvisioningBusy, limit)
+	}
+
+	log.Printf("[scenario] %d starts in progress, waiting up to %s for a provisioning slot", limit, timeout)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case m.provisionSlots <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: no slot freed within %s", ErrProvisioningBusy, timeout)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: %w", ErrClientCancelled, ctx.Err())
+	}
+}
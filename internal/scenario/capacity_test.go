@@ -0,0 +1,214 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/docker"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartScenario_GlobalCapacity(t *testing.T) {
+	ctx := context.Background()
+	mockDocker := &MockDockerClient{}
+	store := storage.NewMemoryStore(
+		&storage.Scenario{ScenarioID: "scn-1", UserID: "user-a", ContainerID: "container-1", Status: types.ScenarioStatusRunning},
+		&storage.Scenario{ScenarioID: "scn-2", UserID: "user-b", ContainerID: "container-2", Status: types.ScenarioStatusProvisioning},
+		&storage.Scenario{ScenarioID: "scn-old", UserID: "user-c", Status: types.ScenarioStatusStopped},
+	)
+	manager := &Manager{Cfg: &config.Config{MaxTotalScenarios: 2}, Docker: mockDocker, Store: store}
+	req := &types.StartScenarioRequest{UserID: "user-c", ScenarioType: "go"}
+
+	// Stopped scenarios do not count, but the two active ones fill the cap
+	_, err := manager.StartScenario(ctx, req)
+	assert.ErrorIs(t, err, ErrCapacityReached)
+	mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+
+	// Stopping one frees a slot
+	mockDocker.On("StopContainer", mock.Anything, "container-1").Return(nil)
+	require.NoError(t, manager.StopScenario(ctx, "scn-1"))
+
+	mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).Return("container-3", 3003, nil)
+	resp, err := manager.StartScenario(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusProvisioning, resp.Status)
+	assert.Zero(t, manager.pending, "the reservation is released once the scenario is stored")
+
+	_, err = manager.StartScenario(ctx, req)
+	assert.ErrorIs(t, err, ErrCapacityReached)
+}
+
+func TestReserveCapacity_CountsPendingStarts(t *testing.T) {
+	ctx := context.Background()
+	manager := &Manager{Cfg: &config.Config{MaxTotalScenarios: 1}, Store: storage.NewMemoryStore()}
+
+	release, err := manager.reserveCapacity(ctx)
+	require.NoError(t, err)
+
+	// A second start while the first is still provisioning is refused
+	_, err = manager.reserveCapacity(ctx)
+	assert.ErrorIs(t, err, ErrCapacityReached)
+
+	release()
+	release, err = manager.reserveCapacity(ctx)
+	require.NoError(t, err)
+	release()
+}
+
+func TestReserveCapacity_ZeroIsUnlimited(t *testing.T) {
+	active := make([]*storage.Scenario, 0, 5)
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		active = append(active, &storage.Scenario{ScenarioID: id, Status: types.ScenarioStatusRunning})
+	}
+	manager := &Manager{Cfg: &config.Config{}, Store: storage.NewMemoryStore(active...)}
+
+	release, err := manager.reserveCapacity(context.Background())
+	require.NoError(t, err)
+	release()
+}
+
+func TestStartScenario_HostLoad(t *testing.T) {
+	limits := config.HostLoadConfig{MaxRunningContainers: 50, MaxLoadPerCPU: 2}
+	tests := []struct {
+		name        string
+		limits      config.HostLoadConfig
+		load        docker.HostLoad
+		loadErr     error
+		expectError bool
+	}{
+		{name: "healthy", limits: limits, load: docker.HostLoad{RunningContainers: 12, CPUs: 8, Load1: 4}},
+		{name: "too_many_containers", limits: limits, load: docker.HostLoad{RunningContainers: 50, CPUs: 8, Load1: 4}, expectError: true},
+		{name: "load_too_high", limits: limits, load: docker.HostLoad{RunningContainers: 12, CPUs: 4, Load1: 9.5}, expectError: true},
+		{name: "load_unknown", limits: limits, load: docker.HostLoad{RunningContainers: 12, CPUs: 8}},
+		{name: "check_fails_open", limits: limits, loadErr: docker.ErrDockerDaemonUnavailable},
+		{name: "load_limit_only", limits: config.HostLoadConfig{MaxLoadPerCPU: 1}, load: docker.HostLoad{RunningContainers: 500, CPUs: 8, Load1: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDocker := &MockDockerClient{}
+			mockDocker.On("HostLoad", mock.Anything).Return(tt.load, tt.loadErr)
+			mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).Return("container-1", 3001, nil)
+			manager := &Manager{Cfg: &config.Config{HostLoad: tt.limits}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+			resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{UserID: "user-a", ScenarioType: "go"})
+			if tt.expectError {
+				assert.ErrorIs(t, err, ErrCapacityReached)
+				assert.Nil(t, resp)
+				mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			mockDocker.AssertCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestStartScenario_HostLoadUncheckedByDefault(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).Return("container-1", 3001, nil)
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+	_, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{UserID: "user-a", ScenarioType: "go"})
+	require.NoError(t, err)
+	mockDocker.AssertNotCalled(t, "HostLoad", mock.Anything)
+}
+
+func TestStartScenario_ConcurrentProvisioningLimit(t *testing.T) {
+	req := &types.StartScenarioRequest{UserID: "user-a", ScenarioType: "go"}
+
+	// newBlockedManager returns a manager whose first container start blocks
+	// until unblock is closed
+	newBlockedManager := func(cfg *config.Config) (*Manager, *MockDockerClient, chan struct{}, chan struct{}) {
+		started := make(chan struct{}, 2)
+		unblock := make(chan struct{})
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).Run(func(mock.Arguments) {
+			started <- struct{}{}
+			<-unblock
+		}).Return("container-1", 3001, nil)
+		return &Manager{Cfg: cfg, Docker: mockDocker, Store: storage.NewMemoryStore()}, mockDocker, started, unblock
+	}
+
+	t.Run("rejects_beyond_limit", func(t *testing.T) {
+		manager, mockDocker, started, unblock := newBlockedManager(&config.Config{MaxConcurrentStarts: 1})
+
+		firstErr := make(chan error, 1)
+		go func() {
+			_, err := manager.StartScenario(context.Background(), req)
+			firstErr <- err
+		}()
+		<-started
+
+		_, err := manager.StartScenario(context.Background(), req)
+		assert.ErrorIs(t, err, ErrProvisioningBusy)
+
+		close(unblock)
+		require.NoError(t, <-firstErr)
+		mockDocker.AssertNumberOfCalls(t, "StartScenarioContainer", 1)
+
+		// The slot is free again once the first start completes
+		_, err = manager.StartScenario(context.Background(), req)
+		require.NoError(t, err)
+	})
+
+	t.Run("queues_until_slot_frees", func(t *testing.T) {
+		manager, mockDocker, started, unblock := newBlockedManager(&config.Config{MaxConcurrentStarts: 1, StartQueueTimeout: time.Minute})
+
+		errs := make(chan error, 2)
+		go func() {
+			_, err := manager.StartScenario(context.Background(), req)
+			errs <- err
+		}()
+		<-started
+
+		go func() {
+			_, err := manager.StartScenario(context.Background(), req)
+			errs <- err
+		}()
+
+		select {
+		case <-started:
+			t.Fatal("second start reached Docker while the first held the only slot")
+		case err := <-errs:
+			t.Fatalf("second start returned early: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		mockDocker.AssertNumberOfCalls(t, "StartScenarioContainer", 1)
+
+		close(unblock)
+		require.NoError(t, <-errs)
+		require.NoError(t, <-errs)
+		mockDocker.AssertNumberOfCalls(t, "StartScenarioContainer", 2)
+	})
+
+	t.Run("queue_timeout", func(t *testing.T) {
+		manager, _, started, unblock := newBlockedManager(&config.Config{MaxConcurrentStarts: 1, StartQueueTimeout: 20 * time.Millisecond})
+		defer close(unblock)
+
+		go manager.StartScenario(context.Background(), req)
+		<-started
+
+		_, err := manager.StartScenario(context.Background(), req)
+		assert.ErrorIs(t, err, ErrProvisioningBusy)
+	})
+
+	t.Run("cancelled_while_queued", func(t *testing.T) {
+		manager, _, started, unblock := newBlockedManager(&config.Config{MaxConcurrentStarts: 1, StartQueueTimeout: time.Minute})
+		defer close(unblock)
+
+		go manager.StartScenario(context.Background(), req)
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := manager.StartScenario(ctx, req)
+		assert.ErrorIs(t, err, ErrClientCancelled)
+	})
+}
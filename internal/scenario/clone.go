@@ -0,0 +1,62 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"fmt"
+	"log"
+	"maps"
+	"time"
+)
+
+// CloneScenario provisions a new scenario with the configuration of one owned
+// by userID. The clone belongs to userID unless admin is set and ownerID
+// names another user, and gets its own container, terminal port and
+// credentials.
+func (m *Manager) CloneScenario(ctx context.Context, scenarioID, userID, ownerID string, admin bool) (*types.StartScenarioResponse, error) {
+	source, err := m.getOwnedScenario(ctx, scenarioID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ownerID == "" {
+		ownerID = userID
+	}
+	if ownerID != userID {
+		if !admin {
+			return nil, fmt.Errorf("%w: only admins may clone a scenario for another user", ErrNotScenarioOwner)
+		}
+		auditLog("scenario.clone_for_user", userID, scenarioID, map[string]interface{}{"owner_id": ownerID})
+	}
+	log.Printf("[scenario] cloning scenario %s for user %s", scenarioID, ownerID)
+
+	return m.StartScenario(ctx, &types.StartScenarioRequest{
+		UserID:         ownerID,
+		ScenarioType:   source.ScenarioType,
+		Script:         source.Script,
+		Mode:           source.Mode,
+		Name:           source.Name,
+		Tags:           append([]string(nil), source.Tags...),
+		Notes:          source.Notes,
+		Entrypoint:     append([]string(nil), source.Entrypoint...),
+		Command:        append([]string(nil), source.Command...),
+		StartTTYD:      source.StartTTYD,
+		PostStart:      append([]string(nil), source.PostStart...),
+		PostStartFatal: source.PostStartFatal,
+		Env:            maps.Clone(source.Env),
+		Labels:         maps.Clone(source.Labels),
+		Limits:         cloneLimits(source),
+		// The clone keeps the source's resolved limit even if the default has since changed
+		ScriptTimeoutSeconds: int(source.ScriptTimeout / time.Second),
+	})
+}
+
+// cloneLimits returns the resource limits source was started with, or nil
+// when it set none and so ran with the scenario type's defaults
+func cloneLimits(source *storage.Scenario) *types.ResourceLimits {
+	if source.CPULimit == 0 && source.MemoryLimitMB == 0 {
+		return nil
+	}
+	return &types.ResourceLimits{CPUs: source.CPULimit, MemoryMB: source.MemoryLimitMB}
+}
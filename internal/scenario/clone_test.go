@@ -0,0 +1,130 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/docker"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloneScenario(t *testing.T) {
+	created := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	source := &storage.Scenario{
+		ScenarioID:       "scn-source",
+		UserID:           "instructor",
+		ScenarioType:     "python",
+		Name:             "lab 1",
+		Tags:             []string{"week1"},
+		Script:           "pip install requests",
+		ContainerID:      "container-source",
+		Status:           types.ScenarioStatusRunning,
+		TerminalPort:     3001,
+		TerminalUsername: "devlab",
+		TerminalPassword: "source-secret",
+		CreatedAt:        created,
+		ExpiresAt:        created.Add(time.Hour),
+		PostStart:        []string{"pip", "list"},
+		PostStartFatal:   true,
+		Env:              map[string]string{"PIP_INDEX_URL": "https://pypi.example.com"},
+		MemoryLimitMB:    1024,
+	}
+
+	tests := []struct {
+		name          string
+		ownerID       string
+		admin         bool
+		expectedOwner string
+	}{
+		{name: "same_owner", expectedOwner: "instructor"},
+		{name: "reassigned_owner", ownerID: "student", admin: true, expectedOwner: "student"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDocker := &MockDockerClient{}
+			mockDocker.On("StartScenarioContainer", mock.Anything, mock.MatchedBy(func(spec docker.ContainerSpec) bool {
+				return spec.ScenarioType == "python" && spec.Script == "pip install requests" &&
+					spec.TerminalPassword != "" && spec.TerminalPassword != "source-secret" &&
+					spec.Limits == docker.ResourceLimits{MemoryMB: 1024}
+			})).Return("container-clone", 3002, nil)
+
+			store := storage.NewMemoryStore(source)
+			manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+			resp, err := manager.CloneScenario(context.Background(), "scn-source", "instructor", tt.ownerID, tt.admin)
+			require.NoError(t, err)
+			assert.NotEqual(t, "scn-source", resp.ScenarioID)
+			assert.Equal(t, types.ScenarioStatusProvisioning, resp.Status)
+			mockDocker.AssertExpectations(t)
+
+			clone, err := store.GetScenario(context.Background(), resp.ScenarioID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedOwner, clone.UserID)
+			assert.Equal(t, "python", clone.ScenarioType)
+			assert.Equal(t, "lab 1", clone.Name)
+			assert.Equal(t, []string{"week1"}, clone.Tags)
+			assert.Equal(t, "pip install requests", clone.Script)
+			assert.Equal(t, []string{"pip", "list"}, clone.PostStart)
+			assert.True(t, clone.PostStartFatal)
+			assert.Equal(t, source.Env, clone.Env)
+			assert.Equal(t, int64(1024), clone.MemoryLimitMB)
+
+			// Runtime state belongs to the clone alone
+			assert.Equal(t, "container-clone", clone.ContainerID)
+			assert.Equal(t, 3002, clone.TerminalPort)
+			assert.NotEqual(t, "source-secret", clone.TerminalPassword)
+			assert.True(t, clone.CreatedAt.After(created))
+			assert.Empty(t, clone.PostStartError)
+		})
+	}
+
+	t.Run("not_owner", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore(source)}
+
+		resp, err := manager.CloneScenario(context.Background(), "scn-source", "student", "", false)
+		assert.ErrorIs(t, err, ErrNotScenarioOwner)
+		assert.Nil(t, resp)
+		mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+	})
+
+	t.Run("reassignment_needs_admin", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore(source)}
+
+		resp, err := manager.CloneScenario(context.Background(), "scn-source", "instructor", "student", false)
+		assert.ErrorIs(t, err, ErrNotScenarioOwner)
+		assert.Nil(t, resp)
+		mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+	})
+
+	t.Run("container_override", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("StartScenarioContainer", mock.Anything, mock.MatchedBy(func(spec docker.ContainerSpec) bool {
+			return assert.ObjectsAreEqual([]string{"/sbin/my-init"}, spec.Entrypoint) &&
+				assert.ObjectsAreEqual([]string{"--foreground"}, spec.Command) &&
+				spec.StartTTYD
+		})).Return("container-clone", 3002, nil)
+
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore(&storage.Scenario{
+			ScenarioID:   "scn-override",
+			UserID:       "instructor",
+			ScenarioType: "go",
+			Status:       types.ScenarioStatusRunning,
+			Entrypoint:   []string{"/sbin/my-init"},
+			Command:      []string{"--foreground"},
+			StartTTYD:    true,
+		})}
+
+		_, err := manager.CloneScenario(context.Background(), "scn-override", "instructor", "", false)
+		require.NoError(t, err)
+		mockDocker.AssertExpectations(t)
+	})
+}
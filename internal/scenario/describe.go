@@ -0,0 +1,61 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+)
+
+// DescribeScenario returns the full stored record of a scenario owned by
+// userID. The terminal password is left out unless includeSecrets is set.
+func (m *Manager) DescribeScenario(ctx context.Context, scenarioID, userID string, includeSecrets bool) (*types.ScenarioDetailsResponse, error) {
+	scenario, err := m.getOwnedScenario(ctx, scenarioID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return m.scenarioDetails(scenario, includeSecrets), nil
+}
+
+// scenarioDetails builds the describe response for a stored scenario
+func (m *Manager) scenarioDetails(scenario *storage.Scenario, includeSecrets bool) *types.ScenarioDetailsResponse {
+	maxAge, _, _ := m.lifetimeLimits(scenario.ScenarioType)
+	resp := &types.ScenarioDetailsResponse{
+		ScenarioID:       scenario.ScenarioID,
+		UserID:           scenario.UserID,
+		ScenarioType:     scenario.ScenarioType,
+		Image:            scenario.Image,
+		Name:             scenario.Name,
+		Tags:             scenario.Tags,
+		Notes:            scenario.Notes,
+		Locked:           scenario.Locked,
+		Mode:             scenario.Mode,
+		Script:           scenario.Script,
+		ContainerID:      scenario.ContainerID,
+		Status:           scenario.Status,
+		StopReason:       scenario.StopReason,
+		TerminalPort:     scenario.TerminalPort,
+		TerminalUsername: scenario.TerminalUsername,
+		CreatedAt:        scenario.CreatedAt,
+		UpdatedAt:        scenario.UpdatedAt,
+		ExpiresAt:        scenario.Expiry(maxAge),
+		LastActivityAt:   scenario.LastActivity(),
+		PostStartError:   scenario.PostStartError,
+		ProvisioningLogs: scenario.ProvisioningLogs,
+		Message:          "Scenario retrieved successfully",
+	}
+	if includeSecrets {
+		resp.TerminalPassword = scenario.TerminalPassword
+	}
+	for _, change := range scenario.StatusHistory {
+		resp.StatusHistory = append(resp.StatusHistory, types.StatusChange{
+			Status: change.Status,
+			At:     change.At,
+			Reason: change.Reason,
+		})
+	}
+	if scenario.Result != nil {
+		resp.ExitCode = &scenario.Result.ExitCode
+		resp.CompletedAt = &scenario.Result.CompletedAt
+	}
+	return resp
+}
@@ -0,0 +1,55 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeScenario(t *testing.T) {
+	created := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	manager := &Manager{
+		Cfg: &config.Config{},
+		Store: storage.NewMemoryStore(&storage.Scenario{
+			ScenarioID:       "scn-1",
+			UserID:           "test-user",
+			ScenarioType:     "go",
+			Script:           "go run .",
+			ContainerID:      "container123",
+			Status:           types.ScenarioStatusRunning,
+			TerminalPort:     3001,
+			TerminalUsername: "devlab",
+			TerminalPassword: "secret",
+			CreatedAt:        created,
+			ExpiresAt:        created.Add(time.Hour),
+		}),
+	}
+	ctx := context.Background()
+
+	resp, err := manager.DescribeScenario(ctx, "scn-1", "test-user", false)
+	require.NoError(t, err)
+	assert.Equal(t, "go run .", resp.Script)
+	assert.Equal(t, "container123", resp.ContainerID)
+	assert.Equal(t, 3001, resp.TerminalPort)
+	assert.Equal(t, "devlab", resp.TerminalUsername)
+	assert.Empty(t, resp.TerminalPassword, "password is redacted by default")
+	assert.Equal(t, created, resp.CreatedAt)
+	assert.Equal(t, created.Add(time.Hour), resp.ExpiresAt)
+	assert.Nil(t, resp.ExitCode)
+
+	resp, err = manager.DescribeScenario(ctx, "scn-1", "test-user", true)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", resp.TerminalPassword)
+
+	_, err = manager.DescribeScenario(ctx, "scn-1", "other-user", false)
+	assert.ErrorIs(t, err, ErrNotScenarioOwner)
+
+	_, err = manager.DescribeScenario(ctx, "missing", "test-user", false)
+	assert.ErrorIs(t, err, ErrScenarioNotFound)
+}
@@ -0,0 +1,173 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/docker"
+	"devlab/internal/types"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Exec limits used when the config leaves them unset
+const (
+	defaultExecTimeout    = 30 * time.Second
+	defaultExecMaxTimeout = 5 * time.Minute
+)
+
+// timeoutExitCode is what the timeout command exits with when it kills the command
+const timeoutExitCode = 124
+
+// ExecuteCommand runs req's command in a running scenario owned by userID and
+// returns its output. The command runs as the scenario user in its home
+// directory, under the container's timeout command so that it is killed
+// rather than left running when the timeout passes. A command exiting
+// non-zero, or writing more than the configured output limit, is reported in
+// the response, not as an error.
+func (m *Manager) ExecuteCommand(ctx context.Context, scenarioID, userID string, req *types.ExecCommandRequest) (*types.ExecCommandResponse, error) {
+	if req == nil || len(req.Command) == 0 || req.Command[0] == "" {
+		return nil, fmt.Errorf("%w: command cannot be empty", ErrInvalidCommand)
+	}
+	timeout, err := m.execTimeout(req.TimeoutSeconds)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkCommandAllowed(req.Command); err != nil {
+		return nil, err
+	}
+
+	scenario, err := m.getOwnedScenario(ctx, scenarioID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if scenario.Status != types.ScenarioStatusRunning {
+		return nil, fmt.Errorf("%w: %s is %s", ErrScenarioNotRunning, scenarioID, scenario.Status)
+	}
+	m.recordActivity(ctx, scenario)
+
+	seconds := int((timeout + time.Second - 1) / time.Second)
+	command := append([]string{"timeout", strconv.Itoa(seconds)}, req.Command...)
+	execCtx, cancel := context.WithTimeout(ctx, timeout+postStartKillGrace)
+	defer cancel()
+
+	started := time.Now()
+	result, err := m.Docker.RunCommand(execCtx, scenario.ContainerID, command, docker.ExecuteCommandOpts{
+		User:           docker.ScenarioUser,
+		WorkingDir:     docker.ScenarioHomeDir,
+		Env:            docker.ScenarioExecEnv(scenario.ScenarioType),
+		MaxOutputBytes: m.execMaxOutputBytes(),
+	})
+	if err != nil {
+		log.Printf("[scenario] failed to run command in scenario %s: %v", scenarioID, err)
+		return nil, fmt.Errorf("failed to run command: %w", err)
+	}
+
+	return &types.ExecCommandResponse{
+		ScenarioID: scenarioID,
+		Stdout:     result.Stdout,
+		Stderr:     result.Stderr,
+		ExitCode:   result.ExitCode,
+		TimedOut:   result.ExitCode == timeoutExitCode && time.Since(started) >= timeout,
+		Truncated:  result.Truncated,
+	}, nil
+}
+
+func (m *Manager) execMaxOutputBytes() int {
+	if m.Cfg == nil {
+		return 0
+	}
+	return m.Cfg.Exec.MaxOutputBytes
+}
+
+// execTimeout resolves the timeout a command may run for, rejecting requests
+// for more than the configured maximum
+func (m *Manager) execTimeout(requestedSeconds int) (time.Duration, error) {
+	timeout, maxTimeout := defaultExecTimeout, defaultExecMaxTimeout
+	if m.Cfg != nil {
+		if m.Cfg.Exec.DefaultTimeout > 0 {
+			timeout = m.Cfg.Exec.DefaultTimeout
+		}
+		if m.Cfg.Exec.MaxTimeout > 0 {
+			maxTimeout = m.Cfg.Exec.MaxTimeout
+		}
+	}
+
+	if requestedSeconds < 0 {
+		return 0, fmt.Errorf("%w: timeout must not be negative", ErrInvalidCommand)
+	}
+	if requestedSeconds > 0 {
+		timeout = time.Duration(requestedSeconds) * time.Second
+	}
+	if timeout > maxTimeout {
+		return 0, fmt.Errorf("%w: timeout %s exceeds the %s limit", ErrInvalidCommand, timeout, maxTimeout)
+	}
+	return timeout, nil
+}
+
+// checkCommandAllowed applies the configured allow- and deny-lists to the
+// base name of command's program, so /usr/bin/curl and curl match alike. The
+// deny-list also covers programs the command hands off to through env,
+// busybox or a shell's -c script. It is a guard against casual misuse, not a
+// sandbox: a determined user can still reach a denied program, e.g. through
+// an interpreter.
+func (m *Manager) checkCommandAllowed(command []string) error {
+	if m.Cfg == nil {
+		return nil
+	}
+	program := filepath.Base(command[0])
+	for _, run := range execPrograms(command) {
+		if slices.Contains(m.Cfg.Exec.DeniedCommands, run) {
+			return fmt.Errorf("%w: %s is denied", ErrCommandNotAllowed, run)
+		}
+	}
+	if len(m.Cfg.Exec.AllowedCommands) > 0 && !slices.Contains(m.Cfg.Exec.AllowedCommands, program) {
+		return fmt.Errorf("%w: %s is not in the allow-list", ErrCommandNotAllowed, program)
+	}
+	return nil
+}
+
+// execShells are the shells whose -c script is searched for denied programs
+var execShells = []string{"sh", "bash", "dash", "ash", "zsh"}
+
+// execPrograms returns the base names of command's program and of the
+// programs it runs in turn: the command env or busybox are given, and every
+// word of a shell's -c script
+func execPrograms(command []string) []string {
+	if len(command) == 0 {
+		return nil
+	}
+	program := filepath.Base(command[0])
+	programs := []string{program}
+	args := command[1:]
+
+	switch {
+	case program == "env":
+		// Skip env's options and variable assignments to reach the command
+		for len(args) > 0 && (strings.HasPrefix(args[0], "-") || strings.Contains(args[0], "=")) {
+			args = args[1:]
+		}
+		programs = append(programs, execPrograms(args)...)
+	case program == "busybox":
+		programs = append(programs, execPrograms(args)...)
+	case slices.Contains(execShells, program):
+		for i, arg := range args {
+			if arg == "-c" && i+1 < len(args) {
+				for _, word := range strings.FieldsFunc(args[i+1], isShellSeparator) {
+					programs = append(programs, filepath.Base(word))
+				}
+				break
+			}
+		}
+	}
+	return programs
+}
+
+// isShellSeparator reports whether r separates words or commands in a script
+func isShellSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(";&|()`$<>'\"", r)
+}
@@ -0,0 +1,128 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/docker"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteCommand(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Exec: config.ExecConfig{
+		DeniedCommands: []string{"nc"},
+		DefaultTimeout: 30 * time.Second,
+		MaxTimeout:     time.Minute,
+	}}
+
+	tests := []struct {
+		name           string
+		userID         string
+		status         types.ScenarioStatus
+		req            types.ExecCommandRequest
+		allowed        []string
+		expectedExec   []string
+		result         docker.ExitResult
+		expectedErr    error
+		expectedResult *types.ExecCommandResponse
+	}{
+		{
+			name:           "success",
+			req:            types.ExecCommandRequest{Command: []string{"go", "test", "./..."}},
+			expectedExec:   []string{"timeout", "30", "go", "test", "./..."},
+			result:         docker.ExitResult{Stdout: "ok\n"},
+			expectedResult: &types.ExecCommandResponse{ScenarioID: "scn-1", Stdout: "ok\n"},
+		},
+		{
+			name:           "non_zero_exit_is_not_an_error",
+			req:            types.ExecCommandRequest{Command: []string{"go", "vet"}, TimeoutSeconds: 45},
+			expectedExec:   []string{"timeout", "45", "go", "vet"},
+			result:         docker.ExitResult{Stderr: "vet: bad\n", ExitCode: 1},
+			expectedResult: &types.ExecCommandResponse{ScenarioID: "scn-1", Stderr: "vet: bad\n", ExitCode: 1},
+		},
+		{
+			name:           "truncated_output",
+			req:            types.ExecCommandRequest{Command: []string{"cat", "/dev/urandom"}},
+			expectedExec:   []string{"timeout", "30", "cat", "/dev/urandom"},
+			result:         docker.ExitResult{Stdout: "binary", ExitCode: -1, Truncated: true},
+			expectedResult: &types.ExecCommandResponse{ScenarioID: "scn-1", Stdout: "binary", ExitCode: -1, Truncated: true},
+		},
+		{
+			name:           "shell_script_without_denied_programs",
+			req:            types.ExecCommandRequest{Command: []string{"sh", "-c", "go test ./... | tail -n 5"}},
+			expectedExec:   []string{"timeout", "30", "sh", "-c", "go test ./... | tail -n 5"},
+			result:         docker.ExitResult{Stdout: "ok\n"},
+			expectedResult: &types.ExecCommandResponse{ScenarioID: "scn-1", Stdout: "ok\n"},
+		},
+		{name: "denied", req: types.ExecCommandRequest{Command: []string{"/usr/bin/nc", "-l"}}, expectedErr: ErrCommandNotAllowed},
+		{name: "denied_through_shell", req: types.ExecCommandRequest{Command: []string{"sh", "-c", "echo hi && /usr/bin/nc -l 8080"}}, expectedErr: ErrCommandNotAllowed},
+		{name: "denied_through_env", req: types.ExecCommandRequest{Command: []string{"env", "-i", "FOO=bar", "nc", "-l"}}, expectedErr: ErrCommandNotAllowed},
+		{name: "denied_through_busybox", req: types.ExecCommandRequest{Command: []string{"busybox", "nc", "-l"}}, expectedErr: ErrCommandNotAllowed},
+		{name: "not_in_allow_list", allowed: []string{"go", "ls"}, req: types.ExecCommandRequest{Command: []string{"curl"}}, expectedErr: ErrCommandNotAllowed},
+		{name: "empty_command", req: types.ExecCommandRequest{}, expectedErr: ErrInvalidCommand},
+		{name: "timeout_over_limit", req: types.ExecCommandRequest{Command: []string{"ls"}, TimeoutSeconds: 120}, expectedErr: ErrInvalidCommand},
+		{name: "not_owner", userID: "other-user", req: types.ExecCommandRequest{Command: []string{"ls"}}, expectedErr: ErrNotScenarioOwner},
+		{name: "not_running", status: types.ScenarioStatusPaused, req: types.ExecCommandRequest{Command: []string{"ls"}}, expectedErr: ErrScenarioNotRunning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.status
+			if status == "" {
+				status = types.ScenarioStatusRunning
+			}
+			userID := tt.userID
+			if userID == "" {
+				userID = "user-1"
+			}
+			store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", UserID: "user-1", ContainerID: "container-1", Status: status})
+			mockDocker := &MockDockerClient{}
+			if tt.expectedExec != nil {
+				mockDocker.On("RunCommand", mock.Anything, "container-1", tt.expectedExec).Return(tt.result, nil)
+			}
+
+			execCfg := *cfg
+			execCfg.Exec.AllowedCommands = tt.allowed
+			manager := &Manager{Cfg: &execCfg, Docker: mockDocker, Store: store}
+			resp, err := manager.ExecuteCommand(ctx, "scn-1", userID, &tt.req)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, resp)
+				mockDocker.AssertNotCalled(t, "RunCommand", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedResult, resp)
+			mockDocker.AssertExpectations(t)
+		})
+	}
+}
+
+// execOptsDocker records the options commands are run with
+type execOptsDocker struct {
+	docker.Client
+	opts docker.ExecuteCommandOpts
+}
+
+func (d *execOptsDocker) RunCommand(ctx context.Context, containerID string, command []string, opts docker.ExecuteCommandOpts) (docker.ExitResult, error) {
+	d.opts = opts
+	return docker.ExitResult{}, nil
+}
+
+func TestExecuteCommand_OutputLimit(t *testing.T) {
+	store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", UserID: "user-1", ContainerID: "container-1", Status: types.ScenarioStatusRunning})
+	recorder := &execOptsDocker{}
+	manager := &Manager{Cfg: &config.Config{Exec: config.ExecConfig{MaxOutputBytes: 4096}}, Docker: recorder, Store: store}
+
+	_, err := manager.ExecuteCommand(context.Background(), "scn-1", "user-1", &types.ExecCommandRequest{Command: []string{"ls"}})
+	require.NoError(t, err)
+	assert.Equal(t, 4096, recorder.opts.MaxOutputBytes)
+}
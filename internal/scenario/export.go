@@ -0,0 +1,196 @@
+package scenario
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// exportPageSize is how many scenarios ExportScenarios reads from the store at a time
+const exportPageSize = 100
+
+// ExportScenarios writes every stored scenario to w, oldest first, as one
+// relaxed extended JSON document per line, the format ImportScenarios reads
+// back. Scenarios are read a page at a time so the export streams. It returns
+// how many scenarios were written and records the export in the audit log.
+func (m *Manager) ExportScenarios(ctx context.Context, adminID string, w io.Writer) (int, error) {
+	if ctx == nil {
+		return 0, errors.New("nil context provided")
+	}
+
+	exported := 0
+	pageToken := ""
+	for {
+		scenarios, next, err := m.store().ListScenariosPaged(ctx, "", pageToken, exportPageSize)
+		if err != nil {
+			return exported, fmt.Errorf("failed to list scenarios: %w", err)
+		}
+
+		for _, scenario := range scenarios {
+			line, err := bson.MarshalExtJSON(scenario, false, false)
+			if err != nil {
+				return exported, fmt.Errorf("failed to encode scenario %s: %w", scenario.ScenarioID, err)
+			}
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return exported, fmt.Errorf("failed to write scenario %s: %w", scenario.ScenarioID, err)
+			}
+			exported++
+		}
+
+		if next == "" {
+			break
+		}
+		pageToken = next
+	}
+
+	auditLog("scenario.export", adminID, "", map[string]interface{}{
+		"exported": exported,
+	})
+	return exported, nil
+}
+
+// ImportScenarios upserts, by scenario ID, the scenarios read from r in the
+// format written by ExportScenarios. Their runtime fields describe the host
+// they were exported from, so they are reconciled against this one rather
+// than trusted; see reconcileImported. Lines that cannot be imported are
+// reported in the response and skipped. The import is recorded in the audit log.
+func (m *Manager) ImportScenarios(ctx context.Context, adminID string, r io.Reader) (*types.ImportScenariosResponse, error) {
+	if ctx == nil {
+		return nil, errors.New("nil context provided")
+	}
+
+	resp := &types.ImportScenariosResponse{}
+	reader := bufio.NewReader(r)
+	for lineNumber := 1; ; lineNumber++ {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, fmt.Errorf("failed to read import: %w", readErr)
+		}
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			scenarioID, reconciled, err := m.importScenario(ctx, line)
+			switch {
+			case err != nil:
+				log.Printf("[scenario] failed to import line %d: %v", lineNumber, err)
+				resp.Failed = append(resp.Failed, types.ScenarioImportFailure{
+					Line:       lineNumber,
+					ScenarioID: scenarioID,
+					Error:      err.Error(),
+				})
+			case reconciled:
+				resp.Reconciled++
+				fallthrough
+			default:
+				resp.Imported++
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	auditLog("scenario.import", adminID, "", map[string]interface{}{
+		"imported":   resp.Imported,
+		"reconciled": resp.Reconciled,
+		"failed":     len(resp.Failed),
+	})
+
+	resp.Message = fmt.Sprintf("Imported %d scenarios", resp.Imported)
+	return resp, nil
+}
+
+// importScenario decodes, reconciles and upserts a single exported scenario,
+// reporting whether reconciling changed it
+func (m *Manager) importScenario(ctx context.Context, line []byte) (string, bool, error) {
+	var scenario storage.Scenario
+	if err := bson.UnmarshalExtJSON(line, false, &scenario); err != nil {
+		return "", false, fmt.Errorf("invalid scenario document: %w", err)
+	}
+	if scenario.ScenarioID == "" {
+		return "", false, errors.New("scenario ID cannot be empty")
+	}
+	if scenario.UserID == "" {
+		return scenario.ScenarioID, false, errors.New("user ID cannot be empty")
+	}
+	if !scenario.Status.Valid() {
+		return scenario.ScenarioID, false, fmt.Errorf("invalid status %q", scenario.Status)
+	}
+
+	reconciled, err := m.reconcileImported(ctx, &scenario)
+	if err != nil {
+		return scenario.ScenarioID, false, err
+	}
+
+	if err := m.store().UpsertScenario(ctx, &scenario); err != nil {
+		return scenario.ScenarioID, false, fmt.Errorf("failed to store scenario: %w", err)
+	}
+	return scenario.ScenarioID, reconciled, nil
+}
+
+// reconcileImported checks an imported scenario's container on this host. A
+// scenario whose container does not exist here loses its container ID and
+// terminal port and, if it was active, is stopped as orphaned. One whose
+// container exists has its terminal port re-read from Docker. It reports
+// whether the scenario was changed.
+func (m *Manager) reconcileImported(ctx context.Context, scenario *storage.Scenario) (bool, error) {
+	exists := false
+	if scenario.ContainerID != "" {
+		var err error
+		exists, err = m.Docker.ContainerExists(ctx, scenario.ContainerID)
+		if err != nil {
+			return false, fmt.Errorf("failed to check container existence: %w", err)
+		}
+	}
+
+	if !exists {
+		changed := scenario.ContainerID != "" || scenario.TerminalPort != 0
+		scenario.ContainerID = ""
+		scenario.TerminalPort = 0
+		if scenario.Status.Active() {
+			scenario.SetStatus(types.ScenarioStatusStopped, "container missing after import")
+			markStopReason(scenario, types.StopReasonOrphaned)
+			changed = true
+		}
+		return changed, nil
+	}
+
+	// Batch scenarios publish no terminal port
+	if scenario.TerminalPort == 0 {
+		return false, nil
+	}
+
+	port := 0
+	if terminalURL, err := m.Docker.GetTerminalURL(ctx, scenario.ContainerID); err != nil {
+		log.Printf("[scenario] failed to read terminal port of imported scenario %s: %v", scenario.ScenarioID, err)
+	} else {
+		port = terminalURLPort(terminalURL)
+	}
+
+	changed := port != scenario.TerminalPort
+	scenario.TerminalPort = port
+	return changed, nil
+}
+
+// terminalURLPort returns the port of a terminal URL, or 0 when it has none
+func terminalURLPort(terminalURL string) int {
+	u, err := url.Parse(terminalURL)
+	if err != nil {
+		return 0
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		return 0
+	}
+	return port
+}
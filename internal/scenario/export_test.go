@@ -0,0 +1,131 @@
+package scenario
+
+import (
+	"bytes"
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportScenarios_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	// Extended JSON keeps millisecond precision
+	base := time.Now().UTC().Truncate(time.Millisecond)
+
+	seeded := []*storage.Scenario{
+		{
+			ScenarioID:       "scn-1",
+			UserID:           "alice",
+			ScenarioType:     "go",
+			Name:             "kept",
+			Tags:             []string{"demo"},
+			ContainerID:      "container-1",
+			Status:           types.ScenarioStatusRunning,
+			TerminalPort:     3001,
+			TerminalUsername: "admin",
+			TerminalPassword: "secret",
+			CreatedAt:        base,
+			ExpiresAt:        base.Add(time.Hour),
+			ScriptTimeout:    time.Minute,
+		},
+		{
+			ScenarioID:   "scn-2",
+			UserID:       "alice",
+			ScenarioType: "python",
+			ContainerID:  "container-2",
+			Status:       types.ScenarioStatusRunning,
+			TerminalPort: 3002,
+			CreatedAt:    base.Add(time.Second),
+		},
+		{
+			ScenarioID:   "scn-3",
+			UserID:       "bob",
+			ScenarioType: "go",
+			Status:       types.ScenarioStatusStopped,
+			StopReason:   types.StopReasonUserRequested,
+			CreatedAt:    base.Add(2 * time.Second),
+			Result:       &storage.ScenarioResult{Stdout: "ok", ExitCode: 0, CompletedAt: base},
+		},
+	}
+
+	exporter := &Manager{Cfg: &config.Config{}, Store: storage.NewMemoryStore(seeded...)}
+	var exported bytes.Buffer
+	count, err := exporter.ExportScenarios(ctx, "admin-user", &exported)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Len(t, strings.Split(strings.TrimSpace(exported.String()), "\n"), 3)
+
+	// On the importing host container-1 survives behind a different port and
+	// container-2 is gone
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(true, nil)
+	mockDocker.On("GetTerminalURL", mock.Anything, "container-1").Return("http://localhost:3005", nil)
+	mockDocker.On("ContainerExists", mock.Anything, "container-2").Return(false, nil)
+	store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-3", UserID: "bob", Status: types.ScenarioStatusRunning})
+	importer := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	resp, err := importer.ImportScenarios(ctx, "admin-user", &exported)
+	require.NoError(t, err)
+	assert.Equal(t, 3, resp.Imported)
+	assert.Equal(t, 2, resp.Reconciled)
+	assert.Empty(t, resp.Failed)
+
+	kept, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	expected := *seeded[0]
+	expected.TerminalPort = 3005
+	assert.Equal(t, &expected, kept)
+
+	orphaned, err := store.GetScenario(ctx, "scn-2")
+	require.NoError(t, err)
+	assert.Empty(t, orphaned.ContainerID)
+	assert.Zero(t, orphaned.TerminalPort)
+	assert.Equal(t, types.ScenarioStatusStopped, orphaned.Status)
+	assert.Equal(t, types.StopReasonOrphaned, orphaned.StopReason)
+
+	replaced, err := store.GetScenario(ctx, "scn-3")
+	require.NoError(t, err)
+	assert.Equal(t, seeded[2], replaced)
+
+	mockDocker.AssertExpectations(t)
+}
+
+func TestImportScenarios_ReportsBadLines(t *testing.T) {
+	ctx := context.Background()
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(false, errors.New("daemon unavailable"))
+	store := storage.NewMemoryStore()
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	input := strings.Join([]string{
+		`{"scenario_id": "scn-ok", "user_id": "alice", "status": "stopped"}`,
+		``,
+		`not json`,
+		`{"user_id": "alice", "status": "stopped"}`,
+		`{"scenario_id": "scn-bad-status", "user_id": "alice", "status": "exploded"}`,
+		`{"scenario_id": "scn-docker", "user_id": "alice", "status": "running", "container_id": "container-1"}`,
+	}, "\n")
+
+	resp, err := manager.ImportScenarios(ctx, "admin-user", strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Imported)
+	require.Len(t, resp.Failed, 4)
+	assert.Equal(t, 3, resp.Failed[0].Line)
+	assert.Equal(t, 4, resp.Failed[1].Line)
+	assert.Equal(t, "scn-bad-status", resp.Failed[2].ScenarioID)
+	assert.Equal(t, "scn-docker", resp.Failed[3].ScenarioID)
+
+	_, err = store.GetScenario(ctx, "scn-ok")
+	assert.NoError(t, err)
+	_, err = store.GetScenario(ctx, "scn-docker")
+	assert.ErrorIs(t, err, storage.ErrScenarioNotFound)
+}
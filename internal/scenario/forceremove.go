@@ -0,0 +1,74 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/docker"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ForceRemoveScenario clears a wedged scenario on behalf of the admin adminID,
+// whatever its status. The container is removed if it still exists, with any
+// failure logged and ignored, and the scenario is marked cleaned up. The
+// action is written to the audit log.
+func (m *Manager) ForceRemoveScenario(ctx context.Context, scenarioID, adminID string) (*types.ForceRemoveScenarioResponse, error) {
+	if ctx == nil {
+		return nil, errors.New("nil context provided")
+	}
+
+	if scenarioID == "" {
+		return nil, fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenarioID)
+	}
+
+	scenario, err := m.store().GetScenario(ctx, scenarioID)
+	if err != nil {
+		log.Printf("[scenario] failed to get scenario from DB: %v", err)
+		if errors.Is(err, storage.ErrScenarioNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrScenarioNotFound, scenarioID)
+		}
+		return nil, fmt.Errorf("failed to get scenario: %w", err)
+	}
+
+	previousStatus := scenario.Status
+	containerRemoved := false
+	if scenario.ContainerID != "" {
+		err := m.Docker.RemoveContainer(ctx, scenario.ContainerID)
+		switch {
+		case err == nil:
+			containerRemoved = true
+		case errors.Is(err, docker.ErrContainerNotFound):
+			log.Printf("[scenario] container %s of scenario %s is already gone", scenario.ContainerID, scenarioID)
+		default:
+			log.Printf("[scenario] ignoring failure to remove container %s of scenario %s: %v", scenario.ContainerID, scenarioID, err)
+		}
+	}
+
+	m.releasePort(scenario.TerminalPort)
+
+	scenario.SetStatus(types.ScenarioStatusCleanedUp, "force removed by admin "+adminID)
+	markStopReason(scenario, types.StopReasonForceRemoved)
+	scenario.UpdatedAt = time.Now()
+	if err := m.updateStatus(ctx, scenario); err != nil {
+		log.Printf("[scenario] failed to mark scenario %s force removed: %v", scenarioID, err)
+		return nil, fmt.Errorf("failed to update scenario: %w", err)
+	}
+
+	auditLog("scenario.force_remove", adminID, scenarioID, map[string]interface{}{
+		"owner_id":          scenario.UserID,
+		"container_id":      scenario.ContainerID,
+		"previous_status":   string(previousStatus),
+		"container_removed": containerRemoved,
+	})
+
+	return &types.ForceRemoveScenarioResponse{
+		ScenarioID:       scenarioID,
+		PreviousStatus:   previousStatus,
+		Status:           scenario.Status,
+		ContainerRemoved: containerRemoved,
+		Message:          "Scenario force removed",
+	}, nil
+}
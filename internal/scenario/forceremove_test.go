@@ -0,0 +1,100 @@
+package scenario
+
+import (
+	"bytes"
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/docker"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	zerologlog "github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForceRemoveScenario(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name            string
+		status          types.ScenarioStatus
+		removeErr       error
+		expectedRemoved bool
+		expectedReason  types.StopReason
+		previousReason  types.StopReason
+	}{
+		{name: "container_gone", status: types.ScenarioStatusProvisioning, removeErr: docker.ErrContainerNotFound, expectedReason: types.StopReasonForceRemoved},
+		{name: "container_removed", status: types.ScenarioStatusRunning, expectedRemoved: true, expectedReason: types.StopReasonForceRemoved},
+		{name: "remove_error_ignored", status: types.ScenarioStatusPaused, removeErr: errors.New("device or resource busy"), expectedReason: types.StopReasonForceRemoved},
+		{name: "keeps_earlier_reason", status: types.ScenarioStatusFailed, expectedRemoved: true, previousReason: types.StopReasonFailed, expectedReason: types.StopReasonFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore(&storage.Scenario{
+				ScenarioID:  "scn-1",
+				UserID:      "owner-user",
+				ContainerID: "container-1",
+				Status:      tt.status,
+				StopReason:  tt.previousReason,
+			})
+			mockDocker := &MockDockerClient{}
+			mockDocker.On("RemoveContainer", mock.Anything, "container-1").Return(tt.removeErr)
+			manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+			resp, err := manager.ForceRemoveScenario(ctx, "scn-1", "admin-user")
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.PreviousStatus)
+			assert.Equal(t, types.ScenarioStatusCleanedUp, resp.Status)
+			assert.Equal(t, tt.expectedRemoved, resp.ContainerRemoved)
+
+			stored, err := store.GetScenario(ctx, "scn-1")
+			require.NoError(t, err)
+			assert.Equal(t, types.ScenarioStatusCleanedUp, stored.Status)
+			assert.Equal(t, tt.expectedReason, stored.StopReason)
+			mockDocker.AssertExpectations(t)
+		})
+	}
+}
+
+func TestForceRemoveScenario_AuditLog(t *testing.T) {
+	originalLogger := zerologlog.Logger
+	defer func() { zerologlog.Logger = originalLogger }()
+	var buf bytes.Buffer
+	zerologlog.Logger = zerolog.New(&buf)
+
+	store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", UserID: "owner-user", ContainerID: "container-1", Status: types.ScenarioStatusProvisioning})
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("RemoveContainer", mock.Anything, "container-1").Return(docker.ErrContainerNotFound)
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	_, err := manager.ForceRemoveScenario(context.Background(), "scn-1", "admin-user")
+	require.NoError(t, err)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, true, entry["audit"])
+	assert.Equal(t, "scenario.force_remove", entry["action"])
+	assert.Equal(t, "admin-user", entry["actor_id"])
+	assert.Equal(t, "scn-1", entry["scenario_id"])
+	assert.Equal(t, "owner-user", entry["owner_id"])
+	assert.Equal(t, "provisioning", entry["previous_status"])
+	assert.Equal(t, false, entry["container_removed"])
+}
+
+func TestForceRemoveScenario_NotFound(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+	_, err := manager.ForceRemoveScenario(context.Background(), "scn-missing", "admin-user")
+
+	assert.ErrorIs(t, err, ErrScenarioNotFound)
+	mockDocker.AssertNotCalled(t, "RemoveContainer", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,81 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/types"
+	"fmt"
+	"time"
+)
+
+// defaultHeartbeatInterval rate-limits heartbeats when the config leaves it unset
+const defaultHeartbeatInterval = 30 * time.Second
+
+// heartbeatKey identifies a user's heartbeats for one scenario. Keying on the
+// user too means another user's requests are never rate-limited by the
+// owner's heartbeats, and are rejected by the ownership check instead.
+type heartbeatKey struct {
+	scenarioID string
+	userID     string
+}
+
+// Heartbeat records that the owner is actively using a scenario, e.g. typing
+// in its terminal, so inactivity-based cleanup leaves it alone. Heartbeats
+// arriving within the configured interval of the last recorded one fail with
+// ErrHeartbeatTooFrequent without touching the store. A heartbeat the store
+// fails to record is returned as an error and does not count towards the
+// rate limit, so the client can retry straight away.
+func (m *Manager) Heartbeat(ctx context.Context, scenarioID, userID string) (*types.HeartbeatResponse, error) {
+	interval := m.heartbeatInterval()
+	now := time.Now()
+	key := heartbeatKey{scenarioID: scenarioID, userID: userID}
+	if last, ok := m.lastHeartbeat(key, now, interval); ok {
+		return nil, fmt.Errorf("%w: retry after %s", ErrHeartbeatTooFrequent, last.Add(interval).Sub(now).Round(time.Second))
+	}
+
+	scenario, err := m.getOwnedScenario(ctx, scenarioID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !scenario.Status.Active() {
+		return nil, fmt.Errorf("%w: %s is %s", ErrScenarioNotRunning, scenarioID, scenario.Status)
+	}
+
+	scenario.LastActivityAt = time.Now()
+	if err := m.store().TouchScenario(ctx, scenarioID, scenario.LastActivityAt); err != nil {
+		return nil, fmt.Errorf("failed to record heartbeat for scenario %s: %w", scenarioID, err)
+	}
+	m.heartbeatMu.Lock()
+	m.heartbeats[key] = scenario.LastActivityAt
+	m.heartbeatMu.Unlock()
+
+	return &types.HeartbeatResponse{
+		ScenarioID:      scenarioID,
+		LastActivityAt:  scenario.LastActivityAt,
+		NextHeartbeatAt: scenario.LastActivityAt.Add(interval),
+	}, nil
+}
+
+// lastHeartbeat returns when key's last heartbeat was recorded if that is
+// within interval of now, dropping any entries that have aged out
+func (m *Manager) lastHeartbeat(key heartbeatKey, now time.Time, interval time.Duration) (time.Time, bool) {
+	m.heartbeatMu.Lock()
+	defer m.heartbeatMu.Unlock()
+
+	if m.heartbeats == nil {
+		m.heartbeats = make(map[heartbeatKey]time.Time)
+	}
+	for k, at := range m.heartbeats {
+		if now.Sub(at) >= interval {
+			delete(m.heartbeats, k)
+		}
+	}
+	last, ok := m.heartbeats[key]
+	return last, ok
+}
+
+func (m *Manager) heartbeatInterval() time.Duration {
+	if m.Cfg != nil && m.Cfg.Cleanup.HeartbeatInterval > 0 {
+		return m.Cfg.Cleanup.HeartbeatInterval
+	}
+	return defaultHeartbeatInterval
+}
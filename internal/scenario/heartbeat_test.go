@@ -0,0 +1,103 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	created := time.Now().Add(-2 * time.Hour)
+	store := storage.NewMemoryStore(&storage.Scenario{
+		ScenarioID:     "scn-1",
+		UserID:         "test-user",
+		ContainerID:    "container-1",
+		Status:         types.ScenarioStatusRunning,
+		CreatedAt:      created,
+		LastActivityAt: created,
+	})
+	cfg := &config.Config{Cleanup: config.CleanupConfig{HeartbeatInterval: time.Minute}}
+	manager := &Manager{Cfg: cfg, Docker: &MockDockerClient{}, Store: store}
+
+	resp, err := manager.Heartbeat(ctx, "scn-1", "test-user")
+	require.NoError(t, err)
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.True(t, stored.LastActivityAt.After(created), "the heartbeat updates the activity timestamp")
+	assert.Equal(t, stored.LastActivityAt, resp.LastActivityAt)
+	assert.Equal(t, resp.LastActivityAt.Add(time.Minute), resp.NextHeartbeatAt)
+
+	t.Run("rate_limited", func(t *testing.T) {
+		_, err := manager.Heartbeat(ctx, "scn-1", "test-user")
+		assert.ErrorIs(t, err, ErrHeartbeatTooFrequent)
+
+		again, err := store.GetScenario(ctx, "scn-1")
+		require.NoError(t, err)
+		assert.Equal(t, stored.LastActivityAt, again.LastActivityAt, "a rate-limited heartbeat is not recorded")
+	})
+
+	t.Run("other_user_not_limited_by_owner", func(t *testing.T) {
+		_, err := manager.Heartbeat(ctx, "scn-1", "other-user")
+		assert.ErrorIs(t, err, ErrNotScenarioOwner)
+	})
+
+	t.Run("accepted_after_interval", func(t *testing.T) {
+		key := heartbeatKey{scenarioID: "scn-1", userID: "test-user"}
+		manager.heartbeatMu.Lock()
+		manager.heartbeats[key] = time.Now().Add(-time.Minute)
+		manager.heartbeatMu.Unlock()
+
+		resp, err := manager.Heartbeat(ctx, "scn-1", "test-user")
+		require.NoError(t, err)
+		assert.False(t, resp.LastActivityAt.Before(stored.LastActivityAt))
+	})
+
+	t.Run("stopped_scenario_rejected", func(t *testing.T) {
+		require.NoError(t, store.StoreScenario(ctx, &storage.Scenario{
+			ScenarioID: "scn-2",
+			UserID:     "test-user",
+			Status:     types.ScenarioStatusStopped,
+			CreatedAt:  created,
+		}))
+		_, err := manager.Heartbeat(ctx, "scn-2", "test-user")
+		assert.ErrorIs(t, err, ErrScenarioNotRunning)
+	})
+}
+
+// failingTouchStore fails every TouchScenario
+type failingTouchStore struct {
+	storage.Store
+}
+
+func (s *failingTouchStore) TouchScenario(ctx context.Context, scenarioID string, at time.Time) error {
+	return errors.New("mongo unavailable")
+}
+
+func TestHeartbeat_StoreError(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(&storage.Scenario{
+		ScenarioID: "scn-1",
+		UserID:     "test-user",
+		Status:     types.ScenarioStatusRunning,
+		CreatedAt:  time.Now(),
+	})
+	cfg := &config.Config{Cleanup: config.CleanupConfig{HeartbeatInterval: time.Minute}}
+	manager := &Manager{Cfg: cfg, Docker: &MockDockerClient{}, Store: &failingTouchStore{Store: store}}
+
+	resp, err := manager.Heartbeat(ctx, "scn-1", "test-user")
+	assert.ErrorContains(t, err, "mongo unavailable")
+	assert.Nil(t, resp)
+
+	// The failed heartbeat is not rate-limited against, so a retry reaches the store
+	manager.Store = store
+	_, err = manager.Heartbeat(ctx, "scn-1", "test-user")
+	assert.NoError(t, err)
+}
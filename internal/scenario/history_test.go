@@ -0,0 +1,73 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusHistory_RecordsTransitions(t *testing.T) {
+	ctx := context.Background()
+	provisioning := &storage.Scenario{ScenarioID: "scn-1", UserID: "test-user", ScenarioType: "go", ContainerID: "container-1"}
+	provisioning.SetStatus(types.ScenarioStatusProvisioning, "container created")
+	store := storage.NewMemoryStore(provisioning)
+
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(true, nil)
+	mockDocker.On("GetContainerStatus", mock.Anything, "container-1").Return("running", nil)
+	mockDocker.On("PauseContainer", mock.Anything, "container-1").Return(nil)
+	mockDocker.On("UnpauseContainer", mock.Anything, "container-1").Return(nil)
+	mockDocker.On("StopContainer", mock.Anything, "container-1").Return(nil)
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	_, err := manager.GetScenarioStatus(ctx, "scn-1")
+	require.NoError(t, err)
+	// Polling a running scenario again is not a transition
+	_, err = manager.GetScenarioStatus(ctx, "scn-1")
+	require.NoError(t, err)
+	_, err = manager.PauseScenario(ctx, "scn-1", "test-user")
+	require.NoError(t, err)
+	_, err = manager.ResumeScenario(ctx, "scn-1", "test-user")
+	require.NoError(t, err)
+	require.NoError(t, manager.StopScenario(ctx, "scn-1"))
+
+	resp, err := manager.DescribeScenario(ctx, "scn-1", "test-user", false)
+	require.NoError(t, err)
+
+	type transition struct {
+		Status types.ScenarioStatus
+		Reason string
+	}
+	var got []transition
+	for i, change := range resp.StatusHistory {
+		got = append(got, transition{change.Status, change.Reason})
+		if i > 0 {
+			assert.False(t, change.At.Before(resp.StatusHistory[i-1].At), "transitions must be in order")
+		}
+	}
+	assert.Equal(t, []transition{
+		{types.ScenarioStatusProvisioning, "container created"},
+		{types.ScenarioStatusRunning, "container running"},
+		{types.ScenarioStatusPaused, "paused by user"},
+		{types.ScenarioStatusRunning, "resumed by user"},
+		{types.ScenarioStatusStopped, "stopped by user"},
+	}, got)
+}
+
+func TestStatusHistory_Capped(t *testing.T) {
+	scenario := &storage.Scenario{ScenarioID: "scn-1"}
+	for i := 0; i < storage.MaxStatusHistory; i++ {
+		scenario.SetStatus(types.ScenarioStatusPaused, "paused by user")
+		scenario.SetStatus(types.ScenarioStatusRunning, "resumed by user")
+	}
+
+	require.Len(t, scenario.StatusHistory, storage.MaxStatusHistory)
+	assert.Equal(t, types.ScenarioStatusRunning, scenario.StatusHistory[storage.MaxStatusHistory-1].Status)
+	assert.Equal(t, types.ScenarioStatusPaused, scenario.StatusHistory[0].Status, "the oldest transitions are dropped")
+}
@@ -0,0 +1,43 @@
+package scenario
+
+import (
+	"devlab/internal/config"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Scenario ID formats accepted by config.ScenarioIDConfig
+const (
+	IDFormatUUIDv7 = "uuidv7"
+	IDFormatUUIDv4 = "uuidv4"
+)
+
+// DefaultIDPrefix starts every scenario ID when no prefix is configured
+const DefaultIDPrefix = "scn-"
+
+// newScenarioID returns a new scenario ID. Uniqueness comes from the UUID's
+// random bits rather than the wall clock, so IDs generated concurrently, or on
+// a host with a coarse clock, never collide. UUIDv7 IDs also sort in creation
+// order; UUIDv4 IDs do not reveal when the scenario was created.
+func newScenarioID(cfg config.ScenarioIDConfig) (string, error) {
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = DefaultIDPrefix
+	}
+
+	var id uuid.UUID
+	var err error
+	switch cfg.Format {
+	case "", IDFormatUUIDv7:
+		id, err = uuid.NewV7()
+	case IDFormatUUIDv4:
+		id, err = uuid.NewRandom()
+	default:
+		return "", fmt.Errorf("unknown scenario ID format %q", cfg.Format)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to generate scenario ID: %w", err)
+	}
+	return prefix + id.String(), nil
+}
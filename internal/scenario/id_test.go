@@ -0,0 +1,75 @@
+package scenario
+
+import (
+	"devlab/internal/config"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewScenarioID_Concurrent(t *testing.T) {
+	const workers, perWorker = 16, 500
+
+	for _, format := range []string{IDFormatUUIDv7, IDFormatUUIDv4} {
+		t.Run(format, func(t *testing.T) {
+			var wg sync.WaitGroup
+			ids := make(chan string, workers*perWorker)
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < perWorker; i++ {
+						id, err := newScenarioID(config.ScenarioIDConfig{Format: format})
+						if !assert.NoError(t, err) {
+							return
+						}
+						ids <- id
+					}
+				}()
+			}
+			wg.Wait()
+			close(ids)
+
+			seen := make(map[string]bool, workers*perWorker)
+			for id := range ids {
+				require.False(t, seen[id], "duplicate scenario ID %s", id)
+				seen[id] = true
+			}
+			assert.Len(t, seen, workers*perWorker)
+		})
+	}
+}
+
+func TestNewScenarioID_Format(t *testing.T) {
+	id, err := newScenarioID(config.ScenarioIDConfig{})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(id, DefaultIDPrefix), id)
+	parsed, err := uuid.Parse(strings.TrimPrefix(id, DefaultIDPrefix))
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(7), parsed.Version())
+
+	id, err = newScenarioID(config.ScenarioIDConfig{Prefix: "lab_", Format: IDFormatUUIDv4})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(id, "lab_"), id)
+	parsed, err = uuid.Parse(strings.TrimPrefix(id, "lab_"))
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(4), parsed.Version())
+
+	_, err = newScenarioID(config.ScenarioIDConfig{Format: "snowflake"})
+	assert.Error(t, err)
+}
+
+func TestNewScenarioID_SortsInCreationOrder(t *testing.T) {
+	ids := make([]string, 1000)
+	for i := range ids {
+		id, err := newScenarioID(config.ScenarioIDConfig{Format: IDFormatUUIDv7})
+		require.NoError(t, err)
+		ids[i] = id
+	}
+	assert.True(t, sort.StringsAreSorted(ids), "UUIDv7 scenario IDs should sort in creation order")
+}
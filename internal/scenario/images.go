@@ -0,0 +1,41 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/docker"
+	"fmt"
+	"log"
+)
+
+// scenarioImage returns the image to create a scenarioType container from,
+// pinned to a digest when one is configured or was resolved at startup
+func (m *Manager) scenarioImage(scenarioType string) string {
+	var defaultImage string
+	if m.Cfg != nil {
+		defaultImage = m.Cfg.DefaultScenarioImage
+	}
+	image := docker.ImageForScenarioType(scenarioType, defaultImage)
+	if pinned, ok := m.ImagePins[image]; ok {
+		return pinned
+	}
+	return image
+}
+
+// ImageAvailability reports, for each of images, whether it is present on the
+// Docker host. A daemon failure aborts the whole check rather than reporting
+// every image as missing.
+func (m *Manager) ImageAvailability(ctx context.Context, images []string) (map[string]bool, error) {
+	available := make(map[string]bool, len(images))
+	for _, image := range images {
+		if _, seen := available[image]; seen {
+			continue
+		}
+		exists, err := m.Docker.ImageExists(ctx, image)
+		if err != nil {
+			log.Printf("[scenario] failed to check image %s: %v", image, err)
+			return nil, fmt.Errorf("failed to check image %s: %w", image, err)
+		}
+		available[image] = exists
+	}
+	return available, nil
+}
@@ -0,0 +1,37 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/docker"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageAvailability(t *testing.T) {
+	mockDocker := new(MockDockerClient)
+	mockDocker.On("ImageExists", mock.Anything, "devlab-go:latest").Return(true, nil).Once()
+	mockDocker.On("ImageExists", mock.Anything, "devlab-python:latest").Return(false, nil).Once()
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker}
+
+	available, err := manager.ImageAvailability(context.Background(), []string{"devlab-go:latest", "devlab-python:latest", "devlab-go:latest"})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"devlab-go:latest": true, "devlab-python:latest": false}, available)
+	mockDocker.AssertExpectations(t)
+}
+
+func TestImageAvailability_DockerUnavailable(t *testing.T) {
+	mockDocker := new(MockDockerClient)
+	mockDocker.On("ImageExists", mock.Anything, "devlab-go:latest").Return(false, docker.ErrDockerDaemonUnavailable)
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker}
+
+	available, err := manager.ImageAvailability(context.Background(), []string{"devlab-go:latest", "devlab-python:latest"})
+
+	assert.ErrorIs(t, err, docker.ErrDockerDaemonUnavailable)
+	assert.Nil(t, available)
+	mockDocker.AssertNotCalled(t, "ImageExists", mock.Anything, "devlab-python:latest")
+}
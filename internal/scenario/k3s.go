@@ -0,0 +1,69 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/docker"
+	"devlab/internal/storage"
+	"fmt"
+	"strings"
+	"time"
+
+	zerologlog "github.com/rs/zerolog/log"
+)
+
+// k3sProbeTimeout bounds a single kubectl readiness probe. The probe runs
+// inside the reconcile loop, so it is kept short: a node that is not ready
+// is simply probed again on the next pass.
+const k3sProbeTimeout = 2 * time.Second
+
+// k3sProbeCommand lists the cluster's nodes, one per line with the status
+// second, giving up on an API server that does not answer within the probe
+var k3sProbeCommand = []string{"kubectl", "get", "nodes", "--no-headers", "--request-timeout=1s"}
+
+// k3sReadyTimeout returns how long a scenario may wait for k3s to come up
+// before it is failed, or zero when the scenario is not gated on k3s
+func (m *Manager) k3sReadyTimeout(scenarioType string) time.Duration {
+	if m.Cfg == nil || !docker.IsK8sScenarioType(scenarioType) {
+		return 0
+	}
+	return m.Cfg.Container.K3sReadyTimeout
+}
+
+// k3sReady probes whether a k8s scenario's k3s node is Ready. Scenarios that
+// are not gated on k3s are always ready. An error is returned once the
+// scenario has waited longer than the configured timeout.
+func (m *Manager) k3sReady(ctx context.Context, scenario *storage.Scenario) (bool, error) {
+	timeout := m.k3sReadyTimeout(scenario.ScenarioType)
+	if timeout <= 0 {
+		return true, nil
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, k3sProbeTimeout)
+	defer cancel()
+	output, err := m.Docker.ExecuteCommand(probeCtx, scenario.ContainerID, k3sProbeCommand, docker.ExecuteCommandOpts{
+		User:       docker.ScenarioUser,
+		WorkingDir: docker.ScenarioHomeDir,
+		Env:        docker.ScenarioExecEnv(scenario.ScenarioType),
+	})
+	if err == nil && nodeReady(output) {
+		return true, nil
+	}
+	zerologlog.Debug().Msgf("[scenario] k3s not ready yet for scenario %s: %v", scenario.ScenarioID, err)
+
+	if waited := time.Since(scenario.CreatedAt); waited >= timeout {
+		return false, fmt.Errorf("k3s not ready after %s", waited.Round(time.Second))
+	}
+	return false, nil
+}
+
+// nodeReady reports whether kubectl get nodes output lists a Ready node
+func nodeReady(output string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		// Cordoned nodes report e.g. "Ready,SchedulingDisabled"
+		if len(fields) >= 2 && strings.Split(fields[1], ",")[0] == "Ready" {
+			return true
+		}
+	}
+	return false
+}
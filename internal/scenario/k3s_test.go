@@ -0,0 +1,102 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileProvisioning_K3sReadiness(t *testing.T) {
+	ctx := context.Background()
+	const timeout = 3 * time.Minute
+
+	tests := []struct {
+		name           string
+		scenarioType   string
+		age            time.Duration
+		probeOutput    string
+		probeErr       error
+		expectProbe    bool
+		expectedStatus types.ScenarioStatus
+		expectedReason types.StopReason
+	}{
+		{name: "ready", scenarioType: "k8s", age: time.Minute, probeOutput: "devlab   Ready   control-plane,master   40s   v1.29.1+k3s1\n", expectProbe: true, expectedStatus: types.ScenarioStatusRunning},
+		{name: "node_not_ready", scenarioType: "go-k8s", age: time.Minute, probeOutput: "devlab   NotReady   control-plane,master   5s   v1.29.1+k3s1\n", expectProbe: true, expectedStatus: types.ScenarioStatusProvisioning},
+		{name: "api_not_up", scenarioType: "k8s", age: time.Minute, probeErr: errors.New("exit code 1"), expectProbe: true, expectedStatus: types.ScenarioStatusProvisioning},
+		{name: "timed_out", scenarioType: "python-k8s", age: timeout + time.Second, probeErr: errors.New("exit code 1"), expectProbe: true, expectedStatus: types.ScenarioStatusFailed, expectedReason: types.StopReasonFailed},
+		{name: "not_a_k8s_type", scenarioType: "go", age: time.Minute, expectedStatus: types.ScenarioStatusRunning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore(&storage.Scenario{
+				ScenarioID:   "scn-1",
+				ScenarioType: tt.scenarioType,
+				ContainerID:  "container-1",
+				Status:       types.ScenarioStatusProvisioning,
+				CreatedAt:    time.Now().Add(-tt.age),
+			})
+			mockDocker := &MockDockerClient{}
+			mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(true, nil)
+			mockDocker.On("GetContainerStatus", mock.Anything, "container-1").Return("running", nil)
+			mockDocker.On("ExecuteCommand", mock.Anything, "container-1", k3sProbeCommand).
+				Run(func(args mock.Arguments) {
+					// The probe must not hold up the reconcile loop for long
+					deadline, ok := args.Get(0).(context.Context).Deadline()
+					require.True(t, ok)
+					assert.LessOrEqual(t, time.Until(deadline), k3sProbeTimeout)
+				}).
+				Return(tt.probeOutput, tt.probeErr)
+			mockDocker.On("StopContainer", mock.Anything, "container-1").Return(nil)
+
+			cfg := &config.Config{Container: config.ContainerConfig{K3sReadyTimeout: timeout}}
+			manager := &Manager{Cfg: cfg, Docker: mockDocker, Store: store}
+			require.NoError(t, manager.ReconcileProvisioning(ctx))
+
+			stored, err := store.GetScenario(ctx, "scn-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, stored.Status)
+			assert.Equal(t, tt.expectedReason, stored.StopReason)
+			if tt.expectProbe {
+				mockDocker.AssertCalled(t, "ExecuteCommand", mock.Anything, "container-1", k3sProbeCommand)
+			} else {
+				mockDocker.AssertNotCalled(t, "ExecuteCommand", mock.Anything, "container-1", k3sProbeCommand)
+			}
+			if tt.expectedStatus == types.ScenarioStatusFailed {
+				mockDocker.AssertCalled(t, "StopContainer", mock.Anything, "container-1")
+			} else {
+				mockDocker.AssertNotCalled(t, "StopContainer", mock.Anything, "container-1")
+			}
+		})
+	}
+}
+
+func TestGetScenarioStatus_K3sGatedScenarioStaysProvisioning(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(&storage.Scenario{
+		ScenarioID:   "scn-1",
+		ScenarioType: "k8s",
+		ContainerID:  "container-1",
+		Status:       types.ScenarioStatusProvisioning,
+		CreatedAt:    time.Now(),
+	})
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(true, nil)
+	mockDocker.On("GetContainerStatus", mock.Anything, "container-1").Return("running", nil)
+
+	cfg := &config.Config{Container: config.ContainerConfig{K3sReadyTimeout: time.Minute}}
+	manager := &Manager{Cfg: cfg, Docker: mockDocker, Store: store}
+	resp, err := manager.GetScenarioStatus(ctx, "scn-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusProvisioning, resp.Status)
+	mockDocker.AssertNotCalled(t, "ExecuteCommand", mock.Anything, mock.Anything, mock.Anything)
+}
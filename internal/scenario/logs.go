@@ -0,0 +1,33 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/docker"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// FollowScenarioLogs opens a live stream of the output of the container of a
+// scenario owned by userID, starting with its last tail lines. The stream ends
+// when the container exits; the caller must close it.
+func (m *Manager) FollowScenarioLogs(ctx context.Context, scenarioID, userID string, tail int) (*docker.LogStream, error) {
+	scenario, err := m.getOwnedScenario(ctx, scenarioID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if scenario.ContainerID == "" {
+		return nil, fmt.Errorf("%w: %s has no container", ErrScenarioNotRunning, scenarioID)
+	}
+	m.recordActivity(ctx, scenario)
+
+	stream, err := m.Docker.FollowLogs(ctx, scenario.ContainerID, tail)
+	if err != nil {
+		log.Printf("[scenario] failed to follow logs of scenario %s: %v", scenarioID, err)
+		if errors.Is(err, docker.ErrContainerNotFound) {
+			return nil, fmt.Errorf("%w: container %s not found", ErrScenarioNotRunning, scenario.ContainerID)
+		}
+		return nil, fmt.Errorf("failed to follow logs: %w", err)
+	}
+	return stream, nil
+}
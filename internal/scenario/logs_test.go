@@ -0,0 +1,60 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/docker"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowScenarioLogs(t *testing.T) {
+	ctx := context.Background()
+	newManager := func(mockDocker *MockDockerClient) *Manager {
+		return &Manager{
+			Cfg:    &config.Config{},
+			Docker: mockDocker,
+			Store: storage.NewMemoryStore(
+				&storage.Scenario{ScenarioID: "scn-1", UserID: "owner", ContainerID: "container-1", Status: types.ScenarioStatusRunning},
+				&storage.Scenario{ScenarioID: "scn-2", UserID: "owner", Status: types.ScenarioStatusCleanedUp},
+			),
+		}
+	}
+
+	t.Run("streams_owned_scenario", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		stream := docker.NewLogStream(io.NopCloser(strings.NewReader("hello\n")), true)
+		mockDocker.On("FollowLogs", mock.Anything, "container-1", 50).Return(stream, nil)
+
+		got, err := newManager(mockDocker).FollowScenarioLogs(ctx, "scn-1", "owner", 50)
+		require.NoError(t, err)
+		assert.Same(t, stream, got)
+	})
+
+	t.Run("other_user", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		_, err := newManager(mockDocker).FollowScenarioLogs(ctx, "scn-1", "intruder", 50)
+		assert.ErrorIs(t, err, ErrNotScenarioOwner)
+		mockDocker.AssertNotCalled(t, "FollowLogs", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("no_container", func(t *testing.T) {
+		_, err := newManager(&MockDockerClient{}).FollowScenarioLogs(ctx, "scn-2", "owner", 50)
+		assert.ErrorIs(t, err, ErrScenarioNotRunning)
+	})
+
+	t.Run("container_gone", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("FollowLogs", mock.Anything, "container-1", 0).Return(nil, docker.ErrContainerNotFound)
+
+		_, err := newManager(mockDocker).FollowScenarioLogs(ctx, "scn-1", "owner", 0)
+		assert.ErrorIs(t, err, ErrScenarioNotRunning)
+	})
+}
@@ -0,0 +1,76 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/types"
+	"fmt"
+	"log"
+	"time"
+)
+
+// PauseScenario freezes the container of a running scenario owned by userID
+// so it stops using CPU while keeping its state. Pausing an already paused
+// scenario succeeds without changes. A paused scenario still counts against
+// capacity and still expires, but is not reaped for inactivity.
+func (m *Manager) PauseScenario(ctx context.Context, scenarioID, userID string) (*types.PauseScenarioResponse, error) {
+	scenario, err := m.getOwnedScenario(ctx, scenarioID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if scenario.Status == types.ScenarioStatusPaused {
+		return &types.PauseScenarioResponse{ScenarioID: scenarioID, Status: scenario.Status, Message: "Scenario is already paused"}, nil
+	}
+	if scenario.Status != types.ScenarioStatusRunning {
+		return nil, fmt.Errorf("%w: %s is %s", ErrScenarioNotRunning, scenarioID, scenario.Status)
+	}
+
+	if err := m.Docker.PauseContainer(ctx, scenario.ContainerID); err != nil {
+		log.Printf("[scenario] failed to pause container %s for scenario %s: %v", scenario.ContainerID, scenarioID, err)
+		return nil, fmt.Errorf("failed to pause scenario: %w", err)
+	}
+
+	scenario.SetStatus(types.ScenarioStatusPaused, "paused by user")
+	scenario.UpdatedAt = time.Now()
+	if err := m.updateStatus(ctx, scenario); err != nil {
+		log.Printf("[scenario] failed to store paused status for scenario %s: %v", scenarioID, err)
+		return nil, fmt.Errorf("failed to update scenario: %w", err)
+	}
+
+	log.Printf("[scenario] paused scenario %s", scenarioID)
+	return &types.PauseScenarioResponse{ScenarioID: scenarioID, Status: scenario.Status, Message: "Scenario paused successfully"}, nil
+}
+
+// ResumeScenario unfreezes a paused scenario owned by userID. Resuming counts
+// as activity, so the time spent paused does not make the scenario idle.
+// Resuming a running scenario succeeds without changes.
+func (m *Manager) ResumeScenario(ctx context.Context, scenarioID, userID string) (*types.PauseScenarioResponse, error) {
+	scenario, err := m.getOwnedScenario(ctx, scenarioID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if scenario.Status == types.ScenarioStatusRunning {
+		return &types.PauseScenarioResponse{ScenarioID: scenarioID, Status: scenario.Status, Message: "Scenario is already running"}, nil
+	}
+	if scenario.Status != types.ScenarioStatusPaused {
+		return nil, fmt.Errorf("%w: %s is %s", ErrScenarioNotPaused, scenarioID, scenario.Status)
+	}
+
+	if err := m.Docker.UnpauseContainer(ctx, scenario.ContainerID); err != nil {
+		log.Printf("[scenario] failed to unpause container %s for scenario %s: %v", scenario.ContainerID, scenarioID, err)
+		return nil, fmt.Errorf("failed to resume scenario: %w", err)
+	}
+
+	now := time.Now()
+	scenario.SetStatus(types.ScenarioStatusRunning, "resumed by user")
+	scenario.UpdatedAt = now
+	scenario.LastActivityAt = now
+	if err := m.updateStatus(ctx, scenario); err != nil {
+		log.Printf("[scenario] failed to store resumed status for scenario %s: %v", scenarioID, err)
+		return nil, fmt.Errorf("failed to update scenario: %w", err)
+	}
+
+	log.Printf("[scenario] resumed scenario %s", scenarioID)
+	return &types.PauseScenarioResponse{ScenarioID: scenarioID, Status: scenario.Status, Message: "Scenario resumed successfully"}, nil
+}
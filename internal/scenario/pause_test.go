@@ -0,0 +1,111 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/docker"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseAndResumeScenario(t *testing.T) {
+	ctx := context.Background()
+	created := time.Now().Add(-2 * time.Hour)
+	store := storage.NewMemoryStore(&storage.Scenario{
+		ScenarioID:     "scn-1",
+		UserID:         "test-user",
+		ContainerID:    "container-1",
+		Status:         types.ScenarioStatusRunning,
+		CreatedAt:      created,
+		LastActivityAt: created,
+	})
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("PauseContainer", mock.Anything, "container-1").Return(nil).Once()
+	mockDocker.On("UnpauseContainer", mock.Anything, "container-1").Return(nil).Once()
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	resp, err := manager.PauseScenario(ctx, "scn-1", "test-user")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusPaused, resp.Status)
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusPaused, stored.Status)
+	assert.True(t, stored.Status.Active(), "a paused scenario still holds its container")
+
+	// Pausing again is a no-op
+	resp, err = manager.PauseScenario(ctx, "scn-1", "test-user")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusPaused, resp.Status)
+
+	resp, err = manager.ResumeScenario(ctx, "scn-1", "test-user")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusRunning, resp.Status)
+	stored, err = store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusRunning, stored.Status)
+	assert.True(t, stored.LastActivityAt.After(created), "resuming counts as activity")
+
+	// Resuming a running scenario is a no-op
+	resp, err = manager.ResumeScenario(ctx, "scn-1", "test-user")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusRunning, resp.Status)
+
+	mockDocker.AssertExpectations(t)
+}
+
+func TestPauseScenario_InvalidTransitions(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(
+		&storage.Scenario{ScenarioID: "scn-provisioning", UserID: "test-user", ContainerID: "container-1", Status: types.ScenarioStatusProvisioning},
+		&storage.Scenario{ScenarioID: "scn-stopped", UserID: "test-user", ContainerID: "container-2", Status: types.ScenarioStatusStopped},
+		&storage.Scenario{ScenarioID: "scn-running", UserID: "test-user", ContainerID: "container-3", Status: types.ScenarioStatusRunning},
+	)
+	mockDocker := &MockDockerClient{}
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	_, err := manager.PauseScenario(ctx, "scn-provisioning", "test-user")
+	assert.ErrorIs(t, err, ErrScenarioNotRunning)
+
+	_, err = manager.PauseScenario(ctx, "scn-stopped", "test-user")
+	assert.ErrorIs(t, err, ErrScenarioNotRunning)
+
+	_, err = manager.ResumeScenario(ctx, "scn-stopped", "test-user")
+	assert.ErrorIs(t, err, ErrScenarioNotPaused)
+
+	_, err = manager.PauseScenario(ctx, "scn-running", "other-user")
+	assert.ErrorIs(t, err, ErrNotScenarioOwner)
+
+	mockDocker.AssertNotCalled(t, "PauseContainer", mock.Anything, mock.Anything)
+	mockDocker.AssertNotCalled(t, "UnpauseContainer", mock.Anything, mock.Anything)
+}
+
+func TestPauseScenario_DockerError(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", UserID: "test-user", ContainerID: "container-1", Status: types.ScenarioStatusRunning})
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("PauseContainer", mock.Anything, "container-1").Return(docker.ErrDockerDaemonUnavailable)
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	_, err := manager.PauseScenario(ctx, "scn-1", "test-user")
+	assert.ErrorIs(t, err, docker.ErrDockerDaemonUnavailable)
+
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusRunning, stored.Status, "status is unchanged when Docker fails")
+}
+
+func TestPausedScenarioCountsTowardsCapacity(t *testing.T) {
+	store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", UserID: "user-a", ContainerID: "container-1", Status: types.ScenarioStatusPaused})
+	mockDocker := &MockDockerClient{}
+	manager := &Manager{Cfg: &config.Config{MaxTotalScenarios: 1}, Docker: mockDocker, Store: store}
+
+	_, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{UserID: "user-b", ScenarioType: "go"})
+	assert.ErrorIs(t, err, ErrCapacityReached)
+	mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,17 @@
+package scenario
+
+// allocatePort reserves a ttyd host port from the pool, or returns 0 to leave
+// the choice to the Docker client when no pool is configured
+func (m *Manager) allocatePort() (int, error) {
+	if m.Ports == nil {
+		return 0, nil
+	}
+	return m.Ports.Allocate()
+}
+
+// releasePort returns a scenario's ttyd host port to the pool
+func (m *Manager) releasePort(port int) {
+	if m.Ports != nil && port > 0 {
+		m.Ports.Release(port)
+	}
+}
@@ -0,0 +1,112 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/docker"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// postStartTimeout bounds how long a post-start hook may hold up the scenario
+const postStartTimeout = 5 * time.Minute
+
+// postStartKillGrace is how long the exec is given past the script timeout
+// for the in-container timeout command to kill the hook and report back
+const postStartKillGrace = 10 * time.Second
+
+// startPostStart runs the post-start hook of a provisioning scenario whose
+// container is up in the background, so a slow hook does not hold up the
+// reconciler. The hook is claimed in the store first; a scenario whose hook
+// another pass or process already started is left alone.
+func (m *Manager) startPostStart(ctx context.Context, scenario *storage.Scenario) {
+	if !scenario.PostStartStartedAt.IsZero() {
+		return
+	}
+	claimed, err := m.store().ClaimPostStart(ctx, scenario.ScenarioID, time.Now())
+	if err != nil {
+		log.Printf("[scenario] failed to claim post-start hook for scenario %s: %v", scenario.ScenarioID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		m.runPostStart(ctx, scenario)
+		recorded, err := m.store().FinishPostStart(ctx, scenario)
+		switch {
+		case err != nil:
+			log.Printf("[scenario] failed to record post-start outcome for scenario %s: %v", scenario.ScenarioID, err)
+		case !recorded:
+			log.Printf("[scenario] scenario %s left provisioning while its post-start hook ran, not recording outcome", scenario.ScenarioID)
+		default:
+			log.Printf("[scenario] reconciled scenario %s to %s", scenario.ScenarioID, scenario.Status)
+		}
+	}()
+}
+
+// runPostStart executes the scenario's post-start hook once its container is up
+// and moves the scenario to running. With a script timeout the hook runs under
+// the container's timeout command, so a runaway hook is killed rather than left
+// behind when the exec is abandoned. A failure is recorded on the scenario; a
+// fatal hook also stops the container and marks the scenario failed instead of
+// running. The caller stores the outcome.
+func (m *Manager) runPostStart(ctx context.Context, scenario *storage.Scenario) {
+	command, execTimeout := scenario.PostStart, postStartTimeout
+	if scenario.ScriptTimeout > 0 {
+		seconds := int((scenario.ScriptTimeout + time.Second - 1) / time.Second)
+		command = append([]string{"timeout", strconv.Itoa(seconds)}, scenario.PostStart...)
+		execTimeout = scenario.ScriptTimeout + postStartKillGrace
+	}
+	execCtx, cancel := context.WithTimeout(ctx, execTimeout)
+	defer cancel()
+
+	output, err := m.Docker.ExecuteCommand(execCtx, scenario.ContainerID, command, docker.ExecuteCommandOpts{
+		User:       docker.ScenarioUser,
+		WorkingDir: docker.ScenarioHomeDir,
+		Env:        docker.ScenarioExecEnv(scenario.ScenarioType),
+	})
+	if err == nil {
+		log.Printf("[scenario] post-start hook for scenario %s completed", scenario.ScenarioID)
+		scenario.SetStatus(types.ScenarioStatusRunning, "container running")
+		return
+	}
+
+	scenario.PostStartError = err.Error()
+	if scenario.ScriptTimeout > 0 && postStartTimedOut(execCtx, err) {
+		scenario.PostStartError = fmt.Sprintf("timed out after %s: %v", scenario.ScriptTimeout, err)
+	}
+	if output = strings.TrimSpace(output); output != "" {
+		scenario.PostStartError += ": " + output
+	}
+	log.Printf("[scenario] post-start hook for scenario %s failed: %v", scenario.ScenarioID, err)
+
+	if !scenario.PostStartFatal {
+		scenario.SetStatus(types.ScenarioStatusRunning, "container running")
+		return
+	}
+	if stopErr := m.Docker.StopContainer(context.WithoutCancel(ctx), scenario.ContainerID); stopErr != nil {
+		log.Printf("[scenario] failed to stop container %s after post-start failure: %v", scenario.ContainerID, stopErr)
+	}
+	m.releasePort(scenario.TerminalPort)
+	scenario.SetStatus(types.ScenarioStatusStopped, "post-start hook failed")
+	markStopReason(scenario, types.StopReasonFailed)
+}
+
+// postStartTimedOut reports whether a hook run under the timeout command was
+// cut short: either the command killed it and exited with its timeout code, or
+// the exec itself ran out of time
+func postStartTimedOut(execCtx context.Context, err error) bool {
+	var exitErr *docker.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode == timeoutExitCode {
+		return true
+	}
+	return errors.Is(execCtx.Err(), context.DeadlineExceeded)
+}
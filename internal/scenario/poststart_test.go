@@ -0,0 +1,191 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/docker"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostStartHook(t *testing.T) {
+	ctx := context.Background()
+	hook := []string{"git", "clone", "https://example.com/course.git"}
+
+	tests := []struct {
+		name           string
+		fatal          bool
+		execErr        error
+		expectedStatus types.ScenarioStatus
+		expectedReason types.StopReason
+		expectedError  string
+	}{
+		{name: "succeeds", expectedStatus: types.ScenarioStatusRunning},
+		{name: "fails", execErr: errors.New("exit code 128"), expectedStatus: types.ScenarioStatusRunning, expectedError: "exit code 128: fatal: repository not found"},
+		{name: "fails_fatal", fatal: true, execErr: errors.New("exit code 128"), expectedStatus: types.ScenarioStatusStopped, expectedReason: types.StopReasonFailed, expectedError: "exit code 128: fatal: repository not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			mockDocker := &MockDockerClient{}
+			mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).Return("container-1", 3001, nil)
+			mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(true, nil)
+			mockDocker.On("GetContainerStatus", mock.Anything, "container-1").Return("running", nil)
+			output := ""
+			if tt.execErr != nil {
+				output = "fatal: repository not found\n"
+			}
+			mockDocker.On("ExecuteCommand", mock.Anything, "container-1", hook).Return(output, tt.execErr).Once()
+			if tt.fatal {
+				mockDocker.On("StopContainer", mock.Anything, "container-1").Return(nil)
+			}
+
+			manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+			resp, err := manager.StartScenario(ctx, &types.StartScenarioRequest{
+				UserID:         "test-user",
+				ScenarioType:   "go",
+				PostStart:      hook,
+				PostStartFatal: tt.fatal,
+			})
+			require.NoError(t, err)
+			mockDocker.AssertNotCalled(t, "ExecuteCommand", mock.Anything, "container-1", hook)
+
+			require.NoError(t, manager.ReconcileProvisioning(ctx))
+			// A second pass must not rerun the hook
+			require.NoError(t, manager.ReconcileProvisioning(ctx))
+
+			require.Eventually(t, func() bool {
+				stored, err := store.GetScenario(ctx, resp.ScenarioID)
+				return err == nil && stored.Status != types.ScenarioStatusProvisioning
+			}, time.Second, 5*time.Millisecond)
+			stored, err := store.GetScenario(ctx, resp.ScenarioID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, stored.Status)
+			assert.Equal(t, tt.expectedReason, stored.StopReason)
+			assert.Equal(t, tt.expectedError, stored.PostStartError)
+			mockDocker.AssertExpectations(t)
+		})
+	}
+}
+
+// finishSignalStore signals once a post-start outcome has been written
+type finishSignalStore struct {
+	storage.Store
+	finished chan struct{}
+}
+
+func (f *finishSignalStore) FinishPostStart(ctx context.Context, s *storage.Scenario) (bool, error) {
+	defer close(f.finished)
+	return f.Store.FinishPostStart(ctx, s)
+}
+
+func TestPostStartHook_RunsInBackgroundOnce(t *testing.T) {
+	ctx := context.Background()
+	hook := []string{"make", "setup"}
+	store := &finishSignalStore{
+		Store: storage.NewMemoryStore(&storage.Scenario{
+			ScenarioID: "scn-1", ContainerID: "container-1", Status: types.ScenarioStatusProvisioning, PostStart: hook,
+		}),
+		finished: make(chan struct{}),
+	}
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(true, nil)
+	mockDocker.On("GetContainerStatus", mock.Anything, "container-1").Return("running", nil)
+	release := make(chan struct{})
+	mockDocker.On("ExecuteCommand", mock.Anything, "container-1", hook).
+		Run(func(mock.Arguments) { <-release }).Return("", nil).Once()
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	// Reconciling returns while the hook is still running, and later passes,
+	// from this or another process, leave the claimed hook alone
+	require.NoError(t, manager.ReconcileProvisioning(ctx))
+	require.NoError(t, manager.ReconcileProvisioning(ctx))
+	other := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+	require.NoError(t, other.ReconcileProvisioning(ctx))
+
+	// A stop made while the hook runs is not overwritten by its outcome
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.False(t, stored.PostStartStartedAt.IsZero())
+	stored.SetStatus(types.ScenarioStatusStopped, "stopped by user")
+	stored.StopReason = types.StopReasonUserRequested
+	require.NoError(t, store.UpdateScenario(ctx, stored))
+	close(release)
+
+	select {
+	case <-store.finished:
+	case <-time.After(time.Second):
+		t.Fatal("post-start outcome was never recorded")
+	}
+	stored, err = store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusStopped, stored.Status)
+	assert.Equal(t, types.StopReasonUserRequested, stored.StopReason)
+	mockDocker.AssertNumberOfCalls(t, "ExecuteCommand", 1)
+}
+
+func TestPostStartHook_ScriptTimeout(t *testing.T) {
+	hook := []string{"sh", "-c", "sleep 600"}
+	tests := []struct {
+		name          string
+		err           error
+		expectedError string
+	}{
+		{
+			// The container's timeout command kills the hook, which then exits 124
+			name:          "killed_by_timeout_command",
+			err:           &docker.ExitError{ExitCode: 124},
+			expectedError: "timed out after 50ms: command failed with exit code 124",
+		},
+		{
+			name:          "hook_failed",
+			err:           &docker.ExitError{ExitCode: 1},
+			expectedError: "command failed with exit code 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scenario := &storage.Scenario{
+				ScenarioID:    "scn-1",
+				ContainerID:   "container-1",
+				Status:        types.ScenarioStatusRunning,
+				PostStart:     hook,
+				ScriptTimeout: 50 * time.Millisecond,
+			}
+
+			mockDocker := &MockDockerClient{}
+			mockDocker.On("ExecuteCommand", mock.Anything, "container-1", []string{"timeout", "1", "sh", "-c", "sleep 600"}).
+				Return("", tt.err).Once()
+
+			manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+			manager.runPostStart(context.Background(), scenario)
+
+			assert.Equal(t, tt.expectedError, scenario.PostStartError)
+			assert.Equal(t, types.ScenarioStatusRunning, scenario.Status)
+			mockDocker.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStartScenario_PostStartRejectedInBatchMode(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+	_, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+		UserID:       "test-user",
+		ScenarioType: "go",
+		Mode:         types.ScenarioModeBatch,
+		PostStart:    []string{"true"},
+	})
+	assert.ErrorIs(t, err, ErrInvalidScenarioMode)
+	mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+}
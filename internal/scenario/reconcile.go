@@ -0,0 +1,114 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DefaultReconcileInterval is how often provisioning scenarios are checked when
+// the config leaves it unset
+const DefaultReconcileInterval = 10 * time.Second
+
+// RunReconciler periodically moves provisioning scenarios to running, or to
+// stopped with a failed reason, based on their container state, so the stored
+// status does not depend on clients polling GetScenarioStatus. It also settles
+// batch scenarios whose scripts no process is waiting on.
+func (m *Manager) RunReconciler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReconcileInterval
+	}
+	log.Printf("[scenario] starting provisioning reconciler with interval: %v", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[scenario] stopping provisioning reconciler")
+			return
+		case <-ticker.C:
+			if err := m.ReconcileProvisioning(ctx); err != nil {
+				log.Printf("[scenario] error reconciling provisioning scenarios: %v", err)
+			}
+			if err := m.ReconcileBatches(ctx); err != nil {
+				log.Printf("[scenario] error reconciling batch scenarios: %v", err)
+			}
+		}
+	}
+}
+
+// ReconcileProvisioning checks the container of every provisioning scenario
+// and records the status it has actually reached
+func (m *Manager) ReconcileProvisioning(ctx context.Context) error {
+	scenarios, err := m.store().ListScenariosByStatus(ctx, types.ScenarioStatusProvisioning)
+	if err != nil {
+		return fmt.Errorf("failed to list provisioning scenarios: %w", err)
+	}
+
+	for _, scenario := range scenarios {
+		if err := m.reconcileScenario(ctx, scenario); err != nil {
+			log.Printf("[scenario] failed to reconcile scenario %s: %v", scenario.ScenarioID, err)
+		}
+	}
+	return nil
+}
+
+// reconcileScenario updates a single provisioning scenario from its container
+// state. Containers still starting are left for the next pass.
+func (m *Manager) reconcileScenario(ctx context.Context, scenario *storage.Scenario) error {
+	exists, err := m.Docker.ContainerExists(ctx, scenario.ContainerID)
+	if err != nil {
+		return fmt.Errorf("failed to check container existence: %w", err)
+	}
+
+	if !exists {
+		scenario.SetStatus(types.ScenarioStatusStopped, "container no longer exists")
+		markStopReason(scenario, types.StopReasonOrphaned)
+	} else {
+		containerStatus, err := m.Docker.GetContainerStatus(ctx, scenario.ContainerID)
+		if err != nil {
+			return fmt.Errorf("failed to get container status: %w", err)
+		}
+
+		switch containerStatus {
+		case "running":
+			// k8s scenarios stay provisioning until k3s can serve kubectl
+			ready, err := m.k3sReady(ctx, scenario)
+			if err != nil {
+				log.Printf("[scenario] failing scenario %s: %v", scenario.ScenarioID, err)
+				if stopErr := m.Docker.StopContainer(context.WithoutCancel(ctx), scenario.ContainerID); stopErr != nil {
+					log.Printf("[scenario] failed to stop container %s: %v", scenario.ContainerID, stopErr)
+				}
+				m.releasePort(scenario.TerminalPort)
+				scenario.SetStatus(types.ScenarioStatusFailed, err.Error())
+				markStopReason(scenario, types.StopReasonFailed)
+				break
+			}
+			if !ready {
+				return nil
+			}
+			// A post-start hook moves the scenario on itself once it finishes
+			if len(scenario.PostStart) > 0 {
+				m.startPostStart(ctx, scenario)
+				return nil
+			}
+			scenario.SetStatus(types.ScenarioStatusRunning, "container running")
+		case "exited", "dead":
+			scenario.SetStatus(types.ScenarioStatusStopped, "container "+containerStatus)
+			markStopReason(scenario, types.StopReasonFailed)
+		default:
+			return nil
+		}
+	}
+
+	if err := m.updateStatus(ctx, scenario); err != nil {
+		return fmt.Errorf("failed to update scenario status: %w", err)
+	}
+	log.Printf("[scenario] reconciled scenario %s to %s", scenario.ScenarioID, scenario.Status)
+	return nil
+}
@@ -0,0 +1,78 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileProvisioning(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name            string
+		exists          bool
+		containerStatus string
+		expectedStatus  types.ScenarioStatus
+		expectedReason  types.StopReason
+	}{
+		{name: "running_container", exists: true, containerStatus: "running", expectedStatus: types.ScenarioStatusRunning},
+		{name: "exited_container", exists: true, containerStatus: "exited", expectedStatus: types.ScenarioStatusStopped, expectedReason: types.StopReasonFailed},
+		{name: "missing_container", exists: false, expectedStatus: types.ScenarioStatusStopped, expectedReason: types.StopReasonOrphaned},
+		{name: "still_starting", exists: true, containerStatus: "created", expectedStatus: types.ScenarioStatusProvisioning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStore(
+				&storage.Scenario{ScenarioID: "scn-1", ContainerID: "container-1", Status: types.ScenarioStatusProvisioning},
+				// Scenarios in other states are not touched
+				&storage.Scenario{ScenarioID: "scn-2", ContainerID: "container-2", Status: types.ScenarioStatusStopped},
+			)
+			mockDocker := &MockDockerClient{}
+			mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(tt.exists, nil)
+			if tt.exists {
+				mockDocker.On("GetContainerStatus", mock.Anything, "container-1").Return(tt.containerStatus, nil)
+			}
+
+			manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+			require.NoError(t, manager.ReconcileProvisioning(ctx))
+
+			stored, err := store.GetScenario(ctx, "scn-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, stored.Status)
+			assert.Equal(t, tt.expectedReason, stored.StopReason)
+			mockDocker.AssertNotCalled(t, "ContainerExists", mock.Anything, "container-2")
+		})
+	}
+}
+
+func TestRunReconciler_ReachesRunningWithoutStatusCalls(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := storage.NewMemoryStore()
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).Return("container-1", 3001, nil)
+	mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(true, nil)
+	mockDocker.On("GetContainerStatus", mock.Anything, "container-1").Return("running", nil)
+
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+	resp, err := manager.StartScenario(ctx, &types.StartScenarioRequest{UserID: "test-user", ScenarioType: "go"})
+	require.NoError(t, err)
+	require.Equal(t, types.ScenarioStatusProvisioning, resp.Status)
+
+	go manager.RunReconciler(ctx, 5*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		stored, err := store.GetScenario(context.Background(), resp.ScenarioID)
+		return err == nil && stored.Status == types.ScenarioStatusRunning
+	}, time.Second, 5*time.Millisecond)
+}
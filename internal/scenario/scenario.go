@@ -2,37 +2,138 @@ package scenario
 
 import (
 	"context"
+	"crypto/rand"
 	"devlab/internal/config"
 	"devlab/internal/docker"
+	"devlab/internal/retry"
 	"devlab/internal/storage"
 	"devlab/internal/types"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"math"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	zerologlog "github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// Custom error types for scenario management
+// Custom error types for scenario management. Those created with retry.New
+// clear up on their own, so callers may try again later.
 var (
 	ErrScenarioNotFound       = errors.New("scenario not found")
 	ErrScenarioNotRunning     = errors.New("scenario is not running")
+	ErrScenarioNotPaused      = errors.New("scenario is not paused")
 	ErrScenarioAlreadyStopped = errors.New("scenario is already stopped")
 	ErrInvalidScenarioID      = errors.New("invalid scenario ID")
-	ErrDatabaseUnavailable    = errors.New("database unavailable")
+	ErrDatabaseUnavailable    = retry.New("database unavailable")
+	ErrClientCancelled        = errors.New("request cancelled by client")
+	ErrNotScenarioOwner       = errors.New("scenario belongs to another user")
+	ErrMaxLifetimeReached     = errors.New("scenario has reached its maximum lifetime")
+	ErrInvalidScenarioMode    = errors.New("invalid scenario mode")
+	ErrNoResults              = errors.New("scenario has no results")
+	ErrResultsNotReady        = retry.New("scenario results are not ready yet")
+	ErrCapacityReached        = retry.New("maximum number of active scenarios reached")
+	ErrInvalidDirectoryFormat = errors.New("invalid directory format")
+	ErrInvalidPageToken       = errors.New("invalid page token")
+	ErrInvalidOverride        = errors.New("invalid container override")
+	ErrInvalidSearchQuery     = errors.New("invalid search query")
+	ErrScriptTooLarge         = errors.New("script is too large")
+	ErrProvisioningBusy       = retry.New("too many scenarios are being provisioned")
+	ErrInvalidScriptTimeout   = errors.New("invalid script timeout")
+	ErrInvalidCommand         = errors.New("invalid command")
+	ErrCommandNotAllowed      = errors.New("command is not allowed")
+	ErrHeartbeatTooFrequent   = retry.New("heartbeat sent too frequently")
+	ErrInvalidBulkStop        = errors.New("invalid bulk stop request")
+	ErrInvalidTTL             = errors.New("invalid scenario TTL")
+	ErrInvalidNotes           = errors.New("invalid scenario notes")
+	ErrProvisioningTimeout    = errors.New("scenario provisioning timed out")
+	ErrUnknownTemplate        = errors.New("unknown scenario template")
+	ErrNoTerminal             = errors.New("scenario has no web terminal")
+	ErrTerminalRestartFailed  = errors.New("web terminal failed to restart")
 )
 
+// Page sizes for ListUserScenariosPage
+const (
+	DefaultListPageSize = 20
+	MaxListPageSize     = 100
+)
+
+// Lifetime defaults used when the config leaves them unset
+const (
+	defaultMaxScenarioAge      = 24 * time.Hour
+	defaultExtendIncrement     = time.Hour
+	defaultMaxScenarioLifetime = 72 * time.Hour
+)
+
+// defaultMaxScriptBytes limits scripts when the config leaves it unset
+const defaultMaxScriptBytes = 64 * 1024
+
 type Manager struct {
 	Cfg    *config.Config
 	DB     *mongo.Database
 	Docker docker.Client
+	// Store overrides the persistence backed by DB when set
+	Store storage.Store
+
+	// capacityMu guards pending, the starts admitted but not yet stored
+	capacityMu sync.Mutex
+	pending    int
+
+	// provisionSlots bounds concurrent StartScenarioContainer calls
+	provisionOnce  sync.Once
+	provisionSlots chan struct{}
+
+	// Ports hands out ttyd host ports; when nil the Docker client scans for one
+	Ports *docker.PortPool
+	// ImagePins maps scenario images to the digest-pinned references their
+	// containers are created from
+	ImagePins map[string]string
+	// Templates holds the scenario templates start requests may name, keyed
+	// by name
+	Templates map[string]types.ScenarioTemplate
+
+	// heartbeats holds when each user last had a heartbeat recorded per
+	// scenario, so rate-limited heartbeats never reach the store
+	heartbeatMu sync.Mutex
+	heartbeats  map[heartbeatKey]time.Time
+
+	// batches holds the IDs of the batch scenarios this process is waiting on
+	batches sync.Map
 }
 
 func NewManager(cfg *config.Config, db *mongo.Database, dockerClient docker.Client) *Manager {
-	return &Manager{Cfg: cfg, DB: db, Docker: dockerClient}
+	m := &Manager{Cfg: cfg, DB: db, Docker: dockerClient, ImagePins: make(map[string]string)}
+	if cfg != nil {
+		m.Ports = docker.NewPortPool(cfg.Container.PortRangeStart, cfg.Container.PortRangeEnd, cfg.Container.PortReclaimAfter)
+		maps.Copy(m.ImagePins, cfg.Container.ImageDigests)
+	}
+	return m
+}
+
+// store returns the scenario persistence, defaulting to MongoDB via DB
+func (m *Manager) store() storage.Store {
+	if m.Store != nil {
+		return m.Store
+	}
+	return storage.NewMongoStore(m.DB)
+}
+
+// updateStatus stores a status change of scenario, retrying transient store
+// failures as configured so the change is not lost to a brief outage
+func (m *Manager) updateStatus(ctx context.Context, scenario *storage.Scenario) error {
+	var policy storage.UpdateRetryPolicy
+	if m.Cfg != nil {
+		policy = storage.UpdateRetryPolicy{Retries: m.Cfg.Mongo.UpdateRetries, Backoff: m.Cfg.Mongo.UpdateBackoff}
+	}
+	return storage.UpdateScenarioWithRetry(ctx, m.store(), scenario, policy)
 }
 
 func (m *Manager) StartScenario(ctx context.Context, req *types.StartScenarioRequest) (*types.StartScenarioResponse, error) {
@@ -48,42 +149,300 @@ func (m *Manager) StartScenario(ctx context.Context, req *types.StartScenarioReq
 		return nil, errors.New("user ID cannot be empty")
 	}
 
+	req, err := m.expandTemplate(req)
+	if err != nil {
+		return nil, err
+	}
+
 	if req.ScenarioType == "" {
 		return nil, errors.New("scenario type cannot be empty")
 	}
 
+	var reservedTypes []string
+	if m.Cfg != nil {
+		reservedTypes = m.Cfg.Container.ReservedTypes
+	}
+	if err := docker.ValidateScenarioType(req.ScenarioType, reservedTypes); err != nil {
+		return nil, err
+	}
+
+	if !req.Mode.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidScenarioMode, req.Mode)
+	}
+	batch := req.Mode == types.ScenarioModeBatch
+
+	if err := validateContainerOverride(req, batch); err != nil {
+		return nil, err
+	}
+	if err := docker.ValidateEnv(req.Env); err != nil {
+		return nil, err
+	}
+	if err := docker.ValidateLabels(req.Labels); err != nil {
+		return nil, err
+	}
+	limits := requestLimits(req)
+	if err := docker.ValidateLimits(limits); err != nil {
+		return nil, err
+	}
+	if err := validateNotes(req.Notes); err != nil {
+		return nil, err
+	}
+	if limit := m.maxScriptBytes(); len(req.Script) > limit {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the %d byte limit", ErrScriptTooLarge, len(req.Script), limit)
+	}
+	if req.ScriptTimeoutSeconds < 0 {
+		return nil, fmt.Errorf("%w: %d seconds", ErrInvalidScriptTimeout, req.ScriptTimeoutSeconds)
+	}
+	if batch && len(req.PostStart) > 0 {
+		return nil, fmt.Errorf("%w: post_start is not supported in batch mode", ErrInvalidScenarioMode)
+	}
+	ttl, err := m.scenarioTTL(req.ScenarioType, req.TTLSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	var idConfig config.ScenarioIDConfig
+	if m.Cfg != nil {
+		idConfig = m.Cfg.ScenarioID
+	}
+	scenarioID, err := newScenarioID(idConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Printf("[scenario] starting scenario for user: %s, type: %s", req.UserID, req.ScenarioType)
 
-	containerID, terminalPort, err := m.Docker.StartScenarioContainer(ctx, req.ScenarioType, req.Script)
+	if err := ctx.Err(); err != nil {
+		log.Printf("[scenario] client cancelled request for user %s before provisioning", req.UserID)
+		return nil, fmt.Errorf("%w: %w", ErrClientCancelled, err)
+	}
+
+	if err := m.checkHostLoad(ctx); err != nil {
+		return nil, err
+	}
+	release, err := m.reserveCapacity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Batch scenarios have no web terminal to protect
+	var terminalUsername, terminalPassword string
+	var hostPort int
+	if !batch {
+		terminalUsername, terminalPassword, err = generateTerminalCredentials()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate terminal credentials: %w", err)
+		}
+		if hostPort, err = m.allocatePort(); err != nil {
+			return nil, err
+		}
+	}
+	// The port goes back to the pool unless the scenario is stored holding it
+	stored := false
+	defer func() {
+		if !stored {
+			m.releasePort(hostPort)
+		}
+	}()
+
+	image := m.scenarioImage(req.ScenarioType)
+	releaseSlot, err := m.acquireProvisionSlot(ctx)
 	if err != nil {
+		return nil, err
+	}
+	provisionCtx, cancelProvision := m.provisioningContext(ctx)
+	containerID, terminalPort, err := m.Docker.StartScenarioContainer(provisionCtx, docker.ContainerSpec{
+		ScenarioType:     req.ScenarioType,
+		Image:            image,
+		Script:           req.Script,
+		Name:             m.containerName(scenarioID),
+		HostPort:         hostPort,
+		TerminalUsername: terminalUsername,
+		TerminalPassword: terminalPassword,
+		Batch:            batch,
+		Entrypoint:       req.Entrypoint,
+		Command:          req.Command,
+		StartTTYD:        req.StartTTYD,
+		Env:              req.Env,
+		Labels:           req.Labels,
+		Limits:           limits,
+		KeepOnFailure:    req.KeepOnFailure || (m.Cfg != nil && m.Cfg.Container.KeepOnFailure),
+	})
+	timedOut := ctx.Err() == nil && errors.Is(provisionCtx.Err(), context.DeadlineExceeded)
+	cancelProvision()
+	releaseSlot()
+	if err != nil {
+		if timedOut {
+			log.Printf("[scenario] provisioning scenario %s for user %s timed out: %v", scenarioID, req.UserID, err)
+			timeout := m.Cfg.ProvisioningTimeout
+			m.recordProvisioningTimeout(ctx, scenarioID, req, image, ttl, timeout)
+			return nil, fmt.Errorf("%w: scenario %s was not provisioned within %s", ErrProvisioningTimeout, scenarioID, timeout)
+		}
+		if ctx.Err() != nil {
+			log.Printf("[scenario] client cancelled request for user %s during provisioning: %v", req.UserID, err)
+			return nil, fmt.Errorf("%w: %w", ErrClientCancelled, ctx.Err())
+		}
 		log.Printf("[scenario] docker error: %v", err)
+		var kept *docker.ProvisioningError
+		if errors.As(err, &kept) {
+			m.recordFailedProvisioning(ctx, scenarioID, req, image, ttl, types.StopReasonFailed, "container failed to start and was kept for debugging", kept)
+			return nil, fmt.Errorf("failed to provision container for scenario %s: %w", scenarioID, err)
+		}
 		return nil, fmt.Errorf("failed to provision container: %w", err)
 	}
 
-	scenarioID := fmt.Sprintf("scn-%d", time.Now().UnixNano())
+	// The scenario has not been persisted yet, so nobody can reach this container
+	// if the client went away while we were provisioning it.
+	if err := ctx.Err(); err != nil {
+		log.Printf("[scenario] client cancelled request for user %s, removing container %s", req.UserID, containerID)
+		if rmErr := m.Docker.RemoveContainer(context.WithoutCancel(ctx), containerID); rmErr != nil {
+			log.Printf("[scenario] failed to remove container %s after client cancellation: %v", containerID, rmErr)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrClientCancelled, err)
+	}
+
+	now := time.Now()
+	// A batch container is already running its script; there is no ttyd to wait for
+	status := types.ScenarioStatusProvisioning
+	if batch {
+		status = types.ScenarioStatusRunning
+	}
 	s := &storage.Scenario{
-		ScenarioID:   scenarioID,
-		UserID:       req.UserID,
-		ScenarioType: req.ScenarioType,
-		ContainerID:  containerID,
-		Status:       "provisioning",
-		TerminalPort: terminalPort,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ScenarioID:       scenarioID,
+		UserID:           req.UserID,
+		ScenarioType:     req.ScenarioType,
+		Image:            image,
+		Script:           req.Script,
+		Name:             req.Name,
+		Tags:             req.Tags,
+		Notes:            req.Notes,
+		ContainerID:      containerID,
+		Mode:             req.Mode,
+		TerminalPort:     terminalPort,
+		TerminalUsername: terminalUsername,
+		TerminalPassword: terminalPassword,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		ExpiresAt:        now.Add(ttl),
+		PostStart:        req.PostStart,
+		PostStartFatal:   req.PostStartFatal,
+		Entrypoint:       req.Entrypoint,
+		Command:          req.Command,
+		StartTTYD:        req.StartTTYD,
+		Env:              req.Env,
+		Labels:           req.Labels,
+		CPULimit:         limits.CPUs,
+		MemoryLimitMB:    limits.MemoryMB,
+		ScriptTimeout:    m.scriptTimeout(req.ScriptTimeoutSeconds),
 	}
+	s.SetStatus(status, "container created")
 
-	if err := storage.StoreScenario(ctx, m.DB, s); err != nil {
+	if err := m.store().StoreScenario(ctx, s); err != nil {
 		log.Printf("[scenario] mongo error: %v", err)
 		// Try to clean up the container if database storage fails
-		m.Docker.StopContainer(ctx, containerID)
+		m.Docker.StopContainer(context.WithoutCancel(ctx), containerID)
 		return nil, fmt.Errorf("failed to store scenario metadata: %w", err)
 	}
+	stored = true
+
+	if batch {
+		go m.watchBatch(context.WithoutCancel(ctx), scenarioID, containerID, s.ScriptTimeout)
+	}
 
 	log.Printf("[scenario] scenario created: %s (container: %s, terminal port: %d)", scenarioID, containerID, terminalPort)
-	return &types.StartScenarioResponse{
+	resp := &types.StartScenarioResponse{
 		ScenarioID: scenarioID,
-		Status:     "provisioning",
-	}, nil
+		Status:     status,
+	}
+	if req.WaitForRunning && !batch {
+		m.attachRunningTerminal(ctx, s, resp)
+	}
+	return resp, nil
+}
+
+// recordFailedProvisioning stores a scenario whose container failed to come
+// up, so it can be described and, when its container was kept for debugging,
+// force-removed like any other. The scenario is recorded as failed with reason
+// and detail and expires after ttl like a running one would, after which
+// cleanup removes the kept container. kept is nil when no container was kept.
+func (m *Manager) recordFailedProvisioning(ctx context.Context, scenarioID string, req *types.StartScenarioRequest, image string, ttl time.Duration, reason types.StopReason, detail string, kept *docker.ProvisioningError) {
+	now := time.Now()
+	s := &storage.Scenario{
+		ScenarioID:   scenarioID,
+		UserID:       req.UserID,
+		ScenarioType: req.ScenarioType,
+		Image:        image,
+		Script:       req.Script,
+		Name:         req.Name,
+		Tags:         req.Tags,
+		Notes:        req.Notes,
+		StopReason:   reason,
+		Mode:         req.Mode,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		ExpiresAt:    now.Add(ttl),
+	}
+	if kept != nil {
+		s.ContainerID = kept.ContainerID
+		s.ProvisioningLogs = kept.Logs
+	}
+	s.SetStatus(types.ScenarioStatusFailed, detail)
+	if err := m.store().StoreScenario(context.WithoutCancel(ctx), s); err != nil {
+		log.Printf("[scenario] failed to record failed scenario %s: %v", scenarioID, err)
+		return
+	}
+	if kept != nil {
+		log.Printf("[scenario] kept failed container %s as scenario %s for debugging", kept.ContainerID, scenarioID)
+	}
+}
+
+// provisioningContext bounds the provisioning of a container, image pull
+// included, by Cfg.ProvisioningTimeout; zero leaves it bounded only by ctx
+func (m *Manager) provisioningContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.Cfg == nil || m.Cfg.ProvisioningTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, m.Cfg.ProvisioningTimeout)
+}
+
+// recordProvisioningTimeout removes whatever container a provisioning cut
+// short by its deadline left behind, found by its name since its ID may never
+// have been returned, and records scenarioID as failed so the user can see
+// what happened to it
+func (m *Manager) recordProvisioningTimeout(ctx context.Context, scenarioID string, req *types.StartScenarioRequest, image string, ttl, timeout time.Duration) {
+	name := m.containerName(scenarioID)
+	if err := m.Docker.RemoveContainer(context.WithoutCancel(ctx), name); err != nil && !errors.Is(err, docker.ErrContainerNotFound) {
+		log.Printf("[scenario] failed to remove container %s after provisioning timeout: %v", name, err)
+	}
+
+	detail := fmt.Sprintf("provisioning did not finish within %s", timeout)
+	m.recordFailedProvisioning(ctx, scenarioID, req, image, ttl, types.StopReasonProvisioningTimeout, detail, nil)
+}
+
+// attachRunningTerminal checks once whether a just-started scenario is already
+// running and, if so, adds its terminal URL and credentials to resp. Any
+// failure leaves the scenario to the reconciler and the fields unset.
+func (m *Manager) attachRunningTerminal(ctx context.Context, s *storage.Scenario, resp *types.StartScenarioResponse) {
+	if err := m.reconcileScenario(ctx, s); err != nil {
+		log.Printf("[scenario] failed to check whether scenario %s is running: %v", s.ScenarioID, err)
+		return
+	}
+	resp.Status = s.Status
+	if s.Status != types.ScenarioStatusRunning {
+		return
+	}
+
+	terminalURL, err := m.Docker.GetTerminalURL(ctx, s.ContainerID)
+	if err != nil {
+		log.Printf("[scenario] failed to get terminal URL for scenario %s: %v", s.ScenarioID, err)
+		return
+	}
+	resp.TerminalURL = &terminalURL
+	resp.TerminalCredentials = &types.TerminalCredentials{
+		Username: s.TerminalUsername,
+		Password: s.TerminalPassword,
+	}
 }
 
 func (m *Manager) GetScenarioStatus(ctx context.Context, scenarioID string) (*types.ScenarioStatusResponse, error) {
@@ -95,10 +454,10 @@ func (m *Manager) GetScenarioStatus(ctx context.Context, scenarioID string) (*ty
 		return nil, fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenarioID)
 	}
 
-	log.Printf("[scenario] getting status for scenario: %s", scenarioID)
+	zerologlog.Debug().Msgf("[scenario] getting status for scenario: %s", scenarioID)
 
 	// Get scenario from database
-	scenario, err := storage.GetScenario(ctx, m.DB, scenarioID)
+	scenario, err := m.store().GetScenario(ctx, scenarioID)
 	if err != nil {
 		log.Printf("[scenario] failed to get scenario from DB: %v", err)
 		if errors.Is(err, storage.ErrScenarioNotFound) {
@@ -107,6 +466,22 @@ func (m *Manager) GetScenarioStatus(ctx context.Context, scenarioID string) (*ty
 		return nil, fmt.Errorf("failed to get scenario: %w", err)
 	}
 
+	m.recordActivity(ctx, scenario)
+
+	// Batch scenarios are settled by completeBatch when their script exits, and
+	// a scenario that is no longer active has nothing left to ask Docker about
+	if scenario.Mode == types.ScenarioModeBatch || !scenario.Status.Active() {
+		return &types.ScenarioStatusResponse{
+			ScenarioID:   scenario.ScenarioID,
+			UserID:       scenario.UserID,
+			ScenarioType: scenario.ScenarioType,
+			ContainerID:  scenario.ContainerID,
+			Status:       scenario.Status,
+			StopReason:   scenario.StopReason,
+			Message:      "Scenario status retrieved successfully",
+		}, nil
+	}
+
 	// Check if container exists and get its status
 	containerExists, err := m.Docker.ContainerExists(ctx, scenario.ContainerID)
 	if err != nil {
@@ -118,15 +493,17 @@ func (m *Manager) GetScenarioStatus(ctx context.Context, scenarioID string) (*ty
 			ScenarioType: scenario.ScenarioType,
 			ContainerID:  scenario.ContainerID,
 			Status:       scenario.Status,
+			StopReason:   scenario.StopReason,
 			Message:      "Container status unavailable",
 		}, nil
 	}
 
 	if !containerExists {
 		// Container doesn't exist, update status to stopped
-		scenario.Status = "stopped"
+		scenario.SetStatus(types.ScenarioStatusStopped, "container no longer exists")
+		markStopReason(scenario, types.StopReasonOrphaned)
 		scenario.UpdatedAt = time.Now()
-		if err := storage.UpdateScenario(ctx, m.DB, scenario); err != nil {
+		if err := m.updateStatus(ctx, scenario); err != nil {
 			log.Printf("[scenario] failed to update scenario status: %v", err)
 		}
 
@@ -135,8 +512,9 @@ func (m *Manager) GetScenarioStatus(ctx context.Context, scenarioID string) (*ty
 			UserID:          scenario.UserID,
 			ScenarioType:    scenario.ScenarioType,
 			ContainerID:     scenario.ContainerID,
-			Status:          "stopped",
+			Status:          types.ScenarioStatusStopped,
 			ContainerStatus: "not_found",
+			StopReason:      scenario.StopReason,
 			Message:         "Container no longer exists",
 		}, nil
 	}
@@ -153,29 +531,34 @@ func (m *Manager) GetScenarioStatus(ctx context.Context, scenarioID string) (*ty
 			ContainerID:     scenario.ContainerID,
 			Status:          scenario.Status,
 			ContainerStatus: "unknown",
+			StopReason:      scenario.StopReason,
 			Message:         "Container status unavailable",
 		}, nil
 	}
 
 	// Update status based on container state
 	status := scenario.Status
-	if containerStatus == "running" && scenario.Status == "provisioning" {
-		status = "running"
-		scenario.Status = "running"
+	// Scenarios gated on k3s readiness or a post-start hook are promoted by
+	// the reconciler instead
+	if containerStatus == "running" && scenario.Status == types.ScenarioStatusProvisioning &&
+		m.k3sReadyTimeout(scenario.ScenarioType) == 0 && len(scenario.PostStart) == 0 {
+		status = types.ScenarioStatusRunning
+		scenario.SetStatus(types.ScenarioStatusRunning, "container running")
 		scenario.UpdatedAt = time.Now()
-		if err := storage.UpdateScenario(ctx, m.DB, scenario); err != nil {
+		if err := m.updateStatus(ctx, scenario); err != nil {
 			log.Printf("[scenario] failed to update scenario status: %v", err)
 		}
 	} else if containerStatus == "exited" || containerStatus == "stopped" {
-		status = "stopped"
-		scenario.Status = "stopped"
+		status = types.ScenarioStatusStopped
+		scenario.SetStatus(types.ScenarioStatusStopped, "container "+containerStatus)
+		markStopReason(scenario, types.StopReasonFailed)
 		scenario.UpdatedAt = time.Now()
-		if err := storage.UpdateScenario(ctx, m.DB, scenario); err != nil {
+		if err := m.updateStatus(ctx, scenario); err != nil {
 			log.Printf("[scenario] failed to update scenario status: %v", err)
 		}
 	}
 
-	log.Printf("[scenario] scenario %s status: %s (container: %s)", scenarioID, status, containerStatus)
+	zerologlog.Debug().Msgf("[scenario] scenario %s status: %s (container: %s)", scenarioID, status, containerStatus)
 
 	return &types.ScenarioStatusResponse{
 		ScenarioID:      scenario.ScenarioID,
@@ -184,6 +567,7 @@ func (m *Manager) GetScenarioStatus(ctx context.Context, scenarioID string) (*ty
 		ContainerID:     scenario.ContainerID,
 		Status:          status,
 		ContainerStatus: containerStatus,
+		StopReason:      scenario.StopReason,
 		Message:         "Scenario status retrieved successfully",
 	}, nil
 }
@@ -197,10 +581,10 @@ func (m *Manager) GetTerminalURL(ctx context.Context, scenarioID string) (string
 		return "", fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenarioID)
 	}
 
-	log.Printf("[scenario] getting terminal URL for scenario: %s", scenarioID)
+	zerologlog.Debug().Msgf("[scenario] getting terminal URL for scenario: %s", scenarioID)
 
 	// Get scenario from database
-	scenario, err := storage.GetScenario(ctx, m.DB, scenarioID)
+	scenario, err := m.store().GetScenario(ctx, scenarioID)
 	if err != nil {
 		log.Printf("[scenario] failed to get scenario from DB: %v", err)
 		if errors.Is(err, storage.ErrScenarioNotFound) {
@@ -209,8 +593,14 @@ func (m *Manager) GetTerminalURL(ctx context.Context, scenarioID string) (string
 		return "", fmt.Errorf("failed to get scenario: %w", err)
 	}
 
+	m.recordActivity(ctx, scenario)
+
+	if err := requireTerminal(scenario); err != nil {
+		return "", err
+	}
+
 	// Check if scenario is running
-	if scenario.Status != "running" {
+	if scenario.Status != types.ScenarioStatusRunning {
 		return "", fmt.Errorf("%w: scenario status is %s", ErrScenarioNotRunning, scenario.Status)
 	}
 
@@ -232,7 +622,7 @@ func (m *Manager) GetTerminalURL(ctx context.Context, scenarioID string) (string
 		return "", fmt.Errorf("failed to get terminal URL: %w", err)
 	}
 
-	log.Printf("[scenario] terminal URL for scenario %s: %s", scenarioID, terminalURL)
+	zerologlog.Debug().Msgf("[scenario] terminal URL for scenario %s: %s", scenarioID, terminalURL)
 	return terminalURL, nil
 }
 
@@ -248,7 +638,7 @@ func (m *Manager) StopScenario(ctx context.Context, scenarioID string) error {
 	log.Printf("[scenario] stopping scenario: %s", scenarioID)
 
 	// Get scenario from database
-	scenario, err := storage.GetScenario(ctx, m.DB, scenarioID)
+	scenario, err := m.store().GetScenario(ctx, scenarioID)
 	if err != nil {
 		log.Printf("[scenario] failed to get scenario from DB: %v", err)
 		if errors.Is(err, storage.ErrScenarioNotFound) {
@@ -257,6 +647,13 @@ func (m *Manager) StopScenario(ctx context.Context, scenarioID string) error {
 		return fmt.Errorf("failed to get scenario: %w", err)
 	}
 
+	// Stopping is idempotent: a scenario that is already down is left untouched
+	switch scenario.Status {
+	case types.ScenarioStatusStopped, types.ScenarioStatusCleanedUp, types.ScenarioStatusCompleted, types.ScenarioStatusFailed:
+		log.Printf("[scenario] scenario %s is already %s, nothing to stop", scenarioID, scenario.Status)
+		return nil
+	}
+
 	// Stop the container
 	if err := m.Docker.StopContainer(ctx, scenario.ContainerID); err != nil {
 		log.Printf("[scenario] failed to stop container %s: %v", scenario.ContainerID, err)
@@ -265,11 +662,13 @@ func (m *Manager) StopScenario(ctx context.Context, scenarioID string) error {
 			return fmt.Errorf("failed to stop container: %w", err)
 		}
 	}
+	m.releasePort(scenario.TerminalPort)
 
 	// Update scenario status
-	scenario.Status = "stopped"
+	scenario.SetStatus(types.ScenarioStatusStopped, "stopped by user")
+	scenario.StopReason = types.StopReasonUserRequested
 	scenario.UpdatedAt = time.Now()
-	if err := storage.UpdateScenario(ctx, m.DB, scenario); err != nil {
+	if err := m.updateStatus(ctx, scenario); err != nil {
 		log.Printf("[scenario] failed to update scenario status: %v", err)
 		return fmt.Errorf("failed to update scenario status: %w", err)
 	}
@@ -278,7 +677,446 @@ func (m *Manager) StopScenario(ctx context.Context, scenarioID string) error {
 	return nil
 }
 
-func (m *Manager) GetDirectoryStructure(ctx context.Context, scenarioID string) (*types.DirectoryStructureResponse, error) {
+// GetTerminalCredentials returns the ttyd login for a scenario owned by userID
+func (m *Manager) GetTerminalCredentials(ctx context.Context, scenarioID, userID string) (*types.TerminalCredentialsResponse, error) {
+	scenario, err := m.getOwnedScenario(ctx, scenarioID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireTerminal(scenario); err != nil {
+		return nil, err
+	}
+
+	username, password := scenario.TerminalUsername, scenario.TerminalPassword
+	if username == "" || password == "" {
+		// Scenarios created before per-scenario credentials use the image default
+		username, password = docker.DefaultTerminalUsername, docker.DefaultTerminalPassword
+	}
+
+	return &types.TerminalCredentialsResponse{
+		ScenarioID: scenarioID,
+		Username:   username,
+		Password:   password,
+		Message:    "Terminal credentials retrieved successfully",
+	}, nil
+}
+
+// RotateTerminalCredentials generates a new ttyd login for a running scenario
+// owned by userID and restarts ttyd so the old credentials stop working
+func (m *Manager) RotateTerminalCredentials(ctx context.Context, scenarioID, userID string) (*types.TerminalCredentialsResponse, error) {
+	scenario, err := m.getOwnedScenario(ctx, scenarioID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireTerminal(scenario); err != nil {
+		return nil, err
+	}
+
+	if scenario.Status != types.ScenarioStatusRunning {
+		return nil, fmt.Errorf("%w: scenario status is %s", ErrScenarioNotRunning, scenario.Status)
+	}
+
+	username, password, err := generateTerminalCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate terminal credentials: %w", err)
+	}
+
+	// The new credentials are only stored once ttyd is serving them; a ttyd
+	// that failed to come back would otherwise leave the old login working
+	// and the stored one useless
+	output, err := m.Docker.ExecuteCommand(ctx, scenario.ContainerID, docker.TTYDRestartCommand(username, password), docker.ExecuteCommandOpts{})
+	if err != nil {
+		log.Printf("[scenario] failed to restart ttyd for scenario %s: %v, output: %s", scenarioID, err, strings.TrimSpace(output))
+		var exitErr *docker.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%w: %s", ErrTerminalRestartFailed, strings.TrimSpace(output))
+		}
+		return nil, fmt.Errorf("failed to restart terminal: %w", err)
+	}
+	if !strings.Contains(output, docker.TTYDRestartedMessage) {
+		log.Printf("[scenario] ttyd did not confirm its restart for scenario %s, output: %s", scenarioID, strings.TrimSpace(output))
+		return nil, fmt.Errorf("%w: %s", ErrTerminalRestartFailed, strings.TrimSpace(output))
+	}
+
+	scenario.TerminalUsername = username
+	scenario.TerminalPassword = password
+	if err := m.store().UpdateScenario(ctx, scenario); err != nil {
+		log.Printf("[scenario] failed to store rotated credentials for scenario %s: %v", scenarioID, err)
+		return nil, fmt.Errorf("failed to update scenario: %w", err)
+	}
+
+	log.Printf("[scenario] rotated terminal credentials for scenario %s", scenarioID)
+	return &types.TerminalCredentialsResponse{
+		ScenarioID: scenarioID,
+		Username:   username,
+		Password:   password,
+		Message:    "Terminal credentials rotated successfully",
+	}, nil
+}
+
+// RebindTerminalPort moves the web terminal of a running scenario owned by
+// userID, or of any running scenario when admin is set, to a newly allocated
+// host port. The container is recreated to apply the new binding, so the
+// stored container ID changes along with the port.
+func (m *Manager) RebindTerminalPort(ctx context.Context, scenarioID, userID string, admin bool) (*types.RebindTerminalResponse, error) {
+	scenario, err := m.getScenarioFor(ctx, scenarioID, userID, admin)
+	if err != nil {
+		return nil, err
+	}
+	if scenario.UserID != userID {
+		auditLog("scenario.terminal_rebind", userID, scenarioID, map[string]interface{}{"owner_id": scenario.UserID})
+	}
+	if err := requireTerminal(scenario); err != nil {
+		return nil, err
+	}
+
+	if scenario.Status != types.ScenarioStatusRunning {
+		return nil, fmt.Errorf("%w: scenario status is %s", ErrScenarioNotRunning, scenario.Status)
+	}
+
+	hostPort, err := m.allocatePort()
+	if err != nil {
+		return nil, err
+	}
+	containerID, terminalPort, err := m.Docker.RebindTerminalPort(ctx, scenario.ContainerID, docker.RebindSpec{
+		ScenarioID:       scenario.ScenarioID,
+		ScenarioType:     scenario.ScenarioType,
+		HostPort:         hostPort,
+		TerminalUsername: scenario.TerminalUsername,
+		TerminalPassword: scenario.TerminalPassword,
+	})
+	if err != nil {
+		m.releasePort(hostPort)
+		log.Printf("[scenario] failed to rebind terminal for scenario %s: %v", scenarioID, err)
+		return nil, fmt.Errorf("failed to rebind terminal: %w", err)
+	}
+	// The old container and its binding are gone whether or not the update lands
+	m.releasePort(scenario.TerminalPort)
+
+	// The original container is gone, so the replacement must be recorded
+	// even if the caller has stopped waiting
+	storeCtx := context.WithoutCancel(ctx)
+	scenario.ContainerID = containerID
+	scenario.TerminalPort = terminalPort
+	scenario.UpdatedAt = time.Now()
+	if err := m.updateStatus(storeCtx, scenario); err != nil {
+		log.Printf("[scenario] failed to store rebound terminal for scenario %s, stopping untracked container %s: %v", scenarioID, containerID, err)
+		// Left running, the replacement would hold its port with no scenario
+		// pointing at it; the stored scenario now names a removed container
+		// and is settled as orphaned by the next status check
+		if stopErr := m.Docker.StopContainer(storeCtx, containerID); stopErr != nil {
+			log.Printf("[scenario] failed to stop container %s: %v", containerID, stopErr)
+		}
+		m.releasePort(terminalPort)
+		return nil, fmt.Errorf("failed to update scenario: %w", err)
+	}
+
+	url, err := m.Docker.GetTerminalURL(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get terminal URL: %w", err)
+	}
+
+	log.Printf("[scenario] rebound terminal for scenario %s to port %d", scenarioID, terminalPort)
+	return &types.RebindTerminalResponse{
+		ScenarioID:   scenarioID,
+		TerminalPort: terminalPort,
+		URL:          url,
+		Message:      "Terminal port rebound successfully",
+	}, nil
+}
+
+// ExtendScenario pushes the expiry of an active scenario owned by userID forward
+// by the configured increment, capped at the maximum scenario lifetime
+func (m *Manager) ExtendScenario(ctx context.Context, scenarioID, userID string) (*types.ExtendScenarioResponse, error) {
+	scenario, err := m.getOwnedScenario(ctx, scenarioID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !scenario.Status.Active() {
+		return nil, fmt.Errorf("%w: %s is %s", ErrScenarioNotRunning, scenarioID, scenario.Status)
+	}
+
+	maxAge, increment, maxLifetime := m.lifetimeLimits(scenario.ScenarioType)
+	expiresAt, err := extendedExpiry(scenario, time.Now(), maxAge, increment, maxLifetime)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, scenarioID)
+	}
+
+	scenario.ExpiresAt = expiresAt
+	scenario.UpdatedAt = time.Now()
+	if err := m.store().UpdateScenario(ctx, scenario); err != nil {
+		log.Printf("[scenario] failed to store extended expiry for scenario %s: %v", scenarioID, err)
+		return nil, fmt.Errorf("failed to update scenario: %w", err)
+	}
+
+	log.Printf("[scenario] extended scenario %s until %s", scenarioID, expiresAt.Format(time.RFC3339))
+	return &types.ExtendScenarioResponse{
+		ScenarioID: scenarioID,
+		ExpiresAt:  expiresAt,
+		Message:    "Scenario lifetime extended successfully",
+	}, nil
+}
+
+// extendedExpiry computes the new expiry for a scenario: increment past the
+// later of its current expiry and now, but never beyond createdAt+maxLifetime
+func extendedExpiry(scenario *storage.Scenario, now time.Time, maxAge, increment, maxLifetime time.Duration) (time.Time, error) {
+	base := scenario.Expiry(maxAge)
+	if base.Before(now) {
+		base = now
+	}
+
+	limit := scenario.CreatedAt.Add(maxLifetime)
+	if !base.Before(limit) {
+		return time.Time{}, ErrMaxLifetimeReached
+	}
+
+	expiresAt := base.Add(increment)
+	if expiresAt.After(limit) {
+		expiresAt = limit
+	}
+	return expiresAt, nil
+}
+
+// lifetimeLimits returns the configured scenario age for scenarioType, extend
+// increment and maximum lifetime, using the defaults for unset values
+func (m *Manager) lifetimeLimits(scenarioType string) (maxAge, increment, maxLifetime time.Duration) {
+	maxAge, increment, maxLifetime = defaultMaxScenarioAge, defaultExtendIncrement, defaultMaxScenarioLifetime
+	if m.Cfg == nil {
+		return
+	}
+	if typeMaxAge := m.Cfg.Cleanup.MaxScenarioAgeFor(scenarioType); typeMaxAge > 0 {
+		maxAge = typeMaxAge
+	}
+	if m.Cfg.Cleanup.ExtendIncrement > 0 {
+		increment = m.Cfg.Cleanup.ExtendIncrement
+	}
+	if m.Cfg.Cleanup.MaxScenarioLifetime > 0 {
+		maxLifetime = m.Cfg.Cleanup.MaxScenarioLifetime
+	}
+	return
+}
+
+// scenarioTTL resolves how long a new scenario lives before cleanup: the
+// type's max age when no TTL is requested, else the requested TTL. TTLs that
+// are negative or beyond the maximum lifetime are rejected outright; others
+// outside [MinScenarioTTL, MaxScenarioTTL] are clamped into that range, or
+// rejected when RejectTTLOutOfRange is set.
+func (m *Manager) scenarioTTL(scenarioType string, requestedSeconds int) (time.Duration, error) {
+	maxAge, _, maxLifetime := m.lifetimeLimits(scenarioType)
+	if requestedSeconds == 0 {
+		return maxAge, nil
+	}
+	// Compared in seconds first so absurd values cannot overflow a Duration
+	if requestedSeconds < 0 || int64(requestedSeconds) > int64(maxLifetime/time.Second) {
+		return 0, fmt.Errorf("%w: %d seconds is outside the hard limit of %s", ErrInvalidTTL, requestedSeconds, maxLifetime)
+	}
+	ttl := time.Duration(requestedSeconds) * time.Second
+
+	var minTTL, maxTTL time.Duration
+	reject := false
+	if m.Cfg != nil {
+		minTTL, maxTTL, reject = m.Cfg.Cleanup.MinScenarioTTL, m.Cfg.Cleanup.MaxScenarioTTL, m.Cfg.Cleanup.RejectTTLOutOfRange
+	}
+	if maxTTL <= 0 || maxTTL > maxLifetime {
+		maxTTL = maxLifetime
+	}
+	if ttl >= minTTL && ttl <= maxTTL {
+		return ttl, nil
+	}
+	if reject {
+		return 0, fmt.Errorf("%w: %s is outside the allowed range of %s to %s", ErrInvalidTTL, ttl, minTTL, maxTTL)
+	}
+	return min(max(ttl, minTTL), maxTTL), nil
+}
+
+// maxScriptBytes returns the configured script size limit, or the default
+// when unset. Scripts are inlined into the container's sh -c command, so
+// anything larger risks failing at exec time with E2BIG.
+func (m *Manager) maxScriptBytes() int {
+	if m.Cfg != nil && m.Cfg.Container.MaxScriptBytes > 0 {
+		return m.Cfg.Container.MaxScriptBytes
+	}
+	return defaultMaxScriptBytes
+}
+
+// scriptTimeout resolves the limit for a scenario's batch script and
+// post-start hook: the request's own limit, else the configured default, in
+// either case capped at the configured maximum
+func (m *Manager) scriptTimeout(requestedSeconds int) time.Duration {
+	timeout := time.Duration(requestedSeconds) * time.Second
+	if m.Cfg == nil {
+		return timeout
+	}
+	if timeout <= 0 {
+		timeout = m.Cfg.Container.ScriptTimeout
+	}
+	if limit := m.Cfg.Container.MaxScriptTimeout; limit > 0 && timeout > limit {
+		return limit
+	}
+	return timeout
+}
+
+// containerName names a scenario's container after its ID so it can be told
+// apart from other containers on the host
+func (m *Manager) containerName(scenarioID string) string {
+	prefix := "devlab-"
+	if m.Cfg != nil {
+		prefix = m.Cfg.Container.NamePrefix
+	}
+	return prefix + scenarioID
+}
+
+// markStopReason records why a scenario stopped unless an earlier code path
+// already did, e.g. a user stop followed by the container disappearing
+func markStopReason(scenario *storage.Scenario, reason types.StopReason) {
+	if scenario.StopReason == "" {
+		scenario.StopReason = reason
+	}
+}
+
+// recordActivity stamps the scenario as in use so inactivity-based cleanup
+// leaves it alone. Failures are logged and never fail the caller's request.
+func (m *Manager) recordActivity(ctx context.Context, scenario *storage.Scenario) {
+	scenario.LastActivityAt = time.Now()
+	if err := m.store().TouchScenario(ctx, scenario.ScenarioID, scenario.LastActivityAt); err != nil {
+		log.Printf("[scenario] failed to record activity for scenario %s: %v", scenario.ScenarioID, err)
+	}
+}
+
+// ListUserScenarios returns the stored status of every scenario owned by userID
+func (m *Manager) ListUserScenarios(ctx context.Context, userID string) ([]*types.ScenarioStatusResponse, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	scenarios, err := m.store().ListScenarios(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scenarios: %w", err)
+	}
+
+	return storedStatuses(scenarios), nil
+}
+
+// ListUserScenariosPage returns one page of userID's scenarios, oldest first,
+// and the token for the next page, which is empty on the last page. pageSize
+// defaults to DefaultListPageSize and is capped at MaxListPageSize.
+func (m *Manager) ListUserScenariosPage(ctx context.Context, userID, pageToken string, pageSize int) ([]*types.ScenarioStatusResponse, string, error) {
+	if userID == "" {
+		return nil, "", errors.New("user ID cannot be empty")
+	}
+
+	if pageSize <= 0 {
+		pageSize = DefaultListPageSize
+	} else if pageSize > MaxListPageSize {
+		pageSize = MaxListPageSize
+	}
+
+	scenarios, next, err := m.store().ListScenariosPaged(ctx, userID, pageToken, pageSize)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidPageToken) {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+		}
+		return nil, "", fmt.Errorf("failed to list scenarios: %w", err)
+	}
+	return storedStatuses(scenarios), next, nil
+}
+
+// storedStatuses converts stored scenarios to status responses without
+// querying their containers
+func storedStatuses(scenarios []*storage.Scenario) []*types.ScenarioStatusResponse {
+	statuses := make([]*types.ScenarioStatusResponse, 0, len(scenarios))
+	for _, scenario := range scenarios {
+		statuses = append(statuses, &types.ScenarioStatusResponse{
+			ScenarioID:   scenario.ScenarioID,
+			UserID:       scenario.UserID,
+			ScenarioType: scenario.ScenarioType,
+			ContainerID:  scenario.ContainerID,
+			Status:       scenario.Status,
+			StopReason:   scenario.StopReason,
+		})
+	}
+	return statuses
+}
+
+// getOwnedScenario loads a scenario and verifies it belongs to userID
+func (m *Manager) getOwnedScenario(ctx context.Context, scenarioID, userID string) (*storage.Scenario, error) {
+	return m.getScenarioFor(ctx, scenarioID, userID, false)
+}
+
+// getScenarioFor loads a scenario on behalf of userID, who must own it
+// unless admin is set
+func (m *Manager) getScenarioFor(ctx context.Context, scenarioID, userID string, admin bool) (*storage.Scenario, error) {
+	if ctx == nil {
+		return nil, errors.New("nil context provided")
+	}
+
+	if scenarioID == "" {
+		return nil, fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenarioID)
+	}
+
+	scenario, err := m.store().GetScenario(ctx, scenarioID)
+	if err != nil {
+		log.Printf("[scenario] failed to get scenario from DB: %v", err)
+		if errors.Is(err, storage.ErrScenarioNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrScenarioNotFound, scenarioID)
+		}
+		return nil, fmt.Errorf("failed to get scenario: %w", err)
+	}
+
+	if !admin && (userID == "" || scenario.UserID != userID) {
+		return nil, fmt.Errorf("%w: %s", ErrNotScenarioOwner, scenarioID)
+	}
+
+	return scenario, nil
+}
+
+// requireTerminal fails with ErrNoTerminal for a batch scenario, which runs
+// its script without a web terminal
+func requireTerminal(scenario *storage.Scenario) error {
+	if scenario.Mode == types.ScenarioModeBatch {
+		return fmt.Errorf("%w: %s runs in batch mode", ErrNoTerminal, scenario.ScenarioID)
+	}
+	return nil
+}
+
+// generateTerminalCredentials creates a random ttyd login for a new scenario
+func generateTerminalCredentials() (string, string, error) {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	return "devlab", base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// validateContainerOverride rejects an entrypoint or command override combined
+// with a script or batch mode, and start_ttyd without an override to apply it to
+func validateContainerOverride(req *types.StartScenarioRequest, batch bool) error {
+	if !req.HasContainerOverride() {
+		if req.StartTTYD {
+			return fmt.Errorf("%w: start_ttyd requires an entrypoint or command", ErrInvalidOverride)
+		}
+		return nil
+	}
+	if req.Script != "" {
+		return fmt.Errorf("%w: entrypoint and command cannot be combined with a script", ErrInvalidOverride)
+	}
+	if batch {
+		return fmt.Errorf("%w: entrypoint and command cannot be used in batch mode", ErrInvalidOverride)
+	}
+	return nil
+}
+
+// requestLimits returns the resource limits req asks for; zero fields leave
+// the scenario type's defaults in place
+func requestLimits(req *types.StartScenarioRequest) docker.ResourceLimits {
+	if req.Limits == nil {
+		return docker.ResourceLimits{}
+	}
+	return docker.ResourceLimits{CPUs: req.Limits.CPUs, MemoryMB: req.Limits.MemoryMB}
+}
+
+func (m *Manager) GetDirectoryStructure(ctx context.Context, scenarioID string, format types.DirectoryFormat) (*types.DirectoryStructureResponse, error) {
 	if ctx == nil {
 		return nil, errors.New("nil context provided")
 	}
@@ -287,10 +1125,17 @@ func (m *Manager) GetDirectoryStructure(ctx context.Context, scenarioID string)
 		return nil, fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenarioID)
 	}
 
-	log.Printf("[scenario] getting directory structure for scenario: %s", scenarioID)
+	if format == "" {
+		format = types.DirectoryFormatFlat
+	}
+	if !format.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDirectoryFormat, format)
+	}
+
+	zerologlog.Debug().Msgf("[scenario] getting directory structure for scenario: %s", scenarioID)
 
 	// Get scenario from database
-	scenario, err := storage.GetScenario(ctx, m.DB, scenarioID)
+	scenario, err := m.store().GetScenario(ctx, scenarioID)
 	if err != nil {
 		log.Printf("[scenario] failed to get scenario from DB: %v", err)
 		if errors.Is(err, storage.ErrScenarioNotFound) {
@@ -299,6 +1144,8 @@ func (m *Manager) GetDirectoryStructure(ctx context.Context, scenarioID string)
 		return nil, fmt.Errorf("failed to get scenario: %w", err)
 	}
 
+	m.recordActivity(ctx, scenario)
+
 	// Check if container exists and is running
 	containerExists, err := m.Docker.ContainerExists(ctx, scenario.ContainerID)
 	if err != nil {
@@ -310,30 +1157,177 @@ func (m *Manager) GetDirectoryStructure(ctx context.Context, scenarioID string)
 		return nil, fmt.Errorf("%w: container %s", ErrScenarioNotRunning, scenario.ContainerID)
 	}
 
-	// Execute command to get directory structure
-	// We'll use a simple find command to get the file tree
-	command := []string{"find", "/home/devlab", "-type", "f", "-o", "-type", "d", "-printf", "%p %y\n"}
-	output, err := m.Docker.ExecuteCommand(ctx, scenario.ContainerID, command)
+	output, truncated, err := m.listWorkspace(ctx, scenario)
 	if err != nil {
 		log.Printf("[scenario] failed to execute directory structure command: %v", err)
 		return nil, fmt.Errorf("failed to get directory structure: %w", err)
 	}
 
+	resp := &types.DirectoryStructureResponse{
+		ScenarioID: scenarioID,
+		Path:       "/home/devlab",
+		Truncated:  truncated,
+		Message:    "Directory structure retrieved successfully",
+	}
+	if truncated {
+		resp.Message = "Directory structure truncated; showing a partial listing"
+	}
+
 	// Parse the output and build the file tree structure
-	structure, err := parseDirectoryStructure(output)
+	if format == types.DirectoryFormatNested {
+		resp.Tree = buildNestedTree(output)
+	} else {
+		structure, err := parseDirectoryStructure(output)
+		if err != nil {
+			log.Printf("[scenario] failed to parse directory structure: %v", err)
+			return nil, fmt.Errorf("failed to parse directory structure: %w", err)
+		}
+		resp.Structure = structure
+	}
+
+	zerologlog.Debug().Msgf("[scenario] successfully retrieved directory structure for scenario %s", scenarioID)
+	return resp, nil
+}
+
+// Directory listing defaults used when the config leaves them unset
+const (
+	defaultDirectoryMaxDepth   = 10
+	defaultDirectoryTimeout    = 10 * time.Second
+	defaultDirectoryMaxEntries = 5000
+	defaultDirectoryAttempts   = 3
+	defaultDirectoryRetryDelay = 250 * time.Millisecond
+)
+
+// directoryResponseMargin is how much of the request's deadline a listing
+// leaves unused, so a partial listing is still answered in time
+const directoryResponseMargin = 500 * time.Millisecond
+
+// listWorkspace runs find over the scenario's home directory within the
+// configured depth, time and size limits, finishing early enough for a
+// partial listing to beat the request's own deadline. A listing cut short by a timeout, a
+// failing find or the entry cap is returned with truncated set; an error is
+// returned only when nothing could be listed. Listings that fail or come back
+// empty, as they do until a just-started container's shell is ready, are
+// retried a bounded number of times after a short delay, within the same
+// overall timeout.
+func (m *Manager) listWorkspace(ctx context.Context, scenario *storage.Scenario) (string, bool, error) {
+	maxDepth, timeout, maxEntries := defaultDirectoryMaxDepth, defaultDirectoryTimeout, defaultDirectoryMaxEntries
+	attempts, retryDelay := defaultDirectoryAttempts, defaultDirectoryRetryDelay
+	user := ""
+	if m.Cfg != nil {
+		user = m.Cfg.Directory.User
+		if m.Cfg.Directory.MaxDepth > 0 {
+			maxDepth = m.Cfg.Directory.MaxDepth
+		}
+		if m.Cfg.Directory.Timeout > 0 {
+			timeout = m.Cfg.Directory.Timeout
+		}
+		if m.Cfg.Directory.MaxEntries > 0 {
+			maxEntries = m.Cfg.Directory.MaxEntries
+		}
+		if m.Cfg.Directory.RetryAttempts > 0 {
+			attempts = m.Cfg.Directory.RetryAttempts
+		}
+		if m.Cfg.Directory.RetryDelay > 0 {
+			retryDelay = m.Cfg.Directory.RetryDelay
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = min(timeout, time.Until(deadline)-directoryResponseMargin)
+	}
+
+	command := []string{"find", docker.ScenarioHomeDir, "-maxdepth", strconv.Itoa(maxDepth), "-type", "f", "-o", "-type", "d", "-printf", "%p %y\n"}
+	opts := docker.ExecuteCommandOpts{
+		User:       user,
+		WorkingDir: docker.ScenarioHomeDir,
+		Env:        docker.ScenarioExecEnv(scenario.ScenarioType),
+	}
+
+	// The timeout bounds the whole listing, retries included
+	listCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var output string
+	var err error
+	for attempt := 1; ; attempt++ {
+		output, err = m.Docker.ExecuteCommand(listCtx, scenario.ContainerID, command, opts)
+
+		// Retry only transient failures that produced nothing to show. find
+		// always prints the home directory itself, so a successful listing
+		// with no output means the container is not ready yet.
+		if output != "" || listCtx.Err() != nil ||
+			errors.Is(err, docker.ErrContainerNotRunning) || errors.Is(err, docker.ErrContainerNotFound) {
+			break
+		}
+		if err == nil {
+			err = errors.New("directory listing returned no output")
+		}
+		if attempt >= attempts {
+			break
+		}
+		log.Printf("[scenario] directory listing attempt %d for scenario %s failed: %v", attempt, scenario.ScenarioID, err)
+
+		select {
+		case <-listCtx.Done():
+		case <-time.After(retryDelay):
+		}
+		if listCtx.Err() != nil {
+			break
+		}
+	}
+
+	truncated := false
 	if err != nil {
-		log.Printf("[scenario] failed to parse directory structure: %v", err)
-		return nil, fmt.Errorf("failed to parse directory structure: %w", err)
+		if output == "" {
+			return "", false, err
+		}
+		log.Printf("[scenario] returning partial directory listing for scenario %s: %v", scenario.ScenarioID, err)
+		truncated = true
+		// The last line may have been cut off mid-path
+		if i := strings.LastIndex(output, "\n"); i >= 0 {
+			output = output[:i+1]
+		} else {
+			output = ""
+		}
 	}
 
-	log.Printf("[scenario] successfully retrieved directory structure for scenario %s", scenarioID)
+	lines := strings.SplitAfter(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) > maxEntries {
+		output = strings.Join(shallowestEntries(lines, maxEntries), "")
+		truncated = true
+	}
 
-	return &types.DirectoryStructureResponse{
-		ScenarioID: scenarioID,
-		Path:       "/home/devlab",
-		Structure:  structure,
-		Message:    "Directory structure retrieved successfully",
-	}, nil
+	return output, truncated, nil
+}
+
+// shallowestEntries keeps the maxEntries lines of find output nearest the
+// workspace root, in their original order. Truncating breadth-first rather
+// than cutting the listing off keeps the top-level structure complete, and
+// every kept entry's parent directory is kept with it.
+func shallowestEntries(lines []string, maxEntries int) []string {
+	depth := func(line string) int {
+		path, _, ok := parseFindLine(strings.TrimSuffix(line, "\n"))
+		if !ok {
+			return math.MaxInt
+		}
+		return strings.Count(path, "/")
+	}
+
+	byDepth := make([]int, len(lines))
+	depths := make([]int, len(lines))
+	for i, line := range lines {
+		byDepth[i] = i
+		depths[i] = depth(line)
+	}
+	sort.SliceStable(byDepth, func(a, b int) bool { return depths[byDepth[a]] < depths[byDepth[b]] })
+
+	keep := byDepth[:maxEntries]
+	sort.Ints(keep)
+	kept := make([]string, 0, maxEntries)
+	for _, i := range keep {
+		kept = append(kept, lines[i])
+	}
+	return kept
 }
 
 // parseDirectoryStructure parses the output of the find command and builds a file tree
@@ -348,25 +1342,8 @@ func parseDirectoryStructure(output string) ([]types.FileNode, error) {
 
 	// First pass: create all nodes
 	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-
-		parts := strings.Fields(line)
-		if len(parts) != 2 {
-			continue
-		}
-
-		path := parts[0]
-		fileType := parts[1]
-
-		// Skip if not under /home/devlab
-		if !strings.HasPrefix(path, "/home/devlab") {
-			continue
-		}
-
-		// Skip cache directories to reduce response size
-		if shouldSkipPath(path) {
+		path, fileType, ok := parseFindLine(line)
+		if !ok {
 			continue
 		}
 
@@ -398,6 +1375,58 @@ func parseDirectoryStructure(output string) ([]types.FileNode, error) {
 	return structure, nil
 }
 
+// parseFindLine splits one "<path> <type>" line of find output, rejecting
+// malformed lines, paths outside /home/devlab and skipped cache directories
+func parseFindLine(line string) (string, string, bool) {
+	parts := strings.Fields(line)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+
+	path := parts[0]
+
+	// Skip if not under /home/devlab
+	if !strings.HasPrefix(path, "/home/devlab") {
+		return "", "", false
+	}
+
+	// Skip cache directories to reduce response size
+	if shouldSkipPath(path) {
+		return "", "", false
+	}
+
+	return path, parts[1], true
+}
+
+// buildNestedTree parses the output of the find command into a single tree
+// rooted at /home/devlab. Children keep the order find listed them in.
+func buildNestedTree(output string) *types.NestedFileNode {
+	root := &types.NestedFileNode{Path: "/home/devlab", Type: "folder", IsRoot: true, IsSaved: true}
+	nodes := map[string]*types.NestedFileNode{root.Path: root}
+
+	var order []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		path, fileType, ok := parseFindLine(line)
+		if !ok || path == root.Path {
+			continue
+		}
+		if _, seen := nodes[path]; seen {
+			continue
+		}
+		nodes[path] = &types.NestedFileNode{Path: path, Type: getNodeType(fileType), IsSaved: true}
+		order = append(order, path)
+	}
+
+	// Attach in listing order so a node's children are appended after it exists
+	for _, path := range order {
+		if parent, ok := nodes[getParentPath(path)]; ok {
+			parent.Children = append(parent.Children, nodes[path])
+		}
+	}
+
+	return root
+}
+
 // getNodeType converts the find command type to our type
 func getNodeType(findType string) string {
 	switch findType {
@@ -27,7 +27,7 @@ func NewIntegrationTestSuite(t *testing.T) *IntegrationTestSuite {
 		DBName:   "devlab_test",
 	}
 
-	client, err := storage.GetMongoClient(context.Background(), cfg.MongoURI)
+	client, err := storage.GetMongoClient(context.Background(), cfg.MongoURI, storage.MongoConcerns{})
 	if err != nil {
 		t.Skipf("MongoDB not available for integration test: %v", err)
 	}
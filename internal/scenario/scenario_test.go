@@ -2,14 +2,23 @@ package scenario
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"devlab/internal/config"
 	"devlab/internal/docker"
+	"devlab/internal/retry"
+	"devlab/internal/storage"
+	"devlab/internal/storage/storagetest"
 	"devlab/internal/types"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockDockerClient for testing
@@ -17,8 +26,8 @@ type MockDockerClient struct {
 	mock.Mock
 }
 
-func (m *MockDockerClient) StartScenarioContainer(ctx context.Context, scenarioType, script string) (string, int, error) {
-	args := m.Called(ctx, scenarioType, script)
+func (m *MockDockerClient) StartScenarioContainer(ctx context.Context, spec docker.ContainerSpec) (string, int, error) {
+	args := m.Called(ctx, spec)
 	return args.String(0), args.Int(1), args.Error(2)
 }
 
@@ -42,11 +51,21 @@ func (m *MockDockerClient) ContainerExists(ctx context.Context, containerID stri
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockDockerClient) ExecuteCommand(ctx context.Context, containerID string, command []string) (string, error) {
+func (m *MockDockerClient) ExecuteCommand(ctx context.Context, containerID string, command []string, opts docker.ExecuteCommandOpts) (string, error) {
 	args := m.Called(ctx, containerID, command)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockDockerClient) RunCommand(ctx context.Context, containerID string, command []string, opts docker.ExecuteCommandOpts) (docker.ExitResult, error) {
+	args := m.Called(ctx, containerID, command)
+	return args.Get(0).(docker.ExitResult), args.Error(1)
+}
+
+func (m *MockDockerClient) HostLoad(ctx context.Context) (docker.HostLoad, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(docker.HostLoad), args.Error(1)
+}
+
 func (m *MockDockerClient) ListContainers(ctx context.Context) ([]docker.ContainerInfo, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
@@ -60,19 +79,87 @@ func (m *MockDockerClient) RemoveContainer(ctx context.Context, containerID stri
 	return args.Error(0)
 }
 
+func (m *MockDockerClient) PruneStoppedContainers(ctx context.Context, labelFilter string) (docker.PruneReport, error) {
+	args := m.Called(ctx, labelFilter)
+	return args.Get(0).(docker.PruneReport), args.Error(1)
+}
+
+func (m *MockDockerClient) RemoveStoppedContainers(ctx context.Context, containerIDs []string) (docker.PruneReport, error) {
+	args := m.Called(ctx, containerIDs)
+	return args.Get(0).(docker.PruneReport), args.Error(1)
+}
+
+func (m *MockDockerClient) ListImages(ctx context.Context, labelFilter string) ([]docker.ImageInfo, error) {
+	args := m.Called(ctx, labelFilter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]docker.ImageInfo), args.Error(1)
+}
+
+func (m *MockDockerClient) RemoveImage(ctx context.Context, imageID string) error {
+	args := m.Called(ctx, imageID)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) WaitForExit(ctx context.Context, containerID string) (docker.ExitResult, error) {
+	args := m.Called(ctx, containerID)
+	return args.Get(0).(docker.ExitResult), args.Error(1)
+}
+
+func (m *MockDockerClient) RebindTerminalPort(ctx context.Context, containerID string, spec docker.RebindSpec) (string, int, error) {
+	args := m.Called(ctx, containerID, spec)
+	return args.String(0), args.Int(1), args.Error(2)
+}
+
+func (m *MockDockerClient) ImageExists(ctx context.Context, image string) (bool, error) {
+	args := m.Called(ctx, image)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDockerClient) KillContainer(ctx context.Context, containerID string) error {
+	args := m.Called(ctx, containerID)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) PauseContainer(ctx context.Context, containerID string) error {
+	args := m.Called(ctx, containerID)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) UnpauseContainer(ctx context.Context, containerID string) error {
+	args := m.Called(ctx, containerID)
+	return args.Error(0)
+}
+
+func (m *MockDockerClient) FollowLogs(ctx context.Context, containerID string, tail int) (*docker.LogStream, error) {
+	args := m.Called(ctx, containerID, tail)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*docker.LogStream), args.Error(1)
+}
+
+// specFor matches a container spec by scenario type and script
+func specFor(scenarioType, script string) interface{} {
+	return mock.MatchedBy(func(spec docker.ContainerSpec) bool {
+		return spec.ScenarioType == scenarioType && spec.Script == script
+	})
+}
+
 // TestStartScenario_Success tests successful scenario creation
 func TestStartScenario_Success(t *testing.T) {
 	mockDocker := &MockDockerClient{}
 
 	// Setup mock expectations
-	mockDocker.On("StartScenarioContainer", mock.Anything, "go", "").
+	mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).
 		Return("container123", 3001, nil)
 
 	// Create manager
 	manager := &Manager{
 		Cfg:    &config.Config{},
-		DB:     nil, // Mock database not needed for unit tests
 		Docker: mockDocker,
+		Store:  storage.NewMemoryStore(),
 	}
 
 	// Test request
@@ -85,14 +172,69 @@ func TestStartScenario_Success(t *testing.T) {
 	resp, err := manager.StartScenario(ctx, req)
 
 	// Assertions
-	assert.NoError(t, err)
-	assert.NotNil(t, resp)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
 	assert.Contains(t, resp.ScenarioID, "scn-")
-	assert.Equal(t, "starting", resp.Status)
+	assert.Equal(t, types.ScenarioStatusProvisioning, resp.Status)
+	assert.Nil(t, resp.TerminalURL)
+	assert.Nil(t, resp.TerminalCredentials)
 
 	mockDocker.AssertExpectations(t)
 }
 
+// TestStartScenario_WaitForRunning tests that a start which finds its
+// container already running returns the terminal details inline
+func TestStartScenario_WaitForRunning(t *testing.T) {
+	tests := []struct {
+		name            string
+		containerStatus string
+		expectedStatus  types.ScenarioStatus
+		expectTerminal  bool
+	}{
+		{name: "running", containerStatus: "running", expectedStatus: types.ScenarioStatusRunning, expectTerminal: true},
+		{name: "still_starting", containerStatus: "created", expectedStatus: types.ScenarioStatusProvisioning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDocker := &MockDockerClient{}
+			mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).Return("container123", 3001, nil)
+			mockDocker.On("ContainerExists", mock.Anything, "container123").Return(true, nil)
+			mockDocker.On("GetContainerStatus", mock.Anything, "container123").Return(tt.containerStatus, nil)
+			if tt.expectTerminal {
+				mockDocker.On("GetTerminalURL", mock.Anything, "container123").Return("http://localhost:3001", nil)
+			}
+
+			store := storage.NewMemoryStore()
+			manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+			resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+				UserID:         "test-user",
+				ScenarioType:   "go",
+				WaitForRunning: true,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.Status)
+
+			stored, err := store.GetScenario(context.Background(), resp.ScenarioID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, stored.Status)
+
+			if tt.expectTerminal {
+				require.NotNil(t, resp.TerminalURL)
+				assert.Equal(t, "http://localhost:3001", *resp.TerminalURL)
+				require.NotNil(t, resp.TerminalCredentials)
+				assert.Equal(t, stored.TerminalUsername, resp.TerminalCredentials.Username)
+				assert.Equal(t, stored.TerminalPassword, resp.TerminalCredentials.Password)
+			} else {
+				assert.Nil(t, resp.TerminalURL)
+				assert.Nil(t, resp.TerminalCredentials)
+			}
+			mockDocker.AssertExpectations(t)
+		})
+	}
+}
+
 // TestStartScenario_InvalidRequest tests invalid request handling
 func TestStartScenario_InvalidRequest(t *testing.T) {
 	manager := &Manager{
@@ -136,12 +278,450 @@ func TestStartScenario_InvalidRequest(t *testing.T) {
 	}
 }
 
+// TestStartScenario_ContainerOverride tests that entrypoint and command
+// overrides reach the container spec and are rejected alongside a script
+func TestStartScenario_ContainerOverride(t *testing.T) {
+	t.Run("passed_to_docker", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("StartScenarioContainer", mock.Anything, mock.MatchedBy(func(spec docker.ContainerSpec) bool {
+			return spec.Script == "" &&
+				assert.ObjectsAreEqual([]string{"/sbin/my-init"}, spec.Entrypoint) &&
+				assert.ObjectsAreEqual([]string{"--foreground"}, spec.Command) &&
+				spec.StartTTYD
+		})).Return("container123", 3001, nil)
+
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+		_, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+			UserID:       "test-user",
+			ScenarioType: "go",
+			Entrypoint:   []string{"/sbin/my-init"},
+			Command:      []string{"--foreground"},
+			StartTTYD:    true,
+		})
+		require.NoError(t, err)
+		mockDocker.AssertExpectations(t)
+	})
+
+	invalid := []struct {
+		name    string
+		request *types.StartScenarioRequest
+	}{
+		{
+			name: "with_script",
+			request: &types.StartScenarioRequest{
+				UserID: "test-user", ScenarioType: "go", Script: "echo hi", Command: []string{"sleep", "60"},
+			},
+		},
+		{
+			name: "batch_mode",
+			request: &types.StartScenarioRequest{
+				UserID: "test-user", ScenarioType: "go", Mode: types.ScenarioModeBatch, Entrypoint: []string{"/sbin/my-init"},
+			},
+		},
+		{
+			name: "start_ttyd_without_override",
+			request: &types.StartScenarioRequest{
+				UserID: "test-user", ScenarioType: "go", StartTTYD: true,
+			},
+		},
+	}
+
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDocker := &MockDockerClient{}
+			manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+			resp, err := manager.StartScenario(context.Background(), tc.request)
+			assert.ErrorIs(t, err, ErrInvalidOverride)
+			assert.Nil(t, resp)
+			mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestStartScenario_ScriptSize tests that scripts up to the configured limit
+// are provisioned and larger ones are rejected before reaching Docker
+func TestStartScenario_ReservedTypes(t *testing.T) {
+	cfg := &config.Config{Container: config.ContainerConfig{ReservedTypes: []string{"java", "dotnet"}}}
+
+	t.Run("reserved_type_rejected", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		manager := &Manager{Cfg: cfg, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+		resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{UserID: "test-user", ScenarioType: "java"})
+
+		assert.ErrorIs(t, err, docker.ErrInvalidScenarioType)
+		assert.Contains(t, err.Error(), "go, go-k8s")
+		assert.Nil(t, resp)
+		mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown_type_falls_back", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("StartScenarioContainer", mock.Anything, specFor("rust", "")).Return("container123", 3001, nil)
+		manager := &Manager{Cfg: cfg, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+		resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{UserID: "test-user", ScenarioType: "rust"})
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.ScenarioID)
+		mockDocker.AssertExpectations(t)
+	})
+}
+
+func TestStartScenario_ContainerName(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	var spec docker.ContainerSpec
+	mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).
+		Run(func(args mock.Arguments) { spec = args.Get(1).(docker.ContainerSpec) }).
+		Return("container123", 3001, nil)
+	cfg := &config.Config{Container: config.ContainerConfig{NamePrefix: "devlab-"}}
+	manager := &Manager{Cfg: cfg, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+	resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{UserID: "test-user", ScenarioType: "go"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "devlab-"+resp.ScenarioID, spec.Name)
+}
+
+func TestStartScenario_PortPool(t *testing.T) {
+	ctx := context.Background()
+	hostPort := func(port int) interface{} {
+		return mock.MatchedBy(func(spec docker.ContainerSpec) bool { return spec.HostPort == port })
+	}
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("StartScenarioContainer", mock.Anything, hostPort(39001)).Return("container-a", 39001, nil).Once()
+	mockDocker.On("StartScenarioContainer", mock.Anything, hostPort(39002)).Return("container-b", 39002, nil).Once()
+	mockDocker.On("StopContainer", mock.Anything, "container-a").Return(nil)
+	mockDocker.On("StartScenarioContainer", mock.Anything, hostPort(39001)).Return("container-c", 39001, nil).Once()
+	manager := &Manager{
+		Cfg:    &config.Config{},
+		Docker: mockDocker,
+		Store:  storage.NewMemoryStore(),
+		Ports:  docker.NewPortPool(39001, 39002, time.Hour),
+	}
+	start := func() (*types.StartScenarioResponse, error) {
+		return manager.StartScenario(ctx, &types.StartScenarioRequest{UserID: "test-user", ScenarioType: "go"})
+	}
+
+	first, err := start()
+	require.NoError(t, err)
+	_, err = start()
+	require.NoError(t, err)
+
+	_, err = start()
+	assert.ErrorIs(t, err, docker.ErrPortUnavailable, "the pool is exhausted")
+
+	require.NoError(t, manager.StopScenario(ctx, first.ScenarioID))
+	third, err := start()
+	require.NoError(t, err, "stopping a scenario frees its port for immediate reuse")
+
+	stored, err := manager.Store.GetScenario(ctx, third.ScenarioID)
+	require.NoError(t, err)
+	assert.Equal(t, "container-c", stored.ContainerID)
+	assert.Equal(t, 39001, stored.TerminalPort)
+	mockDocker.AssertExpectations(t)
+}
+
+func TestStartScenario_PinnedImage(t *testing.T) {
+	const pinned = "devlab-go@sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945"
+	ctx := context.Background()
+	var images []string
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("StartScenarioContainer", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { images = append(images, args.Get(1).(docker.ContainerSpec).Image) }).
+		Return("container123", 3001, nil)
+	cfg := &config.Config{Container: config.ContainerConfig{ImageDigests: map[string]string{"devlab-go:latest": pinned}}}
+	manager := NewManager(cfg, nil, mockDocker)
+	// Leave ttyd ports to the mock rather than probing the host
+	manager.Ports = nil
+	store := storage.NewMemoryStore()
+	manager.Store = store
+
+	for _, scenarioType := range []string{"go", "python"} {
+		resp, err := manager.StartScenario(ctx, &types.StartScenarioRequest{UserID: "test-user", ScenarioType: scenarioType})
+		require.NoError(t, err)
+
+		stored, err := store.GetScenario(ctx, resp.ScenarioID)
+		require.NoError(t, err)
+		images = append(images, stored.Image)
+	}
+
+	assert.Equal(t, []string{pinned, pinned, "devlab-python:latest", "devlab-python:latest"}, images,
+		"the pinned digest is passed to Docker and stored on the scenario")
+}
+
+func TestStartScenario_PerTypeMaxAge(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("StartScenarioContainer", mock.Anything, mock.Anything).Return("container123", 3001, nil)
+	cfg := &config.Config{Cleanup: config.CleanupConfig{
+		MaxScenarioAge:       24 * time.Hour,
+		MaxScenarioAgeByType: map[string]time.Duration{"k8s": 2 * time.Hour},
+	}}
+	store := storage.NewMemoryStore()
+	manager := &Manager{Cfg: cfg, Docker: mockDocker, Store: store}
+
+	for scenarioType, maxAge := range map[string]time.Duration{"k8s": 2 * time.Hour, "go": 24 * time.Hour} {
+		resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{UserID: "test-user", ScenarioType: scenarioType})
+		require.NoError(t, err)
+
+		stored, err := store.GetScenario(context.Background(), resp.ScenarioID)
+		require.NoError(t, err)
+		assert.Equal(t, maxAge, stored.ExpiresAt.Sub(stored.CreatedAt), scenarioType)
+	}
+}
+
+func TestStartScenario_KeepOnFailure(t *testing.T) {
+	ctx := context.Background()
+	const logs = "Starting ttyd on port 3000...\nERROR: ttyd failed to start\n"
+
+	t.Run("kept", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		keep := mock.MatchedBy(func(spec docker.ContainerSpec) bool { return spec.KeepOnFailure })
+		mockDocker.On("StartScenarioContainer", mock.Anything, keep).Return("", 0, &docker.ProvisioningError{
+			ContainerID: "container-1",
+			Logs:        logs,
+			Err:         fmt.Errorf("%w: container exited unexpectedly with code 1", docker.ErrTTYDFailedToStart),
+		})
+		store := storage.NewMemoryStore()
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+		resp, startErr := manager.StartScenario(ctx, &types.StartScenarioRequest{UserID: "test-user", ScenarioType: "go", KeepOnFailure: true})
+
+		assert.ErrorIs(t, startErr, docker.ErrTTYDFailedToStart)
+		assert.Nil(t, resp)
+		mockDocker.AssertNotCalled(t, "RemoveContainer", mock.Anything, mock.Anything)
+		mockDocker.AssertNotCalled(t, "StopContainer", mock.Anything, mock.Anything)
+
+		scenarios, err := store.ListScenarios(ctx, "test-user")
+		require.NoError(t, err)
+		require.Len(t, scenarios, 1)
+		assert.Contains(t, startErr.Error(), scenarios[0].ScenarioID)
+		assert.Equal(t, "container-1", scenarios[0].ContainerID)
+		assert.Equal(t, types.ScenarioStatusFailed, scenarios[0].Status)
+		assert.Equal(t, types.StopReasonFailed, scenarios[0].StopReason)
+		assert.Equal(t, logs, scenarios[0].ProvisioningLogs)
+		// The kept container expires like a running scenario would
+		ttl, err := manager.scenarioTTL("go", 0)
+		require.NoError(t, err)
+		assert.Equal(t, ttl, scenarios[0].ExpiresAt.Sub(scenarios[0].CreatedAt))
+	})
+
+	t.Run("kept_by_config", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		keep := mock.MatchedBy(func(spec docker.ContainerSpec) bool { return spec.KeepOnFailure })
+		mockDocker.On("StartScenarioContainer", mock.Anything, keep).Return("", 0, &docker.ProvisioningError{ContainerID: "container-1", Err: docker.ErrTTYDFailedToStart})
+		store := storage.NewMemoryStore()
+		cfg := &config.Config{Container: config.ContainerConfig{KeepOnFailure: true}}
+		manager := &Manager{Cfg: cfg, Docker: mockDocker, Store: store}
+
+		_, err := manager.StartScenario(ctx, &types.StartScenarioRequest{UserID: "test-user", ScenarioType: "go"})
+
+		assert.ErrorIs(t, err, docker.ErrTTYDFailedToStart)
+		scenarios, err := store.ListScenarios(ctx, "test-user")
+		require.NoError(t, err)
+		assert.Len(t, scenarios, 1)
+	})
+
+	t.Run("not_kept", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		discard := mock.MatchedBy(func(spec docker.ContainerSpec) bool { return !spec.KeepOnFailure })
+		mockDocker.On("StartScenarioContainer", mock.Anything, discard).Return("", 0, docker.ErrTTYDFailedToStart)
+		store := storage.NewMemoryStore()
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+		_, err := manager.StartScenario(ctx, &types.StartScenarioRequest{UserID: "test-user", ScenarioType: "go"})
+
+		assert.ErrorIs(t, err, docker.ErrTTYDFailedToStart)
+		scenarios, err := store.ListScenarios(ctx, "test-user")
+		require.NoError(t, err)
+		assert.Empty(t, scenarios)
+	})
+}
+
+func TestStartScenario_ScriptSize(t *testing.T) {
+	const limit = 1024
+
+	tests := []struct {
+		name        string
+		cfg         config.ContainerConfig
+		scriptBytes int
+		expectError bool
+	}{
+		{name: "at_limit", cfg: config.ContainerConfig{MaxScriptBytes: limit}, scriptBytes: limit},
+		{name: "beyond_limit", cfg: config.ContainerConfig{MaxScriptBytes: limit}, scriptBytes: limit + 1, expectError: true},
+		{name: "default_limit_near", scriptBytes: defaultMaxScriptBytes - 1},
+		{name: "default_limit_beyond", scriptBytes: 2 * defaultMaxScriptBytes, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := strings.Repeat("#", tt.scriptBytes)
+			mockDocker := &MockDockerClient{}
+			if !tt.expectError {
+				mockDocker.On("StartScenarioContainer", mock.Anything, mock.MatchedBy(func(spec docker.ContainerSpec) bool {
+					return spec.Script == script
+				})).Return("container123", 3001, nil)
+			}
+			manager := &Manager{Cfg: &config.Config{Container: tt.cfg}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+			resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+				UserID:       "test-user",
+				ScenarioType: "go",
+				Script:       script,
+			})
+
+			if tt.expectError {
+				assert.ErrorIs(t, err, ErrScriptTooLarge)
+				assert.Nil(t, resp)
+				mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			mockDocker.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStartScenario_Env(t *testing.T) {
+	t.Run("passed_to_container_and_stored", func(t *testing.T) {
+		env := map[string]string{"GREETING": "$(touch /tmp/pwned); echo hi"}
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("StartScenarioContainer", mock.Anything, mock.MatchedBy(func(spec docker.ContainerSpec) bool {
+			return assert.ObjectsAreEqual(env, spec.Env)
+		})).Return("container123", 3001, nil)
+		store := storage.NewMemoryStore()
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+		resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+			UserID:       "test-user",
+			ScenarioType: "go",
+			Env:          env,
+		})
+		require.NoError(t, err)
+		mockDocker.AssertExpectations(t)
+
+		stored, err := store.GetScenario(context.Background(), resp.ScenarioID)
+		require.NoError(t, err)
+		assert.Equal(t, env, stored.Env)
+	})
+
+	for name, env := range map[string]map[string]string{
+		"invalid_name":  {"MY-VAR": "x"},
+		"reserved_name": {"TTYD_CREDENTIAL": "admin:admin"},
+		"null_byte":     {"MY_VAR": "a\x00b"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockDocker := &MockDockerClient{}
+			manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+			resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+				UserID:       "test-user",
+				ScenarioType: "go",
+				Env:          env,
+			})
+			assert.ErrorIs(t, err, docker.ErrInvalidEnv)
+			assert.Nil(t, resp)
+			mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestStartScenario_Labels(t *testing.T) {
+	t.Run("passed_to_container_and_stored", func(t *testing.T) {
+		labels := map[string]string{"cost-center": "eng", "course-id": "k8s-101"}
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("StartScenarioContainer", mock.Anything, mock.MatchedBy(func(spec docker.ContainerSpec) bool {
+			return assert.ObjectsAreEqual(labels, spec.Labels)
+		})).Return("container123", 3001, nil)
+		store := storage.NewMemoryStore()
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+		resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+			UserID:       "test-user",
+			ScenarioType: "go",
+			Labels:       labels,
+		})
+		require.NoError(t, err)
+		mockDocker.AssertExpectations(t)
+
+		stored, err := store.GetScenario(context.Background(), resp.ScenarioID)
+		require.NoError(t, err)
+		assert.Equal(t, labels, stored.Labels)
+	})
+
+	t.Run("invalid_key", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+		resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+			UserID:       "test-user",
+			ScenarioType: "go",
+			Labels:       map[string]string{"Cost Center": "eng"},
+		})
+		assert.ErrorIs(t, err, docker.ErrInvalidLabel)
+		assert.Nil(t, resp)
+		mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+	})
+}
+
+func TestStartScenario_Limits(t *testing.T) {
+	t.Run("passed_to_container_and_stored", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("StartScenarioContainer", mock.Anything, mock.MatchedBy(func(spec docker.ContainerSpec) bool {
+			return spec.Limits == docker.ResourceLimits{CPUs: 1.5, MemoryMB: 2048}
+		})).Return("container123", 3001, nil)
+		store := storage.NewMemoryStore()
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+		resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+			UserID:       "test-user",
+			ScenarioType: "go",
+			Limits:       &types.ResourceLimits{CPUs: 1.5, MemoryMB: 2048},
+		})
+		require.NoError(t, err)
+		mockDocker.AssertExpectations(t)
+
+		stored, err := store.GetScenario(context.Background(), resp.ScenarioID)
+		require.NoError(t, err)
+		assert.Equal(t, 1.5, stored.CPULimit)
+		assert.Equal(t, int64(2048), stored.MemoryLimitMB)
+	})
+
+	t.Run("unset_leaves_type_default", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("StartScenarioContainer", mock.Anything, mock.MatchedBy(func(spec docker.ContainerSpec) bool {
+			return spec.Limits == docker.ResourceLimits{}
+		})).Return("container123", 3001, nil)
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+		_, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{UserID: "test-user", ScenarioType: "go"})
+		require.NoError(t, err)
+		mockDocker.AssertExpectations(t)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+		resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+			UserID:       "test-user",
+			ScenarioType: "go",
+			Limits:       &types.ResourceLimits{MemoryMB: 1},
+		})
+		assert.ErrorIs(t, err, docker.ErrInvalidLimits)
+		assert.Nil(t, resp)
+		mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+	})
+}
+
 // TestStartScenario_DockerError tests Docker error handling
 func TestStartScenario_DockerError(t *testing.T) {
 	mockDocker := &MockDockerClient{}
 
 	// Setup mock to return error
-	mockDocker.On("StartScenarioContainer", mock.Anything, "go", "").
+	mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).
 		Return("", 0, docker.ErrDockerDaemonUnavailable)
 
 	manager := &Manager{
@@ -165,6 +745,110 @@ func TestStartScenario_DockerError(t *testing.T) {
 	mockDocker.AssertExpectations(t)
 }
 
+// TestStartScenario_ClientCancelled tests that a container provisioned for a
+// client that has gone away is removed before the scenario is persisted
+func TestStartScenario_ClientCancelled(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Simulate the client disconnecting while the container is being provisioned
+	mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).
+		Run(func(args mock.Arguments) { cancel() }).
+		Return("container123", 3001, nil)
+	mockDocker.On("RemoveContainer", mock.Anything, "container123").
+		Return(nil)
+
+	manager := &Manager{
+		Cfg:    &config.Config{},
+		DB:     nil,
+		Docker: mockDocker,
+	}
+
+	req := &types.StartScenarioRequest{
+		UserID:       "test-user",
+		ScenarioType: "go",
+	}
+
+	resp, err := manager.StartScenario(ctx, req)
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, ErrClientCancelled)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	mockDocker.AssertExpectations(t)
+	mockDocker.AssertNotCalled(t, "StopContainer", mock.Anything, mock.Anything)
+}
+
+func TestStartScenario_ProvisioningTimeout(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	// An image pull that hangs until provisioning is cancelled
+	mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).
+		Run(func(args mock.Arguments) { <-args.Get(0).(context.Context).Done() }).
+		Return("", 0, context.DeadlineExceeded)
+	mockDocker.On("RemoveContainer", mock.Anything, mock.MatchedBy(func(name string) bool {
+		return strings.HasPrefix(name, "devlab-scn-")
+	})).Return(nil)
+
+	store := storage.NewMemoryStore()
+	cfg := &config.Config{ProvisioningTimeout: 50 * time.Millisecond, Container: config.ContainerConfig{NamePrefix: "devlab-"}}
+	manager := &Manager{Cfg: cfg, Docker: mockDocker, Store: store}
+
+	started := time.Now()
+	resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{UserID: "test-user", ScenarioType: "go", Name: "slow"})
+	assert.ErrorIs(t, err, ErrProvisioningTimeout)
+	assert.NotErrorIs(t, err, ErrClientCancelled)
+	assert.Nil(t, resp)
+	assert.Less(t, time.Since(started), 5*time.Second)
+	mockDocker.AssertExpectations(t)
+
+	// The scenario is recorded as failed under the removed container's name
+	scenarios, err := store.ListScenarios(context.Background(), "test-user")
+	require.NoError(t, err)
+	require.Len(t, scenarios, 1)
+	failed := scenarios[0]
+	assert.Equal(t, types.ScenarioStatusFailed, failed.Status)
+	assert.Equal(t, types.StopReasonProvisioningTimeout, failed.StopReason)
+	assert.Equal(t, "slow", failed.Name)
+	assert.Empty(t, failed.ContainerID)
+	// It expires like any failed scenario, so cleanup eventually removes it
+	assert.True(t, failed.ExpiresAt.After(started), "expires at %s", failed.ExpiresAt)
+	mockDocker.AssertCalled(t, "RemoveContainer", mock.Anything, "devlab-"+failed.ScenarioID)
+}
+
+func TestStartScenario_ProvisioningTimeout_ContainerAlreadyGone(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).
+		Run(func(args mock.Arguments) { <-args.Get(0).(context.Context).Done() }).
+		Return("", 0, context.DeadlineExceeded)
+	// The pull never got as far as creating a container
+	mockDocker.On("RemoveContainer", mock.Anything, mock.Anything).Return(docker.ErrContainerNotFound)
+
+	store := storage.NewMemoryStore()
+	manager := &Manager{Cfg: &config.Config{ProvisioningTimeout: 20 * time.Millisecond}, Docker: mockDocker, Store: store}
+
+	_, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{UserID: "test-user", ScenarioType: "go"})
+	assert.ErrorIs(t, err, ErrProvisioningTimeout)
+
+	scenarios, err := store.ListScenarios(context.Background(), "test-user")
+	require.NoError(t, err)
+	require.Len(t, scenarios, 1)
+	assert.Equal(t, types.ScenarioStatusFailed, scenarios[0].Status)
+}
+
+func TestStartScenario_WithinProvisioningTimeout(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).Return("container123", 3001, nil)
+
+	manager := &Manager{Cfg: &config.Config{ProvisioningTimeout: time.Minute}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+	resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{UserID: "test-user", ScenarioType: "go"})
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusProvisioning, resp.Status)
+	mockDocker.AssertNotCalled(t, "RemoveContainer", mock.Anything, mock.Anything)
+}
+
 // TestGetTerminalURL_Success tests successful terminal URL retrieval
 func TestGetTerminalURL_Success(t *testing.T) {
 	mockDocker := &MockDockerClient{}
@@ -189,6 +873,185 @@ func TestGetTerminalURL_Success(t *testing.T) {
 	assert.Empty(t, url)
 }
 
+// TestRotateTerminalCredentials tests that rotated credentials are stored
+// only once ttyd is back up serving them
+func TestRotateTerminalCredentials(t *testing.T) {
+	newStore := func() storage.Store {
+		return storage.NewMemoryStore(&storage.Scenario{
+			ScenarioID:       "scn-1",
+			UserID:           "test-user",
+			ContainerID:      "container123",
+			Status:           types.ScenarioStatusRunning,
+			TerminalUsername: "devlab",
+			TerminalPassword: "old",
+		})
+	}
+
+	tests := []struct {
+		name     string
+		output   string
+		execErr  error
+		expected error
+	}{
+		{name: "restarted", output: docker.TTYDRestartedMessage + "\n"},
+		{name: "ttyd_down", output: "ERROR: ttyd failed to restart\n", execErr: &docker.ExitError{ExitCode: 1}, expected: ErrTerminalRestartFailed},
+		{name: "unconfirmed", output: "", expected: ErrTerminalRestartFailed},
+		{name: "docker_unavailable", execErr: docker.ErrDockerDaemonUnavailable, expected: docker.ErrDockerDaemonUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore()
+			mockDocker := &MockDockerClient{}
+			mockDocker.On("ExecuteCommand", mock.Anything, "container123", mock.Anything).Return(tt.output, tt.execErr)
+			manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+			resp, err := manager.RotateTerminalCredentials(ctx, "scn-1", "test-user")
+			stored, getErr := store.GetScenario(ctx, "scn-1")
+			require.NoError(t, getErr)
+			if tt.expected != nil {
+				assert.ErrorIs(t, err, tt.expected)
+				assert.Equal(t, "old", stored.TerminalPassword, "credentials ttyd does not serve must not be stored")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, resp.Password, stored.TerminalPassword)
+			assert.NotEqual(t, "old", stored.TerminalPassword)
+		})
+	}
+}
+
+// TestTerminalPaths_RejectBatch tests that a batch scenario, which has no web
+// terminal, is rejected before Docker is contacted
+func TestTerminalPaths_RejectBatch(t *testing.T) {
+	ctx := context.Background()
+	mockDocker := &MockDockerClient{}
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore(newBatchScenario("scn-1"))}
+
+	_, err := manager.GetTerminalURL(ctx, "scn-1")
+	assert.ErrorIs(t, err, ErrNoTerminal)
+	_, err = manager.GetTerminalCredentials(ctx, "scn-1", "test-user")
+	assert.ErrorIs(t, err, ErrNoTerminal)
+	_, err = manager.RotateTerminalCredentials(ctx, "scn-1", "test-user")
+	assert.ErrorIs(t, err, ErrNoTerminal)
+	_, err = manager.RebindTerminalPort(ctx, "scn-1", "test-user", false)
+	assert.ErrorIs(t, err, ErrNoTerminal)
+	mockDocker.AssertNotCalled(t, "ExecuteCommand", mock.Anything, mock.Anything, mock.Anything)
+	mockDocker.AssertNotCalled(t, "RebindTerminalPort", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestRebindTerminalPort tests that a rebind stores the replacement container
+// and port and that the terminal URL follows them
+func TestRebindTerminalPort(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(&storage.Scenario{
+		ScenarioID:       "scn-1",
+		UserID:           "test-user",
+		ContainerID:      "container123",
+		Status:           types.ScenarioStatusRunning,
+		TerminalPort:     3001,
+		TerminalUsername: "devlab",
+		TerminalPassword: "secret",
+	})
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("RebindTerminalPort", mock.Anything, "container123", docker.RebindSpec{
+		ScenarioID:       "scn-1",
+		TerminalUsername: "devlab",
+		TerminalPassword: "secret",
+	}).Return("container456", 3050, nil)
+	mockDocker.On("ContainerExists", mock.Anything, "container456").Return(true, nil)
+	mockDocker.On("GetContainerStatus", mock.Anything, "container456").Return("running", nil).Maybe()
+	mockDocker.On("GetTerminalURL", mock.Anything, "container456").Return("http://localhost:3050", nil)
+
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	resp, err := manager.RebindTerminalPort(ctx, "scn-1", "test-user", false)
+	require.NoError(t, err)
+	assert.Equal(t, 3050, resp.TerminalPort)
+	assert.Equal(t, "http://localhost:3050", resp.URL)
+
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, 3050, stored.TerminalPort)
+	assert.Equal(t, "container456", stored.ContainerID)
+
+	url, err := manager.GetTerminalURL(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:3050", url)
+
+	_, err = manager.RebindTerminalPort(ctx, "scn-1", "other-user", false)
+	assert.ErrorIs(t, err, ErrNotScenarioOwner)
+	mockDocker.AssertNumberOfCalls(t, "RebindTerminalPort", 1)
+}
+
+// TestRebindTerminalPort_Admin tests that an admin may rebind another user's terminal
+func TestRebindTerminalPort_Admin(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(&storage.Scenario{
+		ScenarioID: "scn-1", UserID: "test-user", ContainerID: "container123", Status: types.ScenarioStatusRunning, TerminalPort: 3001,
+	})
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("RebindTerminalPort", mock.Anything, "container123", mock.Anything).Return("container456", 3050, nil)
+	mockDocker.On("GetTerminalURL", mock.Anything, "container456").Return("http://localhost:3050", nil)
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	resp, err := manager.RebindTerminalPort(ctx, "scn-1", "admin-user", true)
+	require.NoError(t, err)
+	assert.Equal(t, 3050, resp.TerminalPort)
+
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, "test-user", stored.UserID)
+	assert.Equal(t, "container456", stored.ContainerID)
+}
+
+// TestRebindTerminalPort_StoreError tests that a replacement the store cannot
+// record is stopped rather than left running untracked
+func TestRebindTerminalPort_StoreError(t *testing.T) {
+	ctx := context.Background()
+	store := &storagetest.FlakyStore{
+		Store: storage.NewMemoryStore(&storage.Scenario{
+			ScenarioID: "scn-1", UserID: "test-user", ContainerID: "container123", Status: types.ScenarioStatusRunning, TerminalPort: 3001,
+		}),
+		Err:      errors.New("database unavailable"),
+		Failures: 1,
+	}
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("RebindTerminalPort", mock.Anything, "container123", mock.Anything).Return("container456", 3050, nil)
+	mockDocker.On("StopContainer", mock.Anything, "container456").Return(nil)
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	_, err := manager.RebindTerminalPort(ctx, "scn-1", "test-user", false)
+	assert.ErrorContains(t, err, "database unavailable")
+	mockDocker.AssertExpectations(t)
+	mockDocker.AssertNotCalled(t, "GetTerminalURL", mock.Anything, mock.Anything)
+}
+
+// TestRebindTerminalPort_DockerError tests that a failed rebind leaves the stored port alone
+func TestRebindTerminalPort_DockerError(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(&storage.Scenario{
+		ScenarioID:   "scn-1",
+		UserID:       "test-user",
+		ContainerID:  "container123",
+		Status:       types.ScenarioStatusRunning,
+		TerminalPort: 3001,
+	})
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("RebindTerminalPort", mock.Anything, "container123", mock.Anything).Return("", 0, docker.ErrPortUnavailable)
+
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	_, err := manager.RebindTerminalPort(ctx, "scn-1", "test-user", false)
+	assert.ErrorIs(t, err, docker.ErrPortUnavailable)
+
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, 3001, stored.TerminalPort)
+	assert.Equal(t, "container123", stored.ContainerID)
+}
+
 // TestStopScenario_Success tests successful scenario stopping
 func TestStopScenario_Success(t *testing.T) {
 	mockDocker := &MockDockerClient{}
@@ -213,6 +1076,160 @@ func TestStopScenario_Success(t *testing.T) {
 	assert.Error(t, err) // Expected to fail without proper DB mocking
 }
 
+// TestStatusTransitions_UseValidConstants walks a scenario through its lifecycle
+// and checks every persisted status is a defined ScenarioStatus
+func TestStatusTransitions_UseValidConstants(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).Return("container-1", 3001, nil)
+	mockDocker.On("ContainerExists", mock.Anything, "container-1").Return(true, nil)
+	mockDocker.On("GetContainerStatus", mock.Anything, "container-1").Return("running", nil)
+	mockDocker.On("StopContainer", mock.Anything, "container-1").Return(nil)
+
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	assertStored := func(scenarioID string, expected types.ScenarioStatus) {
+		t.Helper()
+		stored, err := store.GetScenario(ctx, scenarioID)
+		require.NoError(t, err)
+		assert.True(t, stored.Status.Valid(), "status %q is not a defined ScenarioStatus", stored.Status)
+		assert.Equal(t, expected, stored.Status)
+	}
+
+	resp, err := manager.StartScenario(ctx, &types.StartScenarioRequest{UserID: "test-user", ScenarioType: "go"})
+	require.NoError(t, err)
+	assertStored(resp.ScenarioID, types.ScenarioStatusProvisioning)
+
+	status, err := manager.GetScenarioStatus(ctx, resp.ScenarioID)
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusRunning, status.Status)
+	assertStored(resp.ScenarioID, types.ScenarioStatusRunning)
+
+	require.NoError(t, manager.StopScenario(ctx, resp.ScenarioID))
+	assertStored(resp.ScenarioID, types.ScenarioStatusStopped)
+}
+
+func TestStopScenario_Idempotent(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("running_scenario_is_stopped", func(t *testing.T) {
+		store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", ContainerID: "container-1", Status: "running"})
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("StopContainer", mock.Anything, "container-1").Return(nil)
+
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+		require.NoError(t, manager.StopScenario(ctx, "scn-1"))
+
+		stored, err := store.GetScenario(ctx, "scn-1")
+		require.NoError(t, err)
+		assert.Equal(t, types.ScenarioStatusStopped, stored.Status)
+		mockDocker.AssertExpectations(t)
+	})
+
+	for _, status := range []types.ScenarioStatus{types.ScenarioStatusStopped, types.ScenarioStatusCleanedUp} {
+		t.Run(string(status)+"_scenario_is_a_no_op", func(t *testing.T) {
+			original := &storage.Scenario{ScenarioID: "scn-2", ContainerID: "container-2", Status: status, StopReason: types.StopReasonExpired}
+			store := storage.NewMemoryStore(original)
+			mockDocker := &MockDockerClient{}
+
+			manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+			require.NoError(t, manager.StopScenario(ctx, "scn-2"))
+
+			stored, err := store.GetScenario(ctx, "scn-2")
+			require.NoError(t, err)
+			assert.Equal(t, original, stored, "an already stopped scenario must not be rewritten")
+			mockDocker.AssertNotCalled(t, "StopContainer", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("missing_scenario_is_not_found", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+		err := manager.StopScenario(ctx, "scn-missing")
+		assert.ErrorIs(t, err, ErrScenarioNotFound)
+		mockDocker.AssertNotCalled(t, "StopContainer", mock.Anything, mock.Anything)
+	})
+}
+
+func TestStopReasons(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("user_stop_records_user_requested", func(t *testing.T) {
+		store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", ContainerID: "container-1", Status: "running"})
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("StopContainer", mock.Anything, "container-1").Return(nil)
+
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+		require.NoError(t, manager.StopScenario(ctx, "scn-1"))
+
+		stored, err := store.GetScenario(ctx, "scn-1")
+		require.NoError(t, err)
+		assert.Equal(t, types.ScenarioStatusStopped, stored.Status)
+		assert.Equal(t, types.StopReasonUserRequested, stored.StopReason)
+	})
+
+	t.Run("vanished_container_records_orphaned", func(t *testing.T) {
+		store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-2", ContainerID: "container-2", Status: "running"})
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("ContainerExists", mock.Anything, "container-2").Return(false, nil)
+
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+		resp, err := manager.GetScenarioStatus(ctx, "scn-2")
+		require.NoError(t, err)
+		assert.Equal(t, types.StopReasonOrphaned, resp.StopReason)
+
+		stored, err := store.GetScenario(ctx, "scn-2")
+		require.NoError(t, err)
+		assert.Equal(t, types.StopReasonOrphaned, stored.StopReason)
+	})
+
+	t.Run("exited_container_records_failed", func(t *testing.T) {
+		store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-3", ContainerID: "container-3", Status: "running"})
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("ContainerExists", mock.Anything, "container-3").Return(true, nil)
+		mockDocker.On("GetContainerStatus", mock.Anything, "container-3").Return("exited", nil)
+
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+		resp, err := manager.GetScenarioStatus(ctx, "scn-3")
+		require.NoError(t, err)
+		assert.Equal(t, types.ScenarioStatusStopped, resp.Status)
+		assert.Equal(t, types.StopReasonFailed, resp.StopReason)
+	})
+
+	t.Run("user_stop_survives_container_removal", func(t *testing.T) {
+		store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-4", ContainerID: "container-4", Status: "stopped", StopReason: types.StopReasonUserRequested})
+		mockDocker := &MockDockerClient{}
+
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+		resp, err := manager.GetScenarioStatus(ctx, "scn-4")
+		require.NoError(t, err)
+		assert.Equal(t, types.StopReasonUserRequested, resp.StopReason)
+	})
+}
+
+func TestGetScenarioStatus_TerminalSkipsDocker(t *testing.T) {
+	ctx := context.Background()
+
+	for _, status := range []types.ScenarioStatus{types.ScenarioStatusStopped, types.ScenarioStatusCleanedUp, types.ScenarioStatusFailed} {
+		t.Run(string(status), func(t *testing.T) {
+			store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", ContainerID: "container-1", Status: status, StopReason: types.StopReasonExpired})
+			// No expectations: any Docker call fails the test
+			mockDocker := &MockDockerClient{}
+			manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+			resp, err := manager.GetScenarioStatus(ctx, "scn-1")
+
+			require.NoError(t, err)
+			assert.Equal(t, status, resp.Status)
+			assert.Equal(t, types.StopReasonExpired, resp.StopReason)
+			mockDocker.AssertExpectations(t)
+		})
+	}
+}
+
 // TestValidateScenarioType tests scenario type validation
 func TestValidateScenarioType(t *testing.T) {
 	validTypes := []string{"go", "docker", "k8s", "python", "go-k8s", "python-k8s"}
@@ -233,6 +1250,54 @@ func TestValidateScenarioType(t *testing.T) {
 	}
 }
 
+// TestErrorsRetryable tests which scenario errors callers may retry
+func TestErrorsRetryable(t *testing.T) {
+	tests := []struct {
+		err       error
+		retryable bool
+	}{
+		{ErrScenarioNotFound, false},
+		{ErrScenarioNotRunning, false},
+		{ErrScenarioAlreadyStopped, false},
+		{ErrInvalidScenarioID, false},
+		{ErrDatabaseUnavailable, true},
+		{ErrClientCancelled, false},
+		{ErrNotScenarioOwner, false},
+		{ErrMaxLifetimeReached, false},
+		{ErrInvalidScenarioMode, false},
+		{ErrNoResults, false},
+		{ErrResultsNotReady, true},
+		{ErrCapacityReached, true},
+		{ErrInvalidDirectoryFormat, false},
+		{ErrInvalidPageToken, false},
+		{ErrInvalidOverride, false},
+		{ErrInvalidSearchQuery, false},
+		{ErrScriptTooLarge, false},
+		{ErrProvisioningBusy, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			assert.Equal(t, tt.retryable, retry.IsRetryable(tt.err))
+			assert.Equal(t, tt.retryable, retry.IsRetryable(fmt.Errorf("%w: scn-1", tt.err)))
+		})
+	}
+
+	t.Run("docker_errors_keep_their_classification", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).Return("", 0, docker.ErrDockerDaemonUnavailable).Once()
+		mockDocker.On("StartScenarioContainer", mock.Anything, specFor("go", "")).Return("", 0, docker.ErrInvalidScenarioType).Once()
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+		req := &types.StartScenarioRequest{UserID: "test-user", ScenarioType: "go"}
+
+		_, err := manager.StartScenario(context.Background(), req)
+		assert.True(t, retry.IsRetryable(err))
+
+		_, err = manager.StartScenario(context.Background(), req)
+		assert.False(t, retry.IsRetryable(err))
+	})
+}
+
 // TestGenerateScenarioID tests scenario ID generation
 func TestGenerateScenarioID(t *testing.T) {
 	id1 := generateScenarioID()
@@ -267,6 +1332,129 @@ func TestGetImageForScenarioType(t *testing.T) {
 	}
 }
 
+// TestExtendedExpiry tests how an extend request moves ExpiresAt
+func TestExtendedExpiry(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	maxAge, increment, maxLifetime := 24*time.Hour, time.Hour, 72*time.Hour
+
+	testCases := []struct {
+		name        string
+		scenario    *storage.Scenario
+		expected    time.Time
+		expectedErr error
+	}{
+		{
+			name:     "adds_increment_to_current_expiry",
+			scenario: &storage.Scenario{CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(23 * time.Hour)},
+			expected: now.Add(24 * time.Hour),
+		},
+		{
+			name:     "legacy_scenario_uses_created_at_plus_max_age",
+			scenario: &storage.Scenario{CreatedAt: now.Add(-time.Hour)},
+			expected: now.Add(24 * time.Hour),
+		},
+		{
+			name:     "lapsed_expiry_extends_from_now",
+			scenario: &storage.Scenario{CreatedAt: now.Add(-30 * time.Hour), ExpiresAt: now.Add(-time.Minute)},
+			expected: now.Add(time.Hour),
+		},
+		{
+			name:     "capped_at_max_lifetime",
+			scenario: &storage.Scenario{CreatedAt: now.Add(-71*time.Hour - 30*time.Minute), ExpiresAt: now.Add(15 * time.Minute)},
+			expected: now.Add(30 * time.Minute),
+		},
+		{
+			name:        "max_lifetime_reached",
+			scenario:    &storage.Scenario{CreatedAt: now.Add(-72 * time.Hour), ExpiresAt: now},
+			expectedErr: ErrMaxLifetimeReached,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expiresAt, err := extendedExpiry(tc.scenario, now, maxAge, increment, maxLifetime)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, expiresAt)
+		})
+	}
+}
+
+func TestScenarioTTL(t *testing.T) {
+	cleanup := config.CleanupConfig{
+		MaxScenarioAge:      2 * time.Hour,
+		MaxScenarioLifetime: 72 * time.Hour,
+		MinScenarioTTL:      5 * time.Minute,
+		MaxScenarioTTL:      24 * time.Hour,
+	}
+
+	testCases := []struct {
+		name             string
+		rejectOutOfRange bool
+		seconds          int
+		expected         time.Duration
+		expectError      bool
+	}{
+		{name: "unset_uses_max_age", seconds: 0, expected: 2 * time.Hour},
+		{name: "within_range", seconds: 3600, expected: time.Hour},
+		{name: "below_min_clamped", seconds: 1, expected: 5 * time.Minute},
+		{name: "above_max_clamped", seconds: 48 * 3600, expected: 24 * time.Hour},
+		{name: "below_min_rejected", rejectOutOfRange: true, seconds: 1, expectError: true},
+		{name: "above_max_rejected", rejectOutOfRange: true, seconds: 48 * 3600, expectError: true},
+		{name: "negative", seconds: -1, expectError: true},
+		{name: "beyond_max_lifetime", seconds: 73 * 3600, expectError: true},
+		{name: "ten_years", seconds: 10 * 365 * 24 * 3600, expectError: true},
+		{name: "overflowing_duration", seconds: math.MaxInt, expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := cleanup
+			cfg.RejectTTLOutOfRange = tc.rejectOutOfRange
+			manager := &Manager{Cfg: &config.Config{Cleanup: cfg}}
+
+			ttl, err := manager.scenarioTTL("go", tc.seconds)
+			if tc.expectError {
+				assert.ErrorIs(t, err, ErrInvalidTTL)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ttl)
+		})
+	}
+}
+
+func TestStartScenario_TTL(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("StartScenarioContainer", mock.Anything, mock.Anything).Return("container123", 3001, nil)
+	store := storage.NewMemoryStore()
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	before := time.Now()
+	resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+		UserID:       "test-user",
+		ScenarioType: "go",
+		TTLSeconds:   1800,
+	})
+	require.NoError(t, err)
+
+	stored, err := store.GetScenario(context.Background(), resp.ScenarioID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, before.Add(30*time.Minute), stored.ExpiresAt, 5*time.Second)
+
+	resp, err = manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+		UserID:       "test-user",
+		ScenarioType: "go",
+		TTLSeconds:   -60,
+	})
+	assert.ErrorIs(t, err, ErrInvalidTTL)
+	assert.Nil(t, resp)
+	mockDocker.AssertNumberOfCalls(t, "StartScenarioContainer", 1)
+}
+
 // TestNilContextHandling tests nil context handling
 func TestNilContextHandling(t *testing.T) {
 	manager := &Manager{
@@ -334,8 +1522,8 @@ func validateScenarioType(scenarioType string) error {
 }
 
 func generateScenarioID() string {
-	// Simple implementation for testing
-	return "scn-test-123456"
+	id, _ := newScenarioID(config.ScenarioIDConfig{})
+	return id
 }
 
 func getImageForScenarioType(scenarioType string) string {
@@ -349,3 +1537,390 @@ func getImageForScenarioType(scenarioType string) string {
 	}
 	return imageMap[scenarioType]
 }
+
+const sampleFindOutput = `/home/devlab d
+/home/devlab/main.go f
+/home/devlab/pkg d
+/home/devlab/pkg/util d
+/home/devlab/pkg/util/util.go f
+/home/devlab/pkg/README.md f
+/home/devlab/.cache d
+/home/devlab/.cache/go-build f
+`
+
+func TestBuildNestedTree(t *testing.T) {
+	tree := buildNestedTree(sampleFindOutput)
+
+	require.NotNil(t, tree)
+	assert.Equal(t, "/home/devlab", tree.Path)
+	assert.True(t, tree.IsRoot)
+	require.Len(t, tree.Children, 2, "cache directories are skipped")
+
+	mainGo, pkg := tree.Children[0], tree.Children[1]
+	assert.Equal(t, "/home/devlab/main.go", mainGo.Path)
+	assert.Equal(t, "file", mainGo.Type)
+	assert.Empty(t, mainGo.Children)
+
+	assert.Equal(t, "/home/devlab/pkg", pkg.Path)
+	assert.Equal(t, "folder", pkg.Type)
+	require.Len(t, pkg.Children, 2)
+	assert.Equal(t, "/home/devlab/pkg/util", pkg.Children[0].Path)
+	assert.Equal(t, "/home/devlab/pkg/README.md", pkg.Children[1].Path)
+
+	require.Len(t, pkg.Children[0].Children, 1)
+	assert.Equal(t, "/home/devlab/pkg/util/util.go", pkg.Children[0].Children[0].Path)
+
+	// The flat format lists the same nodes from the same output
+	flat, err := parseDirectoryStructure(sampleFindOutput)
+	require.NoError(t, err)
+	assert.Len(t, flat, 6)
+}
+
+func TestBuildNestedTree_EmptyOutput(t *testing.T) {
+	tree := buildNestedTree("")
+
+	require.NotNil(t, tree)
+	assert.True(t, tree.IsRoot)
+	assert.Empty(t, tree.Children)
+}
+
+func TestGetDirectoryStructure_Formats(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ContainerExists", mock.Anything, "container123").Return(true, nil)
+	mockDocker.On("ExecuteCommand", mock.Anything, "container123", mock.Anything).Return(sampleFindOutput, nil)
+
+	manager := &Manager{
+		Cfg:    &config.Config{},
+		Docker: mockDocker,
+		Store: storage.NewMemoryStore(&storage.Scenario{
+			ScenarioID: "scn-1", ContainerID: "container123", Status: types.ScenarioStatusRunning,
+		}),
+	}
+	ctx := context.Background()
+
+	flat, err := manager.GetDirectoryStructure(ctx, "scn-1", "")
+	require.NoError(t, err)
+	assert.Len(t, flat.Structure, 6)
+	assert.Nil(t, flat.Tree)
+
+	nested, err := manager.GetDirectoryStructure(ctx, "scn-1", types.DirectoryFormatNested)
+	require.NoError(t, err)
+	assert.Empty(t, nested.Structure)
+	require.NotNil(t, nested.Tree)
+	assert.Len(t, nested.Tree.Children, 2)
+
+	_, err = manager.GetDirectoryStructure(ctx, "scn-1", "xml")
+	assert.ErrorIs(t, err, ErrInvalidDirectoryFormat)
+}
+
+func TestGetDirectoryStructure_Truncated(t *testing.T) {
+	newManager := func(mockDocker *MockDockerClient, cfg *config.Config) *Manager {
+		mockDocker.On("ContainerExists", mock.Anything, "container123").Return(true, nil)
+		return &Manager{
+			Cfg:    cfg,
+			Docker: mockDocker,
+			Store: storage.NewMemoryStore(&storage.Scenario{
+				ScenarioID: "scn-1", ContainerID: "container123", Status: types.ScenarioStatusRunning,
+			}),
+		}
+	}
+	ctx := context.Background()
+
+	t.Run("partial_output_on_timeout", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		partial := "/home/devlab d\n/home/devlab/pkg d\n/home/devlab/pkg/util.go f\n/home/devlab/pkg/READ"
+		mockDocker.On("ExecuteCommand", mock.Anything, "container123", mock.MatchedBy(func(cmd []string) bool {
+			return len(cmd) > 3 && cmd[2] == "-maxdepth" && cmd[3] == "4"
+		})).Return(partial, context.DeadlineExceeded).Once()
+		manager := newManager(mockDocker, &config.Config{Directory: config.DirectoryConfig{MaxDepth: 4}})
+
+		resp, err := manager.GetDirectoryStructure(ctx, "scn-1", types.DirectoryFormatNested)
+		require.NoError(t, err)
+		assert.True(t, resp.Truncated)
+		require.NotNil(t, resp.Tree)
+		require.Len(t, resp.Tree.Children, 1)
+		assert.Equal(t, "/home/devlab/pkg", resp.Tree.Children[0].Path)
+		assert.Len(t, resp.Tree.Children[0].Children, 1)
+		mockDocker.AssertExpectations(t)
+	})
+
+	t.Run("bounded_by_request_deadline", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		partial := "/home/devlab d\n/home/devlab/pkg d\n"
+		mockDocker.On("ExecuteCommand", mock.MatchedBy(func(execCtx context.Context) bool {
+			deadline, ok := execCtx.Deadline()
+			return ok && time.Until(deadline) < time.Second
+		}), "container123", mock.Anything).Return(partial, context.DeadlineExceeded).Once()
+		manager := newManager(mockDocker, &config.Config{Directory: config.DirectoryConfig{Timeout: time.Minute}})
+
+		reqCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		resp, err := manager.GetDirectoryStructure(reqCtx, "scn-1", "")
+		require.NoError(t, err)
+		assert.True(t, resp.Truncated)
+		assert.Len(t, resp.Structure, 2)
+		mockDocker.AssertExpectations(t)
+	})
+
+	t.Run("entry_cap", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("ExecuteCommand", mock.Anything, "container123", mock.Anything).Return(sampleFindOutput, nil)
+		manager := newManager(mockDocker, &config.Config{Directory: config.DirectoryConfig{MaxEntries: 3}})
+
+		resp, err := manager.GetDirectoryStructure(ctx, "scn-1", "")
+		require.NoError(t, err)
+		assert.True(t, resp.Truncated)
+		assert.Len(t, resp.Structure, 3)
+	})
+
+	t.Run("entry_cap_keeps_top_levels", func(t *testing.T) {
+		// 40 top-level directories of 250 files each, listed depth-first as
+		// find prints them
+		var find strings.Builder
+		find.WriteString("/home/devlab d\n")
+		for d := 0; d < 40; d++ {
+			dir := fmt.Sprintf("/home/devlab/dir%02d", d)
+			find.WriteString(dir + " d\n")
+			for f := 0; f < 250; f++ {
+				fmt.Fprintf(&find, "%s/file%03d.txt f\n", dir, f)
+			}
+		}
+
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("ExecuteCommand", mock.Anything, "container123", mock.Anything).Return(find.String(), nil)
+		manager := newManager(mockDocker, &config.Config{Directory: config.DirectoryConfig{MaxEntries: 100}})
+
+		flat, err := manager.GetDirectoryStructure(ctx, "scn-1", "")
+		require.NoError(t, err)
+		assert.True(t, flat.Truncated)
+		require.Len(t, flat.Structure, 100)
+		paths := make(map[string]bool)
+		for _, node := range flat.Structure {
+			paths[node.Path] = true
+		}
+		for d := 0; d < 40; d++ {
+			assert.True(t, paths[fmt.Sprintf("/home/devlab/dir%02d", d)], "top-level directory %d must be kept", d)
+		}
+		for path := range paths {
+			if path != "/home/devlab" {
+				assert.True(t, paths[getParentPath(path)], "parent of %s must be kept", path)
+			}
+		}
+
+		nested, err := manager.GetDirectoryStructure(ctx, "scn-1", types.DirectoryFormatNested)
+		require.NoError(t, err)
+		assert.True(t, nested.Truncated)
+		require.Len(t, nested.Tree.Children, 40)
+		assert.Len(t, nested.Tree.Children[0].Children, 59, "the remaining budget goes to the first directories listed")
+	})
+
+	t.Run("retries_empty_failure", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("ExecuteCommand", mock.Anything, "container123", mock.Anything).Return("", errors.New("connection reset")).Once()
+		mockDocker.On("ExecuteCommand", mock.Anything, "container123", mock.Anything).Return(sampleFindOutput, nil).Once()
+		manager := newManager(mockDocker, &config.Config{})
+
+		resp, err := manager.GetDirectoryStructure(ctx, "scn-1", "")
+		require.NoError(t, err)
+		assert.False(t, resp.Truncated)
+		assert.Len(t, resp.Structure, 6)
+		mockDocker.AssertNumberOfCalls(t, "ExecuteCommand", 2)
+	})
+}
+
+func TestGetDirectoryStructure_RetriesUntilReady(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Directory: config.DirectoryConfig{RetryAttempts: 3, RetryDelay: time.Millisecond}}
+
+	tests := []struct {
+		name          string
+		results       [][2]interface{}
+		expectedCalls int
+		expectedErr   error
+	}{
+		{
+			name:          "fails_once",
+			results:       [][2]interface{}{{"", errors.New("OCI runtime exec failed")}, {sampleFindOutput, nil}},
+			expectedCalls: 2,
+		},
+		{
+			name:          "empty_until_ready",
+			results:       [][2]interface{}{{"", nil}, {"", nil}, {sampleFindOutput, nil}},
+			expectedCalls: 3,
+		},
+		{
+			name:          "gives_up_after_attempts",
+			results:       [][2]interface{}{{"", errors.New("OCI runtime exec failed")}, {"", errors.New("OCI runtime exec failed")}, {"", errors.New("OCI runtime exec failed")}},
+			expectedCalls: 3,
+			expectedErr:   errors.New("failed to get directory structure: OCI runtime exec failed"),
+		},
+		{
+			name:          "container_stopped",
+			results:       [][2]interface{}{{"", docker.ErrContainerNotRunning}},
+			expectedCalls: 1,
+			expectedErr:   fmt.Errorf("failed to get directory structure: %w", docker.ErrContainerNotRunning),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDocker := &MockDockerClient{}
+			mockDocker.On("ContainerExists", mock.Anything, "container123").Return(true, nil)
+			for _, result := range tt.results {
+				mockDocker.On("ExecuteCommand", mock.Anything, "container123", mock.Anything).Return(result[0], result[1]).Once()
+			}
+			manager := &Manager{
+				Cfg:    cfg,
+				Docker: mockDocker,
+				Store: storage.NewMemoryStore(&storage.Scenario{
+					ScenarioID: "scn-1", ContainerID: "container123", Status: types.ScenarioStatusRunning,
+				}),
+			}
+
+			resp, err := manager.GetDirectoryStructure(ctx, "scn-1", "")
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, resp.Structure, 6)
+			}
+			mockDocker.AssertNumberOfCalls(t, "ExecuteCommand", tt.expectedCalls)
+		})
+	}
+}
+
+func TestGetDirectoryStructure_RetriesShareTimeout(t *testing.T) {
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ContainerExists", mock.Anything, "container123").Return(true, nil)
+	mockDocker.On("ExecuteCommand", mock.Anything, "container123", mock.Anything).Return("", errors.New("OCI runtime exec failed"))
+	manager := &Manager{
+		Cfg: &config.Config{Directory: config.DirectoryConfig{
+			Timeout: 100 * time.Millisecond, RetryAttempts: 1000, RetryDelay: 20 * time.Millisecond,
+		}},
+		Docker: mockDocker,
+		Store: storage.NewMemoryStore(&storage.Scenario{
+			ScenarioID: "scn-1", ContainerID: "container123", Status: types.ScenarioStatusRunning,
+		}),
+	}
+
+	started := time.Now()
+	_, err := manager.GetDirectoryStructure(context.Background(), "scn-1", "")
+	assert.EqualError(t, err, "failed to get directory structure: OCI runtime exec failed")
+	assert.Less(t, time.Since(started), time.Second, "retries stop once the listing timeout is spent")
+	assert.Less(t, len(mockDocker.Calls), 20)
+}
+
+// listOptsDocker records the options the directory listing is run with
+type listOptsDocker struct {
+	docker.Client
+	opts docker.ExecuteCommandOpts
+}
+
+func (d *listOptsDocker) ContainerExists(ctx context.Context, containerID string) (bool, error) {
+	return true, nil
+}
+
+func (d *listOptsDocker) ExecuteCommand(ctx context.Context, containerID string, command []string, opts docker.ExecuteCommandOpts) (string, error) {
+	d.opts = opts
+	return sampleFindOutput, nil
+}
+
+func TestGetDirectoryStructure_User(t *testing.T) {
+	ctx := context.Background()
+	newManager := func(cfg *config.Config) (*Manager, *listOptsDocker) {
+		recorder := &listOptsDocker{}
+		return &Manager{
+			Cfg:    cfg,
+			Docker: recorder,
+			Store: storage.NewMemoryStore(&storage.Scenario{
+				ScenarioID: "scn-1", ContainerID: "container123", Status: types.ScenarioStatusRunning,
+			}),
+		}, recorder
+	}
+
+	manager, recorder := newManager(&config.Config{})
+	_, err := manager.GetDirectoryStructure(ctx, "scn-1", "")
+	require.NoError(t, err)
+	assert.Empty(t, recorder.opts.User, "listings run as the container's default user unless configured")
+
+	manager, recorder = newManager(&config.Config{Directory: config.DirectoryConfig{User: docker.ScenarioUser}})
+	_, err = manager.GetDirectoryStructure(ctx, "scn-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, docker.ScenarioUser, recorder.opts.User)
+}
+
+func TestStatusUpdates_RetryTransientStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{Mongo: config.MongoConfig{UpdateRetries: 2, UpdateBackoff: time.Millisecond}}
+
+	tests := []struct {
+		name           string
+		setupMock      func(*MockDockerClient)
+		update         func(*Manager) error
+		expectedStatus types.ScenarioStatus
+		expectedReason types.StopReason
+	}{
+		{
+			name: "status_reconciliation",
+			setupMock: func(m *MockDockerClient) {
+				m.On("ContainerExists", mock.Anything, "container-1").Return(false, nil)
+			},
+			update: func(m *Manager) error {
+				_, err := m.GetScenarioStatus(ctx, "scn-1")
+				return err
+			},
+			expectedStatus: types.ScenarioStatusStopped,
+			expectedReason: types.StopReasonOrphaned,
+		},
+		{
+			name: "stop",
+			setupMock: func(m *MockDockerClient) {
+				m.On("StopContainer", mock.Anything, "container-1").Return(nil)
+			},
+			update: func(m *Manager) error {
+				return m.StopScenario(ctx, "scn-1")
+			},
+			expectedStatus: types.ScenarioStatusStopped,
+			expectedReason: types.StopReasonUserRequested,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &storagetest.FlakyStore{
+				Store:    storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", UserID: "alice", ContainerID: "container-1", Status: types.ScenarioStatusRunning}),
+				Err:      ErrDatabaseUnavailable,
+				Failures: 1,
+			}
+			mockDocker := &MockDockerClient{}
+			tt.setupMock(mockDocker)
+			manager := &Manager{Cfg: cfg, Docker: mockDocker, Store: store}
+
+			require.NoError(t, tt.update(manager))
+			assert.Equal(t, 2, store.Updates)
+
+			stored, err := store.GetScenario(ctx, "scn-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, stored.Status)
+			assert.Equal(t, tt.expectedReason, stored.StopReason)
+			mockDocker.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStopScenario_GivesUpAfterRetries(t *testing.T) {
+	ctx := context.Background()
+	store := &storagetest.FlakyStore{
+		Store:    storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", UserID: "alice", ContainerID: "container-1", Status: types.ScenarioStatusRunning}),
+		Err:      ErrDatabaseUnavailable,
+		Failures: 10,
+	}
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("StopContainer", mock.Anything, "container-1").Return(nil)
+	cfg := &config.Config{Mongo: config.MongoConfig{UpdateRetries: 2, UpdateBackoff: time.Millisecond}}
+	manager := &Manager{Cfg: cfg, Docker: mockDocker, Store: store}
+
+	err := manager.StopScenario(ctx, "scn-1")
+	assert.ErrorIs(t, err, ErrDatabaseUnavailable)
+	assert.Equal(t, 3, store.Updates)
+}
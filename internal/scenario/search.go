@@ -0,0 +1,62 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxSearchQueryLength bounds the free text accepted by SearchScenarios
+const maxSearchQueryLength = 256
+
+// SearchScenarios finds userID's scenarios whose name, tags, notes, or type match
+// query, best match first. Paging follows ListUserScenariosPage: pageSize
+// defaults to DefaultListPageSize and is capped at MaxListPageSize.
+func (m *Manager) SearchScenarios(ctx context.Context, userID, query, pageToken string, pageSize int) (*types.SearchScenariosResponse, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: query cannot be empty", ErrInvalidSearchQuery)
+	}
+	if len(query) > maxSearchQueryLength {
+		return nil, fmt.Errorf("%w: query exceeds %d characters", ErrInvalidSearchQuery, maxSearchQueryLength)
+	}
+
+	if pageSize <= 0 {
+		pageSize = DefaultListPageSize
+	} else if pageSize > MaxListPageSize {
+		pageSize = MaxListPageSize
+	}
+
+	hits, next, err := m.store().SearchScenarios(ctx, userID, query, pageToken, pageSize)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidPageToken) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+		}
+		return nil, fmt.Errorf("failed to search scenarios: %w", err)
+	}
+
+	results := make([]types.ScenarioSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, types.ScenarioSearchResult{
+			ScenarioID:   hit.Scenario.ScenarioID,
+			Name:         hit.Scenario.Name,
+			Tags:         hit.Scenario.Tags,
+			ScenarioType: hit.Scenario.ScenarioType,
+			Status:       hit.Scenario.Status,
+			CreatedAt:    hit.Scenario.CreatedAt,
+			Score:        hit.Score,
+		})
+	}
+	return &types.SearchScenariosResponse{
+		Results:       results,
+		NextPageToken: next,
+		Message:       "Scenarios searched successfully",
+	}, nil
+}
@@ -0,0 +1,36 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/storage"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchScenarios(t *testing.T) {
+	manager := &Manager{
+		Cfg: &config.Config{},
+		Store: storage.NewMemoryStore(
+			&storage.Scenario{ScenarioID: "scn-1", UserID: "test-user", ScenarioType: "go", Name: "Channels lab", Tags: []string{"concurrency"}},
+			&storage.Scenario{ScenarioID: "scn-2", UserID: "other-user", ScenarioType: "go", Name: "Channels lab"},
+		),
+	}
+	ctx := context.Background()
+
+	resp, err := manager.SearchScenarios(ctx, "test-user", "  channels ", "", 0)
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	assert.Equal(t, "scn-1", resp.Results[0].ScenarioID)
+	assert.Equal(t, []string{"concurrency"}, resp.Results[0].Tags)
+	assert.Positive(t, resp.Results[0].Score)
+	assert.Empty(t, resp.NextPageToken)
+
+	_, err = manager.SearchScenarios(ctx, "test-user", "   ", "", 0)
+	assert.ErrorIs(t, err, ErrInvalidSearchQuery)
+
+	_, err = manager.SearchScenarios(ctx, "test-user", "channels", "bogus", 0)
+	assert.ErrorIs(t, err, ErrInvalidPageToken)
+}
@@ -0,0 +1,39 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/types"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ScenarioStats summarises every stored scenario: counts by status, type and
+// user, and the longest-running scenario
+func (m *Manager) ScenarioStats(ctx context.Context) (*types.ScenarioStatsResponse, error) {
+	if ctx == nil {
+		return nil, errors.New("nil context provided")
+	}
+
+	stats, err := m.store().ScenarioStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute scenario stats: %w", err)
+	}
+
+	resp := &types.ScenarioStatsResponse{
+		Total:    stats.Total,
+		ByStatus: stats.ByStatus,
+		ByType:   stats.ByType,
+		ByUser:   stats.ByUser,
+	}
+	if oldest := stats.OldestRunning; oldest != nil {
+		resp.OldestRunning = &types.RunningScenarioSummary{
+			ScenarioID:   oldest.ScenarioID,
+			UserID:       oldest.UserID,
+			ScenarioType: oldest.ScenarioType,
+			CreatedAt:    oldest.CreatedAt,
+			RunningFor:   time.Since(oldest.CreatedAt).Truncate(time.Second).String(),
+		}
+	}
+	return resp, nil
+}
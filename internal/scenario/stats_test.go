@@ -0,0 +1,37 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScenarioStats(t *testing.T) {
+	started := time.Now().Add(-2 * time.Hour)
+	manager := &Manager{
+		Cfg: &config.Config{},
+		Store: storage.NewMemoryStore(
+			&storage.Scenario{ScenarioID: "scn-1", UserID: "alice", ScenarioType: "go", Status: types.ScenarioStatusRunning, CreatedAt: started},
+			&storage.Scenario{ScenarioID: "scn-2", UserID: "bob", ScenarioType: "go", Status: types.ScenarioStatusStopped, CreatedAt: started.Add(-time.Hour)},
+		),
+	}
+
+	resp, err := manager.ScenarioStats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.Total)
+	assert.Equal(t, map[types.ScenarioStatus]int{types.ScenarioStatusRunning: 1, types.ScenarioStatusStopped: 1}, resp.ByStatus)
+	assert.Equal(t, map[string]int{"go": 2}, resp.ByType)
+	assert.Equal(t, map[string]int{"alice": 1, "bob": 1}, resp.ByUser)
+	require.NotNil(t, resp.OldestRunning)
+	assert.Equal(t, "scn-1", resp.OldestRunning.ScenarioID)
+	assert.Equal(t, "alice", resp.OldestRunning.UserID)
+	running, err := time.ParseDuration(resp.OldestRunning.RunningFor)
+	require.NoError(t, err)
+	assert.InDelta(t, (2 * time.Hour).Seconds(), running.Seconds(), 5)
+}
@@ -0,0 +1,113 @@
+package scenario
+
+import (
+	"devlab/internal/docker"
+	"devlab/internal/types"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+)
+
+// LoadTemplates reads the scenario templates in the JSON file at path, an
+// object keyed by template name, e.g.
+// {"intro-go-lab":{"scenario_type":"go","script":"...","tags":["intro"]}}.
+// Every template is validated so a broken one fails at load time rather
+// than on the first start that names it.
+func LoadTemplates(path string) (map[string]types.ScenarioTemplate, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario templates: %w", err)
+	}
+	var templates map[string]types.ScenarioTemplate
+	if err := json.Unmarshal(content, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario templates %s: %w", path, err)
+	}
+	for name, tmpl := range templates {
+		if err := validateTemplate(tmpl); err != nil {
+			return nil, fmt.Errorf("invalid scenario template %q: %w", name, err)
+		}
+	}
+	return templates, nil
+}
+
+func validateTemplate(tmpl types.ScenarioTemplate) error {
+	if tmpl.ScenarioType == "" {
+		return fmt.Errorf("%w: scenario type cannot be empty", docker.ErrInvalidScenarioType)
+	}
+	if !tmpl.Mode.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidScenarioMode, tmpl.Mode)
+	}
+	if err := docker.ValidateEnv(tmpl.Env); err != nil {
+		return err
+	}
+	if err := docker.ValidateLabels(tmpl.Labels); err != nil {
+		return err
+	}
+	if tmpl.Limits != nil {
+		if err := docker.ValidateLimits(docker.ResourceLimits{CPUs: tmpl.Limits.CPUs, MemoryMB: tmpl.Limits.MemoryMB}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandTemplate returns req with the template it names filled in. Fields
+// req sets override the template's, limits field by field, and env and
+// labels are merged key by key. req itself is left untouched; a request
+// naming no template is returned as is.
+func (m *Manager) expandTemplate(req *types.StartScenarioRequest) (*types.StartScenarioRequest, error) {
+	if req.Template == "" {
+		return req, nil
+	}
+	tmpl, ok := m.Templates[req.Template]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTemplate, req.Template)
+	}
+
+	expanded := *req
+	if expanded.ScenarioType == "" {
+		expanded.ScenarioType = tmpl.ScenarioType
+	}
+	if expanded.Mode == "" {
+		expanded.Mode = tmpl.Mode
+	}
+	if expanded.Script == "" {
+		expanded.Script = tmpl.Script
+	}
+	if len(expanded.Tags) == 0 {
+		expanded.Tags = tmpl.Tags
+	}
+	if expanded.TTLSeconds == 0 {
+		expanded.TTLSeconds = tmpl.TTLSeconds
+	}
+	if expanded.ScriptTimeoutSeconds == 0 {
+		expanded.ScriptTimeoutSeconds = tmpl.ScriptTimeoutSeconds
+	}
+	expanded.Env = mergeOver(tmpl.Env, req.Env)
+	expanded.Labels = mergeOver(tmpl.Labels, req.Labels)
+	if tmpl.Limits != nil {
+		limits := *tmpl.Limits
+		if req.Limits != nil {
+			if req.Limits.CPUs != 0 {
+				limits.CPUs = req.Limits.CPUs
+			}
+			if req.Limits.MemoryMB != 0 {
+				limits.MemoryMB = req.Limits.MemoryMB
+			}
+		}
+		expanded.Limits = &limits
+	}
+	return &expanded, nil
+}
+
+// mergeOver returns base with overrides applied, or nil when both are empty
+func mergeOver(base, overrides map[string]string) map[string]string {
+	if len(base) == 0 && len(overrides) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(base)+len(overrides))
+	maps.Copy(merged, base)
+	maps.Copy(merged, overrides)
+	return merged
+}
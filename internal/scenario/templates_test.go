@@ -0,0 +1,182 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/docker"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var introGoLab = types.ScenarioTemplate{
+	ScenarioType: "go",
+	Script:       "go mod init lab",
+	Env:          map[string]string{"LAB": "intro", "LEVEL": "1"},
+	Labels:       map[string]string{"course": "go-101"},
+	Limits:       &types.ResourceLimits{CPUs: 1, MemoryMB: 1024},
+	Tags:         []string{"intro", "go"},
+	TTLSeconds:   3600,
+}
+
+func TestExpandTemplate(t *testing.T) {
+	manager := &Manager{Templates: map[string]types.ScenarioTemplate{"intro-go-lab": introGoLab}}
+
+	t.Run("fills_in_template", func(t *testing.T) {
+		req := &types.StartScenarioRequest{UserID: "alice", Template: "intro-go-lab"}
+
+		expanded, err := manager.expandTemplate(req)
+		require.NoError(t, err)
+		assert.Equal(t, &types.StartScenarioRequest{
+			UserID:       "alice",
+			Template:     "intro-go-lab",
+			ScenarioType: "go",
+			Script:       "go mod init lab",
+			Env:          map[string]string{"LAB": "intro", "LEVEL": "1"},
+			Labels:       map[string]string{"course": "go-101"},
+			Limits:       &types.ResourceLimits{CPUs: 1, MemoryMB: 1024},
+			Tags:         []string{"intro", "go"},
+			TTLSeconds:   3600,
+		}, expanded)
+	})
+
+	t.Run("request_overrides_template", func(t *testing.T) {
+		req := &types.StartScenarioRequest{
+			UserID:     "alice",
+			Template:   "intro-go-lab",
+			Script:     "go test ./...",
+			Env:        map[string]string{"LEVEL": "2", "DEBUG": "1"},
+			Limits:     &types.ResourceLimits{MemoryMB: 2048},
+			Tags:       []string{"homework"},
+			TTLSeconds: 600,
+		}
+
+		expanded, err := manager.expandTemplate(req)
+		require.NoError(t, err)
+		assert.Equal(t, "go", expanded.ScenarioType)
+		assert.Equal(t, "go test ./...", expanded.Script)
+		assert.Equal(t, map[string]string{"LAB": "intro", "LEVEL": "2", "DEBUG": "1"}, expanded.Env)
+		assert.Equal(t, map[string]string{"course": "go-101"}, expanded.Labels)
+		assert.Equal(t, &types.ResourceLimits{CPUs: 1, MemoryMB: 2048}, expanded.Limits)
+		assert.Equal(t, []string{"homework"}, expanded.Tags)
+		assert.Equal(t, 600, expanded.TTLSeconds)
+
+		// The caller's request and the registry are left as they were
+		assert.Equal(t, map[string]string{"LEVEL": "2", "DEBUG": "1"}, req.Env)
+		assert.Equal(t, "", req.ScenarioType)
+		assert.Equal(t, map[string]string{"LAB": "intro", "LEVEL": "1"}, manager.Templates["intro-go-lab"].Env)
+		assert.Equal(t, &types.ResourceLimits{CPUs: 1, MemoryMB: 1024}, manager.Templates["intro-go-lab"].Limits)
+	})
+
+	t.Run("no_template", func(t *testing.T) {
+		req := &types.StartScenarioRequest{UserID: "alice", ScenarioType: "python"}
+
+		expanded, err := manager.expandTemplate(req)
+		require.NoError(t, err)
+		assert.Same(t, req, expanded)
+	})
+
+	t.Run("unknown_template", func(t *testing.T) {
+		_, err := manager.expandTemplate(&types.StartScenarioRequest{UserID: "alice", Template: "advanced-rust"})
+		assert.ErrorIs(t, err, ErrUnknownTemplate)
+	})
+}
+
+func TestStartScenario_Template(t *testing.T) {
+	t.Run("expanded_server_side", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("StartScenarioContainer", mock.Anything, mock.MatchedBy(func(spec docker.ContainerSpec) bool {
+			return spec.ScenarioType == "go" && spec.Script == "go mod init lab" &&
+				spec.Env["LAB"] == "intro" && spec.Env["LEVEL"] == "3" &&
+				spec.Limits == docker.ResourceLimits{CPUs: 1, MemoryMB: 1024}
+		})).Return("container123", 3001, nil)
+		store := storage.NewMemoryStore()
+		manager := &Manager{
+			Cfg:       &config.Config{},
+			Docker:    mockDocker,
+			Store:     store,
+			Templates: map[string]types.ScenarioTemplate{"intro-go-lab": introGoLab},
+		}
+
+		resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+			UserID:   "test-user",
+			Template: "intro-go-lab",
+			Env:      map[string]string{"LEVEL": "3"},
+		})
+		require.NoError(t, err)
+		mockDocker.AssertExpectations(t)
+
+		stored, err := store.GetScenario(context.Background(), resp.ScenarioID)
+		require.NoError(t, err)
+		assert.Equal(t, "go", stored.ScenarioType)
+		assert.Equal(t, []string{"intro", "go"}, stored.Tags)
+	})
+
+	t.Run("unknown_template", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+		resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{UserID: "test-user", Template: "intro-go-lab"})
+		assert.ErrorIs(t, err, ErrUnknownTemplate)
+		assert.Nil(t, resp)
+		mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+	})
+}
+
+func TestLoadTemplates(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "templates.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		templates, err := LoadTemplates(write(t, `{
+			"intro-go-lab": {
+				"scenario_type": "go",
+				"script": "go mod init lab",
+				"env": {"LAB": "intro", "LEVEL": "1"},
+				"labels": {"course": "go-101"},
+				"limits": {"cpus": 1, "memory_mb": 1024},
+				"tags": ["intro", "go"],
+				"ttl_seconds": 3600
+			}
+		}`))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]types.ScenarioTemplate{"intro-go-lab": introGoLab}, templates)
+	})
+
+	tests := []struct {
+		name     string
+		content  string
+		expected error
+	}{
+		{name: "missing_type", content: `{"lab": {"script": "true"}}`, expected: docker.ErrInvalidScenarioType},
+		{name: "invalid_mode", content: `{"lab": {"scenario_type": "go", "mode": "daemon"}}`, expected: ErrInvalidScenarioMode},
+		{name: "invalid_env", content: `{"lab": {"scenario_type": "go", "env": {"1BAD": "x"}}}`, expected: docker.ErrInvalidEnv},
+		{name: "invalid_limits", content: `{"lab": {"scenario_type": "go", "limits": {"memory_mb": 1}}}`, expected: docker.ErrInvalidLimits},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadTemplates(write(t, tt.content))
+			assert.ErrorIs(t, err, tt.expected)
+			assert.ErrorContains(t, err, `"lab"`)
+		})
+	}
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := LoadTemplates(write(t, `{"lab":`))
+		assert.Error(t, err)
+	})
+
+	t.Run("missing_file", func(t *testing.T) {
+		_, err := LoadTemplates(filepath.Join(t.TempDir(), "missing.json"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,62 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/docker"
+	"devlab/internal/types"
+	"errors"
+	"fmt"
+)
+
+// ListTerminals returns the web terminal of each running scenario owned by
+// userID. Their containers are inspected together with a single
+// container listing rather than one inspect each. Scenarios in any other
+// status are skipped, and a running scenario whose container is gone or not
+// up is reported as unreachable.
+func (m *Manager) ListTerminals(ctx context.Context, userID string) ([]types.ScenarioTerminal, error) {
+	if ctx == nil {
+		return nil, errors.New("nil context provided")
+	}
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	scenarios, err := m.store().ListScenarios(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scenarios: %w", err)
+	}
+
+	terminals := []types.ScenarioTerminal{}
+	var containers map[string]docker.ContainerInfo
+	for _, scenario := range scenarios {
+		if scenario.Status != types.ScenarioStatusRunning {
+			continue
+		}
+		if containers == nil {
+			if containers, err = m.containersByID(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		terminal := types.ScenarioTerminal{ScenarioID: scenario.ScenarioID}
+		if container, ok := containers[scenario.ContainerID]; ok {
+			terminal.URL = container.TerminalURL
+			terminal.Reachable = container.Running() && container.TerminalURL != ""
+		}
+		terminals = append(terminals, terminal)
+	}
+	return terminals, nil
+}
+
+// containersByID lists every container on the Docker host, keyed by ID
+func (m *Manager) containersByID(ctx context.Context) (map[string]docker.ContainerInfo, error) {
+	containers, err := m.Docker.ListContainers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	byID := make(map[string]docker.ContainerInfo, len(containers))
+	for _, container := range containers {
+		byID[container.ID] = container
+	}
+	return byID, nil
+}
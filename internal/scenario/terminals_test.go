@@ -0,0 +1,63 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/docker"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTerminals(t *testing.T) {
+	store := storage.NewMemoryStore(
+		&storage.Scenario{ScenarioID: "scn-up", UserID: "alice", ContainerID: "container-up", Status: types.ScenarioStatusRunning},
+		&storage.Scenario{ScenarioID: "scn-paused", UserID: "alice", ContainerID: "container-paused", Status: types.ScenarioStatusRunning},
+		&storage.Scenario{ScenarioID: "scn-gone", UserID: "alice", ContainerID: "container-gone", Status: types.ScenarioStatusRunning},
+		&storage.Scenario{ScenarioID: "scn-stopped", UserID: "alice", ContainerID: "container-stopped", Status: types.ScenarioStatusStopped},
+		&storage.Scenario{ScenarioID: "scn-bob", UserID: "bob", ContainerID: "container-bob", Status: types.ScenarioStatusRunning},
+	)
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ListContainers", mock.Anything).Return([]docker.ContainerInfo{
+		{ID: "container-up", Status: "Up 5 minutes", TerminalURL: "http://localhost:3001"},
+		{ID: "container-paused", Status: "Up 5 minutes (Paused)", TerminalURL: "http://localhost:3002"},
+		{ID: "container-stopped", Status: "Exited (0) 1 minute ago"},
+		{ID: "container-bob", Status: "Up 1 minute", TerminalURL: "http://localhost:3003"},
+	}, nil).Once()
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	terminals, err := manager.ListTerminals(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []types.ScenarioTerminal{
+		{ScenarioID: "scn-up", URL: "http://localhost:3001", Reachable: true},
+		{ScenarioID: "scn-paused", URL: "http://localhost:3002", Reachable: false},
+		{ScenarioID: "scn-gone", Reachable: false},
+	}, terminals)
+	mockDocker.AssertNumberOfCalls(t, "ListContainers", 1)
+}
+
+func TestListTerminals_NoneRunning(t *testing.T) {
+	store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-stopped", UserID: "alice", Status: types.ScenarioStatusStopped})
+	mockDocker := &MockDockerClient{}
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	terminals, err := manager.ListTerminals(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.NotNil(t, terminals)
+	assert.Empty(t, terminals)
+	mockDocker.AssertNotCalled(t, "ListContainers", mock.Anything)
+}
+
+func TestListTerminals_DockerError(t *testing.T) {
+	store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-up", UserID: "alice", ContainerID: "container-up", Status: types.ScenarioStatusRunning})
+	mockDocker := &MockDockerClient{}
+	mockDocker.On("ListContainers", mock.Anything).Return(nil, docker.ErrDockerDaemonUnavailable)
+	manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+	_, err := manager.ListTerminals(context.Background(), "alice")
+	assert.ErrorIs(t, err, docker.ErrDockerDaemonUnavailable)
+}
@@ -0,0 +1,115 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxNotesLength caps a scenario's notes, in characters
+const MaxNotesLength = 4096
+
+// validateNotes rejects notes that are too long or not valid UTF-8
+func validateNotes(notes string) error {
+	if !utf8.ValidString(notes) {
+		return fmt.Errorf("%w: notes must be valid UTF-8", ErrInvalidNotes)
+	}
+	if n := utf8.RuneCountInString(notes); n > MaxNotesLength {
+		return fmt.Errorf("%w: %d characters exceeds the limit of %d", ErrInvalidNotes, n, MaxNotesLength)
+	}
+	return nil
+}
+
+// UpdateScenario applies the fields set in req to a scenario owned by userID
+// and returns its updated record
+func (m *Manager) UpdateScenario(ctx context.Context, scenarioID, userID string, req *types.UpdateScenarioRequest) (*types.ScenarioDetailsResponse, error) {
+	if ctx == nil {
+		return nil, errors.New("nil context provided")
+	}
+	if req == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+
+	scenario, err := m.getOwnedScenario(ctx, scenarioID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.applyUpdate(ctx, scenario, req); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[scenario] updated scenario %s", scenarioID)
+	resp := m.scenarioDetails(scenario, false)
+	resp.Message = "Scenario updated successfully"
+	return resp, nil
+}
+
+// AdminUpdateScenario applies the fields set in req to any user's scenario
+// on behalf of the admin adminID, e.g. to lock a demo scenario against
+// cleanup. The change is written to the audit log.
+func (m *Manager) AdminUpdateScenario(ctx context.Context, scenarioID, adminID string, req *types.UpdateScenarioRequest) (*types.ScenarioDetailsResponse, error) {
+	if ctx == nil {
+		return nil, errors.New("nil context provided")
+	}
+	if req == nil {
+		return nil, errors.New("request cannot be nil")
+	}
+	if scenarioID == "" {
+		return nil, fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenarioID)
+	}
+
+	scenario, err := m.store().GetScenario(ctx, scenarioID)
+	if err != nil {
+		if errors.Is(err, storage.ErrScenarioNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrScenarioNotFound, scenarioID)
+		}
+		return nil, fmt.Errorf("failed to get scenario: %w", err)
+	}
+	if err := m.applyUpdate(ctx, scenario, req); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{"owner_id": scenario.UserID}
+	if req.Notes != nil {
+		fields["notes_changed"] = true
+	}
+	if req.Locked != nil {
+		fields["locked"] = *req.Locked
+	}
+	auditLog("scenario.admin_update", adminID, scenarioID, fields)
+
+	resp := m.scenarioDetails(scenario, false)
+	resp.Message = "Scenario updated successfully"
+	return resp, nil
+}
+
+// applyUpdate validates req, applies its fields to scenario and stores them.
+// Each field is written on its own, so clearing notes or unlocking persists
+// and a status change made since scenario was read is not overwritten.
+func (m *Manager) applyUpdate(ctx context.Context, scenario *storage.Scenario, req *types.UpdateScenarioRequest) error {
+	if req.Notes != nil {
+		if err := validateNotes(*req.Notes); err != nil {
+			return err
+		}
+		if err := m.store().SetScenarioNotes(ctx, scenario.ScenarioID, *req.Notes); err != nil {
+			log.Printf("[scenario] failed to store notes of scenario %s: %v", scenario.ScenarioID, err)
+			return fmt.Errorf("failed to update scenario: %w", err)
+		}
+		scenario.Notes = *req.Notes
+		scenario.UpdatedAt = time.Now()
+	}
+	if req.Locked != nil {
+		if err := m.store().SetScenarioLocked(ctx, scenario.ScenarioID, *req.Locked); err != nil {
+			log.Printf("[scenario] failed to store lock of scenario %s: %v", scenario.ScenarioID, err)
+			return fmt.Errorf("failed to update scenario: %w", err)
+		}
+		scenario.Locked = *req.Locked
+		scenario.UpdatedAt = time.Now()
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package scenario
+
+import (
+	"context"
+	"devlab/internal/config"
+	"devlab/internal/storage"
+	"devlab/internal/types"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartScenario_Notes(t *testing.T) {
+	t.Run("stored", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		mockDocker.On("StartScenarioContainer", mock.Anything, mock.Anything).Return("container123", 3001, nil)
+		store := storage.NewMemoryStore()
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: store}
+
+		resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+			UserID:       "test-user",
+			ScenarioType: "go",
+			Notes:        "Reproduces the deadlock from ticket 42",
+		})
+		require.NoError(t, err)
+
+		stored, err := store.GetScenario(context.Background(), resp.ScenarioID)
+		require.NoError(t, err)
+		assert.Equal(t, "Reproduces the deadlock from ticket 42", stored.Notes)
+	})
+
+	t.Run("too_long", func(t *testing.T) {
+		mockDocker := &MockDockerClient{}
+		manager := &Manager{Cfg: &config.Config{}, Docker: mockDocker, Store: storage.NewMemoryStore()}
+
+		resp, err := manager.StartScenario(context.Background(), &types.StartScenarioRequest{
+			UserID:       "test-user",
+			ScenarioType: "go",
+			Notes:        strings.Repeat("x", MaxNotesLength+1),
+		})
+		assert.ErrorIs(t, err, ErrInvalidNotes)
+		assert.Nil(t, resp)
+		mockDocker.AssertNotCalled(t, "StartScenarioContainer", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUpdateScenario_NotesRoundTripAndSearch(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(&storage.Scenario{
+		ScenarioID:   "scn-1",
+		UserID:       "test-user",
+		ScenarioType: "go",
+		Name:         "Channels lab",
+		Status:       types.ScenarioStatusRunning,
+	})
+	manager := &Manager{Cfg: &config.Config{}, Store: store}
+	notes := "Pairing session: the worker pool leaks goroutines"
+
+	resp, err := manager.UpdateScenario(ctx, "scn-1", "test-user", &types.UpdateScenarioRequest{Notes: &notes})
+	require.NoError(t, err)
+	assert.Equal(t, notes, resp.Notes)
+	assert.Equal(t, "Channels lab", resp.Name)
+
+	described, err := manager.DescribeScenario(ctx, "scn-1", "test-user", false)
+	require.NoError(t, err)
+	assert.Equal(t, notes, described.Notes)
+
+	found, err := manager.SearchScenarios(ctx, "test-user", "goroutines", "", 0)
+	require.NoError(t, err)
+	require.Len(t, found.Results, 1)
+	assert.Equal(t, "scn-1", found.Results[0].ScenarioID)
+
+	// Leaving notes out of the request keeps them
+	_, err = manager.UpdateScenario(ctx, "scn-1", "test-user", &types.UpdateScenarioRequest{})
+	require.NoError(t, err)
+	described, err = manager.DescribeScenario(ctx, "scn-1", "test-user", false)
+	require.NoError(t, err)
+	assert.Equal(t, notes, described.Notes)
+
+	cleared := ""
+	_, err = manager.UpdateScenario(ctx, "scn-1", "test-user", &types.UpdateScenarioRequest{Notes: &cleared})
+	require.NoError(t, err)
+	found, err = manager.SearchScenarios(ctx, "test-user", "goroutines", "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, found.Results)
+}
+
+// staleReadStore serves the scenario as it was when the store was created,
+// standing in for a status change racing an update
+type staleReadStore struct {
+	storage.Store
+	stale storage.Scenario
+}
+
+func (s *staleReadStore) GetScenario(ctx context.Context, scenarioID string) (*storage.Scenario, error) {
+	stale := s.stale
+	return &stale, nil
+}
+
+func TestUpdateScenario_DoesNotOverwriteStatus(t *testing.T) {
+	ctx := context.Background()
+	read := storage.Scenario{ScenarioID: "scn-1", UserID: "test-user", Notes: "draft", Locked: true, Status: types.ScenarioStatusRunning}
+	current := read
+	current.SetStatus(types.ScenarioStatusStopped, "stopped by user")
+	memory := storage.NewMemoryStore(&current)
+	manager := &Manager{Cfg: &config.Config{}, Store: &staleReadStore{Store: memory, stale: read}}
+
+	cleared, unlocked := "", false
+	_, err := manager.UpdateScenario(ctx, "scn-1", "test-user", &types.UpdateScenarioRequest{Notes: &cleared, Locked: &unlocked})
+	require.NoError(t, err)
+
+	stored, err := memory.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Empty(t, stored.Notes)
+	assert.False(t, stored.Locked)
+	assert.Equal(t, types.ScenarioStatusStopped, stored.Status)
+}
+
+func TestUpdateScenario_Rejected(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", UserID: "test-user", Notes: "original"})
+	manager := &Manager{Cfg: &config.Config{}, Store: store}
+
+	tooLong := strings.Repeat("é", MaxNotesLength+1)
+	_, err := manager.UpdateScenario(ctx, "scn-1", "test-user", &types.UpdateScenarioRequest{Notes: &tooLong})
+	assert.ErrorIs(t, err, ErrInvalidNotes)
+
+	// The cap counts characters, not bytes
+	longest := strings.Repeat("é", MaxNotesLength)
+	_, err = manager.UpdateScenario(ctx, "scn-1", "test-user", &types.UpdateScenarioRequest{Notes: &longest})
+	assert.NoError(t, err)
+
+	notes := "hijacked"
+	_, err = manager.UpdateScenario(ctx, "scn-1", "other-user", &types.UpdateScenarioRequest{Notes: &notes})
+	assert.ErrorIs(t, err, ErrNotScenarioOwner)
+	_, err = manager.UpdateScenario(ctx, "missing", "test-user", &types.UpdateScenarioRequest{Notes: &notes})
+	assert.ErrorIs(t, err, ErrScenarioNotFound)
+
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.Equal(t, longest, stored.Notes)
+}
+
+func TestUpdateScenario_Lock(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(&storage.Scenario{ScenarioID: "scn-1", UserID: "test-user", Notes: "demo", Status: types.ScenarioStatusRunning})
+	manager := &Manager{Cfg: &config.Config{}, Store: store}
+	locked, unlocked := true, false
+
+	resp, err := manager.UpdateScenario(ctx, "scn-1", "test-user", &types.UpdateScenarioRequest{Locked: &locked})
+	require.NoError(t, err)
+	assert.True(t, resp.Locked)
+	assert.Equal(t, "demo", resp.Notes, "notes are left alone")
+
+	stored, err := store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.True(t, stored.Locked)
+
+	_, err = manager.UpdateScenario(ctx, "scn-1", "other-user", &types.UpdateScenarioRequest{Locked: &unlocked})
+	assert.ErrorIs(t, err, ErrNotScenarioOwner)
+
+	// Admins may unlock any user's scenario
+	resp, err = manager.AdminUpdateScenario(ctx, "scn-1", "admin-user", &types.UpdateScenarioRequest{Locked: &unlocked})
+	require.NoError(t, err)
+	assert.False(t, resp.Locked)
+	stored, err = store.GetScenario(ctx, "scn-1")
+	require.NoError(t, err)
+	assert.False(t, stored.Locked)
+
+	_, err = manager.AdminUpdateScenario(ctx, "missing", "admin-user", &types.UpdateScenarioRequest{Locked: &locked})
+	assert.ErrorIs(t, err, ErrScenarioNotFound)
+	_, err = manager.AdminUpdateScenario(ctx, "", "admin-user", &types.UpdateScenarioRequest{Locked: &locked})
+	assert.ErrorIs(t, err, ErrInvalidScenarioID)
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// CachedStore wraps a Store with a bounded, short-lived LRU cache in front of
+// GetScenario. Writes made through the CachedStore evict the cached entry once
+// they complete so they are visible immediately; writes made by other processes (such as the
+// cleanup worker) become visible once the entry's TTL lapses.
+type CachedStore struct {
+	Store
+
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+	// generation counts invalidations. A lookup that misses only caches what
+	// it read when no write completed while it was reading, so a write that
+	// lands between the read and the fill cannot leave a stale entry behind.
+	generation uint64
+}
+
+type cacheEntry struct {
+	scenario  Scenario
+	expiresAt time.Time
+}
+
+// NewCachedStore returns store wrapped with a GetScenario cache holding at
+// most size scenarios for ttl each
+func NewCachedStore(store Store, size int, ttl time.Duration) *CachedStore {
+	return &CachedStore{
+		Store:   store,
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *CachedStore) GetScenario(ctx context.Context, scenarioID string) (*Scenario, error) {
+	s, ok, generation := c.get(scenarioID)
+	if ok {
+		return s, nil
+	}
+
+	s, err := c.Store.GetScenario(ctx, scenarioID)
+	if err != nil {
+		return nil, err
+	}
+	c.put(s, generation)
+	return s, nil
+}
+
+func (c *CachedStore) StoreScenario(ctx context.Context, s *Scenario) error {
+	if s != nil {
+		defer c.invalidate(s.ScenarioID)
+	}
+	return c.Store.StoreScenario(ctx, s)
+}
+
+func (c *CachedStore) UpdateScenario(ctx context.Context, s *Scenario) error {
+	if s != nil {
+		defer c.invalidate(s.ScenarioID)
+	}
+	return c.Store.UpdateScenario(ctx, s)
+}
+
+func (c *CachedStore) UpsertScenario(ctx context.Context, s *Scenario) error {
+	if s != nil {
+		defer c.invalidate(s.ScenarioID)
+	}
+	return c.Store.UpsertScenario(ctx, s)
+}
+
+// TouchScenario only moves LastActivityAt, so the cached copy is refreshed
+// in place rather than evicted; otherwise every status poll would miss
+func (c *CachedStore) TouchScenario(ctx context.Context, scenarioID string, at time.Time) error {
+	if err := c.Store.TouchScenario(ctx, scenarioID, at); err != nil {
+		c.invalidate(scenarioID)
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	if elem, ok := c.entries[scenarioID]; ok {
+		elem.Value.(*cacheEntry).scenario.LastActivityAt = at
+	}
+	return nil
+}
+
+func (c *CachedStore) MarkExpiryWarned(ctx context.Context, scenarioID string, expiresAt time.Time) error {
+	defer c.invalidate(scenarioID)
+	return c.Store.MarkExpiryWarned(ctx, scenarioID, expiresAt)
+}
+
+func (c *CachedStore) SetScenarioNotes(ctx context.Context, scenarioID, notes string) error {
+	defer c.invalidate(scenarioID)
+	return c.Store.SetScenarioNotes(ctx, scenarioID, notes)
+}
+
+func (c *CachedStore) SetScenarioLocked(ctx context.Context, scenarioID string, locked bool) error {
+	defer c.invalidate(scenarioID)
+	return c.Store.SetScenarioLocked(ctx, scenarioID, locked)
+}
+
+func (c *CachedStore) ClaimPostStart(ctx context.Context, scenarioID string, at time.Time) (bool, error) {
+	defer c.invalidate(scenarioID)
+	return c.Store.ClaimPostStart(ctx, scenarioID, at)
+}
+
+func (c *CachedStore) FinishPostStart(ctx context.Context, s *Scenario) (bool, error) {
+	if s != nil {
+		defer c.invalidate(s.ScenarioID)
+	}
+	return c.Store.FinishPostStart(ctx, s)
+}
+
+func (c *CachedStore) DeleteScenario(ctx context.Context, scenarioID string) error {
+	defer c.invalidate(scenarioID)
+	return c.Store.DeleteScenario(ctx, scenarioID)
+}
+
+// get returns a copy of the cached scenario when present and unexpired,
+// along with the current generation to pass to put on a miss
+func (c *CachedStore) get(scenarioID string) (*Scenario, bool, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[scenarioID]
+	if !ok {
+		return nil, false, c.generation
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, scenarioID)
+		return nil, false, c.generation
+	}
+	c.order.MoveToFront(elem)
+	s := entry.scenario
+	return &s, true, c.generation
+}
+
+// put caches a copy of s, evicting the least recently used entry when full.
+// Nothing is cached when a write completed since generation was read.
+func (c *CachedStore) put(s *Scenario, generation uint64) {
+	if c.size <= 0 || c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return
+	}
+
+	entry := &cacheEntry{scenario: *s, expiresAt: c.now().Add(c.ttl)}
+	if elem, ok := c.entries[s.ScenarioID]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[s.ScenarioID] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).scenario.ScenarioID)
+	}
+}
+
+func (c *CachedStore) invalidate(scenarioID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	if elem, ok := c.entries[scenarioID]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, scenarioID)
+	}
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"devlab/internal/types"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore counts GetScenario calls that reach the underlying store
+type countingStore struct {
+	Store
+	gets atomic.Int32
+}
+
+func (c *countingStore) GetScenario(ctx context.Context, scenarioID string) (*Scenario, error) {
+	c.gets.Add(1)
+	return c.Store.GetScenario(ctx, scenarioID)
+}
+
+func newTestCachedStore(size int, ttl time.Duration, scenarios ...*Scenario) (*CachedStore, *countingStore, *time.Time) {
+	backing := &countingStore{Store: NewMemoryStore(scenarios...)}
+	cache := NewCachedStore(backing, size, ttl)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	return cache, backing, &now
+}
+
+func TestCachedStore_HitAndMiss(t *testing.T) {
+	ctx := context.Background()
+	cache, backing, now := newTestCachedStore(10, 2*time.Second, &Scenario{ScenarioID: "s1", Status: types.ScenarioStatusRunning})
+
+	s, err := cache.GetScenario(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusRunning, s.Status)
+	assert.EqualValues(t, 1, backing.gets.Load(), "first lookup misses")
+
+	_, err = cache.GetScenario(ctx, "s1")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, backing.gets.Load(), "second lookup is served from cache")
+
+	// Callers get copies, so mutating a result does not poison the cache
+	s.Status = types.ScenarioStatusStopped
+	cached, err := cache.GetScenario(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusRunning, cached.Status)
+
+	*now = now.Add(2 * time.Second)
+	_, err = cache.GetScenario(ctx, "s1")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, backing.gets.Load(), "expired entry is refetched")
+
+	_, err = cache.GetScenario(ctx, "missing")
+	assert.ErrorIs(t, err, ErrScenarioNotFound)
+}
+
+func TestCachedStore_InvalidatesOnUpdate(t *testing.T) {
+	ctx := context.Background()
+	cache, backing, _ := newTestCachedStore(10, time.Minute, &Scenario{ScenarioID: "s1", Status: types.ScenarioStatusRunning})
+
+	s, err := cache.GetScenario(ctx, "s1")
+	require.NoError(t, err)
+
+	s.Status = types.ScenarioStatusStopped
+	require.NoError(t, cache.UpdateScenario(ctx, s))
+
+	s, err = cache.GetScenario(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusStopped, s.Status, "a stop is visible immediately")
+	assert.EqualValues(t, 2, backing.gets.Load())
+
+	require.NoError(t, cache.DeleteScenario(ctx, "s1"))
+	_, err = cache.GetScenario(ctx, "s1")
+	assert.ErrorIs(t, err, ErrScenarioNotFound)
+}
+
+func TestCachedStore_TouchRefreshesInPlace(t *testing.T) {
+	ctx := context.Background()
+	cache, backing, now := newTestCachedStore(10, time.Minute, &Scenario{ScenarioID: "s1"})
+
+	_, err := cache.GetScenario(ctx, "s1")
+	require.NoError(t, err)
+
+	require.NoError(t, cache.TouchScenario(ctx, "s1", *now))
+	s, err := cache.GetScenario(ctx, "s1")
+	require.NoError(t, err)
+	assert.True(t, s.LastActivityAt.Equal(*now))
+	assert.EqualValues(t, 1, backing.gets.Load())
+}
+
+func TestCachedStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	cache, backing, _ := newTestCachedStore(2, time.Minute,
+		&Scenario{ScenarioID: "s1"}, &Scenario{ScenarioID: "s2"}, &Scenario{ScenarioID: "s3"})
+
+	for _, id := range []string{"s1", "s2", "s1", "s3"} {
+		_, err := cache.GetScenario(ctx, id)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 3, backing.gets.Load())
+
+	// s2 was least recently used when s3 arrived
+	_, err := cache.GetScenario(ctx, "s1")
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, backing.gets.Load())
+	_, err = cache.GetScenario(ctx, "s2")
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, backing.gets.Load())
+}
+
+// writeDuringGetStore runs write after the underlying GetScenario has read the
+// scenario but before the result is returned
+type writeDuringGetStore struct {
+	Store
+	write func()
+}
+
+func (w *writeDuringGetStore) GetScenario(ctx context.Context, scenarioID string) (*Scenario, error) {
+	s, err := w.Store.GetScenario(ctx, scenarioID)
+	if w.write != nil {
+		write := w.write
+		w.write = nil
+		write()
+	}
+	return s, err
+}
+
+func TestCachedStore_WriteDuringFillIsNotCachedOver(t *testing.T) {
+	ctx := context.Background()
+	backing := &writeDuringGetStore{Store: NewMemoryStore(&Scenario{ScenarioID: "s1", Status: types.ScenarioStatusRunning})}
+	cache := NewCachedStore(backing, 10, time.Minute)
+	backing.write = func() {
+		require.NoError(t, cache.UpdateScenario(ctx, &Scenario{ScenarioID: "s1", Status: types.ScenarioStatusStopped}))
+	}
+
+	s, err := cache.GetScenario(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusRunning, s.Status, "the lookup returns what it read")
+
+	s, err = cache.GetScenario(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, types.ScenarioStatusStopped, s.Status, "the stale read must not be cached over the write")
+}
@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"context"
+	"devlab/internal/types"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store for tests and local development. It
+// copies scenarios in and out so callers cannot mutate stored state directly,
+// matching the behaviour of a real database.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	scenarios map[string]Scenario
+}
+
+// NewMemoryStore returns a MemoryStore seeded with scenarios
+func NewMemoryStore(scenarios ...*Scenario) *MemoryStore {
+	store := &MemoryStore{scenarios: make(map[string]Scenario)}
+	for _, s := range scenarios {
+		store.scenarios[s.ScenarioID] = *s
+	}
+	return store
+}
+
+func (m *MemoryStore) StoreScenario(ctx context.Context, s *Scenario) error {
+	if s == nil {
+		return fmt.Errorf("%w: scenario cannot be nil", ErrInvalidScenario)
+	}
+
+	if s.ScenarioID == "" {
+		return fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenario)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scenarios[s.ScenarioID] = *s
+	return nil
+}
+
+func (m *MemoryStore) GetScenario(ctx context.Context, scenarioID string) (*Scenario, error) {
+	if scenarioID == "" {
+		return nil, fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenario)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.scenarios[scenarioID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrScenarioNotFound, scenarioID)
+	}
+	return &s, nil
+}
+
+func (m *MemoryStore) UpdateScenario(ctx context.Context, s *Scenario) error {
+	if s == nil {
+		return fmt.Errorf("%w: scenario cannot be nil", ErrInvalidScenario)
+	}
+
+	if s.ScenarioID == "" {
+		return fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenario)
+	}
+
+	s.UpdatedAt = time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Like the Mongo update, updating a missing scenario is a no-op
+	if _, ok := m.scenarios[s.ScenarioID]; ok {
+		m.scenarios[s.ScenarioID] = *s
+	}
+	return nil
+}
+
+func (m *MemoryStore) UpsertScenario(ctx context.Context, s *Scenario) error {
+	if s == nil {
+		return fmt.Errorf("%w: scenario cannot be nil", ErrInvalidScenario)
+	}
+
+	if s.ScenarioID == "" {
+		return fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenario)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scenarios[s.ScenarioID] = *s
+	return nil
+}
+
+func (m *MemoryStore) TouchScenario(ctx context.Context, scenarioID string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.scenarios[scenarioID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrScenarioNotFound, scenarioID)
+	}
+	s.LastActivityAt = at
+	m.scenarios[scenarioID] = s
+	return nil
+}
+
+func (m *MemoryStore) MarkExpiryWarned(ctx context.Context, scenarioID string, expiresAt time.Time) error {
+	return m.modify(scenarioID, func(s *Scenario) { s.ExpiryWarnedFor = expiresAt })
+}
+
+func (m *MemoryStore) SetScenarioNotes(ctx context.Context, scenarioID, notes string) error {
+	return m.modify(scenarioID, func(s *Scenario) { s.Notes = notes })
+}
+
+func (m *MemoryStore) SetScenarioLocked(ctx context.Context, scenarioID string, locked bool) error {
+	return m.modify(scenarioID, func(s *Scenario) { s.Locked = locked })
+}
+
+func (m *MemoryStore) ClaimPostStart(ctx context.Context, scenarioID string, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.scenarios[scenarioID]
+	if !ok || s.Status != types.ScenarioStatusProvisioning || !s.PostStartStartedAt.IsZero() {
+		return false, nil
+	}
+	s.PostStartStartedAt = at
+	s.UpdatedAt = at
+	m.scenarios[scenarioID] = s
+	return true, nil
+}
+
+func (m *MemoryStore) FinishPostStart(ctx context.Context, outcome *Scenario) (bool, error) {
+	if outcome == nil {
+		return false, fmt.Errorf("%w: scenario cannot be nil", ErrInvalidScenario)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.scenarios[outcome.ScenarioID]
+	if !ok || s.Status != types.ScenarioStatusProvisioning {
+		return false, nil
+	}
+	s.Status = outcome.Status
+	if outcome.StopReason != "" {
+		s.StopReason = outcome.StopReason
+	}
+	if outcome.PostStartError != "" {
+		s.PostStartError = outcome.PostStartError
+	}
+	if n := len(outcome.StatusHistory); n > 0 {
+		s.StatusHistory = append(append([]StatusChange(nil), s.StatusHistory...), outcome.StatusHistory[n-1])
+		if excess := len(s.StatusHistory) - MaxStatusHistory; excess > 0 {
+			s.StatusHistory = s.StatusHistory[excess:]
+		}
+	}
+	s.UpdatedAt = time.Now()
+	m.scenarios[outcome.ScenarioID] = s
+	return true, nil
+}
+
+// modify applies a targeted update to one stored scenario, like the Mongo
+// $set helpers, leaving its other fields untouched
+func (m *MemoryStore) modify(scenarioID string, update func(*Scenario)) error {
+	if scenarioID == "" {
+		return fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenario)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.scenarios[scenarioID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrScenarioNotFound, scenarioID)
+	}
+	update(&s)
+	s.UpdatedAt = time.Now()
+	m.scenarios[scenarioID] = s
+	return nil
+}
+
+func (m *MemoryStore) DeleteScenario(ctx context.Context, scenarioID string) error {
+	if scenarioID == "" {
+		return fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenario)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.scenarios, scenarioID)
+	return nil
+}
+
+func (m *MemoryStore) ListScenarios(ctx context.Context, userID string) ([]*Scenario, error) {
+	return m.list(func(s *Scenario) bool {
+		return userID == "" || s.UserID == userID
+	}), nil
+}
+
+func (m *MemoryStore) ListScenariosPaged(ctx context.Context, userID, pageToken string, limit int) ([]*Scenario, string, error) {
+	match := func(s *Scenario) bool { return userID == "" || s.UserID == userID }
+	if pageToken != "" {
+		cursor, err := decodePageToken(pageToken)
+		if err != nil {
+			return nil, "", err
+		}
+		match = func(s *Scenario) bool { return (userID == "" || s.UserID == userID) && cursor.after(s) }
+	}
+
+	scenarios, next := page(m.list(match), limit)
+	return scenarios, next, nil
+}
+
+func (m *MemoryStore) SearchScenarios(ctx context.Context, userID, query, pageToken string, limit int) ([]SearchHit, string, error) {
+	offset, err := decodeOffsetToken(pageToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	terms := searchTerms(query)
+	var hits []SearchHit
+	for _, s := range m.list(func(s *Scenario) bool { return userID == "" || s.UserID == userID }) {
+		if score := searchScore(s, terms); score > 0 {
+			hits = append(hits, SearchHit{Scenario: s, Score: score})
+		}
+	}
+	rankHits(hits)
+
+	if offset >= len(hits) {
+		return nil, "", nil
+	}
+	hits, next := pageHits(hits[offset:], offset, limit)
+	return hits, next, nil
+}
+
+func (m *MemoryStore) ListScenariosByStatus(ctx context.Context, statuses ...types.ScenarioStatus) ([]*Scenario, error) {
+	return m.list(func(s *Scenario) bool {
+		for _, status := range statuses {
+			if s.Status == status {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (m *MemoryStore) CountActiveScenarios(ctx context.Context) (int64, error) {
+	return int64(len(m.list(func(s *Scenario) bool { return s.Status.Active() }))), nil
+}
+
+func (m *MemoryStore) ListScenariosExpiringBy(ctx context.Context, by time.Time, statuses ...types.ScenarioStatus) ([]*Scenario, error) {
+	return m.list(func(s *Scenario) bool {
+		if !s.ExpiresAt.IsZero() && s.ExpiresAt.After(by) {
+			return false
+		}
+		for _, status := range statuses {
+			if s.Status == status {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (m *MemoryStore) ScenarioStats(ctx context.Context) (*ScenarioStats, error) {
+	stats := newScenarioStats()
+	// list orders by creation time, so the first running scenario is the oldest
+	for _, s := range m.list(func(*Scenario) bool { return true }) {
+		stats.Total++
+		stats.ByStatus[s.Status]++
+		stats.ByType[s.ScenarioType]++
+		stats.ByUser[s.UserID]++
+		if s.Status == types.ScenarioStatusRunning && stats.OldestRunning == nil {
+			stats.OldestRunning = s
+		}
+	}
+	return stats, nil
+}
+
+// list returns copies of the matching scenarios ordered by creation time
+func (m *MemoryStore) list(match func(*Scenario) bool) []*Scenario {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var scenarios []*Scenario
+	for _, s := range m.scenarios {
+		s := s
+		if match(&s) {
+			scenarios = append(scenarios, &s)
+		}
+	}
+	sort.Slice(scenarios, func(i, j int) bool {
+		if !scenarios[i].CreatedAt.Equal(scenarios[j].CreatedAt) {
+			return scenarios[i].CreatedAt.Before(scenarios[j].CreatedAt)
+		}
+		return scenarios[i].ScenarioID < scenarios[j].ScenarioID
+	})
+	return scenarios
+}
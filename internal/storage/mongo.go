@@ -2,11 +2,15 @@ package storage
 
 import (
 	"context"
+	"devlab/internal/types"
 	"fmt"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 	"go.mongodb.org/mongo-driver/bson"
 	"errors"
+	"strconv"
 	"time"
 )
 
@@ -15,21 +19,168 @@ var (
 	ErrScenarioNotFound = errors.New("scenario not found")
 	ErrDatabaseNil      = errors.New("database is nil")
 	ErrInvalidScenario  = errors.New("invalid scenario data")
+	ErrInvalidPageToken = errors.New("invalid page token")
 )
 
 type Scenario struct {
-	ScenarioID   string    `bson:"scenario_id"`
-	UserID       string    `bson:"user_id"`
-	ScenarioType string    `bson:"scenario_type"`
-	ContainerID  string    `bson:"container_id"`
-	Status       string    `bson:"status"`
-	TerminalPort int       `bson:"terminal_port,omitempty"`
-	CreatedAt    time.Time `bson:"created_at,omitempty"`
-	UpdatedAt    time.Time `bson:"updated_at,omitempty"`
+	ScenarioID       string           `bson:"scenario_id"`
+	UserID           string           `bson:"user_id"`
+	ScenarioType     string           `bson:"scenario_type"`
+	// Image is the image the container was created from, pinned to a digest when configured
+	Image            string           `bson:"image,omitempty"`
+	Name             string           `bson:"name,omitempty"`
+	Tags             []string         `bson:"tags,omitempty"`
+	Notes            string           `bson:"notes,omitempty"`
+	// Locked scenarios are never cleaned up
+	Locked           bool             `bson:"locked,omitempty"`
+	Script           string           `bson:"script,omitempty"`
+	ContainerID      string           `bson:"container_id"`
+	Status           types.ScenarioStatus `bson:"status"`
+	TerminalPort     int              `bson:"terminal_port,omitempty"`
+	TerminalUsername string           `bson:"terminal_username,omitempty"`
+	TerminalPassword string           `bson:"terminal_password,omitempty"`
+	CreatedAt        time.Time        `bson:"created_at,omitempty"`
+	UpdatedAt        time.Time        `bson:"updated_at,omitempty"`
+	ExpiresAt        time.Time        `bson:"expires_at,omitempty"`
+	LastActivityAt   time.Time        `bson:"last_activity_at,omitempty"`
+	StopReason       types.StopReason `bson:"stop_reason,omitempty"`
+	// ExpiryWarnedFor is the expiry time the last expiring-soon warning was sent for
+	ExpiryWarnedFor  time.Time        `bson:"expiry_warned_for,omitempty"`
+	Mode             types.ScenarioMode `bson:"mode,omitempty"`
+	// Result is the captured outcome of a batch scenario, set once its script exits
+	Result           *ScenarioResult  `bson:"result,omitempty"`
+	// PostStart runs once the container is first seen running; PostStartError
+	// records its failure, which stops the scenario when PostStartFatal is set
+	PostStart        []string         `bson:"post_start,omitempty"`
+	PostStartFatal   bool             `bson:"post_start_fatal,omitempty"`
+	PostStartError   string           `bson:"post_start_error,omitempty"`
+	// PostStartStartedAt is set by ClaimPostStart when a process starts the hook
+	PostStartStartedAt time.Time      `bson:"post_start_started_at,omitempty"`
+	// Entrypoint, Command and StartTTYD record the start request's container override
+	Entrypoint       []string         `bson:"entrypoint,omitempty"`
+	Command          []string         `bson:"command,omitempty"`
+	StartTTYD        bool             `bson:"start_ttyd,omitempty"`
+	// Env is the start request's extra environment variables
+	Env              map[string]string `bson:"env,omitempty"`
+	// Labels are the start request's container labels
+	Labels           map[string]string `bson:"labels,omitempty"`
+	// CPULimit and MemoryLimitMB are the start request's resource limits
+	CPULimit         float64          `bson:"cpu_limit,omitempty"`
+	MemoryLimitMB    int64            `bson:"memory_limit_mb,omitempty"`
+	// ScriptTimeout bounds the batch script and post-start hook; zero means no limit
+	ScriptTimeout    time.Duration    `bson:"script_timeout,omitempty"`
+	// ProvisioningLogs holds the output of a container kept after failing to come up
+	ProvisioningLogs string           `bson:"provisioning_logs,omitempty"`
+	// StatusHistory lists the scenario's status transitions, oldest first,
+	// keeping the last MaxStatusHistory of them; see SetStatus
+	StatusHistory    []StatusChange   `bson:"status_history,omitempty"`
 }
 
-func GetMongoClient(ctx context.Context, uri string) (*mongo.Client, error) {
-	return mongo.Connect(ctx, options.Client().ApplyURI(uri))
+// StatusChange records a scenario entering a status and why
+type StatusChange struct {
+	Status types.ScenarioStatus `bson:"status"`
+	At     time.Time            `bson:"at"`
+	Reason string               `bson:"reason,omitempty"`
+}
+
+// MaxStatusHistory caps how many transitions a scenario's StatusHistory keeps
+const MaxStatusHistory = 20
+
+// SetStatus moves the scenario to status and appends the transition, with
+// reason, to its StatusHistory, dropping the oldest entries beyond
+// MaxStatusHistory. Setting the current status again records nothing.
+func (s *Scenario) SetStatus(status types.ScenarioStatus, reason string) {
+	if s.Status == status {
+		return
+	}
+	s.Status = status
+	s.StatusHistory = append(s.StatusHistory, StatusChange{Status: status, At: time.Now(), Reason: reason})
+	if excess := len(s.StatusHistory) - MaxStatusHistory; excess > 0 {
+		s.StatusHistory = append([]StatusChange(nil), s.StatusHistory[excess:]...)
+	}
+}
+
+// ScenarioResult records how a batch scenario's script finished
+type ScenarioResult struct {
+	Stdout      string    `bson:"stdout"`
+	Stderr      string    `bson:"stderr"`
+	ExitCode    int       `bson:"exit_code"`
+	// TimedOut is set when the script was killed for exceeding its timeout
+	TimedOut    bool      `bson:"timed_out,omitempty"`
+	CompletedAt time.Time `bson:"completed_at"`
+}
+
+// LastActivity returns when the scenario was last used, treating creation as
+// its first activity
+func (s *Scenario) LastActivity() time.Time {
+	if s.LastActivityAt.After(s.CreatedAt) {
+		return s.LastActivityAt
+	}
+	return s.CreatedAt
+}
+
+// Expiry returns when the scenario becomes eligible for cleanup. Scenarios
+// stored before ExpiresAt existed expire maxAge after creation.
+func (s *Scenario) Expiry(maxAge time.Duration) time.Time {
+	if !s.ExpiresAt.IsZero() {
+		return s.ExpiresAt
+	}
+	return s.CreatedAt.Add(maxAge)
+}
+
+// MongoConcerns selects the client-wide write and read concerns. WriteConcern
+// is "majority" or a node count such as "1"; ReadConcern is a level such as
+// "local" or "majority". Empty values keep the URI's, or else the server's,
+// defaults. WriteTimeout bounds how long a write waits for its concern.
+type MongoConcerns struct {
+	WriteConcern string
+	ReadConcern  string
+	WriteTimeout time.Duration
+}
+
+// readConcernLevels are the read concern levels MongoDB accepts
+var readConcernLevels = map[string]bool{
+	"local":        true,
+	"available":    true,
+	"majority":     true,
+	"linearizable": true,
+	"snapshot":     true,
+}
+
+func GetMongoClient(ctx context.Context, uri string, concerns MongoConcerns) (*mongo.Client, error) {
+	opts, err := MongoClientOptions(uri, concerns)
+	if err != nil {
+		return nil, err
+	}
+	return mongo.Connect(ctx, opts)
+}
+
+// MongoClientOptions builds the client options for uri with concerns applied
+func MongoClientOptions(uri string, concerns MongoConcerns) (*options.ClientOptions, error) {
+	opts := options.Client().ApplyURI(uri)
+
+	if concerns.WriteConcern != "" {
+		wc := &writeconcern.WriteConcern{WTimeout: concerns.WriteTimeout}
+		if concerns.WriteConcern == "majority" {
+			wc.W = "majority"
+		} else {
+			w, err := strconv.Atoi(concerns.WriteConcern)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid write concern %q: must be \"majority\" or a node count", concerns.WriteConcern)
+			}
+			wc.W = w
+		}
+		opts.SetWriteConcern(wc)
+	}
+
+	if concerns.ReadConcern != "" {
+		if !readConcernLevels[concerns.ReadConcern] {
+			return nil, fmt.Errorf("invalid read concern %q", concerns.ReadConcern)
+		}
+		opts.SetReadConcern(&readconcern.ReadConcern{Level: concerns.ReadConcern})
+	}
+
+	return opts, nil
 }
 
 func StoreScenario(ctx context.Context, db *mongo.Database, s *Scenario) error {
@@ -102,6 +253,172 @@ func UpdateScenario(ctx context.Context, db *mongo.Database, s *Scenario) error
 	return nil
 }
 
+// UpsertScenario replaces the scenario with s's ID, inserting it when missing
+func UpsertScenario(ctx context.Context, db *mongo.Database, s *Scenario) error {
+	if db == nil {
+		return fmt.Errorf("%w", ErrDatabaseNil)
+	}
+	
+	if s == nil {
+		return fmt.Errorf("%w: scenario cannot be nil", ErrInvalidScenario)
+	}
+	
+	if s.ScenarioID == "" {
+		return fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenario)
+	}
+	
+	_, err := db.Collection("scenarios").ReplaceOne(
+		ctx,
+		bson.M{"scenario_id": s.ScenarioID},
+		s,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert scenario: %w", err)
+	}
+	
+	return nil
+}
+
+// TouchScenario records activity on a scenario without rewriting the rest of the document
+func TouchScenario(ctx context.Context, db *mongo.Database, scenarioID string, at time.Time) error {
+	if db == nil {
+		return fmt.Errorf("%w", ErrDatabaseNil)
+	}
+	
+	if scenarioID == "" {
+		return fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenario)
+	}
+	
+	update := bson.M{"$set": bson.M{"last_activity_at": at}}
+	result, err := db.Collection("scenarios").UpdateOne(ctx, bson.M{"scenario_id": scenarioID}, update)
+	if err != nil {
+		return fmt.Errorf("failed to record scenario activity: %w", err)
+	}
+	
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrScenarioNotFound, scenarioID)
+	}
+	
+	return nil
+}
+
+// MarkExpiryWarned records that an expiring-soon warning was sent for
+// expiresAt without rewriting the rest of the document
+func MarkExpiryWarned(ctx context.Context, db *mongo.Database, scenarioID string, expiresAt time.Time) error {
+	return setScenarioFields(ctx, db, scenarioID, bson.M{"expiry_warned_for": expiresAt})
+}
+
+// SetScenarioNotes replaces a scenario's notes without rewriting the rest of
+// the document; empty notes are stored as such, clearing them
+func SetScenarioNotes(ctx context.Context, db *mongo.Database, scenarioID, notes string) error {
+	return setScenarioFields(ctx, db, scenarioID, bson.M{"notes": notes})
+}
+
+// SetScenarioLocked locks or unlocks a scenario against cleanup without
+// rewriting the rest of the document
+func SetScenarioLocked(ctx context.Context, db *mongo.Database, scenarioID string, locked bool) error {
+	return setScenarioFields(ctx, db, scenarioID, bson.M{"locked": locked})
+}
+
+// ClaimPostStart marks the post-start hook of a provisioning scenario as
+// started at at. It reports false when the scenario is no longer provisioning
+// or its hook was already claimed, so the hook runs at most once however many
+// reconcilers see the scenario.
+func ClaimPostStart(ctx context.Context, db *mongo.Database, scenarioID string, at time.Time) (bool, error) {
+	if db == nil {
+		return false, fmt.Errorf("%w", ErrDatabaseNil)
+	}
+	
+	filter := bson.M{
+		"scenario_id":           scenarioID,
+		"status":                types.ScenarioStatusProvisioning,
+		"post_start_started_at": bson.M{"$exists": false},
+	}
+	result, err := db.Collection("scenarios").UpdateOne(ctx, filter, setFieldsUpdate(bson.M{"post_start_started_at": at}, at))
+	if err != nil {
+		return false, fmt.Errorf("failed to claim post-start hook: %w", err)
+	}
+	
+	return result.MatchedCount == 1, nil
+}
+
+// FinishPostStart records the outcome of s's post-start hook: its status,
+// stop reason and hook error, and the latest status history entry. Only those
+// fields are written, and only while the stored scenario is still
+// provisioning, so a stop made while the hook ran is kept. It reports whether
+// the outcome was recorded.
+func FinishPostStart(ctx context.Context, db *mongo.Database, s *Scenario) (bool, error) {
+	if db == nil {
+		return false, fmt.Errorf("%w", ErrDatabaseNil)
+	}
+	
+	if s == nil {
+		return false, fmt.Errorf("%w: scenario cannot be nil", ErrInvalidScenario)
+	}
+	
+	filter := bson.M{"scenario_id": s.ScenarioID, "status": types.ScenarioStatusProvisioning}
+	result, err := db.Collection("scenarios").UpdateOne(ctx, filter, postStartOutcomeUpdate(s, time.Now()))
+	if err != nil {
+		return false, fmt.Errorf("failed to record post-start outcome: %w", err)
+	}
+	
+	return result.MatchedCount == 1, nil
+}
+
+// postStartOutcomeUpdate builds the FinishPostStart update for s
+func postStartOutcomeUpdate(s *Scenario, updatedAt time.Time) bson.M {
+	fields := bson.M{"status": s.Status}
+	if s.StopReason != "" {
+		fields["stop_reason"] = s.StopReason
+	}
+	if s.PostStartError != "" {
+		fields["post_start_error"] = s.PostStartError
+	}
+	update := setFieldsUpdate(fields, updatedAt)
+	if n := len(s.StatusHistory); n > 0 {
+		update["$push"] = bson.M{"status_history": bson.M{
+			"$each":  bson.A{s.StatusHistory[n-1]},
+			"$slice": -MaxStatusHistory,
+		}}
+	}
+	return update
+}
+
+// setScenarioFields $sets fields, and updated_at, on one scenario. Unlike
+// UpdateScenario it leaves every other field as stored, so concurrent writers
+// are not overwritten, and it stores zero values such as false or "" that the
+// omitempty tags would drop.
+func setScenarioFields(ctx context.Context, db *mongo.Database, scenarioID string, fields bson.M) error {
+	if db == nil {
+		return fmt.Errorf("%w", ErrDatabaseNil)
+	}
+	
+	if scenarioID == "" {
+		return fmt.Errorf("%w: scenario ID cannot be empty", ErrInvalidScenario)
+	}
+	
+	result, err := db.Collection("scenarios").UpdateOne(ctx, bson.M{"scenario_id": scenarioID}, setFieldsUpdate(fields, time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to update scenario: %w", err)
+	}
+	
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", ErrScenarioNotFound, scenarioID)
+	}
+	
+	return nil
+}
+
+// setFieldsUpdate builds the $set update of fields stamped with updatedAt
+func setFieldsUpdate(fields bson.M, updatedAt time.Time) bson.M {
+	set := bson.M{"updated_at": updatedAt}
+	for key, value := range fields {
+		set[key] = value
+	}
+	return bson.M{"$set": set}
+}
+
 func DeleteScenario(ctx context.Context, db *mongo.Database, scenarioID string) error {
 	if db == nil {
 		return fmt.Errorf("%w", ErrDatabaseNil)
@@ -142,3 +459,115 @@ func ListScenarios(ctx context.Context, db *mongo.Database, userID string) ([]*S
 	
 	return scenarios, nil
 }
+
+// ListScenariosPaged returns up to limit of userID's scenarios, oldest first,
+// starting after pageToken. The returned token resumes after the last scenario
+// and is empty once there are no more.
+func ListScenariosPaged(ctx context.Context, db *mongo.Database, userID, pageToken string, limit int) ([]*Scenario, string, error) {
+	if db == nil {
+		return nil, "", fmt.Errorf("%w", ErrDatabaseNil)
+	}
+	
+	filter := bson.M{}
+	if userID != "" {
+		filter["user_id"] = userID
+	}
+	if pageToken != "" {
+		cursor, err := decodePageToken(pageToken)
+		if err != nil {
+			return nil, "", err
+		}
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$gt": cursor.CreatedAt}},
+			bson.M{"created_at": cursor.CreatedAt, "scenario_id": bson.M{"$gt": cursor.ScenarioID}},
+		}
+	}
+	
+	// Fetch one extra scenario to learn whether another page follows
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "scenario_id", Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit) + 1)
+	}
+	cursor, err := db.Collection("scenarios").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list scenarios: %w", err)
+	}
+	defer cursor.Close(ctx)
+	
+	var scenarios []*Scenario
+	if err = cursor.All(ctx, &scenarios); err != nil {
+		return nil, "", fmt.Errorf("failed to decode scenarios: %w", err)
+	}
+	
+	scenarios, next := page(scenarios, limit)
+	return scenarios, next, nil
+}
+
+// ListScenariosByStatus returns every scenario whose status is one of statuses
+func ListScenariosByStatus(ctx context.Context, db *mongo.Database, statuses ...types.ScenarioStatus) ([]*Scenario, error) {
+	if db == nil {
+		return nil, fmt.Errorf("%w", ErrDatabaseNil)
+	}
+	
+	filter := bson.M{"status": bson.M{"$in": statuses}}
+	
+	cursor, err := db.Collection("scenarios").Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scenarios: %w", err)
+	}
+	defer cursor.Close(ctx)
+	
+	var scenarios []*Scenario
+	if err = cursor.All(ctx, &scenarios); err != nil {
+		return nil, fmt.Errorf("failed to decode scenarios: %w", err)
+	}
+	
+	return scenarios, nil
+}
+
+// CountActiveScenarios returns how many scenarios are in an active status,
+// counted by the server rather than by loading every active scenario
+func CountActiveScenarios(ctx context.Context, db *mongo.Database) (int64, error) {
+	if db == nil {
+		return 0, fmt.Errorf("%w", ErrDatabaseNil)
+	}
+	
+	filter := bson.M{"status": bson.M{"$in": types.ActiveScenarioStatuses}}
+	
+	count, err := db.Collection("scenarios").CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count active scenarios: %w", err)
+	}
+	
+	return count, nil
+}
+
+// ListScenariosExpiringBy returns the scenarios in one of statuses whose
+// expires_at is at or before by, along with any stored without an expiry,
+// using the expires_at index rather than scanning every active scenario
+func ListScenariosExpiringBy(ctx context.Context, db *mongo.Database, by time.Time, statuses ...types.ScenarioStatus) ([]*Scenario, error) {
+	if db == nil {
+		return nil, fmt.Errorf("%w", ErrDatabaseNil)
+	}
+	
+	filter := bson.M{
+		"status": bson.M{"$in": statuses},
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$lte": by}},
+			bson.M{"expires_at": nil},
+		},
+	}
+	
+	cursor, err := db.Collection("scenarios").Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expiring scenarios: %w", err)
+	}
+	defer cursor.Close(ctx)
+	
+	var scenarios []*Scenario
+	if err = cursor.All(ctx, &scenarios); err != nil {
+		return nil, fmt.Errorf("failed to decode scenarios: %w", err)
+	}
+	
+	return scenarios, nil
+}
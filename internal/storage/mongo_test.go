@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"devlab/internal/types"
 	"fmt"
 	"testing"
 	"time"
@@ -13,6 +14,71 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+func TestMongoClientOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		concerns      MongoConcerns
+		expectedW     interface{}
+		expectedRead  string
+		expectedError bool
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:         "majority_writes_local_reads",
+			concerns:     MongoConcerns{WriteConcern: "majority", ReadConcern: "local", WriteTimeout: 5 * time.Second},
+			expectedW:    "majority",
+			expectedRead: "local",
+		},
+		{
+			name:      "node_count",
+			concerns:  MongoConcerns{WriteConcern: "2"},
+			expectedW: 2,
+		},
+		{
+			name:          "invalid_write_concern",
+			concerns:      MongoConcerns{WriteConcern: "all"},
+			expectedError: true,
+		},
+		{
+			name:          "negative_write_concern",
+			concerns:      MongoConcerns{WriteConcern: "-1"},
+			expectedError: true,
+		},
+		{
+			name:          "invalid_read_concern",
+			concerns:      MongoConcerns{ReadConcern: "eventual"},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := MongoClientOptions("mongodb://localhost:27017", tt.concerns)
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			if tt.expectedW == nil {
+				assert.Nil(t, opts.WriteConcern)
+			} else {
+				require.NotNil(t, opts.WriteConcern)
+				assert.Equal(t, tt.expectedW, opts.WriteConcern.W)
+				assert.Equal(t, tt.concerns.WriteTimeout, opts.WriteConcern.WTimeout)
+			}
+			if tt.expectedRead == "" {
+				assert.Nil(t, opts.ReadConcern)
+			} else {
+				require.NotNil(t, opts.ReadConcern)
+				assert.Equal(t, tt.expectedRead, opts.ReadConcern.Level)
+			}
+		})
+	}
+}
+
 // TestMongoConnection tests MongoDB connection functionality
 func TestMongoConnection(t *testing.T) {
 	tests := []struct {
@@ -42,7 +108,7 @@ func TestMongoConnection(t *testing.T) {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 
-			client, err := GetMongoClient(ctx, tt.mongoURI)
+			client, err := GetMongoClient(ctx, tt.mongoURI, MongoConcerns{})
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -71,7 +137,7 @@ func TestScenarioCRUD(t *testing.T) {
 	defer cancel()
 
 	// Connect to test database
-	client, err := GetMongoClient(ctx, "mongodb://localhost:27017")
+	client, err := GetMongoClient(ctx, "mongodb://localhost:27017", MongoConcerns{})
 	if err != nil {
 		t.Skipf("MongoDB not available: %v", err)
 	}
@@ -136,7 +202,7 @@ func TestScenarioCRUD(t *testing.T) {
 		var updatedScenario Scenario
 		err = collection.FindOne(ctx, bson.M{"scenario_id": "test-scn-123"}).Decode(&updatedScenario)
 		assert.NoError(t, err)
-		assert.Equal(t, "stopped", updatedScenario.Status)
+		assert.Equal(t, types.ScenarioStatusStopped, updatedScenario.Status)
 	})
 
 	t.Run("delete_scenario", func(t *testing.T) {
@@ -157,7 +223,7 @@ func TestScenarioQueries(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := GetMongoClient(ctx, "mongodb://localhost:27017")
+	client, err := GetMongoClient(ctx, "mongodb://localhost:27017", MongoConcerns{})
 	if err != nil {
 		t.Skipf("MongoDB not available: %v", err)
 	}
@@ -253,7 +319,7 @@ func TestScenarioIndexes(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := GetMongoClient(ctx, "mongodb://localhost:27017")
+	client, err := GetMongoClient(ctx, "mongodb://localhost:27017", MongoConcerns{})
 	if err != nil {
 		t.Skipf("MongoDB not available: %v", err)
 	}
@@ -316,7 +382,7 @@ func TestScenarioAggregation(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := GetMongoClient(ctx, "mongodb://localhost:27017")
+	client, err := GetMongoClient(ctx, "mongodb://localhost:27017", MongoConcerns{})
 	if err != nil {
 		t.Skipf("MongoDB not available: %v", err)
 	}
@@ -401,7 +467,7 @@ func TestScenarioErrorHandling(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	client, err := GetMongoClient(ctx, "mongodb://localhost:27017")
+	client, err := GetMongoClient(ctx, "mongodb://localhost:27017", MongoConcerns{})
 	if err != nil {
 		t.Skipf("MongoDB not available: %v", err)
 	}
@@ -455,7 +521,7 @@ func TestScenarioConcurrency(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := GetMongoClient(ctx, "mongodb://localhost:27017")
+	client, err := GetMongoClient(ctx, "mongodb://localhost:27017", MongoConcerns{})
 	if err != nil {
 		t.Skipf("MongoDB not available: %v", err)
 	}
@@ -504,7 +570,7 @@ func TestScenarioPerformance(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	client, err := GetMongoClient(ctx, "mongodb://localhost:27017")
+	client, err := GetMongoClient(ctx, "mongodb://localhost:27017", MongoConcerns{})
 	if err != nil {
 		t.Skipf("MongoDB not available: %v", err)
 	}
@@ -566,7 +632,7 @@ func BenchmarkScenarioInsert(b *testing.B) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	client, err := GetMongoClient(ctx, "mongodb://localhost:27017")
+	client, err := GetMongoClient(ctx, "mongodb://localhost:27017", MongoConcerns{})
 	if err != nil {
 		b.Skipf("MongoDB not available: %v", err)
 	}
@@ -599,7 +665,7 @@ func BenchmarkScenarioQuery(b *testing.B) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	client, err := GetMongoClient(ctx, "mongodb://localhost:27017")
+	client, err := GetMongoClient(ctx, "mongodb://localhost:27017", MongoConcerns{})
 	if err != nil {
 		b.Skipf("MongoDB not available: %v", err)
 	}
@@ -643,3 +709,57 @@ func BenchmarkScenarioQuery(b *testing.B) {
 		cursor.Close(ctx)
 	}
 }
+
+func TestSetFieldsUpdate_SetsOnlyTargetedFields(t *testing.T) {
+	updatedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	warnedFor := updatedAt.Add(time.Hour)
+	data, err := bson.Marshal(setFieldsUpdate(bson.M{"expiry_warned_for": warnedFor}, updatedAt))
+	require.NoError(t, err)
+
+	var update struct {
+		Set map[string]time.Time `bson:"$set"`
+	}
+	require.NoError(t, bson.Unmarshal(data, &update))
+	assert.Equal(t, map[string]time.Time{"expiry_warned_for": warnedFor, "updated_at": updatedAt}, update.Set)
+}
+
+func TestSetFieldsUpdate_KeepsZeroValues(t *testing.T) {
+	updatedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for field, cleared := range map[string]interface{}{"notes": "", "locked": false} {
+		t.Run(field, func(t *testing.T) {
+			data, err := bson.Marshal(setFieldsUpdate(bson.M{field: cleared}, updatedAt))
+			require.NoError(t, err)
+
+			var update bson.M
+			require.NoError(t, bson.Unmarshal(data, &update))
+			set := update["$set"].(bson.M)
+			// Unlike a full document write, the cleared value is sent rather
+			// than dropped by the omitempty tag
+			value, ok := set[field]
+			require.True(t, ok)
+			assert.Equal(t, cleared, value)
+		})
+	}
+}
+
+func TestPostStartOutcomeUpdate_WritesOnlyOutcome(t *testing.T) {
+	updatedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := &Scenario{ScenarioID: "scn-1", Status: types.ScenarioStatusProvisioning, Notes: "kept", PostStart: []string{"make"}}
+	s.SetStatus(types.ScenarioStatusStopped, "post-start hook failed")
+	s.StopReason = types.StopReasonFailed
+	s.PostStartError = "exit code 2"
+
+	data, err := bson.Marshal(postStartOutcomeUpdate(s, updatedAt))
+	require.NoError(t, err)
+	var update bson.M
+	require.NoError(t, bson.Unmarshal(data, &update))
+
+	set := update["$set"].(bson.M)
+	assert.Len(t, set, 4)
+	assert.Equal(t, string(types.ScenarioStatusStopped), set["status"])
+	assert.Equal(t, string(types.StopReasonFailed), set["stop_reason"])
+	assert.Equal(t, "exit code 2", set["post_start_error"])
+	push := update["$push"].(bson.M)["status_history"].(bson.M)
+	assert.Len(t, push["$each"], 1)
+	assert.EqualValues(t, -MaxStatusHistory, push["$slice"])
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pageCursor identifies the last scenario of a page. Pages are ordered by
+// creation time with the scenario ID breaking ties, so a cursor stays valid
+// while scenarios are added or removed between requests.
+type pageCursor struct {
+	CreatedAt  time.Time
+	ScenarioID string
+}
+
+// after reports whether s sorts after the cursor
+func (c pageCursor) after(s *Scenario) bool {
+	if !s.CreatedAt.Equal(c.CreatedAt) {
+		return s.CreatedAt.After(c.CreatedAt)
+	}
+	return s.ScenarioID > c.ScenarioID
+}
+
+// encodePageToken returns the opaque token that resumes listing after s
+func encodePageToken(s *Scenario) string {
+	raw := strconv.FormatInt(s.CreatedAt.UnixNano(), 10) + ":" + s.ScenarioID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodePageToken parses a token produced by encodePageToken
+func decodePageToken(token string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+	}
+
+	nanos, scenarioID, ok := strings.Cut(string(raw), ":")
+	if !ok || scenarioID == "" {
+		return pageCursor{}, fmt.Errorf("%w: malformed cursor", ErrInvalidPageToken)
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+	}
+	return pageCursor{CreatedAt: time.Unix(0, n).UTC(), ScenarioID: scenarioID}, nil
+}
+
+// page trims scenarios, sorted in cursor order, to limit and returns the token
+// for the following page, or "" when scenarios fit within limit. A limit of
+// zero or less returns everything.
+func page(scenarios []*Scenario, limit int) ([]*Scenario, string) {
+	if limit <= 0 || len(scenarios) <= limit {
+		return scenarios, ""
+	}
+	scenarios = scenarios[:limit]
+	return scenarios, encodePageToken(scenarios[limit-1])
+}
+
+// Search results are ranked by relevance rather than creation time, so their
+// page tokens carry a plain offset into the ranking instead of a cursor
+const offsetTokenPrefix = "offset:"
+
+// encodeOffsetToken returns the opaque token that resumes a search at offset
+func encodeOffsetToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(offsetTokenPrefix + strconv.Itoa(offset)))
+}
+
+// decodeOffsetToken parses a token produced by encodeOffsetToken; the empty
+// token starts at the beginning
+func decodeOffsetToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+	}
+
+	value, ok := strings.CutPrefix(string(raw), offsetTokenPrefix)
+	if !ok {
+		return 0, fmt.Errorf("%w: malformed offset", ErrInvalidPageToken)
+	}
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("%w: malformed offset", ErrInvalidPageToken)
+	}
+	return offset, nil
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_ListScenariosPaged(t *testing.T) {
+	ctx := context.Background()
+	base := time.Now()
+	store := NewMemoryStore(
+		&Scenario{ScenarioID: "s1", UserID: "alice", CreatedAt: base},
+		&Scenario{ScenarioID: "s2", UserID: "alice", CreatedAt: base},
+		&Scenario{ScenarioID: "s3", UserID: "bob", CreatedAt: base.Add(time.Second)},
+		&Scenario{ScenarioID: "s4", UserID: "alice", CreatedAt: base.Add(2 * time.Second)},
+	)
+
+	var ids []string
+	token := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3, "paging should terminate")
+		scenarios, next, err := store.ListScenariosPaged(ctx, "alice", token, 2)
+		require.NoError(t, err)
+		for _, s := range scenarios {
+			ids = append(ids, s.ScenarioID)
+		}
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	assert.Equal(t, []string{"s1", "s2", "s4"}, ids)
+
+	all, next, err := store.ListScenariosPaged(ctx, "", "", 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+	assert.Empty(t, next)
+
+	_, _, err = store.ListScenariosPaged(ctx, "alice", "not a token", 2)
+	assert.ErrorIs(t, err, ErrInvalidPageToken)
+}
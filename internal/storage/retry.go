@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"devlab/internal/retry"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UpdateRetryPolicy bounds how UpdateScenarioWithRetry retries transient
+// failures: up to Retries more attempts, waiting Backoff before the first and
+// doubling the wait after each
+type UpdateRetryPolicy struct {
+	Retries int
+	Backoff time.Duration
+}
+
+// IsTransient reports whether err is a failure worth retrying: a network
+// error, a server-side timeout or a MongoDB error labelled retryable, or any
+// error marked with retry.New. Cancelled or expired contexts are not transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) || retry.IsRetryable(err) {
+		return true
+	}
+	var labeled mongo.LabeledError
+	return errors.As(err, &labeled) &&
+		(labeled.HasErrorLabel("RetryableWriteError") || labeled.HasErrorLabel("TransientTransactionError"))
+}
+
+// UpdateScenarioWithRetry updates s in store, retrying transient failures as
+// set by policy so a brief database outage does not leave s with a stale
+// status. Other errors, and the last transient one, are returned as is.
+func UpdateScenarioWithRetry(ctx context.Context, store Store, s *Scenario, policy UpdateRetryPolicy) error {
+	backoff := policy.Backoff
+	for attempt := 0; ; attempt++ {
+		err := store.UpdateScenario(ctx, s)
+		if err == nil || attempt >= policy.Retries || !IsTransient(err) {
+			return err
+		}
+
+		log.Printf("[storage] retrying update of scenario %s after transient error (attempt %d of %d): %v", s.ScenarioID, attempt+1, policy.Retries, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
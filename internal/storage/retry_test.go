@@ -0,0 +1,92 @@
+package storage_test
+
+import (
+	"context"
+	"devlab/internal/retry"
+	"devlab/internal/storage"
+	"devlab/internal/storage/storagetest"
+	"devlab/internal/types"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "nil", err: nil, expected: false},
+		{name: "network_error", err: mongo.CommandError{Labels: []string{"NetworkError"}}, expected: true},
+		{name: "retryable_write", err: fmt.Errorf("update: %w", mongo.CommandError{Labels: []string{"RetryableWriteError"}}), expected: true},
+		{name: "max_time_expired", err: mongo.CommandError{Code: 50, Name: "MaxTimeMSExpired"}, expected: true},
+		{name: "retry_sentinel", err: retry.New("database unavailable"), expected: true},
+		{name: "duplicate_key", err: mongo.CommandError{Code: 11000}, expected: false},
+		{name: "invalid_scenario", err: storage.ErrInvalidScenario, expected: false},
+		{name: "context_cancelled", err: context.Canceled, expected: false},
+		{name: "context_deadline", err: fmt.Errorf("update: %w", context.DeadlineExceeded), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, storage.IsTransient(tt.err))
+		})
+	}
+}
+
+func TestUpdateScenarioWithRetry(t *testing.T) {
+	ctx := context.Background()
+	transient := mongo.CommandError{Message: "connection reset", Labels: []string{"NetworkError"}}
+	policy := storage.UpdateRetryPolicy{Retries: 2, Backoff: time.Millisecond}
+
+	tests := []struct {
+		name            string
+		err             error
+		failures        int
+		expectedUpdates int
+		expectedErr     bool
+	}{
+		{name: "succeeds_first_time", err: transient, failures: 0, expectedUpdates: 1},
+		{name: "transient_then_success", err: transient, failures: 1, expectedUpdates: 2},
+		{name: "retries_exhausted", err: transient, failures: 3, expectedUpdates: 3, expectedErr: true},
+		{name: "permanent_not_retried", err: storage.ErrInvalidScenario, failures: 1, expectedUpdates: 1, expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &storagetest.FlakyStore{
+				Store:    storage.NewMemoryStore(&storage.Scenario{ScenarioID: "s1", Status: types.ScenarioStatusRunning}),
+				Err:      tt.err,
+				Failures: tt.failures,
+			}
+
+			err := storage.UpdateScenarioWithRetry(ctx, store, &storage.Scenario{ScenarioID: "s1", Status: types.ScenarioStatusStopped}, policy)
+			assert.Equal(t, tt.expectedUpdates, store.Updates)
+
+			stored, getErr := store.GetScenario(ctx, "s1")
+			require.NoError(t, getErr)
+			if tt.expectedErr {
+				assert.Equal(t, tt.err, err)
+				assert.Equal(t, types.ScenarioStatusRunning, stored.Status)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, types.ScenarioStatusStopped, stored.Status)
+			}
+		})
+	}
+}
+
+func TestUpdateScenarioWithRetry_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	store := &storagetest.FlakyStore{Store: storage.NewMemoryStore(), Err: retry.New("database unavailable"), Failures: 5}
+
+	err := storage.UpdateScenarioWithRetry(ctx, store, &storage.Scenario{ScenarioID: "s1"}, storage.UpdateRetryPolicy{Retries: 5, Backoff: time.Hour})
+	assert.Error(t, err)
+	assert.Equal(t, 1, store.Updates)
+}
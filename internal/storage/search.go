@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Text search weights: a hit in a scenario's name ranks above one in its tags,
+// then its notes, then its type
+const (
+	searchWeightName  = 10
+	searchWeightTags  = 5
+	searchWeightNotes = 2
+	searchWeightType  = 1
+)
+
+// Names of the text index behind SearchScenarios. A collection holds a single
+// text index, so the one from before notes were searched is dropped first.
+const (
+	textIndexName       = "scenario_text_v2"
+	legacyTextIndexName = "scenario_text"
+)
+
+// Server error codes for dropping an index from a missing collection or a
+// missing index from an existing one
+const (
+	mongoNamespaceNotFound = 26
+	mongoIndexNotFound     = 27
+)
+
+// SearchHit is a scenario matched by a text search with its relevance score
+type SearchHit struct {
+	Scenario *Scenario
+	Score    float64
+}
+
+// EnsureIndexes creates the indexes the scenarios collection relies on,
+// including the text index behind SearchScenarios. Existing indexes with the
+// same definition are left as they are.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	if db == nil {
+		return fmt.Errorf("%w", ErrDatabaseNil)
+	}
+
+	collection := db.Collection("scenarios")
+	if _, err := collection.Indexes().DropOne(ctx, legacyTextIndexName); err != nil {
+		var cmdErr mongo.CommandError
+		if !errors.As(err, &cmdErr) || (cmdErr.Code != mongoNamespaceNotFound && cmdErr.Code != mongoIndexNotFound) {
+			return fmt.Errorf("failed to drop legacy text index: %w", err)
+		}
+	}
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "scenario_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "name", Value: "text"}, {Key: "tags", Value: "text"}, {Key: "notes", Value: "text"}, {Key: "scenario_type", Value: "text"}},
+			Options: options.Index().SetName(textIndexName).SetWeights(bson.D{
+				{Key: "name", Value: searchWeightName},
+				{Key: "tags", Value: searchWeightTags},
+				{Key: "notes", Value: searchWeightNotes},
+				{Key: "scenario_type", Value: searchWeightType},
+			}),
+		},
+	}
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return fmt.Errorf("failed to create scenario indexes: %w", err)
+	}
+	return nil
+}
+
+// SearchScenarios runs a text search over userID's scenarios, best match
+// first, and returns one page of hits with the token for the next page
+func SearchScenarios(ctx context.Context, db *mongo.Database, userID, query, pageToken string, limit int) ([]SearchHit, string, error) {
+	if db == nil {
+		return nil, "", fmt.Errorf("%w", ErrDatabaseNil)
+	}
+
+	offset, err := decodeOffsetToken(pageToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	filter := bson.M{"$text": bson.M{"$search": query}}
+	if userID != "" {
+		filter["user_id"] = userID
+	}
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "created_at", Value: -1}, {Key: "scenario_id", Value: 1}}).
+		SetSkip(int64(offset))
+	// Fetch one extra hit to learn whether another page follows
+	if limit > 0 {
+		opts.SetLimit(int64(limit) + 1)
+	}
+
+	cursor, err := db.Collection("scenarios").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to search scenarios: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		Scenario `bson:",inline"`
+		Score    float64 `bson:"score"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, "", fmt.Errorf("failed to decode scenarios: %w", err)
+	}
+
+	hits := make([]SearchHit, 0, len(docs))
+	for i := range docs {
+		hits = append(hits, SearchHit{Scenario: &docs[i].Scenario, Score: docs[i].Score})
+	}
+	hits, next := pageHits(hits, offset, limit)
+	return hits, next, nil
+}
+
+// searchTerms splits a query into the lower-cased words it matches on
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// searchScore approximates the Mongo text index for the in-memory store: each
+// query term found as a word in a field adds that field's weight
+func searchScore(s *Scenario, terms []string) float64 {
+	fields := []struct {
+		words  []string
+		weight float64
+	}{
+		{searchTerms(s.Name), searchWeightName},
+		{searchTerms(strings.Join(s.Tags, " ")), searchWeightTags},
+		{searchTerms(s.Notes), searchWeightNotes},
+		{searchTerms(s.ScenarioType), searchWeightType},
+	}
+
+	var score float64
+	for _, term := range terms {
+		for _, field := range fields {
+			for _, word := range field.words {
+				if word == term {
+					score += field.weight
+				}
+			}
+		}
+	}
+	return score
+}
+
+// rankHits orders hits best match first, newest first among equal scores
+func rankHits(hits []SearchHit) {
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.Scenario.CreatedAt.Equal(b.Scenario.CreatedAt) {
+			return a.Scenario.CreatedAt.After(b.Scenario.CreatedAt)
+		}
+		return a.Scenario.ScenarioID < b.Scenario.ScenarioID
+	})
+}
+
+// pageHits trims hits, which start at offset, to limit and returns the token
+// for the following page, or "" when hits fit within limit
+func pageHits(hits []SearchHit, offset, limit int) ([]SearchHit, string) {
+	if limit <= 0 || len(hits) <= limit {
+		return hits, ""
+	}
+	return hits[:limit], encodeOffsetToken(offset + limit)
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_SearchScenarios(t *testing.T) {
+	ctx := context.Background()
+	base := time.Now()
+	store := NewMemoryStore(
+		&Scenario{ScenarioID: "s1", UserID: "alice", ScenarioType: "go", Name: "Concurrency workshop", Tags: []string{"channels"}, CreatedAt: base},
+		&Scenario{ScenarioID: "s2", UserID: "alice", ScenarioType: "python", Name: "Flask basics", Tags: []string{"web", "concurrency"}, CreatedAt: base.Add(time.Second)},
+		&Scenario{ScenarioID: "s3", UserID: "alice", ScenarioType: "k8s", Name: "Pods", CreatedAt: base.Add(2 * time.Second)},
+		&Scenario{ScenarioID: "s4", UserID: "bob", ScenarioType: "go", Name: "Concurrency deep dive", CreatedAt: base.Add(3 * time.Second)},
+	)
+
+	ids := func(hits []SearchHit) []string {
+		var out []string
+		for _, hit := range hits {
+			out = append(out, hit.Scenario.ScenarioID)
+		}
+		return out
+	}
+
+	t.Run("name_ranks_above_tags", func(t *testing.T) {
+		hits, next, err := store.SearchScenarios(ctx, "alice", "concurrency", "", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"s1", "s2"}, ids(hits))
+		assert.Greater(t, hits[0].Score, hits[1].Score)
+		assert.Empty(t, next)
+	})
+
+	t.Run("matches_type_case_insensitively", func(t *testing.T) {
+		hits, _, err := store.SearchScenarios(ctx, "alice", "K8S", "", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"s3"}, ids(hits))
+	})
+
+	t.Run("any_term_matches", func(t *testing.T) {
+		hits, _, err := store.SearchScenarios(ctx, "alice", "pods flask", "", 10)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"s2", "s3"}, ids(hits))
+	})
+
+	t.Run("owner_scoped", func(t *testing.T) {
+		hits, _, err := store.SearchScenarios(ctx, "bob", "concurrency", "", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"s4"}, ids(hits))
+	})
+
+	t.Run("no_match", func(t *testing.T) {
+		hits, next, err := store.SearchScenarios(ctx, "alice", "rust", "", 10)
+		require.NoError(t, err)
+		assert.Empty(t, hits)
+		assert.Empty(t, next)
+	})
+
+	t.Run("paged", func(t *testing.T) {
+		first, next, err := store.SearchScenarios(ctx, "alice", "concurrency", "", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"s1"}, ids(first))
+		require.NotEmpty(t, next)
+
+		second, next, err := store.SearchScenarios(ctx, "alice", "concurrency", next, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"s2"}, ids(second))
+		assert.Empty(t, next)
+	})
+
+	t.Run("invalid_token", func(t *testing.T) {
+		_, _, err := store.SearchScenarios(ctx, "alice", "concurrency", "not a token", 1)
+		assert.ErrorIs(t, err, ErrInvalidPageToken)
+
+		// A list cursor is not a search offset
+		_, _, err = store.SearchScenarios(ctx, "alice", "concurrency", encodePageToken(&Scenario{ScenarioID: "s1", CreatedAt: base}), 1)
+		assert.ErrorIs(t, err, ErrInvalidPageToken)
+	})
+}
+
+func TestMemoryStore_SearchScenarios_Notes(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(
+		&Scenario{ScenarioID: "s1", UserID: "alice", ScenarioType: "go", Notes: "Debugging a flaky mutex test"},
+		&Scenario{ScenarioID: "s2", UserID: "alice", ScenarioType: "go", Tags: []string{"mutex"}},
+		&Scenario{ScenarioID: "s3", UserID: "alice", ScenarioType: "go", Notes: "unrelated"},
+	)
+
+	hits, _, err := store.SearchScenarios(ctx, "alice", "mutex", "", 10)
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "s2", hits[0].Scenario.ScenarioID, "tags rank above notes")
+	assert.Equal(t, "s1", hits[1].Scenario.ScenarioID)
+	assert.Equal(t, float64(searchWeightNotes), hits[1].Score)
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"devlab/internal/types"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ScenarioStats summarises every stored scenario
+type ScenarioStats struct {
+	Total    int
+	ByStatus map[types.ScenarioStatus]int
+	ByType   map[string]int
+	ByUser   map[string]int
+	// OldestRunning is the running scenario created first, nil when none runs
+	OldestRunning *Scenario
+}
+
+// scenarioStatsPipeline computes ScenarioStats in a single pass, each facet
+// producing one part of the summary
+var scenarioStatsPipeline = mongo.Pipeline{
+	{{Key: "$facet", Value: bson.M{
+		"total":     bson.A{bson.M{"$count": "count"}},
+		"by_status": bson.A{bson.M{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+		"by_type":   bson.A{bson.M{"$group": bson.M{"_id": "$scenario_type", "count": bson.M{"$sum": 1}}}},
+		"by_user":   bson.A{bson.M{"$group": bson.M{"_id": "$user_id", "count": bson.M{"$sum": 1}}}},
+		"oldest_running": bson.A{
+			bson.M{"$match": bson.M{"status": types.ScenarioStatusRunning}},
+			bson.M{"$sort": bson.D{{Key: "created_at", Value: 1}, {Key: "scenario_id", Value: 1}}},
+			bson.M{"$limit": 1},
+		},
+	}}},
+}
+
+// statsGroup is one group of a $group facet
+type statsGroup struct {
+	Key   string `bson:"_id"`
+	Count int    `bson:"count"`
+}
+
+// AggregateScenarioStats summarises the scenarios collection with an
+// aggregation pipeline rather than loading every scenario
+func AggregateScenarioStats(ctx context.Context, db *mongo.Database) (*ScenarioStats, error) {
+	if db == nil {
+		return nil, fmt.Errorf("%w", ErrDatabaseNil)
+	}
+
+	cursor, err := db.Collection("scenarios").Aggregate(ctx, scenarioStatsPipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate scenario stats: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var facets []struct {
+		Total         []statsGroup `bson:"total"`
+		ByStatus      []statsGroup `bson:"by_status"`
+		ByType        []statsGroup `bson:"by_type"`
+		ByUser        []statsGroup `bson:"by_user"`
+		OldestRunning []*Scenario  `bson:"oldest_running"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil {
+		return nil, fmt.Errorf("failed to decode scenario stats: %w", err)
+	}
+
+	stats := newScenarioStats()
+	if len(facets) == 0 {
+		return stats, nil
+	}
+	facet := facets[0]
+	if len(facet.Total) > 0 {
+		stats.Total = facet.Total[0].Count
+	}
+	for _, group := range facet.ByStatus {
+		stats.ByStatus[types.ScenarioStatus(group.Key)] = group.Count
+	}
+	for _, group := range facet.ByType {
+		stats.ByType[group.Key] = group.Count
+	}
+	for _, group := range facet.ByUser {
+		stats.ByUser[group.Key] = group.Count
+	}
+	if len(facet.OldestRunning) > 0 {
+		stats.OldestRunning = facet.OldestRunning[0]
+	}
+	return stats, nil
+}
+
+func newScenarioStats() *ScenarioStats {
+	return &ScenarioStats{
+		ByStatus: make(map[types.ScenarioStatus]int),
+		ByType:   make(map[string]int),
+		ByUser:   make(map[string]int),
+	}
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"devlab/internal/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statsFixture returns scenarios whose oldest running one is scn-2
+func statsFixture() []*Scenario {
+	base := time.Now().UTC().Truncate(time.Millisecond)
+	return []*Scenario{
+		{ScenarioID: "scn-1", UserID: "alice", ScenarioType: "go", Status: types.ScenarioStatusStopped, CreatedAt: base},
+		{ScenarioID: "scn-2", UserID: "alice", ScenarioType: "python", Status: types.ScenarioStatusRunning, CreatedAt: base.Add(time.Second)},
+		{ScenarioID: "scn-3", UserID: "bob", ScenarioType: "go", Status: types.ScenarioStatusRunning, CreatedAt: base.Add(2 * time.Second)},
+		{ScenarioID: "scn-4", UserID: "carol", ScenarioType: "k8s", Status: types.ScenarioStatusPaused, CreatedAt: base.Add(3 * time.Second)},
+	}
+}
+
+func assertFixtureStats(t *testing.T, stats *ScenarioStats) {
+	t.Helper()
+	assert.Equal(t, 4, stats.Total)
+	assert.Equal(t, map[types.ScenarioStatus]int{
+		types.ScenarioStatusStopped: 1,
+		types.ScenarioStatusRunning: 2,
+		types.ScenarioStatusPaused:  1,
+	}, stats.ByStatus)
+	assert.Equal(t, map[string]int{"go": 2, "python": 1, "k8s": 1}, stats.ByType)
+	assert.Equal(t, map[string]int{"alice": 2, "bob": 1, "carol": 1}, stats.ByUser)
+	require.NotNil(t, stats.OldestRunning)
+	assert.Equal(t, "scn-2", stats.OldestRunning.ScenarioID)
+}
+
+func TestMemoryStore_ScenarioStats(t *testing.T) {
+	ctx := context.Background()
+
+	stats, err := NewMemoryStore(statsFixture()...).ScenarioStats(ctx)
+	require.NoError(t, err)
+	assertFixtureStats(t, stats)
+
+	stats, err = NewMemoryStore().ScenarioStats(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Total)
+	assert.Empty(t, stats.ByStatus)
+	assert.Nil(t, stats.OldestRunning)
+}
+
+func TestAggregateScenarioStats(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := GetMongoClient(ctx, "mongodb://localhost:27017", MongoConcerns{})
+	if err != nil {
+		t.Skipf("MongoDB not available: %v", err)
+	}
+	defer client.Disconnect(ctx)
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Skipf("MongoDB not available: %v", err)
+	}
+
+	db := client.Database("devlab_test")
+	collection := db.Collection("scenarios")
+	collection.Drop(ctx)
+	defer collection.Drop(ctx)
+
+	t.Run("empty", func(t *testing.T) {
+		stats, err := AggregateScenarioStats(ctx, db)
+		require.NoError(t, err)
+		assert.Zero(t, stats.Total)
+		assert.Nil(t, stats.OldestRunning)
+	})
+
+	for _, s := range statsFixture() {
+		require.NoError(t, StoreScenario(ctx, db, s))
+	}
+
+	t.Run("summary", func(t *testing.T) {
+		stats, err := AggregateScenarioStats(ctx, db)
+		require.NoError(t, err)
+		assertFixtureStats(t, stats)
+	})
+
+	t.Run("nil_database", func(t *testing.T) {
+		_, err := AggregateScenarioStats(ctx, nil)
+		assert.ErrorIs(t, err, ErrDatabaseNil)
+	})
+}
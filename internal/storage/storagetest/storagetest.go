@@ -0,0 +1,25 @@
+// Package storagetest provides storage.Store doubles shared by the tests of
+// the packages that persist scenarios
+package storagetest
+
+import (
+	"context"
+	"devlab/internal/storage"
+)
+
+// FlakyStore fails the first Failures UpdateScenario calls with Err and
+// passes the rest through to the embedded Store. Updates counts every call.
+type FlakyStore struct {
+	storage.Store
+	Err      error
+	Failures int
+	Updates  int
+}
+
+func (f *FlakyStore) UpdateScenario(ctx context.Context, s *storage.Scenario) error {
+	f.Updates++
+	if f.Updates <= f.Failures {
+		return f.Err
+	}
+	return f.Store.UpdateScenario(ctx, s)
+}
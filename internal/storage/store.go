@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"devlab/internal/types"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Store is the scenario persistence used by the scenario and cleanup managers
+type Store interface {
+	StoreScenario(ctx context.Context, s *Scenario) error
+	GetScenario(ctx context.Context, scenarioID string) (*Scenario, error)
+	UpdateScenario(ctx context.Context, s *Scenario) error
+	UpsertScenario(ctx context.Context, s *Scenario) error
+	TouchScenario(ctx context.Context, scenarioID string, at time.Time) error
+	MarkExpiryWarned(ctx context.Context, scenarioID string, expiresAt time.Time) error
+	SetScenarioNotes(ctx context.Context, scenarioID, notes string) error
+	SetScenarioLocked(ctx context.Context, scenarioID string, locked bool) error
+	ClaimPostStart(ctx context.Context, scenarioID string, at time.Time) (bool, error)
+	FinishPostStart(ctx context.Context, s *Scenario) (bool, error)
+	DeleteScenario(ctx context.Context, scenarioID string) error
+	ListScenarios(ctx context.Context, userID string) ([]*Scenario, error)
+	ListScenariosPaged(ctx context.Context, userID, pageToken string, limit int) ([]*Scenario, string, error)
+	ListScenariosByStatus(ctx context.Context, statuses ...types.ScenarioStatus) ([]*Scenario, error)
+	ListScenariosExpiringBy(ctx context.Context, by time.Time, statuses ...types.ScenarioStatus) ([]*Scenario, error)
+	CountActiveScenarios(ctx context.Context) (int64, error)
+	SearchScenarios(ctx context.Context, userID, query, pageToken string, limit int) ([]SearchHit, string, error)
+	ScenarioStats(ctx context.Context) (*ScenarioStats, error)
+}
+
+// MongoStore is the MongoDB-backed Store
+type MongoStore struct {
+	DB *mongo.Database
+}
+
+// NewMongoStore returns a Store backed by the scenarios collection of db
+func NewMongoStore(db *mongo.Database) *MongoStore {
+	return &MongoStore{DB: db}
+}
+
+func (m *MongoStore) StoreScenario(ctx context.Context, s *Scenario) error {
+	return StoreScenario(ctx, m.DB, s)
+}
+
+func (m *MongoStore) GetScenario(ctx context.Context, scenarioID string) (*Scenario, error) {
+	return GetScenario(ctx, m.DB, scenarioID)
+}
+
+func (m *MongoStore) UpdateScenario(ctx context.Context, s *Scenario) error {
+	return UpdateScenario(ctx, m.DB, s)
+}
+
+func (m *MongoStore) UpsertScenario(ctx context.Context, s *Scenario) error {
+	return UpsertScenario(ctx, m.DB, s)
+}
+
+func (m *MongoStore) TouchScenario(ctx context.Context, scenarioID string, at time.Time) error {
+	return TouchScenario(ctx, m.DB, scenarioID, at)
+}
+
+func (m *MongoStore) MarkExpiryWarned(ctx context.Context, scenarioID string, expiresAt time.Time) error {
+	return MarkExpiryWarned(ctx, m.DB, scenarioID, expiresAt)
+}
+
+func (m *MongoStore) SetScenarioNotes(ctx context.Context, scenarioID, notes string) error {
+	return SetScenarioNotes(ctx, m.DB, scenarioID, notes)
+}
+
+func (m *MongoStore) SetScenarioLocked(ctx context.Context, scenarioID string, locked bool) error {
+	return SetScenarioLocked(ctx, m.DB, scenarioID, locked)
+}
+
+func (m *MongoStore) ClaimPostStart(ctx context.Context, scenarioID string, at time.Time) (bool, error) {
+	return ClaimPostStart(ctx, m.DB, scenarioID, at)
+}
+
+func (m *MongoStore) FinishPostStart(ctx context.Context, s *Scenario) (bool, error) {
+	return FinishPostStart(ctx, m.DB, s)
+}
+
+func (m *MongoStore) DeleteScenario(ctx context.Context, scenarioID string) error {
+	return DeleteScenario(ctx, m.DB, scenarioID)
+}
+
+func (m *MongoStore) ListScenarios(ctx context.Context, userID string) ([]*Scenario, error) {
+	return ListScenarios(ctx, m.DB, userID)
+}
+
+func (m *MongoStore) ListScenariosPaged(ctx context.Context, userID, pageToken string, limit int) ([]*Scenario, string, error) {
+	return ListScenariosPaged(ctx, m.DB, userID, pageToken, limit)
+}
+
+func (m *MongoStore) ListScenariosByStatus(ctx context.Context, statuses ...types.ScenarioStatus) ([]*Scenario, error) {
+	return ListScenariosByStatus(ctx, m.DB, statuses...)
+}
+
+func (m *MongoStore) CountActiveScenarios(ctx context.Context) (int64, error) {
+	return CountActiveScenarios(ctx, m.DB)
+}
+
+func (m *MongoStore) ListScenariosExpiringBy(ctx context.Context, by time.Time, statuses ...types.ScenarioStatus) ([]*Scenario, error) {
+	return ListScenariosExpiringBy(ctx, m.DB, by, statuses...)
+}
+
+func (m *MongoStore) SearchScenarios(ctx context.Context, userID, query, pageToken string, limit int) ([]SearchHit, string, error) {
+	return SearchScenarios(ctx, m.DB, userID, query, pageToken, limit)
+}
+
+func (m *MongoStore) ScenarioStats(ctx context.Context) (*ScenarioStats, error) {
+	return AggregateScenarioStats(ctx, m.DB)
+}
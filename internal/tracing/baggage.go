@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BaggageSpanProcessor copies the listed baggage members onto each span as
+// attributes when it starts, so backends can filter traces by values such as
+// user_id that were set once near the edge of a request
+type BaggageSpanProcessor struct {
+	Keys []string
+}
+
+var _ sdktrace.SpanProcessor = BaggageSpanProcessor{}
+
+func (p BaggageSpanProcessor) OnStart(ctx context.Context, span sdktrace.ReadWriteSpan) {
+	if len(p.Keys) == 0 {
+		return
+	}
+	bag := baggage.FromContext(ctx)
+	for _, key := range p.Keys {
+		if member := bag.Member(key); member.Key() != "" {
+			span.SetAttributes(attribute.String(key, member.Value()))
+		}
+	}
+}
+
+func (BaggageSpanProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (BaggageSpanProcessor) Shutdown(context.Context) error   { return nil }
+func (BaggageSpanProcessor) ForceFlush(context.Context) error { return nil }
+
+// WithUserID returns ctx with userID in its baggage under "user_id" and
+// records it on the current span, which started before the user was known
+func WithUserID(ctx context.Context, userID string) context.Context {
+	if userID == "" {
+		return ctx
+	}
+	member, err := baggage.NewMemberRaw("user_id", userID)
+	if err != nil {
+		return ctx
+	}
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("user_id", userID))
+	return baggage.ContextWithBaggage(ctx, bag)
+}
@@ -0,0 +1,133 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"devlab/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// Exporter kinds chosen by ExporterKind
+const (
+	ExporterStdout   = "stdout"
+	ExporterOTLPGRPC = "otlp-grpc"
+	ExporterOTLPHTTP = "otlp-http"
+)
+
+// ExporterKind reports which exporter cfg selects: OTLP when an endpoint is
+// configured, over gRPC or HTTP per OTLPProtocol, otherwise stdout
+func ExporterKind(cfg config.TracingConfig) (string, error) {
+	if cfg.OTLPEndpoint == "" {
+		return ExporterStdout, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.OTLPProtocol)) {
+	case "grpc":
+		return ExporterOTLPGRPC, nil
+	case "", "http/protobuf", "http":
+		return ExporterOTLPHTTP, nil
+	default:
+		return "", fmt.Errorf("invalid OTLP protocol %q: must be grpc or http/protobuf", cfg.OTLPProtocol)
+	}
+}
+
+// NewExporter builds the span exporter selected by cfg
+func NewExporter(ctx context.Context, cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	kind, err := ExporterKind(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case ExporterOTLPGRPC:
+		return otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(cfg.OTLPEndpoint))
+	case ExporterOTLPHTTP:
+		return otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+	default:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	}
+}
+
+// NewSampler builds the sampler named by cfg.Sampler using the names defined
+// for OTEL_TRACES_SAMPLER. Ratio samplers read their ratio from
+// cfg.SamplerArg, defaulting to 1.
+func NewSampler(cfg config.TracingConfig) (sdktrace.Sampler, error) {
+	ratio := 1.0
+	if cfg.SamplerArg != "" {
+		r, err := strconv.ParseFloat(cfg.SamplerArg, 64)
+		if err != nil || r < 0 || r > 1 {
+			return nil, fmt.Errorf("invalid sampler ratio %q: must be between 0 and 1", cfg.SamplerArg)
+		}
+		ratio = r
+	}
+
+	switch strings.ToLower(strings.TrimSpace(cfg.Sampler)) {
+	case "always_on":
+		return sdktrace.AlwaysSample(), nil
+	case "always_off":
+		return sdktrace.NeverSample(), nil
+	case "traceidratio":
+		return sdktrace.TraceIDRatioBased(ratio), nil
+	case "", "parentbased_always_on":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	case "parentbased_traceidratio":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)), nil
+	default:
+		return nil, fmt.Errorf("invalid sampler %q", cfg.Sampler)
+	}
+}
+
+// newTracerProvider builds a tracer provider sampling per cfg that hands spans
+// to export, an option such as sdktrace.WithBatcher
+func newTracerProvider(cfg config.TracingConfig, export sdktrace.TracerProviderOption, res *resource.Resource) (*sdktrace.TracerProvider, error) {
+	sampler, err := NewSampler(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithSpanProcessor(BaggageSpanProcessor{Keys: cfg.BaggageKeys}),
+		export,
+		sdktrace.WithResource(res),
+	), nil
+}
+
+// Init installs a global tracer provider exporting through cfg's exporter and
+// returns a function that flushes and stops it. Trace context and baggage are
+// propagated in W3C format, and OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES
+// override the resource.
+func Init(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	exporter, err := NewExporter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(cfg.ServiceName)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider, err := newTracerProvider(cfg, sdktrace.WithBatcher(exporter), res)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
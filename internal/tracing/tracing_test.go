@@ -0,0 +1,141 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"devlab/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestExporterKind(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.TracingConfig
+		expected    string
+		expectError bool
+	}{
+		{name: "no_endpoint", cfg: config.TracingConfig{OTLPProtocol: "grpc"}, expected: ExporterStdout},
+		{name: "grpc", cfg: config.TracingConfig{OTLPEndpoint: "http://collector:4317", OTLPProtocol: "grpc"}, expected: ExporterOTLPGRPC},
+		{name: "http", cfg: config.TracingConfig{OTLPEndpoint: "http://collector:4318", OTLPProtocol: "http/protobuf"}, expected: ExporterOTLPHTTP},
+		{name: "default_protocol", cfg: config.TracingConfig{OTLPEndpoint: "http://collector:4318"}, expected: ExporterOTLPHTTP},
+		{name: "unknown_protocol", cfg: config.TracingConfig{OTLPEndpoint: "http://collector:4318", OTLPProtocol: "thrift"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, err := ExporterKind(tt.cfg)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, kind)
+		})
+	}
+}
+
+func TestNewExporter_SelectsOTLPForEndpoint(t *testing.T) {
+	ctx := context.Background()
+
+	for _, protocol := range []string{"grpc", "http/protobuf"} {
+		t.Run(protocol, func(t *testing.T) {
+			exporter, err := NewExporter(ctx, config.TracingConfig{OTLPEndpoint: "http://localhost:4317", OTLPProtocol: protocol})
+			require.NoError(t, err)
+			assert.IsType(t, &otlptrace.Exporter{}, exporter)
+			require.NoError(t, exporter.Shutdown(ctx))
+		})
+	}
+
+	exporter, err := NewExporter(ctx, config.TracingConfig{})
+	require.NoError(t, err)
+	assert.IsType(t, &stdouttrace.Exporter{}, exporter)
+}
+
+func TestNewSampler(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.TracingConfig
+		expectError bool
+	}{
+		{name: "default", cfg: config.TracingConfig{}},
+		{name: "always_off", cfg: config.TracingConfig{Sampler: "always_off"}},
+		{name: "ratio", cfg: config.TracingConfig{Sampler: "parentbased_traceidratio", SamplerArg: "0.25"}},
+		{name: "ratio_out_of_range", cfg: config.TracingConfig{Sampler: "traceidratio", SamplerArg: "2"}, expectError: true},
+		{name: "ratio_not_a_number", cfg: config.TracingConfig{Sampler: "traceidratio", SamplerArg: "half"}, expectError: true},
+		{name: "unknown", cfg: config.TracingConfig{Sampler: "jaeger_remote"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sampler, err := NewSampler(tt.cfg)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, sampler)
+		})
+	}
+}
+
+func TestSamplerRatioControlsExport(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		ratio    string
+		expected int
+	}{
+		{name: "zero_percent", ratio: "0", expected: 0},
+		{name: "hundred_percent", ratio: "1", expected: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			cfg := config.TracingConfig{Sampler: "traceidratio", SamplerArg: tt.ratio}
+			provider, err := newTracerProvider(cfg, sdktrace.WithSyncer(exporter), resource.Empty())
+			require.NoError(t, err)
+			defer provider.Shutdown(ctx)
+
+			for i := 0; i < 10; i++ {
+				_, span := provider.Tracer("test").Start(ctx, "op")
+				span.End()
+			}
+			require.NoError(t, provider.ForceFlush(ctx))
+			assert.Len(t, exporter.GetSpans(), tt.expected)
+		})
+	}
+}
+
+func TestBaggageSpanProcessor_CopiesUserID(t *testing.T) {
+	ctx := context.Background()
+	exporter := tracetest.NewInMemoryExporter()
+	cfg := config.TracingConfig{BaggageKeys: []string{"user_id"}}
+	provider, err := newTracerProvider(cfg, sdktrace.WithSyncer(exporter), resource.Empty())
+	require.NoError(t, err)
+	defer provider.Shutdown(ctx)
+
+	ctx = WithUserID(ctx, "alice")
+	_, span := provider.Tracer("test").Start(ctx, "op")
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	found := false
+	for _, attr := range spans[0].Attributes {
+		if attr.Key == "user_id" {
+			found = true
+			assert.Equal(t, "alice", attr.Value.AsString())
+		}
+	}
+	assert.True(t, found, "user_id baggage is recorded on the span")
+}
@@ -1,26 +1,193 @@
 package types
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
 // Shared request and response types to avoid circular imports
 
 type StartScenarioRequest struct {
-	UserID       string `json:"user_id"`
-	ScenarioType string `json:"scenario_type"`
-	Script       string `json:"script"`
+	UserID       string       `json:"user_id"`
+	ScenarioType string       `json:"scenario_type"`
+	Script       string       `json:"script"`
+	Mode         ScenarioMode `json:"mode,omitempty"`
+	// Name and Tags label the scenario for search; Notes is freeform text
+	// about it, also searched
+	Name  string   `json:"name,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	Notes string   `json:"notes,omitempty"`
+	// WaitForRunning asks start to check once whether the container is already
+	// up and, if so, return its terminal URL and credentials with the response
+	WaitForRunning bool `json:"wait_for_running,omitempty"`
+	// Entrypoint and Command replace the generated startup logic, so they
+	// cannot be combined with a script or batch mode. StartTTYD still launches
+	// the web terminal in the background before handing over to the override.
+	Entrypoint []string `json:"entrypoint,omitempty"`
+	Command    []string `json:"command,omitempty"`
+	StartTTYD  bool     `json:"start_ttyd,omitempty"`
+	// PostStart is run in the container once it is up, before the scenario is
+	// reported as running. A failure is recorded on the scenario and stops it
+	// only when PostStartFatal is set.
+	PostStart      []string `json:"post_start,omitempty"`
+	PostStartFatal bool     `json:"post_start_fatal,omitempty"`
+	// ScriptTimeoutSeconds bounds a batch script or post-start hook; zero
+	// uses the server default, and a limit above the server maximum is cut down
+	// to it. A script still running at the limit is killed.
+	ScriptTimeoutSeconds int `json:"script_timeout_seconds,omitempty"`
+	// TTLSeconds is how long the scenario lives before cleanup; zero uses the
+	// server default. TTLs outside the server's range are clamped into it or
+	// rejected, depending on its configuration.
+	TTLSeconds int `json:"ttl_seconds,omitempty"`
+	// KeepOnFailure leaves a container that fails to come up in place for
+	// debugging; the scenario is recorded as failed with the container's logs
+	KeepOnFailure bool `json:"keep_on_failure,omitempty"`
+	// Env sets extra environment variables in the container. Names must be
+	// valid shell identifiers and values cannot contain null bytes.
+	Env map[string]string `json:"env,omitempty"`
+	// Labels are attached to the container, e.g. for external accounting.
+	// Keys use lowercase letters, digits, dots and hyphens, and may not start
+	// with "devlab.", which is reserved for devlab's own labels.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Limits caps the container's CPU and memory. Unset fields use the
+	// server's default for the scenario type, if any.
+	Limits *ResourceLimits `json:"limits,omitempty"`
+	// Template names a server-side scenario template, e.g. "intro-go-lab",
+	// that fills in the fields the request leaves unset. Its env and labels
+	// are merged with the request's, which win on conflict.
+	Template string `json:"template,omitempty"`
+}
+
+// ScenarioTemplate is a named, pre-baked scenario configuration, e.g. the
+// lab an instructor hands out, that start requests reference by name
+type ScenarioTemplate struct {
+	ScenarioType         string            `json:"scenario_type"`
+	Mode                 ScenarioMode      `json:"mode,omitempty"`
+	Script               string            `json:"script,omitempty"`
+	Env                  map[string]string `json:"env,omitempty"`
+	Labels               map[string]string `json:"labels,omitempty"`
+	Limits               *ResourceLimits   `json:"limits,omitempty"`
+	Tags                 []string          `json:"tags,omitempty"`
+	TTLSeconds           int               `json:"ttl_seconds,omitempty"`
+	ScriptTimeoutSeconds int               `json:"script_timeout_seconds,omitempty"`
+}
+
+// ResourceLimits caps a scenario container's CPU and memory
+type ResourceLimits struct {
+	// CPUs is the number of CPUs the container may use, e.g. 1.5
+	CPUs float64 `json:"cpus,omitempty"`
+	// MemoryMB is the memory limit in megabytes; at least 6 when set
+	MemoryMB int64 `json:"memory_mb,omitempty"`
+}
+
+// HasContainerOverride reports whether the request replaces the generated startup logic
+func (r *StartScenarioRequest) HasContainerOverride() bool {
+	return len(r.Entrypoint) > 0 || len(r.Command) > 0
+}
+
+// ScenarioMode selects how a scenario runs. Interactive scenarios keep a web
+// terminal open until stopped; batch scenarios run the script once and record
+// its output and exit code. The empty mode is interactive.
+type ScenarioMode string
+
+const (
+	ScenarioModeInteractive ScenarioMode = "interactive"
+	ScenarioModeBatch       ScenarioMode = "batch"
+)
+
+// Valid reports whether m is a known mode or empty
+func (m ScenarioMode) Valid() bool {
+	return m == "" || m == ScenarioModeInteractive || m == ScenarioModeBatch
 }
 
 type StartScenarioResponse struct {
-	ScenarioID string `json:"scenario_id"`
-	Status     string `json:"status"`
+	ScenarioID string         `json:"scenario_id"`
+	Status     ScenarioStatus `json:"status"`
+	// TerminalURL and TerminalCredentials are set only when the scenario was
+	// already running when start returned; otherwise they are null and the
+	// client fetches them once the scenario is running
+	TerminalURL         *string              `json:"terminal_url"`
+	TerminalCredentials *TerminalCredentials `json:"terminal_credentials"`
 }
 
+// TerminalCredentials are the basic auth credentials for a scenario's web terminal
+type TerminalCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// ScenarioStatus is the lifecycle state of a scenario. It serializes as the
+// bare string in JSON and BSON.
+type ScenarioStatus string
+
+const (
+	ScenarioStatusProvisioning ScenarioStatus = "provisioning"
+	ScenarioStatusRunning      ScenarioStatus = "running"
+	ScenarioStatusPaused       ScenarioStatus = "paused"
+	ScenarioStatusStopped      ScenarioStatus = "stopped"
+	ScenarioStatusCleanedUp    ScenarioStatus = "cleaned_up"
+	// Batch scenarios finish as completed (exit code 0) or failed
+	ScenarioStatusCompleted ScenarioStatus = "completed"
+	ScenarioStatusFailed    ScenarioStatus = "failed"
+)
+
+// ErrInvalidScenarioStatus is returned when parsing an unknown status
+var ErrInvalidScenarioStatus = errors.New("invalid scenario status")
+
+// ParseScenarioStatus converts an inbound value, e.g. a query parameter, to a ScenarioStatus
+func ParseScenarioStatus(value string) (ScenarioStatus, error) {
+	status := ScenarioStatus(value)
+	if !status.Valid() {
+		return "", fmt.Errorf("%w: %q", ErrInvalidScenarioStatus, value)
+	}
+	return status, nil
+}
+
+// Valid reports whether s is one of the defined statuses
+func (s ScenarioStatus) Valid() bool {
+	switch s {
+	case ScenarioStatusProvisioning, ScenarioStatusRunning, ScenarioStatusPaused, ScenarioStatusStopped,
+		ScenarioStatusCleanedUp, ScenarioStatusCompleted, ScenarioStatusFailed:
+		return true
+	}
+	return false
+}
+
+// Active reports whether the scenario has, or is getting, a live container.
+// A paused container is still live: it holds its memory and a capacity slot.
+func (s ScenarioStatus) Active() bool {
+	return s == ScenarioStatusProvisioning || s == ScenarioStatusRunning || s == ScenarioStatusPaused
+}
+
+// ActiveScenarioStatuses lists every status for which Active is true
+var ActiveScenarioStatuses = []ScenarioStatus{ScenarioStatusProvisioning, ScenarioStatusRunning, ScenarioStatusPaused}
+
+// StopReason records why a scenario is no longer running
+type StopReason string
+
+const (
+	StopReasonUserRequested StopReason = "user_requested"
+	StopReasonExpired       StopReason = "expired"
+	StopReasonOrphaned      StopReason = "orphaned"
+	StopReasonFailed        StopReason = "failed"
+	// StopReasonForceRemoved marks a scenario cleared by an admin force-remove
+	StopReasonForceRemoved StopReason = "force_removed"
+	// StopReasonProvisioningTimeout marks a scenario whose container was not
+	// provisioned within the configured deadline
+	StopReasonProvisioningTimeout StopReason = "provisioning_timeout"
+)
+
 type ScenarioStatusResponse struct {
-	ScenarioID      string `json:"scenario_id"`
-	UserID          string `json:"user_id"`
-	ScenarioType    string `json:"scenario_type"`
-	ContainerID     string `json:"container_id"`
-	Status          string `json:"status"`
-	ContainerStatus string `json:"container_status,omitempty"`
-	Message         string `json:"message"`
+	ScenarioID      string         `json:"scenario_id"`
+	UserID          string         `json:"user_id"`
+	ScenarioType    string         `json:"scenario_type"`
+	ContainerID     string         `json:"container_id"`
+	Status          ScenarioStatus `json:"status"`
+	ContainerStatus string         `json:"container_status,omitempty"`
+	StopReason      StopReason     `json:"stop_reason,omitempty"`
+	Message         string         `json:"message"`
 }
 
 type TerminalURLResponse struct {
@@ -29,27 +196,325 @@ type TerminalURLResponse struct {
 	Message    string `json:"message"`
 }
 
-// FileNode represents a file or directory in the file tree
+// ScenarioTerminal is the web terminal of one of a user's running scenarios.
+// Reachable reports whether its container is up and publishing ttyd; the URL
+// is empty when it is not published.
+type ScenarioTerminal struct {
+	ScenarioID string `json:"scenario_id"`
+	URL        string `json:"url"`
+	Reachable  bool   `json:"reachable"`
+}
+
+// TerminalCredentialsResponse carries the ttyd login for a scenario's web terminal
+type TerminalCredentialsResponse struct {
+	ScenarioID string `json:"scenario_id"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	Message    string `json:"message"`
+}
+
+// CloneScenarioRequest optionally names the user who will own the clone, which
+// only admins may set; without one the caller does
+type CloneScenarioRequest struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+// RebindTerminalResponse reports the host port a scenario's terminal was moved to
+type RebindTerminalResponse struct {
+	ScenarioID   string `json:"scenario_id"`
+	TerminalPort int    `json:"terminal_port"`
+	URL          string `json:"url"`
+	Message      string `json:"message"`
+}
+
+// FileNode represents a file or directory in the file tree. Its flags are
+// also encoded under their deprecated camelCase names; see legacyFileNodeFlags.
 type FileNode struct {
 	Path     string   `json:"path"`
 	Type     string   `json:"type"` // "file" or "folder"
-	IsRoot   bool     `json:"isRoot"`
+	IsRoot   bool     `json:"is_root"`
 	Children []string `json:"children,omitempty"`
 	Content  string   `json:"content,omitempty"`
-	IsOpen   bool     `json:"isOpen"`
-	IsSaved  bool     `json:"isSaved"`
+	IsOpen   bool     `json:"is_open"`
+	IsSaved  bool     `json:"is_saved"`
+}
+
+// NestedFileNode is a file or directory with its children embedded, so
+// clients receive the whole tree without reassembling it from paths. Like
+// FileNode, its flags are also encoded under their deprecated names.
+type NestedFileNode struct {
+	Path     string            `json:"path"`
+	Type     string            `json:"type"` // "file" or "folder"
+	IsRoot   bool              `json:"is_root"`
+	Children []*NestedFileNode `json:"children,omitempty"`
+	IsOpen   bool              `json:"is_open"`
+	IsSaved  bool              `json:"is_saved"`
+}
+
+// legacyFileNodeFlags holds the camelCase names file node flags were encoded
+// under before they followed the API's snake_case convention. They are still
+// emitted for clients that have not moved to is_root, is_open and is_saved,
+// and will be dropped in a future release.
+type legacyFileNodeFlags struct {
+	IsRoot  bool `json:"isRoot"`
+	IsOpen  bool `json:"isOpen"`
+	IsSaved bool `json:"isSaved"`
+}
+
+// MarshalJSON encodes n with its flags under both their canonical and
+// deprecated names
+func (n FileNode) MarshalJSON() ([]byte, error) {
+	type fileNode FileNode
+	return json.Marshal(struct {
+		fileNode
+		legacyFileNodeFlags
+	}{fileNode(n), legacyFileNodeFlags{IsRoot: n.IsRoot, IsOpen: n.IsOpen, IsSaved: n.IsSaved}})
+}
+
+// MarshalJSON encodes n and its children with their flags under both their
+// canonical and deprecated names
+func (n NestedFileNode) MarshalJSON() ([]byte, error) {
+	type nestedFileNode NestedFileNode
+	return json.Marshal(struct {
+		nestedFileNode
+		legacyFileNodeFlags
+	}{nestedFileNode(n), legacyFileNodeFlags{IsRoot: n.IsRoot, IsOpen: n.IsOpen, IsSaved: n.IsSaved}})
+}
+
+// DirectoryFormat selects the shape of a directory structure response
+type DirectoryFormat string
+
+const (
+	// DirectoryFormatFlat lists every node with children as path strings
+	DirectoryFormatFlat DirectoryFormat = "flat"
+	// DirectoryFormatNested returns a single tree rooted at the workspace
+	DirectoryFormatNested DirectoryFormat = "nested"
+)
+
+// Valid reports whether f is a known format
+func (f DirectoryFormat) Valid() bool {
+	return f == DirectoryFormatFlat || f == DirectoryFormatNested
 }
 
-// DirectoryStructureResponse represents the response for directory structure endpoint
+// DirectoryStructureResponse represents the response for directory structure endpoint.
+// Structure is set for the flat format and Tree for the nested one.
 type DirectoryStructureResponse struct {
-	ScenarioID string     `json:"scenario_id"`
-	Path       string     `json:"path"`
-	Structure  []FileNode `json:"structure"`
-	Message    string     `json:"message"`
+	ScenarioID string          `json:"scenario_id"`
+	Path       string          `json:"path"`
+	Structure  []FileNode      `json:"structure"`
+	Tree       *NestedFileNode `json:"tree,omitempty"`
+	// Truncated is set when the entry cap, the time limit or a failing find
+	// cut the listing short; entries below the depth limit are never listed
+	// and do not set it
+	Truncated bool   `json:"truncated"`
+	Message   string `json:"message"`
 }
 
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Code    string `json:"code,omitempty"`
+// ExtendScenarioResponse reports a scenario's new expiry after an extend request
+type ExtendScenarioResponse struct {
+	ScenarioID string    `json:"scenario_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Message    string    `json:"message"`
+}
+
+// HeartbeatResponse reports when a scenario's activity was last recorded
+type HeartbeatResponse struct {
+	ScenarioID     string    `json:"scenario_id"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	// NextHeartbeatAt is the earliest time another heartbeat will be accepted
+	NextHeartbeatAt time.Time `json:"next_heartbeat_at"`
+}
+
+// ForceRemoveScenarioResponse reports the outcome of an admin force-remove
+type ForceRemoveScenarioResponse struct {
+	ScenarioID     string         `json:"scenario_id"`
+	PreviousStatus ScenarioStatus `json:"previous_status"`
+	Status         ScenarioStatus `json:"status"`
+	// ContainerRemoved is false when the container was already gone or could not be removed
+	ContainerRemoved bool   `json:"container_removed"`
+	Message          string `json:"message"`
+}
+
+// ImportScenariosResponse reports the outcome of an admin scenario import
+type ImportScenariosResponse struct {
+	Imported int `json:"imported"`
+	// Reconciled counts the imported scenarios whose container, terminal
+	// port or status was corrected to match this host
+	Reconciled int                     `json:"reconciled"`
+	Failed     []ScenarioImportFailure `json:"failed,omitempty"`
+	Message    string                  `json:"message"`
+}
+
+// ScenarioImportFailure describes a line of an import that was skipped
+type ScenarioImportFailure struct {
+	Line       int    `json:"line"`
+	ScenarioID string `json:"scenario_id,omitempty"`
+	Error      string `json:"error"`
+}
+
+// StopScenariosRequest lists the scenarios a bulk stop stops
+type StopScenariosRequest struct {
+	ScenarioIDs []string `json:"scenario_ids"`
+}
+
+// StopScenariosResponse reports the outcome of a bulk stop. Scenarios that
+// were already stopped count as stopped.
+type StopScenariosResponse struct {
+	Stopped []string              `json:"stopped"`
+	Failed  []ScenarioStopFailure `json:"failed,omitempty"`
+	Message string                `json:"message"`
+}
+
+// ScenarioStopFailure describes a scenario a bulk stop could not stop
+type ScenarioStopFailure struct {
+	ScenarioID string `json:"scenario_id"`
+	Error      string `json:"error"`
+}
+
+// ScenarioStatsResponse summarises every stored scenario for operators
+type ScenarioStatsResponse struct {
+	Total    int                    `json:"total"`
+	ByStatus map[ScenarioStatus]int `json:"by_status"`
+	ByType   map[string]int         `json:"by_type"`
+	ByUser   map[string]int         `json:"by_user"`
+	// OldestRunning is omitted when no scenario is running
+	OldestRunning *RunningScenarioSummary `json:"oldest_running,omitempty"`
+}
+
+// RunningScenarioSummary identifies a running scenario and how long it has run
+type RunningScenarioSummary struct {
+	ScenarioID   string    `json:"scenario_id"`
+	UserID       string    `json:"user_id"`
+	ScenarioType string    `json:"scenario_type"`
+	CreatedAt    time.Time `json:"created_at"`
+	// RunningFor is the time since CreatedAt, e.g. "26h3m0s"
+	RunningFor string `json:"running_for"`
+}
+
+// PauseScenarioResponse reports the status of a scenario after a pause or resume
+type PauseScenarioResponse struct {
+	ScenarioID string         `json:"scenario_id"`
+	Status     ScenarioStatus `json:"status"`
+	Message    string         `json:"message"`
+}
+
+// ExecCommandRequest runs a command in a scenario's container
+type ExecCommandRequest struct {
+	// Command is the program and its arguments; it is not run through a shell
+	Command []string `json:"command"`
+	// TimeoutSeconds bounds the command; zero uses the deployment default
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// ExecCommandResponse carries the output of a command run in a scenario
+type ExecCommandResponse struct {
+	ScenarioID string `json:"scenario_id"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	ExitCode   int    `json:"exit_code"`
+	TimedOut   bool   `json:"timed_out"`
+	// Truncated is set when the command wrote more than the server's output
+	// limit; the output is cut off there and ExitCode is -1 if the command
+	// was still running
+	Truncated bool `json:"truncated"`
+}
+
+// ScenarioResultsResponse carries the captured output of a finished batch scenario
+type ScenarioResultsResponse struct {
+	ScenarioID  string         `json:"scenario_id"`
+	Status      ScenarioStatus `json:"status"`
+	Stdout      string         `json:"stdout"`
+	Stderr      string         `json:"stderr"`
+	ExitCode    int            `json:"exit_code"`
+	TimedOut    bool           `json:"timed_out"`
+	CompletedAt time.Time      `json:"completed_at"`
+	Message     string         `json:"message"`
+}
+
+// ScenarioDetailsResponse is the complete stored record of a scenario. The
+// terminal password is only included when explicitly requested.
+type ScenarioDetailsResponse struct {
+	ScenarioID       string         `json:"scenario_id"`
+	UserID           string         `json:"user_id"`
+	ScenarioType     string         `json:"scenario_type"`
+	Image            string         `json:"image,omitempty"`
+	Name             string         `json:"name,omitempty"`
+	Tags             []string       `json:"tags,omitempty"`
+	Notes            string         `json:"notes,omitempty"`
+	Locked           bool           `json:"locked"`
+	Mode             ScenarioMode   `json:"mode,omitempty"`
+	Script           string         `json:"script,omitempty"`
+	ContainerID      string         `json:"container_id"`
+	Status           ScenarioStatus `json:"status"`
+	StopReason       StopReason     `json:"stop_reason,omitempty"`
+	TerminalPort     int            `json:"terminal_port,omitempty"`
+	TerminalUsername string         `json:"terminal_username,omitempty"`
+	TerminalPassword string         `json:"terminal_password,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	ExpiresAt        time.Time      `json:"expires_at"`
+	LastActivityAt   time.Time      `json:"last_activity_at"`
+	ExitCode         *int           `json:"exit_code,omitempty"`
+	CompletedAt      *time.Time     `json:"completed_at,omitempty"`
+	PostStartError   string         `json:"post_start_error,omitempty"`
+	ProvisioningLogs string         `json:"provisioning_logs,omitempty"`
+	// StatusHistory lists the scenario's most recent status transitions,
+	// oldest first
+	StatusHistory []StatusChange `json:"status_history,omitempty"`
+	Message       string         `json:"message"`
+}
+
+// StatusChange is a scenario entering a status, when and why
+type StatusChange struct {
+	Status ScenarioStatus `json:"status"`
+	At     time.Time      `json:"at"`
+	Reason string         `json:"reason,omitempty"`
+}
+
+// UpdateScenarioRequest changes the user-editable fields of a scenario.
+// Fields left out are unchanged.
+type UpdateScenarioRequest struct {
+	Notes *string `json:"notes,omitempty"`
+	// Locked keeps the scenario from being cleaned up, however long it has
+	// expired or sat idle
+	Locked *bool `json:"locked,omitempty"`
+}
+
+// ScenarioSearchResult is a scenario matched by a search, with its relevance
+// score; higher scores are better matches
+type ScenarioSearchResult struct {
+	ScenarioID   string         `json:"scenario_id"`
+	Name         string         `json:"name,omitempty"`
+	Tags         []string       `json:"tags,omitempty"`
+	ScenarioType string         `json:"scenario_type"`
+	Status       ScenarioStatus `json:"status"`
+	CreatedAt    time.Time      `json:"created_at"`
+	Score        float64        `json:"score"`
+}
+
+type SearchScenariosResponse struct {
+	Results []ScenarioSearchResult `json:"results"`
+	// NextPageToken resumes the search; it is empty on the last page
+	NextPageToken string `json:"next_page_token,omitempty"`
+	Message       string `json:"message"`
+}
+
+// ScenarioEvent is pushed on the events stream when one of the user's scenarios changes status
+type ScenarioEvent struct {
+	ScenarioID string         `json:"scenario_id"`
+	Status     ScenarioStatus `json:"status"`
+	Timestamp  time.Time      `json:"timestamp"`
+}
+
+// FieldError describes a single invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
 	Message string `json:"message"`
 }
+
+type ErrorResponse struct {
+	Error   string       `json:"error"`
+	Code    string       `json:"code,omitempty"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
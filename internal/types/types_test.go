@@ -0,0 +1,98 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScenarioStatus(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    ScenarioStatus
+		expectError bool
+	}{
+		{input: "provisioning", expected: ScenarioStatusProvisioning},
+		{input: "running", expected: ScenarioStatusRunning},
+		{input: "stopped", expected: ScenarioStatusStopped},
+		{input: "cleaned_up", expected: ScenarioStatusCleanedUp},
+		{input: "completed", expected: ScenarioStatusCompleted},
+		{input: "failed", expected: ScenarioStatusFailed},
+		{input: "starting", expectError: true},
+		{input: "Running", expectError: true},
+		{input: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			status, err := ParseScenarioStatus(tt.input)
+			if tt.expectError {
+				assert.ErrorIs(t, err, ErrInvalidScenarioStatus)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, status)
+		})
+	}
+}
+
+func TestScenarioStatus_Active(t *testing.T) {
+	assert.True(t, ScenarioStatusProvisioning.Active())
+	assert.True(t, ScenarioStatusRunning.Active())
+	assert.False(t, ScenarioStatusStopped.Active())
+	assert.False(t, ScenarioStatusCleanedUp.Active())
+	assert.False(t, ScenarioStatusCompleted.Active())
+	assert.False(t, ScenarioStatusFailed.Active())
+}
+
+func TestScenarioMode_Valid(t *testing.T) {
+	assert.True(t, ScenarioMode("").Valid())
+	assert.True(t, ScenarioModeInteractive.Valid())
+	assert.True(t, ScenarioModeBatch.Valid())
+	assert.False(t, ScenarioMode("Batch").Valid())
+}
+
+func TestScenarioStatus_JSONIsBareString(t *testing.T) {
+	data, err := json.Marshal(StartScenarioResponse{ScenarioID: "scn-1", Status: ScenarioStatusRunning})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"scenario_id":"scn-1","status":"running","terminal_url":null,"terminal_credentials":null}`, string(data))
+}
+
+func TestFileNode_JSONFieldNames(t *testing.T) {
+	data, err := json.Marshal(FileNode{Path: "/home/devlab/main.go", Type: "file", IsOpen: true})
+	require.NoError(t, err)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, map[string]any{
+		"path": "/home/devlab/main.go", "type": "file",
+		"is_root": false, "is_open": true, "is_saved": false,
+		// Deprecated names, still emitted during the transition
+		"isRoot": false, "isOpen": true, "isSaved": false,
+	}, fields)
+
+	// Decoding uses the canonical names
+	var node FileNode
+	require.NoError(t, json.Unmarshal([]byte(`{"path":"/home/devlab","is_root":true,"isOpen":true}`), &node))
+	assert.Equal(t, FileNode{Path: "/home/devlab", IsRoot: true}, node)
+}
+
+func TestNestedFileNode_JSONFieldNames(t *testing.T) {
+	data, err := json.Marshal(&NestedFileNode{
+		Path: "/home/devlab", Type: "folder", IsRoot: true, IsSaved: true,
+		Children: []*NestedFileNode{{Path: "/home/devlab/main.go", Type: "file"}},
+	})
+	require.NoError(t, err)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, true, fields["is_root"])
+	assert.Equal(t, true, fields["is_saved"])
+	assert.Equal(t, true, fields["isRoot"])
+	child := fields["children"].([]any)[0].(map[string]any)
+	assert.Equal(t, false, child["is_root"])
+	assert.Equal(t, false, child["isRoot"])
+	assert.Equal(t, "/home/devlab/main.go", child["path"])
+}
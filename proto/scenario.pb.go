@@ -22,12 +22,13 @@ const (
 )
 
 type StartScenarioRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	ScenarioType  string                 `protobuf:"bytes,2,opt,name=scenario_type,json=scenarioType,proto3" json:"scenario_type,omitempty"`
-	Script        string                 `protobuf:"bytes,3,opt,name=script,proto3" json:"script,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ScenarioType   string                 `protobuf:"bytes,2,opt,name=scenario_type,json=scenarioType,proto3" json:"scenario_type,omitempty"`
+	Script         string                 `protobuf:"bytes,3,opt,name=script,proto3" json:"script,omitempty"`
+	WaitForRunning bool                   `protobuf:"varint,4,opt,name=wait_for_running,json=waitForRunning,proto3" json:"wait_for_running,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *StartScenarioRequest) Reset() {
@@ -81,12 +82,21 @@ func (x *StartScenarioRequest) GetScript() string {
 	return ""
 }
 
+func (x *StartScenarioRequest) GetWaitForRunning() bool {
+	if x != nil {
+		return x.WaitForRunning
+	}
+	return false
+}
+
 type StartScenarioResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ScenarioId    string                 `protobuf:"bytes,1,opt,name=scenario_id,json=scenarioId,proto3" json:"scenario_id,omitempty"`
-	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	ScenarioId          string                 `protobuf:"bytes,1,opt,name=scenario_id,json=scenarioId,proto3" json:"scenario_id,omitempty"`
+	Status              string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	TerminalUrl         string                 `protobuf:"bytes,3,opt,name=terminal_url,json=terminalUrl,proto3" json:"terminal_url,omitempty"`
+	TerminalCredentials *TerminalCredentials   `protobuf:"bytes,4,opt,name=terminal_credentials,json=terminalCredentials,proto3" json:"terminal_credentials,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
 }
 
 func (x *StartScenarioResponse) Reset() {
@@ -133,6 +143,72 @@ func (x *StartScenarioResponse) GetStatus() string {
 	return ""
 }
 
+func (x *StartScenarioResponse) GetTerminalUrl() string {
+	if x != nil {
+		return x.TerminalUrl
+	}
+	return ""
+}
+
+func (x *StartScenarioResponse) GetTerminalCredentials() *TerminalCredentials {
+	if x != nil {
+		return x.TerminalCredentials
+	}
+	return nil
+}
+
+type TerminalCredentials struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TerminalCredentials) Reset() {
+	*x = TerminalCredentials{}
+	mi := &file_proto_scenario_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TerminalCredentials) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TerminalCredentials) ProtoMessage() {}
+
+func (x *TerminalCredentials) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_scenario_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TerminalCredentials.ProtoReflect.Descriptor instead.
+func (*TerminalCredentials) Descriptor() ([]byte, []int) {
+	return file_proto_scenario_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TerminalCredentials) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *TerminalCredentials) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
 type StopScenarioRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ScenarioId    string                 `protobuf:"bytes,1,opt,name=scenario_id,json=scenarioId,proto3" json:"scenario_id,omitempty"`
@@ -142,7 +218,7 @@ type StopScenarioRequest struct {
 
 func (x *StopScenarioRequest) Reset() {
 	*x = StopScenarioRequest{}
-	mi := &file_proto_scenario_proto_msgTypes[2]
+	mi := &file_proto_scenario_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -154,7 +230,7 @@ func (x *StopScenarioRequest) String() string {
 func (*StopScenarioRequest) ProtoMessage() {}
 
 func (x *StopScenarioRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_scenario_proto_msgTypes[2]
+	mi := &file_proto_scenario_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -167,7 +243,7 @@ func (x *StopScenarioRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StopScenarioRequest.ProtoReflect.Descriptor instead.
 func (*StopScenarioRequest) Descriptor() ([]byte, []int) {
-	return file_proto_scenario_proto_rawDescGZIP(), []int{2}
+	return file_proto_scenario_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *StopScenarioRequest) GetScenarioId() string {
@@ -187,7 +263,7 @@ type StopScenarioResponse struct {
 
 func (x *StopScenarioResponse) Reset() {
 	*x = StopScenarioResponse{}
-	mi := &file_proto_scenario_proto_msgTypes[3]
+	mi := &file_proto_scenario_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -199,7 +275,7 @@ func (x *StopScenarioResponse) String() string {
 func (*StopScenarioResponse) ProtoMessage() {}
 
 func (x *StopScenarioResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_scenario_proto_msgTypes[3]
+	mi := &file_proto_scenario_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -212,7 +288,7 @@ func (x *StopScenarioResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StopScenarioResponse.ProtoReflect.Descriptor instead.
 func (*StopScenarioResponse) Descriptor() ([]byte, []int) {
-	return file_proto_scenario_proto_rawDescGZIP(), []int{3}
+	return file_proto_scenario_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *StopScenarioResponse) GetScenarioId() string {
@@ -238,7 +314,7 @@ type GetScenarioStatusRequest struct {
 
 func (x *GetScenarioStatusRequest) Reset() {
 	*x = GetScenarioStatusRequest{}
-	mi := &file_proto_scenario_proto_msgTypes[4]
+	mi := &file_proto_scenario_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -250,7 +326,7 @@ func (x *GetScenarioStatusRequest) String() string {
 func (*GetScenarioStatusRequest) ProtoMessage() {}
 
 func (x *GetScenarioStatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_scenario_proto_msgTypes[4]
+	mi := &file_proto_scenario_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -263,7 +339,7 @@ func (x *GetScenarioStatusRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetScenarioStatusRequest.ProtoReflect.Descriptor instead.
 func (*GetScenarioStatusRequest) Descriptor() ([]byte, []int) {
-	return file_proto_scenario_proto_rawDescGZIP(), []int{4}
+	return file_proto_scenario_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetScenarioStatusRequest) GetScenarioId() string {
@@ -288,7 +364,7 @@ type GetScenarioStatusResponse struct {
 
 func (x *GetScenarioStatusResponse) Reset() {
 	*x = GetScenarioStatusResponse{}
-	mi := &file_proto_scenario_proto_msgTypes[5]
+	mi := &file_proto_scenario_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -300,7 +376,7 @@ func (x *GetScenarioStatusResponse) String() string {
 func (*GetScenarioStatusResponse) ProtoMessage() {}
 
 func (x *GetScenarioStatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_scenario_proto_msgTypes[5]
+	mi := &file_proto_scenario_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -313,7 +389,7 @@ func (x *GetScenarioStatusResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetScenarioStatusResponse.ProtoReflect.Descriptor instead.
 func (*GetScenarioStatusResponse) Descriptor() ([]byte, []int) {
-	return file_proto_scenario_proto_rawDescGZIP(), []int{5}
+	return file_proto_scenario_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GetScenarioStatusResponse) GetScenarioId() string {
@@ -374,7 +450,7 @@ type GetTerminalURLRequest struct {
 
 func (x *GetTerminalURLRequest) Reset() {
 	*x = GetTerminalURLRequest{}
-	mi := &file_proto_scenario_proto_msgTypes[6]
+	mi := &file_proto_scenario_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -386,7 +462,7 @@ func (x *GetTerminalURLRequest) String() string {
 func (*GetTerminalURLRequest) ProtoMessage() {}
 
 func (x *GetTerminalURLRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_scenario_proto_msgTypes[6]
+	mi := &file_proto_scenario_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -399,7 +475,7 @@ func (x *GetTerminalURLRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetTerminalURLRequest.ProtoReflect.Descriptor instead.
 func (*GetTerminalURLRequest) Descriptor() ([]byte, []int) {
-	return file_proto_scenario_proto_rawDescGZIP(), []int{6}
+	return file_proto_scenario_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *GetTerminalURLRequest) GetScenarioId() string {
@@ -420,7 +496,7 @@ type GetTerminalURLResponse struct {
 
 func (x *GetTerminalURLResponse) Reset() {
 	*x = GetTerminalURLResponse{}
-	mi := &file_proto_scenario_proto_msgTypes[7]
+	mi := &file_proto_scenario_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -432,7 +508,7 @@ func (x *GetTerminalURLResponse) String() string {
 func (*GetTerminalURLResponse) ProtoMessage() {}
 
 func (x *GetTerminalURLResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_scenario_proto_msgTypes[7]
+	mi := &file_proto_scenario_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -445,7 +521,7 @@ func (x *GetTerminalURLResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetTerminalURLResponse.ProtoReflect.Descriptor instead.
 func (*GetTerminalURLResponse) Descriptor() ([]byte, []int) {
-	return file_proto_scenario_proto_rawDescGZIP(), []int{7}
+	return file_proto_scenario_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *GetTerminalURLResponse) GetScenarioId() string {
@@ -478,7 +554,7 @@ type GetDirectoryStructureRequest struct {
 
 func (x *GetDirectoryStructureRequest) Reset() {
 	*x = GetDirectoryStructureRequest{}
-	mi := &file_proto_scenario_proto_msgTypes[8]
+	mi := &file_proto_scenario_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -490,7 +566,7 @@ func (x *GetDirectoryStructureRequest) String() string {
 func (*GetDirectoryStructureRequest) ProtoMessage() {}
 
 func (x *GetDirectoryStructureRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_scenario_proto_msgTypes[8]
+	mi := &file_proto_scenario_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -503,7 +579,7 @@ func (x *GetDirectoryStructureRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetDirectoryStructureRequest.ProtoReflect.Descriptor instead.
 func (*GetDirectoryStructureRequest) Descriptor() ([]byte, []int) {
-	return file_proto_scenario_proto_rawDescGZIP(), []int{8}
+	return file_proto_scenario_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *GetDirectoryStructureRequest) GetScenarioId() string {
@@ -528,7 +604,7 @@ type FileNode struct {
 
 func (x *FileNode) Reset() {
 	*x = FileNode{}
-	mi := &file_proto_scenario_proto_msgTypes[9]
+	mi := &file_proto_scenario_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -540,7 +616,7 @@ func (x *FileNode) String() string {
 func (*FileNode) ProtoMessage() {}
 
 func (x *FileNode) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_scenario_proto_msgTypes[9]
+	mi := &file_proto_scenario_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -553,7 +629,7 @@ func (x *FileNode) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FileNode.ProtoReflect.Descriptor instead.
 func (*FileNode) Descriptor() ([]byte, []int) {
-	return file_proto_scenario_proto_rawDescGZIP(), []int{9}
+	return file_proto_scenario_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *FileNode) GetPath() string {
@@ -617,7 +693,7 @@ type GetDirectoryStructureResponse struct {
 
 func (x *GetDirectoryStructureResponse) Reset() {
 	*x = GetDirectoryStructureResponse{}
-	mi := &file_proto_scenario_proto_msgTypes[10]
+	mi := &file_proto_scenario_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -629,7 +705,7 @@ func (x *GetDirectoryStructureResponse) String() string {
 func (*GetDirectoryStructureResponse) ProtoMessage() {}
 
 func (x *GetDirectoryStructureResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_scenario_proto_msgTypes[10]
+	mi := &file_proto_scenario_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -642,7 +718,7 @@ func (x *GetDirectoryStructureResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetDirectoryStructureResponse.ProtoReflect.Descriptor instead.
 func (*GetDirectoryStructureResponse) Descriptor() ([]byte, []int) {
-	return file_proto_scenario_proto_rawDescGZIP(), []int{10}
+	return file_proto_scenario_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *GetDirectoryStructureResponse) GetScenarioId() string {
@@ -673,19 +749,309 @@ func (x *GetDirectoryStructureResponse) GetMessage() string {
 	return ""
 }
 
+type ListScenariosRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListScenariosRequest) Reset() {
+	*x = ListScenariosRequest{}
+	mi := &file_proto_scenario_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListScenariosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListScenariosRequest) ProtoMessage() {}
+
+func (x *ListScenariosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_scenario_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListScenariosRequest.ProtoReflect.Descriptor instead.
+func (*ListScenariosRequest) Descriptor() ([]byte, []int) {
+	return file_proto_scenario_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListScenariosRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListScenariosRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ScenarioSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ScenarioId    string                 `protobuf:"bytes,1,opt,name=scenario_id,json=scenarioId,proto3" json:"scenario_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ScenarioType  string                 `protobuf:"bytes,3,opt,name=scenario_type,json=scenarioType,proto3" json:"scenario_type,omitempty"`
+	ContainerId   string                 `protobuf:"bytes,4,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	StopReason    string                 `protobuf:"bytes,6,opt,name=stop_reason,json=stopReason,proto3" json:"stop_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScenarioSummary) Reset() {
+	*x = ScenarioSummary{}
+	mi := &file_proto_scenario_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScenarioSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScenarioSummary) ProtoMessage() {}
+
+func (x *ScenarioSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_scenario_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScenarioSummary.ProtoReflect.Descriptor instead.
+func (*ScenarioSummary) Descriptor() ([]byte, []int) {
+	return file_proto_scenario_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ScenarioSummary) GetScenarioId() string {
+	if x != nil {
+		return x.ScenarioId
+	}
+	return ""
+}
+
+func (x *ScenarioSummary) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ScenarioSummary) GetScenarioType() string {
+	if x != nil {
+		return x.ScenarioType
+	}
+	return ""
+}
+
+func (x *ScenarioSummary) GetContainerId() string {
+	if x != nil {
+		return x.ContainerId
+	}
+	return ""
+}
+
+func (x *ScenarioSummary) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ScenarioSummary) GetStopReason() string {
+	if x != nil {
+		return x.StopReason
+	}
+	return ""
+}
+
+type ListScenariosResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Scenarios     []*ScenarioSummary     `protobuf:"bytes,1,rep,name=scenarios,proto3" json:"scenarios,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListScenariosResponse) Reset() {
+	*x = ListScenariosResponse{}
+	mi := &file_proto_scenario_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListScenariosResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListScenariosResponse) ProtoMessage() {}
+
+func (x *ListScenariosResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_scenario_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListScenariosResponse.ProtoReflect.Descriptor instead.
+func (*ListScenariosResponse) Descriptor() ([]byte, []int) {
+	return file_proto_scenario_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListScenariosResponse) GetScenarios() []*ScenarioSummary {
+	if x != nil {
+		return x.Scenarios
+	}
+	return nil
+}
+
+func (x *ListScenariosResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type WatchUserScenariosRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchUserScenariosRequest) Reset() {
+	*x = WatchUserScenariosRequest{}
+	mi := &file_proto_scenario_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchUserScenariosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchUserScenariosRequest) ProtoMessage() {}
+
+func (x *WatchUserScenariosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_scenario_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchUserScenariosRequest.ProtoReflect.Descriptor instead.
+func (*WatchUserScenariosRequest) Descriptor() ([]byte, []int) {
+	return file_proto_scenario_proto_rawDescGZIP(), []int{15}
+}
+
+type WatchUserScenariosResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Snapshot           bool                   `protobuf:"varint,1,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	Scenarios          []*ScenarioSummary     `protobuf:"bytes,2,rep,name=scenarios,proto3" json:"scenarios,omitempty"`
+	RemovedScenarioIds []string               `protobuf:"bytes,3,rep,name=removed_scenario_ids,json=removedScenarioIds,proto3" json:"removed_scenario_ids,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *WatchUserScenariosResponse) Reset() {
+	*x = WatchUserScenariosResponse{}
+	mi := &file_proto_scenario_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchUserScenariosResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchUserScenariosResponse) ProtoMessage() {}
+
+func (x *WatchUserScenariosResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_scenario_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchUserScenariosResponse.ProtoReflect.Descriptor instead.
+func (*WatchUserScenariosResponse) Descriptor() ([]byte, []int) {
+	return file_proto_scenario_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *WatchUserScenariosResponse) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+func (x *WatchUserScenariosResponse) GetScenarios() []*ScenarioSummary {
+	if x != nil {
+		return x.Scenarios
+	}
+	return nil
+}
+
+func (x *WatchUserScenariosResponse) GetRemovedScenarioIds() []string {
+	if x != nil {
+		return x.RemovedScenarioIds
+	}
+	return nil
+}
+
 var File_proto_scenario_proto protoreflect.FileDescriptor
 
 const file_proto_scenario_proto_rawDesc = "" +
 	"\n" +
-	"\x14proto/scenario.proto\x12\bscenario\"l\n" +
+	"\x14proto/scenario.proto\x12\bscenario\"\x96\x01\n" +
 	"\x14StartScenarioRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12#\n" +
 	"\rscenario_type\x18\x02 \x01(\tR\fscenarioType\x12\x16\n" +
-	"\x06script\x18\x03 \x01(\tR\x06script\"P\n" +
+	"\x06script\x18\x03 \x01(\tR\x06script\x12(\n" +
+	"\x10wait_for_running\x18\x04 \x01(\bR\x0ewaitForRunning\"\xc5\x01\n" +
 	"\x15StartScenarioResponse\x12\x1f\n" +
 	"\vscenario_id\x18\x01 \x01(\tR\n" +
 	"scenarioId\x12\x16\n" +
-	"\x06status\x18\x02 \x01(\tR\x06status\"6\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12!\n" +
+	"\fterminal_url\x18\x03 \x01(\tR\vterminalUrl\x12P\n" +
+	"\x14terminal_credentials\x18\x04 \x01(\v2\x1d.scenario.TerminalCredentialsR\x13terminalCredentials\"M\n" +
+	"\x13TerminalCredentials\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"6\n" +
 	"\x13StopScenarioRequest\x12\x1f\n" +
 	"\vscenario_id\x18\x01 \x01(\tR\n" +
 	"scenarioId\"Q\n" +
@@ -729,13 +1095,36 @@ const file_proto_scenario_proto_rawDesc = "" +
 	"scenarioId\x12\x12\n" +
 	"\x04path\x18\x02 \x01(\tR\x04path\x120\n" +
 	"\tstructure\x18\x03 \x03(\v2\x12.scenario.FileNodeR\tstructure\x12\x18\n" +
-	"\amessage\x18\x04 \x01(\tR\amessage2\xcf\x03\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\"R\n" +
+	"\x14ListScenariosRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\"\xcc\x01\n" +
+	"\x0fScenarioSummary\x12\x1f\n" +
+	"\vscenario_id\x18\x01 \x01(\tR\n" +
+	"scenarioId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12#\n" +
+	"\rscenario_type\x18\x03 \x01(\tR\fscenarioType\x12!\n" +
+	"\fcontainer_id\x18\x04 \x01(\tR\vcontainerId\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x1f\n" +
+	"\vstop_reason\x18\x06 \x01(\tR\n" +
+	"stopReason\"x\n" +
+	"\x15ListScenariosResponse\x127\n" +
+	"\tscenarios\x18\x01 \x03(\v2\x19.scenario.ScenarioSummaryR\tscenarios\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\x1b\n" +
+	"\x19WatchUserScenariosRequest\"\xa3\x01\n" +
+	"\x1aWatchUserScenariosResponse\x12\x1a\n" +
+	"\bsnapshot\x18\x01 \x01(\bR\bsnapshot\x127\n" +
+	"\tscenarios\x18\x02 \x03(\v2\x19.scenario.ScenarioSummaryR\tscenarios\x120\n" +
+	"\x14removed_scenario_ids\x18\x03 \x03(\tR\x12removedScenarioIds2\x84\x05\n" +
 	"\x0fScenarioService\x12P\n" +
 	"\rStartScenario\x12\x1e.scenario.StartScenarioRequest\x1a\x1f.scenario.StartScenarioResponse\x12M\n" +
 	"\fStopScenario\x12\x1d.scenario.StopScenarioRequest\x1a\x1e.scenario.StopScenarioResponse\x12\\\n" +
 	"\x11GetScenarioStatus\x12\".scenario.GetScenarioStatusRequest\x1a#.scenario.GetScenarioStatusResponse\x12S\n" +
 	"\x0eGetTerminalURL\x12\x1f.scenario.GetTerminalURLRequest\x1a .scenario.GetTerminalURLResponse\x12h\n" +
-	"\x15GetDirectoryStructure\x12&.scenario.GetDirectoryStructureRequest\x1a'.scenario.GetDirectoryStructureResponseB\x0eZ\fdevlab/protob\x06proto3"
+	"\x15GetDirectoryStructure\x12&.scenario.GetDirectoryStructureRequest\x1a'.scenario.GetDirectoryStructureResponse\x12P\n" +
+	"\rListScenarios\x12\x1e.scenario.ListScenariosRequest\x1a\x1f.scenario.ListScenariosResponse\x12a\n" +
+	"\x12WatchUserScenarios\x12#.scenario.WatchUserScenariosRequest\x1a$.scenario.WatchUserScenariosResponse0\x01B\x0eZ\fdevlab/protob\x06proto3"
 
 var (
 	file_proto_scenario_proto_rawDescOnce sync.Once
@@ -749,37 +1138,50 @@ func file_proto_scenario_proto_rawDescGZIP() []byte {
 	return file_proto_scenario_proto_rawDescData
 }
 
-var file_proto_scenario_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_proto_scenario_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_proto_scenario_proto_goTypes = []any{
 	(*StartScenarioRequest)(nil),          // 0: scenario.StartScenarioRequest
 	(*StartScenarioResponse)(nil),         // 1: scenario.StartScenarioResponse
-	(*StopScenarioRequest)(nil),           // 2: scenario.StopScenarioRequest
-	(*StopScenarioResponse)(nil),          // 3: scenario.StopScenarioResponse
-	(*GetScenarioStatusRequest)(nil),      // 4: scenario.GetScenarioStatusRequest
-	(*GetScenarioStatusResponse)(nil),     // 5: scenario.GetScenarioStatusResponse
-	(*GetTerminalURLRequest)(nil),         // 6: scenario.GetTerminalURLRequest
-	(*GetTerminalURLResponse)(nil),        // 7: scenario.GetTerminalURLResponse
-	(*GetDirectoryStructureRequest)(nil),  // 8: scenario.GetDirectoryStructureRequest
-	(*FileNode)(nil),                      // 9: scenario.FileNode
-	(*GetDirectoryStructureResponse)(nil), // 10: scenario.GetDirectoryStructureResponse
+	(*TerminalCredentials)(nil),           // 2: scenario.TerminalCredentials
+	(*StopScenarioRequest)(nil),           // 3: scenario.StopScenarioRequest
+	(*StopScenarioResponse)(nil),          // 4: scenario.StopScenarioResponse
+	(*GetScenarioStatusRequest)(nil),      // 5: scenario.GetScenarioStatusRequest
+	(*GetScenarioStatusResponse)(nil),     // 6: scenario.GetScenarioStatusResponse
+	(*GetTerminalURLRequest)(nil),         // 7: scenario.GetTerminalURLRequest
+	(*GetTerminalURLResponse)(nil),        // 8: scenario.GetTerminalURLResponse
+	(*GetDirectoryStructureRequest)(nil),  // 9: scenario.GetDirectoryStructureRequest
+	(*FileNode)(nil),                      // 10: scenario.FileNode
+	(*GetDirectoryStructureResponse)(nil), // 11: scenario.GetDirectoryStructureResponse
+	(*ListScenariosRequest)(nil),          // 12: scenario.ListScenariosRequest
+	(*ScenarioSummary)(nil),               // 13: scenario.ScenarioSummary
+	(*ListScenariosResponse)(nil),         // 14: scenario.ListScenariosResponse
+	(*WatchUserScenariosRequest)(nil),     // 15: scenario.WatchUserScenariosRequest
+	(*WatchUserScenariosResponse)(nil),    // 16: scenario.WatchUserScenariosResponse
 }
 var file_proto_scenario_proto_depIdxs = []int32{
-	9,  // 0: scenario.GetDirectoryStructureResponse.structure:type_name -> scenario.FileNode
-	0,  // 1: scenario.ScenarioService.StartScenario:input_type -> scenario.StartScenarioRequest
-	2,  // 2: scenario.ScenarioService.StopScenario:input_type -> scenario.StopScenarioRequest
-	4,  // 3: scenario.ScenarioService.GetScenarioStatus:input_type -> scenario.GetScenarioStatusRequest
-	6,  // 4: scenario.ScenarioService.GetTerminalURL:input_type -> scenario.GetTerminalURLRequest
-	8,  // 5: scenario.ScenarioService.GetDirectoryStructure:input_type -> scenario.GetDirectoryStructureRequest
-	1,  // 6: scenario.ScenarioService.StartScenario:output_type -> scenario.StartScenarioResponse
-	3,  // 7: scenario.ScenarioService.StopScenario:output_type -> scenario.StopScenarioResponse
-	5,  // 8: scenario.ScenarioService.GetScenarioStatus:output_type -> scenario.GetScenarioStatusResponse
-	7,  // 9: scenario.ScenarioService.GetTerminalURL:output_type -> scenario.GetTerminalURLResponse
-	10, // 10: scenario.ScenarioService.GetDirectoryStructure:output_type -> scenario.GetDirectoryStructureResponse
-	6,  // [6:11] is the sub-list for method output_type
-	1,  // [1:6] is the sub-list for method input_type
-	1,  // [1:1] is the sub-list for extension type_name
-	1,  // [1:1] is the sub-list for extension extendee
-	0,  // [0:1] is the sub-list for field type_name
+	2,  // 0: scenario.StartScenarioResponse.terminal_credentials:type_name -> scenario.TerminalCredentials
+	10, // 1: scenario.GetDirectoryStructureResponse.structure:type_name -> scenario.FileNode
+	13, // 2: scenario.ListScenariosResponse.scenarios:type_name -> scenario.ScenarioSummary
+	13, // 3: scenario.WatchUserScenariosResponse.scenarios:type_name -> scenario.ScenarioSummary
+	0,  // 4: scenario.ScenarioService.StartScenario:input_type -> scenario.StartScenarioRequest
+	3,  // 5: scenario.ScenarioService.StopScenario:input_type -> scenario.StopScenarioRequest
+	5,  // 6: scenario.ScenarioService.GetScenarioStatus:input_type -> scenario.GetScenarioStatusRequest
+	7,  // 7: scenario.ScenarioService.GetTerminalURL:input_type -> scenario.GetTerminalURLRequest
+	9,  // 8: scenario.ScenarioService.GetDirectoryStructure:input_type -> scenario.GetDirectoryStructureRequest
+	12, // 9: scenario.ScenarioService.ListScenarios:input_type -> scenario.ListScenariosRequest
+	15, // 10: scenario.ScenarioService.WatchUserScenarios:input_type -> scenario.WatchUserScenariosRequest
+	1,  // 11: scenario.ScenarioService.StartScenario:output_type -> scenario.StartScenarioResponse
+	4,  // 12: scenario.ScenarioService.StopScenario:output_type -> scenario.StopScenarioResponse
+	6,  // 13: scenario.ScenarioService.GetScenarioStatus:output_type -> scenario.GetScenarioStatusResponse
+	8,  // 14: scenario.ScenarioService.GetTerminalURL:output_type -> scenario.GetTerminalURLResponse
+	11, // 15: scenario.ScenarioService.GetDirectoryStructure:output_type -> scenario.GetDirectoryStructureResponse
+	14, // 16: scenario.ScenarioService.ListScenarios:output_type -> scenario.ListScenariosResponse
+	16, // 17: scenario.ScenarioService.WatchUserScenarios:output_type -> scenario.WatchUserScenariosResponse
+	11, // [11:18] is the sub-list for method output_type
+	4,  // [4:11] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_proto_scenario_proto_init() }
@@ -793,7 +1195,7 @@ func file_proto_scenario_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_scenario_proto_rawDesc), len(file_proto_scenario_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   11,
+			NumMessages:   17,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -24,6 +24,8 @@ const (
 	ScenarioService_GetScenarioStatus_FullMethodName     = "/scenario.ScenarioService/GetScenarioStatus"
 	ScenarioService_GetTerminalURL_FullMethodName        = "/scenario.ScenarioService/GetTerminalURL"
 	ScenarioService_GetDirectoryStructure_FullMethodName = "/scenario.ScenarioService/GetDirectoryStructure"
+	ScenarioService_ListScenarios_FullMethodName         = "/scenario.ScenarioService/ListScenarios"
+	ScenarioService_WatchUserScenarios_FullMethodName    = "/scenario.ScenarioService/WatchUserScenarios"
 )
 
 // ScenarioServiceClient is the client API for ScenarioService service.
@@ -35,6 +37,8 @@ type ScenarioServiceClient interface {
 	GetScenarioStatus(ctx context.Context, in *GetScenarioStatusRequest, opts ...grpc.CallOption) (*GetScenarioStatusResponse, error)
 	GetTerminalURL(ctx context.Context, in *GetTerminalURLRequest, opts ...grpc.CallOption) (*GetTerminalURLResponse, error)
 	GetDirectoryStructure(ctx context.Context, in *GetDirectoryStructureRequest, opts ...grpc.CallOption) (*GetDirectoryStructureResponse, error)
+	ListScenarios(ctx context.Context, in *ListScenariosRequest, opts ...grpc.CallOption) (*ListScenariosResponse, error)
+	WatchUserScenarios(ctx context.Context, in *WatchUserScenariosRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchUserScenariosResponse], error)
 }
 
 type scenarioServiceClient struct {
@@ -95,6 +99,35 @@ func (c *scenarioServiceClient) GetDirectoryStructure(ctx context.Context, in *G
 	return out, nil
 }
 
+func (c *scenarioServiceClient) ListScenarios(ctx context.Context, in *ListScenariosRequest, opts ...grpc.CallOption) (*ListScenariosResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListScenariosResponse)
+	err := c.cc.Invoke(ctx, ScenarioService_ListScenarios_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *scenarioServiceClient) WatchUserScenarios(ctx context.Context, in *WatchUserScenariosRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchUserScenariosResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ScenarioService_ServiceDesc.Streams[0], ScenarioService_WatchUserScenarios_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchUserScenariosRequest, WatchUserScenariosResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ScenarioService_WatchUserScenariosClient = grpc.ServerStreamingClient[WatchUserScenariosResponse]
+
 // ScenarioServiceServer is the server API for ScenarioService service.
 // All implementations must embed UnimplementedScenarioServiceServer
 // for forward compatibility.
@@ -104,6 +137,8 @@ type ScenarioServiceServer interface {
 	GetScenarioStatus(context.Context, *GetScenarioStatusRequest) (*GetScenarioStatusResponse, error)
 	GetTerminalURL(context.Context, *GetTerminalURLRequest) (*GetTerminalURLResponse, error)
 	GetDirectoryStructure(context.Context, *GetDirectoryStructureRequest) (*GetDirectoryStructureResponse, error)
+	ListScenarios(context.Context, *ListScenariosRequest) (*ListScenariosResponse, error)
+	WatchUserScenarios(*WatchUserScenariosRequest, grpc.ServerStreamingServer[WatchUserScenariosResponse]) error
 	mustEmbedUnimplementedScenarioServiceServer()
 }
 
@@ -129,6 +164,12 @@ func (UnimplementedScenarioServiceServer) GetTerminalURL(context.Context, *GetTe
 func (UnimplementedScenarioServiceServer) GetDirectoryStructure(context.Context, *GetDirectoryStructureRequest) (*GetDirectoryStructureResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetDirectoryStructure not implemented")
 }
+func (UnimplementedScenarioServiceServer) ListScenarios(context.Context, *ListScenariosRequest) (*ListScenariosResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListScenarios not implemented")
+}
+func (UnimplementedScenarioServiceServer) WatchUserScenarios(*WatchUserScenariosRequest, grpc.ServerStreamingServer[WatchUserScenariosResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchUserScenarios not implemented")
+}
 func (UnimplementedScenarioServiceServer) mustEmbedUnimplementedScenarioServiceServer() {}
 func (UnimplementedScenarioServiceServer) testEmbeddedByValue()                         {}
 
@@ -240,6 +281,35 @@ func _ScenarioService_GetDirectoryStructure_Handler(srv interface{}, ctx context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ScenarioService_ListScenarios_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListScenariosRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScenarioServiceServer).ListScenarios(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ScenarioService_ListScenarios_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScenarioServiceServer).ListScenarios(ctx, req.(*ListScenariosRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ScenarioService_WatchUserScenarios_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchUserScenariosRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ScenarioServiceServer).WatchUserScenarios(m, &grpc.GenericServerStream[WatchUserScenariosRequest, WatchUserScenariosResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ScenarioService_WatchUserScenariosServer = grpc.ServerStreamingServer[WatchUserScenariosResponse]
+
 // ScenarioService_ServiceDesc is the grpc.ServiceDesc for ScenarioService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -267,7 +337,17 @@ var ScenarioService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetDirectoryStructure",
 			Handler:    _ScenarioService_GetDirectoryStructure_Handler,
 		},
+		{
+			MethodName: "ListScenarios",
+			Handler:    _ScenarioService_ListScenarios_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchUserScenarios",
+			Handler:       _ScenarioService_WatchUserScenarios_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/scenario.proto",
 }
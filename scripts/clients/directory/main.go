@@ -18,11 +18,11 @@ type DirectoryStructureResponse struct {
 type FileNode struct {
 	Path     string   `json:"path"`
 	Type     string   `json:"type"`
-	IsRoot   bool     `json:"isRoot"`
+	IsRoot   bool     `json:"is_root"`
 	Children []string `json:"children,omitempty"`
 	Content  string   `json:"content,omitempty"`
-	IsOpen   bool     `json:"isOpen"`
-	IsSaved  bool     `json:"isSaved"`
+	IsOpen   bool     `json:"is_open"`
+	IsSaved  bool     `json:"is_saved"`
 }
 
 func main() {
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	pb "devlab/proto"
 )
 
@@ -19,8 +21,10 @@ func main() {
 	client := pb.NewScenarioServiceClient(conn)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	// The server requires a JWT; generate one with scripts/generate_token.go.
+	// The scenario is owned by the token's user.
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+os.Getenv("DEVLAB_TOKEN"))
 	resp, err := client.StartScenario(ctx, &pb.StartScenarioRequest{
-		UserId:       "user1",
 		ScenarioType: "go",
 		Script:       "echo Hello from gRPC!",
 	})
@@ -12,6 +12,7 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 )
 
 // REST client for Status API
@@ -45,6 +46,7 @@ func getScenarioStatusGRPC(scenarioID string) error {
 	client := pb.NewScenarioServiceClient(conn)
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+os.Getenv("DEVLAB_TOKEN"))
 
 	resp, err := client.GetScenarioStatus(ctx, &pb.GetScenarioStatusRequest{
 		ScenarioId: scenarioID,